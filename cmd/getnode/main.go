@@ -7,7 +7,7 @@ import (
 	"os"
 
 	"github.com/gopost/integration/internal/config"
-	"github.com/gopost/integration/internal/drupal"
+	"github.com/gopost/integration/pkg/drupal"
 	"github.com/gopost/integration/internal/logger"
 )
 
@@ -41,11 +41,11 @@ func main() {
 		cfg.Drupal.AuthMethod,
 		cfg.Drupal.SkipTLSVerify,
 		appLogger,
+		drupal.WithUserAgent(cfg.UserAgent),
+		drupal.WithProxy(cfg.Drupal.Proxy),
 	)
 	if err != nil {
-		appLogger.Error("Failed to create Drupal client", logger.Error(err))
-		_ = appLogger.Sync()
-		os.Exit(1)
+		appLogger.WithError(err).Fatal("Failed to create Drupal client")
 	}
 
 	// List nodes first to get a valid UUID
@@ -54,21 +54,16 @@ func main() {
 	const defaultLimit = 5
 	listResult, err := client.ListNodes(context.Background(), defaultLimit)
 	if err != nil {
-		appLogger.Error("Failed to list nodes", logger.Error(err))
-		_ = appLogger.Sync()
-		os.Exit(1)
-	}
-
-	// Pretty print the list
-	jsonBytes, err := json.MarshalIndent(listResult, "", "  ")
-	if err != nil {
-		appLogger.Error("Failed to marshal JSON", logger.Error(err))
-		_ = appLogger.Sync()
-		os.Exit(1)
+		appLogger.WithError(err).Fatal("Failed to list nodes")
 	}
 
 	fmt.Println("=== Node List ===")
-	fmt.Println(string(jsonBytes))
+	for _, node := range listResult.Data {
+		fmt.Printf("%s  %s\n", node.ID, node.Title())
+	}
+	if next := listResult.Next(); next != "" {
+		fmt.Printf("Next page: %s\n", next)
+	}
 
 	// If a node ID was provided, try to fetch it
 	if len(os.Args) > 1 {
@@ -79,20 +74,15 @@ func main() {
 
 		nodeResult, nodeErr := client.GetNode(context.Background(), nodeID)
 		if nodeErr != nil {
-			appLogger.Error("Failed to fetch node",
+			appLogger.WithError(nodeErr).Fatal("Failed to fetch node",
 				logger.String("node_id", nodeID),
-				logger.Error(nodeErr),
 			)
-			_ = appLogger.Sync()
-			os.Exit(1)
 		}
 
 		// Pretty print JSON
 		nodeJSON, marshalErr := json.MarshalIndent(nodeResult, "", "  ")
 		if marshalErr != nil {
-			appLogger.Error("Failed to marshal JSON", logger.Error(marshalErr))
-			_ = appLogger.Sync()
-			os.Exit(1)
+			appLogger.WithError(marshalErr).Fatal("Failed to marshal JSON")
 		}
 
 		fmt.Println("\n=== Node Details ===")
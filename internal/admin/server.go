@@ -0,0 +1,367 @@
+// Package admin serves gopost's operational HTTP endpoints, such as /status and /metrics.
+package admin
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gopost/integration/internal/buildinfo"
+	"github.com/gopost/integration/internal/dedup"
+	"github.com/gopost/integration/internal/integration"
+	"github.com/gopost/integration/internal/logger"
+	"github.com/gopost/integration/internal/metrics"
+)
+
+// shutdownTimeout bounds how long in-flight admin requests may run after shutdown starts.
+const shutdownTimeout = 5 * time.Second
+
+// StatusSource provides the service status reported by /status. *integration.Service satisfies it.
+type StatusSource interface {
+	Status() integration.Status
+}
+
+// PauseControl pauses and resumes posting. *integration.Service and *integration.Group
+// satisfy it. An empty city means all cities.
+type PauseControl interface {
+	Pause(ctx context.Context, city, reason string) error
+	Resume(ctx context.Context, city string) error
+}
+
+// SyncTrigger starts a sync run ahead of schedule. *integration.Service and
+// *integration.Group satisfy it.
+type SyncTrigger interface {
+	TriggerSync(runToken string) bool
+}
+
+// DedupLookup reports an article's dedup state. *integration.Service and
+// *integration.Group satisfy it.
+type DedupLookup interface {
+	DedupLookup(ctx context.Context, articleID string) (dedup.Entry, error)
+}
+
+// SuppressionControl adds articles to and removes them from the suppression list.
+// *integration.Service and *integration.Group satisfy it.
+type SuppressionControl interface {
+	Suppress(ctx context.Context, articleID string) error
+	Unsuppress(ctx context.Context, articleID string) error
+}
+
+// ModerationReceiver applies editorial decisions on posted nodes. *integration.Service and
+// *integration.Group satisfy it.
+type ModerationReceiver interface {
+	RecordModeration(ctx context.Context, event integration.ModerationEvent) error
+}
+
+// Option configures optional admin endpoints.
+type Option func(*Server)
+
+// WithPauseControl enables POST /pause and POST /resume.
+func WithPauseControl(control PauseControl) Option {
+	return func(s *Server) {
+		s.pauses = control
+	}
+}
+
+// WithSyncTrigger enables POST /sync.
+func WithSyncTrigger(trigger SyncTrigger) Option {
+	return func(s *Server) {
+		s.trigger = trigger
+	}
+}
+
+// WithDedupLookup enables GET /dedup.
+func WithDedupLookup(lookup DedupLookup) Option {
+	return func(s *Server) {
+		s.dedup = lookup
+	}
+}
+
+// WithSuppressionControl enables POST and DELETE /suppress. When token is set, requests
+// must send it as "Authorization: Bearer <token>", so a Drupal webhook can be pointed at
+// the endpoint.
+func WithSuppressionControl(control SuppressionControl, token string) Option {
+	return func(s *Server) {
+		s.suppressions = control
+		s.suppressToken = token
+	}
+}
+
+// WithModerationReceiver enables POST /moderation, called by a Drupal webhook. When token
+// is set, requests must send it as "Authorization: Bearer <token>".
+func WithModerationReceiver(receiver ModerationReceiver, token string) Option {
+	return func(s *Server) {
+		s.moderation = receiver
+		s.moderationToken = token
+	}
+}
+
+// StatusResponse is the JSON document served by /status.
+type StatusResponse struct {
+	Build     buildinfo.Info     `json:"build"`
+	StartedAt time.Time          `json:"started_at"`
+	Uptime    string             `json:"uptime"`
+	Service   integration.Status `json:"service"`
+}
+
+// Server is the admin HTTP server.
+type Server struct {
+	addr      string
+	source    StatusSource
+	build     buildinfo.Info
+	startedAt time.Time
+	pauses    PauseControl // nil disables the pause endpoints
+	trigger   SyncTrigger  // nil disables /sync
+	dedup     DedupLookup  // nil disables /dedup
+	logger    logger.Logger
+
+	suppressions  SuppressionControl // nil disables /suppress
+	suppressToken string             // Bearer token required by /suppress, if set
+
+	moderation      ModerationReceiver // nil disables /moderation
+	moderationToken string             // Bearer token required by /moderation, if set
+}
+
+// NewServer returns an admin server that will listen on addr (e.g. ":8080").
+func NewServer(addr string, source StatusSource, log logger.Logger, opts ...Option) *Server {
+	s := &Server{
+		addr:      addr,
+		source:    source,
+		build:     buildinfo.Get(),
+		startedAt: time.Now(),
+		logger:    log,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Handler returns the admin routes.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /status", s.handleStatus)
+	mux.HandleFunc("GET /stats", s.handleStats)
+	mux.HandleFunc("GET /metrics", s.handleMetrics)
+	if s.pauses != nil {
+		mux.HandleFunc("POST /pause", s.handlePause)
+		mux.HandleFunc("POST /resume", s.handleResume)
+	}
+	if s.trigger != nil {
+		mux.HandleFunc("POST /sync", s.handleSync)
+	}
+	if s.dedup != nil {
+		mux.HandleFunc("GET /dedup", s.handleDedup)
+	}
+	if s.suppressions != nil {
+		mux.HandleFunc("POST /suppress", s.handleSuppress)
+		mux.HandleFunc("DELETE /suppress", s.handleSuppress)
+	}
+	if s.moderation != nil {
+		mux.HandleFunc("POST /moderation", s.handleModeration)
+	}
+	return mux
+}
+
+// Run serves until ctx is done, then shuts down gracefully.
+func (s *Server) Run(ctx context.Context) error {
+	srv := &http.Server{
+		Addr:              s.addr,
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- srv.ListenAndServe()
+	}()
+	s.logger.Info("Admin server listening",
+		logger.String("addr", s.addr),
+	)
+
+	select {
+	case err := <-errCh:
+		return fmt.Errorf("admin server: %w", err)
+	case <-ctx.Done():
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("admin server shutdown: %w", err)
+	}
+	if err := <-errCh; err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("admin server: %w", err)
+	}
+	return nil
+}
+
+func (s *Server) handleStatus(w http.ResponseWriter, _ *http.Request) {
+	resp := StatusResponse{
+		Build:     s.build,
+		StartedAt: s.startedAt,
+		Uptime:    time.Since(s.startedAt).Round(time.Second).String(),
+		Service:   s.source.Status(),
+	}
+	s.writeJSON(w, "status", resp)
+}
+
+// handleStats serves the run totals since startup, per tenant in multi-tenant mode.
+func (s *Server) handleStats(w http.ResponseWriter, _ *http.Request) {
+	s.writeJSON(w, "stats", s.source.Status().Stats)
+}
+
+// handleMetrics serves the health score, its components, each city's sync lag, the last
+// run's counts, and moderation decisions as Prometheus metrics, labeled by tenant in
+// multi-tenant mode.
+func (s *Server) handleMetrics(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	if err := metrics.WriteText(w, metrics.Collect(s.source.Status())); err != nil {
+		s.logger.Warn("Failed to write metrics response",
+			logger.Error(err),
+		)
+	}
+}
+
+// handleSync starts a sync run ahead of schedule: POST /sync[?run_token=<token>]. It
+// responds 202 when a run was queued and 409 when one is already pending.
+func (s *Server) handleSync(w http.ResponseWriter, r *http.Request) {
+	if !s.trigger.TriggerSync(r.URL.Query().Get("run_token")) {
+		http.Error(w, "sync already pending", http.StatusConflict)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// handleDedup reports an article's dedup state: GET /dedup?id=<article id>.
+func (s *Server) handleDedup(w http.ResponseWriter, r *http.Request) {
+	articleID := r.URL.Query().Get("id")
+	if articleID == "" {
+		http.Error(w, "id is required", http.StatusBadRequest)
+		return
+	}
+	entry, err := s.dedup.DedupLookup(r.Context(), articleID)
+	if err != nil {
+		s.logger.Warn("Admin dedup lookup failed",
+			logger.String("article_id", articleID),
+			logger.Error(err),
+		)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	s.writeJSON(w, "dedup", entry)
+}
+
+// handleSuppress suppresses an article, POST /suppress?id=<article id>, or lifts its
+// suppression, DELETE /suppress?id=<article id>. The article ID is the node's
+// field_external_id.
+func (s *Server) handleSuppress(w http.ResponseWriter, r *http.Request) {
+	if !authorized(w, r, s.suppressToken) {
+		return
+	}
+	articleID := r.URL.Query().Get("id")
+	if articleID == "" {
+		http.Error(w, "id is required", http.StatusBadRequest)
+		return
+	}
+
+	action, change := "suppress", s.suppressions.Suppress
+	if r.Method == http.MethodDelete {
+		action, change = "unsuppress", s.suppressions.Unsuppress
+	}
+	if err := change(r.Context(), articleID); err != nil {
+		s.logger.Warn("Admin suppression request failed",
+			logger.String("action", action),
+			logger.String("article_id", articleID),
+			logger.Error(err),
+		)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleModeration applies an editorial decision sent by a Drupal webhook as a JSON
+// integration.ModerationEvent: POST /moderation.
+func (s *Server) handleModeration(w http.ResponseWriter, r *http.Request) {
+	if !authorized(w, r, s.moderationToken) {
+		return
+	}
+	var event integration.ModerationEvent
+	if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+		http.Error(w, "invalid moderation event: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := s.moderation.RecordModeration(r.Context(), event); err != nil {
+		if errors.Is(err, integration.ErrInvalidModeration) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		s.logger.Warn("Admin moderation request failed",
+			logger.String("article_id", event.ArticleID),
+			logger.String("decision", event.Decision),
+			logger.Error(err),
+		)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// authorized reports whether the request sends token as "Authorization: Bearer <token>",
+// answering 401 when it does not. An empty token accepts every request.
+func authorized(w http.ResponseWriter, r *http.Request, token string) bool {
+	if token == "" {
+		return true
+	}
+	sent, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || subtle.ConstantTimeCompare([]byte(sent), []byte(token)) != 1 {
+		http.Error(w, "invalid token", http.StatusUnauthorized)
+		return false
+	}
+	return true
+}
+
+func (s *Server) writeJSON(w http.ResponseWriter, name string, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		s.logger.Warn("Failed to write "+name+" response",
+			logger.Error(err),
+		)
+	}
+}
+
+// handlePause pauses posting: POST /pause?city=<name>&reason=<text>. Both parameters are optional.
+func (s *Server) handlePause(w http.ResponseWriter, r *http.Request) {
+	city := r.URL.Query().Get("city")
+	if err := s.pauses.Pause(r.Context(), city, r.URL.Query().Get("reason")); err != nil {
+		s.writeControlError(w, "pause", city, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleResume resumes posting: POST /resume?city=<name>.
+func (s *Server) handleResume(w http.ResponseWriter, r *http.Request) {
+	city := r.URL.Query().Get("city")
+	if err := s.pauses.Resume(r.Context(), city); err != nil {
+		s.writeControlError(w, "resume", city, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) writeControlError(w http.ResponseWriter, action, city string, err error) {
+	s.logger.Warn("Admin control request failed",
+		logger.String("action", action),
+		logger.String("city", city),
+		logger.Error(err),
+	)
+	http.Error(w, err.Error(), http.StatusInternalServerError)
+}
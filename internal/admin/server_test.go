@@ -0,0 +1,298 @@
+package admin_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gopost/integration/internal/admin"
+	"github.com/gopost/integration/internal/dedup"
+	"github.com/gopost/integration/internal/integration"
+	"github.com/gopost/integration/internal/logger"
+)
+
+type fakeStatusSource struct {
+	status integration.Status
+}
+
+func (f fakeStatusSource) Status() integration.Status {
+	return f.status
+}
+
+func TestHandler_Status(t *testing.T) {
+	source := fakeStatusSource{status: integration.Status{CityCount: 3, OutboxEnabled: true}}
+	server := admin.NewServer(":0", source, logger.NewNopLogger())
+
+	rec := httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/status", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status code = %d, want 200", rec.Code)
+	}
+	var resp admin.StatusResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if resp.Build.Version == "" || resp.Build.GoVersion == "" {
+		t.Errorf("build info = %+v, want version and go_version", resp.Build)
+	}
+	if resp.Service.CityCount != 3 || !resp.Service.OutboxEnabled {
+		t.Errorf("service status = %+v, want city_count=3 outbox_enabled=true", resp.Service)
+	}
+}
+
+func TestHandler_Metrics(t *testing.T) {
+	syncLag := 2 * time.Hour
+	source := fakeStatusSource{status: integration.Status{Tenants: map[string]integration.Status{
+		"north": {
+			Health: integration.Health{
+				Score:      0.5,
+				ErrorRatio: 0.25,
+				Backends:   integration.BackendHealth{Elasticsearch: true, Drupal: true},
+				Lag:        90 * time.Second,
+			},
+			LastReport: integration.RunReport{Cities: []integration.CityReport{
+				{City: "sudbury_com", SyncLag: &syncLag},
+				{City: "timmins_com"},
+			}},
+		},
+	}}}
+	server := admin.NewServer(":0", source, logger.NewNopLogger())
+
+	rec := httptest.NewRecorder()
+	server.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status code = %d, want 200", rec.Code)
+	}
+	body := rec.Body.String()
+	for _, want := range []string{
+		"# TYPE gopost_health_score gauge\n",
+		`gopost_health_score{tenant="north"} 0.5` + "\n",
+		`gopost_health_error_ratio{tenant="north"} 0.25` + "\n",
+		`gopost_health_lag_seconds{tenant="north"} 90` + "\n",
+		`gopost_backend_up{tenant="north",backend="drupal"} 1` + "\n",
+		`gopost_backend_up{tenant="north",backend="redis"} 0` + "\n",
+		`gopost_sync_lag_seconds{tenant="north",city="sudbury_com"} 7200` + "\n",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("metrics missing %q:\n%s", want, body)
+		}
+	}
+	if strings.Contains(body, "timmins_com") {
+		t.Errorf("metrics list a sync lag for a city without one:\n%s", body)
+	}
+}
+
+type fakePauseControl struct {
+	paused map[string]string
+}
+
+func (f *fakePauseControl) Pause(_ context.Context, city, reason string) error {
+	f.paused[city] = reason
+	return nil
+}
+
+func (f *fakePauseControl) Resume(_ context.Context, city string) error {
+	delete(f.paused, city)
+	return nil
+}
+
+func TestHandler_PauseAndResume(t *testing.T) {
+	control := &fakePauseControl{paused: make(map[string]string)}
+	server := admin.NewServer(":0", fakeStatusSource{}, logger.NewNopLogger(), admin.WithPauseControl(control))
+	handler := server.Handler()
+
+	serve := func(method, target string) int {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(method, target, nil))
+		return rec.Code
+	}
+
+	if code := serve(http.MethodPost, "/pause?city=sudbury_com&reason=bad+feed"); code != http.StatusNoContent {
+		t.Fatalf("POST /pause status = %d, want 204", code)
+	}
+	if control.paused["sudbury_com"] != "bad feed" {
+		t.Errorf("paused = %v, want sudbury_com with reason", control.paused)
+	}
+	if code := serve(http.MethodPost, "/resume?city=sudbury_com"); code != http.StatusNoContent {
+		t.Fatalf("POST /resume status = %d, want 204", code)
+	}
+	if len(control.paused) != 0 {
+		t.Errorf("paused = %v after resume, want none", control.paused)
+	}
+	if code := serve(http.MethodGet, "/pause"); code != http.StatusMethodNotAllowed {
+		t.Errorf("GET /pause status = %d, want 405", code)
+	}
+
+	withoutControl := admin.NewServer(":0", fakeStatusSource{}, logger.NewNopLogger()).Handler()
+	rec := httptest.NewRecorder()
+	withoutControl.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/pause", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("POST /pause without control status = %d, want 404", rec.Code)
+	}
+}
+
+type fakeControl struct {
+	triggered bool
+	runToken  string
+}
+
+func (f *fakeControl) TriggerSync(runToken string) bool {
+	already := f.triggered
+	f.triggered = true
+	if !already {
+		f.runToken = runToken
+	}
+	return !already
+}
+
+func (f *fakeControl) DedupLookup(_ context.Context, articleID string) (dedup.Entry, error) {
+	if articleID == "posted-1" {
+		return dedup.Entry{State: dedup.StatePosted}, nil
+	}
+	return dedup.Entry{State: dedup.StateNone}, nil
+}
+
+func TestHandler_SyncAndDedup(t *testing.T) {
+	control := &fakeControl{}
+	handler := admin.NewServer(":0", fakeStatusSource{}, logger.NewNopLogger(),
+		admin.WithSyncTrigger(control),
+		admin.WithDedupLookup(control),
+	).Handler()
+
+	serve := func(method, target string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(method, target, nil))
+		return rec
+	}
+
+	if code := serve(http.MethodPost, "/sync?run_token=nightly-42").Code; code != http.StatusAccepted {
+		t.Errorf("first POST /sync status = %d, want 202", code)
+	}
+	if control.runToken != "nightly-42" {
+		t.Errorf("run token = %q, want nightly-42", control.runToken)
+	}
+	if code := serve(http.MethodPost, "/sync").Code; code != http.StatusConflict {
+		t.Errorf("second POST /sync status = %d, want 409", code)
+	}
+
+	rec := serve(http.MethodGet, "/dedup?id=posted-1")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /dedup status = %d, want 200", rec.Code)
+	}
+	var entry dedup.Entry
+	if err := json.NewDecoder(rec.Body).Decode(&entry); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if entry.State != dedup.StatePosted {
+		t.Errorf("state = %q, want %q", entry.State, dedup.StatePosted)
+	}
+	if code := serve(http.MethodGet, "/dedup").Code; code != http.StatusBadRequest {
+		t.Errorf("GET /dedup without id status = %d, want 400", code)
+	}
+}
+
+type fakeSuppressionControl struct {
+	suppressed map[string]bool
+}
+
+func (f *fakeSuppressionControl) Suppress(_ context.Context, articleID string) error {
+	f.suppressed[articleID] = true
+	return nil
+}
+
+func (f *fakeSuppressionControl) Unsuppress(_ context.Context, articleID string) error {
+	delete(f.suppressed, articleID)
+	return nil
+}
+
+func TestHandler_Suppress(t *testing.T) {
+	control := &fakeSuppressionControl{suppressed: make(map[string]bool)}
+	handler := admin.NewServer(":0", fakeStatusSource{}, logger.NewNopLogger(),
+		admin.WithSuppressionControl(control, "webhook-secret"),
+	).Handler()
+
+	serve := func(method, target, token string) int {
+		req := httptest.NewRequest(method, target, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	if code := serve(http.MethodPost, "/suppress?id=es-1", "wrong"); code != http.StatusUnauthorized {
+		t.Errorf("POST /suppress with a wrong token status = %d, want 401", code)
+	}
+	if code := serve(http.MethodPost, "/suppress?id=es-1", ""); code != http.StatusUnauthorized {
+		t.Errorf("POST /suppress without a token status = %d, want 401", code)
+	}
+	if len(control.suppressed) != 0 {
+		t.Fatalf("suppressed = %v after rejected requests, want none", control.suppressed)
+	}
+	if code := serve(http.MethodPost, "/suppress?id=es-1", "webhook-secret"); code != http.StatusNoContent {
+		t.Fatalf("POST /suppress status = %d, want 204", code)
+	}
+	if !control.suppressed["es-1"] {
+		t.Errorf("suppressed = %v, want es-1", control.suppressed)
+	}
+	if code := serve(http.MethodDelete, "/suppress?id=es-1", "webhook-secret"); code != http.StatusNoContent {
+		t.Fatalf("DELETE /suppress status = %d, want 204", code)
+	}
+	if len(control.suppressed) != 0 {
+		t.Errorf("suppressed = %v after DELETE, want none", control.suppressed)
+	}
+	if code := serve(http.MethodPost, "/suppress", "webhook-secret"); code != http.StatusBadRequest {
+		t.Errorf("POST /suppress without id status = %d, want 400", code)
+	}
+}
+
+type fakeModerationReceiver struct {
+	events []integration.ModerationEvent
+}
+
+func (f *fakeModerationReceiver) RecordModeration(_ context.Context, event integration.ModerationEvent) error {
+	if err := event.Validate(); err != nil {
+		return err
+	}
+	f.events = append(f.events, event)
+	return nil
+}
+
+func TestHandler_Moderation(t *testing.T) {
+	receiver := &fakeModerationReceiver{}
+	handler := admin.NewServer(":0", fakeStatusSource{}, logger.NewNopLogger(),
+		admin.WithModerationReceiver(receiver, "webhook-secret"),
+	).Handler()
+
+	serve := func(body, token string) int {
+		req := httptest.NewRequest(http.MethodPost, "/moderation", strings.NewReader(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	if code := serve(`{"article_id":"es-1","decision":"published"}`, "wrong"); code != http.StatusUnauthorized {
+		t.Errorf("POST /moderation with a wrong token status = %d, want 401", code)
+	}
+	if code := serve(`{"article_id":"es-1","decision":"published","city":"sudbury_com"}`, "webhook-secret"); code != http.StatusNoContent {
+		t.Fatalf("POST /moderation status = %d, want 204", code)
+	}
+	if len(receiver.events) != 1 || receiver.events[0].Decision != "published" || receiver.events[0].City != "sudbury_com" {
+		t.Errorf("events = %+v, want the published decision", receiver.events)
+	}
+	for _, body := range []string{`{"article_id":"es-1","decision":"liked"}`, `{"decision":"rejected"}`, `not json`} {
+		if code := serve(body, "webhook-secret"); code != http.StatusBadRequest {
+			t.Errorf("POST /moderation %s status = %d, want 400", body, code)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+// Package buildinfo reports the version of the running gopost binary.
+//
+// Release builds stamp version, commit, and date with -ldflags, e.g.
+//
+//	go build -ldflags "-X github.com/gopost/integration/internal/buildinfo.version=v1.2.0"
+//
+// Anything not stamped falls back to the module and VCS data the Go toolchain embeds.
+package buildinfo
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+)
+
+// Set at build time via -ldflags
+var (
+	version = ""
+	commit  = ""
+	date    = ""
+)
+
+// Info describes the running binary.
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	Date      string `json:"date"`
+	GoVersion string `json:"go_version"`
+}
+
+// Get returns the build information, preferring ldflags values over embedded VCS data.
+func Get() Info {
+	info := Info{
+		Version:   version,
+		Commit:    commit,
+		Date:      date,
+		GoVersion: runtime.Version(),
+	}
+
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		if info.Version == "" && bi.Main.Version != "" && bi.Main.Version != "(devel)" {
+			info.Version = bi.Main.Version
+		}
+		for _, setting := range bi.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				if info.Commit == "" {
+					info.Commit = setting.Value
+				}
+			case "vcs.time":
+				if info.Date == "" {
+					info.Date = setting.Value
+				}
+			}
+		}
+	}
+
+	if info.Version == "" {
+		info.Version = "dev"
+	}
+	if info.Commit == "" {
+		info.Commit = "unknown"
+	}
+	if info.Date == "" {
+		info.Date = "unknown"
+	}
+	return info
+}
+
+// String formats the information for `gopost version`.
+func (i Info) String() string {
+	return fmt.Sprintf("gopost %s\n  commit:     %s\n  built:      %s\n  go version: %s", i.Version, i.Commit, i.Date, i.GoVersion)
+}
@@ -0,0 +1,119 @@
+// Package canary keeps the state of a canary trial of a candidate configuration in Redis,
+// so the trial's run count and verdict survive restarts and are shared by replicas.
+package canary
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Key, after the key prefix, holds the JSON-encoded trial.
+const Key = "canary"
+
+// Verdicts of a trial.
+const (
+	VerdictRunning = "running" // The trial has not completed its runs
+	VerdictPassed  = "passed"  // The candidate volume stayed within the threshold
+	VerdictFailed  = "failed"  // The candidate volume deviated beyond the threshold
+)
+
+// Trial is the state of the trial of one candidate configuration in the canary cities.
+// Baseline and Candidate count the articles the current and candidate configurations
+// passed in those cities over the trial's runs.
+type Trial struct {
+	Fingerprint string    `json:"fingerprint"` // Identifies the candidate configuration and canary cities
+	Runs        int       `json:"runs"`
+	Baseline    int       `json:"baseline"`
+	Candidate   int       `json:"candidate"`
+	Deviation   float64   `json:"deviation"` // Relative change of Candidate from Baseline
+	Verdict     string    `json:"verdict"`
+	StartedAt   time.Time `json:"started_at"`
+	DecidedAt   time.Time `json:"decided_at,omitzero"`
+}
+
+// NewTrial returns a running trial of the configuration identified by fingerprint.
+func NewTrial(fingerprint string, now time.Time) Trial {
+	return Trial{Fingerprint: fingerprint, Verdict: VerdictRunning, StartedAt: now}
+}
+
+// Add counts one run of the trial. Once runs runs are counted, the verdict is decided by
+// comparing the deviation with maxDeviation. It reports whether this run decided it; a
+// decided trial is not changed.
+func (t *Trial) Add(baseline, candidate, runs int, maxDeviation float64, now time.Time) bool {
+	if t.Verdict != VerdictRunning {
+		return false
+	}
+	t.Runs++
+	t.Baseline += baseline
+	t.Candidate += candidate
+	t.Deviation = Deviation(t.Baseline, t.Candidate)
+	if t.Runs < runs {
+		return false
+	}
+	t.Verdict = VerdictPassed
+	if t.Deviation > maxDeviation {
+		t.Verdict = VerdictFailed
+	}
+	t.DecidedAt = now
+	return true
+}
+
+// Deviation returns how far candidate is from baseline, relative to baseline. With no
+// baseline articles, each candidate article counts as a full deviation.
+func Deviation(baseline, candidate int) float64 {
+	diff := math.Abs(float64(candidate - baseline))
+	if baseline == 0 {
+		return diff
+	}
+	return diff / float64(baseline)
+}
+
+// Store reads and saves the trial.
+type Store struct {
+	client *redis.Client
+	key    string // Key after the key prefix
+}
+
+// NewStore returns a store backed by client, keeping its data under prefix (redis.key_prefix).
+func NewStore(client *redis.Client, prefix string) *Store {
+	return &Store{client: client, key: prefix + Key}
+}
+
+// Load returns the saved trial of the configuration identified by fingerprint, or a new
+// running trial when none was saved or the saved one is of another configuration.
+func (s *Store) Load(ctx context.Context, fingerprint string, now time.Time) (Trial, error) {
+	data, err := s.client.Get(ctx, s.key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return NewTrial(fingerprint, now), nil
+	}
+	if err != nil {
+		return Trial{}, fmt.Errorf("load canary trial: %w", err)
+	}
+
+	var trial Trial
+	if err := json.Unmarshal(data, &trial); err != nil {
+		return Trial{}, fmt.Errorf("decode canary trial: %w", err)
+	}
+	if trial.Fingerprint != fingerprint {
+		return NewTrial(fingerprint, now), nil
+	}
+	return trial, nil
+}
+
+// Save stores the trial, replacing any earlier one.
+func (s *Store) Save(ctx context.Context, trial Trial) error {
+	data, err := json.Marshal(trial)
+	if err != nil {
+		return fmt.Errorf("encode canary trial: %w", err)
+	}
+	if err := s.client.Set(ctx, s.key, data, 0).Err(); err != nil {
+		return fmt.Errorf("save canary trial: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package canary_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gopost/integration/internal/canary"
+	"github.com/gopost/integration/internal/dedup/deduptest"
+)
+
+func TestTrial_Add(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		baseline  int
+		candidate int
+		want      string
+	}{
+		{"within threshold", 10, 13, canary.VerdictPassed},
+		{"volume doubled", 10, 20, canary.VerdictFailed},
+		{"volume dropped", 10, 4, canary.VerdictFailed},
+		{"nothing matched either way", 0, 0, canary.VerdictPassed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			trial := canary.NewTrial("abc", now)
+			if trial.Add(tt.baseline, tt.candidate, 2, 0.5, now) {
+				t.Fatal("first of two runs decided the trial")
+			}
+			if !trial.Add(tt.baseline, tt.candidate, 2, 0.5, now) {
+				t.Fatal("last run did not decide the trial")
+			}
+			if trial.Verdict != tt.want {
+				t.Errorf("Verdict = %q (deviation %v), want %q", trial.Verdict, trial.Deviation, tt.want)
+			}
+			// A decided trial keeps its verdict
+			if trial.Add(0, 100, 2, 0.5, now) || trial.Runs != 2 {
+				t.Errorf("decided trial changed: %+v", trial)
+			}
+		})
+	}
+}
+
+func TestStore(t *testing.T) {
+	mr := miniredis.RunT(t)
+	store := canary.NewStore(deduptest.NewClient(t, mr), deduptest.KeyPrefix)
+	ctx := context.Background()
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+
+	trial, err := store.Load(ctx, "abc", now)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if trial.Verdict != canary.VerdictRunning || trial.Runs != 0 {
+		t.Fatalf("Load() of nothing saved = %+v, want a new running trial", trial)
+	}
+
+	trial.Add(5, 6, 12, 0.5, now)
+	if err := store.Save(ctx, trial); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	loaded, err := store.Load(ctx, "abc", now.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if loaded.Runs != 1 || loaded.Baseline != 5 || loaded.Candidate != 6 || !loaded.StartedAt.Equal(now) {
+		t.Errorf("Load() = %+v, want the saved trial", loaded)
+	}
+
+	// A changed candidate configuration starts over
+	fresh, err := store.Load(ctx, "def", now.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if fresh.Fingerprint != "def" || fresh.Runs != 0 {
+		t.Errorf("Load() of another fingerprint = %+v, want a new trial", fresh)
+	}
+}
@@ -0,0 +1,82 @@
+// Package chaos injects failures and latency into the pipeline backends so retry and
+// queue behavior can be exercised end-to-end in staging. It must never be enabled in
+// production.
+package chaos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/gopost/integration/internal/config"
+	"github.com/gopost/integration/internal/logger"
+)
+
+// ErrInjected is the error returned for injected failures.
+var ErrInjected = errors.New("chaos: injected failure")
+
+// Injector decides, for one backend, whether a call fails and how long it is delayed.
+type Injector struct {
+	backend     string
+	failureRate float64
+	latency     time.Duration
+	jitter      time.Duration
+	logger      logger.Logger
+
+	mu  sync.Mutex
+	rng *rand.Rand
+}
+
+// NewInjector returns an injector for backend. A zero seed picks a random one; a fixed
+// seed makes the failure sequence reproducible.
+func NewInjector(backend string, cfg config.ChaosBackendConfig, seed uint64, log logger.Logger) *Injector {
+	if seed == 0 {
+		seed = rand.Uint64()
+	}
+	return &Injector{
+		backend:     backend,
+		failureRate: cfg.FailureRate,
+		latency:     cfg.Latency,
+		jitter:      cfg.Jitter,
+		logger:      log,
+		rng:         rand.New(rand.NewPCG(seed, seed)),
+	}
+}
+
+// Inject delays the call by the configured latency plus up to jitter, then fails it with
+// the configured probability. op names the operation in logs and errors.
+func (i *Injector) Inject(ctx context.Context, op string) error {
+	delay, fail := i.roll()
+	if delay > 0 {
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+	}
+	if !fail {
+		return nil
+	}
+
+	i.logger.Debug("Chaos failure injected",
+		logger.String("backend", i.backend),
+		logger.String("operation", op),
+	)
+	return fmt.Errorf("%s %s: %w", i.backend, op, ErrInjected)
+}
+
+func (i *Injector) roll() (time.Duration, bool) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+
+	delay := i.latency
+	if i.jitter > 0 {
+		delay += time.Duration(i.rng.Int64N(int64(i.jitter)))
+	}
+	return delay, i.failureRate > 0 && i.rng.Float64() < i.failureRate
+}
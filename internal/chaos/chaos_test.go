@@ -0,0 +1,61 @@
+package chaos_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gopost/integration/internal/chaos"
+	"github.com/gopost/integration/internal/config"
+	"github.com/gopost/integration/internal/logger"
+)
+
+func TestInjector_FailureRate(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		rate      float64
+		wantFails func(fails int) bool
+	}{
+		{0, func(fails int) bool { return fails == 0 }},
+		{1, func(fails int) bool { return fails == 1000 }},
+		{0.3, func(fails int) bool { return fails > 200 && fails < 400 }},
+	}
+	for _, tt := range tests {
+		injector := chaos.NewInjector("drupal", config.ChaosBackendConfig{FailureRate: tt.rate}, 42, logger.NewNopLogger())
+		fails := 0
+		for range 1000 {
+			if err := injector.Inject(ctx, "post"); err != nil {
+				if !errors.Is(err, chaos.ErrInjected) {
+					t.Fatalf("Inject() error = %v, want ErrInjected", err)
+				}
+				fails++
+			}
+		}
+		if !tt.wantFails(fails) {
+			t.Errorf("rate %v: %d of 1000 calls failed", tt.rate, fails)
+		}
+	}
+}
+
+func TestInjector_SeedIsReproducible(t *testing.T) {
+	ctx := context.Background()
+	cfg := config.ChaosBackendConfig{FailureRate: 0.5}
+	a := chaos.NewInjector("redis", cfg, 7, logger.NewNopLogger())
+	b := chaos.NewInjector("redis", cfg, 7, logger.NewNopLogger())
+	for i := range 100 {
+		if (a.Inject(ctx, "reserve") == nil) != (b.Inject(ctx, "reserve") == nil) {
+			t.Fatalf("call %d differed between injectors with the same seed", i)
+		}
+	}
+}
+
+func TestInjector_LatencyHonorsContext(t *testing.T) {
+	injector := chaos.NewInjector("elasticsearch", config.ChaosBackendConfig{Latency: time.Hour}, 1, logger.NewNopLogger())
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if err := injector.Inject(ctx, "search"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Inject() error = %v, want context.DeadlineExceeded", err)
+	}
+}
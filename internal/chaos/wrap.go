@@ -0,0 +1,112 @@
+package chaos
+
+import (
+	"context"
+	"time"
+
+	"github.com/gopost/integration/internal/outbox"
+	"github.com/gopost/integration/pkg/drupal"
+	"github.com/gopost/integration/pkg/pipeline"
+)
+
+// Source wraps a pipeline.Source, injecting failures before each search.
+type Source struct {
+	pipeline.Source
+	Injector *Injector
+}
+
+func (s *Source) Search(ctx context.Context, index string, query any) (*pipeline.SearchResult, error) {
+	if err := s.Injector.Inject(ctx, "search"); err != nil {
+		return nil, err
+	}
+	return s.Source.Search(ctx, index, query)
+}
+
+// Poster wraps a pipeline.Poster, injecting failures before each post.
+type Poster struct {
+	pipeline.Poster
+	Injector *Injector
+}
+
+func (p *Poster) PostArticle(ctx context.Context, req drupal.ArticleRequest) error {
+	if err := p.Injector.Inject(ctx, "post"); err != nil {
+		return err
+	}
+	return p.Poster.PostArticle(ctx, req)
+}
+
+// Tracker wraps a pipeline.Tracker, injecting failures before each call. An injected
+// HasPosted failure reports false, as the Redis tracker does when Redis is unreachable.
+type Tracker struct {
+	pipeline.Tracker
+	Injector *Injector
+}
+
+func (t *Tracker) HasPosted(ctx context.Context, articleID string) bool {
+	if err := t.Injector.Inject(ctx, "has_posted"); err != nil {
+		return false
+	}
+	return t.Tracker.HasPosted(ctx, articleID)
+}
+
+func (t *Tracker) Reserve(ctx context.Context, articleID string) (bool, error) {
+	if err := t.Injector.Inject(ctx, "reserve"); err != nil {
+		return false, err
+	}
+	return t.Tracker.Reserve(ctx, articleID)
+}
+
+func (t *Tracker) Release(ctx context.Context, articleID string) error {
+	if err := t.Injector.Inject(ctx, "release"); err != nil {
+		return err
+	}
+	return t.Tracker.Release(ctx, articleID)
+}
+
+func (t *Tracker) MarkPosted(ctx context.Context, articleID string) error {
+	if err := t.Injector.Inject(ctx, "mark_posted"); err != nil {
+		return err
+	}
+	return t.Tracker.MarkPosted(ctx, articleID)
+}
+
+// Queue wraps an outbox.Queue, injecting failures before each call.
+type Queue struct {
+	outbox.Queue
+	Injector *Injector
+}
+
+func (q *Queue) Enqueue(ctx context.Context, item outbox.Item) (bool, error) {
+	if err := q.Injector.Inject(ctx, "enqueue"); err != nil {
+		return false, err
+	}
+	return q.Queue.Enqueue(ctx, item)
+}
+
+func (q *Queue) Dequeue(ctx context.Context, wait time.Duration) (*outbox.Delivery, error) {
+	if err := q.Injector.Inject(ctx, "dequeue"); err != nil {
+		return nil, err
+	}
+	return q.Queue.Dequeue(ctx, wait)
+}
+
+func (q *Queue) Ack(ctx context.Context, delivery *outbox.Delivery) error {
+	if err := q.Injector.Inject(ctx, "ack"); err != nil {
+		return err
+	}
+	return q.Queue.Ack(ctx, delivery)
+}
+
+func (q *Queue) Nack(ctx context.Context, delivery *outbox.Delivery, maxAttempts int, delay time.Duration) (bool, error) {
+	if err := q.Injector.Inject(ctx, "nack"); err != nil {
+		return false, err
+	}
+	return q.Queue.Nack(ctx, delivery, maxAttempts, delay)
+}
+
+func (q *Queue) Requeue(ctx context.Context, delivery *outbox.Delivery, delay time.Duration) error {
+	if err := q.Injector.Inject(ctx, "requeue"); err != nil {
+		return err
+	}
+	return q.Queue.Requeue(ctx, delivery, delay)
+}
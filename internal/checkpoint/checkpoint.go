@@ -0,0 +1,88 @@
+// Package checkpoint persists the time of the last completed sync in Redis so a restarted
+// service can tell how long it was down, and the newest article posted for each city so
+// sync lag can be measured across restarts.
+package checkpoint
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Key, after the key prefix, holds the last check time in RFC 3339 format.
+const Key = "last_check"
+
+// PostedKey, after the key prefix, is a hash of city name to the published time, in Unix
+// milliseconds, of the newest article posted for the city.
+const PostedKey = "newest_posted"
+
+// recordPostedScript raises the city's newest posted time to ARGV[2] unless it is older.
+var recordPostedScript = redis.NewScript(`
+local current = tonumber(redis.call("HGET", KEYS[1], ARGV[1]))
+if current and current >= tonumber(ARGV[2]) then
+	return 0
+end
+redis.call("HSET", KEYS[1], ARGV[1], ARGV[2])
+return 1
+`)
+
+// Store reads and writes the last check time.
+type Store struct {
+	client    *redis.Client
+	key       string // Key after the key prefix
+	postedKey string // PostedKey after the key prefix
+}
+
+// NewStore returns a store backed by client, keeping its data under prefix (redis.key_prefix).
+func NewStore(client *redis.Client, prefix string) *Store {
+	return &Store{client: client, key: prefix + Key, postedKey: prefix + PostedKey}
+}
+
+// Load returns the saved last check time, or the zero time if none was saved.
+func (s *Store) Load(ctx context.Context) (time.Time, error) {
+	value, err := s.client.Get(ctx, s.key).Result()
+	if errors.Is(err, redis.Nil) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("load last check: %w", err)
+	}
+	lastCheck, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("parse last check %q: %w", value, err)
+	}
+	return lastCheck, nil
+}
+
+// Save records the last check time.
+func (s *Store) Save(ctx context.Context, lastCheck time.Time) error {
+	if err := s.client.Set(ctx, s.key, lastCheck.UTC().Format(time.RFC3339Nano), 0).Err(); err != nil {
+		return fmt.Errorf("save last check: %w", err)
+	}
+	return nil
+}
+
+// RecordPosted records that an article published at publishedAt was posted for city. The
+// city's newest posted time only moves forward, so posting an older article keeps it.
+func (s *Store) RecordPosted(ctx context.Context, city string, publishedAt time.Time) error {
+	if err := recordPostedScript.Run(ctx, s.client, []string{s.postedKey}, city, publishedAt.UnixMilli()).Err(); err != nil {
+		return fmt.Errorf("record newest posted for %s: %w", city, err)
+	}
+	return nil
+}
+
+// NewestPosted returns the published time of the newest article posted for city, or the
+// zero time if none was recorded.
+func (s *Store) NewestPosted(ctx context.Context, city string) (time.Time, error) {
+	millis, err := s.client.HGet(ctx, s.postedKey, city).Int64()
+	if errors.Is(err, redis.Nil) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("load newest posted for %s: %w", city, err)
+	}
+	return time.UnixMilli(millis), nil
+}
@@ -2,29 +2,96 @@ package config
 
 import (
 	"context"
+	"encoding/base64"
 	"errors"
 	"fmt"
+	"maps"
+	"net/url"
 	"os"
+	"path/filepath"
+	"slices"
 	"strings"
+	"text/template"
 	"time"
 
+	"github.com/gopost/integration/internal/netproxy"
+	"github.com/gopost/integration/pkg/pipeline"
 	"gopkg.in/yaml.v3"
 )
 
 type Config struct {
-	Debug         bool                `yaml:"debug"` // Application debug mode (controls log level and format)
+	Debug          bool                 `yaml:"debug"`      // Application debug mode (controls log level and format)
+	UserAgent      string               `yaml:"user_agent"` // User-Agent sent to Drupal, Elasticsearch, and the sources service (default: gopost/<version>)
+	Elasticsearch  ElasticsearchConfig  `yaml:"elasticsearch"`
+	Drupal         DrupalConfig         `yaml:"drupal"`
+	Redis          RedisConfig          `yaml:"redis"`
+	Service        ServiceConfig        `yaml:"service"`
+	Cities         []CityConfig         `yaml:"cities"`
+	CityDiscovery  CityDiscoveryConfig  `yaml:"city_discovery"`
+	Percolator     PercolatorConfig     `yaml:"percolator"`
+	SearchTemplate SearchTemplateConfig `yaml:"search_template"` // Optional: search with a template stored in Elasticsearch
+	Categories     CategoriesConfig     `yaml:"categories"`
+	Translation    TranslationConfig    `yaml:"translation"` // Optional: machine translation provider for cities with translate_to
+	Audit          AuditConfig          `yaml:"audit"`       // Optional: copies of posted articles in Elasticsearch
+	Sources        SourcesConfig        `yaml:"sources"`     // Optional: Sources service configuration
+	Outbox         OutboxConfig         `yaml:"outbox"`      // Optional: Redis work queue between discovery and posting
+	Admin          AdminConfig          `yaml:"admin"`       // Optional: operational HTTP endpoints
+	GRPC           GRPCConfig           `yaml:"grpc"`        // Optional: operational gRPC API for fleet tooling
+	History        HistoryConfig        `yaml:"history"`     // Optional: run history for "gopost report"
+	Timeline       TimelineConfig       `yaml:"timeline"`    // Optional: per-article stage times for "gopost trace"
+	Consistency    ConsistencyConfig    `yaml:"consistency"` // Optional: checks that posted nodes still exist in Drupal
+	Suppression    SuppressionConfig    `yaml:"suppression"` // Optional: articles never to post again, such as those editors deleted
+	Moderation     ModerationConfig     `yaml:"moderation"`  // Optional: Drupal webhook reporting editorial decisions on posted nodes
+	Canary         CanaryConfig         `yaml:"canary"`      // Optional: trial of new keywords and source lists in a few cities
+	Smoke          SmokeConfig          `yaml:"smoke"`       // Optional: settings for "gopost smoke"
+	Hooks          []HookConfig         `yaml:"hooks"`       // Optional: commands and HTTP callouts run at lifecycle points
+	Logging        LoggingConfig        `yaml:"logging"`     // Optional: log destination and sampling of repeated messages
+	Metrics        MetricsConfig        `yaml:"metrics"`     // Optional: metrics pushed after "gopost once" runs
+	Monitoring     MonitoringConfig     `yaml:"monitoring"`  // Optional: dead man's switch pings for each run
+	Chaos          ChaosConfig          `yaml:"chaos"`       // Staging only: failure injection
+	Tenants        []TenantConfig       `yaml:"tenants"`     // Optional: independent pipelines run in one process
+}
+
+// TenantConfig is one independent pipeline in multi-tenant mode: its own Elasticsearch
+// cluster, Drupal site, Redis database, and cities. Service settings are shared.
+type TenantConfig struct {
+	Name          string              `yaml:"name"`
 	Elasticsearch ElasticsearchConfig `yaml:"elasticsearch"`
 	Drupal        DrupalConfig        `yaml:"drupal"`
 	Redis         RedisConfig         `yaml:"redis"`
-	Service       ServiceConfig       `yaml:"service"`
 	Cities        []CityConfig        `yaml:"cities"`
-	Sources       SourcesConfig       `yaml:"sources"` // Optional: Sources service configuration
+	CityDiscovery CityDiscoveryConfig `yaml:"city_discovery"`
 }
 
 type ElasticsearchConfig struct {
-	URL      string `yaml:"url"`
-	Username string `yaml:"username"`
-	Password string `yaml:"password"`
+	URL          string `yaml:"url"`
+	CloudID      string `yaml:"cloud_id"` // Elastic Cloud deployment ID, instead of url
+	Username     string `yaml:"username"`
+	Password     string `yaml:"password"`
+	APIKey       string `yaml:"api_key"`       // Base64-encoded API key ("id:api_key"), instead of username and password
+	ServiceToken string `yaml:"service_token"` // Service account token sent as a bearer token, instead of username and password
+	Proxy        string `yaml:"proxy"`         // Optional: SOCKS5/HTTP proxy URL, or "direct" to ignore HTTP(S)_PROXY
+}
+
+// Endpoint returns the cluster URL: url, or the Elasticsearch URL encoded in cloud_id.
+func (c ElasticsearchConfig) Endpoint() (string, error) {
+	if c.CloudID == "" {
+		return c.URL, nil
+	}
+	// A Cloud ID is "<label>:<base64 of host$es_uuid$kibana_uuid>"; the label may be absent
+	encoded := c.CloudID
+	if i := strings.LastIndexByte(encoded, ':'); i >= 0 {
+		encoded = encoded[i+1:]
+	}
+	decoded, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", fmt.Errorf("decode cloud ID: %w", err)
+	}
+	parts := strings.Split(string(decoded), "$")
+	if len(parts) < 2 || parts[0] == "" || parts[1] == "" {
+		return "", errors.New("cloud ID must encode a host and an Elasticsearch ID")
+	}
+	return "https://" + parts[1] + "." + parts[0], nil
 }
 
 type DrupalConfig struct {
@@ -33,40 +100,1142 @@ type DrupalConfig struct {
 	Token         string `yaml:"token"`           // API key/token for authentication
 	AuthMethod    string `yaml:"auth_method"`     // AUTH-METHOD header value (application ID)
 	SkipTLSVerify bool   `yaml:"skip_tls_verify"` // Skip TLS certificate verification (development only)
+	ConnStats     bool   `yaml:"conn_stats"`      // Debugging: connection reuse and timing histograms on /metrics
+	Proxy         string `yaml:"proxy"`           // Optional: SOCKS5/HTTP proxy URL, or "direct" to ignore HTTP(S)_PROXY
+	RevisionLog   string `yaml:"revision_log"`    // Go template for the revision log of created nodes (default: DefaultRevisionLog; RevisionLogNone for none)
 }
 
+// DefaultRevisionLog is the revision log message of created nodes, so Drupal's revision
+// UI shows where they came from. Templates can use .Version (the gopost version), .Source,
+// .ID (the Elasticsearch ID), .City, .Title, and .URL.
+const DefaultRevisionLog = "Imported by gopost {{.Version}} from {{.Source}} ({{.ID}})"
+
+// RevisionLogNone leaves the revision log of created nodes empty.
+const RevisionLogNone = "none"
+
 type RedisConfig struct {
-	URL      string `yaml:"url"`
-	Password string `yaml:"password"`
-	DB       int    `yaml:"db"`
+	URL       string          `yaml:"url"`
+	Username  string          `yaml:"username"` // Optional: ACL user (Redis 6+); password is then that user's password
+	Password  string          `yaml:"password"`
+	DB        int             `yaml:"db"`
+	Proxy     string          `yaml:"proxy"`      // Optional: SOCKS5/HTTP proxy URL for reaching Redis
+	KeyPrefix string          `yaml:"key_prefix"` // Starts every key gopost writes, so environments can share an instance (default: "gopost:")
+	TLS       RedisTLSConfig  `yaml:"tls"`
+	Pool      RedisPoolConfig `yaml:"pool"`
+}
+
+// RedisTLSConfig controls TLS to Redis. Setting cert_file and key_file also presents a
+// client certificate, for servers that require mutual TLS.
+type RedisTLSConfig struct {
+	Enabled    bool   `yaml:"enabled"`
+	CAFile     string `yaml:"ca_file"`     // CA bundle (PEM) for verifying the server (default: system roots)
+	CertFile   string `yaml:"cert_file"`   // Client certificate (PEM)
+	KeyFile    string `yaml:"key_file"`    // Client private key (PEM)
+	ServerName string `yaml:"server_name"` // Name to verify the server certificate against (default: the host of redis.url)
+	SkipVerify bool   `yaml:"skip_verify"` // Skip certificate verification (development only)
+}
+
+// RedisPoolConfig sizes the Redis connection pool. Zero values keep go-redis' defaults.
+type RedisPoolConfig struct {
+	Size        int           `yaml:"size"`         // Most open connections (default: 10 per CPU)
+	MinIdle     int           `yaml:"min_idle"`     // Idle connections kept open, e.g. to skip the TLS handshake on bursts
+	MaxIdle     int           `yaml:"max_idle"`     // Idle connections beyond this are closed (default: no limit)
+	IdleTimeout time.Duration `yaml:"idle_timeout"` // Idle connections are closed after this (default: 30m)
+	MaxLifetime time.Duration `yaml:"max_lifetime"` // Connections are closed at this age, e.g. to follow a failover (default: never)
+	Timeout     time.Duration `yaml:"timeout"`      // How long a command waits for a free connection (default: read timeout + 1s)
 }
 
+// DefaultKeyPrefix is the default redis.key_prefix.
+const DefaultKeyPrefix = "gopost:"
+
 type ServiceConfig struct {
-	CheckInterval time.Duration `yaml:"check_interval"`
-	RateLimitRPS  int           `yaml:"rate_limit_rps"`
-	LookbackHours int           `yaml:"lookback_hours"`
-	CrimeKeywords []string      `yaml:"crime_keywords"`
-	ContentType   string        `yaml:"content_type"`
-	GroupType     string        `yaml:"group_type"`
-	DedupTTL      time.Duration `yaml:"dedup_ttl"` // Default: 8760h (1 year)
+	CheckInterval  time.Duration        `yaml:"check_interval"`
+	RateLimitRPS   int                  `yaml:"rate_limit_rps"`
+	LookbackHours  int                  `yaml:"lookback_hours"`
+	CatchupHours   int                  `yaml:"catchup_hours"` // Longest lookback used to cover downtime after a restart (default: 168)
+	SearchSize     int                  `yaml:"search_size"`   // Articles fetched per Elasticsearch search (default: 100)
+	CrimeKeywords  []string             `yaml:"crime_keywords"`
+	KeywordMatch   string               `yaml:"keyword_match"` // "word" (default): plain keywords match whole words only; "stem"; or "substring"
+	StemSubfield   string               `yaml:"stem_subfield"` // Stemmed subfield of title and body also searched with keyword_match: stem (default: english)
+	ContentType    string               `yaml:"content_type"`
+	GroupType      string               `yaml:"group_type"`
+	GroupRefresh   time.Duration        `yaml:"group_refresh"`   // How often city group_name values are re-resolved to UUIDs (default: 1h)
+	DedupTTL       time.Duration        `yaml:"dedup_ttl"`       // Default: 8760h (1 year)
+	DedupLease     time.Duration        `yaml:"dedup_lease"`     // How long an article stays reserved while being posted (default: 2m)
+	DedupBloom     DedupBloomConfig     `yaml:"dedup_bloom"`     // Optional: Bloom filter in front of the exact dedup checks
+	Identity       string               `yaml:"identity"`        // How articles are identified for dedup and field_external_id: "id" (default), "es_id", "url", "hash", or "field:<name>"
+	RunTokenTTL    time.Duration        `yaml:"run_token_ttl"`   // How long a run token's processed articles are remembered for retries (default: 30m)
+	Timezone       string               `yaml:"timezone"`        // IANA zone for published dates without a zone (default: UTC)
+	DateLayouts    []string             `yaml:"date_layouts"`    // Go time layouts tried for published_date after RFC 3339 and epoch values
+	Titles         TitlesConfig         `yaml:"titles"`          // Optional: title normalization
+	NearDuplicates NearDuplicatesConfig `yaml:"near_duplicates"` // Optional: similarity-based clone suppression
+	URLs           URLsConfig           `yaml:"urls"`            // Optional: canonical URL normalization
+	LinkCheck      LinkCheckConfig      `yaml:"link_check"`      // Optional: link health check before posting
+	Warmup         WarmupConfig         `yaml:"warmup"`          // Optional: post limit for newly enabled cities
+	Highlight      HighlightConfig      `yaml:"highlight"`       // Optional: matched keyword fragments for editorial context
+	Classify       ClassifyConfig       `yaml:"classify"`        // Which article fields crime_keywords are matched in, and their weights
+	Severity       SeverityConfig       `yaml:"severity"`        // Optional: keyword-weighted severity score
+	Promotion      PromotionConfig      `yaml:"promotion"`       // Optional: Drupal promote and sticky flags of created nodes
+	GroupQuota     GroupQuotaConfig     `yaml:"group_quota"`     // Optional: daily post caps per Drupal group
+	Maintenance    MaintenanceConfig    `yaml:"maintenance"`     // Retry backoff while Drupal is in maintenance mode
+	AuthFailure    AuthFailureConfig    `yaml:"auth_failure"`    // Retry backoff while Drupal rejects the credentials
+	ClosedIndex    ClosedIndexConfig    `yaml:"closed_index"`    // Retry backoff for cities whose index is closed or frozen
+	Health         HealthConfig         `yaml:"health"`          // Health score thresholds for /status and /metrics
+	SyncLag        SyncLagConfig        `yaml:"sync_lag"`        // Optional: per-city lag of posting behind Elasticsearch
+	VolumeAnomaly  VolumeAnomalyConfig  `yaml:"volume_anomaly"`  // Optional: per-city runs far outside the usual article volume
+	SearchCache    SearchCacheConfig    `yaml:"search_cache"`    // Optional: reuse of identical searches, e.g. by cities sharing an index
+	MultiSearch    bool                 `yaml:"multi_search"`    // Run all city searches in one _msearch request per run, falling back to individual searches
+	AllowedSources []string             `yaml:"allowed_sources"` // Only post these outlets (source field or URL domain); empty allows all
+	BlockedSources []string             `yaml:"blocked_sources"` // Never post these outlets
+	FieldMap       []FieldMapConfig     `yaml:"field_map"`       // Optional: extra Drupal fields populated from article data
+	PathAlias      string               `yaml:"path_alias"`      // Optional: Go template for the URL alias of created nodes, e.g. "/crime/{{.City}}/{{slug .Title}}"
+	PostOrder      string               `yaml:"post_order"`      // Order each city's articles are posted in: "newest_first" (default), "oldest_first", or "severity"
+
+	// Most articles one city processes per run, or per replay across its pages; the rest are
+	// left unprocessed and logged (default: 0, only limited by search_size)
+	MaxArticlesPerCityPerRun int `yaml:"max_articles_per_city_per_run"`
+
+	// Longest article body kept in memory and posted, in bytes; longer bodies are cut and
+	// logged, bounding memory in big runs (default: 1048576, 1 MiB)
+	MaxBodyBytes int `yaml:"max_body_bytes"`
+
+	// Drupal posts one city runs at once, started in post_order and paced by the shared rate
+	// limiter (default: 1, one post at a time)
+	PostConcurrency int `yaml:"post_concurrency"`
+
+	// Optional: file recording articles posted to Drupal whose dedup marker could not be
+	// written to Redis, so they are not posted again before a retry writes the marker
+	MarkJournal string `yaml:"mark_journal"`
+}
+
+// maxPostConcurrency bounds service.post_concurrency and cities' post_concurrency, well
+// above what a Drupal site is likely to take from one client.
+const maxPostConcurrency = 32
+
+// Field map value types, selecting how an article property is encoded for Drupal.
+const (
+	FieldTypeString   = "string"   // Plain string (default)
+	FieldTypeInteger  = "integer"  // Number
+	FieldTypeText     = "text"     // Formatted text: {value, format, summary}
+	FieldTypeLink     = "link"     // Link: {uri, title}
+	FieldTypeDateTime = "datetime" // Date and time in UTC
+	FieldTypeDate     = "date"     // Calendar date only
+	FieldTypeMetatag  = "metatag"  // Metatag module field: {value: {tag: value}}, from tags
+)
+
+// DefaultMetatags are the tags of a metatag field map without tags: the canonical URL and
+// the search and social previews, so syndicated pages point search engines at the original.
+var DefaultMetatags = map[string]string{
+	"canonical_url":  "canonical_url",
+	"description":    "summary",
+	"og_title":       "title",
+	"og_description": "summary",
+}
+
+// FieldMapSources are the article properties a field map can read, named after the
+// Elasticsearch fields they come from, plus "highlights" (see HighlightConfig), "severity"
+// (see SeverityConfig), and "summary" (the intro, description, or start of the body).
+var FieldMapSources = []string{
+	"id", "title", "body", "canonical_url", "published_date", "source", "intro", "description",
+	"og_title", "og_description", "og_image", "og_url", "word_count", "category", "section", "keywords",
+	"highlights", "severity", "summary",
+}
+
+// FieldTransformerPrefix marks a field map source naming a Go function registered with
+// the service (see integration.WithFieldTransformer) rather than an article property,
+// e.g. "fn:first_paragraph".
+const FieldTransformerPrefix = "fn:"
+
+// FieldMapConfig populates one Drupal field from article data, such as a teaser
+// ({field: field_teaser, source: intro, type: text}) or a link to the original story.
+type FieldMapConfig struct {
+	Field   string `yaml:"field"`   // Drupal attribute name, e.g. "field_teaser"
+	Source  string `yaml:"source"`  // Article property (see FieldMapSources), or "fn:" and a field transformer name
+	Type    string `yaml:"type"`    // string (default), integer, text, link, datetime, or date
+	Format  string `yaml:"format"`  // Text format for type text (default: basic_html)
+	Summary string `yaml:"summary"` // Article property used as the summary for type text
+	Title   string `yaml:"title"`   // Article property used as the link title for type link
+
+	// Metatag name to article property for type metatag, e.g. {og_title: title} (default: DefaultMetatags)
+	Tags map[string]string `yaml:"tags"`
+}
+
+// NearDuplicatesConfig controls suppression of near-identical article bodies, such as
+// the same wire copy published by two outlets.
+type NearDuplicatesConfig struct {
+	Enabled   bool          `yaml:"enabled"`
+	Threshold float64       `yaml:"threshold"` // Minimum SimHash similarity (0-1) to count as a clone (default: 0.9)
+	Window    time.Duration `yaml:"window"`    // How long posted bodies are remembered (default: 48h)
+}
+
+// DedupBloomConfig controls the Bloom filter of dedup markers, which spares articles that
+// are certainly new the per-article Redis lookup, e.g. in backfills of millions of IDs.
+type DedupBloomConfig struct {
+	Enabled           bool          `yaml:"enabled"`
+	Capacity          int           `yaml:"capacity"`            // Markers the filter is sized for (default: 10000000)
+	FalsePositiveRate float64       `yaml:"false_positive_rate"` // Share of new articles still checked exactly at capacity (default: 0.01)
+	Refresh           time.Duration `yaml:"refresh"`             // How often the local snapshot is reloaded from Redis (default: 1m)
+}
+
+// URLsConfig controls canonical URL normalization. Normalized URLs drop tracking
+// parameters and fragments and are resolved against the article source when relative.
+type URLsConfig struct {
+	Normalize     bool     `yaml:"normalize"`      // Normalize canonical_url before filtering and posting
+	StripParams   []string `yaml:"strip_params"`   // Query parameters to remove; "utm_*" matches a prefix (default: utm_*, fbclid, gclid, ...)
+	ForceHTTPS    bool     `yaml:"force_https"`    // Rewrite http URLs to https
+	RejectInvalid bool     `yaml:"reject_invalid"` // Skip articles whose URL cannot be normalized instead of posting it as-is
+}
+
+// How keywords match article text.
+const (
+	KeywordMatchSubstring = "substring" // Anywhere, even inside a longer word ("theft" in "thefts")
+	KeywordMatchWord      = "word"      // Whole words only, so short keywords stop matching inside other words
+	KeywordMatchStem      = "stem"      // Whole words in any inflection ("robbery" in "robberies", "assault" in "assaulted")
+)
+
+// Link check actions for broken links.
+const (
+	LinkCheckActionSkip = "skip" // Do not post the article
+	LinkCheckActionFlag = "flag" // Post it anyway and log a warning
+)
+
+// LinkCheckConfig controls the health check of canonical URLs before posting.
+type LinkCheckConfig struct {
+	Enabled         bool          `yaml:"enabled"`
+	Action          string        `yaml:"action"`           // What to do with broken links: "skip" (default) or "flag"
+	Timeout         time.Duration `yaml:"timeout"`          // Per-check timeout (default: 5s)
+	PerDomainRPS    float64       `yaml:"per_domain_rps"`   // Checks per second against one host (default: 1)
+	CacheTTL        time.Duration `yaml:"cache_ttl"`        // How long results are reused (default: 1h)
+	PaywallPatterns []string      `yaml:"paywall_patterns"` // URL substrings identifying paywall redirects (default: paywall, subscribe, /login, ...)
+}
+
+// HighlightConfig requests Elasticsearch highlighting of the crime keywords, so editors can
+// see why an article was selected. Fragments appear in the run report and "gopost preview",
+// and can be posted to Drupal with the "highlights" field map source.
+type HighlightConfig struct {
+	Enabled      bool `yaml:"enabled"`
+	FragmentSize int  `yaml:"fragment_size"` // Characters per body fragment (default: 150)
+	Fragments    int  `yaml:"fragments"`     // Body fragments per article (default: 3)
+}
+
+// ClassifyConfig sets which article fields the crime keyword classifier reads and how much
+// a keyword in each counts: an article is a crime article when the weights of the fields
+// mentioning a keyword sum to at least min_score. Fields are "title", "body", and "tags"
+// (the keywords the source assigned). The default, title: 1 and body: 1 with min_score 1,
+// matches a keyword in either; title: 1 alone trusts only headlines.
+type ClassifyConfig struct {
+	Fields   map[string]int `yaml:"fields"`    // Field to weight (default: title: 1, body: 1)
+	MinScore int            `yaml:"min_score"` // Default: 1
+}
+
+// SeverityConfig scores crime articles by keyword weights (e.g. homicide: 10, theft: 2).
+// Scores can be posted with the "severity" field map source, filter articles, and order
+// posting with post_order "severity".
+type SeverityConfig struct {
+	Weights  map[string]int `yaml:"weights"`   // Keyword to weight; an article scores the sum of the keywords it mentions
+	MinScore int            `yaml:"min_score"` // Skip articles scoring below this (default: 0, no threshold)
+}
+
+// PromotionConfig sets Drupal's promote (front page) and sticky (top of lists) flags on
+// created nodes. A city's promote and sticky replace the flags here, and a severity
+// threshold sets a flag on articles scoring at least that much regardless of either. Flags
+// left unset keep the content type's defaults.
+type PromotionConfig struct {
+	Promote         *bool `yaml:"promote"`
+	Sticky          *bool `yaml:"sticky"`
+	PromoteSeverity int   `yaml:"promote_severity"` // Promote articles whose severity score reaches this (requires severity.weights)
+	StickySeverity  int   `yaml:"sticky_severity"`  // Make articles sticky whose severity score reaches this (requires severity.weights)
+}
+
+// GroupQuotaConfig caps the articles posted to each Drupal group per day, as requested by
+// group moderators. Days start at rollover in service.timezone. Quotas are enabled when
+// daily_limit or groups is set.
+type GroupQuotaConfig struct {
+	DailyLimit int            `yaml:"daily_limit"` // Posts per group per day; 0 leaves groups not in groups unlimited
+	Groups     map[string]int `yaml:"groups"`      // Limits by group UUID, overriding daily_limit
+	Rollover   string         `yaml:"rollover"`    // Local time the quota day starts, "HH:MM" (default: "00:00")
+	Overflow   string         `yaml:"overflow"`    // What happens to articles over the quota: "defer" (default) or "drop"
+}
+
+// MaintenanceConfig controls how posting waits out Drupal's maintenance mode. After a post
+// finds the site in maintenance, posting pauses for backoff; each retry that finds it still
+// in maintenance doubles the wait, up to max_backoff.
+type MaintenanceConfig struct {
+	Backoff    time.Duration `yaml:"backoff"`     // Wait before the first retry (default: 1m)
+	MaxBackoff time.Duration `yaml:"max_backoff"` // Longest wait between retries (default: 30m)
+}
+
+// AuthFailureConfig controls how long posting stops after Drupal rejects the credentials
+// (401, or a 403 not caused by the CSRF token). Posting is retried after backoff; each retry
+// that is still rejected doubles the wait, up to max_backoff.
+type AuthFailureConfig struct {
+	Backoff    time.Duration `yaml:"backoff"`     // Wait before the first retry (default: 5m)
+	MaxBackoff time.Duration `yaml:"max_backoff"` // Longest wait between retries (default: 1h)
+}
+
+// ClosedIndexConfig controls how long cities are skipped after their index is found closed
+// or frozen. The search is retried after backoff; each retry that finds the index still
+// unavailable doubles the wait, up to max_backoff.
+type ClosedIndexConfig struct {
+	Backoff    time.Duration `yaml:"backoff"`     // Wait before the first retry (default: 15m)
+	MaxBackoff time.Duration `yaml:"max_backoff"` // Longest wait between retries (default: 6h)
+}
+
+// HealthConfig tunes the health score. Its lag component is 1 while the newest article
+// found is at most check_interval ahead of the last check time, and falls to 0 at max_lag.
+type HealthConfig struct {
+	MaxLag time.Duration `yaml:"max_lag"` // Lag at which the score's lag component reaches 0 (default: 12 x check_interval)
+}
+
+// SyncLagConfig measures each city's sync lag after every run: how far the newest article
+// matching its search in Elasticsearch, regardless of the search window, is ahead of the
+// newest article posted for it. A growing lag exposes stalls in runs that succeed without
+// finding anything.
+type SyncLagConfig struct {
+	Enabled   bool          `yaml:"enabled"`
+	WarnAfter time.Duration `yaml:"warn_after"` // Lag logged as a warning (default: 6h)
+}
+
+// SearchCacheConfig keeps search results in memory for a short time, so cities sharing an
+// index and search (differing only in group routing, say) run it once per run. Results are
+// keyed by index and the full query, which includes the search window.
+type SearchCacheConfig struct {
+	Enabled bool          `yaml:"enabled"`
+	TTL     time.Duration `yaml:"ttl"` // How long a result is reused (default: 1m)
+}
+
+// VolumeAnomalyConfig compares the articles each city found and posted in a run with the
+// city's rolling average, and reports runs far outside it: nothing for a normally busy
+// city, or a sudden spike, which usually means a broken index or mapping upstream.
+type VolumeAnomalyConfig struct {
+	Enabled     bool    `yaml:"enabled"`
+	Window      int     `yaml:"window"`       // Runs the rolling average spans (default: 48)
+	MinRuns     int     `yaml:"min_runs"`     // Runs a city needs before it is checked (default: 12)
+	MinAverage  float64 `yaml:"min_average"`  // Average from which a run with none is an anomaly (default: 3)
+	SpikeFactor float64 `yaml:"spike_factor"` // Multiple of the average (at least 1) that is an anomaly (default: 10)
+}
+
+// Quota overflow actions for articles that do not fit a group's daily quota.
+const (
+	QuotaOverflowDefer = "defer" // Keep the article and post it once the quota allows
+	QuotaOverflowDrop  = "drop"  // Skip the article
+)
+
+// Post orders, selecting which of a city's candidate articles are posted first.
+const (
+	PostOrderNewestFirst = "newest_first" // Most recently published first
+	PostOrderOldestFirst = "oldest_first" // Earliest published first
+	PostOrderSeverity    = "severity"     // Highest severity score first, newest first among equal scores
+)
+
+// PostOrders lists the valid post_order values.
+var PostOrders = []string{PostOrderNewestFirst, PostOrderOldestFirst, PostOrderSeverity}
+
+// WarmupConfig limits posting for newly enabled cities, so a city whose index already
+// holds months of articles does not flood its Drupal group on the first run.
+type WarmupConfig struct {
+	Enabled  bool   `yaml:"enabled"`
+	Runs     int    `yaml:"runs"`      // Number of runs a new city stays in warm-up (default: 3)
+	MaxPosts int    `yaml:"max_posts"` // Posts (or outbox entries) per city per warm-up run (default: 5)
+	Order    string `yaml:"order"`     // Which candidates go first, as in post_order (default: service.post_order)
+}
+
+// TitlesConfig controls title normalization. Normalized titles strip wire-service
+// prefixes such as "UPDATE:", punctuation, and case, so republished stories compare equal.
+type TitlesConfig struct {
+	StripPrefixes []string `yaml:"strip_prefixes"` // Prefixes removed before comparison (default: UPDATE, BREAKING, ...)
+	Dedup         bool     `yaml:"dedup"`          // Also skip articles whose normalized title was already posted
+	CleanPosted   bool     `yaml:"clean_posted"`   // Strip prefixes from the title posted to Drupal
 }
 
 type CityConfig struct {
-	Name    string `yaml:"name"`
-	Index   string `yaml:"index"`
-	GroupID string `yaml:"group_id"`
+	Name           string   `yaml:"name"`
+	Index          string   `yaml:"index"`
+	GroupID        string   `yaml:"group_id"`
+	GroupName      string   `yaml:"group_name"`      // Drupal group label, resolved to a UUID instead of setting group_id
+	QueryTemplate  string   `yaml:"query_template"`  // Go template file rendering the Elasticsearch query, replacing the keyword search
+	AllowedSources []string `yaml:"allowed_sources"` // Replaces service.allowed_sources for this city
+	BlockedSources []string `yaml:"blocked_sources"` // Added to service.blocked_sources for this city
+	Language       string   `yaml:"language"`        // Drupal langcode of the city's nodes (e.g. "fr"), also sent as Accept-Language
+	LanguagePrefix string   `yaml:"language_prefix"` // Path prefix of the language's routes (default: language; LanguagePrefixNone for none)
+	TranslateFrom  string   `yaml:"translate_from"`  // Langcode of the articles to translate (default: detected by the provider)
+	TranslateTo    string   `yaml:"translate_to"`    // Machine-translate articles into this langcode before posting
+	Promote        *bool    `yaml:"promote"`         // Replaces service.promotion.promote for this city
+	Sticky         *bool    `yaml:"sticky"`          // Replaces service.promotion.sticky for this city
+	Identity       string   `yaml:"identity"`        // Replaces service.identity for this city
+
+	PostConcurrency int `yaml:"post_concurrency"` // Replaces service.post_concurrency for this city
+}
+
+// LanguagePrefixNone posts a city with a language through the unprefixed endpoints, for
+// the site's default language or sites that negotiate the language without a path prefix.
+const LanguagePrefixNone = "none"
+
+// EndpointPrefix returns the path prefix of the city's JSON:API endpoints, e.g. "fr" for
+// /fr/jsonapi/node/article, or "" for the unprefixed ones.
+func (c CityConfig) EndpointPrefix() string {
+	switch c.LanguagePrefix {
+	case "":
+		return c.Language
+	case LanguagePrefixNone:
+		return ""
+	}
+	return c.LanguagePrefix
+}
+
+// CityDiscoveryConfig controls discovery of cities from Elasticsearch index names, so a
+// city added upstream is picked up without a config change. Indices matching the pattern
+// become cities named after the part matched by "*" (e.g. "sudbury_com" for
+// "sudbury_com_articles"); cities listed under cities take precedence.
+type CityDiscoveryConfig struct {
+	Enabled  bool              `yaml:"enabled"`
+	Pattern  string            `yaml:"pattern"`  // Index pattern with a single "*" (default: "*_articles")
+	Groups   map[string]string `yaml:"groups"`   // Drupal group UUID per discovered city; unmapped cities are skipped
+	Interval time.Duration     `yaml:"interval"` // How often the index list is refreshed (default: 10m)
+}
+
+// PercolatorConfig selects articles with the city queries stored in an Elasticsearch
+// percolator index, so deployments sharing a cluster apply the same criteria. Each run
+// fetches the city's articles in the search window and percolates them against the
+// query stored under the city name.
+type PercolatorConfig struct {
+	Enabled  bool   `yaml:"enabled"`
+	Index    string `yaml:"index"`    // Percolator index holding one query per city (default: "gopost_queries")
+	Register bool   `yaml:"register"` // Store this deployment's city queries at startup, replacing the saved ones
+}
+
+// SearchTemplateConfig searches with a mustache search template stored in Elasticsearch
+// instead of the keyword search gopost builds, so the query can be tuned on the cluster
+// without a redeploy. Cities with a query_template keep it.
+type SearchTemplateConfig struct {
+	Enabled bool   `yaml:"enabled"`
+	ID      string `yaml:"id"`   // Stored template ID (default: "gopost-articles")
+	File    string `yaml:"file"` // Mustache source stored by "gopost search-template push"
+}
+
+// AuditConfig indexes a copy of every posted article into an Elasticsearch index: the
+// JSON:API document sent to Drupal, the node UUID, and timestamps, so posts can be traced in
+// Kibana next to the source articles.
+type AuditConfig struct {
+	Enabled bool   `yaml:"enabled"`
+	Index   string `yaml:"index"` // Audit index, one document per article ID (default: "gopost_audit")
+}
+
+// CategoriesConfig tags posted articles with crime category taxonomy terms (e.g. violent
+// crime, property crime, court, police operations) in field_crime_categories, chosen by the
+// keywords each article mentions.
+type CategoriesConfig struct {
+	TermType string           `yaml:"term_type"` // JSON:API type of category terms (default: taxonomy_term--crime_categories)
+	Refresh  time.Duration    `yaml:"refresh"`   // How often term names are re-resolved to UUIDs (default: 1h)
+	Mappings []CategoryConfig `yaml:"mappings"`
+}
+
+// CategoryConfig maps a keyword group to a category term. An article mentioning any of
+// the keywords (case-insensitive, title or body) is tagged with the term.
+type CategoryConfig struct {
+	Term     string   `yaml:"term"`    // Term name, resolved to its UUID like city group_name
+	TermID   string   `yaml:"term_id"` // Term UUID, instead of term
+	Keywords []string `yaml:"keywords"`
 }
 
 type SourcesConfig struct {
 	URL     string        `yaml:"url"`      // Sources service API URL (e.g., "http://localhost:8080")
 	Timeout time.Duration `yaml:"timeout"`  // Request timeout (default: 5s)
 	Enabled bool          `yaml:"enabled"`  // Enable fetching cities from sources service
+
+	Outlets         map[string]OutletConfig `yaml:"outlets"`          // Attribution per source identifier or domain
+	TermType        string                  `yaml:"term_type"`        // JSON:API type of outlet taxonomy terms (default: taxonomy_term--sources)
+	AttributionBody bool                    `yaml:"attribution_body"` // Append a "Source: ..." line to posted bodies
+}
+
+// OutletConfig describes how a news outlet is credited on posted articles.
+type OutletConfig struct {
+	Name     string   `yaml:"name"`     // Display name, e.g. "Sudbury.com"
+	Homepage string   `yaml:"homepage"` // Outlet homepage URL
+	Terms    []string `yaml:"terms"`    // Drupal taxonomy term UUIDs to tag posts with
+	Identity string   `yaml:"identity"` // Replaces the city's identity for this outlet's articles, e.g. "field:guid"
+}
+
+// OutboxConfig controls the Redis work queue that decouples discovery from posting.
+// When enabled, each run only enqueues candidate articles; a pool of posting workers
+// drains the queue continuously with retries.
+type OutboxConfig struct {
+	Enabled      bool          `yaml:"enabled"`       // Enqueue candidates instead of posting inline
+	Backend      string        `yaml:"backend"`       // "list" (single replica) or "stream" (consumer group shared by replicas); default: list
+	Workers      int           `yaml:"workers"`       // Number of posting workers (default: 2)
+	MaxAttempts  int           `yaml:"max_attempts"`  // Posting attempts before an item is dead-lettered (default: 5)
+	ConsumerName string        `yaml:"consumer_name"` // Stream consumer name, unique per replica (default: hostname-pid)
+	ClaimIdle    time.Duration `yaml:"claim_idle"`    // In-flight items idle this long are taken back from crashed workers or replicas (default: 5m)
+
+	RetryBackoff    time.Duration `yaml:"retry_backoff"`     // Delay before the first retry of a failed post, doubled per attempt (default: 30s)
+	MaxRetryBackoff time.Duration `yaml:"max_retry_backoff"` // Cap on the retry delay (default: 15m)
+}
+
+// ChaosConfig injects failures and latency into backends so retry and queue behavior can
+// be verified in staging. Never enable it in production.
+type ChaosConfig struct {
+	Enabled       bool               `yaml:"enabled"`
+	Seed          uint64             `yaml:"seed"` // Fixed seed for a reproducible failure sequence (default: random)
+	Elasticsearch ChaosBackendConfig `yaml:"elasticsearch"`
+	Drupal        ChaosBackendConfig `yaml:"drupal"`
+	Redis         ChaosBackendConfig `yaml:"redis"` // Dedup tracker and outbox queue
+}
+
+// ChaosBackendConfig sets the injected faults for one backend.
+type ChaosBackendConfig struct {
+	FailureRate float64       `yaml:"failure_rate"` // Probability (0-1) that a call fails
+	Latency     time.Duration `yaml:"latency"`      // Delay added to every call
+	Jitter      time.Duration `yaml:"jitter"`       // Extra random delay of up to this much
+}
+
+// AdminConfig controls the admin HTTP server serving /status.
+type AdminConfig struct {
+	Addr string `yaml:"addr"` // Listen address, e.g. ":8080"; empty disables the server
 }
 
+// HistoryConfig controls persistence of run summaries in Redis for trend reporting.
+type HistoryConfig struct {
+	Enabled   bool          `yaml:"enabled"`   // Save a summary of every run
+	Retention time.Duration `yaml:"retention"` // How long run summaries are kept (default: 720h)
+}
+
+// TimelineConfig records when each article was published, found, classified, queued,
+// posted, and marked, in a Redis hash per article, for "gopost trace <article_id>".
+type TimelineConfig struct {
+	Enabled bool          `yaml:"enabled"`
+	TTL     time.Duration `yaml:"ttl"` // How long a timeline is kept after its last event (default: 720h)
+}
+
+// Consistency policies for posted articles whose Drupal node was deleted.
+const (
+	ConsistencyPolicyRecord = "record" // Keep the article marked as posted and record the deletion
+	ConsistencyPolicyClear  = "clear"  // Clear the article's dedup markers so a later run can post it again
+)
+
+// ConsistencyConfig controls the periodic check that articles recorded as posted still
+// have their node in Drupal, catching nodes deleted by editors.
+type ConsistencyConfig struct {
+	Enabled    bool          `yaml:"enabled"`
+	Interval   time.Duration `yaml:"interval"`    // Time between checks (default: 1h)
+	SampleSize int           `yaml:"sample_size"` // Posted articles checked per pass (default: 20)
+	Window     time.Duration `yaml:"window"`      // Only articles posted within this long are checked (default: 168h)
+	Policy     string        `yaml:"policy"`      // ConsistencyPolicyRecord (default) or ConsistencyPolicyClear
+}
+
+// SuppressionConfig controls the permanent list of articles that are never posted again,
+// filled by consistency checks and by a Drupal webhook calling the admin /suppress endpoint
+// when editors delete a node.
+type SuppressionConfig struct {
+	Enabled      bool   `yaml:"enabled"`
+	WebhookToken string `yaml:"webhook_token"` // Bearer token required by /suppress; empty accepts any caller
+}
+
+// ModerationConfig controls the admin /moderation endpoint, which a Drupal webhook calls
+// when editors publish, reject, edit, or delete posted nodes.
+type ModerationConfig struct {
+	Enabled      bool   `yaml:"enabled"`
+	WebhookToken string `yaml:"webhook_token"` // Bearer token required by /moderation; empty accepts any caller
+}
+
+// CanaryConfig trials a candidate keyword and source configuration in a few canary
+// cities before it applies everywhere. While the trial runs, each canary city is searched
+// and filtered with the candidate lists, and the articles the current lists would have
+// passed are counted alongside. After Runs runs the trial passes if the candidate volume
+// stayed within MaxDeviation of the baseline, and fails otherwise.
+type CanaryConfig struct {
+	Enabled        bool     `yaml:"enabled"`
+	Cities         []string `yaml:"cities"`          // Cities the candidate lists apply to during the trial
+	Runs           int      `yaml:"runs"`            // Runs the trial lasts (default: 12)
+	MaxDeviation   float64  `yaml:"max_deviation"`   // Largest relative change in volume that passes, e.g. 0.5 for ±50% (default: 0.5)
+	Promote        bool     `yaml:"promote"`         // Apply the candidate lists to every city once the trial passes
+	CrimeKeywords  []string `yaml:"crime_keywords"`  // Candidate service.crime_keywords; empty keeps the current list
+	AllowedSources []string `yaml:"allowed_sources"` // Candidate service.allowed_sources; empty keeps the current list
+	BlockedSources []string `yaml:"blocked_sources"` // Candidate service.blocked_sources; empty keeps the current list
+}
+
+// SmokeConfig controls the end-to-end check run by "gopost smoke".
+type SmokeConfig struct {
+	GroupID string `yaml:"group_id"` // Drupal group UUID the throwaway test node is posted to
+}
+
+// MetricsConfig controls metrics beyond the admin /metrics endpoint.
+type MetricsConfig struct {
+	Push MetricsPushConfig `yaml:"push"`
+}
+
+// MetricsPushConfig pushes the /metrics gauges at the end of each "gopost once" run, for
+// cron deployments that nothing scrapes. Set pushgateway, statsd, or both.
+type MetricsPushConfig struct {
+	Pushgateway string        `yaml:"pushgateway"` // Pushgateway base URL, e.g. "http://pushgateway:9091"
+	StatsD      string        `yaml:"statsd"`      // StatsD host:port (UDP); labels are sent as DogStatsD tags
+	Job         string        `yaml:"job"`         // job label and Pushgateway grouping key (default: gopost)
+	Instance    string        `yaml:"instance"`    // instance label and Pushgateway grouping key (default: hostname)
+	Timeout     time.Duration `yaml:"timeout"`     // Pushgateway request timeout (default: 10s)
+}
+
+// MonitoringConfig pings a dead man's switch monitor such as healthchecks.io: <ping_url>/start
+// when a run starts, <ping_url> when it succeeds, and <ping_url>/fail when a city's search
+// or any post fails.
+type MonitoringConfig struct {
+	PingURL     string        `yaml:"ping_url"`     // Check URL, e.g. "https://hc-ping.com/<uuid>"
+	PingTimeout time.Duration `yaml:"ping_timeout"` // Timeout of each ping (default: 10s)
+}
+
+// TranslationConfig selects the machine translation API used for cities with translate_to,
+// e.g. to post English wire stories to a French city group. The title, body, intro,
+// description, and Open Graph text are translated; translations are cached in Redis.
+type TranslationConfig struct {
+	Provider string        `yaml:"provider"`  // "deepl", "google", or "libretranslate"
+	URL      string        `yaml:"url"`       // API base URL, e.g. a self-hosted LibreTranslate (default: the provider's public API)
+	APIKey   string        `yaml:"api_key"`   // Required for DeepL and Google
+	Timeout  time.Duration `yaml:"timeout"`   // Timeout of each translation request (default: 30s)
+	CacheTTL time.Duration `yaml:"cache_ttl"` // How long translations are cached in Redis (default: 720h)
+}
+
+// Translation providers
+const (
+	TranslationProviderDeepL          = "deepl"
+	TranslationProviderGoogle         = "google"
+	TranslationProviderLibreTranslate = "libretranslate"
+)
+
+// LoggingConfig controls log output beyond the level and format selected by debug.
+type LoggingConfig struct {
+	Output   LogOutputConfig   `yaml:"output"`
+	GELF     LogGELFConfig     `yaml:"gelf"` // Also ship entries to Graylog or Logstash
+	Sampling LogSamplingConfig `yaml:"sampling"`
+}
+
+// LogOutputConfig selects where log entries are written. Syslog receives RFC 5424 messages
+// carrying each entry as JSON; journald receives each log field as a journal field.
+type LogOutputConfig struct {
+	Type     string `yaml:"type"`     // stderr (default), stdout, file, syslog, or journald
+	Path     string `yaml:"path"`     // file: log file, appended to
+	Network  string `yaml:"network"`  // syslog: udp, tcp, or unix; empty uses the local syslog socket
+	Address  string `yaml:"address"`  // syslog: host:port or socket path; journald: socket path (default: /run/systemd/journal/socket)
+	Facility string `yaml:"facility"` // syslog: facility name, e.g. local0 (default: daemon)
+	Tag      string `yaml:"tag"`      // syslog APP-NAME and journald SYSLOG_IDENTIFIER (default: gopost)
+}
+
+// LogGELFConfig ships log entries to a Graylog or Logstash GELF input in addition to the
+// regular output, so no log shipper sidecar is needed.
+type LogGELFConfig struct {
+	Enabled     bool   `yaml:"enabled"`
+	Network     string `yaml:"network"`     // udp (default) or tcp
+	Address     string `yaml:"address"`     // GELF input host:port, e.g. "graylog:12201"
+	Compression string `yaml:"compression"` // udp: gzip (default), zlib, or none; tcp is never compressed
+	Host        string `yaml:"host"`        // Message host field (default: hostname)
+}
+
+// Log output types
+const (
+	LogOutputStderr   = "stderr"
+	LogOutputStdout   = "stdout"
+	LogOutputFile     = "file"
+	LogOutputSyslog   = "syslog"
+	LogOutputJournald = "journald"
+)
+
+// LogOutputs lists the valid logging.output.type values.
+var LogOutputs = []string{LogOutputStderr, LogOutputStdout, LogOutputFile, LogOutputSyslog, LogOutputJournald}
+
+// LogSamplingConfig suppresses repeats of the same log message, such as the per-article
+// Redis errors logged during an outage. Each tick, the first initial entries with a given
+// level and message are logged, then every thereafter-th; the number suppressed is logged
+// per message every summary_interval. When disabled, production mode keeps zap's default
+// sampling (100 per second, then every 100th) without summaries.
+type LogSamplingConfig struct {
+	Enabled         bool          `yaml:"enabled"`
+	Initial         int           `yaml:"initial"`          // Entries per message logged in full each tick (default: 10)
+	Thereafter      int           `yaml:"thereafter"`       // Then log every Nth entry; 0 suppresses the rest of the tick
+	Tick            time.Duration `yaml:"tick"`             // Sampling window (default: 1s)
+	SummaryInterval time.Duration `yaml:"summary_interval"` // How often suppressed counts are logged (default: 1m)
+}
+
+// HookConfig runs a shell command or HTTP callout at a lifecycle point. The event is passed
+// as JSON: on stdin to the command (run with sh -c, GOPOST_EVENT set to the event name), or
+// as the body of a POST to the URL. Hook failures are logged and never stop posting.
+type HookConfig struct {
+	Event   string            `yaml:"event"`   // pre_post, post_success, post_failure, or run_complete
+	Command string            `yaml:"command"` // Shell command; set command or url, not both
+	URL     string            `yaml:"url"`     // HTTP(S) endpoint; non-2xx responses are failures
+	Headers map[string]string `yaml:"headers"` // Extra request headers for url, e.g. Authorization
+	Timeout time.Duration     `yaml:"timeout"` // How long the hook may run (default: 10s)
+}
+
+// Hook events, the lifecycle points hooks run at.
+const (
+	HookPrePost       = "pre_post"       // An article is about to be posted
+	HookPostSuccess   = "post_success"   // An article was posted
+	HookPostFailure   = "post_failure"   // Posting an article failed
+	HookRunComplete   = "run_complete"   // A run finished; the payload holds the run report
+	HookCanaryVerdict = "canary_verdict" // A canary trial passed or failed; the payload holds the trial
+)
+
+// HookEvents lists the valid hook event values.
+var HookEvents = []string{HookPrePost, HookPostSuccess, HookPostFailure, HookRunComplete, HookCanaryVerdict}
+
+// GRPCConfig controls the admin gRPC server. Setting cert_file and key_file serves TLS;
+// adding client_ca_file also requires client certificates signed by that CA (mTLS).
+type GRPCConfig struct {
+	Addr         string `yaml:"addr"`           // Listen address, e.g. ":9090"; empty disables the server
+	CertFile     string `yaml:"cert_file"`      // Server certificate (PEM)
+	KeyFile      string `yaml:"key_file"`       // Server private key (PEM)
+	ClientCAFile string `yaml:"client_ca_file"` // CA bundle (PEM) for verifying client certificates
+}
+
+// Outbox backends
+const (
+	OutboxBackendList   = "list"
+	OutboxBackendStream = "stream"
+)
+
 // Validate checks if the configuration is valid and returns an error if not.
+// In multi-tenant mode each tenant's pipeline is validated as a standalone config.
 func (c *Config) Validate() error {
-	if c.Elasticsearch.URL == "" {
-		return errors.New("elasticsearch.url is required")
+	if (c.GRPC.CertFile == "") != (c.GRPC.KeyFile == "") {
+		return errors.New("grpc.cert_file and grpc.key_file must be set together")
+	}
+	if c.GRPC.ClientCAFile != "" && c.GRPC.CertFile == "" {
+		return errors.New("grpc.client_ca_file requires grpc.cert_file and grpc.key_file")
+	}
+	if err := validateHooks(c.Hooks); err != nil {
+		return err
+	}
+	if err := validateLogOutput(c.Logging.Output); err != nil {
+		return err
+	}
+	if err := validateLogGELF(c.Logging.GELF); err != nil {
+		return err
+	}
+	if gateway := c.Metrics.Push.Pushgateway; gateway != "" {
+		if u, err := url.Parse(gateway); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("metrics.push.pushgateway must be an absolute http(s) URL, got %q", gateway)
+		}
+	}
+	if ping := c.Monitoring.PingURL; ping != "" {
+		if u, err := url.Parse(ping); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("monitoring.ping_url must be an absolute http(s) URL, got %q", ping)
+		}
+	}
+	if sampling := c.Logging.Sampling; sampling.Initial < 0 || sampling.Thereafter < 0 || sampling.Tick < 0 || sampling.SummaryInterval < 0 {
+		return errors.New("logging.sampling.initial, thereafter, tick, and summary_interval must not be negative")
+	}
+	if len(c.Tenants) == 0 {
+		return c.validatePipeline()
+	}
+
+	if c.Sources.Enabled {
+		return errors.New("sources.enabled is not supported with tenants")
+	}
+	names := make(map[string]bool, len(c.Tenants))
+	redisTargets := make(map[string]string, len(c.Tenants))
+	for i, tenant := range c.Tenants {
+		if tenant.Name == "" {
+			return fmt.Errorf("tenants[%d].name is required", i)
+		}
+		if names[tenant.Name] {
+			return fmt.Errorf("tenants[%d].name %q is not unique", i, tenant.Name)
+		}
+		names[tenant.Name] = true
+
+		// Tenants sharing a Redis database and key prefix would share dedup keys and queues
+		target := fmt.Sprintf("%s/%d/%s", tenant.Redis.URL, tenant.Redis.DB, tenant.Redis.KeyPrefix)
+		if other, ok := redisTargets[target]; ok {
+			return fmt.Errorf("tenants %q and %q use the same Redis database and key prefix", other, tenant.Name)
+		}
+		redisTargets[target] = tenant.Name
+
+		if err := c.ForTenant(tenant).validatePipeline(); err != nil {
+			return fmt.Errorf("tenants[%d] (%s): %w", i, tenant.Name, err)
+		}
+	}
+	return nil
+}
+
+// validateKeywords compiles the regular expression keywords (/pattern/ or /pattern/i).
+func (c *Config) validateKeywords() error {
+	lists := map[string][]string{
+		"service.crime_keywords": c.Service.CrimeKeywords,
+		"canary.crime_keywords":  c.Canary.CrimeKeywords,
+	}
+	for i, mapping := range c.Categories.Mappings {
+		lists[fmt.Sprintf("categories.mappings[%d].keywords", i)] = mapping.Keywords
+	}
+	for _, name := range slices.Sorted(maps.Keys(lists)) {
+		for _, keyword := range lists[name] {
+			if _, err := pipeline.ParseKeyword(keyword); err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// maxSearchSize is Elasticsearch's default index.max_result_window, the most hits one
+// search can return.
+const maxSearchSize = 10000
+
+// minKeywordLength is the length below which a keyword matched as a substring is likely to
+// match inside unrelated words.
+const minKeywordLength = 3
+
+// Warnings returns problems in the configuration that do not stop gopost from running
+// but likely make it post the wrong articles, such as keywords that match too much.
+func (c *Config) Warnings() []string {
+	warnings := keywordWarnings("service.crime_keywords", c.Service.CrimeKeywords, c.Service.KeywordMatch)
+	warnings = append(warnings, keywordWarnings("canary.crime_keywords", c.Canary.CrimeKeywords, c.Service.KeywordMatch)...)
+	for i, mapping := range c.Categories.Mappings {
+		name := fmt.Sprintf("categories.mappings[%d].keywords", i)
+		warnings = append(warnings, keywordWarnings(name, mapping.Keywords, c.Service.KeywordMatch)...)
+	}
+	return warnings
+}
+
+// keywordWarnings flags keywords that are duplicated (ignoring case), and plain keywords
+// that contain regular expression metacharacters, which are matched literally, or that are
+// so short they match inside other words when matched as substrings.
+func keywordWarnings(name string, keywords []string, match string) []string {
+	var warnings []string
+	seen := make(map[string]bool, len(keywords))
+	for _, keyword := range keywords {
+		lowered := strings.ToLower(strings.TrimSpace(keyword))
+		if seen[lowered] {
+			warnings = append(warnings, fmt.Sprintf("%s: %q is listed more than once", name, keyword))
+			continue
+		}
+		seen[lowered] = true
+		if pattern, _ := pipeline.ParseKeyword(keyword); pattern != nil {
+			continue
+		}
+		if match == KeywordMatchSubstring && len([]rune(lowered)) < minKeywordLength {
+			warnings = append(warnings, fmt.Sprintf("%s: %q is shorter than %d characters and matches inside other words; "+
+				"set service.keyword_match: word or use a longer keyword", name, keyword, minKeywordLength))
+		}
+		if strings.ContainsAny(keyword, `\+*?()|[]{}^$`) {
+			warnings = append(warnings, fmt.Sprintf("%s: %q contains regular expression characters, which are matched literally; "+
+				"write it as /pattern/ to match a regular expression", name, keyword))
+		}
+	}
+	return warnings
+}
+
+// validateFieldMap checks that every field map entry names a field, a known type, and
+// known article properties.
+func validateFieldMap(mappings []FieldMapConfig) error {
+	types := []string{FieldTypeString, FieldTypeInteger, FieldTypeText, FieldTypeLink, FieldTypeDateTime, FieldTypeDate, FieldTypeMetatag}
+	for i, mapping := range mappings {
+		if mapping.Field == "" {
+			return fmt.Errorf("service.field_map[%d].field is required", i)
+		}
+		if mapping.Source == "" && mapping.Type != FieldTypeMetatag {
+			return fmt.Errorf("service.field_map[%d].source is required", i)
+		}
+		if !slices.Contains(types, mapping.Type) {
+			return fmt.Errorf("service.field_map[%d].type must be one of %s, got %q", i, strings.Join(types, ", "), mapping.Type)
+		}
+		sources := []string{mapping.Source, mapping.Summary, mapping.Title}
+		for _, tag := range slices.Sorted(maps.Keys(mapping.Tags)) {
+			sources = append(sources, mapping.Tags[tag])
+		}
+		if name, ok := strings.CutPrefix(mapping.Source, FieldTransformerPrefix); ok {
+			if name == "" {
+				return fmt.Errorf("service.field_map[%d] (%s): source %q names no field transformer", i, mapping.Field, mapping.Source)
+			}
+			if mapping.Type == FieldTypeMetatag {
+				return fmt.Errorf("service.field_map[%d] (%s): type metatag takes its values from tags, not a field transformer", i, mapping.Field)
+			}
+			sources = sources[1:]
+		}
+		for _, source := range sources {
+			if source != "" && !slices.Contains(FieldMapSources, source) {
+				return fmt.Errorf("service.field_map[%d] (%s): unknown article property %q", i, mapping.Field, source)
+			}
+		}
+	}
+	return nil
+}
+
+// validateLogOutput checks that the log output type is known and has the settings it
+// requires.
+func validateLogOutput(output LogOutputConfig) error {
+	if output.Type != "" && !slices.Contains(LogOutputs, output.Type) {
+		return fmt.Errorf("logging.output.type must be one of %s, got %q", strings.Join(LogOutputs, ", "), output.Type)
+	}
+	if output.Type == LogOutputFile && output.Path == "" {
+		return errors.New("logging.output.path is required for file output")
+	}
+	if output.Type != LogOutputSyslog {
+		return nil
+	}
+	if !slices.Contains([]string{"", "udp", "tcp", "unix"}, output.Network) {
+		return fmt.Errorf("logging.output.network must be udp, tcp, or unix, got %q", output.Network)
+	}
+	if output.Network != "" && output.Address == "" {
+		return fmt.Errorf("logging.output.address is required for %s syslog", output.Network)
+	}
+	return nil
+}
+
+// validateLogGELF checks that an enabled GELF sink has an address and a known network
+// and compression.
+func validateLogGELF(gelf LogGELFConfig) error {
+	if !gelf.Enabled {
+		return nil
+	}
+	if gelf.Address == "" {
+		return errors.New("logging.gelf.address is required when logging.gelf is enabled")
+	}
+	if !slices.Contains([]string{"", "udp", "tcp"}, gelf.Network) {
+		return fmt.Errorf("logging.gelf.network must be udp or tcp, got %q", gelf.Network)
+	}
+	if !slices.Contains([]string{"", "gzip", "zlib", "none"}, gelf.Compression) {
+		return fmt.Errorf("logging.gelf.compression must be gzip, zlib, or none, got %q", gelf.Compression)
+	}
+	return nil
+}
+
+// validateHooks checks that every hook names a known event and exactly one of a command
+// or an absolute HTTP(S) URL.
+func validateHooks(hooks []HookConfig) error {
+	for i, hook := range hooks {
+		if !slices.Contains(HookEvents, hook.Event) {
+			return fmt.Errorf("hooks[%d].event must be one of %s, got %q", i, strings.Join(HookEvents, ", "), hook.Event)
+		}
+		if (hook.Command == "") == (hook.URL == "") {
+			return fmt.Errorf("hooks[%d] (%s): set exactly one of command and url", i, hook.Event)
+		}
+		if hook.URL != "" {
+			u, err := url.Parse(hook.URL)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return fmt.Errorf("hooks[%d] (%s): url must be an absolute http or https URL, got %q", i, hook.Event, hook.URL)
+			}
+		}
+		if hook.Timeout < 0 {
+			return fmt.Errorf("hooks[%d] (%s): timeout must be non-negative, got %s", i, hook.Event, hook.Timeout)
+		}
+	}
+	return nil
+}
+
+// ForTenant returns the standalone config of one tenant's pipeline: this config with the
+// tenant's backends and cities.
+func (c *Config) ForTenant(tenant TenantConfig) *Config {
+	tenantCfg := *c
+	tenantCfg.Elasticsearch = tenant.Elasticsearch
+	tenantCfg.Drupal = tenant.Drupal
+	tenantCfg.Redis = tenant.Redis
+	tenantCfg.Cities = tenant.Cities
+	tenantCfg.CityDiscovery = tenant.CityDiscovery
+	tenantCfg.Tenants = nil
+	if c.Service.MarkJournal != "" {
+		// Each tenant's marks go to Redis databases of its own, so each keeps its own journal
+		ext := filepath.Ext(c.Service.MarkJournal)
+		tenantCfg.Service.MarkJournal = strings.TrimSuffix(c.Service.MarkJournal, ext) + "." + tenant.Name + ext
+	}
+	return &tenantCfg
+}
+
+// validateClassify checks that the classified fields are known and that their weights can
+// reach min_score, so some article can match.
+func validateClassify(classify ClassifyConfig) error {
+	if len(classify.Fields) == 0 {
+		return nil
+	}
+	total := 0
+	for _, field := range slices.Sorted(maps.Keys(classify.Fields)) {
+		if !slices.Contains(pipeline.ClassifyFields, field) {
+			return fmt.Errorf("service.classify.fields: unknown field %q (want %s)", field, strings.Join(pipeline.ClassifyFields, ", "))
+		}
+		if classify.Fields[field] < 0 {
+			return fmt.Errorf("service.classify.fields.%s must not be negative, got %d", field, classify.Fields[field])
+		}
+		total += classify.Fields[field]
+	}
+	if minScore := max(classify.MinScore, 1); total < minScore {
+		return fmt.Errorf("service.classify.fields weights sum to %d, below min_score %d, so no article can match", total, minScore)
+	}
+	return nil
+}
+
+// validateWarmup checks the warm-up limits when warm-up is enabled.
+func validateWarmup(warmup WarmupConfig) error {
+	if !warmup.Enabled {
+		return nil
+	}
+	if warmup.Runs <= 0 {
+		return fmt.Errorf("service.warmup.runs must be positive, got %d", warmup.Runs)
+	}
+	if warmup.MaxPosts <= 0 {
+		return fmt.Errorf("service.warmup.max_posts must be positive, got %d", warmup.MaxPosts)
+	}
+	if !slices.Contains(PostOrders, warmup.Order) {
+		return fmt.Errorf("service.warmup.order must be one of %v, got %q", PostOrders, warmup.Order)
+	}
+	return nil
+}
+
+// validateVolumeAnomaly checks the rolling average and thresholds when volume anomaly
+// detection is enabled.
+func validateVolumeAnomaly(anomaly VolumeAnomalyConfig) error {
+	if !anomaly.Enabled {
+		return nil
+	}
+	if anomaly.Window <= 0 {
+		return fmt.Errorf("service.volume_anomaly.window must be positive, got %d", anomaly.Window)
+	}
+	if anomaly.MinRuns < 0 {
+		return fmt.Errorf("service.volume_anomaly.min_runs must not be negative, got %d", anomaly.MinRuns)
+	}
+	if anomaly.MinAverage <= 0 {
+		return fmt.Errorf("service.volume_anomaly.min_average must be positive, got %v", anomaly.MinAverage)
+	}
+	if anomaly.SpikeFactor <= 1 {
+		return fmt.Errorf("service.volume_anomaly.spike_factor must be greater than 1, got %v", anomaly.SpikeFactor)
+	}
+	return nil
+}
+
+// validateGroupQuota checks the quota limits, and the rollover time and overflow action
+// when quotas are enabled.
+func validateGroupQuota(quota GroupQuotaConfig) error {
+	if quota.DailyLimit < 0 {
+		return fmt.Errorf("service.group_quota.daily_limit must be non-negative, got %d", quota.DailyLimit)
+	}
+	for group, limit := range quota.Groups {
+		if limit < 0 {
+			return fmt.Errorf("service.group_quota.groups.%s must be non-negative, got %d", group, limit)
+		}
+	}
+	if quota.DailyLimit == 0 && len(quota.Groups) == 0 {
+		return nil
+	}
+	if _, err := time.Parse("15:04", quota.Rollover); err != nil {
+		return fmt.Errorf("service.group_quota.rollover must be HH:MM, got %q", quota.Rollover)
+	}
+	if quota.Overflow != QuotaOverflowDefer && quota.Overflow != QuotaOverflowDrop {
+		return fmt.Errorf("service.group_quota.overflow must be %q or %q, got %q", QuotaOverflowDefer, QuotaOverflowDrop, quota.Overflow)
+	}
+	return nil
+}
+
+// validateConsistency checks the check schedule and policy when the consistency check is
+// enabled.
+func validateConsistency(consistency ConsistencyConfig) error {
+	if !consistency.Enabled {
+		return nil
+	}
+	if consistency.Interval <= 0 {
+		return fmt.Errorf("consistency.interval must be positive, got %v", consistency.Interval)
+	}
+	if consistency.SampleSize <= 0 {
+		return fmt.Errorf("consistency.sample_size must be positive, got %d", consistency.SampleSize)
+	}
+	if consistency.Window <= 0 {
+		return fmt.Errorf("consistency.window must be positive, got %v", consistency.Window)
+	}
+	if consistency.Policy != ConsistencyPolicyRecord && consistency.Policy != ConsistencyPolicyClear {
+		return fmt.Errorf("consistency.policy must be %q or %q, got %q", ConsistencyPolicyRecord, ConsistencyPolicyClear, consistency.Policy)
+	}
+	return nil
+}
+
+// validateCanary checks the trial length, threshold, and candidate lists when a canary
+// trial is enabled.
+func validateCanary(canary CanaryConfig, percolator bool) error {
+	if !canary.Enabled {
+		return nil
+	}
+	if len(canary.Cities) == 0 {
+		return errors.New("canary.cities is required when canary.enabled is true")
+	}
+	if canary.Runs <= 0 {
+		return fmt.Errorf("canary.runs must be positive, got %d", canary.Runs)
+	}
+	if canary.MaxDeviation <= 0 {
+		return fmt.Errorf("canary.max_deviation must be positive, got %v", canary.MaxDeviation)
+	}
+	if len(canary.CrimeKeywords) == 0 && len(canary.AllowedSources) == 0 && len(canary.BlockedSources) == 0 {
+		return errors.New("canary requires at least one of crime_keywords, allowed_sources, or blocked_sources")
+	}
+	// Percolator queries are registered once for every city, so they cannot differ per city
+	if percolator && len(canary.CrimeKeywords) > 0 {
+		return errors.New("canary.crime_keywords is not supported with percolator.enabled")
+	}
+	return nil
+}
+
+// validateElasticsearch checks that the cluster and at most one kind of credentials are set.
+func validateElasticsearch(es ElasticsearchConfig) error {
+	switch {
+	case es.URL == "" && es.CloudID == "":
+		return errors.New("elasticsearch.url or elasticsearch.cloud_id is required")
+	case es.URL != "" && es.CloudID != "":
+		return errors.New("elasticsearch.url and elasticsearch.cloud_id are mutually exclusive")
+	}
+	if _, err := es.Endpoint(); err != nil {
+		return fmt.Errorf("elasticsearch.cloud_id: %w", err)
+	}
+	var auth []string
+	if es.Username != "" {
+		auth = append(auth, "username")
+	}
+	if es.APIKey != "" {
+		auth = append(auth, "api_key")
+	}
+	if es.ServiceToken != "" {
+		auth = append(auth, "service_token")
+	}
+	if len(auth) > 1 {
+		return fmt.Errorf("elasticsearch.%s are mutually exclusive", strings.Join(auth, " and "))
+	}
+	return nil
+}
+
+// validateRedis checks the Redis address, key prefix, TLS files, and pool sizes.
+func validateRedis(r RedisConfig) error {
+	if r.URL == "" {
+		return errors.New("redis.url is required")
+	}
+	// The prefix ends up in SCAN patterns, where these characters would match other keys
+	if strings.ContainsAny(r.KeyPrefix, `*?[]\`) {
+		return fmt.Errorf("redis.key_prefix must not contain *, ?, [, ], or \\, got %q", r.KeyPrefix)
+	}
+	if r.Username != "" && r.Password == "" {
+		return errors.New("redis.username requires redis.password")
+	}
+	if (r.TLS.CertFile == "") != (r.TLS.KeyFile == "") {
+		return errors.New("redis.tls.cert_file and redis.tls.key_file must be set together")
+	}
+	if !r.TLS.Enabled && (r.TLS.CAFile != "" || r.TLS.CertFile != "" || r.TLS.ServerName != "" || r.TLS.SkipVerify) {
+		return errors.New("redis.tls settings require redis.tls.enabled")
+	}
+	for _, setting := range []struct {
+		name  string
+		value int
+	}{{"size", r.Pool.Size}, {"min_idle", r.Pool.MinIdle}, {"max_idle", r.Pool.MaxIdle}} {
+		if setting.value < 0 {
+			return fmt.Errorf("redis.pool.%s must be non-negative, got %d", setting.name, setting.value)
+		}
+	}
+	for _, setting := range []struct {
+		name  string
+		value time.Duration
+	}{{"idle_timeout", r.Pool.IdleTimeout}, {"max_lifetime", r.Pool.MaxLifetime}, {"timeout", r.Pool.Timeout}} {
+		if setting.value < 0 {
+			return fmt.Errorf("redis.pool.%s must be non-negative, got %v", setting.name, setting.value)
+		}
+	}
+	if r.Pool.Size > 0 && r.Pool.MinIdle > r.Pool.Size {
+		return fmt.Errorf("redis.pool.min_idle (%d) must not exceed redis.pool.size (%d)", r.Pool.MinIdle, r.Pool.Size)
+	}
+	return nil
+}
+
+// validatePipeline checks the settings of a single pipeline.
+func (c *Config) validatePipeline() error {
+	if err := validateElasticsearch(c.Elasticsearch); err != nil {
+		return err
 	}
 	if c.Drupal.URL == "" {
 		return errors.New("drupal.url is required")
@@ -74,8 +1243,20 @@ func (c *Config) Validate() error {
 	if c.Drupal.Token == "" {
 		return errors.New("drupal.token is required")
 	}
-	if c.Redis.URL == "" {
-		return errors.New("redis.url is required")
+	if c.Drupal.RevisionLog != "" && c.Drupal.RevisionLog != RevisionLogNone {
+		if _, err := template.New("revision_log").Parse(c.Drupal.RevisionLog); err != nil {
+			return fmt.Errorf("drupal.revision_log: %w", err)
+		}
+	}
+	if err := validateRedis(c.Redis); err != nil {
+		return err
+	}
+	for _, backend := range []struct{ name, proxy string }{
+		{"elasticsearch", c.Elasticsearch.Proxy}, {"drupal", c.Drupal.Proxy}, {"redis", c.Redis.Proxy},
+	} {
+		if _, err := netproxy.Parse(backend.proxy); err != nil {
+			return fmt.Errorf("%s.proxy: %w", backend.name, err)
+		}
 	}
 	if c.Service.RateLimitRPS <= 0 {
 		return fmt.Errorf("service.rate_limit_rps must be positive, got %d", c.Service.RateLimitRPS)
@@ -83,12 +1264,183 @@ func (c *Config) Validate() error {
 	if c.Service.CheckInterval <= 0 {
 		return fmt.Errorf("service.check_interval must be positive, got %v", c.Service.CheckInterval)
 	}
+	if c.Service.CatchupHours < 0 {
+		return fmt.Errorf("service.catchup_hours must be non-negative, got %d", c.Service.CatchupHours)
+	}
 	if c.Service.DedupTTL < 0 {
 		return fmt.Errorf("service.dedup_ttl must be non-negative, got %v", c.Service.DedupTTL)
 	}
-	// Cities are required either from config or sources service
-	if !c.Sources.Enabled && len(c.Cities) == 0 {
-		return errors.New("at least one city must be configured or sources service must be enabled")
+	if _, err := time.LoadLocation(c.Service.Timezone); err != nil {
+		return fmt.Errorf("service.timezone %q: %w", c.Service.Timezone, err)
+	}
+	if c.Service.SearchSize < 0 || c.Service.SearchSize > maxSearchSize {
+		return fmt.Errorf("service.search_size must be between 1 and %d, got %d", maxSearchSize, c.Service.SearchSize)
+	}
+	if c.Service.MaxArticlesPerCityPerRun < 0 {
+		return fmt.Errorf("service.max_articles_per_city_per_run must not be negative, got %d", c.Service.MaxArticlesPerCityPerRun)
+	}
+	if c.Service.MaxBodyBytes < 0 {
+		return fmt.Errorf("service.max_body_bytes must not be negative, got %d", c.Service.MaxBodyBytes)
+	}
+	if c.Service.PostConcurrency < 0 || c.Service.PostConcurrency > maxPostConcurrency {
+		return fmt.Errorf("service.post_concurrency must be between 1 and %d, got %d", maxPostConcurrency, c.Service.PostConcurrency)
+	}
+	if c.Service.NearDuplicates.Enabled && (c.Service.NearDuplicates.Threshold <= 0 || c.Service.NearDuplicates.Threshold > 1) {
+		return fmt.Errorf("service.near_duplicates.threshold must be in (0, 1], got %v", c.Service.NearDuplicates.Threshold)
+	}
+	if c.Service.KeywordMatch != "" && !slices.Contains([]string{KeywordMatchSubstring, KeywordMatchWord, KeywordMatchStem}, c.Service.KeywordMatch) {
+		return fmt.Errorf("service.keyword_match must be %q, %q, or %q, got %q",
+			KeywordMatchSubstring, KeywordMatchWord, KeywordMatchStem, c.Service.KeywordMatch)
+	}
+	if err := c.validateKeywords(); err != nil {
+		return err
+	}
+	if c.Service.LinkCheck.Enabled && c.Service.LinkCheck.Action != LinkCheckActionSkip && c.Service.LinkCheck.Action != LinkCheckActionFlag {
+		return fmt.Errorf("service.link_check.action must be %q or %q, got %q", LinkCheckActionSkip, LinkCheckActionFlag, c.Service.LinkCheck.Action)
+	}
+	if c.Service.PathAlias != "" && !strings.HasPrefix(c.Service.PathAlias, "/") {
+		return fmt.Errorf("service.path_alias must start with /, got %q", c.Service.PathAlias)
+	}
+	if err := validateFieldMap(c.Service.FieldMap); err != nil {
+		return err
+	}
+	if c.Service.PostOrder != "" && !slices.Contains(PostOrders, c.Service.PostOrder) {
+		return fmt.Errorf("service.post_order must be one of %v, got %q", PostOrders, c.Service.PostOrder)
+	}
+	if len(c.Service.Severity.Weights) == 0 && (c.Service.PostOrder == PostOrderSeverity || c.Service.Warmup.Order == PostOrderSeverity) {
+		return errors.New("post order severity requires service.severity.weights")
+	}
+	if c.Service.Severity.MinScore > 0 && len(c.Service.Severity.Weights) == 0 {
+		return errors.New("service.severity.min_score requires service.severity.weights")
+	}
+	if c.Service.Promotion.PromoteSeverity < 0 || c.Service.Promotion.StickySeverity < 0 {
+		return fmt.Errorf("service.promotion.promote_severity and sticky_severity must not be negative, got %d and %d",
+			c.Service.Promotion.PromoteSeverity, c.Service.Promotion.StickySeverity)
+	}
+	if (c.Service.Promotion.PromoteSeverity > 0 || c.Service.Promotion.StickySeverity > 0) && len(c.Service.Severity.Weights) == 0 {
+		return errors.New("service.promotion.promote_severity and sticky_severity require service.severity.weights")
+	}
+	if err := validateClassify(c.Service.Classify); err != nil {
+		return err
+	}
+	if c.Service.Highlight.Enabled && (c.Service.Highlight.FragmentSize <= 0 || c.Service.Highlight.Fragments <= 0) {
+		return fmt.Errorf("service.highlight.fragment_size and fragments must be positive, got %d and %d",
+			c.Service.Highlight.FragmentSize, c.Service.Highlight.Fragments)
+	}
+	if err := validateWarmup(c.Service.Warmup); err != nil {
+		return err
+	}
+	if err := validateVolumeAnomaly(c.Service.VolumeAnomaly); err != nil {
+		return err
+	}
+	if c.Service.SearchCache.Enabled && c.Service.SearchCache.TTL <= 0 {
+		return fmt.Errorf("service.search_cache.ttl must be positive, got %v", c.Service.SearchCache.TTL)
+	}
+	if err := validateGroupQuota(c.Service.GroupQuota); err != nil {
+		return err
+	}
+	if c.Service.Maintenance.Backoff < 0 || c.Service.Maintenance.MaxBackoff < c.Service.Maintenance.Backoff {
+		return fmt.Errorf("service.maintenance.backoff must be non-negative and at most max_backoff, got %s and %s",
+			c.Service.Maintenance.Backoff, c.Service.Maintenance.MaxBackoff)
+	}
+	if c.Service.AuthFailure.Backoff < 0 || c.Service.AuthFailure.MaxBackoff < c.Service.AuthFailure.Backoff {
+		return fmt.Errorf("service.auth_failure.backoff must be non-negative and at most max_backoff, got %s and %s",
+			c.Service.AuthFailure.Backoff, c.Service.AuthFailure.MaxBackoff)
+	}
+	if c.Service.ClosedIndex.Backoff < 0 || c.Service.ClosedIndex.MaxBackoff < c.Service.ClosedIndex.Backoff {
+		return fmt.Errorf("service.closed_index.backoff must be non-negative and at most max_backoff, got %s and %s",
+			c.Service.ClosedIndex.Backoff, c.Service.ClosedIndex.MaxBackoff)
+	}
+	if c.Service.Health.MaxLag != 0 && c.Service.Health.MaxLag <= c.Service.CheckInterval {
+		return fmt.Errorf("service.health.max_lag must be greater than check_interval, got %s", c.Service.Health.MaxLag)
+	}
+	for i, mapping := range c.Categories.Mappings {
+		if mapping.Term == "" && mapping.TermID == "" {
+			return fmt.Errorf("categories.mappings[%d]: term or term_id is required", i)
+		}
+		if len(mapping.Keywords) == 0 {
+			return fmt.Errorf("categories.mappings[%d]: keywords are required", i)
+		}
+	}
+	if c.Service.DedupLease < 0 {
+		return fmt.Errorf("service.dedup_lease must be non-negative, got %v", c.Service.DedupLease)
+	}
+	if c.Service.RunTokenTTL < 0 {
+		return fmt.Errorf("service.run_token_ttl must be non-negative, got %v", c.Service.RunTokenTTL)
+	}
+	if c.Chaos.Enabled {
+		backends := []struct {
+			name   string
+			config ChaosBackendConfig
+		}{
+			{"elasticsearch", c.Chaos.Elasticsearch},
+			{"drupal", c.Chaos.Drupal},
+			{"redis", c.Chaos.Redis},
+		}
+		for _, backend := range backends {
+			if backend.config.FailureRate < 0 || backend.config.FailureRate > 1 {
+				return fmt.Errorf("chaos.%s.failure_rate must be in [0, 1], got %v", backend.name, backend.config.FailureRate)
+			}
+			if backend.config.Latency < 0 || backend.config.Jitter < 0 {
+				return fmt.Errorf("chaos.%s latency and jitter must be non-negative", backend.name)
+			}
+		}
+	}
+	if bloom := c.Service.DedupBloom; bloom.Enabled {
+		if bloom.Capacity <= 0 {
+			return fmt.Errorf("service.dedup_bloom.capacity must be positive, got %d", bloom.Capacity)
+		}
+		if bloom.FalsePositiveRate <= 0 || bloom.FalsePositiveRate >= 1 {
+			return fmt.Errorf("service.dedup_bloom.false_positive_rate must be in (0, 1), got %v", bloom.FalsePositiveRate)
+		}
+		if bloom.Refresh <= 0 {
+			return fmt.Errorf("service.dedup_bloom.refresh must be positive, got %v", bloom.Refresh)
+		}
+	}
+	if _, err := pipeline.ParseIdentity(c.Service.Identity, nil); err != nil {
+		return fmt.Errorf("service.identity: %w", err)
+	}
+	for key, outlet := range c.Sources.Outlets {
+		if _, err := pipeline.ParseIdentity(outlet.Identity, nil); err != nil {
+			return fmt.Errorf("sources.outlets[%s].identity: %w", key, err)
+		}
+	}
+	if c.History.Enabled && c.History.Retention <= 0 {
+		return fmt.Errorf("history.retention must be positive, got %v", c.History.Retention)
+	}
+	if c.Timeline.Enabled && c.Timeline.TTL <= 0 {
+		return fmt.Errorf("timeline.ttl must be positive, got %v", c.Timeline.TTL)
+	}
+	if err := validateConsistency(c.Consistency); err != nil {
+		return err
+	}
+	if err := validateCanary(c.Canary, c.Percolator.Enabled); err != nil {
+		return err
+	}
+	if c.Outbox.Enabled && c.Outbox.Workers <= 0 {
+		return fmt.Errorf("outbox.workers must be positive, got %d", c.Outbox.Workers)
+	}
+	if c.Outbox.Enabled && c.Outbox.Backend != OutboxBackendList && c.Outbox.Backend != OutboxBackendStream {
+		return fmt.Errorf("outbox.backend must be %q or %q, got %q", OutboxBackendList, OutboxBackendStream, c.Outbox.Backend)
+	}
+	if c.Outbox.Enabled && c.Outbox.MaxAttempts <= 0 {
+		return fmt.Errorf("outbox.max_attempts must be positive, got %d", c.Outbox.MaxAttempts)
+	}
+	if c.Outbox.Enabled && c.Outbox.ClaimIdle <= 0 {
+		return fmt.Errorf("outbox.claim_idle must be positive, got %v", c.Outbox.ClaimIdle)
+	}
+	if c.Outbox.RetryBackoff < 0 || c.Outbox.MaxRetryBackoff < 0 {
+		return errors.New("outbox.retry_backoff and max_retry_backoff must not be negative")
+	}
+	// Cities are required either from config, city discovery, or sources service
+	if !c.Sources.Enabled && !c.CityDiscovery.Enabled && len(c.Cities) == 0 {
+		return errors.New("at least one city must be configured or city discovery or sources service must be enabled")
+	}
+	if c.CityDiscovery.Enabled && strings.Count(c.CityDiscovery.Pattern, "*") != 1 {
+		return fmt.Errorf("city_discovery.pattern must contain a single \"*\", got %q", c.CityDiscovery.Pattern)
+	}
+	if c.CityDiscovery.Enabled && c.CityDiscovery.Interval <= 0 {
+		return fmt.Errorf("city_discovery.interval must be positive, got %v", c.CityDiscovery.Interval)
 	}
 	if c.Sources.Enabled && c.Sources.URL == "" {
 		return errors.New("sources.url is required when sources.enabled is true")
@@ -98,19 +1450,96 @@ func (c *Config) Validate() error {
 			return fmt.Errorf("cities[%d].name is required", i)
 		}
 		// group_id is optional - articles can be posted without a group
+		if city.GroupID != "" && city.GroupName != "" {
+			return fmt.Errorf("cities[%d] (%s): set group_id or group_name, not both", i, city.Name)
+		}
+		if city.Language != "" && !validLangcode(city.Language) {
+			return fmt.Errorf("cities[%d] (%s): language must be a Drupal langcode such as \"fr\" or \"pt-br\", got %q", i, city.Name, city.Language)
+		}
+		if strings.ContainsAny(city.LanguagePrefix, "/?#") {
+			return fmt.Errorf("cities[%d] (%s): language_prefix must be a single path segment, got %q", i, city.Name, city.LanguagePrefix)
+		}
+		if err := c.validateTranslation(city); err != nil {
+			return fmt.Errorf("cities[%d] (%s): %w", i, city.Name, err)
+		}
+		if city.PostConcurrency < 0 || city.PostConcurrency > maxPostConcurrency {
+			return fmt.Errorf("cities[%d] (%s): post_concurrency must be between 1 and %d, got %d", i, city.Name, maxPostConcurrency, city.PostConcurrency)
+		}
+		if _, err := pipeline.ParseIdentity(city.Identity, nil); err != nil {
+			return fmt.Errorf("cities[%d] (%s): identity: %w", i, city.Name, err)
+		}
 	}
 	return nil
 }
 
-func Load(path string) (*Config, error) {
-	data, err := os.ReadFile(path)
-	if err != nil {
-		return nil, fmt.Errorf("read config file: %w", err)
+// validateTranslation checks a city's translate_from and translate_to against the
+// translation settings.
+func (c *Config) validateTranslation(city CityConfig) error {
+	if city.TranslateTo == "" {
+		if city.TranslateFrom != "" {
+			return errors.New("translate_from requires translate_to")
+		}
+		return nil
+	}
+	for _, code := range []string{city.TranslateFrom, city.TranslateTo} {
+		if code != "" && !validLangcode(code) {
+			return fmt.Errorf("translate_from and translate_to must be langcodes such as \"en\" or \"fr\", got %q", code)
+		}
+	}
+	translation := c.Translation
+	switch translation.Provider {
+	case TranslationProviderDeepL, TranslationProviderGoogle:
+		if translation.APIKey == "" {
+			return fmt.Errorf("translate_to requires translation.api_key for provider %s", translation.Provider)
+		}
+	case TranslationProviderLibreTranslate:
+	default:
+		return fmt.Errorf("translate_to requires translation.provider %q, %q, or %q, got %q",
+			TranslationProviderDeepL, TranslationProviderGoogle, TranslationProviderLibreTranslate, translation.Provider)
+	}
+	if u := translation.URL; u != "" {
+		if parsed, err := url.Parse(u); err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return fmt.Errorf("translation.url must be an absolute http(s) URL, got %q", u)
+		}
 	}
+	return nil
+}
 
+// validLangcode reports whether code looks like a Drupal langcode: lower case letters and
+// digits in hyphen-separated parts, starting with a letter.
+func validLangcode(code string) bool {
+	if code == "" || code[0] < 'a' || code[0] > 'z' {
+		return false
+	}
+	for part := range strings.SplitSeq(code, "-") {
+		if part == "" {
+			return false
+		}
+		for _, c := range part {
+			if (c < 'a' || c > 'z') && (c < '0' || c > '9') {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Load reads the config file at path, applies GOPOST_* environment variables on top,
+// fills defaults, and validates the result. An empty path loads the configuration from
+// the environment alone.
+func Load(path string) (*Config, error) {
 	var cfg Config
-	if err := yaml.Unmarshal(data, &cfg); err != nil {
-		return nil, fmt.Errorf("parse config: %w", err)
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("read config file: %w", err)
+		}
+		if err := yaml.Unmarshal(data, &cfg); err != nil {
+			return nil, fmt.Errorf("parse config: %w", err)
+		}
+	}
+	if err := applyEnv(&cfg, os.Environ()); err != nil {
+		return nil, fmt.Errorf("parse environment: %w", err)
 	}
 
 	// Set defaults
@@ -126,6 +1555,9 @@ func Load(path string) (*Config, error) {
 	// For now, we'll allow 0 to mean "no filter" and only set default if truly unset
 	// Note: YAML parsing makes it hard to distinguish unset from 0, so we rely on
 	// the service logic to handle 0 as "no date filter"
+	if cfg.Service.CatchupHours == 0 {
+		cfg.Service.CatchupHours = 7 * 24
+	}
 	if len(cfg.Service.CrimeKeywords) == 0 {
 		cfg.Service.CrimeKeywords = []string{
 			"police", "arrest", "charged", "court",
@@ -134,19 +1566,263 @@ func Load(path string) (*Config, error) {
 			"investigation", "warrant", "sentence",
 		}
 	}
+	if cfg.Service.SearchSize == 0 {
+		cfg.Service.SearchSize = 100
+	}
+	if cfg.Service.MaxBodyBytes == 0 {
+		cfg.Service.MaxBodyBytes = 1 << 20
+	}
+	if cfg.Service.PostConcurrency == 0 {
+		cfg.Service.PostConcurrency = 1
+	}
+	if cfg.Service.KeywordMatch == "" {
+		cfg.Service.KeywordMatch = KeywordMatchWord
+	}
+	if cfg.Service.StemSubfield == "" {
+		cfg.Service.StemSubfield = "english"
+	}
 	if cfg.Service.ContentType == "" {
 		cfg.Service.ContentType = "node--article"
 	}
 	if cfg.Service.GroupType == "" {
 		cfg.Service.GroupType = "group--crime_news"
 	}
+	if cfg.Service.GroupRefresh == 0 {
+		cfg.Service.GroupRefresh = time.Hour
+	}
 	const hoursPerYear = 8760
 	if cfg.Service.DedupTTL == 0 {
 		cfg.Service.DedupTTL = hoursPerYear * time.Hour // 1 year default
 	}
+	if cfg.Service.Timezone == "" {
+		cfg.Service.Timezone = "UTC"
+	}
+	if cfg.Service.NearDuplicates.Threshold == 0 {
+		cfg.Service.NearDuplicates.Threshold = 0.9
+	}
+	if cfg.Service.LinkCheck.Action == "" {
+		cfg.Service.LinkCheck.Action = LinkCheckActionSkip
+	}
+	for i := range cfg.Service.FieldMap {
+		mapping := &cfg.Service.FieldMap[i]
+		if mapping.Type == "" {
+			mapping.Type = FieldTypeString
+		}
+		if mapping.Type == FieldTypeText && mapping.Format == "" {
+			mapping.Format = "basic_html"
+		}
+		if mapping.Type == FieldTypeMetatag && len(mapping.Tags) == 0 {
+			mapping.Tags = maps.Clone(DefaultMetatags)
+		}
+	}
+	if cfg.Service.LinkCheck.Timeout == 0 {
+		cfg.Service.LinkCheck.Timeout = 5 * time.Second
+	}
+	if cfg.Service.LinkCheck.PerDomainRPS == 0 {
+		cfg.Service.LinkCheck.PerDomainRPS = 1
+	}
+	if cfg.Service.LinkCheck.CacheTTL == 0 {
+		cfg.Service.LinkCheck.CacheTTL = time.Hour
+	}
+	if cfg.Service.NearDuplicates.Window == 0 {
+		cfg.Service.NearDuplicates.Window = 48 * time.Hour
+	}
+	if cfg.Service.DedupLease == 0 {
+		cfg.Service.DedupLease = 2 * time.Minute
+	}
+	if cfg.Service.RunTokenTTL == 0 {
+		cfg.Service.RunTokenTTL = 30 * time.Minute
+	}
+	if len(cfg.Service.Classify.Fields) == 0 {
+		cfg.Service.Classify.Fields = map[string]int{pipeline.ClassifyTitle: 1, pipeline.ClassifyBody: 1}
+	}
+	if cfg.Service.Classify.MinScore == 0 {
+		cfg.Service.Classify.MinScore = 1
+	}
+	if cfg.Service.Highlight.FragmentSize == 0 {
+		cfg.Service.Highlight.FragmentSize = 150
+	}
+	if cfg.Service.Highlight.Fragments == 0 {
+		cfg.Service.Highlight.Fragments = 3
+	}
+	if cfg.Service.DedupBloom.Capacity == 0 {
+		cfg.Service.DedupBloom.Capacity = 10_000_000
+	}
+	if cfg.Service.DedupBloom.FalsePositiveRate == 0 {
+		cfg.Service.DedupBloom.FalsePositiveRate = 0.01
+	}
+	if cfg.Service.DedupBloom.Refresh == 0 {
+		cfg.Service.DedupBloom.Refresh = time.Minute
+	}
+	if cfg.Service.Identity == "" {
+		cfg.Service.Identity = pipeline.IdentityID
+	}
+	if cfg.Service.Warmup.Runs == 0 {
+		cfg.Service.Warmup.Runs = 3
+	}
+	if cfg.Service.Warmup.MaxPosts == 0 {
+		cfg.Service.Warmup.MaxPosts = 5
+	}
+	if cfg.Service.PostOrder == "" {
+		cfg.Service.PostOrder = PostOrderNewestFirst
+	}
+	if cfg.Service.Warmup.Order == "" {
+		cfg.Service.Warmup.Order = cfg.Service.PostOrder
+	}
+	if cfg.Service.GroupQuota.Rollover == "" {
+		cfg.Service.GroupQuota.Rollover = "00:00"
+	}
+	if cfg.Service.GroupQuota.Overflow == "" {
+		cfg.Service.GroupQuota.Overflow = QuotaOverflowDefer
+	}
+	if cfg.Service.Maintenance.Backoff == 0 {
+		cfg.Service.Maintenance.Backoff = time.Minute
+	}
+	if cfg.Service.Maintenance.MaxBackoff == 0 {
+		cfg.Service.Maintenance.MaxBackoff = 30 * time.Minute
+	}
+	if cfg.Service.AuthFailure.Backoff == 0 {
+		cfg.Service.AuthFailure.Backoff = 5 * time.Minute
+	}
+	if cfg.Service.AuthFailure.MaxBackoff == 0 {
+		cfg.Service.AuthFailure.MaxBackoff = time.Hour
+	}
+	if cfg.Service.ClosedIndex.Backoff == 0 {
+		cfg.Service.ClosedIndex.Backoff = 15 * time.Minute
+	}
+	if cfg.Service.ClosedIndex.MaxBackoff == 0 {
+		cfg.Service.ClosedIndex.MaxBackoff = 6 * time.Hour
+	}
+	if cfg.Service.Health.MaxLag == 0 {
+		cfg.Service.Health.MaxLag = 12 * cfg.Service.CheckInterval
+	}
+	if cfg.Service.SearchCache.TTL == 0 {
+		cfg.Service.SearchCache.TTL = time.Minute
+	}
+	if cfg.Service.VolumeAnomaly.Window == 0 {
+		cfg.Service.VolumeAnomaly.Window = 48
+	}
+	if cfg.Service.VolumeAnomaly.MinRuns == 0 {
+		cfg.Service.VolumeAnomaly.MinRuns = 12
+	}
+	if cfg.Service.VolumeAnomaly.MinAverage == 0 {
+		cfg.Service.VolumeAnomaly.MinAverage = 3
+	}
+	if cfg.Service.VolumeAnomaly.SpikeFactor == 0 {
+		cfg.Service.VolumeAnomaly.SpikeFactor = 10
+	}
+	if cfg.Service.SyncLag.WarnAfter == 0 {
+		cfg.Service.SyncLag.WarnAfter = 6 * time.Hour
+	}
+	setCityDiscoveryDefaults(&cfg.CityDiscovery)
+	if cfg.Percolator.Index == "" {
+		cfg.Percolator.Index = "gopost_queries"
+	}
+	if cfg.SearchTemplate.ID == "" {
+		cfg.SearchTemplate.ID = "gopost-articles"
+	}
+	if cfg.Audit.Index == "" {
+		cfg.Audit.Index = "gopost_audit"
+	}
+	if cfg.Categories.TermType == "" {
+		cfg.Categories.TermType = "taxonomy_term--crime_categories"
+	}
+	if cfg.Categories.Refresh == 0 {
+		cfg.Categories.Refresh = time.Hour
+	}
+	if cfg.Redis.KeyPrefix == "" {
+		cfg.Redis.KeyPrefix = DefaultKeyPrefix
+	}
+	for i := range cfg.Tenants {
+		setCityDiscoveryDefaults(&cfg.Tenants[i].CityDiscovery)
+		if cfg.Tenants[i].Redis.KeyPrefix == "" {
+			cfg.Tenants[i].Redis.KeyPrefix = DefaultKeyPrefix
+		}
+	}
+	if cfg.Logging.Sampling.Initial == 0 {
+		cfg.Logging.Sampling.Initial = 10
+	}
+	if cfg.Logging.Sampling.Tick == 0 {
+		cfg.Logging.Sampling.Tick = time.Second
+	}
+	if cfg.Logging.Sampling.SummaryInterval == 0 {
+		cfg.Logging.Sampling.SummaryInterval = time.Minute
+	}
+	for i := range cfg.Hooks {
+		if cfg.Hooks[i].Timeout == 0 {
+			cfg.Hooks[i].Timeout = 10 * time.Second
+		}
+	}
 	if cfg.Sources.Timeout == 0 {
 		cfg.Sources.Timeout = 5 * time.Second
 	}
+	if cfg.Sources.TermType == "" {
+		cfg.Sources.TermType = "taxonomy_term--sources"
+	}
+	if cfg.History.Retention == 0 {
+		cfg.History.Retention = 30 * 24 * time.Hour
+	}
+	if cfg.Timeline.TTL == 0 {
+		cfg.Timeline.TTL = 30 * 24 * time.Hour
+	}
+	if cfg.Canary.Runs == 0 {
+		cfg.Canary.Runs = 12
+	}
+	if cfg.Canary.MaxDeviation == 0 {
+		cfg.Canary.MaxDeviation = 0.5
+	}
+	if cfg.Consistency.Interval == 0 {
+		cfg.Consistency.Interval = time.Hour
+	}
+	if cfg.Consistency.SampleSize == 0 {
+		cfg.Consistency.SampleSize = 20
+	}
+	if cfg.Consistency.Window == 0 {
+		cfg.Consistency.Window = 7 * 24 * time.Hour
+	}
+	if cfg.Consistency.Policy == "" {
+		cfg.Consistency.Policy = ConsistencyPolicyRecord
+	}
+	if cfg.Outbox.Workers == 0 {
+		cfg.Outbox.Workers = 2
+	}
+	if cfg.Outbox.MaxAttempts == 0 {
+		cfg.Outbox.MaxAttempts = 5
+	}
+	if cfg.Outbox.Backend == "" {
+		cfg.Outbox.Backend = OutboxBackendList
+	}
+	if cfg.Outbox.ConsumerName == "" {
+		hostname, _ := os.Hostname()
+		cfg.Outbox.ConsumerName = fmt.Sprintf("%s-%d", hostname, os.Getpid())
+	}
+	if cfg.Outbox.ClaimIdle == 0 {
+		cfg.Outbox.ClaimIdle = 5 * time.Minute
+	}
+	if cfg.Outbox.RetryBackoff == 0 {
+		cfg.Outbox.RetryBackoff = 30 * time.Second
+	}
+	if cfg.Outbox.MaxRetryBackoff == 0 {
+		cfg.Outbox.MaxRetryBackoff = 15 * time.Minute
+	}
+	if cfg.Metrics.Push.Job == "" {
+		cfg.Metrics.Push.Job = "gopost"
+	}
+	if cfg.Metrics.Push.Instance == "" {
+		cfg.Metrics.Push.Instance, _ = os.Hostname()
+	}
+	if cfg.Metrics.Push.Timeout == 0 {
+		cfg.Metrics.Push.Timeout = 10 * time.Second
+	}
+	if cfg.Monitoring.PingTimeout == 0 {
+		cfg.Monitoring.PingTimeout = 10 * time.Second
+	}
+	if cfg.Translation.Timeout == 0 {
+		cfg.Translation.Timeout = 30 * time.Second
+	}
+	if cfg.Translation.CacheTTL == 0 {
+		cfg.Translation.CacheTTL = 720 * time.Hour
+	}
 
 	// Override with environment variables if present
 	if esURL := os.Getenv("ES_URL"); esURL != "" {
@@ -167,12 +1843,24 @@ func Load(path string) (*Config, error) {
 	if redisURL := os.Getenv("REDIS_URL"); redisURL != "" {
 		cfg.Redis.URL = redisURL
 	}
+	if redisKeyPrefix := os.Getenv("REDIS_KEY_PREFIX"); redisKeyPrefix != "" {
+		cfg.Redis.KeyPrefix = redisKeyPrefix
+	}
 	if sourcesURL := os.Getenv("SOURCES_URL"); sourcesURL != "" {
 		cfg.Sources.URL = sourcesURL
 	}
 	if sourcesEnabled := os.Getenv("SOURCES_ENABLED"); sourcesEnabled != "" {
 		cfg.Sources.Enabled = parseBool(sourcesEnabled)
 	}
+	if outboxEnabled := os.Getenv("OUTBOX_ENABLED"); outboxEnabled != "" {
+		cfg.Outbox.Enabled = parseBool(outboxEnabled)
+	}
+	if adminAddr := os.Getenv("ADMIN_ADDR"); adminAddr != "" {
+		cfg.Admin.Addr = adminAddr
+	}
+	if grpcAddr := os.Getenv("GRPC_ADDR"); grpcAddr != "" {
+		cfg.GRPC.Addr = grpcAddr
+	}
 	// Parse APP_DEBUG environment variable
 	if appDebug := os.Getenv("APP_DEBUG"); appDebug != "" {
 		cfg.Debug = parseBool(appDebug)
@@ -186,6 +1874,16 @@ func Load(path string) (*Config, error) {
 	return &cfg, nil
 }
 
+// setCityDiscoveryDefaults fills the city discovery pattern and refresh interval.
+func setCityDiscoveryDefaults(discovery *CityDiscoveryConfig) {
+	if discovery.Pattern == "" {
+		discovery.Pattern = "*_articles"
+	}
+	if discovery.Interval == 0 {
+		discovery.Interval = 10 * time.Minute
+	}
+}
+
 // LoadWithSources loads configuration and optionally fetches cities from sources service.
 // If sources service is enabled and cities are fetched successfully, they override the config file cities.
 func LoadWithSources(path string, sourcesClient interface{ GetCities(context.Context) ([]CityConfig, error) }) (*Config, error) {
@@ -1,8 +1,11 @@
 package config
 
 import (
+	"encoding/base64"
 	"os"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestParseBool(t *testing.T) {
@@ -72,3 +75,230 @@ func TestConfigDebugFromEnv(t *testing.T) {
 		})
 	}
 }
+
+func TestValidate_Tenants(t *testing.T) {
+	tenant := func(name string, db int) TenantConfig {
+		return TenantConfig{
+			Name:          name,
+			Elasticsearch: ElasticsearchConfig{URL: "http://es:9200"},
+			Drupal:        DrupalConfig{URL: "https://" + name + ".example", Token: "secret"},
+			Redis:         RedisConfig{URL: "redis:6379", DB: db},
+			Cities:        []CityConfig{{Name: name + "_city"}},
+		}
+	}
+	base := func(tenants ...TenantConfig) *Config {
+		return &Config{
+			Service: ServiceConfig{RateLimitRPS: 1, CheckInterval: time.Minute, Timezone: "UTC"},
+			Tenants: tenants,
+		}
+	}
+
+	if err := base(tenant("north", 0), tenant("south", 1)).Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	// One database is fine when the key prefixes keep the tenants apart
+	prefixed := tenant("south", 0)
+	prefixed.Redis.KeyPrefix = "gopost:south:"
+	if err := base(tenant("north", 0), prefixed).Validate(); err != nil {
+		t.Fatalf("Validate() with distinct key prefixes error = %v", err)
+	}
+
+	missingDrupal := tenant("south", 1)
+	globPrefix := tenant("south", 1)
+	globPrefix.Redis.KeyPrefix = "gopost:*"
+	missingDrupal.Drupal.URL = ""
+	tests := []struct {
+		name    string
+		cfg     *Config
+		wantErr string
+	}{
+		{"duplicate name", base(tenant("north", 0), tenant("north", 1)), "not unique"},
+		{"shared redis database", base(tenant("north", 0), tenant("south", 0)), "same Redis database"},
+		{"tenant pipeline invalid", base(tenant("north", 0), missingDrupal), "tenants[1] (south): drupal.url is required"},
+		{"glob in key prefix", base(tenant("north", 0), globPrefix), "redis.key_prefix must not contain"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.cfg.Validate()
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidate_Elasticsearch(t *testing.T) {
+	cloudID := "staging:" + base64.StdEncoding.EncodeToString([]byte("us-east-1.aws.found.io$abc123$kibana456"))
+	tests := []struct {
+		name    string
+		es      ElasticsearchConfig
+		wantURL string
+		wantErr string
+	}{
+		{name: "url", es: ElasticsearchConfig{URL: "http://es:9200", Username: "gopost"}, wantURL: "http://es:9200"},
+		{name: "cloud ID", es: ElasticsearchConfig{CloudID: cloudID, APIKey: "a2V5"}, wantURL: "https://abc123.us-east-1.aws.found.io"},
+		{name: "neither", es: ElasticsearchConfig{}, wantErr: "elasticsearch.url or elasticsearch.cloud_id is required"},
+		{name: "both", es: ElasticsearchConfig{URL: "http://es:9200", CloudID: cloudID}, wantErr: "mutually exclusive"},
+		{name: "malformed cloud ID", es: ElasticsearchConfig{CloudID: "staging:bm8tZG9sbGFy"}, wantErr: "elasticsearch.cloud_id"},
+		{name: "two kinds of credentials", es: ElasticsearchConfig{URL: "http://es:9200", APIKey: "a2V5", ServiceToken: "token"},
+			wantErr: "elasticsearch.api_key and service_token are mutually exclusive"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateElasticsearch(tt.es)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("validateElasticsearch() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("validateElasticsearch() error = %v", err)
+			}
+			if got, _ := tt.es.Endpoint(); got != tt.wantURL {
+				t.Errorf("Endpoint() = %q, want %q", got, tt.wantURL)
+			}
+		})
+	}
+}
+
+func TestValidateRedis(t *testing.T) {
+	tests := []struct {
+		name    string
+		redis   RedisConfig
+		wantErr string
+	}{
+		{name: "address only", redis: RedisConfig{URL: "localhost:6379"}},
+		{name: "tls with ACL user", redis: RedisConfig{URL: "redis:6380", Username: "gopost", Password: "secret",
+			TLS: RedisTLSConfig{Enabled: true, CAFile: "ca.pem", CertFile: "client.pem", KeyFile: "client-key.pem"}}},
+		{name: "no address", redis: RedisConfig{}, wantErr: "redis.url is required"},
+		{name: "username without password", redis: RedisConfig{URL: "redis:6379", Username: "gopost"},
+			wantErr: "redis.username requires redis.password"},
+		{name: "certificate without key", redis: RedisConfig{URL: "redis:6379", TLS: RedisTLSConfig{Enabled: true, CertFile: "client.pem"}},
+			wantErr: "must be set together"},
+		{name: "tls settings while disabled", redis: RedisConfig{URL: "redis:6379", TLS: RedisTLSConfig{CAFile: "ca.pem"}},
+			wantErr: "require redis.tls.enabled"},
+		{name: "negative pool size", redis: RedisConfig{URL: "redis:6379", Pool: RedisPoolConfig{Size: -1}},
+			wantErr: "redis.pool.size must be non-negative"},
+		{name: "negative lifetime", redis: RedisConfig{URL: "redis:6379", Pool: RedisPoolConfig{MaxLifetime: -time.Second}},
+			wantErr: "redis.pool.max_lifetime must be non-negative"},
+		{name: "more idle than open", redis: RedisConfig{URL: "redis:6379", Pool: RedisPoolConfig{Size: 2, MinIdle: 3}},
+			wantErr: "must not exceed redis.pool.size"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateRedis(tt.redis)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("validateRedis() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("validateRedis() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWarnings_Keywords(t *testing.T) {
+	tests := []struct {
+		name     string
+		keywords []string
+		match    string
+		want     []string
+	}{
+		{name: "clean list", keywords: []string{"police", "robbery"}, match: KeywordMatchSubstring},
+		{name: "short keyword", keywords: []string{"ca", "police"}, match: KeywordMatchSubstring,
+			want: []string{`service.crime_keywords: "ca" is shorter than 3 characters`}},
+		{name: "short keyword matched as a word", keywords: []string{"ca"}, match: KeywordMatchWord},
+		{name: "short keyword matched by stem", keywords: []string{"ca"}, match: KeywordMatchStem},
+		{name: "duplicate", keywords: []string{"Police", "police "}, match: KeywordMatchSubstring,
+			want: []string{`service.crime_keywords: "police " is listed more than once`}},
+		{name: "regex characters", keywords: []string{`rob(bery)?`}, match: KeywordMatchWord,
+			want: []string{`service.crime_keywords: "rob(bery)?" contains regular expression characters`}},
+		{name: "pattern", keywords: []string{`/\brob(bery)?\b/i`}, match: KeywordMatchWord},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{Service: ServiceConfig{CrimeKeywords: tt.keywords, KeywordMatch: tt.match}}
+			got := cfg.Warnings()
+			if len(got) != len(tt.want) {
+				t.Fatalf("Warnings() = %q, want %d warnings", got, len(tt.want))
+			}
+			for i, want := range tt.want {
+				if !strings.HasPrefix(got[i], want) {
+					t.Errorf("Warnings()[%d] = %q, want prefix %q", i, got[i], want)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateKeywords(t *testing.T) {
+	cfg := &Config{
+		Service:    ServiceConfig{CrimeKeywords: []string{"police", `/\bB&E\b/i`}},
+		Categories: CategoriesConfig{Mappings: []CategoryConfig{{Keywords: []string{"/rob(bery/"}}}},
+	}
+	err := cfg.validateKeywords()
+	if err == nil || !strings.HasPrefix(err.Error(), "categories.mappings[0].keywords: keyword /rob(bery/") {
+		t.Errorf("validateKeywords() error = %v, want the invalid category pattern", err)
+	}
+
+	cfg.Categories.Mappings = nil
+	if err := cfg.validateKeywords(); err != nil {
+		t.Errorf("validateKeywords() error = %v", err)
+	}
+}
+
+func TestValidateClassify(t *testing.T) {
+	tests := []struct {
+		name     string
+		classify ClassifyConfig
+		wantErr  string
+	}{
+		{name: "default"},
+		{name: "title only", classify: ClassifyConfig{Fields: map[string]int{"title": 1}}},
+		{name: "weighted", classify: ClassifyConfig{Fields: map[string]int{"title": 2, "body": 1, "tags": 1}, MinScore: 2}},
+		{name: "unknown field", classify: ClassifyConfig{Fields: map[string]int{"headline": 1}},
+			wantErr: `service.classify.fields: unknown field "headline" (want title, body, tags)`},
+		{name: "negative weight", classify: ClassifyConfig{Fields: map[string]int{"body": -1, "title": 3}},
+			wantErr: "service.classify.fields.body must not be negative, got -1"},
+		{name: "unreachable score", classify: ClassifyConfig{Fields: map[string]int{"title": 1, "body": 1}, MinScore: 3},
+			wantErr: "service.classify.fields weights sum to 2, below min_score 3, so no article can match"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateClassify(tt.classify)
+			if tt.wantErr == "" && err != nil || tt.wantErr != "" && (err == nil || err.Error() != tt.wantErr) {
+				t.Errorf("validateClassify() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateFieldMap_Transformers(t *testing.T) {
+	tests := []struct {
+		name    string
+		mapping FieldMapConfig
+		wantErr string
+	}{
+		{name: "transformer", mapping: FieldMapConfig{Field: "field_summary", Source: "fn:first_paragraph", Type: FieldTypeText, Summary: "intro"}},
+		{name: "unnamed", mapping: FieldMapConfig{Field: "field_summary", Source: "fn:", Type: FieldTypeString},
+			wantErr: `service.field_map[0] (field_summary): source "fn:" names no field transformer`},
+		{name: "metatag", mapping: FieldMapConfig{Field: "field_metatag", Source: "fn:tags", Type: FieldTypeMetatag},
+			wantErr: "service.field_map[0] (field_metatag): type metatag takes its values from tags, not a field transformer"},
+		{name: "unknown summary", mapping: FieldMapConfig{Field: "field_summary", Source: "fn:first_paragraph", Type: FieldTypeText, Summary: "fn:x"},
+			wantErr: `service.field_map[0] (field_summary): unknown article property "fn:x"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateFieldMap([]FieldMapConfig{tt.mapping})
+			if tt.wantErr == "" && err != nil || tt.wantErr != "" && (err == nil || err.Error() != tt.wantErr) {
+				t.Errorf("validateFieldMap() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// EnvPrefix starts the environment variables that can set any config field. The rest of
+// the name is the field's YAML path, upper-cased and joined with "_":
+// service.check_interval is GOPOST_SERVICE_CHECK_INTERVAL. Lists and maps such as
+// GOPOST_CITIES take a JSON or YAML document.
+const EnvPrefix = "GOPOST_"
+
+// envAliases are alternative names for variables, kept for readability in manifests.
+var envAliases = map[string]string{
+	EnvPrefix + "CITIES_JSON": EnvPrefix + "CITIES",
+}
+
+// applyEnv overlays GOPOST_* variables from environ ("KEY=value" pairs, as returned by
+// os.Environ) onto cfg.
+func applyEnv(cfg *Config, environ []string) error {
+	values := make(map[string]string)
+	for _, entry := range environ {
+		key, value, ok := strings.Cut(entry, "=")
+		if !ok || !strings.HasPrefix(key, EnvPrefix) || value == "" {
+			continue
+		}
+		if canonical, isAlias := envAliases[key]; isAlias {
+			key = canonical
+		}
+		values[key] = value
+	}
+	if len(values) == 0 {
+		return nil
+	}
+
+	root, err := envNode(reflect.TypeFor[Config](), strings.TrimSuffix(EnvPrefix, "_"), values)
+	if err != nil {
+		return err
+	}
+	if root == nil {
+		return nil
+	}
+	if err := root.Decode(cfg); err != nil {
+		return fmt.Errorf("decode environment config: %w", err)
+	}
+	return nil
+}
+
+// envNode builds a YAML mapping for the fields of struct type t that are set in values,
+// or returns nil if none are.
+func envNode(t reflect.Type, prefix string, values map[string]string) (*yaml.Node, error) {
+	mapping := &yaml.Node{Kind: yaml.MappingNode}
+	for i := range t.NumField() {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if name == "" || name == "-" || !field.IsExported() {
+			continue
+		}
+		key := prefix + "_" + strings.ToUpper(name)
+
+		var value *yaml.Node
+		switch field.Type.Kind() {
+		case reflect.Struct:
+			child, err := envNode(field.Type, key, values)
+			if err != nil {
+				return nil, err
+			}
+			value = child
+		case reflect.Slice, reflect.Map:
+			raw, ok := values[key]
+			if !ok {
+				continue
+			}
+			var doc yaml.Node
+			if err := yaml.Unmarshal([]byte(raw), &doc); err != nil {
+				return nil, fmt.Errorf("parse %s: %w", key, err)
+			}
+			if len(doc.Content) == 0 {
+				continue
+			}
+			value = doc.Content[0]
+		case reflect.Bool:
+			if raw, ok := values[key]; ok {
+				value = &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: fmt.Sprint(parseBool(raw))}
+			}
+		case reflect.String:
+			if raw, ok := values[key]; ok {
+				value = &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: raw}
+			}
+		default:
+			// Numbers and durations are resolved by the decoder from the field type
+			if raw, ok := values[key]; ok {
+				value = &yaml.Node{Kind: yaml.ScalarNode, Value: strings.TrimSpace(raw)}
+			}
+		}
+		if value == nil {
+			continue
+		}
+		mapping.Content = append(mapping.Content,
+			&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: name},
+			value,
+		)
+	}
+
+	if len(mapping.Content) == 0 {
+		return nil, nil
+	}
+	return mapping, nil
+}
@@ -0,0 +1,80 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestApplyEnv(t *testing.T) {
+	cfg := Config{Drupal: DrupalConfig{URL: "https://file.example", Username: "file-user"}}
+	environ := []string{
+		"GOPOST_DRUPAL_URL=https://env.example",
+		"GOPOST_DEBUG=yes",
+		"GOPOST_REDIS_DB=3",
+		"GOPOST_SERVICE_CHECK_INTERVAL=90s",
+		"GOPOST_SERVICE_NEAR_DUPLICATES_THRESHOLD=0.8",
+		"GOPOST_SERVICE_CRIME_KEYWORDS=[\"police\", \"arrest\"]",
+		"GOPOST_DRUPAL_TOKEN=123: not yaml",
+		`GOPOST_CITIES_JSON=[{"name": "sudbury_com", "group_id": "g-1", "blocked_sources": ["paywall.example"]}]`,
+		"GOPOST_SOURCES_OUTLETS={sudbury.com: {name: Sudbury.com}}",
+		"UNRELATED=1",
+	}
+	if err := applyEnv(&cfg, environ); err != nil {
+		t.Fatalf("applyEnv() error = %v", err)
+	}
+
+	if cfg.Drupal.URL != "https://env.example" || cfg.Drupal.Username != "file-user" {
+		t.Errorf("Drupal = %+v, want env URL over file and file username kept", cfg.Drupal)
+	}
+	if cfg.Drupal.Token != "123: not yaml" {
+		t.Errorf("Drupal.Token = %q, want raw string", cfg.Drupal.Token)
+	}
+	if !cfg.Debug || cfg.Redis.DB != 3 || cfg.Service.CheckInterval != 90*time.Second || cfg.Service.NearDuplicates.Threshold != 0.8 {
+		t.Errorf("scalars not applied: debug=%v db=%d interval=%v threshold=%v",
+			cfg.Debug, cfg.Redis.DB, cfg.Service.CheckInterval, cfg.Service.NearDuplicates.Threshold)
+	}
+	if strings.Join(cfg.Service.CrimeKeywords, ",") != "police,arrest" {
+		t.Errorf("CrimeKeywords = %v", cfg.Service.CrimeKeywords)
+	}
+	if len(cfg.Cities) != 1 || cfg.Cities[0].GroupID != "g-1" || cfg.Cities[0].BlockedSources[0] != "paywall.example" {
+		t.Errorf("Cities = %+v", cfg.Cities)
+	}
+	if cfg.Sources.Outlets["sudbury.com"].Name != "Sudbury.com" {
+		t.Errorf("Outlets = %+v", cfg.Sources.Outlets)
+	}
+}
+
+func TestApplyEnv_InvalidValue(t *testing.T) {
+	tests := []string{
+		"GOPOST_SERVICE_CHECK_INTERVAL=soon",
+		"GOPOST_CITIES=[{name: ",
+	}
+	for _, entry := range tests {
+		t.Run(entry, func(t *testing.T) {
+			if err := applyEnv(&Config{}, []string{entry}); err == nil {
+				t.Errorf("applyEnv(%q) error = nil", entry)
+			}
+		})
+	}
+}
+
+func TestLoad_EnvironmentOnly(t *testing.T) {
+	t.Setenv("GOPOST_ELASTICSEARCH_URL", "http://es:9200")
+	t.Setenv("GOPOST_DRUPAL_URL", "https://drupal.example")
+	t.Setenv("GOPOST_DRUPAL_TOKEN", "secret")
+	t.Setenv("GOPOST_REDIS_URL", "redis:6379")
+
+	if _, err := Load(""); err == nil || !strings.Contains(err.Error(), "city") {
+		t.Fatalf("Load() without cities error = %v, want city validation error", err)
+	}
+
+	t.Setenv("GOPOST_CITIES_JSON", `[{"name": "sudbury_com"}]`)
+	cfg, err := Load("")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Cities[0].Name != "sudbury_com" || cfg.Service.CheckInterval != 5*time.Minute {
+		t.Errorf("Load() = cities %+v, check interval %v; want env cities and defaults", cfg.Cities, cfg.Service.CheckInterval)
+	}
+}
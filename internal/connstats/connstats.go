@@ -0,0 +1,129 @@
+// Package connstats aggregates the connection reuse and timings of Drupal requests into
+// counters and histograms, to verify that keep-alive and HTTP/2 work end to end, e.g.
+// through a proxy that closes idle connections.
+package connstats
+
+import (
+	"cmp"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/gopost/integration/pkg/drupal"
+)
+
+// Buckets are the histogram upper bounds, in seconds.
+var Buckets = []float64{0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Histogram counts observations per bucket of Buckets.
+type Histogram struct {
+	Counts []uint64 // Cumulative: Counts[i] observations were at most Buckets[i]
+	Sum    float64  // Sum of all observations, in seconds
+	Count  uint64
+}
+
+// observe adds one observation.
+func (h *Histogram) observe(d time.Duration) {
+	if h.Counts == nil {
+		h.Counts = make([]uint64, len(Buckets))
+	}
+	seconds := d.Seconds()
+	for i, bound := range Buckets {
+		if seconds <= bound {
+			h.Counts[i]++
+		}
+	}
+	h.Sum += seconds
+	h.Count++
+}
+
+// clone returns a copy that does not share Counts.
+func (h Histogram) clone() Histogram {
+	h.Counts = slices.Clone(h.Counts)
+	return h
+}
+
+// Requests counts requests by connection reuse and protocol.
+type Requests struct {
+	Reused   bool
+	Protocol string
+	Count    uint64
+}
+
+// Snapshot is a copy of the recorded stats. Setup histograms only cover requests that
+// opened a new connection.
+type Snapshot struct {
+	Requests []Requests
+	DNS      Histogram
+	Connect  Histogram
+	TLS      Histogram
+	TTFB     Histogram
+}
+
+// Recorder aggregates drupal.ConnStats. It is safe for concurrent use.
+type Recorder struct {
+	mu       sync.Mutex
+	requests map[Requests]uint64 // Keyed with Count zero
+	dns      Histogram
+	connect  Histogram
+	tls      Histogram
+	ttfb     Histogram
+}
+
+// NewRecorder returns an empty recorder.
+func NewRecorder() *Recorder {
+	return &Recorder{requests: make(map[Requests]uint64)}
+}
+
+// Observe records one request; pass it to drupal.WithConnStats.
+func (r *Recorder) Observe(stats drupal.ConnStats) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.requests[Requests{Reused: stats.Reused, Protocol: stats.Protocol}]++
+	r.ttfb.observe(stats.TTFB)
+	if stats.Reused {
+		return
+	}
+	if stats.DNS > 0 {
+		r.dns.observe(stats.DNS)
+	}
+	if stats.Connect > 0 {
+		r.connect.observe(stats.Connect)
+	}
+	if stats.TLS > 0 {
+		r.tls.observe(stats.TLS)
+	}
+}
+
+// Snapshot returns the stats recorded so far, with Requests sorted by protocol.
+func (r *Recorder) Snapshot() *Snapshot {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	snapshot := &Snapshot{
+		DNS:     r.dns.clone(),
+		Connect: r.connect.clone(),
+		TLS:     r.tls.clone(),
+		TTFB:    r.ttfb.clone(),
+	}
+	for key, count := range r.requests {
+		key.Count = count
+		snapshot.Requests = append(snapshot.Requests, key)
+	}
+	slices.SortFunc(snapshot.Requests, func(a, b Requests) int {
+		if c := cmp.Compare(a.Protocol, b.Protocol); c != 0 {
+			return c
+		}
+		return cmp.Compare(boolRank(a.Reused), boolRank(b.Reused))
+	})
+	return snapshot
+}
+
+// boolRank orders new connections before reused ones.
+func boolRank(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
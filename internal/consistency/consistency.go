@@ -0,0 +1,168 @@
+// Package consistency records the Drupal node behind every posted article in Redis, so a
+// periodic check can sample recent posts and find nodes deleted by editors while the
+// dedup store still reports the article as posted.
+package consistency
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Redis keys, after the key prefix: sorted sets of posts and detected deletions, scored by
+// Unix milliseconds.
+const (
+	PostsKey     = "posted_nodes"
+	DeletionsKey = "deleted_nodes"
+)
+
+// Post is the node created for an article.
+type Post struct {
+	ArticleID   string    `json:"article_id"`
+	NodeID      string    `json:"node_id"`
+	ContentType string    `json:"content_type"`
+	City        string    `json:"city"`
+	TitleKey    string    `json:"title_key,omitempty"` // Dedup key of the normalized title, when title dedup is enabled
+	PostedAt    time.Time `json:"posted_at"`
+
+	member string // Sorted set member the post was loaded from
+}
+
+// Deletion is a post whose node was found missing.
+type Deletion struct {
+	Post
+	DetectedAt time.Time `json:"detected_at"`
+}
+
+// Store keeps the posts of the last window in Redis, and the deletions detected in it.
+type Store struct {
+	client       *redis.Client
+	postsKey     string // PostsKey after the key prefix
+	deletionsKey string // DeletionsKey after the key prefix
+	window       time.Duration
+}
+
+// NewStore returns a store backed by client keeping posts and deletions for window, under
+// prefix (redis.key_prefix).
+func NewStore(client *redis.Client, prefix string, window time.Duration) *Store {
+	return &Store{
+		client:       client,
+		postsKey:     prefix + PostsKey,
+		deletionsKey: prefix + DeletionsKey,
+		window:       window,
+	}
+}
+
+// Record adds a post and trims posts older than the window.
+func (s *Store) Record(ctx context.Context, post Post) error {
+	data, err := json.Marshal(post)
+	if err != nil {
+		return fmt.Errorf("encode post: %w", err)
+	}
+	pipe := s.client.TxPipeline()
+	pipe.ZAdd(ctx, s.postsKey, redis.Z{Score: float64(post.PostedAt.UnixMilli()), Member: data})
+	pipe.ZRemRangeByScore(ctx, s.postsKey, "-inf", s.cutoff())
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("record post of %s: %w", post.ArticleID, err)
+	}
+	return nil
+}
+
+// Sample returns up to n posts of the window, picked at random.
+func (s *Store) Sample(ctx context.Context, n int) ([]Post, error) {
+	members, err := s.client.ZRangeByScore(ctx, s.postsKey, &redis.ZRangeBy{Min: s.cutoff(), Max: "+inf"}).Result()
+	if err != nil {
+		return nil, fmt.Errorf("load posts: %w", err)
+	}
+	rand.Shuffle(len(members), func(i, j int) { members[i], members[j] = members[j], members[i] })
+	posts := make([]Post, 0, min(n, len(members)))
+	for _, member := range members[:min(n, len(members))] {
+		post := Post{member: member}
+		if err := json.Unmarshal([]byte(member), &post); err != nil {
+			return nil, fmt.Errorf("decode post: %w", err)
+		}
+		posts = append(posts, post)
+	}
+	return posts, nil
+}
+
+// Remove drops a sampled post, so it is not checked again.
+func (s *Store) Remove(ctx context.Context, post Post) error {
+	if err := s.client.ZRem(ctx, s.postsKey, post.member).Err(); err != nil {
+		return fmt.Errorf("remove post of %s: %w", post.ArticleID, err)
+	}
+	return nil
+}
+
+// Forget drops the posts of the given articles, for nodes removed on purpose, and returns
+// the posts dropped.
+func (s *Store) Forget(ctx context.Context, articleIDs ...string) ([]Post, error) {
+	members, err := s.client.ZRange(ctx, s.postsKey, 0, -1).Result()
+	if err != nil {
+		return nil, fmt.Errorf("load posts: %w", err)
+	}
+	var forgotten []Post
+	var remove []any
+	for _, member := range members {
+		var post Post
+		if err := json.Unmarshal([]byte(member), &post); err != nil {
+			return nil, fmt.Errorf("decode post: %w", err)
+		}
+		if slices.Contains(articleIDs, post.ArticleID) {
+			forgotten = append(forgotten, post)
+			remove = append(remove, member)
+		}
+	}
+	if len(remove) == 0 {
+		return nil, nil
+	}
+	if err := s.client.ZRem(ctx, s.postsKey, remove...).Err(); err != nil {
+		return nil, fmt.Errorf("forget posts: %w", err)
+	}
+	return forgotten, nil
+}
+
+// RecordDeletion moves a sampled post to the deletions, trimming deletions older than the
+// window.
+func (s *Store) RecordDeletion(ctx context.Context, post Post, detectedAt time.Time) error {
+	data, err := json.Marshal(Deletion{Post: post, DetectedAt: detectedAt})
+	if err != nil {
+		return fmt.Errorf("encode deletion: %w", err)
+	}
+	pipe := s.client.TxPipeline()
+	pipe.ZRem(ctx, s.postsKey, post.member)
+	pipe.ZAdd(ctx, s.deletionsKey, redis.Z{Score: float64(detectedAt.UnixMilli()), Member: data})
+	pipe.ZRemRangeByScore(ctx, s.deletionsKey, "-inf", s.cutoff())
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("record deletion of %s: %w", post.ArticleID, err)
+	}
+	return nil
+}
+
+// Deletions returns the deletions detected within the window, oldest first.
+func (s *Store) Deletions(ctx context.Context) ([]Deletion, error) {
+	members, err := s.client.ZRangeByScore(ctx, s.deletionsKey, &redis.ZRangeBy{Min: s.cutoff(), Max: "+inf"}).Result()
+	if err != nil {
+		return nil, fmt.Errorf("load deletions: %w", err)
+	}
+	deletions := make([]Deletion, 0, len(members))
+	for _, member := range members {
+		var deletion Deletion
+		if err := json.Unmarshal([]byte(member), &deletion); err != nil {
+			return nil, fmt.Errorf("decode deletion: %w", err)
+		}
+		deletions = append(deletions, deletion)
+	}
+	return deletions, nil
+}
+
+// cutoff returns the score before which entries fall out of the window.
+func (s *Store) cutoff() string {
+	return "(" + strconv.FormatInt(time.Now().Add(-s.window).UnixMilli(), 10)
+}
@@ -0,0 +1,58 @@
+package consistency_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gopost/integration/internal/consistency"
+	"github.com/gopost/integration/internal/dedup/deduptest"
+)
+
+func TestStore_SamplesWindowAndRecordsDeletions(t *testing.T) {
+	mr := miniredis.RunT(t)
+	store := consistency.NewStore(deduptest.NewClient(t, mr), deduptest.KeyPrefix, 24*time.Hour)
+	ctx := context.Background()
+	now := time.Now()
+
+	for _, post := range []consistency.Post{
+		{ArticleID: "old", NodeID: "n0", PostedAt: now.Add(-48 * time.Hour)},
+		{ArticleID: "a", NodeID: "n1", PostedAt: now.Add(-time.Hour)},
+		{ArticleID: "b", NodeID: "n2", PostedAt: now},
+	} {
+		if err := store.Record(ctx, post); err != nil {
+			t.Fatalf("Record() error = %v", err)
+		}
+	}
+
+	posts, err := store.Sample(ctx, 10)
+	if err != nil {
+		t.Fatalf("Sample() error = %v", err)
+	}
+	if len(posts) != 2 {
+		t.Fatalf("Sample(10) returned %d posts, want the 2 within the window", len(posts))
+	}
+	if one, err := store.Sample(ctx, 1); err != nil || len(one) != 1 {
+		t.Fatalf("Sample(1) = %v, %v; want one post", one, err)
+	}
+
+	deleted := posts[0]
+	if err := store.RecordDeletion(ctx, deleted, now); err != nil {
+		t.Fatalf("RecordDeletion() error = %v", err)
+	}
+	if err := store.Remove(ctx, posts[1]); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+	if remaining, err := store.Sample(ctx, 10); err != nil || len(remaining) != 0 {
+		t.Errorf("Sample() after deletion and removal = %v, %v; want no posts", remaining, err)
+	}
+
+	deletions, err := store.Deletions(ctx)
+	if err != nil {
+		t.Fatalf("Deletions() error = %v", err)
+	}
+	if len(deletions) != 1 || deletions[0].ArticleID != deleted.ArticleID || deletions[0].NodeID != deleted.NodeID {
+		t.Errorf("Deletions() = %+v, want %s", deletions, deleted.ArticleID)
+	}
+}
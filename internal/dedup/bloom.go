@@ -0,0 +1,259 @@
+package dedup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gopost/integration/internal/logger"
+	"github.com/redis/go-redis/v9"
+)
+
+// BloomKeyPrefix, after the key prefix, starts the key of the Bloom filter bitmap:
+// posted:bloom:<bits>:<hashes>, so filters sized differently never share bits. The key
+// with ":built" appended is set once the filter holds every marker.
+const BloomKeyPrefix = "posted:bloom:"
+
+// maxBloomBits is the size of the largest Redis bitmap (512 MB).
+const maxBloomBits = 1 << 32
+
+// errBloomNotBuilt is returned by load when no replica has built the filter yet.
+var errBloomNotBuilt = errors.New("bloom filter not built")
+
+// BloomFilter is a Bloom filter of the dedup markers, kept as a Redis bitmap shared by
+// replicas and read from a local snapshot. It tells apart articles that are certainly
+// new, which skip the per-article EXISTS, from those that may be posted, which are
+// checked exactly. Bits are never cleared, so markers that expire or are cleared only
+// become false positives, still checked exactly.
+type BloomFilter struct {
+	client   *redis.Client
+	key      string
+	builtKey string
+	bits     uint64
+	hashes   uint64
+	refresh  time.Duration
+	logger   logger.Logger
+
+	refreshing sync.Mutex // Held while a snapshot is loaded or the filter rebuilt
+
+	mu       sync.RWMutex
+	snapshot []byte // nil until a built filter is loaded
+	loadedAt time.Time
+}
+
+// NewBloomFilter returns a filter under prefix (redis.key_prefix) sized for capacity
+// markers at falsePositiveRate, capped at the largest Redis bitmap. The local snapshot is
+// reloaded from Redis every refresh, picking up articles other replicas posted. A filter
+// is used by passing it to Tracker.UseBloomFilter.
+func NewBloomFilter(client *redis.Client, prefix string, capacity int, falsePositiveRate float64, refresh time.Duration, log logger.Logger) *BloomFilter {
+	n := float64(max(capacity, 1))
+	bits := uint64(math.Ceil(-n * math.Log(falsePositiveRate) / (math.Ln2 * math.Ln2)))
+	bits = min(max(bits, 64), maxBloomBits)
+	hashes := uint64(max(math.Round(float64(bits)/n*math.Ln2), 1))
+
+	key := prefix + BloomKeyPrefix + strconv.FormatUint(bits, 10) + ":" + strconv.FormatUint(hashes, 10)
+	return &BloomFilter{
+		client:   client,
+		key:      key,
+		builtKey: key + ":built",
+		bits:     bits,
+		hashes:   hashes,
+		refresh:  refresh,
+		logger:   log,
+	}
+}
+
+// offsets returns the bits set for id, by double hashing the halves of its FNV-1a hash.
+func (f *BloomFilter) offsets(id string) []uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(id))
+	sum := h.Sum64()
+	h1, h2 := sum&math.MaxUint32, sum>>32|1
+	offsets := make([]uint64, f.hashes)
+	for i := range offsets {
+		offsets[i] = (h1 + uint64(i)*h2) % f.bits
+	}
+	return offsets
+}
+
+// newBitmap returns an empty bitmap the size of the filter.
+func (f *BloomFilter) newBitmap() []byte {
+	return make([]byte, (f.bits+7)/8)
+}
+
+// setBit sets a bit in Redis' bitmap layout, most significant bit first.
+func setBit(bitmap []byte, offset uint64) {
+	bitmap[offset/8] |= 0x80 >> (offset % 8)
+}
+
+func hasBit(bitmap []byte, offset uint64) bool {
+	return bitmap[offset/8]&(0x80>>(offset%8)) != 0
+}
+
+// test reports whether id may be in the snapshot. ok is false without a snapshot.
+func (f *BloomFilter) test(id string) (maybe, ok bool) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	if f.snapshot == nil {
+		return false, false
+	}
+	for _, offset := range f.offsets(id) {
+		if !hasBit(f.snapshot, offset) {
+			return false, true
+		}
+	}
+	return true, true
+}
+
+// add sets id's bits in Redis and in the local snapshot.
+func (f *BloomFilter) add(ctx context.Context, id string) error {
+	offsets := f.offsets(id)
+	f.mu.Lock()
+	if f.snapshot != nil {
+		for _, offset := range offsets {
+			setBit(f.snapshot, offset)
+		}
+	}
+	f.mu.Unlock()
+
+	pipe := f.client.Pipeline()
+	for _, offset := range offsets {
+		pipe.SetBit(ctx, f.key, int64(offset), 1)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("add article %s to bloom filter: %w", id, err)
+	}
+	return nil
+}
+
+// stale reports whether the snapshot is missing or older than the refresh interval.
+func (f *BloomFilter) stale() bool {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return time.Since(f.loadedAt) >= f.refresh
+}
+
+// load replaces the snapshot with the bitmap in Redis. Without a built filter the
+// snapshot is dropped and errBloomNotBuilt returned.
+func (f *BloomFilter) load(ctx context.Context) error {
+	pipe := f.client.Pipeline()
+	built := pipe.Exists(ctx, f.builtKey)
+	bitmap := pipe.Get(ctx, f.key)
+	_, err := pipe.Exec(ctx)
+
+	var snapshot []byte
+	switch {
+	case err != nil && !errors.Is(err, redis.Nil):
+		// Until the next refresh, articles are checked exactly
+		err = fmt.Errorf("load bloom filter: %w", err)
+	case built.Val() == 1:
+		// Bits past the end of the Redis string are unset
+		snapshot, err = f.newBitmap(), nil
+		copy(snapshot, bitmap.Val())
+	default:
+		err = errBloomNotBuilt
+	}
+	f.mu.Lock()
+	f.snapshot = snapshot
+	f.loadedAt = time.Now()
+	f.mu.Unlock()
+	return err
+}
+
+// build ORs bitmap, filled by setting each marker's offsets, into the Redis bitmap and
+// marks the filter built. Markers written meanwhile set their own bits, so none is
+// missed; concurrent builds by other replicas only set the same bits again.
+func (f *BloomFilter) build(ctx context.Context, bitmap []byte) error {
+	staging := f.key + ":build:" + strconv.FormatInt(time.Now().UnixNano(), 36)
+	_, err := f.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, staging, bitmap, 0)
+		pipe.BitOpOr(ctx, f.key, f.key, staging)
+		pipe.Del(ctx, staging)
+		pipe.Set(ctx, f.builtKey, "1", 0)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("build bloom filter: %w", err)
+	}
+	return nil
+}
+
+// clear deletes the filter from Redis and drops the snapshot, so it is rebuilt on next use.
+func (f *BloomFilter) clear(ctx context.Context) error {
+	f.mu.Lock()
+	f.snapshot = nil
+	f.loadedAt = time.Time{}
+	f.mu.Unlock()
+	if err := f.client.Del(ctx, f.key, f.builtKey).Err(); err != nil {
+		return fmt.Errorf("delete bloom filter: %w", err)
+	}
+	return nil
+}
+
+// definitelyNew reports whether the bloom filter rules the article out. It refreshes a
+// stale snapshot first, building the filter from the markers if no replica has yet. When
+// the filter is unavailable, or another goroutine is refreshing it without a snapshot to
+// fall back on, it returns false so the article is checked exactly.
+func (t *Tracker) definitelyNew(ctx context.Context, articleID string) bool {
+	if t.bloom.stale() && t.bloom.refreshing.TryLock() {
+		t.refreshBloom(ctx)
+		t.bloom.refreshing.Unlock()
+	}
+	maybe, ok := t.bloom.test(articleID)
+	return ok && !maybe
+}
+
+// refreshBloom loads the snapshot, or builds the filter by scanning the markers. It is
+// not bound by the caller's deadline, which is sized for a single check, not a scan.
+func (t *Tracker) refreshBloom(ctx context.Context) {
+	ctx = context.WithoutCancel(ctx)
+	err := t.bloom.load(ctx)
+	if !errors.Is(err, errBloomNotBuilt) {
+		if err != nil {
+			t.logger.Warn("Failed to load bloom filter; checking articles exactly",
+				logger.String("redis_key", t.bloom.key),
+				logger.Error(err),
+			)
+		}
+		return
+	}
+
+	start := time.Now()
+	t.logger.Info("Building bloom filter from the posted markers",
+		logger.String("redis_key", t.bloom.key),
+	)
+	bitmap := t.bloom.newBitmap()
+	markers := 0
+	err = t.scan(ctx, func(keys []string) error {
+		for _, key := range keys {
+			for _, offset := range t.bloom.offsets(key[len(t.prefix):]) {
+				setBit(bitmap, offset)
+			}
+		}
+		markers += len(keys)
+		return nil
+	})
+	if err == nil {
+		err = t.bloom.build(ctx, bitmap)
+	}
+	if err == nil {
+		err = t.bloom.load(ctx)
+	}
+	if err != nil {
+		t.logger.Warn("Failed to build bloom filter; checking articles exactly",
+			logger.String("redis_key", t.bloom.key),
+			logger.Error(err),
+		)
+		return
+	}
+	t.logger.Info("Built bloom filter",
+		logger.String("redis_key", t.bloom.key),
+		logger.Int("markers", markers),
+		logger.Duration("duration", time.Since(start)),
+	)
+}
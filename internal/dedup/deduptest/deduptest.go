@@ -0,0 +1,52 @@
+// Package deduptest provides a miniredis-backed dedup.Tracker for tests,
+// so pipeline tests can exercise real Redis commands without Docker.
+package deduptest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gopost/integration/internal/dedup"
+	"github.com/gopost/integration/internal/logger"
+	"github.com/redis/go-redis/v9"
+)
+
+// Dedup durations used by NewTracker.
+const (
+	DefaultTTL   = 24 * time.Hour
+	DefaultLease = time.Minute
+)
+
+// KeyPrefix is the key prefix used by NewTracker, the default redis.key_prefix. Tests
+// building other stores on the same instance pass it too.
+const KeyPrefix = "gopost:"
+
+// NewTracker returns a Tracker backed by an in-memory miniredis instance along with
+// the instance itself, which tests can use to inspect keys or fast-forward TTLs.
+// Both are closed automatically when the test finishes.
+func NewTracker(tb testing.TB) (*dedup.Tracker, *miniredis.Miniredis) {
+	tb.Helper()
+	return NewTrackerWithTTL(tb, DefaultTTL)
+}
+
+// NewTrackerWithTTL is like NewTracker but uses the given dedup TTL.
+func NewTrackerWithTTL(tb testing.TB, ttl time.Duration) (*dedup.Tracker, *miniredis.Miniredis) {
+	tb.Helper()
+
+	mr := miniredis.RunT(tb)
+	client := NewClient(tb, mr)
+	return dedup.NewTracker(client, KeyPrefix, ttl, DefaultLease, logger.NewNopLogger()), mr
+}
+
+// NewClient returns a go-redis client connected to the miniredis instance.
+// The client is closed automatically when the test finishes.
+func NewClient(tb testing.TB, mr *miniredis.Miniredis) *redis.Client {
+	tb.Helper()
+
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	tb.Cleanup(func() {
+		_ = client.Close()
+	})
+	return client
+}
@@ -0,0 +1,211 @@
+package deduptest_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gopost/integration/internal/dedup"
+	"github.com/gopost/integration/internal/dedup/deduptest"
+	"github.com/gopost/integration/internal/logger"
+)
+
+func TestNewTracker_MarkAndExpire(t *testing.T) {
+	ctx := context.Background()
+	tracker, mr := deduptest.NewTracker(t)
+
+	if tracker.HasPosted(ctx, "article-1") {
+		t.Fatal("HasPosted() = true before MarkPosted")
+	}
+	if err := tracker.MarkPosted(ctx, "article-1"); err != nil {
+		t.Fatalf("MarkPosted() error = %v", err)
+	}
+	if !tracker.HasPosted(ctx, "article-1") {
+		t.Fatal("HasPosted() = false after MarkPosted")
+	}
+	if !mr.Exists("gopost:posted:article:article-1") {
+		t.Error("expected gopost:posted:article:article-1 key in Redis")
+	}
+
+	mr.FastForward(deduptest.DefaultTTL)
+	if tracker.HasPosted(ctx, "article-1") {
+		t.Error("HasPosted() = true after TTL expiry")
+	}
+}
+
+func TestNewTracker_FlushAll(t *testing.T) {
+	ctx := context.Background()
+	tracker, mr := deduptest.NewTracker(t)
+
+	for _, id := range []string{"a", "b", "c"} {
+		if err := tracker.MarkPosted(ctx, id); err != nil {
+			t.Fatalf("MarkPosted(%q) error = %v", id, err)
+		}
+	}
+	if err := mr.Set("unrelated", "1"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+
+	if err := tracker.FlushAll(ctx); err != nil {
+		t.Fatalf("FlushAll() error = %v", err)
+	}
+	if keys := mr.Keys(); len(keys) != 1 || keys[0] != "unrelated" {
+		t.Errorf("Keys() = %v, want only unrelated key", keys)
+	}
+}
+
+func TestNewTracker_TwoPhaseMarking(t *testing.T) {
+	ctx := context.Background()
+	tracker, mr := deduptest.NewTracker(t)
+
+	reserved, err := tracker.Reserve(ctx, "article-1")
+	if err != nil || !reserved {
+		t.Fatalf("Reserve() = %v, %v; want true, nil", reserved, err)
+	}
+	if again, _ := tracker.Reserve(ctx, "article-1"); again {
+		t.Fatal("second Reserve() = true while reservation is held")
+	}
+	if !tracker.HasPosted(ctx, "article-1") {
+		t.Error("HasPosted() = false while reservation is held")
+	}
+
+	// A failed post releases the reservation for the next attempt
+	if err := tracker.Release(ctx, "article-1"); err != nil {
+		t.Fatalf("Release() error = %v", err)
+	}
+	if reserved, _ := tracker.Reserve(ctx, "article-1"); !reserved {
+		t.Fatal("Reserve() after Release() = false")
+	}
+
+	// Release must not undo a completed post
+	if err := tracker.MarkPosted(ctx, "article-1"); err != nil {
+		t.Fatalf("MarkPosted() error = %v", err)
+	}
+	if err := tracker.Release(ctx, "article-1"); err != nil {
+		t.Fatalf("Release() error = %v", err)
+	}
+	if !tracker.HasPosted(ctx, "article-1") {
+		t.Error("HasPosted() = false after Release() of a posted article")
+	}
+
+	// An abandoned reservation expires after the lease
+	if reserved, _ := tracker.Reserve(ctx, "article-2"); !reserved {
+		t.Fatal("Reserve(article-2) = false")
+	}
+	mr.FastForward(deduptest.DefaultLease)
+	if reserved, _ := tracker.Reserve(ctx, "article-2"); !reserved {
+		t.Error("Reserve() after lease expiry = false")
+	}
+}
+
+func TestNewTracker_Statistics(t *testing.T) {
+	ctx := context.Background()
+	tracker, mr := deduptest.NewTracker(t)
+
+	for _, id := range []string{"oldest", "older", "new"} {
+		if err := tracker.MarkPosted(ctx, id); err != nil {
+			t.Fatalf("MarkPosted(%q) error = %v", id, err)
+		}
+		if id != "new" {
+			mr.FastForward(time.Hour)
+		}
+	}
+	if reserved, _ := tracker.Reserve(ctx, "in-flight"); !reserved {
+		t.Fatal("Reserve(in-flight) = false")
+	}
+	if err := mr.Set("gopost:posted:article:legacy", "1"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+
+	if count, err := tracker.Count(ctx); err != nil || count != 5 {
+		t.Errorf("Count() = %d, %v; want 5", count, err)
+	}
+
+	// Closest to expiry first; pending reservations are left out, markers without expiry last
+	oldest, err := tracker.OldestEntries(ctx, 3)
+	if err != nil {
+		t.Fatalf("OldestEntries() error = %v", err)
+	}
+	var ids []string
+	for _, marker := range oldest {
+		ids = append(ids, marker.ID)
+	}
+	if want := []string{"oldest", "older", "new"}; !slices.Equal(ids, want) {
+		t.Errorf("OldestEntries(3) = %v, want %v", ids, want)
+	}
+	if oldest[0].TTL != deduptest.DefaultTTL-2*time.Hour {
+		t.Errorf("oldest TTL = %v, want %v", oldest[0].TTL, deduptest.DefaultTTL-2*time.Hour)
+	}
+	if all, _ := tracker.OldestEntries(ctx, 10); len(all) != 4 || all[3].ID != "legacy" || all[3].TTL != 0 {
+		t.Errorf("OldestEntries(10) = %+v, want the legacy marker last without a TTL", all)
+	}
+
+	if ttl, err := tracker.TTLOf(ctx, "new"); err != nil || ttl != deduptest.DefaultTTL {
+		t.Errorf("TTLOf(new) = %v, %v; want %v", ttl, err, deduptest.DefaultTTL)
+	}
+	if ttl, err := tracker.TTLOf(ctx, "legacy"); err != nil || ttl != 0 {
+		t.Errorf("TTLOf(legacy) = %v, %v; want 0 (never expires)", ttl, err)
+	}
+	if _, err := tracker.TTLOf(ctx, "missing"); !errors.Is(err, dedup.ErrNotTracked) {
+		t.Errorf("TTLOf(missing) error = %v, want ErrNotTracked", err)
+	}
+
+	if size, err := tracker.AverageSize(ctx, 10); err != nil || size <= 0 {
+		t.Errorf("AverageSize() = %d, %v; want a positive size", size, err)
+	}
+}
+
+func TestNewTracker_BloomFilter(t *testing.T) {
+	ctx := context.Background()
+	mr := miniredis.RunT(t)
+	newTracker := func() *dedup.Tracker {
+		client := deduptest.NewClient(t, mr)
+		tracker := dedup.NewTracker(client, deduptest.KeyPrefix, deduptest.DefaultTTL, deduptest.DefaultLease, logger.NewNopLogger())
+		tracker.UseBloomFilter(dedup.NewBloomFilter(client, deduptest.KeyPrefix, 1000, 0.01, time.Hour, logger.NewNopLogger()))
+		return tracker
+	}
+	// Posted before the filter existed, so it is built from the markers
+	if err := mr.Set("gopost:posted:article:before", "posted"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+
+	first := newTracker()
+	if !first.HasPosted(ctx, "before") {
+		t.Fatal("HasPosted(before) = false, want the filter built from existing markers")
+	}
+	if !slices.ContainsFunc(mr.Keys(), func(key string) bool { return strings.HasSuffix(key, ":built") }) {
+		t.Errorf("Keys() = %v, want the filter marked built", mr.Keys())
+	}
+	commands := mr.CommandCount()
+	if first.HasPosted(ctx, "new") {
+		t.Fatal("HasPosted(new) = true")
+	}
+	if got := mr.CommandCount() - commands; got != 0 {
+		t.Errorf("HasPosted() of a new article sent %d Redis commands, want 0", got)
+	}
+
+	if err := first.MarkPosted(ctx, "new"); err != nil {
+		t.Fatalf("MarkPosted() error = %v", err)
+	}
+	if !first.HasPosted(ctx, "new") {
+		t.Error("HasPosted(new) = false after MarkPosted")
+	}
+	// Another replica loads the shared filter, including the article just posted
+	if second := newTracker(); !second.HasPosted(ctx, "new") || !second.HasPosted(ctx, "before") {
+		t.Error("second replica's HasPosted() = false for a posted article")
+	}
+
+	if err := first.FlushAll(ctx); err != nil {
+		t.Fatalf("FlushAll() error = %v", err)
+	}
+	if keys := mr.Keys(); len(keys) != 0 {
+		t.Errorf("Keys() after FlushAll = %v, want the filter deleted too", keys)
+	}
+	if first.HasPosted(ctx, "new") {
+		t.Error("HasPosted(new) = true after FlushAll")
+	}
+}
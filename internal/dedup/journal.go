@@ -0,0 +1,162 @@
+package dedup
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Journal records dedup keys of posted articles whose MarkPosted failed, until a retry
+// writes them to Redis. Keys in the journal count as posted, so a Redis blip right after
+// a post cannot get the article posted again. With a file path the journal survives
+// restarts: each key is appended and synced before Add returns, and Remove rewrites the
+// file. Without one it only lasts for the life of the process.
+type Journal struct {
+	path string
+
+	mu   sync.Mutex
+	keys map[string]struct{}
+}
+
+// OpenJournal loads the journal at path, creating it on the first Add. An empty path
+// keeps the journal in memory.
+func OpenJournal(path string) (*Journal, error) {
+	journal := &Journal{path: path, keys: make(map[string]struct{})}
+	if path == "" {
+		return journal, nil
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return journal, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open mark journal: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		// A crash mid-append can leave a partial last line; its key is written again on retry
+		if key := strings.TrimSpace(scanner.Text()); key != "" {
+			journal.keys[key] = struct{}{}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read mark journal %s: %w", path, err)
+	}
+	return journal, nil
+}
+
+// Add records key as posted but not yet marked. The key is kept in memory even when
+// writing the file fails, so the rest of the process still treats it as posted.
+func (j *Journal) Add(key string) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if _, ok := j.keys[key]; ok {
+		return nil
+	}
+	j.keys[key] = struct{}{}
+	if j.path == "" {
+		return nil
+	}
+
+	f, err := os.OpenFile(j.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("open mark journal: %w", err)
+	}
+	if _, err := f.WriteString(key + "\n"); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("append to mark journal: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("sync mark journal: %w", err)
+	}
+	return f.Close()
+}
+
+// Has reports whether key is waiting to be marked.
+func (j *Journal) Has(key string) bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	_, ok := j.keys[key]
+	return ok
+}
+
+// Pending returns the keys waiting to be marked, sorted.
+func (j *Journal) Pending() []string {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return slices.Sorted(maps.Keys(j.keys))
+}
+
+// Len returns the number of keys waiting to be marked.
+func (j *Journal) Len() int {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return len(j.keys)
+}
+
+// Remove drops keys whose markers were written and rewrites the file without them.
+func (j *Journal) Remove(keys ...string) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	for _, key := range keys {
+		delete(j.keys, key)
+	}
+	return j.rewrite()
+}
+
+// Clear drops every key, as when the dedup cache is flushed.
+func (j *Journal) Clear() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	clear(j.keys)
+	return j.rewrite()
+}
+
+// rewrite replaces the file with the keys in memory, through a temporary file renamed
+// over it so a crash leaves either the old or the new journal. The caller holds j.mu.
+func (j *Journal) rewrite() error {
+	if j.path == "" {
+		return nil
+	}
+	if len(j.keys) == 0 {
+		if err := os.Remove(j.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("remove mark journal: %w", err)
+		}
+		return nil
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(j.path), filepath.Base(j.path)+".*")
+	if err != nil {
+		return fmt.Errorf("rewrite mark journal: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	w := bufio.NewWriter(tmp)
+	for _, key := range slices.Sorted(maps.Keys(j.keys)) {
+		_, _ = w.WriteString(key + "\n")
+	}
+	if err := w.Flush(); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("rewrite mark journal: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("sync mark journal: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("rewrite mark journal: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), j.path); err != nil {
+		return fmt.Errorf("replace mark journal: %w", err)
+	}
+	return nil
+}
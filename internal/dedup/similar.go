@@ -0,0 +1,208 @@
+package dedup
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gopost/integration/internal/logger"
+	"github.com/gopost/integration/pkg/pipeline"
+	"github.com/redis/go-redis/v9"
+)
+
+// fingerprintsKey, after the key prefix, is a sorted set of "{simhash hex}:{article ID}"
+// members scored by post time. Each member is also kept in one bucket per band, under
+// "fingerprints:b{bands}:{band}:{band value hex}".
+const fingerprintsKey = "fingerprints"
+
+// minBandBits is the narrowest band worth a bucket; lower thresholds need narrower bands,
+// whose buckets hold so much of the index that FindSimilar scans all of it instead.
+const minBandBits = 4
+
+// NearDuplicateIndex remembers SimHash fingerprints of recently posted article bodies
+// so near-identical copies (the same wire story from two outlets) can be suppressed.
+//
+// Lookups only compare against fingerprints sharing a band with the candidate: the 64
+// bits are split into one more band than the Hamming distance the threshold allows, so
+// any fingerprint within that distance matches the candidate exactly in at least one band.
+type NearDuplicateIndex struct {
+	client    *redis.Client
+	key       string // fingerprintsKey after the key prefix
+	threshold float64
+	bands     int // 0 when the threshold allows too many differing bits to band
+	window    time.Duration
+	logger    logger.Logger
+}
+
+// NearMatch is a previously posted article similar to a candidate.
+type NearMatch struct {
+	ArticleID  string
+	Similarity float64
+}
+
+// NewNearDuplicateIndex returns an index treating fingerprints at least threshold
+// similar (0-1) and posted within window as duplicates, keeping them under prefix
+// (redis.key_prefix).
+func NewNearDuplicateIndex(client *redis.Client, prefix string, threshold float64, window time.Duration, log logger.Logger) *NearDuplicateIndex {
+	// Most differing bits a fingerprint can have and still reach the threshold
+	maxDistance := 0
+	for maxDistance < 64 && pipeline.Similarity(0, 1<<(maxDistance+1)-1) >= threshold {
+		maxDistance++
+	}
+	bands := 0
+	if 64/(maxDistance+1) >= minBandBits {
+		bands = maxDistance + 1
+	}
+	return &NearDuplicateIndex{
+		client:    client,
+		key:       prefix + fingerprintsKey,
+		threshold: threshold,
+		bands:     bands,
+		window:    window,
+		logger:    log,
+	}
+}
+
+// bucketKeys returns the keys of the buckets of fingerprint, one per band.
+func (n *NearDuplicateIndex) bucketKeys(fingerprint uint64) []string {
+	keys := make([]string, 0, n.bands)
+	for band := range n.bands {
+		start, end := band*64/n.bands, (band+1)*64/n.bands
+		value := fingerprint >> start
+		if end-start < 64 {
+			value &= 1<<(end-start) - 1
+		}
+		keys = append(keys, fmt.Sprintf("%s:b%d:%d:%x", n.key, n.bands, band, value))
+	}
+	return keys
+}
+
+// FindSimilar returns the most similar article posted within the window, or nil if none
+// reaches the threshold. Fingerprints older than the window are pruned along the way.
+func (n *NearDuplicateIndex) FindSimilar(ctx context.Context, fingerprint uint64) (*NearMatch, error) {
+	cutoff := strconv.FormatInt(time.Now().Add(-n.window).UnixMilli(), 10)
+	if err := n.client.ZRemRangeByScore(ctx, n.key, "-inf", "("+cutoff).Err(); err != nil {
+		return nil, fmt.Errorf("prune fingerprints: %w", err)
+	}
+
+	members, err := n.candidates(ctx, fingerprint, cutoff)
+	if err != nil {
+		return nil, err
+	}
+
+	var best *NearMatch
+	for _, member := range members {
+		hexPrint, articleID, ok := strings.Cut(member, ":")
+		if !ok {
+			continue
+		}
+		stored, parseErr := strconv.ParseUint(hexPrint, 16, 64)
+		if parseErr != nil {
+			continue
+		}
+		similarity := pipeline.Similarity(fingerprint, stored)
+		if similarity >= n.threshold && (best == nil || similarity > best.Similarity) {
+			best = &NearMatch{ArticleID: articleID, Similarity: similarity}
+		}
+	}
+	return best, nil
+}
+
+// candidates returns the fingerprints posted since cutoff that share a band with
+// fingerprint, pruning the older ones from its buckets, or all of them when unbanded.
+func (n *NearDuplicateIndex) candidates(ctx context.Context, fingerprint uint64, cutoff string) ([]string, error) {
+	if n.bands == 0 {
+		members, err := n.client.ZRange(ctx, n.key, 0, -1).Result()
+		if err != nil {
+			return nil, fmt.Errorf("load fingerprints: %w", err)
+		}
+		return members, nil
+	}
+
+	keys := n.bucketKeys(fingerprint)
+	buckets := make([]*redis.StringSliceCmd, len(keys))
+	_, err := n.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, key := range keys {
+			pipe.ZRemRangeByScore(ctx, key, "-inf", "("+cutoff)
+			buckets[i] = pipe.ZRange(ctx, key, 0, -1)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("load fingerprint buckets: %w", err)
+	}
+
+	seen := make(map[string]bool)
+	var members []string
+	for _, bucket := range buckets {
+		for _, member := range bucket.Val() {
+			if !seen[member] {
+				seen[member] = true
+				members = append(members, member)
+			}
+		}
+	}
+	return members, nil
+}
+
+// Similarity returns how similar two fingerprints are (0-1) and whether that reaches the
+// index's threshold, for comparing against articles not yet in the index.
+func (n *NearDuplicateIndex) Similarity(a, b uint64) (float64, bool) {
+	similarity := pipeline.Similarity(a, b)
+	return similarity, similarity >= n.threshold
+}
+
+// Add records a posted article's fingerprint. Buckets expire once nothing has been added
+// to them for the window.
+func (n *NearDuplicateIndex) Add(ctx context.Context, articleID string, fingerprint uint64) error {
+	entry := redis.Z{Score: float64(time.Now().UnixMilli()), Member: fmt.Sprintf("%016x:%s", fingerprint, articleID)}
+	_, err := n.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZAdd(ctx, n.key, entry)
+		if n.bands > 0 {
+			for _, key := range n.bucketKeys(fingerprint) {
+				pipe.ZAdd(ctx, key, entry)
+				pipe.Expire(ctx, key, n.window)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		n.logger.Error("Redis error recording article fingerprint",
+			logger.String("article_id", articleID),
+			logger.Error(err),
+		)
+		return fmt.Errorf("add fingerprint: %w", err)
+	}
+	return nil
+}
+
+// Remove forgets the fingerprint of an article, so a repost of it is not suppressed as a
+// near-duplicate of itself.
+func (n *NearDuplicateIndex) Remove(ctx context.Context, articleID string) error {
+	members, err := n.client.ZRange(ctx, n.key, 0, -1).Result()
+	if err != nil {
+		return fmt.Errorf("load fingerprints: %w", err)
+	}
+	pipe := n.client.TxPipeline()
+	for _, member := range members {
+		hexPrint, id, ok := strings.Cut(member, ":")
+		if !ok || id != articleID {
+			continue
+		}
+		pipe.ZRem(ctx, n.key, member)
+		if stored, err := strconv.ParseUint(hexPrint, 16, 64); err == nil && n.bands > 0 {
+			for _, key := range n.bucketKeys(stored) {
+				pipe.ZRem(ctx, key, member)
+			}
+		}
+	}
+	if pipe.Len() == 0 {
+		return nil
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("remove fingerprint of %s: %w", articleID, err)
+	}
+	return nil
+}
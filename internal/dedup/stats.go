@@ -0,0 +1,167 @@
+package dedup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// statsScanBatch is the SCAN COUNT hint used when walking the markers for statistics.
+const statsScanBatch = 1000
+
+// ErrNotTracked is returned by TTLOf when the article has no marker.
+var ErrNotTracked = errors.New("article has no dedup marker")
+
+// Marker is one article's dedup marker, as listed by OldestEntries. Title markers are
+// listed with an ID of "title:<hash>".
+type Marker struct {
+	ID string `json:"id"`
+	Entry
+}
+
+// scan calls fn with each batch of marker keys under the tracker's prefix.
+func (t *Tracker) scan(ctx context.Context, fn func(keys []string) error) error {
+	pattern := t.prefix + "*"
+	var cursor uint64
+	for {
+		keys, next, err := t.client.Scan(ctx, cursor, pattern, statsScanBatch).Result()
+		if err != nil {
+			return fmt.Errorf("scan %s: %w", pattern, err)
+		}
+		if len(keys) > 0 {
+			if err := fn(keys); err != nil {
+				return err
+			}
+		}
+		if next == 0 {
+			return nil
+		}
+		cursor = next
+	}
+}
+
+// Count returns the number of markers, posted and pending, article and title. It walks
+// the key space with SCAN, so on a large set it takes a while but does not block Redis.
+func (t *Tracker) Count(ctx context.Context) (int64, error) {
+	var count int64
+	err := t.scan(ctx, func(keys []string) error {
+		count += int64(len(keys))
+		return nil
+	})
+	return count, err
+}
+
+// OldestEntries returns up to n posted markers closest to expiry, soonest first. Markers
+// are all written with the same TTL, so these are the oldest ones; markers that never
+// expire (dedup_ttl 0, or legacy keys) carry no age and are listed after them. Pending
+// reservations are in-flight posts and are left out.
+func (t *Tracker) OldestEntries(ctx context.Context, n int) ([]Marker, error) {
+	if n <= 0 {
+		return nil, nil
+	}
+	var oldest []Marker
+	err := t.scan(ctx, func(keys []string) error {
+		pipe := t.client.Pipeline()
+		gets := make([]*redis.StringCmd, len(keys))
+		ttls := make([]*redis.DurationCmd, len(keys))
+		for i, key := range keys {
+			gets[i] = pipe.Get(ctx, key)
+			ttls[i] = pipe.PTTL(ctx, key)
+		}
+		if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+			return fmt.Errorf("read markers: %w", err)
+		}
+		for i, key := range keys {
+			value, err := gets[i].Result()
+			if err != nil || value == statePending {
+				// Expired since the scan, or an in-flight post
+				continue
+			}
+			marker := Marker{ID: strings.TrimPrefix(key, t.prefix), Entry: Entry{State: StatePosted}}
+			if remaining := ttls[i].Val(); remaining > 0 {
+				marker.TTL = remaining
+			}
+			oldest = append(oldest, marker)
+		}
+		if len(oldest) > n {
+			slices.SortFunc(oldest, compareExpiry)
+			oldest = oldest[:n]
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	slices.SortFunc(oldest, compareExpiry)
+	return oldest, nil
+}
+
+// compareExpiry orders markers by remaining TTL, those without one last, then by ID.
+func compareExpiry(a, b Marker) int {
+	switch {
+	case a.TTL == b.TTL:
+		return strings.Compare(a.ID, b.ID)
+	case a.TTL == 0:
+		return 1
+	case b.TTL == 0:
+		return -1
+	case a.TTL < b.TTL:
+		return -1
+	default:
+		return 1
+	}
+}
+
+// TTLOf returns the time until the article's marker expires, 0 when it never expires, or
+// ErrNotTracked when there is no marker.
+func (t *Tracker) TTLOf(ctx context.Context, articleID string) (time.Duration, error) {
+	ttl, err := t.client.PTTL(ctx, t.key(articleID)).Result()
+	if err != nil {
+		return 0, fmt.Errorf("ttl of article %s: %w", articleID, err)
+	}
+	// go-redis passes PTTL's -2 (no key) and -1 (no expiry) through unscaled
+	switch ttl {
+	case -2:
+		return 0, ErrNotTracked
+	case -1:
+		return 0, nil
+	}
+	return ttl, nil
+}
+
+// AverageSize returns the average size in bytes of up to sample markers, as reported by
+// MEMORY USAGE, for estimating the set's footprint as Count times the average. It
+// returns 0 when there are no markers.
+func (t *Tracker) AverageSize(ctx context.Context, sample int) (int64, error) {
+	var total, sampled int64
+	errSampled := errors.New("sampled")
+	err := t.scan(ctx, func(keys []string) error {
+		for _, key := range keys {
+			if sampled >= int64(sample) {
+				return errSampled
+			}
+			size, err := t.client.MemoryUsage(ctx, key).Result()
+			if errors.Is(err, redis.Nil) {
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("memory usage of %s: %w", key, err)
+			}
+			total += size
+			sampled++
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, errSampled) {
+		return 0, err
+	}
+	if sampled == 0 {
+		return 0, nil
+	}
+	return total / sampled, nil
+}
@@ -0,0 +1,54 @@
+package dedup
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// suppressedKey, after the key prefix, is a set of the IDs of articles that must never be
+// posted again.
+const suppressedKey = "suppressed"
+
+// SuppressionList remembers articles that must not be posted again, such as those whose
+// node an editor deleted. Unlike posted markers, suppressions never expire.
+type SuppressionList struct {
+	client *redis.Client
+	key    string // suppressedKey after the key prefix
+}
+
+// NewSuppressionList returns a suppression list backed by client, under prefix
+// (redis.key_prefix).
+func NewSuppressionList(client *redis.Client, prefix string) *SuppressionList {
+	return &SuppressionList{client: client, key: prefix + suppressedKey}
+}
+
+// Add suppresses the articles.
+func (l *SuppressionList) Add(ctx context.Context, articleIDs ...string) error {
+	members := make([]any, len(articleIDs))
+	for i, id := range articleIDs {
+		members[i] = id
+	}
+	if err := l.client.SAdd(ctx, l.key, members...).Err(); err != nil {
+		return fmt.Errorf("suppress articles: %w", err)
+	}
+	return nil
+}
+
+// Remove lifts the suppression of an article.
+func (l *SuppressionList) Remove(ctx context.Context, articleID string) error {
+	if err := l.client.SRem(ctx, l.key, articleID).Err(); err != nil {
+		return fmt.Errorf("unsuppress article %s: %w", articleID, err)
+	}
+	return nil
+}
+
+// Contains reports whether the article is suppressed.
+func (l *SuppressionList) Contains(ctx context.Context, articleID string) (bool, error) {
+	suppressed, err := l.client.SIsMember(ctx, l.key, articleID).Result()
+	if err != nil {
+		return false, fmt.Errorf("check suppression of %s: %w", articleID, err)
+	}
+	return suppressed, nil
+}
@@ -2,6 +2,7 @@ package dedup
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -9,24 +10,77 @@ import (
 	"github.com/redis/go-redis/v9"
 )
 
+// Values stored under an article key. Keys written before two-phase marking hold "1",
+// which is treated as posted.
+const (
+	statePending = "pending"
+	statePosted  = "posted"
+)
+
+// Entry states reported by Lookup.
+const (
+	StateNone    = "none"
+	StatePending = statePending
+	StatePosted  = statePosted
+)
+
+// Entry is the dedup state of one article.
+type Entry struct {
+	State string        `json:"state"`         // StateNone, StatePending, or StatePosted
+	TTL   time.Duration `json:"ttl,omitempty"` // Time until the marker expires; 0 when it never expires
+}
+
+// releaseScript deletes a key only while it still holds a pending reservation, so a
+// release never undoes a posted marker written by another worker.
+var releaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// ArticleKeyPrefix, after the key prefix, starts the key of each article's marker:
+// posted:article:<article_id>. Title dedup markers are posted:article:title:<hash>.
+const ArticleKeyPrefix = "posted:article:"
+
+// Tracker records posted articles in Redis using two-phase marking: Reserve claims an
+// article as pending for a short lease before it is posted, MarkPosted promotes it to
+// posted for ttl, and Release drops the reservation when posting fails. Concurrent
+// workers and retries after a crash therefore converge on a single post per article.
 type Tracker struct {
 	client *redis.Client
+	prefix string // Key prefix followed by ArticleKeyPrefix
 	ttl    time.Duration
+	lease  time.Duration
 	logger logger.Logger
+	bloom  *BloomFilter // nil checks every article exactly
 }
 
-func NewTracker(client *redis.Client, ttl time.Duration, log logger.Logger) *Tracker {
+// NewTracker returns a tracker that keeps posted markers under prefix (redis.key_prefix)
+// for ttl (0 means forever) and pending reservations for lease. The lease must outlast a
+// Drupal post; if the process dies mid-post, the article becomes eligible again once the
+// lease expires.
+func NewTracker(client *redis.Client, prefix string, ttl, lease time.Duration, log logger.Logger) *Tracker {
 	return &Tracker{
 		client: client,
+		prefix: prefix + ArticleKeyPrefix,
 		ttl:    ttl,
+		lease:  lease,
 		logger: log,
 	}
 }
 
+// UseBloomFilter puts filter in front of HasPosted's exact checks. Replicas sharing the
+// dedup keys should all use it, or markers they write are missing from the filter.
+func (t *Tracker) UseBloomFilter(filter *BloomFilter) {
+	t.bloom = filter
+}
+
 func (t *Tracker) key(articleID string) string {
-	return fmt.Sprintf("posted:article:%s", articleID)
+	return t.prefix + articleID
 }
 
+// HasPosted reports whether the article is posted or reserved by an in-flight post.
 func (t *Tracker) HasPosted(ctx context.Context, articleID string) bool {
 	key := t.key(articleID)
 
@@ -35,6 +89,13 @@ func (t *Tracker) HasPosted(ctx context.Context, articleID string) bool {
 		logger.String("redis_key", key),
 	)
 
+	if t.bloom != nil && t.definitelyNew(ctx, articleID) {
+		t.logger.Debug("Article not in bloom filter",
+			logger.String("article_id", articleID),
+		)
+		return false
+	}
+
 	exists, err := t.client.Exists(ctx, key).Result()
 	if err != nil {
 		t.logger.Error("Redis error checking article",
@@ -62,6 +123,91 @@ func (t *Tracker) HasPosted(ctx context.Context, articleID string) bool {
 	return alreadyPosted
 }
 
+// Lookup returns the article's dedup state for operators. Unlike HasPosted, Redis errors
+// are returned rather than treated as not posted.
+func (t *Tracker) Lookup(ctx context.Context, articleID string) (Entry, error) {
+	key := t.key(articleID)
+
+	pipe := t.client.Pipeline()
+	get := pipe.Get(ctx, key)
+	ttl := pipe.PTTL(ctx, key)
+	if _, err := pipe.Exec(ctx); err != nil && !errors.Is(err, redis.Nil) {
+		return Entry{}, fmt.Errorf("look up article %s: %w", articleID, err)
+	}
+
+	value, err := get.Result()
+	if errors.Is(err, redis.Nil) {
+		return Entry{State: StateNone}, nil
+	}
+	if err != nil {
+		return Entry{}, fmt.Errorf("look up article %s: %w", articleID, err)
+	}
+
+	entry := Entry{State: StatePosted}
+	if value == statePending {
+		entry.State = StatePending
+	}
+	if remaining := ttl.Val(); remaining > 0 {
+		entry.TTL = remaining
+	}
+	return entry, nil
+}
+
+// Reserve marks the article as pending before it is posted. It returns false if the
+// article is already posted or reserved by another worker.
+func (t *Tracker) Reserve(ctx context.Context, articleID string) (bool, error) {
+	key := t.key(articleID)
+
+	reserved, err := t.client.SetNX(ctx, key, statePending, t.lease).Result()
+	if err != nil {
+		t.logger.Error("Redis error reserving article",
+			logger.String("article_id", articleID),
+			logger.String("redis_key", key),
+			logger.Error(err),
+		)
+		return false, fmt.Errorf("reserve article %s: %w", articleID, err)
+	}
+
+	t.logger.Debug("Article reservation",
+		logger.String("article_id", articleID),
+		logger.String("redis_key", key),
+		logger.Bool("reserved", reserved),
+		logger.Duration("lease", t.lease),
+	)
+	if reserved && t.bloom != nil {
+		// Other replicas still see the reservation through Reserve itself
+		if err := t.bloom.add(ctx, articleID); err != nil {
+			t.logger.Warn("Redis error adding reserved article to bloom filter",
+				logger.String("article_id", articleID),
+				logger.Error(err),
+			)
+		}
+	}
+	return reserved, nil
+}
+
+// Release drops a pending reservation after a failed post so the article can be retried.
+// Posted markers are left untouched.
+func (t *Tracker) Release(ctx context.Context, articleID string) error {
+	key := t.key(articleID)
+
+	if err := releaseScript.Run(ctx, t.client, []string{key}, statePending).Err(); err != nil {
+		t.logger.Error("Redis error releasing article",
+			logger.String("article_id", articleID),
+			logger.String("redis_key", key),
+			logger.Error(err),
+		)
+		return fmt.Errorf("release article %s: %w", articleID, err)
+	}
+
+	t.logger.Debug("Article reservation released",
+		logger.String("article_id", articleID),
+		logger.String("redis_key", key),
+	)
+	return nil
+}
+
+// MarkPosted promotes the article to posted, whether or not it was reserved first.
 func (t *Tracker) MarkPosted(ctx context.Context, articleID string) error {
 	key := t.key(articleID)
 
@@ -71,7 +217,7 @@ func (t *Tracker) MarkPosted(ctx context.Context, articleID string) error {
 		logger.Duration("ttl", t.ttl),
 	)
 
-	err := t.client.Set(ctx, key, "1", t.ttl).Err()
+	err := t.client.Set(ctx, key, statePosted, t.ttl).Err()
 	if err != nil {
 		t.logger.Error("Redis error marking article as posted",
 			logger.String("article_id", articleID),
@@ -81,6 +227,16 @@ func (t *Tracker) MarkPosted(ctx context.Context, articleID string) error {
 		)
 		return err
 	}
+	if t.bloom != nil {
+		// Reported as a failed mark, so the caller retries until the filter has the article
+		if err := t.bloom.add(ctx, articleID); err != nil {
+			t.logger.Error("Redis error adding posted article to bloom filter",
+				logger.String("article_id", articleID),
+				logger.Error(err),
+			)
+			return err
+		}
+	}
 
 	t.logger.Debug("Article marked as posted",
 		logger.String("article_id", articleID),
@@ -116,14 +272,14 @@ func (t *Tracker) Clear(ctx context.Context, articleID string) error {
 	return nil
 }
 
-// FlushAll removes all posted article keys from Redis
+// FlushAll removes all posted article keys under the key prefix from Redis
 // This will clear the entire deduplication cache
 func (t *Tracker) FlushAll(ctx context.Context) error {
 	t.logger.Info("Flushing all posted article keys from Redis cache")
 
-	// Use SCAN to find all keys matching the pattern "posted:article:*"
+	// Use SCAN to find all keys matching the pattern "<prefix>posted:article:*"
 	// This is safer than FLUSHDB which would clear the entire Redis database
-	pattern := "posted:article:*"
+	pattern := t.prefix + "*"
 	var cursor uint64
 	var deletedCount int
 
@@ -157,6 +313,12 @@ func (t *Tracker) FlushAll(ctx context.Context) error {
 		}
 	}
 
+	if t.bloom != nil {
+		if err := t.bloom.clear(ctx); err != nil {
+			return err
+		}
+	}
+
 	t.logger.Info("Flushed Redis cache",
 		logger.Int("keys_deleted", deletedCount),
 		logger.String("pattern", pattern),
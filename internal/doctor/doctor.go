@@ -0,0 +1,361 @@
+// Package doctor diagnoses the environment gopost runs in: process limits, name resolution
+// and TLS certificates of the configured backends, clock skew against them, and Redis
+// latency. Each finding carries a remediation hint.
+package doctor
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"runtime"
+	"time"
+
+	"github.com/gopost/integration/internal/config"
+	"github.com/gopost/integration/internal/redisconn"
+	"github.com/redis/go-redis/v9"
+)
+
+// Thresholds above which a finding is reported as a warning or failure.
+const (
+	minOpenFiles     = 4096                // Soft open-file limit below which bursts of connections can fail
+	certExpiryWarn   = 14 * 24 * time.Hour // Certificates expiring sooner are reported
+	clockSkewWarn    = 2 * time.Second     // Date headers have one-second resolution
+	clockSkewFail    = time.Minute
+	redisLatencyWarn = 10 * time.Millisecond // Average PING round trip
+	redisPings       = 5
+	checkTimeout     = 5 * time.Second
+)
+
+// Status is the outcome of a check.
+type Status int
+
+const (
+	StatusOK   Status = iota // Nothing to fix
+	StatusWarn               // Works, but worth fixing
+	StatusFail               // Posting is likely to fail
+)
+
+func (s Status) String() string {
+	switch s {
+	case StatusWarn:
+		return "WARN"
+	case StatusFail:
+		return "FAIL"
+	default:
+		return "OK"
+	}
+}
+
+// Check is one diagnosed aspect of the environment.
+type Check struct {
+	Name   string
+	Status Status
+	Detail string // What was found
+	Hint   string // How to fix it; empty when the check passed
+}
+
+// Report lists the checks in the order they ran.
+type Report struct {
+	Checks []Check
+}
+
+// Failed reports whether any check failed. Warnings do not fail the report.
+func (r Report) Failed() bool {
+	for _, check := range r.Checks {
+		if check.Status == StatusFail {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Report) add(check Check) {
+	r.Checks = append(r.Checks, check)
+}
+
+// Option configures optional Run behavior.
+type Option func(*doctor)
+
+// WithRootCAs verifies backend certificates against pool instead of the system roots.
+func WithRootCAs(pool *x509.CertPool) Option {
+	return func(d *doctor) {
+		d.rootCAs = pool
+	}
+}
+
+// WithNow sets the clock compared with the backends' Date headers (default: time.Now).
+func WithNow(now func() time.Time) Option {
+	return func(d *doctor) {
+		d.now = now
+	}
+}
+
+type doctor struct {
+	rootCAs  *x509.CertPool
+	now      func() time.Time
+	resolver *net.Resolver
+}
+
+// target is a configured backend checked by Run.
+type target struct {
+	name          string // e.g. "elasticsearch" or "acme: drupal"
+	key           string // Config key of the address, for hints
+	url           *url.URL
+	skipTLSVerify bool
+	clock         bool // Compare the clock with the backend's Date header
+}
+
+// Run checks the process limits and every backend configured in cfg, including those of
+// each tenant in multi-tenant mode.
+func Run(ctx context.Context, cfg *config.Config, opts ...Option) Report {
+	d := &doctor{now: time.Now, resolver: net.DefaultResolver}
+	for _, opt := range opts {
+		opt(d)
+	}
+
+	var report Report
+	report.add(Check{
+		Name:   "runtime",
+		Status: StatusOK,
+		Detail: fmt.Sprintf("%s %s/%s, GOMAXPROCS=%d, %d CPUs",
+			runtime.Version(), runtime.GOOS, runtime.GOARCH, runtime.GOMAXPROCS(0), runtime.NumCPU()),
+	})
+	if check, ok := openFilesCheck(); ok {
+		report.add(check)
+	}
+
+	if len(cfg.Tenants) == 0 {
+		d.checkPipeline(ctx, cfg, "", &report)
+	}
+	for _, tenant := range cfg.Tenants {
+		d.checkPipeline(ctx, cfg.ForTenant(tenant), tenant.Name+": ", &report)
+	}
+	if cfg.Sources.Enabled {
+		if u, err := url.Parse(cfg.Sources.URL); err == nil && u.Host != "" {
+			d.checkTarget(ctx, target{name: "sources", key: "sources.url", url: u}, &report)
+		}
+	}
+	return report
+}
+
+// checkPipeline checks the Elasticsearch, Drupal, and Redis backends of one pipeline.
+func (d *doctor) checkPipeline(ctx context.Context, cfg *config.Config, prefix string, report *Report) {
+	esURL, _ := cfg.Elasticsearch.Endpoint() // An invalid cloud_id fails as an invalid URL
+	targets := []struct {
+		target
+		raw string
+	}{
+		{target{name: prefix + "elasticsearch", key: "elasticsearch.url", clock: true}, esURL},
+		{target{name: prefix + "drupal", key: "drupal.url", clock: true, skipTLSVerify: cfg.Drupal.SkipTLSVerify}, cfg.Drupal.URL},
+	}
+	for _, t := range targets {
+		u, err := url.Parse(t.raw)
+		if err != nil || u.Host == "" {
+			report.add(Check{
+				Name:   t.name,
+				Status: StatusFail,
+				Detail: fmt.Sprintf("invalid URL %q", t.raw),
+				Hint:   fmt.Sprintf("set %s to an absolute URL such as https://host:port", t.key),
+			})
+			continue
+		}
+		t.url = u
+		d.checkTarget(ctx, t.target, report)
+	}
+
+	// redis.url is a host:port address rather than a URL
+	redisTarget := target{name: prefix + "redis", key: "redis.url", url: &url.URL{Host: cfg.Redis.URL}}
+	if d.checkDNS(ctx, redisTarget, report) {
+		report.add(d.redisLatency(ctx, prefix+"redis", cfg.Redis))
+	}
+}
+
+// checkTarget checks a backend's name resolution, then its certificate and clock.
+func (d *doctor) checkTarget(ctx context.Context, t target, report *Report) {
+	if !d.checkDNS(ctx, t, report) {
+		return
+	}
+	if t.url.Scheme == "https" {
+		report.add(d.tlsCheck(ctx, t))
+	}
+	if t.clock {
+		report.add(d.clockCheck(ctx, t))
+	}
+}
+
+// checkDNS adds the name resolution check of a target and reports whether it resolved.
+func (d *doctor) checkDNS(ctx context.Context, t target, report *Report) bool {
+	host := t.url.Hostname()
+	name := fmt.Sprintf("%s dns (%s)", t.name, host)
+	if net.ParseIP(host) != nil {
+		return true
+	}
+
+	lookupCtx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+	addrs, err := d.resolver.LookupHost(lookupCtx, host)
+	if err != nil {
+		report.add(Check{
+			Name:   name,
+			Status: StatusFail,
+			Detail: err.Error(),
+			Hint:   fmt.Sprintf("check the host name in %s, and the resolvers in /etc/resolv.conf (or the container's DNS settings)", t.key),
+		})
+		return false
+	}
+	report.add(Check{Name: name, Status: StatusOK, Detail: fmt.Sprintf("resolves to %v", addrs)})
+	return true
+}
+
+// tlsCheck verifies the certificate chain a target presents and how long it stays valid.
+func (d *doctor) tlsCheck(ctx context.Context, t target) Check {
+	addr := t.url.Host
+	if t.url.Port() == "" {
+		addr = net.JoinHostPort(t.url.Hostname(), "443")
+	}
+	check := Check{Name: fmt.Sprintf("%s tls (%s)", t.name, addr)}
+
+	dialer := &tls.Dialer{
+		NetDialer: &net.Dialer{Timeout: checkTimeout},
+		Config:    &tls.Config{ServerName: t.url.Hostname(), RootCAs: d.rootCAs},
+	}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		check.Status = StatusFail
+		check.Detail = err.Error()
+		check.Hint = tlsHint(err, t.key)
+		if t.skipTLSVerify {
+			// Posting still works, so this is not fatal, but it should not reach production
+			check.Status = StatusWarn
+			check.Hint += "; drupal.skip_tls_verify currently hides this"
+		}
+		return check
+	}
+	defer conn.Close()
+
+	chain := conn.(*tls.Conn).ConnectionState().PeerCertificates
+	leaf := chain[0]
+	remaining := leaf.NotAfter.Sub(d.now())
+	check.Detail = fmt.Sprintf("issued by %q, valid until %s", leaf.Issuer.CommonName, leaf.NotAfter.Format(time.DateOnly))
+	check.Status = StatusOK
+	if remaining < certExpiryWarn {
+		check.Status = StatusWarn
+		check.Hint = fmt.Sprintf("the certificate expires in %s; renew it", remaining.Round(time.Hour))
+	}
+	return check
+}
+
+// tlsHint suggests a fix for a failed TLS handshake.
+func tlsHint(err error, key string) string {
+	var unknownAuthority x509.UnknownAuthorityError
+	var hostname x509.HostnameError
+	var invalid x509.CertificateInvalidError
+	switch {
+	case errors.As(err, &unknownAuthority):
+		return "the chain does not lead to a trusted root: serve the intermediate certificates, or install the issuing CA on this host (or point SSL_CERT_FILE at it)"
+	case errors.As(err, &hostname):
+		return fmt.Sprintf("the certificate does not cover the host in %s: use a name it lists, or reissue it", key)
+	case errors.As(err, &invalid) && invalid.Reason == x509.Expired:
+		return "the certificate has expired (or this host's clock is wrong); renew it"
+	default:
+		return fmt.Sprintf("check that %s points at a TLS endpoint and that no proxy intercepts the connection", key)
+	}
+}
+
+// clockCheck compares the local clock with the Date header of a target's response. Any
+// response will do, so the request is not authenticated and certificates are not verified
+// (tlsCheck covers them).
+func (d *doctor) clockCheck(ctx context.Context, t target) Check {
+	check := Check{Name: fmt.Sprintf("%s clock skew", t.name)}
+	client := &http.Client{
+		Timeout:   checkTimeout,
+		Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}, //nolint:gosec // Only the Date header is read
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, t.url.String(), http.NoBody)
+	if err != nil {
+		check.Status = StatusFail
+		check.Detail = err.Error()
+		return check
+	}
+	sent := d.now()
+	resp, err := client.Do(req)
+	if err != nil {
+		check.Status = StatusFail
+		check.Detail = err.Error()
+		check.Hint = fmt.Sprintf("check that %s is reachable from this host (firewall, proxy, or security group)", t.key)
+		return check
+	}
+	resp.Body.Close()
+	received := d.now()
+
+	serverTime, err := http.ParseTime(resp.Header.Get("Date"))
+	if err != nil {
+		check.Status = StatusWarn
+		check.Detail = "the response has no valid Date header"
+		check.Hint = "the clock could not be compared; check it against NTP manually"
+		return check
+	}
+	// The server stamped the response somewhere during the round trip
+	local := sent.Add(received.Sub(sent) / 2)
+	skew := local.Sub(serverTime).Round(time.Second)
+	check.Detail = fmt.Sprintf("local clock is %s ahead of the server", skew)
+	if skew < 0 {
+		check.Detail = fmt.Sprintf("local clock is %s behind the server", -skew)
+	}
+
+	switch abs := max(skew, -skew); {
+	case abs > clockSkewFail:
+		check.Status = StatusFail
+	case abs > clockSkewWarn:
+		check.Status = StatusWarn
+	default:
+		check.Status = StatusOK
+		return check
+	}
+	check.Hint = "synchronize this host's clock with NTP (e.g. timedatectl set-ntp true); skew shifts the search window and Redis expiry times"
+	return check
+}
+
+// redisLatency measures the average PING round trip to Redis.
+func (d *doctor) redisLatency(ctx context.Context, name string, redisCfg config.RedisConfig) Check {
+	check := Check{Name: fmt.Sprintf("%s latency (%s)", name, redisCfg.URL)}
+	opts, err := redisconn.Options(redisCfg)
+	if err != nil {
+		check.Status = StatusFail
+		check.Detail = err.Error()
+		return check
+	}
+	client := redis.NewClient(opts)
+	defer client.Close()
+
+	pingCtx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+	var total, slowest time.Duration
+	for range redisPings {
+		start := time.Now()
+		if err := client.Ping(pingCtx).Err(); err != nil {
+			check.Status = StatusFail
+			check.Detail = err.Error()
+			check.Hint = "check redis.url, redis.username and redis.password, redis.tls, and that Redis accepts connections from this host"
+			return check
+		}
+		elapsed := time.Since(start)
+		total += elapsed
+		slowest = max(slowest, elapsed)
+	}
+
+	average := total / redisPings
+	check.Detail = fmt.Sprintf("average PING %s, slowest %s", average.Round(time.Microsecond), slowest.Round(time.Microsecond))
+	check.Status = StatusOK
+	if average > redisLatencyWarn {
+		check.Status = StatusWarn
+		check.Hint = "every article takes several Redis round trips; run Redis closer to gopost or check the network path"
+	}
+	return check
+}
@@ -0,0 +1,72 @@
+package doctor_test
+
+import (
+	"context"
+	"crypto/x509"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gopost/integration/internal/config"
+	"github.com/gopost/integration/internal/doctor"
+)
+
+func TestRun(t *testing.T) {
+	drupalServer := httptest.NewUnstartedServer(http.NotFoundHandler())
+	drupalServer.Config.ErrorLog = log.New(io.Discard, "", 0) // Handshakes rejected by the untrusted case
+	drupalServer.StartTLS()
+	t.Cleanup(drupalServer.Close)
+	esServer := httptest.NewServer(http.NotFoundHandler())
+	t.Cleanup(esServer.Close)
+	redisServer := miniredis.RunT(t)
+	trusted := x509.NewCertPool()
+	trusted.AddCert(drupalServer.Certificate())
+
+	cfg := &config.Config{
+		Elasticsearch: config.ElasticsearchConfig{URL: esServer.URL},
+		Drupal:        config.DrupalConfig{URL: drupalServer.URL},
+		Redis:         config.RedisConfig{URL: redisServer.Addr()},
+		Sources:       config.SourcesConfig{Enabled: true, URL: "http://sources.gopost.invalid"},
+	}
+	fastClock := func() time.Time { return time.Now().Add(5 * time.Minute) }
+
+	tests := []struct {
+		name  string
+		opts  []doctor.Option
+		check string // Name prefix of the check
+		want  doctor.Status
+		hint  string // Substring of the expected hint
+	}{
+		{name: "trusted certificate", opts: []doctor.Option{doctor.WithRootCAs(trusted)}, check: "drupal tls", want: doctor.StatusOK},
+		{name: "untrusted certificate", check: "drupal tls", want: doctor.StatusFail, hint: "trusted root"},
+		{name: "clock in sync", check: "elasticsearch clock skew", want: doctor.StatusOK},
+		{name: "clock ahead", opts: []doctor.Option{doctor.WithNow(fastClock)}, check: "elasticsearch clock skew", want: doctor.StatusFail, hint: "NTP"},
+		{name: "redis reachable", check: "redis latency", want: doctor.StatusOK},
+		{name: "unresolvable host", check: "sources dns", want: doctor.StatusFail, hint: "sources.url"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := doctor.Run(context.Background(), cfg, tt.opts...)
+
+			for _, check := range report.Checks {
+				if !strings.HasPrefix(check.Name, tt.check) {
+					continue
+				}
+				if check.Status != tt.want || !strings.Contains(check.Hint, tt.hint) {
+					t.Errorf("%s = %s (%s), hint %q; want %s with hint containing %q",
+						check.Name, check.Status, check.Detail, check.Hint, tt.want, tt.hint)
+				}
+				if tt.want == doctor.StatusFail && !report.Failed() {
+					t.Error("Failed() = false, want true")
+				}
+				return
+			}
+			t.Fatalf("no %q check in %+v", tt.check, report.Checks)
+		})
+	}
+}
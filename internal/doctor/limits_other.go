@@ -0,0 +1,8 @@
+//go:build !unix
+
+package doctor
+
+// openFilesCheck is skipped where the open-file limit cannot be read.
+func openFilesCheck() (Check, bool) {
+	return Check{}, false
+}
@@ -0,0 +1,27 @@
+//go:build unix
+
+package doctor
+
+import (
+	"fmt"
+	"syscall"
+)
+
+// openFilesCheck reports the soft and hard open-file limits of the process.
+func openFilesCheck() (Check, bool) {
+	var limit syscall.Rlimit
+	if err := syscall.Getrlimit(syscall.RLIMIT_NOFILE, &limit); err != nil {
+		return Check{Name: "open files limit", Status: StatusWarn, Detail: err.Error()}, true
+	}
+
+	check := Check{
+		Name:   "open files limit",
+		Status: StatusOK,
+		Detail: fmt.Sprintf("soft %d, hard %d", limit.Cur, limit.Max),
+	}
+	if limit.Cur < minOpenFiles {
+		check.Status = StatusWarn
+		check.Hint = fmt.Sprintf("raise the soft limit to at least %d: ulimit -n, or LimitNOFILE= in the systemd unit", minOpenFiles)
+	}
+	return check, true
+}
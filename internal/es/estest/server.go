@@ -0,0 +1,510 @@
+// Package estest provides an in-memory fake Elasticsearch search endpoint for tests.
+// It serves canned hits per index to the official go-elasticsearch client,
+// enabling end-to-end pipeline tests without a running cluster.
+package estest
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/elastic/go-elasticsearch/v8"
+)
+
+// Hit is a canned search hit.
+type Hit struct {
+	ID     string         // Document _id
+	Source any            // Document _source, encoded as JSON
+	Fields map[string]any // Document fields, e.g. "_percolator_document_slot" for a percolate search
+
+	Highlight map[string][]string // Highlighted fragments by field, returned whether or not the query asks for them
+}
+
+// Request is a search request received by the fake server.
+type Request struct {
+	Index string         // Index from the request path, or the header of a multi search
+	Body  map[string]any // Decoded query body
+	Multi bool           // Whether the search was part of a multi search
+}
+
+// failure is a queued error response returned instead of handling the next search.
+type failure struct {
+	statusCode int
+	errorType  string
+	reason     string
+}
+
+// Server is a fake Elasticsearch server backed by httptest.
+// Only the _search, _msearch, search template, stored script, _cat/indices, create index,
+// mapping, and index-by-ID APIs are implemented; the query itself is recorded but not
+// evaluated, so every search against an index returns that index's canned hits (honoring
+// "size"). Indexed documents become canned hits.
+type Server struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	indices  map[string][]Hit
+	mappings map[string]map[string]any // Field mappings ("properties") by index
+	closed   map[string]bool           // Indices whose searches fail with index_closed_exception
+	scripts  map[string]string         // Stored search template sources by ID
+	requests []Request
+	failures []failure
+
+	multiSearchOff bool // _msearch is rejected, as by a proxy or security setup blocking it
+}
+
+// NewServer starts a fake Elasticsearch server with no indices.
+// The server is closed automatically when the test finishes.
+func NewServer(tb testing.TB) *Server {
+	tb.Helper()
+
+	s := &Server{
+		indices:  make(map[string][]Hit),
+		mappings: make(map[string]map[string]any),
+		closed:   make(map[string]bool),
+		scripts:  make(map[string]string),
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/{index}/_search", s.handleSearch)
+	mux.HandleFunc("POST /_msearch", s.handleMultiSearch)
+	mux.HandleFunc("/{index}/_search/template", s.handleSearchTemplate)
+	mux.HandleFunc("GET /_cat/indices/{pattern}", s.handleCatIndices)
+	mux.HandleFunc("PUT /{index}", s.handleCreateIndex)
+	mux.HandleFunc("GET /{index}/_mapping", s.handleGetMapping)
+	mux.HandleFunc("PUT /{index}/_mapping", s.handlePutMapping)
+	mux.HandleFunc("PUT /{index}/_doc/{id}", s.handleIndexDocument)
+	mux.HandleFunc("POST /{index}/_update/{id}", s.handleUpdateDocument)
+
+	// PUT /_scripts/{id} would conflict with PUT /{index}/_mapping in the mux
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if id, ok := strings.CutPrefix(r.URL.Path, "/_scripts/"); ok && r.Method == http.MethodPut {
+			s.handlePutScript(w, r, id)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	}))
+	tb.Cleanup(s.Close)
+	return s
+}
+
+// Client returns a go-elasticsearch client pointed at the fake server.
+func (s *Server) Client(tb testing.TB) *elasticsearch.Client {
+	tb.Helper()
+
+	client, err := elasticsearch.NewClient(elasticsearch.Config{Addresses: []string{s.URL}})
+	if err != nil {
+		tb.Fatalf("estest: create client: %v", err)
+	}
+	return client
+}
+
+// CreateIndex registers an empty index so searches return zero hits instead of index_not_found.
+func (s *Server) CreateIndex(index string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.indices[index]; !ok {
+		s.indices[index] = nil
+	}
+}
+
+// CloseIndex makes searches of an index fail as closed until OpenIndex.
+func (s *Server) CloseIndex(index string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed[index] = true
+}
+
+// OpenIndex reopens an index closed by CloseIndex.
+func (s *Server) OpenIndex(index string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.closed, index)
+}
+
+// AddHits appends canned hits to an index, creating it if needed.
+func (s *Server) AddHits(index string, hits ...Hit) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.indices[index] = append(s.indices[index], hits...)
+}
+
+// SetMapping sets the field mappings of an index, creating it if needed.
+func (s *Server) SetMapping(index string, properties map[string]any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.indices[index]; !ok {
+		s.indices[index] = nil
+	}
+	s.mappings[index] = properties
+}
+
+// Mapping returns the field mappings of an index, or nil if it has none.
+func (s *Server) Mapping(index string) map[string]any {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.mappings[index]
+}
+
+// Documents returns the hits of an index, including documents indexed through the API.
+func (s *Server) Documents(index string) []Hit {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Hit(nil), s.indices[index]...)
+}
+
+// Script returns the source of the search template stored under id.
+func (s *Server) Script(id string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	source, ok := s.scripts[id]
+	return source, ok
+}
+
+// Requests returns a copy of all search requests received so far.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// DisableMultiSearch makes _msearch requests fail as unauthorized.
+func (s *Server) DisableMultiSearch() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.multiSearchOff = true
+}
+
+// FailNext makes the next search return an Elasticsearch error response. In a multi
+// search only that search fails.
+func (s *Server) FailNext(statusCode int, errorType, reason string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures = append(s.failures, failure{statusCode: statusCode, errorType: errorType, reason: reason})
+}
+
+func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
+	body := map[string]any{}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeError(w, http.StatusBadRequest, "parsing_exception", err.Error())
+			return
+		}
+	}
+	statusCode, response := s.search(Request{Index: r.PathValue("index"), Body: body})
+	writeJSON(w, statusCode, response)
+}
+
+// handleSearchTemplate runs a stored search template as a search whose recorded body is the
+// template request ({"id": ..., "params": ...}). The template is not rendered.
+func (s *Server) handleSearchTemplate(w http.ResponseWriter, r *http.Request) {
+	body := map[string]any{}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, "parsing_exception", err.Error())
+		return
+	}
+	id, _ := body["id"].(string)
+	if _, ok := s.Script(id); !ok {
+		writeError(w, http.StatusNotFound, "resource_not_found_exception", fmt.Sprintf("unable to find script [%s] in cluster state", id))
+		return
+	}
+	statusCode, response := s.search(Request{Index: r.PathValue("index"), Body: body})
+	writeJSON(w, statusCode, response)
+}
+
+// handlePutScript stores a mustache search template.
+func (s *Server) handlePutScript(w http.ResponseWriter, r *http.Request, id string) {
+	var body struct {
+		Script struct {
+			Lang   string `json:"lang"`
+			Source string `json:"source"`
+		} `json:"script"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, "parsing_exception", err.Error())
+		return
+	}
+	if body.Script.Lang != "mustache" {
+		writeError(w, http.StatusBadRequest, "illegal_argument_exception", fmt.Sprintf("unsupported script lang [%s]", body.Script.Lang))
+		return
+	}
+	s.mu.Lock()
+	s.scripts[id] = body.Script.Source
+	s.mu.Unlock()
+	writeJSON(w, http.StatusOK, map[string]any{"acknowledged": true})
+}
+
+// handleMultiSearch runs each header and body pair of the NDJSON request as a search.
+func (s *Server) handleMultiSearch(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	off := s.multiSearchOff
+	s.mu.Unlock()
+	if off {
+		writeError(w, http.StatusForbidden, "security_exception", "action [indices:data/read/msearch] is unauthorized")
+		return
+	}
+
+	responses := []any{}
+	dec := json.NewDecoder(r.Body)
+	for dec.More() {
+		var header struct {
+			Index string `json:"index"`
+		}
+		body := map[string]any{}
+		if err := dec.Decode(&header); err != nil {
+			writeError(w, http.StatusBadRequest, "parsing_exception", err.Error())
+			return
+		}
+		if err := dec.Decode(&body); err != nil {
+			writeError(w, http.StatusBadRequest, "parsing_exception", err.Error())
+			return
+		}
+		statusCode, response := s.search(Request{Index: header.Index, Body: body, Multi: true})
+		response["status"] = statusCode
+		responses = append(responses, response)
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"took": 1, "responses": responses})
+}
+
+// search records a search and returns its status code and response body.
+func (s *Server) search(req Request) (int, map[string]any) {
+	index, body := req.Index, req.Body
+	s.mu.Lock()
+	s.requests = append(s.requests, req)
+	if len(s.failures) > 0 {
+		next := s.failures[0]
+		s.failures = s.failures[1:]
+		s.mu.Unlock()
+		return next.statusCode, errorBody(next.statusCode, next.errorType, next.reason)
+	}
+	if s.closed[index] {
+		s.mu.Unlock()
+		return http.StatusBadRequest, errorBody(http.StatusBadRequest, "index_closed_exception", "closed")
+	}
+	hits, ok := s.indices[index]
+	hits = append([]Hit(nil), hits...)
+	s.mu.Unlock()
+
+	if !ok {
+		return http.StatusNotFound, errorBody(http.StatusNotFound, "index_not_found_exception", fmt.Sprintf("no such index [%s]", index))
+	}
+
+	total := len(hits)
+	if size, isNumber := body["size"].(float64); isNumber && int(size) < len(hits) {
+		hits = hits[:int(size)]
+	}
+
+	renderedHits := make([]map[string]any, 0, len(hits))
+	for _, hit := range hits {
+		rendered := map[string]any{
+			"_index":  index,
+			"_id":     hit.ID,
+			"_score":  1.0,
+			"_source": hit.Source,
+		}
+		if hit.Fields != nil {
+			rendered["fields"] = hit.Fields
+		}
+		if hit.Highlight != nil {
+			rendered["highlight"] = hit.Highlight
+		}
+		renderedHits = append(renderedHits, rendered)
+	}
+
+	return http.StatusOK, map[string]any{
+		"took":      1,
+		"timed_out": false,
+		"hits": map[string]any{
+			"total": map[string]any{"value": total, "relation": "eq"},
+			"hits":  renderedHits,
+		},
+	}
+}
+
+// handleCatIndices lists the indices matching the pattern in the cat API's JSON format.
+func (s *Server) handleCatIndices(w http.ResponseWriter, r *http.Request) {
+	pattern := r.PathValue("pattern")
+
+	s.mu.Lock()
+	rows := []map[string]any{}
+	for index := range s.indices {
+		if matched, _ := path.Match(pattern, index); matched {
+			rows = append(rows, map[string]any{"index": index})
+		}
+	}
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, rows)
+}
+
+// handleCreateIndex creates an index with the properties of the request's mappings.
+func (s *Server) handleCreateIndex(w http.ResponseWriter, r *http.Request) {
+	index := r.PathValue("index")
+
+	var body struct {
+		Mappings struct {
+			Properties map[string]any `json:"properties"`
+		} `json:"mappings"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeError(w, http.StatusBadRequest, "parsing_exception", err.Error())
+			return
+		}
+	}
+
+	s.mu.Lock()
+	_, exists := s.indices[index]
+	if !exists {
+		s.indices[index] = nil
+		s.mappings[index] = body.Mappings.Properties
+	}
+	s.mu.Unlock()
+
+	if exists {
+		writeError(w, http.StatusBadRequest, "resource_already_exists_exception", fmt.Sprintf("index [%s] already exists", index))
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"acknowledged": true, "index": index})
+}
+
+// handleGetMapping returns the field mappings of an index.
+func (s *Server) handleGetMapping(w http.ResponseWriter, r *http.Request) {
+	index := r.PathValue("index")
+
+	s.mu.Lock()
+	_, exists := s.indices[index]
+	properties := s.mappings[index]
+	s.mu.Unlock()
+
+	if !exists {
+		writeError(w, http.StatusNotFound, "index_not_found_exception", fmt.Sprintf("no such index [%s]", index))
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		index: map[string]any{"mappings": map[string]any{"properties": properties}},
+	})
+}
+
+// handlePutMapping adds the request's properties to an index's mappings.
+func (s *Server) handlePutMapping(w http.ResponseWriter, r *http.Request) {
+	index := r.PathValue("index")
+
+	var body struct {
+		Properties map[string]any `json:"properties"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, "parsing_exception", err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	_, exists := s.indices[index]
+	if exists {
+		if s.mappings[index] == nil {
+			s.mappings[index] = make(map[string]any)
+		}
+		for field, property := range body.Properties {
+			s.mappings[index][field] = property
+		}
+	}
+	s.mu.Unlock()
+
+	if !exists {
+		writeError(w, http.StatusNotFound, "index_not_found_exception", fmt.Sprintf("no such index [%s]", index))
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"acknowledged": true})
+}
+
+// handleIndexDocument stores a document as a hit of its index, replacing the source of one
+// with the same ID. Canned Fields of the replaced hit are kept, so a test can stage the
+// percolate result of a query the code under test registers.
+func (s *Server) handleIndexDocument(w http.ResponseWriter, r *http.Request) {
+	index, id := r.PathValue("index"), r.PathValue("id")
+
+	var source map[string]any
+	if err := json.NewDecoder(r.Body).Decode(&source); err != nil {
+		writeError(w, http.StatusBadRequest, "parsing_exception", err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	hits := s.indices[index]
+	if i := slices.IndexFunc(hits, func(hit Hit) bool { return hit.ID == id }); i >= 0 {
+		hits[i].Source = source
+	} else {
+		s.indices[index] = append(hits, Hit{ID: id, Source: source})
+	}
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, map[string]any{"_index": index, "_id": id, "result": "created"})
+}
+
+// handleUpdateDocument merges the top-level fields of a partial document into the source of
+// a stored hit. The source of a canned hit is converted to a JSON object first.
+func (s *Server) handleUpdateDocument(w http.ResponseWriter, r *http.Request) {
+	index, id := r.PathValue("index"), r.PathValue("id")
+
+	var update struct {
+		Doc map[string]any `json:"doc"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		writeError(w, http.StatusBadRequest, "parsing_exception", err.Error())
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	hits := s.indices[index]
+	i := slices.IndexFunc(hits, func(hit Hit) bool { return hit.ID == id })
+	if i < 0 {
+		writeError(w, http.StatusNotFound, "document_missing_exception", fmt.Sprintf("[%s]: document missing", id))
+		return
+	}
+	source, ok := hits[i].Source.(map[string]any)
+	if !ok {
+		data, err := json.Marshal(hits[i].Source)
+		if err == nil {
+			err = json.Unmarshal(data, &source)
+		}
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "mapper_parsing_exception", err.Error())
+			return
+		}
+	}
+	if source == nil {
+		source = make(map[string]any)
+	}
+	maps.Copy(source, update.Doc)
+	hits[i].Source = source
+	writeJSON(w, http.StatusOK, map[string]any{"_index": index, "_id": id, "result": "updated"})
+}
+
+func writeJSON(w http.ResponseWriter, statusCode int, body any) {
+	// The official client refuses to talk to servers that do not identify as Elasticsearch
+	w.Header().Set("X-Elastic-Product", "Elasticsearch")
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func writeError(w http.ResponseWriter, statusCode int, errorType, reason string) {
+	writeJSON(w, statusCode, errorBody(statusCode, errorType, reason))
+}
+
+func errorBody(statusCode int, errorType, reason string) map[string]any {
+	return map[string]any{
+		"error": map[string]any{
+			"type":   errorType,
+			"reason": reason,
+		},
+		"status": statusCode,
+	}
+}
@@ -0,0 +1,111 @@
+package estest_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gopost/integration/internal/es/estest"
+)
+
+func TestServer_ServesCannedHits(t *testing.T) {
+	server := estest.NewServer(t)
+	server.AddHits("sudbury_com_articles",
+		estest.Hit{ID: "1", Source: map[string]any{"title": "Police arrest suspect"}},
+		estest.Hit{ID: "2", Source: map[string]any{"title": "Court hearing delayed"}},
+		estest.Hit{ID: "3", Source: map[string]any{"title": "Robbery downtown"}},
+	)
+	client := server.Client(t)
+
+	res, err := client.Search(
+		client.Search.WithContext(context.Background()),
+		client.Search.WithIndex("sudbury_com_articles"),
+		client.Search.WithBody(strings.NewReader(`{"query":{"match_all":{}},"size":2}`)),
+	)
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	defer res.Body.Close()
+
+	var result struct {
+		Hits struct {
+			Total struct {
+				Value int `json:"value"`
+			} `json:"total"`
+			Hits []struct {
+				ID string `json:"_id"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+
+	if result.Hits.Total.Value != 3 {
+		t.Errorf("total = %d, want 3", result.Hits.Total.Value)
+	}
+	if len(result.Hits.Hits) != 2 || result.Hits.Hits[0].ID != "1" {
+		t.Errorf("hits = %+v, want first two canned hits", result.Hits.Hits)
+	}
+
+	requests := server.Requests()
+	if len(requests) != 1 || requests[0].Index != "sudbury_com_articles" {
+		t.Fatalf("Requests() = %+v, want one request for sudbury_com_articles", requests)
+	}
+	if _, ok := requests[0].Body["query"]; !ok {
+		t.Error("recorded request body is missing query")
+	}
+}
+
+func TestServer_MissingIndex(t *testing.T) {
+	server := estest.NewServer(t)
+	client := server.Client(t)
+
+	res, err := client.Search(
+		client.Search.WithIndex("missing_articles"),
+		client.Search.WithBody(strings.NewReader(`{"query":{"match_all":{}}}`)),
+	)
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusNotFound {
+		t.Errorf("status = %d, want %d", res.StatusCode, http.StatusNotFound)
+	}
+}
+
+func TestServer_CreatesIndicesAndStoresDocuments(t *testing.T) {
+	server := estest.NewServer(t)
+	client := server.Client(t)
+
+	for _, want := range []int{http.StatusOK, http.StatusBadRequest} {
+		res, err := client.Indices.Create("gopost_queries",
+			client.Indices.Create.WithBody(strings.NewReader(`{"mappings":{"properties":{"query":{"type":"percolator"}}}}`)),
+		)
+		if err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+		res.Body.Close()
+		if res.StatusCode != want {
+			t.Errorf("Create() status = %d, want %d", res.StatusCode, want)
+		}
+	}
+
+	res, err := client.Index("gopost_queries", strings.NewReader(`{"query":{"match_all":{}}}`),
+		client.Index.WithDocumentID("sudbury_com"),
+	)
+	if err != nil {
+		t.Fatalf("Index() error = %v", err)
+	}
+	res.Body.Close()
+
+	if got := server.Mapping("gopost_queries")["query"]; got == nil {
+		t.Errorf("mapping of query = %v, want percolator", got)
+	}
+	if docs := server.Documents("gopost_queries"); len(docs) != 1 || docs[0].ID != "sudbury_com" {
+		t.Errorf("Documents() = %+v, want the indexed query", docs)
+	}
+}
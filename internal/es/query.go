@@ -0,0 +1,159 @@
+// Package es builds Elasticsearch search bodies from typed query clauses instead of nested
+// maps, so a misplaced key fails to compile and each clause's JSON can be tested on its own.
+// Source returns the JSON-encodable map the pipeline's searches take.
+package es
+
+// Query is a query clause.
+type Query interface {
+	// Source returns the clause as a JSON-encodable map, e.g. {"exists": {"field": "title"}}.
+	Source() map[string]any
+}
+
+// Bool combines clauses. Empty clause lists are left out.
+type Bool struct {
+	Must    []Query // Must match, and count towards the score
+	Filter  []Query // Must match, without scoring
+	Should  []Query // Should match; with no Must or Filter, at least one must
+	MustNot []Query // Must not match
+}
+
+func (q Bool) Source() map[string]any {
+	body := map[string]any{}
+	for _, clauses := range []struct {
+		name    string
+		queries []Query
+	}{{"must", q.Must}, {"filter", q.Filter}, {"should", q.Should}, {"must_not", q.MustNot}} {
+		if len(clauses.queries) > 0 {
+			body[clauses.name] = sources(clauses.queries)
+		}
+	}
+	return map[string]any{"bool": body}
+}
+
+// Range matches field values within bounds. Empty bounds and format are left out.
+type Range struct {
+	Field  string
+	GTE    string
+	GT     string
+	LTE    string
+	LT     string
+	Format string // Date format of the bounds, e.g. "strict_date_optional_time||epoch_millis"
+}
+
+func (q Range) Source() map[string]any {
+	bounds := map[string]any{}
+	for _, bound := range []struct{ name, value string }{
+		{"gte", q.GTE}, {"gt", q.GT}, {"lte", q.LTE}, {"lt", q.LT}, {"format", q.Format},
+	} {
+		if bound.value != "" {
+			bounds[bound.name] = bound.value
+		}
+	}
+	return map[string]any{"range": map[string]any{q.Field: bounds}}
+}
+
+// MultiMatch matches text in several fields. Empty Type and Operator leave the
+// Elasticsearch defaults ("best_fields" and "or").
+type MultiMatch struct {
+	Query    string
+	Fields   []string // Field names, optionally boosted, e.g. "title^2"
+	Type     string
+	Operator string
+}
+
+func (q MultiMatch) Source() map[string]any {
+	body := map[string]any{
+		"query":  q.Query,
+		"fields": q.Fields,
+	}
+	if q.Type != "" {
+		body["type"] = q.Type
+	}
+	if q.Operator != "" {
+		body["operator"] = q.Operator
+	}
+	return map[string]any{"multi_match": body}
+}
+
+// Match matches text in a field.
+type Match struct {
+	Field string
+	Query string
+}
+
+func (q Match) Source() map[string]any {
+	return map[string]any{"match": map[string]any{q.Field: q.Query}}
+}
+
+// MatchPhrase matches the words of a phrase in order in a field.
+type MatchPhrase struct {
+	Field  string
+	Phrase string
+}
+
+func (q MatchPhrase) Source() map[string]any {
+	return map[string]any{"match_phrase": map[string]any{q.Field: q.Phrase}}
+}
+
+// Exists matches documents with a value in a field.
+type Exists struct {
+	Field string
+}
+
+func (q Exists) Source() map[string]any {
+	return map[string]any{"exists": map[string]any{"field": q.Field}}
+}
+
+// MatchAll matches every document.
+type MatchAll struct{}
+
+func (MatchAll) Source() map[string]any {
+	return map[string]any{"match_all": map[string]any{}}
+}
+
+// Sort orders hits by a field.
+type Sort struct {
+	Field        string
+	Order        string // "asc" or "desc"
+	UnmappedType string // Type assumed in indices without the field, so they sort instead of failing
+}
+
+func (s Sort) source() map[string]any {
+	body := map[string]any{"order": s.Order}
+	if s.UnmappedType != "" {
+		body["unmapped_type"] = s.UnmappedType
+	}
+	return map[string]any{s.Field: body}
+}
+
+// Search is a search request body.
+type Search struct {
+	Query Query
+	Size  int // Hits returned; always sent, so 0 only counts
+	Sort  []Sort
+}
+
+// Source returns the body as a JSON-encodable map, to which callers may add members such
+// as "highlight".
+func (s Search) Source() map[string]any {
+	body := map[string]any{"size": s.Size}
+	if s.Query != nil {
+		body["query"] = s.Query.Source()
+	}
+	if len(s.Sort) > 0 {
+		sorts := make([]any, len(s.Sort))
+		for i, sort := range s.Sort {
+			sorts[i] = sort.source()
+		}
+		body["sort"] = sorts
+	}
+	return body
+}
+
+func sources(queries []Query) []any {
+	clauses := make([]any, len(queries))
+	for i, query := range queries {
+		clauses[i] = query.Source()
+	}
+	return clauses
+}
@@ -0,0 +1,67 @@
+package es_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/gopost/integration/internal/es"
+)
+
+func TestSearch_Source(t *testing.T) {
+	tests := []struct {
+		name   string
+		search es.Search
+		want   string
+	}{
+		{
+			name: "keyword search in a window",
+			search: es.Search{
+				Query: es.Bool{Must: []es.Query{
+					es.Range{Field: "published_date", GTE: "2025-01-15T00:00:00Z", Format: "strict_date_optional_time"},
+					es.MultiMatch{Query: "police robbery", Fields: []string{"title^2", "body"}, Type: "best_fields", Operator: "or"},
+				}},
+				Size: 100,
+				Sort: []es.Sort{{Field: "published_date", Order: "desc"}},
+			},
+			want: `{"query":{"bool":{"must":[` +
+				`{"range":{"published_date":{"format":"strict_date_optional_time","gte":"2025-01-15T00:00:00Z"}}},` +
+				`{"multi_match":{"fields":["title^2","body"],"operator":"or","query":"police robbery","type":"best_fields"}}]}},` +
+				`"size":100,"sort":[{"published_date":{"order":"desc"}}]}`,
+		},
+		{
+			name: "filters and exclusions",
+			search: es.Search{
+				Query: es.Bool{
+					Filter:  []es.Query{es.Match{Field: "city", Query: "sudbury_com"}, es.MatchPhrase{Field: "title", Phrase: "break and enter"}},
+					MustNot: []es.Query{es.Exists{Field: "rolled_back"}},
+				},
+				Size: 10,
+				Sort: []es.Sort{{Field: "posted_at", Order: "asc", UnmappedType: "date"}},
+			},
+			want: `{"query":{"bool":{"filter":[{"match":{"city":"sudbury_com"}},{"match_phrase":{"title":"break and enter"}}],` +
+				`"must_not":[{"exists":{"field":"rolled_back"}}]}},"size":10,` +
+				`"sort":[{"posted_at":{"order":"asc","unmapped_type":"date"}}]}`,
+		},
+		{
+			name:   "count only",
+			search: es.Search{Query: es.MatchAll{}},
+			want:   `{"query":{"match_all":{}},"size":0}`,
+		},
+		{
+			name:   "empty bool",
+			search: es.Search{Query: es.Bool{}, Size: 1},
+			want:   `{"query":{"bool":{}},"size":1}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := json.Marshal(tt.search.Source())
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Source() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
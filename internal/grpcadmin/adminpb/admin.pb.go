@@ -0,0 +1,1290 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        (unknown)
+// source: admin.proto
+
+package adminpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	durationpb "google.golang.org/protobuf/types/known/durationpb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type StatusRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StatusRequest) Reset() {
+	*x = StatusRequest{}
+	mi := &file_admin_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StatusRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatusRequest) ProtoMessage() {}
+
+func (x *StatusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatusRequest.ProtoReflect.Descriptor instead.
+func (*StatusRequest) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{0}
+}
+
+type StatusResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Build         *BuildInfo             `protobuf:"bytes,1,opt,name=build,proto3" json:"build,omitempty"`
+	StartedAt     *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=started_at,json=startedAt,proto3" json:"started_at,omitempty"`
+	Uptime        *durationpb.Duration   `protobuf:"bytes,3,opt,name=uptime,proto3" json:"uptime,omitempty"`
+	Service       *ServiceStatus         `protobuf:"bytes,4,opt,name=service,proto3" json:"service,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StatusResponse) Reset() {
+	*x = StatusResponse{}
+	mi := &file_admin_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StatusResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StatusResponse) ProtoMessage() {}
+
+func (x *StatusResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StatusResponse.ProtoReflect.Descriptor instead.
+func (*StatusResponse) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *StatusResponse) GetBuild() *BuildInfo {
+	if x != nil {
+		return x.Build
+	}
+	return nil
+}
+
+func (x *StatusResponse) GetStartedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.StartedAt
+	}
+	return nil
+}
+
+func (x *StatusResponse) GetUptime() *durationpb.Duration {
+	if x != nil {
+		return x.Uptime
+	}
+	return nil
+}
+
+func (x *StatusResponse) GetService() *ServiceStatus {
+	if x != nil {
+		return x.Service
+	}
+	return nil
+}
+
+type BuildInfo struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Version       string                 `protobuf:"bytes,1,opt,name=version,proto3" json:"version,omitempty"`
+	Commit        string                 `protobuf:"bytes,2,opt,name=commit,proto3" json:"commit,omitempty"`
+	Date          string                 `protobuf:"bytes,3,opt,name=date,proto3" json:"date,omitempty"`
+	GoVersion     string                 `protobuf:"bytes,4,opt,name=go_version,json=goVersion,proto3" json:"go_version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BuildInfo) Reset() {
+	*x = BuildInfo{}
+	mi := &file_admin_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BuildInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BuildInfo) ProtoMessage() {}
+
+func (x *BuildInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BuildInfo.ProtoReflect.Descriptor instead.
+func (*BuildInfo) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *BuildInfo) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+func (x *BuildInfo) GetCommit() string {
+	if x != nil {
+		return x.Commit
+	}
+	return ""
+}
+
+func (x *BuildInfo) GetDate() string {
+	if x != nil {
+		return x.Date
+	}
+	return ""
+}
+
+func (x *BuildInfo) GetGoVersion() string {
+	if x != nil {
+		return x.GoVersion
+	}
+	return ""
+}
+
+type ServiceStatus struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	LastCheck     *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=last_check,json=lastCheck,proto3" json:"last_check,omitempty"`
+	LastReport    *RunReport             `protobuf:"bytes,2,opt,name=last_report,json=lastReport,proto3" json:"last_report,omitempty"`
+	CityCount     int32                  `protobuf:"varint,3,opt,name=city_count,json=cityCount,proto3" json:"city_count,omitempty"`
+	OutboxEnabled bool                   `protobuf:"varint,4,opt,name=outbox_enabled,json=outboxEnabled,proto3" json:"outbox_enabled,omitempty"`
+	// Per-tenant status in multi-tenant mode.
+	Tenants map[string]*ServiceStatus `protobuf:"bytes,5,rep,name=tenants,proto3" json:"tenants,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// Set while Drupal is in maintenance mode.
+	Maintenance *MaintenanceStatus `protobuf:"bytes,6,opt,name=maintenance,proto3" json:"maintenance,omitempty"`
+	// In multi-tenant mode, that of the least healthy tenant.
+	Health        *Health `protobuf:"bytes,7,opt,name=health,proto3" json:"health,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ServiceStatus) Reset() {
+	*x = ServiceStatus{}
+	mi := &file_admin_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ServiceStatus) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ServiceStatus) ProtoMessage() {}
+
+func (x *ServiceStatus) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ServiceStatus.ProtoReflect.Descriptor instead.
+func (*ServiceStatus) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ServiceStatus) GetLastCheck() *timestamppb.Timestamp {
+	if x != nil {
+		return x.LastCheck
+	}
+	return nil
+}
+
+func (x *ServiceStatus) GetLastReport() *RunReport {
+	if x != nil {
+		return x.LastReport
+	}
+	return nil
+}
+
+func (x *ServiceStatus) GetCityCount() int32 {
+	if x != nil {
+		return x.CityCount
+	}
+	return 0
+}
+
+func (x *ServiceStatus) GetOutboxEnabled() bool {
+	if x != nil {
+		return x.OutboxEnabled
+	}
+	return false
+}
+
+func (x *ServiceStatus) GetTenants() map[string]*ServiceStatus {
+	if x != nil {
+		return x.Tenants
+	}
+	return nil
+}
+
+func (x *ServiceStatus) GetMaintenance() *MaintenanceStatus {
+	if x != nil {
+		return x.Maintenance
+	}
+	return nil
+}
+
+func (x *ServiceStatus) GetHealth() *Health {
+	if x != nil {
+		return x.Health
+	}
+	return nil
+}
+
+type Health struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// 0 (down) to 1 (healthy).
+	Score float64 `protobuf:"fixed64,1,opt,name=score,proto3" json:"score,omitempty"`
+	// Failed posts over attempted posts in the last run.
+	ErrorRatio      float64 `protobuf:"fixed64,2,opt,name=error_ratio,json=errorRatio,proto3" json:"error_ratio,omitempty"`
+	ElasticsearchUp bool    `protobuf:"varint,3,opt,name=elasticsearch_up,json=elasticsearchUp,proto3" json:"elasticsearch_up,omitempty"`
+	DrupalUp        bool    `protobuf:"varint,4,opt,name=drupal_up,json=drupalUp,proto3" json:"drupal_up,omitempty"`
+	RedisUp         bool    `protobuf:"varint,5,opt,name=redis_up,json=redisUp,proto3" json:"redis_up,omitempty"`
+	// How far the newest article found is ahead of the last check time.
+	Lag           *durationpb.Duration `protobuf:"bytes,6,opt,name=lag,proto3" json:"lag,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Health) Reset() {
+	*x = Health{}
+	mi := &file_admin_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Health) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Health) ProtoMessage() {}
+
+func (x *Health) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Health.ProtoReflect.Descriptor instead.
+func (*Health) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Health) GetScore() float64 {
+	if x != nil {
+		return x.Score
+	}
+	return 0
+}
+
+func (x *Health) GetErrorRatio() float64 {
+	if x != nil {
+		return x.ErrorRatio
+	}
+	return 0
+}
+
+func (x *Health) GetElasticsearchUp() bool {
+	if x != nil {
+		return x.ElasticsearchUp
+	}
+	return false
+}
+
+func (x *Health) GetDrupalUp() bool {
+	if x != nil {
+		return x.DrupalUp
+	}
+	return false
+}
+
+func (x *Health) GetRedisUp() bool {
+	if x != nil {
+		return x.RedisUp
+	}
+	return false
+}
+
+func (x *Health) GetLag() *durationpb.Duration {
+	if x != nil {
+		return x.Lag
+	}
+	return nil
+}
+
+type MaintenanceStatus struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Since *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=since,proto3" json:"since,omitempty"`
+	// When posting is next attempted.
+	RetryAt       *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=retry_at,json=retryAt,proto3" json:"retry_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MaintenanceStatus) Reset() {
+	*x = MaintenanceStatus{}
+	mi := &file_admin_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MaintenanceStatus) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MaintenanceStatus) ProtoMessage() {}
+
+func (x *MaintenanceStatus) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MaintenanceStatus.ProtoReflect.Descriptor instead.
+func (*MaintenanceStatus) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *MaintenanceStatus) GetSince() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Since
+	}
+	return nil
+}
+
+func (x *MaintenanceStatus) GetRetryAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.RetryAt
+	}
+	return nil
+}
+
+type RunReport struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	StartedAt     *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=started_at,json=startedAt,proto3" json:"started_at,omitempty"`
+	Duration      *durationpb.Duration   `protobuf:"bytes,2,opt,name=duration,proto3" json:"duration,omitempty"`
+	Cities        []*CityReport          `protobuf:"bytes,3,rep,name=cities,proto3" json:"cities,omitempty"`
+	Dedup         *DedupReport           `protobuf:"bytes,4,opt,name=dedup,proto3" json:"dedup,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RunReport) Reset() {
+	*x = RunReport{}
+	mi := &file_admin_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RunReport) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RunReport) ProtoMessage() {}
+
+func (x *RunReport) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RunReport.ProtoReflect.Descriptor instead.
+func (*RunReport) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *RunReport) GetStartedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.StartedAt
+	}
+	return nil
+}
+
+func (x *RunReport) GetDuration() *durationpb.Duration {
+	if x != nil {
+		return x.Duration
+	}
+	return nil
+}
+
+func (x *RunReport) GetCities() []*CityReport {
+	if x != nil {
+		return x.Cities
+	}
+	return nil
+}
+
+func (x *RunReport) GetDedup() *DedupReport {
+	if x != nil {
+		return x.Dedup
+	}
+	return nil
+}
+
+type CityReport struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	City          string                 `protobuf:"bytes,1,opt,name=city,proto3" json:"city,omitempty"`
+	Found         int32                  `protobuf:"varint,2,opt,name=found,proto3" json:"found,omitempty"`
+	Posted        int32                  `protobuf:"varint,3,opt,name=posted,proto3" json:"posted,omitempty"`
+	Queued        int32                  `protobuf:"varint,4,opt,name=queued,proto3" json:"queued,omitempty"`
+	Skipped       int32                  `protobuf:"varint,5,opt,name=skipped,proto3" json:"skipped,omitempty"`
+	Errors        int32                  `protobuf:"varint,6,opt,name=errors,proto3" json:"errors,omitempty"`
+	Failed        bool                   `protobuf:"varint,7,opt,name=failed,proto3" json:"failed,omitempty"`
+	BrokenLinks   int32                  `protobuf:"varint,8,opt,name=broken_links,json=brokenLinks,proto3" json:"broken_links,omitempty"`
+	Paused        bool                   `protobuf:"varint,9,opt,name=paused,proto3" json:"paused,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CityReport) Reset() {
+	*x = CityReport{}
+	mi := &file_admin_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CityReport) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CityReport) ProtoMessage() {}
+
+func (x *CityReport) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CityReport.ProtoReflect.Descriptor instead.
+func (*CityReport) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *CityReport) GetCity() string {
+	if x != nil {
+		return x.City
+	}
+	return ""
+}
+
+func (x *CityReport) GetFound() int32 {
+	if x != nil {
+		return x.Found
+	}
+	return 0
+}
+
+func (x *CityReport) GetPosted() int32 {
+	if x != nil {
+		return x.Posted
+	}
+	return 0
+}
+
+func (x *CityReport) GetQueued() int32 {
+	if x != nil {
+		return x.Queued
+	}
+	return 0
+}
+
+func (x *CityReport) GetSkipped() int32 {
+	if x != nil {
+		return x.Skipped
+	}
+	return 0
+}
+
+func (x *CityReport) GetErrors() int32 {
+	if x != nil {
+		return x.Errors
+	}
+	return 0
+}
+
+func (x *CityReport) GetFailed() bool {
+	if x != nil {
+		return x.Failed
+	}
+	return false
+}
+
+func (x *CityReport) GetBrokenLinks() int32 {
+	if x != nil {
+		return x.BrokenLinks
+	}
+	return 0
+}
+
+func (x *CityReport) GetPaused() bool {
+	if x != nil {
+		return x.Paused
+	}
+	return false
+}
+
+type DedupReport struct {
+	state               protoimpl.MessageState `protogen:"open.v1"`
+	AlreadyPosted       int32                  `protobuf:"varint,1,opt,name=already_posted,json=alreadyPosted,proto3" json:"already_posted,omitempty"`
+	TitleMatches        int32                  `protobuf:"varint,2,opt,name=title_matches,json=titleMatches,proto3" json:"title_matches,omitempty"`
+	NearMatches         int32                  `protobuf:"varint,3,opt,name=near_matches,json=nearMatches,proto3" json:"near_matches,omitempty"`
+	ReserveConflicts    int32                  `protobuf:"varint,4,opt,name=reserve_conflicts,json=reserveConflicts,proto3" json:"reserve_conflicts,omitempty"`
+	AlreadyQueued       int32                  `protobuf:"varint,5,opt,name=already_queued,json=alreadyQueued,proto3" json:"already_queued,omitempty"`
+	TopDuplicateSources []*SourceCount         `protobuf:"bytes,6,rep,name=top_duplicate_sources,json=topDuplicateSources,proto3" json:"top_duplicate_sources,omitempty"`
+	unknownFields       protoimpl.UnknownFields
+	sizeCache           protoimpl.SizeCache
+}
+
+func (x *DedupReport) Reset() {
+	*x = DedupReport{}
+	mi := &file_admin_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DedupReport) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DedupReport) ProtoMessage() {}
+
+func (x *DedupReport) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DedupReport.ProtoReflect.Descriptor instead.
+func (*DedupReport) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *DedupReport) GetAlreadyPosted() int32 {
+	if x != nil {
+		return x.AlreadyPosted
+	}
+	return 0
+}
+
+func (x *DedupReport) GetTitleMatches() int32 {
+	if x != nil {
+		return x.TitleMatches
+	}
+	return 0
+}
+
+func (x *DedupReport) GetNearMatches() int32 {
+	if x != nil {
+		return x.NearMatches
+	}
+	return 0
+}
+
+func (x *DedupReport) GetReserveConflicts() int32 {
+	if x != nil {
+		return x.ReserveConflicts
+	}
+	return 0
+}
+
+func (x *DedupReport) GetAlreadyQueued() int32 {
+	if x != nil {
+		return x.AlreadyQueued
+	}
+	return 0
+}
+
+func (x *DedupReport) GetTopDuplicateSources() []*SourceCount {
+	if x != nil {
+		return x.TopDuplicateSources
+	}
+	return nil
+}
+
+type SourceCount struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Source        string                 `protobuf:"bytes,1,opt,name=source,proto3" json:"source,omitempty"`
+	Count         int32                  `protobuf:"varint,2,opt,name=count,proto3" json:"count,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SourceCount) Reset() {
+	*x = SourceCount{}
+	mi := &file_admin_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SourceCount) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SourceCount) ProtoMessage() {}
+
+func (x *SourceCount) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SourceCount.ProtoReflect.Descriptor instead.
+func (*SourceCount) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *SourceCount) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
+func (x *SourceCount) GetCount() int32 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+type TriggerSyncRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Optional: retrying with the same token skips the articles earlier attempts processed.
+	RunToken      string `protobuf:"bytes,1,opt,name=run_token,json=runToken,proto3" json:"run_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TriggerSyncRequest) Reset() {
+	*x = TriggerSyncRequest{}
+	mi := &file_admin_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TriggerSyncRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TriggerSyncRequest) ProtoMessage() {}
+
+func (x *TriggerSyncRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TriggerSyncRequest.ProtoReflect.Descriptor instead.
+func (*TriggerSyncRequest) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *TriggerSyncRequest) GetRunToken() string {
+	if x != nil {
+		return x.RunToken
+	}
+	return ""
+}
+
+type TriggerSyncResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// False when a triggered run was already pending.
+	Triggered     bool `protobuf:"varint,1,opt,name=triggered,proto3" json:"triggered,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TriggerSyncResponse) Reset() {
+	*x = TriggerSyncResponse{}
+	mi := &file_admin_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TriggerSyncResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TriggerSyncResponse) ProtoMessage() {}
+
+func (x *TriggerSyncResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TriggerSyncResponse.ProtoReflect.Descriptor instead.
+func (*TriggerSyncResponse) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *TriggerSyncResponse) GetTriggered() bool {
+	if x != nil {
+		return x.Triggered
+	}
+	return false
+}
+
+type PauseRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	City          string                 `protobuf:"bytes,1,opt,name=city,proto3" json:"city,omitempty"`
+	Reason        string                 `protobuf:"bytes,2,opt,name=reason,proto3" json:"reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PauseRequest) Reset() {
+	*x = PauseRequest{}
+	mi := &file_admin_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PauseRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PauseRequest) ProtoMessage() {}
+
+func (x *PauseRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PauseRequest.ProtoReflect.Descriptor instead.
+func (*PauseRequest) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *PauseRequest) GetCity() string {
+	if x != nil {
+		return x.City
+	}
+	return ""
+}
+
+func (x *PauseRequest) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+type PauseResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PauseResponse) Reset() {
+	*x = PauseResponse{}
+	mi := &file_admin_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PauseResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PauseResponse) ProtoMessage() {}
+
+func (x *PauseResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PauseResponse.ProtoReflect.Descriptor instead.
+func (*PauseResponse) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{13}
+}
+
+type ResumeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	City          string                 `protobuf:"bytes,1,opt,name=city,proto3" json:"city,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ResumeRequest) Reset() {
+	*x = ResumeRequest{}
+	mi := &file_admin_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResumeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResumeRequest) ProtoMessage() {}
+
+func (x *ResumeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResumeRequest.ProtoReflect.Descriptor instead.
+func (*ResumeRequest) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *ResumeRequest) GetCity() string {
+	if x != nil {
+		return x.City
+	}
+	return ""
+}
+
+type ResumeResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ResumeResponse) Reset() {
+	*x = ResumeResponse{}
+	mi := &file_admin_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResumeResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResumeResponse) ProtoMessage() {}
+
+func (x *ResumeResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResumeResponse.ProtoReflect.Descriptor instead.
+func (*ResumeResponse) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{15}
+}
+
+type DedupLookupRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ArticleId     string                 `protobuf:"bytes,1,opt,name=article_id,json=articleId,proto3" json:"article_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DedupLookupRequest) Reset() {
+	*x = DedupLookupRequest{}
+	mi := &file_admin_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DedupLookupRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DedupLookupRequest) ProtoMessage() {}
+
+func (x *DedupLookupRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DedupLookupRequest.ProtoReflect.Descriptor instead.
+func (*DedupLookupRequest) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *DedupLookupRequest) GetArticleId() string {
+	if x != nil {
+		return x.ArticleId
+	}
+	return ""
+}
+
+type DedupLookupResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// "none", "pending", or "posted".
+	State string `protobuf:"bytes,1,opt,name=state,proto3" json:"state,omitempty"`
+	// Time until the marker expires; unset when it never expires.
+	Ttl           *durationpb.Duration `protobuf:"bytes,2,opt,name=ttl,proto3" json:"ttl,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DedupLookupResponse) Reset() {
+	*x = DedupLookupResponse{}
+	mi := &file_admin_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DedupLookupResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DedupLookupResponse) ProtoMessage() {}
+
+func (x *DedupLookupResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_admin_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DedupLookupResponse.ProtoReflect.Descriptor instead.
+func (*DedupLookupResponse) Descriptor() ([]byte, []int) {
+	return file_admin_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *DedupLookupResponse) GetState() string {
+	if x != nil {
+		return x.State
+	}
+	return ""
+}
+
+func (x *DedupLookupResponse) GetTtl() *durationpb.Duration {
+	if x != nil {
+		return x.Ttl
+	}
+	return nil
+}
+
+var File_admin_proto protoreflect.FileDescriptor
+
+const file_admin_proto_rawDesc = "" +
+	"\n" +
+	"\vadmin.proto\x12\x0fgopost.admin.v1\x1a\x1egoogle/protobuf/duration.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"\x0f\n" +
+	"\rStatusRequest\"\xea\x01\n" +
+	"\x0eStatusResponse\x120\n" +
+	"\x05build\x18\x01 \x01(\v2\x1a.gopost.admin.v1.BuildInfoR\x05build\x129\n" +
+	"\n" +
+	"started_at\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\tstartedAt\x121\n" +
+	"\x06uptime\x18\x03 \x01(\v2\x19.google.protobuf.DurationR\x06uptime\x128\n" +
+	"\aservice\x18\x04 \x01(\v2\x1e.gopost.admin.v1.ServiceStatusR\aservice\"p\n" +
+	"\tBuildInfo\x12\x18\n" +
+	"\aversion\x18\x01 \x01(\tR\aversion\x12\x16\n" +
+	"\x06commit\x18\x02 \x01(\tR\x06commit\x12\x12\n" +
+	"\x04date\x18\x03 \x01(\tR\x04date\x12\x1d\n" +
+	"\n" +
+	"go_version\x18\x04 \x01(\tR\tgoVersion\"\xe7\x03\n" +
+	"\rServiceStatus\x129\n" +
+	"\n" +
+	"last_check\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\tlastCheck\x12;\n" +
+	"\vlast_report\x18\x02 \x01(\v2\x1a.gopost.admin.v1.RunReportR\n" +
+	"lastReport\x12\x1d\n" +
+	"\n" +
+	"city_count\x18\x03 \x01(\x05R\tcityCount\x12%\n" +
+	"\x0eoutbox_enabled\x18\x04 \x01(\bR\routboxEnabled\x12E\n" +
+	"\atenants\x18\x05 \x03(\v2+.gopost.admin.v1.ServiceStatus.TenantsEntryR\atenants\x12D\n" +
+	"\vmaintenance\x18\x06 \x01(\v2\".gopost.admin.v1.MaintenanceStatusR\vmaintenance\x12/\n" +
+	"\x06health\x18\a \x01(\v2\x17.gopost.admin.v1.HealthR\x06health\x1aZ\n" +
+	"\fTenantsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x124\n" +
+	"\x05value\x18\x02 \x01(\v2\x1e.gopost.admin.v1.ServiceStatusR\x05value:\x028\x01\"\xcf\x01\n" +
+	"\x06Health\x12\x14\n" +
+	"\x05score\x18\x01 \x01(\x01R\x05score\x12\x1f\n" +
+	"\verror_ratio\x18\x02 \x01(\x01R\n" +
+	"errorRatio\x12)\n" +
+	"\x10elasticsearch_up\x18\x03 \x01(\bR\x0felasticsearchUp\x12\x1b\n" +
+	"\tdrupal_up\x18\x04 \x01(\bR\bdrupalUp\x12\x19\n" +
+	"\bredis_up\x18\x05 \x01(\bR\aredisUp\x12+\n" +
+	"\x03lag\x18\x06 \x01(\v2\x19.google.protobuf.DurationR\x03lag\"|\n" +
+	"\x11MaintenanceStatus\x120\n" +
+	"\x05since\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\x05since\x125\n" +
+	"\bretry_at\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\aretryAt\"\xe6\x01\n" +
+	"\tRunReport\x129\n" +
+	"\n" +
+	"started_at\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\tstartedAt\x125\n" +
+	"\bduration\x18\x02 \x01(\v2\x19.google.protobuf.DurationR\bduration\x123\n" +
+	"\x06cities\x18\x03 \x03(\v2\x1b.gopost.admin.v1.CityReportR\x06cities\x122\n" +
+	"\x05dedup\x18\x04 \x01(\v2\x1c.gopost.admin.v1.DedupReportR\x05dedup\"\xeb\x01\n" +
+	"\n" +
+	"CityReport\x12\x12\n" +
+	"\x04city\x18\x01 \x01(\tR\x04city\x12\x14\n" +
+	"\x05found\x18\x02 \x01(\x05R\x05found\x12\x16\n" +
+	"\x06posted\x18\x03 \x01(\x05R\x06posted\x12\x16\n" +
+	"\x06queued\x18\x04 \x01(\x05R\x06queued\x12\x18\n" +
+	"\askipped\x18\x05 \x01(\x05R\askipped\x12\x16\n" +
+	"\x06errors\x18\x06 \x01(\x05R\x06errors\x12\x16\n" +
+	"\x06failed\x18\a \x01(\bR\x06failed\x12!\n" +
+	"\fbroken_links\x18\b \x01(\x05R\vbrokenLinks\x12\x16\n" +
+	"\x06paused\x18\t \x01(\bR\x06paused\"\xa2\x02\n" +
+	"\vDedupReport\x12%\n" +
+	"\x0ealready_posted\x18\x01 \x01(\x05R\ralreadyPosted\x12#\n" +
+	"\rtitle_matches\x18\x02 \x01(\x05R\ftitleMatches\x12!\n" +
+	"\fnear_matches\x18\x03 \x01(\x05R\vnearMatches\x12+\n" +
+	"\x11reserve_conflicts\x18\x04 \x01(\x05R\x10reserveConflicts\x12%\n" +
+	"\x0ealready_queued\x18\x05 \x01(\x05R\ralreadyQueued\x12P\n" +
+	"\x15top_duplicate_sources\x18\x06 \x03(\v2\x1c.gopost.admin.v1.SourceCountR\x13topDuplicateSources\";\n" +
+	"\vSourceCount\x12\x16\n" +
+	"\x06source\x18\x01 \x01(\tR\x06source\x12\x14\n" +
+	"\x05count\x18\x02 \x01(\x05R\x05count\"1\n" +
+	"\x12TriggerSyncRequest\x12\x1b\n" +
+	"\trun_token\x18\x01 \x01(\tR\brunToken\"3\n" +
+	"\x13TriggerSyncResponse\x12\x1c\n" +
+	"\ttriggered\x18\x01 \x01(\bR\ttriggered\":\n" +
+	"\fPauseRequest\x12\x12\n" +
+	"\x04city\x18\x01 \x01(\tR\x04city\x12\x16\n" +
+	"\x06reason\x18\x02 \x01(\tR\x06reason\"\x0f\n" +
+	"\rPauseResponse\"#\n" +
+	"\rResumeRequest\x12\x12\n" +
+	"\x04city\x18\x01 \x01(\tR\x04city\"\x10\n" +
+	"\x0eResumeResponse\"3\n" +
+	"\x12DedupLookupRequest\x12\x1d\n" +
+	"\n" +
+	"article_id\x18\x01 \x01(\tR\tarticleId\"X\n" +
+	"\x13DedupLookupResponse\x12\x14\n" +
+	"\x05state\x18\x01 \x01(\tR\x05state\x12+\n" +
+	"\x03ttl\x18\x02 \x01(\v2\x19.google.protobuf.DurationR\x03ttl2\x99\x03\n" +
+	"\x05Admin\x12I\n" +
+	"\x06Status\x12\x1e.gopost.admin.v1.StatusRequest\x1a\x1f.gopost.admin.v1.StatusResponse\x12X\n" +
+	"\vTriggerSync\x12#.gopost.admin.v1.TriggerSyncRequest\x1a$.gopost.admin.v1.TriggerSyncResponse\x12F\n" +
+	"\x05Pause\x12\x1d.gopost.admin.v1.PauseRequest\x1a\x1e.gopost.admin.v1.PauseResponse\x12I\n" +
+	"\x06Resume\x12\x1e.gopost.admin.v1.ResumeRequest\x1a\x1f.gopost.admin.v1.ResumeResponse\x12X\n" +
+	"\vDedupLookup\x12#.gopost.admin.v1.DedupLookupRequest\x1a$.gopost.admin.v1.DedupLookupResponseB:Z8github.com/gopost/integration/internal/grpcadmin/adminpbb\x06proto3"
+
+var (
+	file_admin_proto_rawDescOnce sync.Once
+	file_admin_proto_rawDescData []byte
+)
+
+func file_admin_proto_rawDescGZIP() []byte {
+	file_admin_proto_rawDescOnce.Do(func() {
+		file_admin_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_admin_proto_rawDesc), len(file_admin_proto_rawDesc)))
+	})
+	return file_admin_proto_rawDescData
+}
+
+var file_admin_proto_msgTypes = make([]protoimpl.MessageInfo, 19)
+var file_admin_proto_goTypes = []any{
+	(*StatusRequest)(nil),         // 0: gopost.admin.v1.StatusRequest
+	(*StatusResponse)(nil),        // 1: gopost.admin.v1.StatusResponse
+	(*BuildInfo)(nil),             // 2: gopost.admin.v1.BuildInfo
+	(*ServiceStatus)(nil),         // 3: gopost.admin.v1.ServiceStatus
+	(*Health)(nil),                // 4: gopost.admin.v1.Health
+	(*MaintenanceStatus)(nil),     // 5: gopost.admin.v1.MaintenanceStatus
+	(*RunReport)(nil),             // 6: gopost.admin.v1.RunReport
+	(*CityReport)(nil),            // 7: gopost.admin.v1.CityReport
+	(*DedupReport)(nil),           // 8: gopost.admin.v1.DedupReport
+	(*SourceCount)(nil),           // 9: gopost.admin.v1.SourceCount
+	(*TriggerSyncRequest)(nil),    // 10: gopost.admin.v1.TriggerSyncRequest
+	(*TriggerSyncResponse)(nil),   // 11: gopost.admin.v1.TriggerSyncResponse
+	(*PauseRequest)(nil),          // 12: gopost.admin.v1.PauseRequest
+	(*PauseResponse)(nil),         // 13: gopost.admin.v1.PauseResponse
+	(*ResumeRequest)(nil),         // 14: gopost.admin.v1.ResumeRequest
+	(*ResumeResponse)(nil),        // 15: gopost.admin.v1.ResumeResponse
+	(*DedupLookupRequest)(nil),    // 16: gopost.admin.v1.DedupLookupRequest
+	(*DedupLookupResponse)(nil),   // 17: gopost.admin.v1.DedupLookupResponse
+	nil,                           // 18: gopost.admin.v1.ServiceStatus.TenantsEntry
+	(*timestamppb.Timestamp)(nil), // 19: google.protobuf.Timestamp
+	(*durationpb.Duration)(nil),   // 20: google.protobuf.Duration
+}
+var file_admin_proto_depIdxs = []int32{
+	2,  // 0: gopost.admin.v1.StatusResponse.build:type_name -> gopost.admin.v1.BuildInfo
+	19, // 1: gopost.admin.v1.StatusResponse.started_at:type_name -> google.protobuf.Timestamp
+	20, // 2: gopost.admin.v1.StatusResponse.uptime:type_name -> google.protobuf.Duration
+	3,  // 3: gopost.admin.v1.StatusResponse.service:type_name -> gopost.admin.v1.ServiceStatus
+	19, // 4: gopost.admin.v1.ServiceStatus.last_check:type_name -> google.protobuf.Timestamp
+	6,  // 5: gopost.admin.v1.ServiceStatus.last_report:type_name -> gopost.admin.v1.RunReport
+	18, // 6: gopost.admin.v1.ServiceStatus.tenants:type_name -> gopost.admin.v1.ServiceStatus.TenantsEntry
+	5,  // 7: gopost.admin.v1.ServiceStatus.maintenance:type_name -> gopost.admin.v1.MaintenanceStatus
+	4,  // 8: gopost.admin.v1.ServiceStatus.health:type_name -> gopost.admin.v1.Health
+	20, // 9: gopost.admin.v1.Health.lag:type_name -> google.protobuf.Duration
+	19, // 10: gopost.admin.v1.MaintenanceStatus.since:type_name -> google.protobuf.Timestamp
+	19, // 11: gopost.admin.v1.MaintenanceStatus.retry_at:type_name -> google.protobuf.Timestamp
+	19, // 12: gopost.admin.v1.RunReport.started_at:type_name -> google.protobuf.Timestamp
+	20, // 13: gopost.admin.v1.RunReport.duration:type_name -> google.protobuf.Duration
+	7,  // 14: gopost.admin.v1.RunReport.cities:type_name -> gopost.admin.v1.CityReport
+	8,  // 15: gopost.admin.v1.RunReport.dedup:type_name -> gopost.admin.v1.DedupReport
+	9,  // 16: gopost.admin.v1.DedupReport.top_duplicate_sources:type_name -> gopost.admin.v1.SourceCount
+	20, // 17: gopost.admin.v1.DedupLookupResponse.ttl:type_name -> google.protobuf.Duration
+	3,  // 18: gopost.admin.v1.ServiceStatus.TenantsEntry.value:type_name -> gopost.admin.v1.ServiceStatus
+	0,  // 19: gopost.admin.v1.Admin.Status:input_type -> gopost.admin.v1.StatusRequest
+	10, // 20: gopost.admin.v1.Admin.TriggerSync:input_type -> gopost.admin.v1.TriggerSyncRequest
+	12, // 21: gopost.admin.v1.Admin.Pause:input_type -> gopost.admin.v1.PauseRequest
+	14, // 22: gopost.admin.v1.Admin.Resume:input_type -> gopost.admin.v1.ResumeRequest
+	16, // 23: gopost.admin.v1.Admin.DedupLookup:input_type -> gopost.admin.v1.DedupLookupRequest
+	1,  // 24: gopost.admin.v1.Admin.Status:output_type -> gopost.admin.v1.StatusResponse
+	11, // 25: gopost.admin.v1.Admin.TriggerSync:output_type -> gopost.admin.v1.TriggerSyncResponse
+	13, // 26: gopost.admin.v1.Admin.Pause:output_type -> gopost.admin.v1.PauseResponse
+	15, // 27: gopost.admin.v1.Admin.Resume:output_type -> gopost.admin.v1.ResumeResponse
+	17, // 28: gopost.admin.v1.Admin.DedupLookup:output_type -> gopost.admin.v1.DedupLookupResponse
+	24, // [24:29] is the sub-list for method output_type
+	19, // [19:24] is the sub-list for method input_type
+	19, // [19:19] is the sub-list for extension type_name
+	19, // [19:19] is the sub-list for extension extendee
+	0,  // [0:19] is the sub-list for field type_name
+}
+
+func init() { file_admin_proto_init() }
+func file_admin_proto_init() {
+	if File_admin_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_admin_proto_rawDesc), len(file_admin_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   19,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_admin_proto_goTypes,
+		DependencyIndexes: file_admin_proto_depIdxs,
+		MessageInfos:      file_admin_proto_msgTypes,
+	}.Build()
+	File_admin_proto = out.File
+	file_admin_proto_goTypes = nil
+	file_admin_proto_depIdxs = nil
+}
@@ -0,0 +1,279 @@
+// Package grpcadmin serves the admin API over gRPC for fleet tooling, optionally with mTLS.
+// It mirrors the endpoints of the admin HTTP server.
+package grpcadmin
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"time"
+
+	"github.com/gopost/integration/internal/admin"
+	"github.com/gopost/integration/internal/buildinfo"
+	"github.com/gopost/integration/internal/grpcadmin/adminpb"
+	"github.com/gopost/integration/internal/integration"
+	"github.com/gopost/integration/internal/logger"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// shutdownTimeout bounds how long in-flight RPCs may run after shutdown starts.
+const shutdownTimeout = 5 * time.Second
+
+// Backend is the service state exposed over gRPC. *integration.Service and
+// *integration.Group satisfy it.
+type Backend interface {
+	admin.StatusSource
+	admin.PauseControl
+	admin.SyncTrigger
+	admin.DedupLookup
+}
+
+// Server is the admin gRPC server.
+type Server struct {
+	adminpb.UnimplementedAdminServer
+
+	addr      string
+	backend   Backend
+	tls       *tls.Config // nil serves plaintext
+	build     buildinfo.Info
+	startedAt time.Time
+	logger    logger.Logger
+}
+
+// NewServer returns a gRPC server that will listen on addr (e.g. ":9090"). A nil
+// tlsConfig serves plaintext.
+func NewServer(addr string, backend Backend, tlsConfig *tls.Config, log logger.Logger) *Server {
+	return &Server{
+		addr:      addr,
+		backend:   backend,
+		tls:       tlsConfig,
+		build:     buildinfo.Get(),
+		startedAt: time.Now(),
+		logger:    log,
+	}
+}
+
+// LoadTLSConfig builds the server TLS configuration from PEM files. When clientCAFile is
+// set, clients must present a certificate signed by one of its CAs (mTLS).
+func LoadTLSConfig(certFile, keyFile, clientCAFile string) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("load server certificate: %w", err)
+	}
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+	if clientCAFile == "" {
+		return tlsConfig, nil
+	}
+
+	caPEM, err := os.ReadFile(clientCAFile)
+	if err != nil {
+		return nil, fmt.Errorf("read client CA: %w", err)
+	}
+	clientCAs := x509.NewCertPool()
+	if !clientCAs.AppendCertsFromPEM(caPEM) {
+		return nil, fmt.Errorf("client CA %s: no certificates found", clientCAFile)
+	}
+	tlsConfig.ClientCAs = clientCAs
+	tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	return tlsConfig, nil
+}
+
+// Run listens on the configured address and serves until ctx is done.
+func (s *Server) Run(ctx context.Context) error {
+	listener, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		return fmt.Errorf("grpc server: %w", err)
+	}
+	return s.Serve(ctx, listener)
+}
+
+// Serve serves on listener until ctx is done, then stops gracefully.
+func (s *Server) Serve(ctx context.Context, listener net.Listener) error {
+	var opts []grpc.ServerOption
+	if s.tls != nil {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(s.tls)))
+	}
+	srv := grpc.NewServer(opts...)
+	adminpb.RegisterAdminServer(srv, s)
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- srv.Serve(listener)
+	}()
+	s.logger.Info("gRPC server listening",
+		logger.String("addr", listener.Addr().String()),
+		logger.Bool("tls", s.tls != nil),
+		logger.Bool("mtls", s.tls != nil && s.tls.ClientAuth == tls.RequireAndVerifyClientCert),
+	)
+
+	select {
+	case err := <-errCh:
+		return fmt.Errorf("grpc server: %w", err)
+	case <-ctx.Done():
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		srv.GracefulStop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(shutdownTimeout):
+		srv.Stop()
+	}
+	if err := <-errCh; err != nil && !errors.Is(err, grpc.ErrServerStopped) {
+		return fmt.Errorf("grpc server: %w", err)
+	}
+	return nil
+}
+
+// Status returns build information, uptime, and the service status.
+func (s *Server) Status(context.Context, *adminpb.StatusRequest) (*adminpb.StatusResponse, error) {
+	return &adminpb.StatusResponse{
+		Build: &adminpb.BuildInfo{
+			Version:   s.build.Version,
+			Commit:    s.build.Commit,
+			Date:      s.build.Date,
+			GoVersion: s.build.GoVersion,
+		},
+		StartedAt: timestamppb.New(s.startedAt),
+		Uptime:    durationpb.New(time.Since(s.startedAt).Round(time.Second)),
+		Service:   serviceStatus(s.backend.Status()),
+	}, nil
+}
+
+// TriggerSync starts a sync run ahead of schedule.
+func (s *Server) TriggerSync(_ context.Context, req *adminpb.TriggerSyncRequest) (*adminpb.TriggerSyncResponse, error) {
+	return &adminpb.TriggerSyncResponse{Triggered: s.backend.TriggerSync(req.GetRunToken())}, nil
+}
+
+// Pause pauses posting for a city, or all cities when the city is empty.
+func (s *Server) Pause(ctx context.Context, req *adminpb.PauseRequest) (*adminpb.PauseResponse, error) {
+	if err := s.backend.Pause(ctx, req.GetCity(), req.GetReason()); err != nil {
+		return nil, s.controlError("pause", req.GetCity(), err)
+	}
+	return &adminpb.PauseResponse{}, nil
+}
+
+// Resume resumes posting for a city, or all cities when the city is empty.
+func (s *Server) Resume(ctx context.Context, req *adminpb.ResumeRequest) (*adminpb.ResumeResponse, error) {
+	if err := s.backend.Resume(ctx, req.GetCity()); err != nil {
+		return nil, s.controlError("resume", req.GetCity(), err)
+	}
+	return &adminpb.ResumeResponse{}, nil
+}
+
+// DedupLookup reports an article's dedup state.
+func (s *Server) DedupLookup(ctx context.Context, req *adminpb.DedupLookupRequest) (*adminpb.DedupLookupResponse, error) {
+	if req.GetArticleId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "article_id is required")
+	}
+	entry, err := s.backend.DedupLookup(ctx, req.GetArticleId())
+	if err != nil {
+		s.logger.Warn("gRPC dedup lookup failed",
+			logger.String("article_id", req.GetArticleId()),
+			logger.Error(err),
+		)
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	resp := &adminpb.DedupLookupResponse{State: entry.State}
+	if entry.TTL > 0 {
+		resp.Ttl = durationpb.New(entry.TTL)
+	}
+	return resp, nil
+}
+
+func (s *Server) controlError(action, city string, err error) error {
+	s.logger.Warn("gRPC control request failed",
+		logger.String("action", action),
+		logger.String("city", city),
+		logger.Error(err),
+	)
+	return status.Error(codes.Internal, err.Error())
+}
+
+func serviceStatus(st integration.Status) *adminpb.ServiceStatus {
+	msg := &adminpb.ServiceStatus{
+		LastCheck:     timestamp(st.LastCheck),
+		LastReport:    runReport(st.LastReport),
+		CityCount:     int32(st.CityCount),
+		OutboxEnabled: st.OutboxEnabled,
+		Health: &adminpb.Health{
+			Score:           st.Health.Score,
+			ErrorRatio:      st.Health.ErrorRatio,
+			ElasticsearchUp: st.Health.Backends.Elasticsearch,
+			DrupalUp:        st.Health.Backends.Drupal,
+			RedisUp:         st.Health.Backends.Redis,
+			Lag:             durationpb.New(st.Health.Lag),
+		},
+	}
+	if st.Maintenance != nil {
+		msg.Maintenance = &adminpb.MaintenanceStatus{
+			Since:   timestamp(st.Maintenance.Since),
+			RetryAt: timestamp(st.Maintenance.RetryAt),
+		}
+	}
+	if len(st.Tenants) > 0 {
+		msg.Tenants = make(map[string]*adminpb.ServiceStatus, len(st.Tenants))
+		for name, tenant := range st.Tenants {
+			msg.Tenants[name] = serviceStatus(tenant)
+		}
+	}
+	return msg
+}
+
+func runReport(report integration.RunReport) *adminpb.RunReport {
+	msg := &adminpb.RunReport{
+		StartedAt: timestamp(report.StartedAt),
+		Duration:  durationpb.New(report.Duration),
+		Dedup: &adminpb.DedupReport{
+			AlreadyPosted:    int32(report.Dedup.AlreadyPosted),
+			TitleMatches:     int32(report.Dedup.TitleMatches),
+			NearMatches:      int32(report.Dedup.NearMatches),
+			ReserveConflicts: int32(report.Dedup.ReserveConflicts),
+			AlreadyQueued:    int32(report.Dedup.AlreadyQueued),
+		},
+	}
+	for _, city := range report.Cities {
+		msg.Cities = append(msg.Cities, &adminpb.CityReport{
+			City:        city.City,
+			Found:       int32(city.Found),
+			Posted:      int32(city.Posted),
+			Queued:      int32(city.Queued),
+			Skipped:     int32(city.Skipped),
+			Errors:      int32(city.Errors),
+			Failed:      city.Failed,
+			BrokenLinks: int32(city.BrokenLinks),
+			Paused:      city.Paused,
+		})
+	}
+	for _, source := range report.Dedup.TopDuplicateSources {
+		msg.Dedup.TopDuplicateSources = append(msg.Dedup.TopDuplicateSources, &adminpb.SourceCount{
+			Source: source.Source,
+			Count:  int32(source.Count),
+		})
+	}
+	return msg
+}
+
+// timestamp leaves zero times (no run yet) unset.
+func timestamp(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
@@ -0,0 +1,241 @@
+package grpcadmin_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gopost/integration/internal/dedup"
+	"github.com/gopost/integration/internal/grpcadmin"
+	"github.com/gopost/integration/internal/grpcadmin/adminpb"
+	"github.com/gopost/integration/internal/integration"
+	"github.com/gopost/integration/internal/logger"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+)
+
+type fakeBackend struct {
+	paused    map[string]string
+	triggered bool
+	runToken  string
+}
+
+func (f *fakeBackend) Status() integration.Status {
+	return integration.Status{
+		CityCount:  2,
+		LastReport: integration.RunReport{Cities: []integration.CityReport{{City: "sudbury_com", Posted: 3}}},
+	}
+}
+
+func (f *fakeBackend) Pause(_ context.Context, city, reason string) error {
+	f.paused[city] = reason
+	return nil
+}
+
+func (f *fakeBackend) Resume(_ context.Context, city string) error {
+	delete(f.paused, city)
+	return nil
+}
+
+func (f *fakeBackend) TriggerSync(runToken string) bool {
+	already := f.triggered
+	f.triggered = true
+	if !already {
+		f.runToken = runToken
+	}
+	return !already
+}
+
+func (f *fakeBackend) DedupLookup(_ context.Context, articleID string) (dedup.Entry, error) {
+	if articleID == "posted-1" {
+		return dedup.Entry{State: dedup.StatePosted, TTL: time.Hour}, nil
+	}
+	return dedup.Entry{State: dedup.StateNone}, nil
+}
+
+// testCA issues certificates for one test.
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	dir  string
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "gopost test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca := &testCA{cert: cert, key: key, dir: t.TempDir()}
+	ca.writePEM(t, "ca.pem", "CERTIFICATE", der)
+	return ca
+}
+
+// issue writes a leaf certificate and key for name and returns their paths.
+func (ca *testCA) issue(t *testing.T, name string, usage x509.ExtKeyUsage) (certFile, keyFile string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ca.writePEM(t, name+".pem", "CERTIFICATE", der), ca.writePEM(t, name+"-key.pem", "EC PRIVATE KEY", keyDER)
+}
+
+func (ca *testCA) writePEM(t *testing.T, name, blockType string, der []byte) string {
+	t.Helper()
+	path := filepath.Join(ca.dir, name)
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestServer_MutualTLS(t *testing.T) {
+	ca := newTestCA(t)
+	serverCert, serverKey := ca.issue(t, "server", x509.ExtKeyUsageServerAuth)
+	clientCert, clientKey := ca.issue(t, "client", x509.ExtKeyUsageClientAuth)
+	caFile := filepath.Join(ca.dir, "ca.pem")
+
+	tlsConfig, err := grpcadmin.LoadTLSConfig(serverCert, serverKey, caFile)
+	if err != nil {
+		t.Fatalf("LoadTLSConfig() error = %v", err)
+	}
+	backend := &fakeBackend{paused: make(map[string]string)}
+	server := grpcadmin.NewServer("", backend, tlsConfig, logger.NewNopLogger())
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- server.Serve(ctx, listener) }()
+	t.Cleanup(func() {
+		cancel()
+		if err := <-done; err != nil {
+			t.Errorf("Serve() error = %v", err)
+		}
+	})
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	dial := func(certs ...tls.Certificate) adminpb.AdminClient {
+		t.Helper()
+		creds := credentials.NewTLS(&tls.Config{RootCAs: roots, Certificates: certs, MinVersion: tls.VersionTLS12})
+		conn, err := grpc.NewClient(listener.Addr().String(), grpc.WithTransportCredentials(creds))
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { _ = conn.Close() })
+		return adminpb.NewAdminClient(conn)
+	}
+	callCtx, callCancel := context.WithTimeout(ctx, 10*time.Second)
+	defer callCancel()
+
+	if _, err := dial().Status(callCtx, &adminpb.StatusRequest{}); err == nil {
+		t.Fatal("Status() without a client certificate succeeded, want TLS rejection")
+	}
+
+	keyPair, err := tls.LoadX509KeyPair(clientCert, clientKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := dial(keyPair)
+
+	statusResp, err := client.Status(callCtx, &adminpb.StatusRequest{})
+	if err != nil {
+		t.Fatalf("Status() error = %v", err)
+	}
+	if statusResp.GetService().GetCityCount() != 2 || statusResp.GetService().GetLastReport().GetCities()[0].GetPosted() != 3 {
+		t.Errorf("Status() service = %v", statusResp.GetService())
+	}
+	if statusResp.GetBuild().GetGoVersion() == "" {
+		t.Errorf("Status() build = %v, want go_version", statusResp.GetBuild())
+	}
+
+	if _, err := client.Pause(callCtx, &adminpb.PauseRequest{City: "sudbury_com", Reason: "bad feed"}); err != nil {
+		t.Fatalf("Pause() error = %v", err)
+	}
+	if backend.paused["sudbury_com"] != "bad feed" {
+		t.Errorf("paused = %v, want sudbury_com with reason", backend.paused)
+	}
+	if _, err := client.Resume(callCtx, &adminpb.ResumeRequest{City: "sudbury_com"}); err != nil {
+		t.Fatalf("Resume() error = %v", err)
+	}
+	if len(backend.paused) != 0 {
+		t.Errorf("paused = %v after resume, want none", backend.paused)
+	}
+
+	for i, want := range []bool{true, false} {
+		resp, err := client.TriggerSync(callCtx, &adminpb.TriggerSyncRequest{RunToken: "nightly-42"})
+		if err != nil {
+			t.Fatalf("TriggerSync() error = %v", err)
+		}
+		if resp.GetTriggered() != want {
+			t.Errorf("TriggerSync() call %d triggered = %v, want %v", i+1, resp.GetTriggered(), want)
+		}
+	}
+	if backend.runToken != "nightly-42" {
+		t.Errorf("run token = %q, want nightly-42", backend.runToken)
+	}
+
+	lookup, err := client.DedupLookup(callCtx, &adminpb.DedupLookupRequest{ArticleId: "posted-1"})
+	if err != nil {
+		t.Fatalf("DedupLookup() error = %v", err)
+	}
+	if lookup.GetState() != dedup.StatePosted || lookup.GetTtl().AsDuration() != time.Hour {
+		t.Errorf("DedupLookup() = %v, want posted with 1h TTL", lookup)
+	}
+	_, err = client.DedupLookup(callCtx, &adminpb.DedupLookupRequest{})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("DedupLookup(empty id) code = %v, want InvalidArgument", status.Code(err))
+	}
+}
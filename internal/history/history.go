@@ -0,0 +1,102 @@
+// Package history persists a summary of each sync run in Redis so posting trends can be
+// reported later with "gopost report".
+package history
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/gopost/integration/internal/logger"
+	"github.com/redis/go-redis/v9"
+)
+
+// Key, after the key prefix, is the sorted set holding run records, scored by start time
+// in Unix milliseconds.
+const Key = "runs"
+
+// Run is the persisted summary of one sync run.
+type Run struct {
+	StartedAt time.Time     `json:"started_at"`
+	Duration  time.Duration `json:"duration"`
+	Cities    []CityRun     `json:"cities"`
+}
+
+// CityRun is one city's counts within a run.
+type CityRun struct {
+	City     string        `json:"city"`
+	Found    int           `json:"found"`
+	Posted   int           `json:"posted"`
+	Queued   int           `json:"queued"`
+	Skipped  int           `json:"skipped"`
+	Errors   int           `json:"errors"`
+	Failed   bool          `json:"failed"`
+	PostTime time.Duration `json:"post_time"` // Total time spent in Drupal posts
+}
+
+// Store saves and reads run records. Records older than the retention period are
+// trimmed whenever a run is saved.
+type Store struct {
+	client    *redis.Client
+	key       string // Key after the key prefix
+	retention time.Duration
+	logger    logger.Logger
+}
+
+// NewStore returns a store keeping records under prefix (redis.key_prefix) for retention.
+func NewStore(client *redis.Client, prefix string, retention time.Duration, log logger.Logger) *Store {
+	return &Store{
+		client:    client,
+		key:       prefix + Key,
+		retention: retention,
+		logger:    log,
+	}
+}
+
+// Save records a run and drops records past the retention period.
+func (s *Store) Save(ctx context.Context, run Run) error {
+	data, err := json.Marshal(run)
+	if err != nil {
+		return fmt.Errorf("encode run: %w", err)
+	}
+	cutoff := time.Now().Add(-s.retention).UnixMilli()
+
+	pipe := s.client.TxPipeline()
+	pipe.ZAdd(ctx, s.key, redis.Z{Score: float64(run.StartedAt.UnixMilli()), Member: data})
+	pipe.ZRemRangeByScore(ctx, s.key, "-inf", "("+strconv.FormatInt(cutoff, 10))
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("save run: %w", err)
+	}
+
+	s.logger.Debug("Run saved to history",
+		logger.Time("started_at", run.StartedAt),
+		logger.Duration("retention", s.retention),
+	)
+	return nil
+}
+
+// Since returns the runs started at or after since, oldest first.
+func (s *Store) Since(ctx context.Context, since time.Time) ([]Run, error) {
+	members, err := s.client.ZRangeByScore(ctx, s.key, &redis.ZRangeBy{
+		Min: strconv.FormatInt(since.UnixMilli(), 10),
+		Max: "+inf",
+	}).Result()
+	if err != nil {
+		return nil, fmt.Errorf("read runs: %w", err)
+	}
+
+	runs := make([]Run, 0, len(members))
+	for _, member := range members {
+		var run Run
+		if err := json.Unmarshal([]byte(member), &run); err != nil {
+			s.logger.Warn("Skipping unreadable run record",
+				logger.Error(err),
+			)
+			continue
+		}
+		runs = append(runs, run)
+	}
+	return runs, nil
+}
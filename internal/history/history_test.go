@@ -0,0 +1,90 @@
+package history_test
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gopost/integration/internal/dedup/deduptest"
+	"github.com/gopost/integration/internal/history"
+	"github.com/gopost/integration/internal/logger"
+)
+
+func TestStore_SaveTrimsAndReadsWindow(t *testing.T) {
+	mr := miniredis.RunT(t)
+	store := history.NewStore(deduptest.NewClient(t, mr), deduptest.KeyPrefix, 48*time.Hour, logger.NewNopLogger())
+	ctx := context.Background()
+	now := time.Now()
+
+	for _, age := range []time.Duration{72 * time.Hour, 30 * time.Hour, time.Hour} {
+		run := history.Run{
+			StartedAt: now.Add(-age),
+			Cities:    []history.CityRun{{City: "sudbury_com", Posted: 1}},
+		}
+		if err := store.Save(ctx, run); err != nil {
+			t.Fatalf("Save() error = %v", err)
+		}
+	}
+
+	all, err := store.Since(ctx, now.Add(-365*24*time.Hour))
+	if err != nil {
+		t.Fatalf("Since() error = %v", err)
+	}
+	if len(all) != 2 {
+		t.Fatalf("Since(year) returned %d runs, want 2 after trimming past retention", len(all))
+	}
+	if !all[0].StartedAt.Before(all[1].StartedAt) {
+		t.Errorf("runs are not oldest first: %v, %v", all[0].StartedAt, all[1].StartedAt)
+	}
+
+	recent, err := store.Since(ctx, now.Add(-24*time.Hour))
+	if err != nil {
+		t.Fatalf("Since() error = %v", err)
+	}
+	if len(recent) != 1 {
+		t.Errorf("Since(24h) returned %d runs, want 1", len(recent))
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	runs := []history.Run{
+		{Cities: []history.CityRun{
+			{City: "toronto_com", Found: 4, Posted: 2, Errors: 2, PostTime: 400 * time.Millisecond},
+			{City: "sudbury_com", Found: 1, Posted: 1, PostTime: 100 * time.Millisecond},
+		}},
+		{Cities: []history.CityRun{
+			{City: "toronto_com", Found: 2, Posted: 2, PostTime: 200 * time.Millisecond},
+			{City: "sudbury_com", Failed: true},
+		}},
+	}
+
+	trends := history.Summarize(runs)
+	if len(trends) != 2 || trends[0].City != "sudbury_com" || trends[1].City != "toronto_com" {
+		t.Fatalf("Summarize() = %+v, want sudbury_com then toronto_com", trends)
+	}
+	toronto := trends[1]
+	if toronto.Runs != 2 || toronto.Found != 6 || toronto.Posted != 4 || toronto.Errors != 2 {
+		t.Errorf("toronto totals = %+v", toronto)
+	}
+	if got := toronto.ErrorRate(); got < 0.333 || got > 0.334 {
+		t.Errorf("ErrorRate() = %v, want 1/3", got)
+	}
+	if got := toronto.AvgPostLatency(); got != 150*time.Millisecond {
+		t.Errorf("AvgPostLatency() = %v, want 150ms", got)
+	}
+	if trends[0].FailedRuns != 1 {
+		t.Errorf("sudbury FailedRuns = %d, want 1", trends[0].FailedRuns)
+	}
+
+	var buf bytes.Buffer
+	if err := history.WriteCSV(&buf, trends); err != nil {
+		t.Fatalf("WriteCSV() error = %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 || !strings.HasSuffix(lines[0], "avg_post_latency_ms") || lines[2] != "toronto_com,2,0,6,4,0,0,2,0.333,150" {
+		t.Errorf("WriteCSV() =\n%s", buf.String())
+	}
+}
@@ -0,0 +1,118 @@
+package history
+
+import (
+	"cmp"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// CityTrend totals one city's runs over a reporting period.
+type CityTrend struct {
+	City       string
+	Runs       int
+	FailedRuns int // Runs whose search failed
+	Found      int
+	Posted     int
+	Queued     int
+	Skipped    int
+	Errors     int
+	PostTime   time.Duration
+}
+
+// ErrorRate is the share of posting attempts that failed.
+func (t CityTrend) ErrorRate() float64 {
+	attempts := t.Posted + t.Errors
+	if attempts == 0 {
+		return 0
+	}
+	return float64(t.Errors) / float64(attempts)
+}
+
+// AvgPostLatency is the mean Drupal post time of the posted articles.
+func (t CityTrend) AvgPostLatency() time.Duration {
+	if t.Posted == 0 {
+		return 0
+	}
+	return t.PostTime / time.Duration(t.Posted)
+}
+
+// Summarize totals runs per city, sorted by city name.
+func Summarize(runs []Run) []CityTrend {
+	byCity := make(map[string]*CityTrend)
+	for _, run := range runs {
+		for _, city := range run.Cities {
+			trend, ok := byCity[city.City]
+			if !ok {
+				trend = &CityTrend{City: city.City}
+				byCity[city.City] = trend
+			}
+			trend.Runs++
+			if city.Failed {
+				trend.FailedRuns++
+			}
+			trend.Found += city.Found
+			trend.Posted += city.Posted
+			trend.Queued += city.Queued
+			trend.Skipped += city.Skipped
+			trend.Errors += city.Errors
+			trend.PostTime += city.PostTime
+		}
+	}
+
+	trends := make([]CityTrend, 0, len(byCity))
+	for _, trend := range byCity {
+		trends = append(trends, *trend)
+	}
+	slices.SortFunc(trends, func(a, b CityTrend) int { return cmp.Compare(a.City, b.City) })
+	return trends
+}
+
+var columns = []string{"city", "runs", "failed_runs", "found", "posted", "queued", "skipped", "errors", "error_rate", "avg_post_latency"}
+
+func (t CityTrend) row(latency string) []string {
+	return []string{
+		t.City,
+		strconv.Itoa(t.Runs),
+		strconv.Itoa(t.FailedRuns),
+		strconv.Itoa(t.Found),
+		strconv.Itoa(t.Posted),
+		strconv.Itoa(t.Queued),
+		strconv.Itoa(t.Skipped),
+		strconv.Itoa(t.Errors),
+		strconv.FormatFloat(t.ErrorRate(), 'f', 3, 64),
+		latency,
+	}
+}
+
+// WriteTable prints trends as an aligned table.
+func WriteTable(w io.Writer, trends []CityTrend) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join(columns, "\t"))
+	for _, trend := range trends {
+		fmt.Fprintln(tw, strings.Join(trend.row(trend.AvgPostLatency().Round(time.Millisecond).String()), "\t"))
+	}
+	return tw.Flush()
+}
+
+// WriteCSV writes trends as CSV with a header row. Latencies are in milliseconds.
+func WriteCSV(w io.Writer, trends []CityTrend) error {
+	cw := csv.NewWriter(w)
+	header := slices.Clone(columns)
+	header[len(header)-1] = "avg_post_latency_ms"
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+	for _, trend := range trends {
+		if err := cw.Write(trend.row(strconv.FormatInt(trend.AvgPostLatency().Milliseconds(), 10))); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,168 @@
+// Package hooks runs user-configured shell commands and HTTP callouts at lifecycle points
+// of a run, so deployments can add side effects (notifications, cache purges, metrics)
+// without forking gopost.
+package hooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/gopost/integration/internal/config"
+)
+
+// maxOutput is how much of a failed command's output or a rejected response body is kept
+// in the returned error.
+const maxOutput = 512
+
+// waitDelay is how long a command's output is still read after its timeout kills it.
+const waitDelay = time.Second
+
+// Runner runs the hooks configured for each event.
+type Runner struct {
+	hooks     map[string][]config.HookConfig
+	client    *http.Client
+	userAgent string
+}
+
+// NewRunner returns a runner for hooks. An empty userAgent keeps Go's default for HTTP
+// callouts.
+func NewRunner(hooks []config.HookConfig, userAgent string) *Runner {
+	byEvent := make(map[string][]config.HookConfig)
+	for _, hook := range hooks {
+		byEvent[hook.Event] = append(byEvent[hook.Event], hook)
+	}
+	return &Runner{
+		hooks:     byEvent,
+		client:    &http.Client{},
+		userAgent: userAgent,
+	}
+}
+
+// Has reports whether any hook runs at event, so callers can skip building its payload.
+func (r *Runner) Has(event string) bool {
+	return len(r.hooks[event]) > 0
+}
+
+// Run passes payload, encoded as JSON, to every hook configured for event, in config
+// order. Each hook runs with its own timeout; a failing hook does not stop the others.
+// The returned error joins the failures.
+func (r *Runner) Run(ctx context.Context, event string, payload any) error {
+	hooks := r.hooks[event]
+	if len(hooks) == 0 {
+		return nil
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("encode %s payload: %w", event, err)
+	}
+
+	var errs []error
+	for _, hook := range hooks {
+		if err := r.runHook(ctx, hook, body); err != nil {
+			errs = append(errs, fmt.Errorf("%s hook %s: %w", event, describe(hook), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// runHook runs one hook with its timeout.
+func (r *Runner) runHook(ctx context.Context, hook config.HookConfig, body []byte) error {
+	if hook.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, hook.Timeout)
+		defer cancel()
+	}
+	if hook.URL != "" {
+		return r.post(ctx, hook, body)
+	}
+	return runCommand(ctx, hook, body)
+}
+
+// runCommand runs the hook's command with sh -c, the payload on stdin.
+func runCommand(ctx context.Context, hook config.HookConfig, body []byte) error {
+	cmd := exec.CommandContext(ctx, "sh", "-c", hook.Command)
+	cmd.Stdin = bytes.NewReader(body)
+	cmd.Env = append(os.Environ(), "GOPOST_EVENT="+hook.Event)
+	// Children of a killed shell may keep its output open; stop waiting for them
+	cmd.WaitDelay = waitDelay
+	output, err := cmd.CombinedOutput()
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if err != nil {
+		if out := truncate(output); out != "" {
+			return fmt.Errorf("%w: %s", err, out)
+		}
+		return err
+	}
+	return nil
+}
+
+// post sends the payload to the hook's URL.
+func (r *Runner) post(ctx context.Context, hook config.HookConfig, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Gopost-Event", hook.Event)
+	if r.userAgent != "" {
+		req.Header.Set("User-Agent", r.userAgent)
+	}
+	for name, value := range hook.Headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		// Drop the *url.Error wrapper: describe names the hook without the query, which may hold a token
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			return urlErr.Err
+		}
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, maxOutput+1))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		if out := truncate(respBody); out != "" {
+			return fmt.Errorf("status %d: %s", resp.StatusCode, out)
+		}
+		return fmt.Errorf("status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// describe names a hook in errors: its URL without query or credentials, or the first
+// word of its command.
+func describe(hook config.HookConfig) string {
+	if hook.URL != "" {
+		if u, err := url.Parse(hook.URL); err == nil {
+			return u.Scheme + "://" + u.Host + u.Path
+		}
+		return "url"
+	}
+	if fields := strings.Fields(hook.Command); len(fields) > 0 {
+		return fmt.Sprintf("%q", fields[0])
+	}
+	return "command"
+}
+
+// truncate trims output for an error message.
+func truncate(output []byte) string {
+	out := strings.TrimSpace(string(output))
+	if len(out) > maxOutput {
+		out = out[:maxOutput] + "..."
+	}
+	return out
+}
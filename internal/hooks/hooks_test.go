@@ -0,0 +1,85 @@
+package hooks_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gopost/integration/internal/config"
+	"github.com/gopost/integration/internal/hooks"
+)
+
+func TestRunner_Run(t *testing.T) {
+	var gotBody, gotEvent, gotAuth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		gotBody = string(body)
+		gotEvent = r.Header.Get("X-Gopost-Event")
+		gotAuth = r.Header.Get("Authorization")
+	}))
+	t.Cleanup(server.Close)
+
+	out := filepath.Join(t.TempDir(), "hook.out")
+	runner := hooks.NewRunner([]config.HookConfig{
+		{Event: config.HookPostSuccess, Command: `printf '%s ' "$GOPOST_EVENT" > "` + out + `"; cat >> "` + out + `"`},
+		{Event: config.HookPostSuccess, URL: server.URL, Headers: map[string]string{"Authorization": "Bearer secret"}},
+	}, "gopost/test")
+
+	if runner.Has(config.HookPrePost) {
+		t.Error("Has(pre_post) = true without pre_post hooks")
+	}
+	if err := runner.Run(context.Background(), config.HookPrePost, map[string]string{"id": "a1"}); err != nil {
+		t.Errorf("Run(pre_post) error = %v, want nil without hooks", err)
+	}
+
+	if err := runner.Run(context.Background(), config.HookPostSuccess, map[string]string{"id": "a1"}); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	written, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("command hook did not run: %v", err)
+	}
+	if want := `post_success {"id":"a1"}`; string(written) != want {
+		t.Errorf("command hook got %q, want %q", written, want)
+	}
+	if gotBody != `{"id":"a1"}` || gotEvent != config.HookPostSuccess || gotAuth != "Bearer secret" {
+		t.Errorf("HTTP hook got body %q, event %q, auth %q", gotBody, gotEvent, gotAuth)
+	}
+}
+
+func TestRunner_RunReportsFailures(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "bad token", http.StatusUnauthorized)
+	}))
+	t.Cleanup(server.Close)
+
+	out := filepath.Join(t.TempDir(), "hook.out")
+	runner := hooks.NewRunner([]config.HookConfig{
+		{Event: config.HookPostFailure, Command: "echo disk full >&2; exit 3"},
+		{Event: config.HookPostFailure, Command: "sleep 5", Timeout: 50 * time.Millisecond},
+		{Event: config.HookPostFailure, URL: server.URL + "/hook?token=secret"},
+		{Event: config.HookPostFailure, Command: `cat > "` + out + `"`},
+	}, "")
+
+	err := runner.Run(context.Background(), config.HookPostFailure, "payload")
+	if err == nil {
+		t.Fatal("Run() error = nil, want the failing hooks")
+	}
+	for _, want := range []string{"exit status 3: disk full", "deadline exceeded", "status 401: bad token"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Run() error = %q, want it to contain %q", err, want)
+		}
+	}
+	if strings.Contains(err.Error(), "secret") {
+		t.Errorf("Run() error = %q, leaks the URL query", err)
+	}
+	if _, statErr := os.Stat(out); statErr != nil {
+		t.Errorf("hook after the failures did not run: %v", statErr)
+	}
+}
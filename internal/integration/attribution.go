@@ -0,0 +1,68 @@
+package integration
+
+import (
+	"cmp"
+	"fmt"
+	"html"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/gopost/integration/internal/config"
+	"github.com/gopost/integration/pkg/drupal"
+	"github.com/gopost/integration/pkg/pipeline"
+)
+
+// outletFor returns the configured attribution for the article's outlet. Keys match
+// like source filter entries (source field or URL domain); an exact source match wins,
+// then the longest matching key, so "news.example.com" beats "example.com".
+func (s *Service) outletFor(article *pipeline.Article) (config.OutletConfig, bool) {
+	outlets := s.config.Sources.Outlets
+	if len(outlets) == 0 {
+		return config.OutletConfig{}, false
+	}
+
+	source := strings.ToLower(strings.TrimSpace(article.Source))
+	for key, outlet := range outlets {
+		if strings.ToLower(strings.TrimSpace(key)) == source && source != "" {
+			return outlet, true
+		}
+	}
+
+	keys := slices.SortedFunc(maps.Keys(outlets), func(a, b string) int {
+		return cmp.Or(cmp.Compare(len(b), len(a)), cmp.Compare(a, b))
+	})
+	for _, key := range keys {
+		if pipeline.NewSourceFilter([]string{key}, nil).Allows(*article) {
+			return outlets[key], true
+		}
+	}
+	return config.OutletConfig{}, false
+}
+
+// applyAttribution credits the article's outlet on req when a mapping is configured.
+func (s *Service) applyAttribution(req *drupal.ArticleRequest, article *pipeline.Article) {
+	outlet, ok := s.outletFor(article)
+	if !ok {
+		return
+	}
+
+	req.SourceName = outlet.Name
+	req.SourceURL = outlet.Homepage
+	req.SourceTerms = outlet.Terms
+	req.SourceTermType = s.config.Sources.TermType
+
+	if s.config.Sources.AttributionBody && outlet.Name != "" {
+		req.Body += attributionHTML(outlet)
+	}
+}
+
+// attributionHTML renders the "Source: ..." paragraph appended to posted bodies.
+func attributionHTML(outlet config.OutletConfig) string {
+	name := html.EscapeString(outlet.Name)
+	if outlet.Homepage == "" {
+		return fmt.Sprintf(`<p class="source-attribution">Source: %s</p>`, name)
+	}
+	return fmt.Sprintf(`<p class="source-attribution">Source: <a href="%s">%s</a></p>`,
+		html.EscapeString(outlet.Homepage), name)
+}
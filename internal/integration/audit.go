@@ -0,0 +1,54 @@
+package integration
+
+import (
+	"context"
+	"time"
+
+	"github.com/gopost/integration/internal/config"
+	"github.com/gopost/integration/internal/logger"
+	"github.com/gopost/integration/pkg/drupal"
+	"github.com/gopost/integration/pkg/pipeline"
+)
+
+// auditRecord is the audit index document of a posted article.
+type auditRecord struct {
+	ArticleID      string               `json:"article_id"`
+	City           string               `json:"city"`
+	SourceIndex    string               `json:"source_index"` // Index the article was found in
+	Title          string               `json:"title"`
+	URL            string               `json:"url,omitempty"`
+	DrupalUUID     string               `json:"drupal_uuid,omitempty"` // Empty when the poster does not report node UUIDs
+	PublishedAt    time.Time            `json:"published_at,omitzero"`
+	PostedAt       time.Time            `json:"posted_at"`
+	PostDurationMS int64                `json:"post_duration_ms"`
+	Payload        drupal.DrupalArticle `json:"payload"` // JSON:API document sent to Drupal
+}
+
+// auditPost indexes a copy of a posted article in the audit index when audit.enabled is
+// set. Failures are logged only, since the post itself succeeded.
+func (s *Service) auditPost(ctx context.Context, cityCfg config.CityConfig, article *pipeline.Article, req drupal.ArticleRequest, nodeID string, postDuration time.Duration) {
+	if s.audit == nil {
+		return
+	}
+
+	record := auditRecord{
+		ArticleID:      article.ID,
+		City:           cityCfg.Name,
+		SourceIndex:    cityIndex(cityCfg),
+		Title:          req.Title,
+		URL:            article.URL,
+		DrupalUUID:     nodeID,
+		PublishedAt:    article.PublishedAt,
+		PostedAt:       time.Now(),
+		PostDurationMS: postDuration.Milliseconds(),
+		Payload:        drupal.ArticleDocument(req),
+	}
+	auditCtx, cancel := context.WithTimeout(ctx, esQueryTimeout)
+	defer cancel()
+	if err := s.audit.IndexDocument(auditCtx, s.config.Audit.Index, article.ID, record); err != nil {
+		logger.FromContext(ctx).Warn("Failed to index audit copy of posted article",
+			logger.String("audit_index", s.config.Audit.Index),
+			logger.Error(err),
+		)
+	}
+}
@@ -0,0 +1,220 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gopost/integration/internal/config"
+	"github.com/gopost/integration/internal/logger"
+	"github.com/gopost/integration/pkg/drupal"
+	"github.com/gopost/integration/pkg/pipeline"
+	"golang.org/x/time/rate"
+)
+
+// benchmarkCity is the city the synthetic articles are searched for.
+const benchmarkCity = "benchmark"
+
+// BenchmarkOptions shape the synthetic articles of a benchmark run.
+type BenchmarkOptions struct {
+	Articles   int     // Articles the search returns
+	Matching   float64 // Share of articles mentioning a crime keyword, 0 to 1
+	Duplicates float64 // Share of articles repeating an earlier article's ID, 0 to 1
+}
+
+// BenchmarkResult measures one benchmark run.
+type BenchmarkResult struct {
+	Articles int
+	Posted   int
+	Skipped  int
+	Duration time.Duration
+	Allocs   uint64 // Heap allocations during the run
+	Bytes    uint64 // Bytes allocated during the run
+}
+
+// ArticlesPerSecond is the throughput of the run.
+func (r BenchmarkResult) ArticlesPerSecond() float64 {
+	if r.Duration <= 0 {
+		return 0
+	}
+	return float64(r.Articles) / r.Duration.Seconds()
+}
+
+// Benchmark runs synthetic articles through the in-process stages of one city's sync:
+// decoding, source filters, classification, dedup, and building the Drupal request with
+// the configured field map, path alias, and categories. The search, dedup tracker, and
+// poster are in-memory fakes, so the result measures gopost itself rather than its
+// backends. Settings that need a backend (Redis features, link checks, sources service
+// attribution, term name lookups) are left out.
+func Benchmark(ctx context.Context, cfg *config.Config, opts BenchmarkOptions) (BenchmarkResult, error) {
+	service, err := NewService(benchmarkConfig(cfg), logger.NewNopLogger(),
+		WithSource(&syntheticSource{hits: syntheticHits(cfg.Service.CrimeKeywords, opts)}),
+		WithPoster(discardPoster{}),
+		WithTracker(newMemoryTracker()),
+		WithLimiter(rate.NewLimiter(rate.Inf, 1)),
+	)
+	if err != nil {
+		return BenchmarkResult{}, err
+	}
+
+	runtime.GC()
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	start := time.Now()
+	result, err := service.ProcessCity(ctx, config.CityConfig{Name: benchmarkCity, GroupID: "benchmark-group"})
+	duration := time.Since(start)
+	runtime.ReadMemStats(&after)
+	if err != nil {
+		return BenchmarkResult{}, fmt.Errorf("benchmark run: %w", err)
+	}
+
+	return BenchmarkResult{
+		Articles: opts.Articles,
+		Posted:   result.Posted,
+		Skipped:  result.Skipped,
+		Duration: duration,
+		Allocs:   after.Mallocs - before.Mallocs,
+		Bytes:    after.TotalAlloc - before.TotalAlloc,
+	}, nil
+}
+
+// benchmarkConfig returns the pipeline settings of cfg without the features that need a
+// backend or would slow the run down for reasons other than gopost's own work.
+func benchmarkConfig(cfg *config.Config) *config.Config {
+	bench := &config.Config{
+		UserAgent:  cfg.UserAgent,
+		Drupal:     config.DrupalConfig{RevisionLog: cfg.Drupal.RevisionLog},
+		Service:    cfg.Service,
+		Categories: cfg.Categories,
+	}
+	bench.Service.NearDuplicates.Enabled = false
+	bench.Service.LinkCheck.Enabled = false
+	bench.Service.Warmup.Enabled = false
+	bench.Service.VolumeAnomaly.Enabled = false
+	bench.Service.SyncLag.Enabled = false
+	bench.Service.SearchCache.Enabled = false
+	bench.Service.GroupQuota = config.GroupQuotaConfig{}
+	bench.Service.MultiSearch = false
+	bench.Service.MaxArticlesPerCityPerRun = 0
+
+	// Term names would be resolved in Drupal; stand-in IDs keep the categorization work
+	bench.Categories.Mappings = append([]config.CategoryConfig(nil), cfg.Categories.Mappings...)
+	for i := range bench.Categories.Mappings {
+		mapping := &bench.Categories.Mappings[i]
+		if mapping.TermID == "" {
+			mapping.TermID = fmt.Sprintf("benchmark-term-%d", i)
+		}
+	}
+	return bench
+}
+
+// syntheticHits returns opts.Articles search hits, spreading the matching and duplicate
+// articles evenly through the results. Matching articles mention the first search term of
+// keywords.
+func syntheticHits(keywords []string, opts BenchmarkOptions) []pipeline.SearchHit {
+	keyword := "police"
+	if terms := pipeline.SearchTerms(keywords); len(terms) > 0 {
+		keyword = terms[0]
+	}
+	now := time.Now().UTC()
+	hits := make([]pipeline.SearchHit, 0, opts.Articles)
+	var matched, duplicated float64
+	lastMatching := -1
+	for i := range opts.Articles {
+		// Duplicates repeat the latest matching article, so the dedup tracker catches them
+		if duplicated += opts.Duplicates; duplicated >= 1 && lastMatching >= 0 {
+			duplicated--
+			hits = append(hits, hits[lastMatching])
+			continue
+		}
+		topic := "community news"
+		if matched += opts.Matching; matched >= 1 {
+			matched--
+			topic = keyword
+			lastMatching = len(hits)
+		}
+		title := fmt.Sprintf("Article %d about %s in the downtown core", i, topic)
+		body := "<p>" + strings.Repeat(fmt.Sprintf("Residents reported %s near the market on Elm Street. ", topic), 20) + "</p>" +
+			"<p>" + strings.Repeat("More details will follow as the story develops. ", 10) + "</p>"
+		source, _ := json.Marshal(map[string]any{
+			pipeline.ESFieldTitle:         title,
+			pipeline.ESFieldBody:          body,
+			"intro":                       "Residents reported " + topic + ".",
+			pipeline.ESFieldCanonicalURL:  fmt.Sprintf("https://news.example.com/%s/%d", benchmarkCity, i),
+			pipeline.ESFieldSource:        "news.example.com",
+			pipeline.ESFieldPublishedDate: now.Add(-time.Duration(i) * time.Second).Format(time.RFC3339),
+		})
+		hits = append(hits, pipeline.SearchHit{ID: fmt.Sprintf("benchmark-%d", i), Source: source})
+	}
+	return hits
+}
+
+// syntheticSource returns the same hits for every search.
+type syntheticSource struct {
+	hits []pipeline.SearchHit
+}
+
+func (s *syntheticSource) Search(context.Context, string, any) (*pipeline.SearchResult, error) {
+	return &pipeline.SearchResult{Total: len(s.hits), Hits: s.hits}, nil
+}
+
+// discardPoster accepts every post without keeping it.
+type discardPoster struct{}
+
+func (discardPoster) PostArticle(context.Context, drupal.ArticleRequest) error {
+	return nil
+}
+
+// memoryTracker is a pipeline.Tracker that keeps its state in a map.
+type memoryTracker struct {
+	mu     sync.Mutex
+	posted map[string]bool // true when posted, false while reserved
+}
+
+func newMemoryTracker() *memoryTracker {
+	return &memoryTracker{posted: make(map[string]bool)}
+}
+
+func (t *memoryTracker) HasPosted(_ context.Context, articleID string) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	_, ok := t.posted[articleID]
+	return ok
+}
+
+func (t *memoryTracker) Reserve(_ context.Context, articleID string) (bool, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, ok := t.posted[articleID]; ok {
+		return false, nil
+	}
+	t.posted[articleID] = false
+	return true, nil
+}
+
+func (t *memoryTracker) Release(_ context.Context, articleID string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !t.posted[articleID] {
+		delete(t.posted, articleID)
+	}
+	return nil
+}
+
+func (t *memoryTracker) MarkPosted(_ context.Context, articleID string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.posted[articleID] = true
+	return nil
+}
+
+func (t *memoryTracker) FlushAll(context.Context) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	clear(t.posted)
+	return nil
+}
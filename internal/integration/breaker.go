@@ -0,0 +1,72 @@
+package integration
+
+import (
+	"sync"
+	"time"
+)
+
+// backoffBreaker stops posting while Drupal turns every post back the same way, e.g. in
+// maintenance mode or rejecting the credentials, rather than failing article after
+// article. Posting resumes for one try once the backoff has passed; each try that fails
+// again doubles the backoff up to a maximum.
+type backoffBreaker struct {
+	mu      sync.Mutex
+	since   time.Time // When a post first failed; zero while posting works
+	retryAt time.Time // When posting tries again
+	backoff time.Duration
+	err     string // The last failure, for Status
+}
+
+// open reports whether posting should wait. Once the backoff has passed it returns false,
+// so the next post tries again.
+func (b *backoffBreaker) open() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return !b.since.IsZero() && time.Now().Before(b.retryAt)
+}
+
+// trip records a failed post and stops posting for backoff, or for double the previous
+// wait up to maxBackoff if posting had already stopped. Posts failing while the breaker is
+// open, such as those of other outbox workers, do not extend the wait; trip then returns
+// false. Otherwise it returns when posting first stopped and how long it now waits.
+func (b *backoffBreaker) trip(backoff, maxBackoff time.Duration, err error) (since time.Time, wait time.Duration, tripped bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	switch {
+	case b.since.IsZero():
+		b.since = now
+		b.backoff = backoff
+	case now.Before(b.retryAt):
+		return b.since, b.backoff, false
+	default:
+		b.backoff = min(2*b.backoff, maxBackoff)
+	}
+	b.retryAt = now.Add(b.backoff)
+	if err != nil {
+		b.err = err.Error()
+	}
+	return b.since, b.backoff, true
+}
+
+// reset records a successful post. It returns when posting had stopped, or the zero time
+// if the breaker was closed.
+func (b *backoffBreaker) reset() (since time.Time) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	since = b.since
+	b.since = time.Time{}
+	b.retryAt = time.Time{}
+	b.err = ""
+	return since
+}
+
+// state returns when posting stopped, when it tries again, and the last failure. since is
+// zero while posting works.
+func (b *backoffBreaker) state() (since, retryAt time.Time, err string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.since, b.retryAt, b.err
+}
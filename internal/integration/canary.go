@@ -0,0 +1,261 @@
+package integration
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/gopost/integration/internal/canary"
+	"github.com/gopost/integration/internal/config"
+	"github.com/gopost/integration/internal/logger"
+	"github.com/gopost/integration/pkg/pipeline"
+)
+
+// CanaryCounts are the articles of a canary city that the current and the candidate
+// configuration passed during a run.
+type CanaryCounts struct {
+	Baseline  int `json:"baseline"`
+	Candidate int `json:"candidate"`
+}
+
+// canaryState holds the trial as last loaded or saved, and the candidate classifier.
+type canaryState struct {
+	mu         sync.RWMutex
+	trial      canary.Trial
+	classifier pipeline.Classifier // nil when the candidate keeps the current keywords
+}
+
+// get returns the trial.
+func (c *canaryState) get() canary.Trial {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.trial
+}
+
+// set replaces the trial.
+func (c *canaryState) set(trial canary.Trial) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.trial = trial
+}
+
+// canaryFingerprint identifies the candidate configuration and canary cities, so changing
+// either starts a new trial.
+func canaryFingerprint(cfg config.CanaryConfig) string {
+	data, _ := json.Marshal([][]string{cfg.Cities, cfg.CrimeKeywords, cfg.AllowedSources, cfg.BlockedSources})
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
+}
+
+// canaryCity reports whether the city takes part in the canary trial.
+func (s *Service) canaryCity(cityCfg config.CityConfig) bool {
+	return s.canaries != nil && slices.Contains(s.config.Canary.Cities, cityCfg.Name)
+}
+
+// canaryTrialRunning reports whether the city is a canary city of a trial still running.
+func (s *Service) canaryTrialRunning(cityCfg config.CityConfig) bool {
+	return s.canaryCity(cityCfg) && s.trial.get().Verdict == canary.VerdictRunning
+}
+
+// candidateApplies reports whether the city uses the candidate configuration: canary
+// cities do unless the trial failed, and every city does once it passed with promote set.
+func (s *Service) candidateApplies(cityCfg config.CityConfig) bool {
+	if s.canaries == nil {
+		return false
+	}
+	switch s.trial.get().Verdict {
+	case canary.VerdictFailed:
+		return false
+	case canary.VerdictPassed:
+		return s.config.Canary.Promote || s.canaryCity(cityCfg)
+	default:
+		return s.canaryCity(cityCfg)
+	}
+}
+
+// candidateKeywords returns canary.crime_keywords, or service.crime_keywords when the
+// candidate keeps them.
+func (s *Service) candidateKeywords() []string {
+	if len(s.config.Canary.CrimeKeywords) > 0 {
+		return s.config.Canary.CrimeKeywords
+	}
+	return s.config.Service.CrimeKeywords
+}
+
+// crimeKeywords returns the keywords the city's search matches, with regular expression
+// keywords reduced to their literal words. Canary cities of a running trial search for
+// both lists, so the articles of each configuration can be counted.
+func (s *Service) crimeKeywords(cityCfg config.CityConfig) []string {
+	switch {
+	case s.canaryTrialRunning(cityCfg):
+		keywords := slices.Clone(s.config.Service.CrimeKeywords)
+		for _, keyword := range s.candidateKeywords() {
+			if !slices.Contains(keywords, keyword) {
+				keywords = append(keywords, keyword)
+			}
+		}
+		return pipeline.SearchTerms(keywords)
+	case s.candidateApplies(cityCfg):
+		return pipeline.SearchTerms(s.candidateKeywords())
+	default:
+		return pipeline.SearchTerms(s.config.Service.CrimeKeywords)
+	}
+}
+
+// classifierFor returns the classifier of the city's configuration.
+func (s *Service) classifierFor(cityCfg config.CityConfig) pipeline.Classifier {
+	if s.trial.classifier != nil && s.candidateApplies(cityCfg) {
+		return s.trial.classifier
+	}
+	return s.classifier
+}
+
+// canaryShadow counts a canary city's articles as the current and candidate
+// configurations would have filtered them.
+type canaryShadow struct {
+	baselineSources  *pipeline.SourceFilter
+	baselineMatch    pipeline.Classifier
+	candidateSources *pipeline.SourceFilter
+	candidateMatch   pipeline.Classifier
+	counts           CanaryCounts
+}
+
+// newCanaryShadow returns the city's shadow counter, or nil unless the city is a canary
+// city of a running trial.
+func (s *Service) newCanaryShadow(cityCfg config.CityConfig) *canaryShadow {
+	if !s.canaryTrialRunning(cityCfg) {
+		return nil
+	}
+	return &canaryShadow{
+		baselineSources:  s.citySourceFilter(cityCfg, s.config.Service.AllowedSources, s.config.Service.BlockedSources),
+		baselineMatch:    s.classifier,
+		candidateSources: s.sourceFilter(cityCfg),
+		candidateMatch:   s.classifierFor(cityCfg),
+	}
+}
+
+// count counts an article under each configuration that passes it.
+func (c *canaryShadow) count(article pipeline.Article) {
+	if c == nil {
+		return
+	}
+	if c.baselineSources.Allows(article) && c.baselineMatch.Matches(article) {
+		c.counts.Baseline++
+	}
+	if c.candidateSources.Allows(article) && c.candidateMatch.Matches(article) {
+		c.counts.Candidate++
+	}
+}
+
+// report returns the counts for the city report, or nil for cities outside the trial.
+func (c *canaryShadow) report() *CanaryCounts {
+	if c == nil {
+		return nil
+	}
+	counts := c.counts
+	return &counts
+}
+
+// loadCanary refreshes the trial from Redis, picking up verdicts of other replicas. When
+// Redis cannot be read the last known trial is kept.
+func (s *Service) loadCanary(ctx context.Context) {
+	if s.canaries == nil {
+		return
+	}
+
+	loadCtx, cancel := context.WithTimeout(ctx, redisTimeout)
+	defer cancel()
+	trial, err := s.canaries.Load(loadCtx, canaryFingerprint(s.config.Canary), time.Now())
+	if err != nil {
+		s.logger.Warn("Failed to load canary trial",
+			logger.Error(err),
+		)
+		return
+	}
+	s.trial.set(trial)
+}
+
+// recordCanary adds the run's canary counts to the trial and saves it. When the run
+// decides the trial, the verdict is logged and the canary_verdict hooks run. Runs in which
+// no canary city was processed are not counted.
+func (s *Service) recordCanary(ctx context.Context, report RunReport) {
+	if s.canaries == nil || s.trial.get().Verdict != canary.VerdictRunning {
+		return
+	}
+	var counts CanaryCounts
+	counted := false
+	for _, city := range report.Cities {
+		if city.Canary != nil {
+			counts.Baseline += city.Canary.Baseline
+			counts.Candidate += city.Canary.Candidate
+			counted = true
+		}
+	}
+	if !counted {
+		return
+	}
+
+	trial := s.trial.get()
+	decided := trial.Add(counts.Baseline, counts.Candidate, s.config.Canary.Runs, s.config.Canary.MaxDeviation, time.Now())
+	saveCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), redisTimeout)
+	defer cancel()
+	if err := s.canaries.Save(saveCtx, trial); err != nil {
+		s.logger.Warn("Failed to save canary trial",
+			logger.Error(err),
+		)
+	}
+	s.trial.set(trial)
+	if !decided {
+		s.logger.Debug("Canary trial run counted",
+			logger.Int("runs", trial.Runs),
+			logger.Int("baseline", trial.Baseline),
+			logger.Int("candidate", trial.Candidate),
+			logger.Float64("deviation", trial.Deviation),
+		)
+		return
+	}
+
+	fields := []logger.Field{
+		logger.String("fingerprint", trial.Fingerprint),
+		logger.Strings("cities", s.config.Canary.Cities),
+		logger.Int("runs", trial.Runs),
+		logger.Int("baseline", trial.Baseline),
+		logger.Int("candidate", trial.Candidate),
+		logger.Float64("deviation", trial.Deviation),
+		logger.Float64("max_deviation", s.config.Canary.MaxDeviation),
+	}
+	if trial.Verdict == canary.VerdictFailed {
+		s.logger.Error("Canary trial failed - candidate configuration reverted", fields...)
+	} else {
+		s.logger.Info("Canary trial passed", append(fields, logger.Bool("promoted", s.config.Canary.Promote))...)
+	}
+	s.canaryVerdictHooks(ctx, trial)
+}
+
+// canaryVerdictHooks runs the canary_verdict hooks with the decided trial.
+func (s *Service) canaryVerdictHooks(ctx context.Context, trial canary.Trial) {
+	if s.hooks == nil || !s.hooks.Has(config.HookCanaryVerdict) {
+		return
+	}
+
+	payload := hookPayload{Event: config.HookCanaryVerdict, Canary: &trial}
+	if err := s.hooks.Run(ctx, config.HookCanaryVerdict, payload); err != nil {
+		logger.FromContext(ctx).Warn("Hook failed",
+			logger.String("event", config.HookCanaryVerdict),
+			logger.Error(err),
+		)
+	}
+}
+
+// canaryStatus returns the trial for Status, or nil unless a trial is configured.
+func (s *Service) canaryStatus() *canary.Trial {
+	if s.canaries == nil {
+		return nil
+	}
+	trial := s.trial.get()
+	return &trial
+}
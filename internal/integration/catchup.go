@@ -0,0 +1,92 @@
+package integration
+
+import (
+	"context"
+	"time"
+
+	"github.com/gopost/integration/internal/logger"
+)
+
+// catchUp widens the first search window after a restart when the service was down longer
+// than lookback_hours, so articles published during the downtime are still found. It does
+// nothing without a saved last check time or when lookback_hours disables the date filter.
+func (s *Service) catchUp(ctx context.Context) {
+	if s.checkpoints == nil || s.config.Service.LookbackHours <= 0 {
+		return
+	}
+
+	loadCtx, cancel := context.WithTimeout(ctx, redisTimeout)
+	defer cancel()
+	lastCheck, err := s.checkpoints.Load(loadCtx)
+	if err != nil {
+		s.logger.Warn("Failed to load last check time, using lookback_hours",
+			logger.Error(err),
+		)
+		return
+	}
+	if lastCheck.IsZero() {
+		return
+	}
+
+	lookback := time.Duration(s.config.Service.LookbackHours) * time.Hour
+	limit := time.Duration(s.config.Service.CatchupHours) * time.Hour
+	downtime := time.Since(lastCheck)
+	window, covered := catchUpWindow(lookback, downtime, limit)
+	if window > lookback {
+		s.mu.Lock()
+		s.lastCheckTS = time.Now().Add(-window)
+		s.catchingUp = true
+		s.mu.Unlock()
+	}
+
+	if !covered {
+		s.logger.Warn("Downtime exceeds catchup_hours; articles published in the gap are not searched, replay it to recover them",
+			logger.Time("last_check", lastCheck),
+			logger.Duration("downtime", downtime),
+			logger.Duration("lookback_window", window),
+		)
+		return
+	}
+	if window > lookback {
+		s.logger.Info("Expanded lookback to recover articles published during downtime",
+			logger.Time("last_check", lastCheck),
+			logger.Duration("downtime", downtime),
+			logger.Duration("lookback_window", window),
+		)
+	}
+}
+
+// catchUpWindow doubles lookback until it covers downtime, stopping at limit. It reports
+// whether the returned window covers the downtime.
+func catchUpWindow(lookback, downtime, limit time.Duration) (time.Duration, bool) {
+	window := lookback
+	for window < downtime && window < limit {
+		window *= 2
+	}
+	if window > limit && limit >= lookback {
+		window = limit
+	}
+	return window, window >= downtime
+}
+
+// saveCheckpoint persists the last check time for catch-up after the next restart.
+// Failures are logged only.
+func (s *Service) saveCheckpoint(ctx context.Context) {
+	if s.checkpoints == nil {
+		return
+	}
+
+	saveCtx, cancel := context.WithTimeout(ctx, redisTimeout)
+	defer cancel()
+	err := s.checkpoints.Save(saveCtx, s.getLastCheckTS())
+	if err != nil {
+		s.logger.Warn("Failed to save last check time",
+			logger.Error(err),
+		)
+	}
+
+	// The save doubles as the health score's Redis check
+	s.mu.Lock()
+	s.redisFailed = err != nil
+	s.mu.Unlock()
+}
@@ -0,0 +1,106 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/gopost/integration/internal/config"
+	"github.com/gopost/integration/internal/logger"
+	"github.com/gopost/integration/pkg/drupal"
+	"github.com/gopost/integration/pkg/pipeline"
+)
+
+// category is a categories mapping with the classifier matching its keywords.
+type category struct {
+	config.CategoryConfig
+	matcher *pipeline.KeywordClassifier
+}
+
+func newCategories(mappings []config.CategoryConfig, mode pipeline.MatchMode) ([]category, error) {
+	categories := make([]category, 0, len(mappings))
+	for i, mapping := range mappings {
+		matcher, err := pipeline.NewKeywordClassifier(mapping.Keywords, mode)
+		if err != nil {
+			return nil, fmt.Errorf("categories.mappings[%d].keywords: %w", i, err)
+		}
+		categories = append(categories, category{
+			CategoryConfig: mapping,
+			matcher:        matcher,
+		})
+	}
+	return categories, nil
+}
+
+// usesTermNames reports whether any category mapping sets a term name.
+func usesTermNames(cfg *config.Config) bool {
+	for _, mapping := range cfg.Categories.Mappings {
+		if mapping.Term != "" && mapping.TermID == "" {
+			return true
+		}
+	}
+	return false
+}
+
+// refreshTerms re-resolves category term names when categories.refresh has passed.
+// Failures are logged; articles keep being tagged with the UUIDs resolved earlier.
+func (s *Service) refreshTerms(ctx context.Context) {
+	if s.terms == nil || !s.terms.due(s.config.Categories.Refresh) {
+		return
+	}
+
+	refreshCtx, cancel := context.WithTimeout(ctx, drupalPostTimeout)
+	defer cancel()
+	if err := s.terms.refresh(refreshCtx); err != nil {
+		s.logger.Warn("Failed to resolve category terms",
+			logger.String("term_type", s.config.Categories.TermType),
+			logger.Error(err),
+		)
+		return
+	}
+
+	for _, mapping := range s.config.Categories.Mappings {
+		if mapping.Term == "" || mapping.TermID != "" {
+			continue
+		}
+		if _, err := s.terms.lookup(mapping.Term); err != nil {
+			s.logger.Error("Category term does not resolve, articles will not be tagged with it",
+				logger.String("term", mapping.Term),
+				logger.Error(err),
+			)
+		}
+	}
+}
+
+// applyCategories tags req with the terms of every category whose keywords the article
+// mentions. Terms that do not resolve are left out; the article is still posted.
+func (s *Service) applyCategories(req *drupal.ArticleRequest, article *pipeline.Article) {
+	var terms []string
+	for _, cat := range s.categories {
+		if !cat.matcher.Matches(*article) {
+			continue
+		}
+		termID := cat.TermID
+		if termID == "" {
+			id, err := s.terms.lookup(cat.Term)
+			if err != nil {
+				s.logger.Warn("Category term not resolved, article posted without it",
+					logger.String("article_id", article.ID),
+					logger.String("term", cat.Term),
+					logger.Error(err),
+				)
+				continue
+			}
+			termID = id
+		}
+		if !slices.Contains(terms, termID) {
+			terms = append(terms, termID)
+		}
+	}
+	if len(terms) == 0 {
+		return
+	}
+
+	req.CrimeTerms = terms
+	req.CrimeTermType = s.config.Categories.TermType
+}
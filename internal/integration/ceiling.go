@@ -0,0 +1,76 @@
+package integration
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gopost/integration/internal/config"
+	"github.com/gopost/integration/internal/logger"
+	"github.com/gopost/integration/pkg/pipeline"
+)
+
+// defaultSearchSize is the number of articles fetched per search when service.search_size
+// is not set.
+const defaultSearchSize = 100
+
+// searchSize returns the number of articles fetched per search.
+func (s *Service) searchSize() int {
+	if s.config.Service.SearchSize > 0 {
+		return s.config.Service.SearchSize
+	}
+	return defaultSearchSize
+}
+
+// defaultMaxBodyBytes is the longest article body kept when service.max_body_bytes is not set.
+const defaultMaxBodyBytes = 1 << 20
+
+// capBody cuts the article's body to service.max_body_bytes. The cut falls on a character
+// boundary and before any tag it would split; Drupal's text formats close the tags left open.
+func (s *Service) capBody(cityCfg config.CityConfig, article *pipeline.Article) {
+	limit := s.config.Service.MaxBodyBytes
+	if limit <= 0 {
+		limit = defaultMaxBodyBytes
+	}
+	if len(article.Content) <= limit {
+		return
+	}
+	s.logger.Warn("Article body cut to service.max_body_bytes",
+		logger.String("city", cityCfg.Name),
+		logger.String("article_id", article.ID),
+		logger.Int("body_bytes", len(article.Content)),
+		logger.Int("max_body_bytes", limit),
+	)
+	article.Content = cutBody(article.Content, limit)
+}
+
+// cutBody returns the longest prefix of body within limit bytes that neither splits a
+// character nor ends inside a tag.
+func cutBody(body string, limit int) string {
+	cut := limit
+	for cut > 0 && !utf8.RuneStart(body[cut]) {
+		cut--
+	}
+	if open := strings.LastIndexByte(body[:cut], '<'); open > strings.LastIndexByte(body[:cut], '>') {
+		cut = open
+	}
+	// Keep a copy, so the search response holding the full body can be freed
+	return strings.Clone(body[:cut])
+}
+
+// capArticles cuts articles down to what remains of the city's per-run ceiling after
+// processed articles, returning the kept articles and how many were cut. Cuts are logged,
+// since the articles cut are not posted by this run.
+func (s *Service) capArticles(cityCfg config.CityConfig, articles []pipeline.Article, processed int) ([]pipeline.Article, int) {
+	ceiling := s.config.Service.MaxArticlesPerCityPerRun
+	if ceiling <= 0 || processed+len(articles) <= ceiling {
+		return articles, 0
+	}
+	kept := max(ceiling-processed, 0)
+	truncated := len(articles) - kept
+	s.logger.Warn("Article ceiling reached; remaining articles are not processed this run",
+		logger.String("city", cityCfg.Name),
+		logger.Int("max_articles_per_city_per_run", ceiling),
+		logger.Int("truncated", truncated),
+	)
+	return articles[:kept], truncated
+}
@@ -0,0 +1,97 @@
+package integration
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/gopost/integration/internal/logger"
+	"github.com/gopost/integration/pkg/pipeline"
+)
+
+// closedIndices tracks indices found closed or frozen, whose cities are skipped until a
+// retry rather than failing every run with the same search error.
+type closedIndices struct {
+	mu      sync.Mutex
+	indices map[string]*closedIndex
+}
+
+// closedIndex is an index found closed or frozen.
+type closedIndex struct {
+	since   time.Time // When a search first found the index unavailable
+	retryAt time.Time // When its cities search it again
+	backoff time.Duration
+	errType string // Elasticsearch error type of the last failed search
+}
+
+// indexClosed returns the error type of the last search of index when its cities should
+// wait for it to reopen. Once the backoff has passed it returns "", so the next search
+// tries the index again.
+func (s *Service) indexClosed(index string) string {
+	s.closed.mu.Lock()
+	defer s.closed.mu.Unlock()
+	state, ok := s.closed.indices[index]
+	if !ok || !time.Now().Before(state.retryAt) {
+		return ""
+	}
+	return state.errType
+}
+
+// closeIndex records a search that failed because index is closed or frozen, and returns
+// the error type of the failure; it returns "" for other errors. Cities searching the
+// index are skipped for service.closed_index.backoff, doubled for each retry that still
+// fails, up to max_backoff.
+func (s *Service) closeIndex(index string, err error) string {
+	if !errors.Is(err, pipeline.ErrIndexUnavailable) {
+		return ""
+	}
+	errType := "index_unavailable"
+	var responseErr *pipeline.ResponseError
+	if errors.As(err, &responseErr) && responseErr.Type != "" {
+		errType = responseErr.Type
+	}
+
+	s.closed.mu.Lock()
+	defer s.closed.mu.Unlock()
+	if s.closed.indices == nil {
+		s.closed.indices = make(map[string]*closedIndex)
+	}
+	now := time.Now()
+	state, ok := s.closed.indices[index]
+	switch {
+	case !ok:
+		state = &closedIndex{since: now, backoff: s.config.Service.ClosedIndex.Backoff}
+		s.closed.indices[index] = state
+	case now.Before(state.retryAt):
+		return state.errType
+	default:
+		state.backoff = min(2*state.backoff, s.config.Service.ClosedIndex.MaxBackoff)
+	}
+	state.retryAt = now.Add(state.backoff)
+	state.errType = errType
+
+	s.logger.Warn("Index closed or frozen - skipping its cities until it reopens",
+		logger.String("index_name", index),
+		logger.String("error_type", errType),
+		logger.Time("closed_since", state.since),
+		logger.Duration("retry_in", state.backoff),
+		logger.Error(err),
+	)
+	return errType
+}
+
+// openIndex records a successful search of index, resuming its cities if it was closed.
+func (s *Service) openIndex(index string) {
+	s.closed.mu.Lock()
+	defer s.closed.mu.Unlock()
+	state, ok := s.closed.indices[index]
+	if !ok {
+		return
+	}
+
+	s.logger.Info("Index reopened - searching its cities again",
+		logger.String("index_name", index),
+		logger.Duration("closed_duration", time.Since(state.since)),
+	)
+	delete(s.closed.indices, index)
+}
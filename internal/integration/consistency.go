@@ -0,0 +1,195 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gopost/integration/internal/config"
+	"github.com/gopost/integration/internal/consistency"
+	"github.com/gopost/integration/internal/logger"
+	"github.com/gopost/integration/pkg/drupal"
+	"github.com/gopost/integration/pkg/pipeline"
+)
+
+// NodeChecker reports whether a posted node still exists in Drupal. *drupal.Client
+// implements it.
+type NodeChecker interface {
+	NodeExists(ctx context.Context, contentType, id string) (bool, error)
+}
+
+// dedupClearer is implemented by trackers that can forget an article, such as
+// *dedup.Tracker.
+type dedupClearer interface {
+	Clear(ctx context.Context, articleID string) error
+}
+
+// ConsistencyReport summarizes one consistency check.
+type ConsistencyReport struct {
+	Checked int // Posts whose node was looked up
+	Missing int // Posts whose node was deleted in Drupal
+}
+
+// recordPost remembers the node created for article when the consistency check is
+// enabled. Failures are logged only; the post is simply never checked.
+func (s *Service) recordPost(ctx context.Context, cityCfg config.CityConfig, article *pipeline.Article, contentType, nodeID string) {
+	if s.posts == nil || nodeID == "" {
+		return
+	}
+
+	recordCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), redisTimeout)
+	defer cancel()
+	post := consistency.Post{
+		ArticleID:   article.ID,
+		NodeID:      nodeID,
+		ContentType: contentType,
+		City:        cityCfg.Name,
+		TitleKey:    s.titleDedupKey(article),
+		PostedAt:    time.Now(),
+	}
+	if err := s.posts.Record(recordCtx, post); err != nil {
+		s.logger.Warn("Failed to record posted node for consistency checks",
+			logger.String("article_id", article.ID),
+			logger.String("city", cityCfg.Name),
+			logger.String("drupal_id", nodeID),
+			logger.Error(err),
+		)
+	}
+}
+
+// runConsistencyChecks checks a sample of recent posts every consistency.interval until
+// ctx is cancelled.
+func (s *Service) runConsistencyChecks(ctx context.Context) {
+	ticker := time.NewTicker(s.config.Consistency.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := s.CheckConsistency(ctx); err != nil {
+				s.logger.Warn("Consistency check failed",
+					logger.Error(err),
+				)
+			}
+		}
+	}
+}
+
+// CheckConsistency looks up the Drupal nodes of up to consistency.sample_size articles
+// posted within consistency.window. Articles whose node was deleted are handled per
+// consistency.policy: "record" keeps them marked as posted and records the deletion,
+// "clear" clears their dedup markers so a later run can post them again.
+func (s *Service) CheckConsistency(ctx context.Context) (ConsistencyReport, error) {
+	var report ConsistencyReport
+	if s.posts == nil || s.nodes == nil {
+		return report, nil
+	}
+
+	sampleCtx, cancel := context.WithTimeout(ctx, redisTimeout)
+	posts, err := s.posts.Sample(sampleCtx, s.config.Consistency.SampleSize)
+	cancel()
+	if err != nil {
+		return report, err
+	}
+
+	for _, post := range posts {
+		checkCtx, checkCancel := context.WithTimeout(ctx, drupalPostTimeout)
+		exists, err := s.nodes.NodeExists(checkCtx, post.ContentType, post.NodeID)
+		checkCancel()
+		if errors.Is(err, drupal.ErrMaintenance) || errors.Is(err, drupal.ErrUnauthorized) {
+			// Every other lookup would fail the same way
+			return report, fmt.Errorf("look up node %s: %w", post.NodeID, err)
+		}
+		if err != nil {
+			s.logger.Warn("Failed to look up posted node",
+				logger.String("article_id", post.ArticleID),
+				logger.String("city", post.City),
+				logger.String("drupal_id", post.NodeID),
+				logger.Error(err),
+			)
+			continue
+		}
+		report.Checked++
+		if exists {
+			continue
+		}
+
+		report.Missing++
+		s.logger.Warn("Posted article missing from Drupal",
+			logger.String("article_id", post.ArticleID),
+			logger.String("city", post.City),
+			logger.String("drupal_id", post.NodeID),
+			logger.Time("posted_at", post.PostedAt),
+			logger.String("policy", s.config.Consistency.Policy),
+		)
+		s.handleMissingNode(ctx, post)
+	}
+
+	s.logger.Info("Consistency check completed",
+		logger.Int("checked", report.Checked),
+		logger.Int("missing", report.Missing),
+	)
+	return report, nil
+}
+
+// handleMissingNode applies consistency.policy to a post whose node was deleted. Failures
+// are logged only; the post stays in the sample and is handled again by a later check.
+func (s *Service) handleMissingNode(ctx context.Context, post consistency.Post) {
+	redisCtx, cancel := context.WithTimeout(ctx, redisTimeout)
+	defer cancel()
+
+	// Trackers that cannot forget an article fall back to recording the deletion
+	clearer, canClear := s.dedup.(dedupClearer)
+	if s.config.Consistency.Policy != config.ConsistencyPolicyClear || !canClear {
+		if err := s.posts.RecordDeletion(redisCtx, post, time.Now()); err != nil {
+			s.logger.Warn("Failed to record deleted node",
+				logger.String("article_id", post.ArticleID),
+				logger.String("drupal_id", post.NodeID),
+				logger.Error(err),
+			)
+		}
+		// Suppressed for good, so the article is not reposted once its posted marker expires
+		if s.suppressed != nil {
+			if err := s.suppressed.Add(redisCtx, post.ArticleID); err != nil {
+				s.logger.Warn("Failed to suppress article of deleted node",
+					logger.String("article_id", post.ArticleID),
+					logger.String("drupal_id", post.NodeID),
+					logger.Error(err),
+				)
+			}
+		}
+		return
+	}
+
+	for _, key := range []string{post.ArticleID, post.TitleKey} {
+		if key == "" {
+			continue
+		}
+		if err := clearer.Clear(redisCtx, key); err != nil {
+			s.logger.Warn("Failed to clear dedup marker of deleted node",
+				logger.String("article_id", post.ArticleID),
+				logger.String("drupal_id", post.NodeID),
+				logger.String("dedup_key", key),
+				logger.Error(err),
+			)
+			return
+		}
+	}
+	if s.nearDups != nil {
+		if err := s.nearDups.Remove(redisCtx, post.ArticleID); err != nil {
+			s.logger.Warn("Failed to remove fingerprint of deleted node",
+				logger.String("article_id", post.ArticleID),
+				logger.Error(err),
+			)
+		}
+	}
+	if err := s.posts.Remove(redisCtx, post); err != nil {
+		s.logger.Warn("Failed to remove checked post",
+			logger.String("article_id", post.ArticleID),
+			logger.Error(err),
+		)
+	}
+}
@@ -0,0 +1,73 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/gopost/integration/internal/dedup"
+	"github.com/gopost/integration/internal/logger"
+)
+
+// errNoDedupLookup is returned by DedupLookup when the tracker cannot report article state.
+var errNoDedupLookup = errors.New("dedup tracker does not support lookups")
+
+// dedupInspector is implemented by trackers that can report an article's dedup state,
+// such as *dedup.Tracker.
+type dedupInspector interface {
+	Lookup(ctx context.Context, articleID string) (dedup.Entry, error)
+}
+
+// TriggerSync requests a run now instead of at the next check_interval tick. A run in
+// progress finishes first. When runToken is set, retrying the run with the same token
+// within service.run_token_ttl skips the articles the earlier attempts processed. It
+// returns false when a triggered run is already pending.
+func (s *Service) TriggerSync(runToken string) bool {
+	select {
+	case s.trigger <- runToken:
+		s.logger.Info("Sync triggered",
+			logger.String("run_token", runToken),
+		)
+		return true
+	default:
+		return false
+	}
+}
+
+// DedupLookup returns the dedup state of an article.
+func (s *Service) DedupLookup(ctx context.Context, articleID string) (dedup.Entry, error) {
+	inspector, ok := s.dedup.(dedupInspector)
+	if !ok {
+		return dedup.Entry{}, errNoDedupLookup
+	}
+
+	lookupCtx, cancel := context.WithTimeout(ctx, redisTimeout)
+	defer cancel()
+	return inspector.Lookup(lookupCtx, articleID)
+}
+
+// TriggerSync requests a run from every tenant. It returns false when every tenant
+// already had a triggered run pending.
+func (g *Group) TriggerSync(runToken string) bool {
+	triggered := false
+	for _, name := range g.names {
+		if g.services[name].TriggerSync(runToken) {
+			triggered = true
+		}
+	}
+	return triggered
+}
+
+// DedupLookup returns the article's dedup state from the first tenant that knows it.
+func (g *Group) DedupLookup(ctx context.Context, articleID string) (dedup.Entry, error) {
+	for _, name := range g.names {
+		entry, err := g.services[name].DedupLookup(ctx, articleID)
+		if err != nil {
+			return dedup.Entry{}, fmt.Errorf("tenant %s: %w", name, err)
+		}
+		if entry.State != dedup.StateNone {
+			return entry, nil
+		}
+	}
+	return dedup.Entry{State: dedup.StateNone}, nil
+}
@@ -0,0 +1,61 @@
+package integration
+
+import (
+	"time"
+
+	"github.com/gopost/integration/internal/logger"
+)
+
+// CredentialStatus describes Drupal rejecting the credentials, as last seen by a post.
+type CredentialStatus struct {
+	Since   time.Time `json:"since"`
+	RetryAt time.Time `json:"retry_at"` // When posting is next attempted
+	Error   string    `json:"error"`
+}
+
+// credentialsRejected reports whether posting should wait for the credentials to be
+// fixed (the circuit is open). Once the backoff has passed it returns false, so the next
+// post tries them again.
+func (s *Service) credentialsRejected() bool {
+	return s.credentials.open()
+}
+
+// rejectCredentials records that Drupal rejected a post's credentials and stops posting for
+// service.auth_failure.backoff, doubled for each retry that is still rejected, up to
+// max_backoff. Every rejection that opens the circuit is logged as an error to alert on;
+// posts failing while it is already open do not extend the wait.
+func (s *Service) rejectCredentials(err error) {
+	since, wait, tripped := s.credentials.trip(s.config.Service.AuthFailure.Backoff, s.config.Service.AuthFailure.MaxBackoff, err)
+	if !tripped {
+		return
+	}
+
+	s.logger.Error("Drupal rejected the credentials - posting stopped",
+		logger.Time("rejected_since", since),
+		logger.Duration("retry_in", wait),
+		logger.Error(err),
+	)
+}
+
+// acceptCredentials records a successful post, resuming posting if the credentials were
+// rejected.
+func (s *Service) acceptCredentials() {
+	since := s.credentials.reset()
+	if since.IsZero() {
+		return
+	}
+
+	s.logger.Info("Drupal accepted the credentials - posting resumed",
+		logger.Duration("rejected_duration", time.Since(since)),
+	)
+}
+
+// credentialStatus returns the credential rejection for Status, or nil while the
+// credentials work.
+func (s *Service) credentialStatus() *CredentialStatus {
+	since, retryAt, err := s.credentials.state()
+	if since.IsZero() {
+		return nil
+	}
+	return &CredentialStatus{Since: since, RetryAt: retryAt, Error: err}
+}
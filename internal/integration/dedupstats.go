@@ -0,0 +1,90 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gopost/integration/internal/config"
+	"github.com/gopost/integration/internal/dedup"
+	"github.com/gopost/integration/internal/logger"
+)
+
+// dedupSizeSample is how many markers DedupStatistics sizes with MEMORY USAGE.
+const dedupSizeSample = 100
+
+// DedupStats describes one pipeline's dedup set.
+type DedupStats struct {
+	Tenant       string                   // Empty outside multi-tenant mode
+	Count        int64                    // Markers, posted and pending, article and title
+	AverageBytes int64                    // Average MEMORY USAGE of a sample of markers
+	Oldest       []dedup.Marker           // Posted markers closest to expiry, soonest first
+	TTLs         map[string]time.Duration // Remaining TTL of each article asked about; 0 never expires
+	Untracked    []string                 // Articles asked about that have no marker
+}
+
+// EstimatedBytes estimates the Redis memory taken by the dedup set.
+func (s DedupStats) EstimatedBytes() int64 {
+	return s.Count * s.AverageBytes
+}
+
+// DedupStatistics counts the dedup markers of each pipeline, sizes them, and lists the
+// oldest posted markers, up to oldest, for confirming that the set grows and expires as
+// expected. The remaining TTL of each of articleIDs is looked up too. It builds its own
+// Redis client from cfg and only reads.
+func DedupStatistics(ctx context.Context, cfg *config.Config, oldest int, articleIDs []string) ([]DedupStats, error) {
+	if len(cfg.Tenants) == 0 {
+		stats, err := dedupStatistics(ctx, cfg, oldest, articleIDs)
+		if err != nil {
+			return nil, err
+		}
+		return []DedupStats{stats}, nil
+	}
+
+	all := make([]DedupStats, 0, len(cfg.Tenants))
+	for _, tenant := range cfg.Tenants {
+		stats, err := dedupStatistics(ctx, cfg.ForTenant(tenant), oldest, articleIDs)
+		if err != nil {
+			return all, fmt.Errorf("tenant %s: %w", tenant.Name, err)
+		}
+		stats.Tenant = tenant.Name
+		all = append(all, stats)
+	}
+	return all, nil
+}
+
+func dedupStatistics(ctx context.Context, cfg *config.Config, oldest int, articleIDs []string) (DedupStats, error) {
+	redisClient, err := newRedisClientFromConfig(cfg)
+	if err != nil {
+		return DedupStats{}, err
+	}
+	defer func() { _ = redisClient.Close() }()
+	tracker := dedup.NewTracker(redisClient, keyPrefix(cfg), cfg.Service.DedupTTL, cfg.Service.DedupLease, logger.NewNopLogger())
+
+	var stats DedupStats
+	if stats.Count, err = tracker.Count(ctx); err != nil {
+		return stats, fmt.Errorf("count markers: %w", err)
+	}
+	if stats.AverageBytes, err = tracker.AverageSize(ctx, dedupSizeSample); err != nil {
+		return stats, fmt.Errorf("size markers: %w", err)
+	}
+	if stats.Oldest, err = tracker.OldestEntries(ctx, oldest); err != nil {
+		return stats, fmt.Errorf("list oldest markers: %w", err)
+	}
+	for _, articleID := range articleIDs {
+		ttl, err := tracker.TTLOf(ctx, articleID)
+		if errors.Is(err, dedup.ErrNotTracked) {
+			stats.Untracked = append(stats.Untracked, articleID)
+			continue
+		}
+		if err != nil {
+			return stats, err
+		}
+		if stats.TTLs == nil {
+			stats.TTLs = make(map[string]time.Duration, len(articleIDs))
+		}
+		stats.TTLs[articleID] = ttl
+	}
+	return stats, nil
+}
@@ -0,0 +1,245 @@
+package integration
+
+import (
+	"github.com/gopost/integration/internal/canary"
+	"github.com/gopost/integration/internal/checkpoint"
+	"github.com/gopost/integration/internal/consistency"
+	"github.com/gopost/integration/internal/dedup"
+	"github.com/gopost/integration/internal/history"
+	"github.com/gopost/integration/internal/linkcheck"
+	"github.com/gopost/integration/internal/outbox"
+	"github.com/gopost/integration/internal/pause"
+	"github.com/gopost/integration/internal/quota"
+	"github.com/gopost/integration/internal/runtoken"
+	"github.com/gopost/integration/internal/timeline"
+	"github.com/gopost/integration/internal/translate"
+	"github.com/gopost/integration/internal/volume"
+	"github.com/gopost/integration/internal/warmup"
+	"github.com/gopost/integration/pkg/pipeline"
+)
+
+// Option overrides a Service dependency. Dependencies that are not overridden are
+// built from config by NewService.
+type Option func(*Service)
+
+// WithSource sets the article search backend instead of an Elasticsearch client built from config.
+func WithSource(source pipeline.Source) Option {
+	return func(s *Service) {
+		s.source = source
+	}
+}
+
+// WithClassifier sets the article classifier instead of keyword matching on service.crime_keywords.
+func WithClassifier(classifier pipeline.Classifier) Option {
+	return func(s *Service) {
+		s.classifier = classifier
+	}
+}
+
+// WithPoster sets the article poster instead of a Drupal client built from config.
+func WithPoster(poster pipeline.Poster) Option {
+	return func(s *Service) {
+		s.poster = poster
+	}
+}
+
+// WithTracker sets the dedup tracker instead of a Redis tracker built from config.
+func WithTracker(tracker pipeline.Tracker) Option {
+	return func(s *Service) {
+		s.dedup = tracker
+	}
+}
+
+// WithLimiter sets the posting rate limiter instead of one built from service.rate_limit_rps.
+func WithLimiter(limiter pipeline.Limiter) Option {
+	return func(s *Service) {
+		s.limiter = limiter
+	}
+}
+
+// WithFieldTransformer registers fn under name for service.field_map sources such as
+// "fn:name", replacing any transformer already registered under that name.
+func WithFieldTransformer(name string, fn pipeline.FieldTransformer) Option {
+	return func(s *Service) {
+		s.fields[name] = fn
+	}
+}
+
+// WithNearDuplicateIndex enables near-duplicate suppression with the given index
+// instead of one built when service.near_duplicates.enabled is set.
+func WithNearDuplicateIndex(index *dedup.NearDuplicateIndex) Option {
+	return func(s *Service) {
+		s.nearDups = index
+	}
+}
+
+// WithLinkChecker enables the link health check with the given checker instead of one
+// built when service.link_check.enabled is set.
+func WithLinkChecker(checker *linkcheck.Checker) Option {
+	return func(s *Service) {
+		s.links = checker
+	}
+}
+
+// WithTranslator translates the articles of cities with translate_to using translator
+// instead of the provider configured under translation.
+func WithTranslator(translator translate.Translator) Option {
+	return func(s *Service) {
+		s.translator = translator
+	}
+}
+
+// WithPauseSwitch sets the Redis pause flags checked before each run and city instead of
+// the switch built alongside the Redis dedup tracker.
+func WithPauseSwitch(pauses *pause.Switch) Option {
+	return func(s *Service) {
+		s.pauses = pauses
+	}
+}
+
+// WithCheckpointStore sets where the last check time is persisted for downtime catch-up
+// instead of the store built alongside the Redis dedup tracker.
+func WithCheckpointStore(checkpoints *checkpoint.Store) Option {
+	return func(s *Service) {
+		s.checkpoints = checkpoints
+	}
+}
+
+// WithRunTokenStore sets where the articles processed by runs triggered with a run token
+// are recorded instead of the store built alongside the Redis dedup tracker.
+func WithRunTokenStore(store *runtoken.Store) Option {
+	return func(s *Service) {
+		s.runTokens = store
+	}
+}
+
+// WithHistory saves a summary of every run to store, whether or not history.enabled is set.
+func WithHistory(store *history.Store) Option {
+	return func(s *Service) {
+		s.history = store
+	}
+}
+
+// WithTimeline records article stage times in store, whether or not timeline.enabled
+// is set.
+func WithTimeline(store *timeline.Store) Option {
+	return func(s *Service) {
+		s.timeline = store
+	}
+}
+
+// WithConsistencyStore records posted nodes in store for consistency checks, whether or
+// not consistency.enabled is set. Checks also need WithNodeChecker or a Drupal client.
+func WithConsistencyStore(store *consistency.Store) Option {
+	return func(s *Service) {
+		s.posts = store
+	}
+}
+
+// WithNodeChecker sets where consistency checks look up posted nodes instead of the Drupal
+// client built from config; it is needed when the poster is replaced with WithPoster.
+func WithNodeChecker(checker NodeChecker) Option {
+	return func(s *Service) {
+		s.nodes = checker
+	}
+}
+
+// WithNodeRemover sets how Rollback deletes and unpublishes posted nodes instead of the
+// Drupal client built from config; it is needed when the poster is replaced with WithPoster.
+func WithNodeRemover(remover NodeRemover) Option {
+	return func(s *Service) {
+		s.remover = remover
+	}
+}
+
+// WithSuppressionList skips the articles on list, whether or not suppression.enabled is set.
+func WithSuppressionList(list *dedup.SuppressionList) Option {
+	return func(s *Service) {
+		s.suppressed = list
+	}
+}
+
+// WithWarmupStore limits posting for new cities using the run counts in store, whether
+// or not service.warmup.enabled is set. The limits are read from service.warmup.
+func WithWarmupStore(store *warmup.Store) Option {
+	return func(s *Service) {
+		s.warmups = store
+	}
+}
+
+// WithCanaryStore keeps the canary trial in store instead of the store built from config.
+// The trial is configured by canary.
+func WithCanaryStore(store *canary.Store) Option {
+	return func(s *Service) {
+		s.canaries = store
+	}
+}
+
+// WithVolumeStore checks each city's run against the rolling averages in store, whether
+// or not service.volume_anomaly.enabled is set. The thresholds are read from
+// service.volume_anomaly.
+func WithVolumeStore(store *volume.Store) Option {
+	return func(s *Service) {
+		s.volumes = store
+	}
+}
+
+// WithQuotaStore enforces service.group_quota using the counters in store instead of the
+// store built from config.
+func WithQuotaStore(store *quota.Store) Option {
+	return func(s *Service) {
+		s.quotas = store
+	}
+}
+
+// WithQueue enables outbox mode with the given work queue instead of the Redis list
+// queue built when outbox.enabled is set.
+func WithQueue(queue outbox.Queue) Option {
+	return func(s *Service) {
+		s.queue = queue
+	}
+}
+
+// WithGroupLister sets where city group_name values are resolved instead of the Drupal
+// client built from config; it is needed when the poster is replaced with WithPoster.
+func WithGroupLister(lister ResourceLister) Option {
+	return func(s *Service) {
+		if lister != nil {
+			s.groups = newResourceDirectory(lister, s.config.Service.GroupType, "label")
+		}
+	}
+}
+
+// WithTermLister sets where category term names are resolved instead of the Drupal client
+// built from config; it is needed when the poster is replaced with WithPoster.
+func WithTermLister(lister ResourceLister) Option {
+	return func(s *Service) {
+		if lister != nil {
+			s.terms = newResourceDirectory(lister, s.config.Categories.TermType, "name")
+		}
+	}
+}
+
+// WithQueryStore sets where city queries are registered when percolator.register is set,
+// instead of the Elasticsearch source.
+func WithQueryStore(store pipeline.QueryStore) Option {
+	return func(s *Service) {
+		s.queries = store
+	}
+}
+
+// WithAuditIndexer sets where copies of posted articles are indexed when audit.enabled is
+// set, instead of the Elasticsearch source.
+func WithAuditIndexer(indexer pipeline.DocumentIndexer) Option {
+	return func(s *Service) {
+		s.audit = indexer
+	}
+}
+
+// WithIndexLister sets where cities are discovered when city_discovery.enabled is set,
+// instead of the Elasticsearch source.
+func WithIndexLister(lister pipeline.IndexLister) Option {
+	return func(s *Service) {
+		s.indices = lister
+	}
+}
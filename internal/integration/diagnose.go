@@ -0,0 +1,145 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gopost/integration/internal/config"
+	"github.com/gopost/integration/internal/es"
+	"github.com/gopost/integration/internal/logger"
+	"github.com/gopost/integration/pkg/pipeline"
+)
+
+// Causes of a city search finding nothing, from the most to the least broken.
+const (
+	DiagnosisIndexMissing  = "index_missing"    // No index matches the city's index name or pattern
+	DiagnosisIndexEmpty    = "index_empty"      // The index holds no documents
+	DiagnosisFieldsMissing = "fields_missing"   // No document has a field the search reads, e.g. after a mapping change
+	DiagnosisNoNewArticles = "no_new_articles"  // Nothing was published since the last check
+	DiagnosisNoMatches     = "no_matches"       // Recent articles exist, but none matched the search
+	DiagnosisFailed        = "diagnosis_failed" // The diagnostic queries failed
+)
+
+// diagnosedFields are the article fields the search and posting depend on.
+var diagnosedFields = []string{pipeline.ESFieldTitle, pipeline.ESFieldBody, pipeline.ESFieldPublishedDate}
+
+// SearchDiagnosis explains why a city's search found nothing.
+type SearchDiagnosis struct {
+	Cause         string    `json:"cause"` // One of the Diagnosis* constants
+	Index         string    `json:"index"`
+	Documents     int       `json:"documents"`                // Documents in the index, of any date
+	Newest        time.Time `json:"newest,omitzero"`          // Newest published_date in the index
+	MissingFields []string  `json:"missing_fields,omitempty"` // Fields no document in the index has
+	Error         string    `json:"error,omitempty"`          // Why the diagnosis failed
+}
+
+// broken reports whether the cause points at the index rather than a quiet news day.
+func (d *SearchDiagnosis) broken() bool {
+	switch d.Cause {
+	case DiagnosisIndexMissing, DiagnosisIndexEmpty, DiagnosisFieldsMissing:
+		return true
+	default:
+		return false
+	}
+}
+
+// diagnoseEmptySearch finds out why the city's search found nothing: whether its index
+// exists, how many documents it holds, how new the newest is, and whether the fields the
+// search reads are present. The diagnosis is logged, as a warning when the index looks
+// broken, and returned for the city report.
+func (s *Service) diagnoseEmptySearch(ctx context.Context, cityCfg config.CityConfig) *SearchDiagnosis {
+	index := cityIndex(cityCfg)
+	diagnosis := &SearchDiagnosis{Index: index}
+	if err := s.diagnoseIndex(ctx, cityCfg, diagnosis); err != nil {
+		diagnosis.Cause = DiagnosisFailed
+		diagnosis.Error = err.Error()
+	}
+
+	fields := []logger.Field{
+		logger.String("city", cityCfg.Name),
+		logger.String("index_name", index),
+		logger.String("cause", diagnosis.Cause),
+		logger.Int("documents", diagnosis.Documents),
+		logger.Time("newest", diagnosis.Newest),
+		logger.Strings("missing_fields", diagnosis.MissingFields),
+	}
+	switch {
+	case diagnosis.Error != "":
+		s.logger.Warn("City search found nothing - diagnosis failed", append(fields, logger.String("error", diagnosis.Error))...)
+	case diagnosis.broken():
+		s.logger.Warn("City search found nothing - index looks broken", fields...)
+	default:
+		s.logger.Info("City search found nothing", fields...)
+	}
+	return diagnosis
+}
+
+// diagnoseIndex fills in the diagnosis of the city's index, and logs the fields of its
+// newest document at debug level for comparing against the fields the search reads.
+func (s *Service) diagnoseIndex(ctx context.Context, cityCfg config.CityConfig, diagnosis *SearchDiagnosis) error {
+	newest, err := s.diagnosticSearch(ctx, diagnosis.Index, es.Search{
+		Query: es.MatchAll{},
+		Size:  1,
+		Sort:  []es.Sort{{Field: pipeline.ESFieldPublishedDate, Order: "desc", UnmappedType: "date"}},
+	}.Source())
+	if err != nil {
+		return fmt.Errorf("count documents: %w", err)
+	}
+	diagnosis.Documents = newest.Total
+	if len(newest.Hits) > 0 {
+		if article, err := pipeline.DecodeArticle(newest.Hits[0].Source, s.dates); err == nil {
+			diagnosis.Newest = article.PublishedAt
+		}
+		s.logger.Debug("Sample article fields",
+			logger.String("index_name", diagnosis.Index),
+			logger.String("city", cityCfg.Name),
+			logger.Any("sample_fields", newest.Hits[0].Source),
+		)
+	}
+
+	if diagnosis.Documents == 0 {
+		diagnosis.Cause = DiagnosisIndexEmpty
+		// Searching a pattern or alias matching no index finds nothing rather than failing
+		if lister, ok := s.source.(pipeline.IndexLister); ok {
+			listCtx, cancel := context.WithTimeout(ctx, esQueryTimeout)
+			indices, err := lister.ListIndices(listCtx, diagnosis.Index)
+			cancel()
+			if err == nil && len(indices) == 0 {
+				diagnosis.Cause = DiagnosisIndexMissing
+			}
+		}
+		return nil
+	}
+
+	for _, field := range diagnosedFields {
+		result, err := s.diagnosticSearch(ctx, diagnosis.Index, es.Search{Query: es.Exists{Field: field}}.Source())
+		if err != nil {
+			return fmt.Errorf("check field %s: %w", field, err)
+		}
+		if result.Total == 0 {
+			diagnosis.MissingFields = append(diagnosis.MissingFields, field)
+		}
+	}
+
+	var since time.Time
+	if s.config.Service.LookbackHours > 0 {
+		since = s.getLastCheckTS()
+	}
+	switch {
+	case len(diagnosis.MissingFields) > 0:
+		diagnosis.Cause = DiagnosisFieldsMissing
+	case !since.IsZero() && diagnosis.Newest.Before(since):
+		diagnosis.Cause = DiagnosisNoNewArticles
+	default:
+		diagnosis.Cause = DiagnosisNoMatches
+	}
+	return nil
+}
+
+// diagnosticSearch runs one query of the diagnosis.
+func (s *Service) diagnosticSearch(ctx context.Context, index string, query map[string]any) (*pipeline.SearchResult, error) {
+	searchCtx, cancel := context.WithTimeout(ctx, esQueryTimeout)
+	defer cancel()
+	return s.source.Search(searchCtx, index, query)
+}
@@ -0,0 +1,94 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gopost/integration/pkg/drupal"
+)
+
+// directoryPageSize is the number of resources fetched per JSON:API page (Drupal's maximum).
+const directoryPageSize = 50
+
+// ResourceLister lists Drupal resources so configured names, such as city group_name values
+// and category terms, can be resolved to UUIDs. *drupal.Client implements it.
+type ResourceLister interface {
+	ListResources(ctx context.Context, resourceType string, opts ...drupal.QueryOption) (*drupal.NodeList, error)
+	NextNodes(ctx context.Context, list *drupal.NodeList) (*drupal.NodeList, error)
+}
+
+// resourceDirectory caches the UUIDs of Drupal resources of one type by label, such as
+// groups by "label" or taxonomy terms by "name". Labels match case-insensitively.
+type resourceDirectory struct {
+	lister       ResourceLister
+	resourceType string
+	labelField   string
+
+	mu        sync.RWMutex
+	ids       map[string]string // Lower-cased label to UUID; ambiguous labels map to ""
+	refreshed time.Time
+}
+
+func newResourceDirectory(lister ResourceLister, resourceType, labelField string) *resourceDirectory {
+	return &resourceDirectory{lister: lister, resourceType: resourceType, labelField: labelField}
+}
+
+// due reports whether the cache is older than interval (or was never filled).
+func (d *resourceDirectory) due(interval time.Duration) bool {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return time.Since(d.refreshed) >= interval
+}
+
+// refresh reloads every label from Drupal. On failure the previous cache is kept.
+func (d *resourceDirectory) refresh(ctx context.Context) error {
+	list, err := d.lister.ListResources(ctx, d.resourceType,
+		drupal.WithFields(d.resourceType, d.labelField),
+		drupal.WithPage(0, directoryPageSize),
+	)
+	ids := make(map[string]string)
+	for err == nil {
+		for _, resource := range list.Data {
+			key := strings.ToLower(strings.TrimSpace(resource.StringAttribute(d.labelField)))
+			if _, seen := ids[key]; seen {
+				ids[key] = "" // Two resources share the label; refuse to guess
+				continue
+			}
+			ids[key] = resource.ID
+		}
+		if list.Next() == "" {
+			break
+		}
+		list, err = d.lister.NextNodes(ctx, list)
+	}
+	if err != nil {
+		return fmt.Errorf("list %s: %w", d.resourceType, err)
+	}
+
+	d.mu.Lock()
+	d.ids = ids
+	d.refreshed = time.Now()
+	d.mu.Unlock()
+	return nil
+}
+
+// lookup returns the UUID of the resource labeled name.
+func (d *resourceDirectory) lookup(name string) (string, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if d.ids == nil {
+		return "", fmt.Errorf("%s labels not loaded yet", d.resourceType)
+	}
+	id, ok := d.ids[strings.ToLower(strings.TrimSpace(name))]
+	switch {
+	case !ok:
+		return "", fmt.Errorf("no %s labeled %q", d.resourceType, name)
+	case id == "":
+		return "", fmt.Errorf("several %s resources are labeled %q", d.resourceType, name)
+	default:
+		return id, nil
+	}
+}
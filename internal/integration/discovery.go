@@ -0,0 +1,107 @@
+package integration
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gopost/integration/internal/config"
+	"github.com/gopost/integration/internal/logger"
+)
+
+// refreshCities returns the cities to process, first re-listing Elasticsearch indices
+// when city discovery is enabled and city_discovery.interval has passed, and re-resolving
+// group names and category terms when their refresh intervals have passed. A failed listing
+// is logged and the previously discovered cities are kept.
+func (s *Service) refreshCities(ctx context.Context) []config.CityConfig {
+	s.refreshGroups(ctx)
+	s.refreshTerms(ctx)
+	s.mu.RLock()
+	due := s.indices != nil && time.Since(s.cityRefresh) >= s.config.CityDiscovery.Interval
+	s.mu.RUnlock()
+	if due {
+		s.discoverCities(ctx)
+	}
+	return s.knownCities()
+}
+
+// knownCities returns the configured cities followed by the discovered ones.
+func (s *Service) knownCities() []config.CityConfig {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if len(s.discovered) == 0 {
+		return s.config.Cities
+	}
+	return slices.Concat(s.config.Cities, s.discovered)
+}
+
+// discoverCities lists the indices matching city_discovery.pattern and replaces the
+// discovered cities with those that are not configured explicitly and have a group mapping.
+func (s *Service) discoverCities(ctx context.Context) {
+	discoveryCfg := s.config.CityDiscovery
+	listCtx, cancel := context.WithTimeout(ctx, esQueryTimeout)
+	defer cancel()
+
+	indices, err := s.indices.ListIndices(listCtx, discoveryCfg.Pattern)
+	if err != nil {
+		s.logger.Warn("City discovery failed, keeping previously discovered cities",
+			logger.String("pattern", discoveryCfg.Pattern),
+			logger.Error(err),
+		)
+		return
+	}
+	slices.Sort(indices)
+
+	s.mu.RLock()
+	previous := s.discovered
+	s.mu.RUnlock()
+
+	var discovered []config.CityConfig
+	for _, index := range indices {
+		name, ok := cityNameFromIndex(discoveryCfg.Pattern, index)
+		if !ok || s.configuredCity(name, index) {
+			continue
+		}
+		isNew := !slices.ContainsFunc(previous, func(c config.CityConfig) bool { return c.Name == name })
+
+		groupID, mapped := discoveryCfg.Groups[name]
+		if !mapped {
+			// Posting without a group would publish the city's articles nowhere visible
+			s.logger.Warn("Discovered city has no group mapping, skipping",
+				logger.String("city", name),
+				logger.String("index", index),
+			)
+			continue
+		}
+		if isNew {
+			s.logger.Info("Discovered city",
+				logger.String("city", name),
+				logger.String("index", index),
+				logger.String("group_id", groupID),
+			)
+		}
+		discovered = append(discovered, config.CityConfig{Name: name, Index: index, GroupID: groupID})
+	}
+
+	s.mu.Lock()
+	s.discovered = discovered
+	s.cityRefresh = time.Now()
+	s.mu.Unlock()
+}
+
+// configuredCity reports whether a city with the name or index is listed under cities.
+func (s *Service) configuredCity(name, index string) bool {
+	return slices.ContainsFunc(s.config.Cities, func(c config.CityConfig) bool {
+		return c.Name == name || cityIndex(c) == index
+	})
+}
+
+// cityNameFromIndex extracts the part of index matched by the "*" in pattern.
+func cityNameFromIndex(pattern, index string) (string, bool) {
+	prefix, suffix, _ := strings.Cut(pattern, "*")
+	if len(index) <= len(prefix)+len(suffix) || !strings.HasPrefix(index, prefix) || !strings.HasSuffix(index, suffix) {
+		return "", false
+	}
+	return index[len(prefix) : len(index)-len(suffix)], true
+}
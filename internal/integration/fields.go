@@ -0,0 +1,208 @@
+package integration
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gopost/integration/internal/config"
+	"github.com/gopost/integration/internal/logger"
+	"github.com/gopost/integration/pkg/drupal"
+	"github.com/gopost/integration/pkg/pipeline"
+)
+
+// builtinFieldTransformers returns the field transformers available without
+// WithFieldTransformer.
+func builtinFieldTransformers() map[string]pipeline.FieldTransformer {
+	return map[string]pipeline.FieldTransformer{
+		"first_paragraph": pipeline.FirstParagraph,
+	}
+}
+
+// checkFieldTransformers reports field map entries whose source names a field
+// transformer that is not registered.
+func (s *Service) checkFieldTransformers() error {
+	for i, mapping := range s.config.Service.FieldMap {
+		name, ok := strings.CutPrefix(mapping.Source, config.FieldTransformerPrefix)
+		if ok && s.fields[name] == nil {
+			return fmt.Errorf("service.field_map[%d] (%s): no field transformer registered as %q", i, mapping.Field, name)
+		}
+	}
+	return nil
+}
+
+// applyFieldMap sets the Drupal fields configured in service.field_map on req.
+// Fields whose source property is empty, or cannot be encoded as the configured type,
+// are left out so Drupal keeps its default.
+func (s *Service) applyFieldMap(req *drupal.ArticleRequest, article *pipeline.Article) {
+	for _, mapping := range s.config.Service.FieldMap {
+		var value any
+		var ok bool
+		if name, transformed := strings.CutPrefix(mapping.Source, config.FieldTransformerPrefix); transformed {
+			value, ok = s.transformField(mapping, name, article)
+		} else {
+			value, ok = fieldValue(mapping, articleProperty(article, mapping.Source), article)
+		}
+		if !ok {
+			continue
+		}
+		if req.Fields == nil {
+			req.Fields = make(map[string]any, len(s.config.Service.FieldMap))
+		}
+		req.Fields[mapping.Field] = value
+	}
+}
+
+// transformField runs the named field transformer. A string result is encoded as the
+// configured field type and any other non-nil result is used as is; errors are logged
+// and leave the field out.
+func (s *Service) transformField(mapping config.FieldMapConfig, name string, article *pipeline.Article) (any, bool) {
+	value, err := s.fields[name](*article)
+	if err != nil {
+		s.logger.Warn("Field transformer failed; field left out",
+			logger.String("field", mapping.Field),
+			logger.String("transformer", name),
+			logger.String("article_id", article.ID),
+			logger.Error(err),
+		)
+		return nil, false
+	}
+	if text, ok := value.(string); ok {
+		return fieldValue(mapping, text, article)
+	}
+	return value, value != nil
+}
+
+// fieldValue encodes text, the mapping's source value, as the configured field type.
+func fieldValue(mapping config.FieldMapConfig, text string, article *pipeline.Article) (any, bool) {
+	if mapping.Type == config.FieldTypeMetatag {
+		return metatagValue(mapping, article)
+	}
+	if text == "" {
+		return nil, false
+	}
+
+	switch mapping.Type {
+	case config.FieldTypeInteger:
+		n, err := strconv.Atoi(text)
+		return n, err == nil
+	case config.FieldTypeText:
+		return drupal.TextValue{
+			Value:   text,
+			Format:  mapping.Format,
+			Summary: articleProperty(article, mapping.Summary),
+		}, true
+	case config.FieldTypeLink:
+		return drupal.LinkValue{URI: text, Title: articleProperty(article, mapping.Title)}, true
+	case config.FieldTypeDateTime, config.FieldTypeDate:
+		t, err := time.Parse(time.RFC3339, text)
+		if err != nil {
+			return nil, false
+		}
+		if mapping.Type == config.FieldTypeDate {
+			return drupal.DateValue(t), true
+		}
+		return drupal.DateTimeValue(t), true
+	default:
+		return text, true
+	}
+}
+
+// metatagValue sets each of the mapping's tags, DefaultMetatags when it has none, from
+// its article property. Tags whose property is empty are left out.
+func metatagValue(mapping config.FieldMapConfig, article *pipeline.Article) (any, bool) {
+	tags := mapping.Tags
+	if len(tags) == 0 {
+		tags = config.DefaultMetatags
+	}
+	value := make(drupal.MetatagValue, len(tags))
+	for tag, source := range tags {
+		if text := articleProperty(article, source); text != "" {
+			value[tag] = text
+		}
+	}
+	return value, len(value) > 0
+}
+
+// maxSummaryLength is the length of summaries taken from the body, about what search
+// engines show of a meta description.
+const maxSummaryLength = 160
+
+// articleSummary returns the article's intro, description, or Open Graph description,
+// or else the start of its body text, cut at a word within maxSummaryLength with an ellipsis.
+func articleSummary(article *pipeline.Article) string {
+	for _, summary := range []string{article.Intro, article.Description, article.OGDescription} {
+		if summary != "" {
+			return summary
+		}
+	}
+	body := pipeline.PlainText(article.Content)
+	if len(body) <= maxSummaryLength {
+		return body
+	}
+	cut := strings.LastIndexByte(body[:maxSummaryLength], ' ')
+	if cut <= 0 {
+		for cut = maxSummaryLength; !utf8.RuneStart(body[cut]); cut-- {
+		}
+	}
+	return strings.TrimRight(body[:cut], " ,;:.") + "…"
+}
+
+// articleProperty returns the article property named in config.FieldMapSources as a
+// string: dates in RFC 3339 and keywords pipe-separated, as in the built-in fields.
+// Highlight fragments are joined with ellipses.
+func articleProperty(article *pipeline.Article, name string) string {
+	switch name {
+	case "id":
+		return article.ID
+	case "title":
+		return article.Title
+	case "body":
+		return article.Content
+	case "canonical_url":
+		return article.URL
+	case "published_date":
+		if article.PublishedAt.IsZero() {
+			return ""
+		}
+		return article.PublishedAt.Format(time.RFC3339)
+	case "source":
+		return article.Source
+	case "intro":
+		return article.Intro
+	case "description":
+		return article.Description
+	case "og_title":
+		return article.OGTitle
+	case "og_description":
+		return article.OGDescription
+	case "og_image":
+		return article.OGImage
+	case "og_url":
+		return article.OGURL
+	case "word_count":
+		if article.WordCount == 0 {
+			return ""
+		}
+		return strconv.Itoa(article.WordCount)
+	case "category":
+		return article.Category
+	case "section":
+		return article.Section
+	case "keywords":
+		return strings.Join(article.Keywords, "|")
+	case "highlights":
+		return strings.Join(article.Highlights, " … ")
+	case "severity":
+		if article.Severity == 0 {
+			return ""
+		}
+		return strconv.Itoa(article.Severity)
+	case "summary":
+		return articleSummary(article)
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,175 @@
+package integration
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/gopost/integration/internal/config"
+)
+
+// Group runs the isolated pipelines of several tenants in one process. Each tenant has
+// its own Service, and with it its own backends, dedup state, and run reports.
+type Group struct {
+	names    []string
+	services map[string]*Service
+}
+
+// NewGroup returns an empty group.
+func NewGroup() *Group {
+	return &Group{services: make(map[string]*Service)}
+}
+
+// Add registers a tenant's service.
+func (g *Group) Add(tenant string, service *Service) {
+	if _, ok := g.services[tenant]; !ok {
+		g.names = append(g.names, tenant)
+	}
+	g.services[tenant] = service
+}
+
+// Run runs every tenant's service until ctx is done. A tenant stopping early does not
+// stop the others.
+func (g *Group) Run(ctx context.Context) error {
+	var wg sync.WaitGroup
+	errs := make([]error, len(g.names))
+	for i, name := range g.names {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := g.services[name].Run(ctx); err != nil && !errors.Is(err, context.Canceled) {
+				errs[i] = fmt.Errorf("tenant %s: %w", name, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+	return ctx.Err()
+}
+
+// Status returns the combined city count and each tenant's status under Tenants.
+func (g *Group) Status() Status {
+	status := Status{Tenants: make(map[string]Status, len(g.names))}
+	for _, name := range g.names {
+		tenantStatus := g.services[name].Status()
+		status.CityCount += tenantStatus.CityCount
+		status.OutboxEnabled = status.OutboxEnabled || tenantStatus.OutboxEnabled
+		if tenantStatus.LastCheck.After(status.LastCheck) {
+			status.LastCheck = tenantStatus.LastCheck
+		}
+		if len(status.Tenants) == 0 || tenantStatus.Health.Score < status.Health.Score {
+			status.Health = tenantStatus.Health
+		}
+		status.Tenants[name] = tenantStatus
+	}
+	tenantStats := make(map[string]Stats, len(status.Tenants))
+	for name, tenantStatus := range status.Tenants {
+		tenantStats[name] = tenantStatus.Stats
+	}
+	status.Stats = sumTenants(tenantStats)
+	return status
+}
+
+// Healthy reports whether every tenant's runs are still completing.
+func (g *Group) Healthy(grace time.Duration) bool {
+	for _, name := range g.names {
+		if !g.services[name].Healthy(grace) {
+			return false
+		}
+	}
+	return true
+}
+
+// FlushCache flushes every tenant's deduplication cache.
+func (g *Group) FlushCache(ctx context.Context) error {
+	for _, name := range g.names {
+		if err := g.services[name].FlushCache(ctx); err != nil {
+			return fmt.Errorf("tenant %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// Replay replays the window for every tenant configuring city (all tenants if city is
+// empty) and returns the combined report.
+func (g *Group) Replay(ctx context.Context, since time.Time, city string) (RunReport, error) {
+	combined := RunReport{StartedAt: time.Now()}
+	err := g.eachTenantWithCity(city, func(service *Service) error {
+		report, err := service.Replay(ctx, since, city)
+		combined.merge(report)
+		return err
+	})
+	combined.Duration = time.Since(combined.StartedAt)
+	if err != nil {
+		return combined, fmt.Errorf("replay: %w", err)
+	}
+	return combined, nil
+}
+
+// RunOnce performs a single run for every tenant, in turn, and returns the combined
+// report. A failing tenant does not stop the others.
+func (g *Group) RunOnce(ctx context.Context) (RunReport, error) {
+	combined := RunReport{StartedAt: time.Now()}
+	var errs []error
+	for _, name := range g.names {
+		report, err := g.services[name].RunOnce(ctx)
+		combined.merge(report)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("tenant %s: %w", name, err))
+		}
+	}
+	combined.Duration = time.Since(combined.StartedAt)
+	return combined, errors.Join(errs...)
+}
+
+// eachTenantWithCity calls fn for the tenants configuring city, or every tenant when city
+// is empty, stopping at the first error.
+func (g *Group) eachTenantWithCity(city string, fn func(*Service) error) error {
+	matched := false
+	for _, name := range g.names {
+		service := g.services[name]
+		if city != "" && !slices.ContainsFunc(service.knownCities(), func(c config.CityConfig) bool { return c.Name == city }) {
+			continue
+		}
+		matched = true
+		if err := fn(service); err != nil {
+			return fmt.Errorf("tenant %s: %w", name, err)
+		}
+	}
+	if !matched {
+		return fmt.Errorf("unknown city %q", city)
+	}
+	return nil
+}
+
+// merge adds another report's cities and dedup counters to r.
+func (r *RunReport) merge(other RunReport) {
+	r.Cities = append(r.Cities, other.Cities...)
+	r.Dedup.AlreadyPosted += other.Dedup.AlreadyPosted
+	r.Dedup.TitleMatches += other.Dedup.TitleMatches
+	r.Dedup.NearMatches += other.Dedup.NearMatches
+	r.Dedup.ReserveConflicts += other.Dedup.ReserveConflicts
+	r.Dedup.AlreadyQueued += other.Dedup.AlreadyQueued
+
+	counts := make(map[string]int)
+	for _, source := range slices.Concat(r.Dedup.TopDuplicateSources, other.Dedup.TopDuplicateSources) {
+		counts[source.Source] += source.Count
+	}
+	r.Dedup.TopDuplicateSources = r.Dedup.TopDuplicateSources[:0]
+	for source, count := range counts {
+		r.Dedup.TopDuplicateSources = append(r.Dedup.TopDuplicateSources, SourceCount{Source: source, Count: count})
+	}
+	slices.SortFunc(r.Dedup.TopDuplicateSources, func(a, b SourceCount) int {
+		return cmp.Or(cmp.Compare(b.Count, a.Count), cmp.Compare(a.Source, b.Source))
+	})
+	if len(r.Dedup.TopDuplicateSources) > topDuplicateSourceCount {
+		r.Dedup.TopDuplicateSources = r.Dedup.TopDuplicateSources[:topDuplicateSourceCount]
+	}
+}
@@ -0,0 +1,68 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/gopost/integration/internal/config"
+	"github.com/gopost/integration/internal/logger"
+)
+
+// usesGroupNames reports whether any configured city sets group_name.
+func usesGroupNames(cfg *config.Config) bool {
+	for _, cityCfg := range cfg.Cities {
+		if cityCfg.GroupName != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// refreshGroups re-resolves group names when service.group_refresh has passed.
+// Failures are logged; cities keep the UUIDs resolved earlier.
+func (s *Service) refreshGroups(ctx context.Context) {
+	if s.groups == nil || !s.groups.due(s.config.Service.GroupRefresh) {
+		return
+	}
+
+	refreshCtx, cancel := context.WithTimeout(ctx, drupalPostTimeout)
+	defer cancel()
+	if err := s.groups.refresh(refreshCtx); err != nil {
+		s.logger.Warn("Failed to resolve Drupal group names",
+			logger.String("group_type", s.config.Service.GroupType),
+			logger.Error(err),
+		)
+		return
+	}
+
+	for _, cityCfg := range s.config.Cities {
+		if cityCfg.GroupName == "" {
+			continue
+		}
+		if _, err := s.groups.lookup(cityCfg.GroupName); err != nil {
+			s.logger.Error("City group name does not resolve, its articles will not be posted",
+				logger.String("city", cityCfg.Name),
+				logger.String("group_name", cityCfg.GroupName),
+				logger.Error(err),
+			)
+		}
+	}
+}
+
+// resolveGroup returns cityCfg with GroupID set from its group_name. Cities configured
+// with group_id (or neither) are returned unchanged.
+func (s *Service) resolveGroup(cityCfg config.CityConfig) (config.CityConfig, error) {
+	if cityCfg.GroupName == "" || cityCfg.GroupID != "" {
+		return cityCfg, nil
+	}
+	if s.groups == nil {
+		return cityCfg, errors.New("group_name is set but no group lister is configured")
+	}
+	id, err := s.groups.lookup(cityCfg.GroupName)
+	if err != nil {
+		return cityCfg, fmt.Errorf("resolve group %q: %w", cityCfg.GroupName, err)
+	}
+	cityCfg.GroupID = id
+	return cityCfg, nil
+}
@@ -0,0 +1,99 @@
+package integration
+
+import (
+	"time"
+
+	"github.com/gopost/integration/pkg/pipeline"
+)
+
+// Health rolls the last run's outcome into one score, so a single alert rule (e.g. score
+// below 0.75 for 15 minutes) covers "gopost is unhealthy".
+type Health struct {
+	Score      float64       `json:"score"`       // 0 (down) to 1 (healthy): the product of the components below
+	ErrorRatio float64       `json:"error_ratio"` // Failed posts over attempted posts in the last run
+	Backends   BackendHealth `json:"backends"`    // Each unavailable backend costs a third of the score
+	Lag        time.Duration `json:"lag"`         // How far the newest article found is ahead of the last check time
+}
+
+// BackendHealth reports which backends the last run found available.
+type BackendHealth struct {
+	Elasticsearch bool `json:"elasticsearch"` // False when every city search failed
+	Drupal        bool `json:"drupal"`        // False in maintenance mode, while the credentials are rejected, or when every post failed
+	Redis         bool `json:"redis"`         // False when saving the last check time failed
+}
+
+// available returns the fraction of backends that are available.
+func (b BackendHealth) available() float64 {
+	up := 0
+	for _, ok := range []bool{b.Elasticsearch, b.Drupal, b.Redis} {
+		if ok {
+			up++
+		}
+	}
+	return float64(up) / 3
+}
+
+// health computes the health score from the last run. Callers hold s.mu.
+func (s *Service) health() Health {
+	var posted, errors, searched, searchFailed int
+	var newest time.Time
+	for _, city := range s.lastReport.Cities {
+		posted += city.Posted
+		errors += city.Errors
+		if city.Paused || city.ClosedIndex != "" {
+			continue
+		}
+		searched++
+		if city.Failed && city.Found == 0 {
+			searchFailed++
+		}
+		if city.Newest.After(newest) {
+			newest = city.Newest
+		}
+	}
+
+	health := Health{
+		Backends: BackendHealth{
+			Elasticsearch: searched == 0 || searchFailed < searched,
+			Drupal:        s.maintenanceStatus() == nil && s.credentialStatus() == nil && (posted > 0 || errors == 0),
+			Redis:         !s.redisFailed,
+		},
+	}
+	if posted+errors > 0 {
+		health.ErrorRatio = float64(errors) / float64(posted+errors)
+	}
+	if newest.After(s.lastCheckTS) {
+		health.Lag = newest.Sub(s.lastCheckTS)
+	}
+	health.Score = (1 - health.ErrorRatio) * health.Backends.available() * s.lagScore(health.Lag)
+	return health
+}
+
+// lagScore is 1 while lag is at most check_interval and falls linearly to 0 at
+// service.health.max_lag.
+func (s *Service) lagScore(lag time.Duration) float64 {
+	interval := s.config.Service.CheckInterval
+	maxLag := s.config.Service.Health.MaxLag
+	if maxLag <= interval {
+		maxLag = 12 * interval
+	}
+	switch {
+	case lag <= interval:
+		return 1
+	case lag >= maxLag:
+		return 0
+	default:
+		return float64(maxLag-lag) / float64(maxLag-interval)
+	}
+}
+
+// newestPublished returns the latest published date among articles.
+func newestPublished(articles []pipeline.Article) time.Time {
+	var newest time.Time
+	for _, article := range articles {
+		if article.PublishedAt.After(newest) {
+			newest = article.PublishedAt
+		}
+	}
+	return newest
+}
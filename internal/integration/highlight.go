@@ -0,0 +1,47 @@
+package integration
+
+import (
+	"slices"
+
+	"github.com/gopost/integration/internal/config"
+	"github.com/gopost/integration/pkg/pipeline"
+)
+
+// highlightRequest asks Elasticsearch for fragments of the title and body around the city's
+// crime keywords. The keyword clause is given as the highlight query so fragments are returned
+// even when the main query does not match on keywords, as with the percolator.
+func (s *Service) highlightRequest(cityCfg config.CityConfig) map[string]any {
+	return map[string]any{
+		"highlight_query": s.keywordClause(s.crimeKeywords(cityCfg)).Source(),
+		"fields": map[string]any{
+			pipeline.ESFieldTitle: map[string]any{"number_of_fragments": 0}, // The whole title
+			pipeline.ESFieldBody: map[string]any{
+				"fragment_size":       s.config.Service.Highlight.FragmentSize,
+				"number_of_fragments": s.config.Service.Highlight.Fragments,
+			},
+		},
+	}
+}
+
+// highlightFragments flattens a hit's highlighted fields: title first, then body, then
+// any other fields a query template highlighted, in name order.
+func highlightFragments(highlight map[string][]string) []string {
+	if len(highlight) == 0 {
+		return nil
+	}
+
+	fields := []string{pipeline.ESFieldTitle, pipeline.ESFieldBody}
+	others := make([]string, 0, len(highlight))
+	for field := range highlight {
+		if !slices.Contains(fields, field) {
+			others = append(others, field)
+		}
+	}
+	slices.Sort(others)
+
+	var fragments []string
+	for _, field := range append(fields, others...) {
+		fragments = append(fragments, highlight[field]...)
+	}
+	return fragments
+}
@@ -0,0 +1,92 @@
+package integration
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/gopost/integration/internal/config"
+	"github.com/gopost/integration/pkg/drupal"
+)
+
+// builtinFieldSources names the article property behind each attribute gopost sets
+// itself, for hints about fields Drupal rejected.
+var builtinFieldSources = map[string]string{
+	"field_url":            "canonical_url",
+	"field_external_id":    "id",
+	"field_intro":          "intro",
+	"field_description":    "description",
+	"field_og_title":       "og_title",
+	"field_og_description": "og_description",
+	"field_og_image":       "og_image",
+	"field_og_url":         "og_url",
+	"field_word_count":     "word_count",
+	"field_category":       "category",
+	"field_section":        "section",
+	"field_keywords":       "keywords",
+	"field_canonical_url":  "canonical_url",
+	"field_published_date": "published_date",
+}
+
+// fieldHints translates the fields a Drupal error response points at into the settings
+// that produce them, e.g. the service.field_map entry behind /data/attributes/field_teaser.
+// Errors without a field pointer get no hint.
+func (s *Service) fieldHints(apiErr *drupal.APIError) []string {
+	var hints []string
+	for _, drupalErr := range apiErr.Errors {
+		hint := s.fieldHint(drupalErr)
+		if hint != "" && !slices.Contains(hints, hint) {
+			hints = append(hints, hint)
+		}
+	}
+	return hints
+}
+
+// fieldHint returns the hint for one error object, or "" when it names no field.
+func (s *Service) fieldHint(drupalErr drupal.DrupalError) string {
+	contentType := s.config.Service.ContentType
+	if drupalErr.Source.Pointer == "/data/type" {
+		return fmt.Sprintf("service.content_type %q is not a content type JSON:API accepts", contentType)
+	}
+
+	field, relationship := drupalErr.Field()
+	if field == "" {
+		return ""
+	}
+	if relationship {
+		switch field {
+		case "field_group":
+			return fmt.Sprintf("field_group references the city's group_id or group_name; check that it is a group of service.group_type %q", s.config.Service.GroupType)
+		case "field_source_terms":
+			return fmt.Sprintf("field_source_terms references the terms in sources.outlets; check that they are terms of sources.term_type %q", s.config.Sources.TermType)
+		case "field_crime_categories":
+			return fmt.Sprintf("field_crime_categories references the terms in categories.mappings; check that they are terms of categories.term_type %q", s.config.Categories.TermType)
+		}
+		return fmt.Sprintf("%s: relationship not set by gopost; check whether %s requires it", field, contentType)
+	}
+
+	// Mapped fields replace built-in ones, so they are checked first
+	for i, mapping := range s.config.Service.FieldMap {
+		if mapping.Field == field && mapping.Type == config.FieldTypeMetatag {
+			return fmt.Sprintf("%s is set by service.field_map[%d] (type metatag); check that %s has the field and that the Metatag module's JSON:API support is enabled",
+				field, i, contentType)
+		}
+		if mapping.Field == field {
+			return fmt.Sprintf("%s is set by service.field_map[%d] (source %s, type %s); check that %s has the field and that the type matches its Drupal field type",
+				field, i, mapping.Source, mapping.Type, contentType)
+		}
+	}
+	switch field {
+	case "title":
+		return fmt.Sprintf("title is the article title; check the title length limit of %s and service.titles", contentType)
+	case "langcode":
+		return "langcode is the city's language; check that the language is enabled on the site and that the content type has language support"
+	case "body":
+		return fmt.Sprintf("body is sent as full_html text; check that %s has a body field and that the full_html text format exists", contentType)
+	case "field_source_name", "field_source_url":
+		return fmt.Sprintf("%s credits the outlet from sources.outlets; add the field to %s or remove the outlet mappings", field, contentType)
+	}
+	if source, ok := builtinFieldSources[field]; ok {
+		return fmt.Sprintf("%s is set from the article's %s; add the field to %s, or map a replacement with service.field_map", field, source, contentType)
+	}
+	return fmt.Sprintf("%s is not set by gopost; check whether %s requires it and populate it with service.field_map", field, contentType)
+}
@@ -0,0 +1,83 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/gopost/integration/internal/config"
+	"github.com/gopost/integration/internal/history"
+	"github.com/gopost/integration/internal/logger"
+)
+
+// saveHistory records the run when run history is enabled. Failures are logged only.
+func (s *Service) saveHistory(ctx context.Context, report RunReport) {
+	if s.history == nil {
+		return
+	}
+
+	saveCtx, cancel := context.WithTimeout(ctx, redisTimeout)
+	defer cancel()
+	if err := s.history.Save(saveCtx, historyRun(report)); err != nil {
+		s.logger.Warn("Failed to save run history",
+			logger.Error(err),
+		)
+	}
+}
+
+// historyRun converts a run report to its persisted summary.
+func historyRun(report RunReport) history.Run {
+	run := history.Run{
+		StartedAt: report.StartedAt,
+		Duration:  report.Duration,
+	}
+	for _, city := range report.Cities {
+		run.Cities = append(run.Cities, history.CityRun{
+			City:     city.City,
+			Found:    city.Found,
+			Posted:   city.Posted,
+			Queued:   city.Queued,
+			Skipped:  city.Skipped,
+			Errors:   city.Errors,
+			Failed:   city.Failed,
+			PostTime: city.PostTime,
+		})
+	}
+	return run
+}
+
+// ReadHistory returns the runs recorded since the given time, oldest first, without
+// building the rest of the pipeline. In multi-tenant mode it reads every tenant's Redis
+// database and prefixes city names with the tenant name.
+func ReadHistory(ctx context.Context, cfg *config.Config, since time.Time, log logger.Logger) ([]history.Run, error) {
+	if len(cfg.Tenants) == 0 {
+		return readHistory(ctx, cfg, since, log)
+	}
+
+	var runs []history.Run
+	for _, tenant := range cfg.Tenants {
+		tenantRuns, err := readHistory(ctx, cfg.ForTenant(tenant), since, log)
+		if err != nil {
+			return nil, fmt.Errorf("tenant %s: %w", tenant.Name, err)
+		}
+		for _, run := range tenantRuns {
+			for i := range run.Cities {
+				run.Cities[i].City = tenant.Name + "/" + run.Cities[i].City
+			}
+			runs = append(runs, run)
+		}
+	}
+	slices.SortStableFunc(runs, func(a, b history.Run) int { return a.StartedAt.Compare(b.StartedAt) })
+	return runs, nil
+}
+
+func readHistory(ctx context.Context, cfg *config.Config, since time.Time, log logger.Logger) ([]history.Run, error) {
+	redisClient, err := newRedisClientFromConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = redisClient.Close() }()
+
+	return history.NewStore(redisClient, keyPrefix(cfg), cfg.History.Retention, log).Since(ctx, since)
+}
@@ -0,0 +1,57 @@
+package integration
+
+import (
+	"context"
+
+	"github.com/gopost/integration/internal/canary"
+	"github.com/gopost/integration/internal/config"
+	"github.com/gopost/integration/internal/logger"
+	"github.com/gopost/integration/pkg/pipeline"
+)
+
+// hookPayload is the JSON passed to hooks: the article for article events, the run report
+// for run_complete, and the trial for canary_verdict.
+type hookPayload struct {
+	Event      string            `json:"event"`
+	City       string            `json:"city,omitempty"`
+	Article    *pipeline.Article `json:"article,omitempty"`
+	DrupalUUID string            `json:"drupal_uuid,omitempty"` // post_success, when the poster reports node UUIDs
+	Error      string            `json:"error,omitempty"`       // post_failure
+	Run        *RunReport        `json:"run,omitempty"`
+	Canary     *canary.Trial     `json:"canary,omitempty"` // canary_verdict
+}
+
+// runArticleHooks runs the hooks configured for an article event. Failures are logged
+// only, with the article's logger from ctx; hooks never change whether or how the
+// article is posted.
+func (s *Service) runArticleHooks(ctx context.Context, event string, cityCfg config.CityConfig, article *pipeline.Article, nodeID string, postErr error) {
+	if s.hooks == nil || !s.hooks.Has(event) {
+		return
+	}
+
+	payload := hookPayload{Event: event, City: cityCfg.Name, Article: article, DrupalUUID: nodeID}
+	if postErr != nil {
+		payload.Error = postErr.Error()
+	}
+	if err := s.hooks.Run(ctx, event, payload); err != nil {
+		logger.FromContext(ctx).Warn("Hook failed",
+			logger.String("event", event),
+			logger.Error(err),
+		)
+	}
+}
+
+// runCompleteHooks runs the run_complete hooks with the run report.
+func (s *Service) runCompleteHooks(ctx context.Context, report RunReport) {
+	if s.hooks == nil || !s.hooks.Has(config.HookRunComplete) {
+		return
+	}
+
+	payload := hookPayload{Event: config.HookRunComplete, Run: &report}
+	if err := s.hooks.Run(ctx, config.HookRunComplete, payload); err != nil {
+		logger.FromContext(ctx).Warn("Hook failed",
+			logger.String("event", config.HookRunComplete),
+			logger.Error(err),
+		)
+	}
+}
@@ -0,0 +1,50 @@
+package integration
+
+import (
+	"cmp"
+
+	"github.com/gopost/integration/internal/config"
+	"github.com/gopost/integration/internal/logger"
+	"github.com/gopost/integration/pkg/pipeline"
+)
+
+// identityFor returns the identity of the article's outlet, else of the city, else
+// service.identity. URL identities hash the canonical URL as normalized by service.urls.
+func (s *Service) identityFor(cityCfg config.CityConfig, article *pipeline.Article) pipeline.Identity {
+	spec := cmp.Or(cityCfg.Identity, s.config.Service.Identity)
+	if outlet, ok := s.outletFor(article); ok && outlet.Identity != "" {
+		spec = outlet.Identity
+	}
+	identity, err := pipeline.ParseIdentity(spec, s.urls)
+	if err != nil {
+		// Checked by config.Load; cities from the sources service carry no identity
+		s.logger.Warn("Invalid article identity; identifying by ID",
+			logger.String("city", cityCfg.Name),
+			logger.String("identity", spec),
+			logger.Error(err),
+		)
+		return pipeline.SourceIDIdentity{}
+	}
+	return identity
+}
+
+// identify sets the article's ID, its dedup key, and its external ID from the search hit.
+// When the identity finds nothing to go by, e.g. a url identity for an article without a
+// URL, the article is identified by _source.id or _id instead.
+func (s *Service) identify(cityCfg config.CityConfig, hit pipeline.SearchHit, article *pipeline.Article) {
+	identity := s.identityFor(cityCfg, article)
+	key := identity.Key(hit, *article)
+	if key == "" {
+		s.logger.Debug("Article has nothing to identify it by; identifying by ID",
+			logger.String("article_id", hit.ID),
+			logger.String("city", cityCfg.Name),
+		)
+		identity = pipeline.SourceIDIdentity{}
+		key = identity.Key(hit, *article)
+	}
+	externalID := identity.ExternalID(hit, *article)
+	article.ID = key
+	if externalID != key {
+		article.ExternalID = externalID
+	}
+}
@@ -0,0 +1,56 @@
+package integration
+
+import (
+	"time"
+
+	"github.com/gopost/integration/internal/logger"
+)
+
+// MaintenanceStatus describes Drupal's maintenance mode as last seen by a post.
+type MaintenanceStatus struct {
+	Since   time.Time `json:"since"`
+	RetryAt time.Time `json:"retry_at"` // When posting is next attempted
+}
+
+// inMaintenance reports whether posting should wait for Drupal to leave maintenance mode.
+// Once the backoff has passed it returns false, so the next post checks the site again.
+func (s *Service) inMaintenance() bool {
+	return s.maintenance.open()
+}
+
+// enterMaintenance records that a post found Drupal in maintenance mode and pauses posting
+// for service.maintenance.backoff, doubled for each retry that finds the site still in
+// maintenance, up to max_backoff. Posts failing while posting is already paused, such as
+// those of other outbox workers, do not extend the wait.
+func (s *Service) enterMaintenance() {
+	since, wait, tripped := s.maintenance.trip(s.config.Service.Maintenance.Backoff, s.config.Service.Maintenance.MaxBackoff, nil)
+	if !tripped {
+		return
+	}
+
+	s.logger.Info("Drupal in maintenance mode - posting paused",
+		logger.Time("maintenance_since", since),
+		logger.Duration("retry_in", wait),
+	)
+}
+
+// leaveMaintenance records a successful post, resuming posting if Drupal was in maintenance.
+func (s *Service) leaveMaintenance() {
+	since := s.maintenance.reset()
+	if since.IsZero() {
+		return
+	}
+
+	s.logger.Info("Drupal maintenance ended - posting resumed",
+		logger.Duration("maintenance_duration", time.Since(since)),
+	)
+}
+
+// maintenanceStatus returns the maintenance mode for Status, or nil while Drupal is up.
+func (s *Service) maintenanceStatus() *MaintenanceStatus {
+	since, retryAt, _ := s.maintenance.state()
+	if since.IsZero() {
+		return nil
+	}
+	return &MaintenanceStatus{Since: since, RetryAt: retryAt}
+}
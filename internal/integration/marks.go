@@ -0,0 +1,70 @@
+package integration
+
+import (
+	"context"
+
+	"github.com/gopost/integration/internal/config"
+	"github.com/gopost/integration/internal/logger"
+)
+
+// hasPosted reports whether the dedup key is posted or reserved in the dedup store, or
+// posted with its marker waiting in the mark journal.
+func (s *Service) hasPosted(ctx context.Context, key string) bool {
+	return s.unmarked.Has(key) || s.dedup.HasPosted(ctx, key)
+}
+
+// deferMark records a dedup key whose MarkPosted failed in the mark journal, for
+// retryMarks to write later. A journal that cannot be written still holds the key in
+// memory, so only a restart before the retry could post the article again.
+func (s *Service) deferMark(cityCfg config.CityConfig, key string) {
+	if err := s.unmarked.Add(key); err != nil {
+		s.logger.Error("Failed to record unmarked article in the mark journal",
+			logger.String("dedup_key", key),
+			logger.String("city", cityCfg.Name),
+			logger.String("mark_journal", s.config.Service.MarkJournal),
+			logger.Error(err),
+		)
+	}
+}
+
+// retryMarks writes the posted markers waiting in the mark journal, including those left
+// by an earlier process. It stops at the first failure, since Redis is then most likely
+// still unreachable, and leaves the rest for the next retry.
+func (s *Service) retryMarks(ctx context.Context) {
+	keys := s.unmarked.Pending()
+	if len(keys) == 0 {
+		return
+	}
+
+	var marked []string
+	var markErr error
+	for _, key := range keys {
+		markCtx, markCancel := context.WithTimeout(context.WithoutCancel(ctx), redisTimeout)
+		markErr = s.dedup.MarkPosted(markCtx, key)
+		markCancel()
+		if markErr != nil {
+			break
+		}
+		marked = append(marked, key)
+	}
+
+	if len(marked) > 0 {
+		if err := s.unmarked.Remove(marked...); err != nil {
+			s.logger.Warn("Failed to update the mark journal",
+				logger.String("mark_journal", s.config.Service.MarkJournal),
+				logger.Error(err),
+			)
+		}
+	}
+	if markErr != nil {
+		s.logger.Warn("Failed to retry posted markers",
+			logger.Int("marked", len(marked)),
+			logger.Int("pending", s.unmarked.Len()),
+			logger.Error(markErr),
+		)
+		return
+	}
+	s.logger.Info("Posted markers written on retry",
+		logger.Int("marked", len(marked)),
+	)
+}
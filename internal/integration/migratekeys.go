@@ -0,0 +1,111 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/gopost/integration/internal/config"
+	"github.com/gopost/integration/internal/dedup"
+)
+
+// KeyMigration is the outcome of MigrateKeys. In multi-tenant mode keys are reported as
+// "<tenant>/<key>".
+type KeyMigration struct {
+	Renamed []string // Keys moved under redis.key_prefix, by their old name
+	Skipped []string // Keys left in place because their new name is already taken
+}
+
+// MigrateKeys moves gopost's Redis keys under redis.key_prefix. With an empty from it
+// moves the dedup markers written before keys were prefixed (posted:article:*); with
+// from set, it moves every key under that earlier prefix, e.g. "gopost:" when giving an
+// environment a prefix of its own. Keys keep their value and TTL. A key whose new name
+// already exists is newer state and is left alone, reported in Skipped. With dryRun the
+// keys are listed without being renamed.
+func MigrateKeys(ctx context.Context, cfg *config.Config, from string, dryRun bool) (KeyMigration, error) {
+	if strings.ContainsAny(from, `*?[]\`) {
+		return KeyMigration{}, fmt.Errorf("prefix to migrate from must not contain *, ?, [, ], or \\, got %q", from)
+	}
+	if len(cfg.Tenants) == 0 {
+		return migrateKeys(ctx, cfg, from, dryRun)
+	}
+
+	var migration KeyMigration
+	for _, tenant := range cfg.Tenants {
+		tenantMigration, err := migrateKeys(ctx, cfg.ForTenant(tenant), from, dryRun)
+		for _, key := range tenantMigration.Renamed {
+			migration.Renamed = append(migration.Renamed, tenant.Name+"/"+key)
+		}
+		for _, key := range tenantMigration.Skipped {
+			migration.Skipped = append(migration.Skipped, tenant.Name+"/"+key)
+		}
+		if err != nil {
+			return migration, fmt.Errorf("tenant %s: %w", tenant.Name, err)
+		}
+	}
+	return migration, nil
+}
+
+func migrateKeys(ctx context.Context, cfg *config.Config, from string, dryRun bool) (KeyMigration, error) {
+	prefix := keyPrefix(cfg)
+	if from == prefix {
+		return KeyMigration{}, fmt.Errorf("keys are already under %q", prefix)
+	}
+	pattern := dedup.ArticleKeyPrefix + "*"
+	if from != "" {
+		pattern = from + "*"
+	}
+
+	redisClient, err := newRedisClientFromConfig(cfg)
+	if err != nil {
+		return KeyMigration{}, err
+	}
+	defer func() { _ = redisClient.Close() }()
+
+	// Keys are listed before any is renamed, so SCAN never returns a key twice
+	var keys []string
+	iter := redisClient.Scan(ctx, 0, pattern, 1000).Iterator()
+	for iter.Next(ctx) {
+		key := iter.Val()
+		// A new prefix nested in the old one, e.g. "gopost:" to "gopost:staging:", also matches
+		if from != "" && strings.HasPrefix(key, prefix) {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	if err := iter.Err(); err != nil {
+		return KeyMigration{}, fmt.Errorf("scan %s: %w", pattern, err)
+	}
+
+	var migration KeyMigration
+	for _, key := range keys {
+		newKey := prefix + strings.TrimPrefix(key, from)
+		if dryRun {
+			exists, err := redisClient.Exists(ctx, newKey).Result()
+			if err != nil {
+				return migration, fmt.Errorf("check %s: %w", newKey, err)
+			}
+			if exists > 0 {
+				migration.Skipped = append(migration.Skipped, key)
+			} else {
+				migration.Renamed = append(migration.Renamed, key)
+			}
+			continue
+		}
+
+		renamed, err := redisClient.RenameNX(ctx, key, newKey).Result()
+		if err != nil && strings.Contains(err.Error(), "no such key") {
+			// Expired or removed since the scan
+			continue
+		}
+		if err != nil {
+			return migration, fmt.Errorf("rename %s to %s: %w", key, newKey, err)
+		}
+		if renamed {
+			migration.Renamed = append(migration.Renamed, key)
+		} else {
+			migration.Skipped = append(migration.Skipped, key)
+		}
+	}
+	return migration, nil
+}
@@ -0,0 +1,166 @@
+package integration
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/gopost/integration/internal/logger"
+	"github.com/gopost/integration/pkg/pipeline"
+)
+
+// Moderation decisions reported by Drupal for posted nodes.
+const (
+	ModerationPublished = "published" // An editor published the node
+	ModerationRejected  = "rejected"  // An editor rejected (or archived) the node
+	ModerationEdited    = "edited"    // An editor changed the node before deciding
+	ModerationDeleted   = "deleted"   // An editor deleted the node
+)
+
+// ErrInvalidModeration is returned by RecordModeration for events it cannot apply.
+var ErrInvalidModeration = errors.New("invalid moderation event")
+
+// ModerationEvent is an editorial decision on a posted node, sent by a Drupal webhook.
+type ModerationEvent struct {
+	ArticleID string    `json:"article_id"`        // The node's field_external_id
+	Decision  string    `json:"decision"`          // One of the Moderation* constants
+	NodeID    string    `json:"node_id,omitempty"` // Drupal node UUID
+	State     string    `json:"state,omitempty"`   // Drupal moderation state, e.g. "archived"
+	Editor    string    `json:"editor,omitempty"`
+	City      string    `json:"city,omitempty"`   // City the node was posted for; labels the metrics
+	Tenant    string    `json:"tenant,omitempty"` // Required in multi-tenant mode
+	At        time.Time `json:"at,omitzero"`      // When the decision was made; defaults to when it was received
+}
+
+// Validate reports whether the event names an article and a known decision.
+func (e ModerationEvent) Validate() error {
+	if e.ArticleID == "" {
+		return fmt.Errorf("%w: article_id is required", ErrInvalidModeration)
+	}
+	switch e.Decision {
+	case ModerationPublished, ModerationRejected, ModerationEdited, ModerationDeleted:
+		return nil
+	default:
+		return fmt.Errorf("%w: unknown decision %q (want published, rejected, edited, or deleted)", ErrInvalidModeration, e.Decision)
+	}
+}
+
+// ModerationCount is how many decisions of one kind were received for a city.
+type ModerationCount struct {
+	City     string `json:"city,omitempty"`
+	Decision string `json:"decision"`
+	Count    int    `json:"count"`
+}
+
+// moderationRecord is the last decision on an article, kept in the "moderation" field of
+// its audit document.
+type moderationRecord struct {
+	Decision string    `json:"decision"`
+	State    string    `json:"state,omitempty"`
+	Editor   string    `json:"editor,omitempty"`
+	At       time.Time `json:"at"`
+}
+
+// moderationState counts the decisions received since startup.
+type moderationState struct {
+	mu     sync.Mutex
+	counts map[ModerationCount]int // Keyed by city and decision, with Count unset
+}
+
+// record counts a decision.
+func (m *moderationState) record(city, decision string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.counts == nil {
+		m.counts = make(map[ModerationCount]int)
+	}
+	m.counts[ModerationCount{City: city, Decision: decision}]++
+}
+
+// snapshot returns the counts ordered by city and decision, or nil before the first decision.
+func (m *moderationState) snapshot() []ModerationCount {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var counts []ModerationCount
+	for key, count := range m.counts {
+		key.Count = count
+		counts = append(counts, key)
+	}
+	slices.SortFunc(counts, func(a, b ModerationCount) int {
+		return cmp.Or(cmp.Compare(a.City, b.City), cmp.Compare(a.Decision, b.Decision))
+	})
+	return counts
+}
+
+// RecordModeration applies an editorial decision on a posted article: rejected and deleted
+// articles are suppressed when suppression.enabled is set, the decision is added to the
+// article's audit document when audit.enabled is set, and it is counted for /metrics.
+func (s *Service) RecordModeration(ctx context.Context, event ModerationEvent) error {
+	if err := event.Validate(); err != nil {
+		return err
+	}
+	if event.At.IsZero() {
+		event.At = time.Now()
+	}
+
+	if (event.Decision == ModerationRejected || event.Decision == ModerationDeleted) && s.suppressed != nil {
+		suppressCtx, cancel := context.WithTimeout(ctx, redisTimeout)
+		err := s.suppressed.Add(suppressCtx, event.ArticleID)
+		cancel()
+		if err != nil {
+			return err
+		}
+	}
+	s.moderation.record(event.City, event.Decision)
+
+	s.logger.Info("Moderation decision received",
+		logger.String("article_id", event.ArticleID),
+		logger.String("decision", event.Decision),
+		logger.String("city", event.City),
+		logger.String("drupal_id", event.NodeID),
+		logger.String("editor", event.Editor),
+	)
+	s.auditModeration(ctx, event)
+	return nil
+}
+
+// auditModeration records the decision in the article's audit document. Failures are
+// logged only; articles posted before audit was enabled have no document to update.
+func (s *Service) auditModeration(ctx context.Context, event ModerationEvent) {
+	updater, ok := s.audit.(pipeline.DocumentUpdater)
+	if !ok {
+		return
+	}
+
+	update := map[string]moderationRecord{"moderation": {
+		Decision: event.Decision,
+		State:    event.State,
+		Editor:   event.Editor,
+		At:       event.At,
+	}}
+	auditCtx, cancel := context.WithTimeout(ctx, esQueryTimeout)
+	defer cancel()
+	if err := updater.UpdateDocument(auditCtx, s.config.Audit.Index, event.ArticleID, update); err != nil {
+		s.logger.Warn("Failed to record moderation decision in audit index",
+			logger.String("article_id", event.ArticleID),
+			logger.String("audit_index", s.config.Audit.Index),
+			logger.Error(err),
+		)
+	}
+}
+
+// RecordModeration passes the decision to the tenant it names.
+func (g *Group) RecordModeration(ctx context.Context, event ModerationEvent) error {
+	service, ok := g.services[event.Tenant]
+	if !ok {
+		return fmt.Errorf("%w: unknown tenant %q", ErrInvalidModeration, event.Tenant)
+	}
+	if err := service.RecordModeration(ctx, event); err != nil {
+		return fmt.Errorf("tenant %s: %w", event.Tenant, err)
+	}
+	return nil
+}
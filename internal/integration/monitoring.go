@@ -0,0 +1,32 @@
+package integration
+
+import (
+	"context"
+
+	"github.com/gopost/integration/internal/logger"
+)
+
+// ping sends the monitoring ping for kind ("start", "success", or "fail") when
+// monitoring.ping_url is set. Failures are logged only; an unreachable monitor never
+// fails a run.
+func (s *Service) ping(ctx context.Context, kind string) {
+	if s.pinger == nil {
+		return
+	}
+
+	var err error
+	switch kind {
+	case "start":
+		err = s.pinger.Start(ctx)
+	case "fail":
+		err = s.pinger.Fail(ctx)
+	default:
+		err = s.pinger.Success(ctx)
+	}
+	if err != nil {
+		s.logger.Warn("Monitoring ping failed",
+			logger.String("ping", kind),
+			logger.Error(err),
+		)
+	}
+}
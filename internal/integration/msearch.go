@@ -0,0 +1,103 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gopost/integration/internal/config"
+	"github.com/gopost/integration/internal/logger"
+	"github.com/gopost/integration/pkg/pipeline"
+)
+
+// prefetchedSearches holds the results of a run's multi-search, keyed by searchKey, for
+// the cities' findArticles.
+type prefetchedSearches struct {
+	mu      sync.Mutex
+	results map[string]pipeline.MultiSearchResult
+}
+
+// set replaces the held results; nil drops them at the end of a run.
+func (p *prefetchedSearches) set(results map[string]pipeline.MultiSearchResult) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.results = results
+}
+
+// get returns the result of the search with key.
+func (p *prefetchedSearches) get(key string) (pipeline.MultiSearchResult, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	result, ok := p.results[key]
+	return result, ok
+}
+
+// prefetchSearches runs the searches of all cities in one multi-search request when
+// service.multi_search is set, so each city's findArticles uses its result instead of
+// searching; cities sharing a search share one. A search that fails there fails only its
+// city. When the request as a whole fails, as on clusters or proxies that do not allow
+// _msearch, it is logged and the cities search individually. Paused cities are searched
+// too; their results go unused. Cities whose index is closed or frozen, or that search with
+// the stored search template, are left out.
+func (s *Service) prefetchSearches(ctx context.Context, cities []config.CityConfig) {
+	s.prefetched.set(nil)
+	if s.multi == nil || len(cities) == 0 {
+		return
+	}
+
+	var window searchWindow
+	if s.config.Service.LookbackHours > 0 {
+		window.since = s.getLastCheckTS()
+	}
+	var keys []string
+	var searches []pipeline.SearchRequest
+	seen := make(map[string]bool)
+	for _, cityCfg := range cities {
+		index := cityIndex(cityCfg)
+		if s.indexClosed(index) != "" || s.usesStoredTemplate(cityCfg) {
+			continue
+		}
+		// Cities whose query fails to build report it from findArticles
+		query, err := s.searchQuery(cityCfg, window)
+		if err != nil {
+			continue
+		}
+		key := searchKey(index, query)
+		if key == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		keys = append(keys, key)
+		searches = append(searches, pipeline.SearchRequest{Index: index, Query: query})
+	}
+	if len(searches) == 0 {
+		return
+	}
+
+	startTime := time.Now()
+	searchCtx, cancel := context.WithTimeout(ctx, esQueryTimeout)
+	defer cancel()
+	results, err := s.multi.MultiSearch(searchCtx, searches)
+	if err == nil && len(results) != len(searches) {
+		err = fmt.Errorf("multi-search returned %d results for %d searches", len(results), len(searches))
+	}
+	if err != nil {
+		s.logger.Warn("Multi-search failed - searching cities individually",
+			logger.Int("searches", len(searches)),
+			logger.Duration("duration", time.Since(startTime)),
+			logger.Error(err),
+		)
+		return
+	}
+
+	prefetched := make(map[string]pipeline.MultiSearchResult, len(results))
+	for i, result := range results {
+		prefetched[keys[i]] = result
+	}
+	s.prefetched.set(prefetched)
+	s.logger.Debug("Multi-search completed",
+		logger.Int("searches", len(searches)),
+		logger.Duration("duration", time.Since(startTime)),
+	)
+}
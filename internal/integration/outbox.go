@@ -0,0 +1,359 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/gopost/integration/internal/config"
+	"github.com/gopost/integration/internal/logger"
+	"github.com/gopost/integration/internal/outbox"
+	"github.com/gopost/integration/pkg/drupal"
+	"github.com/gopost/integration/pkg/pipeline"
+)
+
+// outboxDequeueWait bounds how long a worker blocks on an empty queue before
+// re-checking for shutdown.
+const outboxDequeueWait = time.Second
+
+// pausedCityRequeueDelay is how long an article of a paused city waits before a worker
+// checks the city's pause flag again.
+const pausedCityRequeueDelay = 30 * time.Second
+
+// enqueueArticle adds a candidate article to the outbox, with the group quota day it was
+// counted on (see takeQuota) for the workers to release if it is never posted. It reports
+// whether the article was newly queued (false if already queued or on error).
+func (s *Service) enqueueArticle(ctx context.Context, cityCfg config.CityConfig, article *pipeline.Article, quotaDay string) bool {
+	enqueueCtx, enqueueCancel := context.WithTimeout(ctx, redisTimeout)
+	defer enqueueCancel()
+
+	item := outbox.Item{City: cityCfg.Name, Article: *article}
+	if quotaDay != "" {
+		item.QuotaGroup, item.QuotaDay = cityCfg.GroupID, quotaDay
+	}
+	added, err := s.queue.Enqueue(enqueueCtx, item)
+	if err != nil {
+		s.logger.Error("Failed to enqueue article",
+			logger.String("article_id", article.ID),
+			logger.String("city", cityCfg.Name),
+			logger.Error(err),
+		)
+		return false
+	}
+	if !added {
+		s.stats.record(duplicateAlreadyQueued, article)
+		s.logger.Debug("Article skipped - already queued",
+			logger.String("article_id", article.ID),
+			logger.String("city", cityCfg.Name),
+		)
+	}
+	return added
+}
+
+// runPostingWorkers drains the outbox with the configured number of workers until ctx is
+// done, taking back items abandoned by crashed workers every half outbox.claim_idle.
+func (s *Service) runPostingWorkers(ctx context.Context) {
+	s.recoverDeliveries(ctx)
+
+	var wg sync.WaitGroup
+	if interval := s.config.Outbox.ClaimIdle / 2; interval > 0 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+					s.recoverDeliveries(ctx)
+				}
+			}
+		}()
+	}
+	for worker := 1; worker <= s.config.Outbox.Workers; worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.postingWorker(ctx, worker)
+		}()
+	}
+	wg.Wait()
+}
+
+func (s *Service) recoverDeliveries(ctx context.Context) {
+	recoverCtx, recoverCancel := context.WithTimeout(ctx, redisTimeout)
+	defer recoverCancel()
+
+	if _, err := s.queue.Recover(recoverCtx); err != nil {
+		s.logger.Warn("Failed to recover in-flight outbox items",
+			logger.Error(err),
+		)
+	}
+}
+
+// postingWorker posts queued articles one at a time, sharing the service rate limiter.
+func (s *Service) postingWorker(ctx context.Context, worker int) {
+	workerLogger := s.logger.With(logger.Int("worker", worker))
+	ctx = logger.WithContext(ctx, workerLogger)
+	workerLogger.Debug("Posting worker started")
+
+	for ctx.Err() == nil {
+		if s.paused(ctx, "") || s.inMaintenance() || s.credentialsRejected() {
+			select {
+			case <-ctx.Done():
+			case <-time.After(outboxDequeueWait):
+			}
+			continue
+		}
+
+		delivery, err := s.queue.Dequeue(ctx, outboxDequeueWait)
+		if err != nil {
+			if ctx.Err() != nil {
+				break
+			}
+			workerLogger.Error("Failed to dequeue article",
+				logger.Error(err),
+			)
+			// Avoid a hot loop while Redis is unavailable
+			select {
+			case <-ctx.Done():
+			case <-time.After(outboxDequeueWait):
+			}
+			continue
+		}
+		if delivery == nil {
+			continue
+		}
+		s.handleDelivery(ctx, workerLogger, delivery)
+	}
+
+	workerLogger.Debug("Posting worker stopped")
+}
+
+// handleDelivery posts a dequeued article and acknowledges or returns it to the queue.
+// Articles of a paused city, and posts failing because Drupal is in maintenance or rejects
+// the credentials, are requeued without counting an attempt.
+// The group quota taken at enqueue is released when the article is acknowledged without
+// being posted, or dead-lettered; items returned for a retry keep it.
+func (s *Service) handleDelivery(ctx context.Context, workerLogger logger.Logger, delivery *outbox.Delivery) {
+	item := delivery.Item
+	article := &item.Article
+
+	stopExtending := s.extendDelivery(ctx, workerLogger, delivery)
+	defer stopExtending()
+
+	cityCfg, ok := s.cityByName(item.City)
+	if !ok {
+		workerLogger.Warn("Dropping queued article for unknown city",
+			logger.String("article_id", article.ID),
+			logger.String("city", item.City),
+		)
+		s.releaseItemQuota(ctx, item)
+		s.ackDelivery(ctx, workerLogger, delivery)
+		return
+	}
+	cityCfg, err := s.resolveGroup(cityCfg)
+	if err != nil {
+		// Retried until the group resolves or attempts run out, rather than posted without a group
+		workerLogger.Warn("Queued article's city group not resolved",
+			logger.String("article_id", article.ID),
+			logger.String("city", item.City),
+			logger.Error(err),
+		)
+		s.nackDelivery(ctx, workerLogger, delivery)
+		return
+	}
+	ctx = logger.WithContext(ctx, workerLogger.With(logger.String("city", cityCfg.Name)))
+
+	// The workers only idle for a global pause; a paused city's articles wait in the queue
+	if s.paused(ctx, cityCfg.Name) {
+		s.requeueDelivery(ctx, workerLogger, delivery, pausedCityRequeueDelay)
+		return
+	}
+
+	// Another worker or an inline run may have posted it since it was queued
+	dedupCtx, dedupCancel := context.WithTimeout(ctx, redisTimeout)
+	alreadyPosted := s.hasPosted(dedupCtx, article.ID)
+	dedupCancel()
+	if alreadyPosted {
+		s.stats.record(duplicateAlreadyPosted, article)
+		s.releaseItemQuota(ctx, item)
+		s.ackDelivery(ctx, workerLogger, delivery)
+		return
+	}
+	// Or been suppressed, e.g. after editors deleted an earlier post of it
+	if s.suppressedArticle(ctx, cityCfg, article) {
+		s.releaseItemQuota(ctx, item)
+		s.ackDelivery(ctx, workerLogger, delivery)
+		return
+	}
+
+	if err := s.waitForRateLimit(ctx, cityCfg, article); err != nil {
+		// Shutting down; leave the item in-flight so Recover picks it up once it goes idle
+		return
+	}
+
+	reserved, err := s.reserveArticle(ctx, cityCfg, article)
+	if err != nil {
+		s.nackDelivery(ctx, workerLogger, delivery)
+		return
+	}
+	if !reserved {
+		// Held by another worker; if it crashes, the lease expires and discovery requeues the article
+		s.releaseItemQuota(ctx, item)
+		s.ackDelivery(ctx, workerLogger, delivery)
+		return
+	}
+
+	postDuration, postErr := s.postArticle(ctx, cityCfg, article)
+	if postErr != nil {
+		s.releaseArticle(ctx, cityCfg, article)
+		if errors.Is(postErr, drupal.ErrMaintenance) || errors.Is(postErr, drupal.ErrUnauthorized) {
+			// Not the article's fault; workers wait for the site or the credentials before dequeueing again
+			s.requeueDelivery(ctx, workerLogger, delivery, 0)
+			return
+		}
+		s.nackDelivery(ctx, workerLogger, delivery)
+		return
+	}
+	s.markPosted(ctx, cityCfg, article)
+	s.ackDelivery(ctx, workerLogger, delivery)
+
+	workerLogger.Info("Posted article",
+		logger.String("title", article.Title),
+		logger.String("city", cityCfg.Name),
+		logger.String("article_id", article.ID),
+		logger.String("url", article.URL),
+		logger.Duration("post_duration", postDuration),
+		logger.Duration("queue_latency", time.Since(item.EnqueuedAt)),
+		logger.Int("attempt", item.Attempts+1),
+	)
+}
+
+// extendDelivery keeps the delivery from being recovered as abandoned while the worker
+// holds it, extending it every third of outbox.claim_idle until the returned stop is called.
+func (s *Service) extendDelivery(ctx context.Context, workerLogger logger.Logger, delivery *outbox.Delivery) (stop func()) {
+	interval := s.config.Outbox.ClaimIdle / 3
+	if interval <= 0 {
+		return func() {}
+	}
+
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			extendCtx, extendCancel := context.WithTimeout(ctx, redisTimeout)
+			err := s.queue.Extend(extendCtx, delivery)
+			extendCancel()
+			if err != nil {
+				workerLogger.Warn("Failed to extend outbox item",
+					logger.String("article_id", delivery.Item.Article.ID),
+					logger.Error(err),
+				)
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+func (s *Service) ackDelivery(ctx context.Context, workerLogger logger.Logger, delivery *outbox.Delivery) {
+	// Use a fresh context so shutdown doesn't strand a completed item in-flight
+	ackCtx, ackCancel := context.WithTimeout(context.WithoutCancel(ctx), redisTimeout)
+	defer ackCancel()
+
+	if err := s.queue.Ack(ackCtx, delivery); err != nil {
+		workerLogger.Warn("Failed to acknowledge outbox item",
+			logger.String("article_id", delivery.Item.Article.ID),
+			logger.Error(err),
+		)
+	}
+}
+
+func (s *Service) nackDelivery(ctx context.Context, workerLogger logger.Logger, delivery *outbox.Delivery) {
+	nackCtx, nackCancel := context.WithTimeout(context.WithoutCancel(ctx), redisTimeout)
+	defer nackCancel()
+
+	delay := s.retryDelay(delivery.Item.Attempts + 1)
+	dead, err := s.queue.Nack(nackCtx, delivery, s.config.Outbox.MaxAttempts, delay)
+	switch {
+	case err != nil:
+		workerLogger.Warn("Failed to return outbox item to queue",
+			logger.String("article_id", delivery.Item.Article.ID),
+			logger.Error(err),
+		)
+	case dead:
+		workerLogger.Error("Article moved to dead-letter queue",
+			logger.String("article_id", delivery.Item.Article.ID),
+			logger.String("city", delivery.Item.City),
+			logger.Int("attempts", delivery.Item.Attempts+1),
+		)
+		s.releaseItemQuota(nackCtx, delivery.Item)
+	default:
+		workerLogger.Debug("Article returned to queue for retry",
+			logger.String("article_id", delivery.Item.Article.ID),
+			logger.Int("attempts", delivery.Item.Attempts+1),
+			logger.Duration("retry_in", delay),
+		)
+	}
+}
+
+// requeueDelivery returns an item that could not be posted for reasons other than the
+// article, e.g. Drupal maintenance or rejected credentials, without counting an attempt against it.
+func (s *Service) requeueDelivery(ctx context.Context, workerLogger logger.Logger, delivery *outbox.Delivery, delay time.Duration) {
+	requeueCtx, requeueCancel := context.WithTimeout(context.WithoutCancel(ctx), redisTimeout)
+	defer requeueCancel()
+
+	if err := s.queue.Requeue(requeueCtx, delivery, delay); err != nil {
+		workerLogger.Warn("Failed to return outbox item to queue",
+			logger.String("article_id", delivery.Item.Article.ID),
+			logger.Error(err),
+		)
+		return
+	}
+	workerLogger.Debug("Article returned to queue",
+		logger.String("article_id", delivery.Item.Article.ID),
+		logger.Duration("retry_in", delay),
+	)
+}
+
+// retryDelay is how long an item waits after its nth failed attempt: outbox.retry_backoff,
+// doubled per earlier attempt and capped at outbox.max_retry_backoff.
+func (s *Service) retryDelay(attempts int) time.Duration {
+	delay := s.config.Outbox.RetryBackoff
+	for i := 1; i < attempts && delay < s.config.Outbox.MaxRetryBackoff; i++ {
+		delay = min(2*delay, s.config.Outbox.MaxRetryBackoff)
+	}
+	return delay
+}
+
+// releaseItemQuota gives back the group quota counted for a queued article that will not
+// be posted.
+func (s *Service) releaseItemQuota(ctx context.Context, item outbox.Item) {
+	s.releaseQuota(context.WithoutCancel(ctx), config.CityConfig{Name: item.City, GroupID: item.QuotaGroup}, item.QuotaDay)
+}
+
+// cityByName looks up a configured or discovered city.
+func (s *Service) cityByName(name string) (config.CityConfig, bool) {
+	for _, cityCfg := range s.knownCities() {
+		if cityCfg.Name == name {
+			return cityCfg, true
+		}
+	}
+	return config.CityConfig{}, false
+}
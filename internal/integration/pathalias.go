@@ -0,0 +1,89 @@
+package integration
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/gopost/integration/internal/config"
+	"github.com/gopost/integration/internal/logger"
+	"github.com/gopost/integration/pkg/drupal"
+	"github.com/gopost/integration/pkg/pipeline"
+)
+
+// maxSlugLength keeps slugs of long titles to a readable length, well within Drupal's
+// 255-character aliases.
+const maxSlugLength = 80
+
+// pathAliasData is what service.path_alias templates can refer to.
+type pathAliasData struct {
+	City      string
+	Title     string
+	ID        string // The article's Elasticsearch ID
+	Source    string
+	Section   string
+	Category  string
+	Published time.Time
+}
+
+// pathAliasFuncs are available to path alias templates in addition to the text/template builtins.
+var pathAliasFuncs = template.FuncMap{
+	// slug turns text into a path segment, e.g. {{slug .Title}}
+	"slug": func(text string) string { return pipeline.Slugify(text, maxSlugLength) },
+}
+
+// parsePathAlias compiles the service.path_alias template and checks it only refers to
+// pathAliasData fields. It returns nil when no alias is configured.
+func parsePathAlias(text string) (*template.Template, error) {
+	if text == "" {
+		return nil, nil
+	}
+	tmpl, err := template.New("path_alias").Funcs(pathAliasFuncs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("service.path_alias: %w", err)
+	}
+	if err := tmpl.Execute(io.Discard, pathAliasData{}); err != nil {
+		return nil, fmt.Errorf("service.path_alias: %w", err)
+	}
+	return tmpl, nil
+}
+
+// applyPathAlias sets the URL alias of the node posted for article. Aliases that fail to
+// render, or render to nothing but slashes (such as a title with no Latin letters or
+// digits), are left out so Drupal falls back to /node/{id} or its Pathauto pattern.
+func (s *Service) applyPathAlias(req *drupal.ArticleRequest, cityCfg config.CityConfig, article *pipeline.Article) {
+	if s.pathAlias == nil {
+		return
+	}
+	var alias strings.Builder
+	err := s.pathAlias.Execute(&alias, pathAliasData{
+		City:      cityCfg.Name,
+		Title:     article.Title,
+		ID:        article.ID,
+		Source:    article.Source,
+		Section:   article.Section,
+		Category:  article.Category,
+		Published: article.PublishedAt,
+	})
+	if err != nil {
+		s.logger.Warn("Failed to render path alias",
+			logger.String("article_id", article.ID),
+			logger.Error(err),
+		)
+		return
+	}
+	path := cleanAlias(alias.String())
+	if strings.Trim(path, "/") == "" {
+		return
+	}
+	req.PathAlias = path
+}
+
+// cleanAlias collapses the empty segments an empty template value leaves, so
+// "/crime//robbery/" becomes "/crime/robbery".
+func cleanAlias(alias string) string {
+	segments := strings.FieldsFunc(alias, func(r rune) bool { return r == '/' })
+	return "/" + strings.Join(segments, "/")
+}
@@ -0,0 +1,68 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/gopost/integration/internal/logger"
+)
+
+// errNoPauseSwitch is returned by Pause and Resume when the service has no pause switch.
+var errNoPauseSwitch = errors.New("pause switch unavailable")
+
+// paused reports whether posting is paused globally or for city. Without a pause switch,
+// or when Redis cannot be read, posting continues.
+func (s *Service) paused(ctx context.Context, city string) bool {
+	if s.pauses == nil {
+		return false
+	}
+
+	pauseCtx, cancel := context.WithTimeout(ctx, redisTimeout)
+	defer cancel()
+	paused, err := s.pauses.Paused(pauseCtx, city)
+	if err != nil {
+		s.logger.Warn("Failed to check pause flags, continuing",
+			logger.String("city", city),
+			logger.Error(err),
+		)
+		return false
+	}
+	return paused
+}
+
+// Pause halts posting for city, or for all cities when city is empty, on every replica
+// sharing the Redis database.
+func (s *Service) Pause(ctx context.Context, city, reason string) error {
+	if s.pauses == nil {
+		return errNoPauseSwitch
+	}
+	if city != "" {
+		if _, ok := s.cityByName(city); !ok {
+			return fmt.Errorf("pause: unknown city %q", city)
+		}
+	}
+	return s.pauses.Pause(ctx, city, reason)
+}
+
+// Resume clears a pause set by Pause or directly in Redis.
+func (s *Service) Resume(ctx context.Context, city string) error {
+	if s.pauses == nil {
+		return errNoPauseSwitch
+	}
+	return s.pauses.Resume(ctx, city)
+}
+
+// Pause pauses city in the tenants configuring it, or every tenant when city is empty.
+func (g *Group) Pause(ctx context.Context, city, reason string) error {
+	return g.eachTenantWithCity(city, func(service *Service) error {
+		return service.Pause(ctx, city, reason)
+	})
+}
+
+// Resume resumes city in the tenants configuring it, or every tenant when city is empty.
+func (g *Group) Resume(ctx context.Context, city string) error {
+	return g.eachTenantWithCity(city, func(service *Service) error {
+		return service.Resume(ctx, city)
+	})
+}
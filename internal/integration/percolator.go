@@ -0,0 +1,125 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/gopost/integration/internal/config"
+	"github.com/gopost/integration/internal/es"
+	"github.com/gopost/integration/internal/logger"
+	"github.com/gopost/integration/pkg/pipeline"
+)
+
+// criteriaQuery returns the query a city's articles must match, without the search window:
+// the query member of its rendered query template, or the keyword search.
+func (s *Service) criteriaQuery(cityCfg config.CityConfig) (any, error) {
+	tmpl, ok := s.templates[cityCfg.Name]
+	if !ok {
+		return es.Bool{Must: []es.Query{s.keywordClause(s.crimeKeywords(cityCfg))}}.Source(), nil
+	}
+
+	rendered, err := s.renderQuery(tmpl, cityCfg, searchWindow{})
+	if err != nil {
+		return nil, err
+	}
+	query, ok := rendered["query"]
+	if !ok {
+		return nil, fmt.Errorf("query template %s has no query member", cityCfg.QueryTemplate)
+	}
+	return query, nil
+}
+
+// registerQueries stores every known city's criteria in the percolator index when
+// percolator.register is set. Failures are logged; the previously stored query stays.
+func (s *Service) registerQueries(ctx context.Context) {
+	if !s.config.Percolator.Enabled || !s.config.Percolator.Register {
+		return
+	}
+
+	registered := 0
+	for _, cityCfg := range s.refreshCities(ctx) {
+		query, err := s.criteriaQuery(cityCfg)
+		if err == nil {
+			saveCtx, cancel := context.WithTimeout(ctx, esQueryTimeout)
+			err = s.queries.SaveQuery(saveCtx, s.config.Percolator.Index, cityCfg.Name, query, cityIndex(cityCfg))
+			cancel()
+		}
+		if err != nil {
+			s.logger.Warn("Failed to register city query",
+				logger.String("city", cityCfg.Name),
+				logger.String("percolator_index", s.config.Percolator.Index),
+				logger.Error(err),
+			)
+			continue
+		}
+		registered++
+	}
+	s.logger.Info("Registered city queries",
+		logger.String("percolator_index", s.config.Percolator.Index),
+		logger.Int("city_count", registered),
+	)
+}
+
+// percolate keeps the articles matching the query stored for the city in the percolator
+// index. Articles are percolated as gopost decodes them, so the stored query can only use
+// fields of pipeline.Article. Without the percolator, articles are returned unchanged.
+func (s *Service) percolate(ctx context.Context, cityCfg config.CityConfig, articles []pipeline.Article) ([]pipeline.Article, error) {
+	if !s.config.Percolator.Enabled || len(articles) == 0 {
+		return articles, nil
+	}
+
+	query := map[string]any{
+		"size":    1,
+		"_source": false,
+		"query": map[string]any{
+			"bool": map[string]any{
+				"filter": []map[string]any{
+					{"ids": map[string]any{"values": []string{cityCfg.Name}}},
+					{"percolate": map[string]any{"field": "query", "documents": articles}},
+				},
+			},
+		},
+	}
+
+	percolateCtx, cancel := context.WithTimeout(ctx, esQueryTimeout)
+	defer cancel()
+	result, err := s.source.Search(percolateCtx, s.config.Percolator.Index, query)
+	if err != nil {
+		s.logger.Error("Percolate search failed",
+			logger.String("city", cityCfg.Name),
+			logger.String("percolator_index", s.config.Percolator.Index),
+			logger.Error(err),
+		)
+		return nil, fmt.Errorf("percolate: %w", err)
+	}
+
+	// The stored query is only returned when it matched at least one article
+	matched := make(map[int]bool)
+	for _, hit := range result.Hits {
+		if hit.ID != cityCfg.Name {
+			continue
+		}
+		var slots []int
+		if err := json.Unmarshal(hit.Fields[pipeline.PercolatorSlotField], &slots); err != nil {
+			return nil, errors.New("percolate: response has no document slots")
+		}
+		for _, slot := range slots {
+			matched[slot] = true
+		}
+	}
+
+	kept := make([]pipeline.Article, 0, len(matched))
+	for i, article := range articles {
+		if matched[i] {
+			kept = append(kept, article)
+		}
+	}
+	s.logger.Debug("Percolated articles",
+		logger.String("city", cityCfg.Name),
+		logger.Int("article_count", len(articles)),
+		logger.Int("matched", len(kept)),
+	)
+	return kept, nil
+}
@@ -0,0 +1,424 @@
+package integration
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gopost/integration/internal/config"
+	"github.com/gopost/integration/internal/logger"
+	"github.com/gopost/integration/internal/timeline"
+	"github.com/gopost/integration/pkg/drupal"
+	"github.com/gopost/integration/pkg/pipeline"
+)
+
+// minFingerprintWords is the shortest body fingerprinted for near-duplicate detection;
+// shorter texts share too many shingles by chance.
+const minFingerprintWords = 30
+
+// titleKeyPrefix namespaces normalized-title hashes among article IDs in the dedup store.
+const titleKeyPrefix = "title:"
+
+// articleRequest builds the Drupal request for an article routed to a city.
+func (s *Service) articleRequest(cityCfg config.CityConfig, article *pipeline.Article) drupal.ArticleRequest {
+	// Derive OG fields from canonical fields if not present (DRY principle)
+	// After crawler refactor: OG fields are only stored in ES if they differ from canonical values.
+	// If present in ES, use them; otherwise derive from canonical fields.
+	ogTitle := article.OGTitle
+	if ogTitle == "" {
+		ogTitle = article.Title
+	}
+	ogDescription := article.OGDescription
+	if ogDescription == "" {
+		// Prefer description, fallback to intro
+		if article.Description != "" {
+			ogDescription = article.Description
+		} else {
+			ogDescription = article.Intro
+		}
+	}
+	ogURL := article.OGURL
+	if ogURL == "" {
+		// Prefer canonical_url, fallback to source
+		if article.URL != "" {
+			ogURL = article.URL
+		} else {
+			ogURL = article.Source
+		}
+	}
+
+	title := article.Title
+	if s.config.Service.Titles.CleanPosted {
+		title = s.titles.StripPrefixes(title)
+		if article.OGTitle == "" {
+			ogTitle = title
+		}
+	}
+
+	req := drupal.ArticleRequest{
+		Title:          title,
+		Body:           article.Content,
+		URL:            article.URL,
+		GroupID:        cityCfg.GroupID,
+		GroupType:      s.config.Service.GroupType,
+		ContentType:    s.config.Service.ContentType,
+		ExternalID:     cmp.Or(article.ExternalID, article.ID),
+		Intro:          article.Intro,
+		Description:    article.Description,
+		OGTitle:        ogTitle,
+		OGDescription:  ogDescription,
+		OGImage:        article.OGImage, // og_image is unique, not duplicated
+		OGURL:          ogURL,
+		WordCount:      article.WordCount,
+		Category:       article.Category,
+		Section:        article.Section,
+		Keywords:       article.Keywords,
+		CanonicalURL:   article.URL, // canonical_url is the same as URL in our case
+		PublishedDate:  article.PublishedAt,
+		Language:       cityCfg.Language,
+		LanguagePrefix: cityCfg.EndpointPrefix(),
+	}
+	s.applyAttribution(&req, article)
+	s.applyCategories(&req, article)
+	s.applyFieldMap(&req, article)
+	s.applyRevisionLog(&req, cityCfg, article)
+	s.applyPromotion(&req, cityCfg, article)
+	s.applyPathAlias(&req, cityCfg, article)
+	return req
+}
+
+// waitForRateLimit blocks until the limiter admits another post.
+func (s *Service) waitForRateLimit(ctx context.Context, cityCfg config.CityConfig, article *pipeline.Article) error {
+	rateLimitStartTime := time.Now()
+	if err := s.limiter.Wait(ctx); err != nil {
+		s.logger.Error("Rate limit wait failed",
+			logger.String("article_id", article.ID),
+			logger.String("city", cityCfg.Name),
+			logger.Error(err),
+		)
+		return err
+	}
+
+	s.logger.Debug("Rate limit wait completed",
+		logger.String("article_id", article.ID),
+		logger.String("city", cityCfg.Name),
+		logger.Duration("rate_limit_wait_duration", time.Since(rateLimitStartTime)),
+	)
+	return nil
+}
+
+// postArticle posts an article to Drupal (with timeout) and returns the post duration.
+// Failures are logged here; callers only decide whether to count or retry them.
+func (s *Service) postArticle(ctx context.Context, cityCfg config.CityConfig, article *pipeline.Article) (time.Duration, error) {
+	ctx = logger.WithContext(ctx, logger.FromContext(ctx).With(logger.String("article_id", article.ID)))
+
+	// Translated before the post timeout starts, since translation APIs have their own
+	translated, err := s.translateArticle(ctx, cityCfg, article)
+	if err != nil {
+		s.logger.Error("Error translating article",
+			logger.String("article_id", article.ID),
+			logger.String("city", cityCfg.Name),
+			logger.String("title", article.Title),
+			logger.Error(err),
+		)
+		s.recordStage(ctx, cityCfg, article, timeline.StageFailed, err.Error())
+		return 0, err
+	}
+	source := article
+	article = translated
+	postCtx, postCancel := context.WithTimeout(ctx, drupalPostTimeout)
+	defer postCancel()
+
+	req := s.articleRequest(cityCfg, article)
+	s.runArticleHooks(ctx, config.HookPrePost, cityCfg, article, "", nil)
+	postStartTime := time.Now()
+	nodeID, postErr := s.createArticle(postCtx, req)
+	postDuration := time.Since(postStartTime)
+	if postErr != nil {
+		// Use a fresh context so hooks still learn of posts cut short by shutdown
+		s.runArticleHooks(context.WithoutCancel(ctx), config.HookPostFailure, cityCfg, article, "", postErr)
+		s.recordStage(ctx, cityCfg, article, timeline.StageFailed, postErr.Error())
+	}
+	if errors.Is(postErr, drupal.ErrMaintenance) {
+		// Logged once by enterMaintenance rather than for every article
+		s.enterMaintenance()
+		return postDuration, postErr
+	}
+	if errors.Is(postErr, drupal.ErrUnauthorized) {
+		// Logged once by rejectCredentials, as the error to alert on
+		s.rejectCredentials(postErr)
+		return postDuration, postErr
+	}
+	if postErr != nil {
+		fields := []logger.Field{
+			logger.String("article_id", article.ID),
+			logger.String("city", cityCfg.Name),
+			logger.String("title", article.Title),
+			logger.String("url", article.URL),
+			logger.Duration("post_duration", postDuration),
+			logger.Error(postErr),
+		}
+		// Point validation errors at the settings that produced the rejected fields
+		var apiErr *drupal.APIError
+		if errors.As(postErr, &apiErr) {
+			if hints := s.fieldHints(apiErr); len(hints) > 0 {
+				fields = append(fields, logger.Strings("hints", hints))
+			}
+		}
+		s.logger.Error("Error posting article", fields...)
+		return postDuration, postErr
+	}
+	s.leaveMaintenance()
+	s.acceptCredentials()
+	s.auditPost(ctx, cityCfg, article, req, nodeID, postDuration)
+	s.recordStage(ctx, cityCfg, article, timeline.StagePosted, nodeID)
+	s.recordPost(ctx, cityCfg, source, req.ContentType, nodeID)
+	s.runArticleHooks(context.WithoutCancel(ctx), config.HookPostSuccess, cityCfg, article, nodeID, nil)
+	return postDuration, nil
+}
+
+// createArticle posts req and returns the UUID of the created node, or "" when the poster
+// does not report it.
+func (s *Service) createArticle(ctx context.Context, req drupal.ArticleRequest) (string, error) {
+	if creator, ok := s.poster.(pipeline.NodeCreator); ok {
+		return creator.CreateArticle(ctx, req)
+	}
+	return "", s.poster.PostArticle(ctx, req)
+}
+
+// reserveArticle claims the article in the dedup store before posting (with timeout).
+// It returns false if another worker holds or has completed the article. Errors are
+// returned rather than treated as "not posted" so an unreachable Redis cannot cause
+// duplicate posts.
+func (s *Service) reserveArticle(ctx context.Context, cityCfg config.CityConfig, article *pipeline.Article) (bool, error) {
+	if s.unmarked.Has(article.ID) {
+		s.logger.Debug("Article skipped - posted, marker waiting for retry",
+			logger.String("article_id", article.ID),
+			logger.String("city", cityCfg.Name),
+		)
+		return false, nil
+	}
+
+	reserveCtx, reserveCancel := context.WithTimeout(ctx, redisTimeout)
+	defer reserveCancel()
+
+	reserved, err := s.dedup.Reserve(reserveCtx, article.ID)
+	if err != nil {
+		s.logger.Error("Failed to reserve article for posting",
+			logger.String("article_id", article.ID),
+			logger.String("city", cityCfg.Name),
+			logger.Error(err),
+		)
+		return false, err
+	}
+	if !reserved {
+		s.stats.record(duplicateReserveConflict, article)
+		s.logger.Debug("Article skipped - reserved or posted by another worker",
+			logger.String("article_id", article.ID),
+			logger.String("city", cityCfg.Name),
+		)
+	}
+	return reserved, nil
+}
+
+// releaseArticle drops the article's reservation after a failed post so a later
+// attempt can claim it. Failures are logged; the lease expires on its own.
+func (s *Service) releaseArticle(ctx context.Context, cityCfg config.CityConfig, article *pipeline.Article) {
+	releaseCtx, releaseCancel := context.WithTimeout(context.WithoutCancel(ctx), redisTimeout)
+	defer releaseCancel()
+
+	if err := s.dedup.Release(releaseCtx, article.ID); err != nil {
+		s.logger.Warn("Failed to release article reservation",
+			logger.String("article_id", article.ID),
+			logger.String("city", cityCfg.Name),
+			logger.Error(err),
+		)
+	}
+}
+
+// markPosted promotes the article's reservation to posted in the dedup store (with timeout).
+// Failures are logged but not returned: the article was posted either way. Failed markers
+// go to the mark journal, which counts them as posted until retryMarks writes them.
+func (s *Service) markPosted(ctx context.Context, cityCfg config.CityConfig, article *pipeline.Article) {
+	// Use a fresh context so shutdown right after a post still records it
+	markCtx, markCancel := context.WithTimeout(context.WithoutCancel(ctx), redisTimeout)
+	defer markCancel()
+
+	markStartTime := time.Now()
+	if markErr := s.dedup.MarkPosted(markCtx, article.ID); markErr != nil {
+		s.logger.Warn("Failed to mark article as posted; retrying later",
+			logger.String("article_id", article.ID),
+			logger.String("city", cityCfg.Name),
+			logger.Duration("mark_duration", time.Since(markStartTime)),
+			logger.Error(markErr),
+		)
+		// Redis is likely unreachable, so the title marker waits with the article's
+		s.deferMark(cityCfg, article.ID)
+		if key := s.titleDedupKey(article); key != "" {
+			s.deferMark(cityCfg, key)
+		}
+		return
+	}
+
+	s.logger.Debug("Article marked as posted",
+		logger.String("article_id", article.ID),
+		logger.String("city", cityCfg.Name),
+		logger.Duration("mark_duration", time.Since(markStartTime)),
+	)
+	s.recordStage(ctx, cityCfg, article, timeline.StageMarked, "")
+	s.recordNewestPosted(markCtx, cityCfg, article)
+
+	if fingerprint, ok := s.bodyFingerprint(article); ok {
+		if addErr := s.nearDups.Add(markCtx, article.ID, fingerprint); addErr != nil {
+			s.logger.Warn("Failed to record article fingerprint",
+				logger.String("article_id", article.ID),
+				logger.String("city", cityCfg.Name),
+				logger.Error(addErr),
+			)
+		}
+	}
+
+	if key := s.titleDedupKey(article); key != "" {
+		if markErr := s.dedup.MarkPosted(markCtx, key); markErr != nil {
+			s.logger.Warn("Failed to mark article title as posted; retrying later",
+				logger.String("article_id", article.ID),
+				logger.String("city", cityCfg.Name),
+				logger.Error(markErr),
+			)
+			s.deferMark(cityCfg, key)
+		}
+	}
+}
+
+// titleDedupKey returns the dedup key for the article's normalized title, or "" when
+// title dedup is disabled or the title is empty.
+func (s *Service) titleDedupKey(article *pipeline.Article) string {
+	if !s.config.Service.Titles.Dedup {
+		return ""
+	}
+	key := s.titles.Key(article.Title)
+	if key == "" {
+		return ""
+	}
+	return titleKeyPrefix + key
+}
+
+// titlePosted reports whether an article with the same normalized title was already
+// posted, or is being posted by one of the city's running posts in window.
+func (s *Service) titlePosted(ctx context.Context, cityCfg config.CityConfig, article *pipeline.Article, window *postWindow) bool {
+	key := s.titleDedupKey(article)
+	if key == "" {
+		return false
+	}
+	if post := window.withTitle(key); post != nil {
+		s.logger.Debug("Article skipped - title being posted",
+			logger.String("article_id", article.ID),
+			logger.String("city", cityCfg.Name),
+			logger.String("title", article.Title),
+			logger.String("duplicate_of", post.article.ID),
+		)
+		return true
+	}
+
+	dedupCtx, dedupCancel := context.WithTimeout(ctx, redisTimeout)
+	defer dedupCancel()
+	if !s.hasPosted(dedupCtx, key) {
+		return false
+	}
+
+	s.logger.Debug("Article skipped - title already posted",
+		logger.String("article_id", article.ID),
+		logger.String("city", cityCfg.Name),
+		logger.String("title", article.Title),
+		logger.String("normalized_title", s.titles.Normalize(article.Title)),
+	)
+	return true
+}
+
+// bodyFingerprint returns the SimHash of the article body when near-duplicate suppression
+// is enabled and the body is long enough to fingerprint reliably.
+func (s *Service) bodyFingerprint(article *pipeline.Article) (uint64, bool) {
+	if s.nearDups == nil {
+		return 0, false
+	}
+	text := pipeline.NormalizeText(article.Content)
+	if len(strings.Fields(text)) < minFingerprintWords {
+		return 0, false
+	}
+	return pipeline.SimHash(text), true
+}
+
+// nearDuplicateOf reports whether the article body closely matches a recently posted one,
+// or one being posted by the city's running posts in window. Lookup failures are logged
+// and treated as no match.
+func (s *Service) nearDuplicateOf(ctx context.Context, cityCfg config.CityConfig, article *pipeline.Article, window *postWindow) bool {
+	fingerprint, ok := s.bodyFingerprint(article)
+	if !ok {
+		return false
+	}
+	if post, similarity := window.similarTo(fingerprint, s.nearDups.Similarity); post != nil {
+		s.logger.Info("Article skipped - near-duplicate of article being posted",
+			logger.String("article_id", article.ID),
+			logger.String("city", cityCfg.Name),
+			logger.String("title", article.Title),
+			logger.String("source", article.Source),
+			logger.String("duplicate_of", post.article.ID),
+			logger.Float64("similarity", similarity),
+		)
+		return true
+	}
+
+	lookupCtx, lookupCancel := context.WithTimeout(ctx, redisTimeout)
+	defer lookupCancel()
+	match, err := s.nearDups.FindSimilar(lookupCtx, fingerprint)
+	if err != nil {
+		s.logger.Warn("Near-duplicate lookup failed",
+			logger.String("article_id", article.ID),
+			logger.String("city", cityCfg.Name),
+			logger.Error(err),
+		)
+		return false
+	}
+	if match == nil || match.ArticleID == article.ID {
+		return false
+	}
+
+	s.logger.Info("Article skipped - near-duplicate of posted article",
+		logger.String("article_id", article.ID),
+		logger.String("city", cityCfg.Name),
+		logger.String("title", article.Title),
+		logger.String("source", article.Source),
+		logger.String("duplicate_of", match.ArticleID),
+		logger.Float64("similarity", match.Similarity),
+	)
+	return true
+}
+
+// sortArticles orders articles by published date, newest or oldest first, or by severity
+// score and then newest first. Articles without a published date go last among equals;
+// ties keep their search order.
+func sortArticles(articles []pipeline.Article, order string) {
+	slices.SortStableFunc(articles, func(a, b pipeline.Article) int {
+		if order == config.PostOrderSeverity && a.Severity != b.Severity {
+			return cmp.Compare(b.Severity, a.Severity)
+		}
+		if a.PublishedAt.IsZero() || b.PublishedAt.IsZero() {
+			return cmp.Compare(boolRank(a.PublishedAt.IsZero()), boolRank(b.PublishedAt.IsZero()))
+		}
+		if order == config.PostOrderOldestFirst {
+			return a.PublishedAt.Compare(b.PublishedAt)
+		}
+		return b.PublishedAt.Compare(a.PublishedAt)
+	})
+}
+
+// boolRank sorts false before true.
+func boolRank(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,161 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/gopost/integration/internal/config"
+	"github.com/gopost/integration/internal/logger"
+	"github.com/gopost/integration/pkg/drupal"
+	"github.com/gopost/integration/pkg/pipeline"
+)
+
+// pendingPost is a Drupal post of a reserved article, started by processArticles.
+type pendingPost struct {
+	article  *pipeline.Article
+	index    int    // Position of the article in the city's articles
+	quotaDay string // Group quota day taken for the article, released if the post fails
+	started  time.Time
+
+	// Dedup keys of the story, taken before the post starts since posting may rewrite
+	// the article (e.g. translation); checked so a copy is not posted alongside it
+	titleKey      string
+	fingerprint   uint64
+	fingerprinted bool
+
+	done     chan struct{} // Closed when duration and err are set
+	duration time.Duration
+	err      error
+}
+
+// postWindow runs up to size posts of one city at once and hands them back in the order
+// they were started, so results are recorded and dedup markers promoted in post_order
+// even when a later post finishes first. With size 1 posts run inline, one at a time.
+type postWindow struct {
+	size    int
+	pending []*pendingPost
+}
+
+// start runs post, in the background unless the window has size 1. When the window is
+// full it first waits for the oldest post. It returns the posts that have finished,
+// oldest first, for the caller to record.
+func (w *postWindow) start(post *pendingPost, run func(*pendingPost)) []*pendingPost {
+	if w.size <= 1 {
+		run(post)
+		return []*pendingPost{post}
+	}
+	var finished []*pendingPost
+	if len(w.pending) >= w.size {
+		finished = append(finished, w.pop())
+	}
+	post.done = make(chan struct{})
+	go func() {
+		defer close(post.done)
+		run(post)
+	}()
+	w.pending = append(w.pending, post)
+	// Hand back posts that have already finished, without waiting for the others
+	for len(w.pending) > 0 && w.finished(w.pending[0]) {
+		finished = append(finished, w.pop())
+	}
+	return finished
+}
+
+// drain waits for every post still running and returns them, oldest first.
+func (w *postWindow) drain() []*pendingPost {
+	var finished []*pendingPost
+	for len(w.pending) > 0 {
+		finished = append(finished, w.pop())
+	}
+	return finished
+}
+
+// pop waits for the oldest post and removes it from the window.
+func (w *postWindow) pop() *pendingPost {
+	post := w.pending[0]
+	<-post.done
+	w.pending = w.pending[1:]
+	return post
+}
+
+// withTitle returns the running post carrying the title dedup key, or nil.
+func (w *postWindow) withTitle(key string) *pendingPost {
+	for _, post := range w.pending {
+		if post.titleKey == key {
+			return post
+		}
+	}
+	return nil
+}
+
+// similarTo returns the running post whose body is most similar to fingerprint, at least
+// as similar as match requires, and its similarity; nil when there is none.
+func (w *postWindow) similarTo(fingerprint uint64, match func(a, b uint64) (float64, bool)) (*pendingPost, float64) {
+	var best *pendingPost
+	var bestSimilarity float64
+	for _, post := range w.pending {
+		if !post.fingerprinted {
+			continue
+		}
+		if similarity, ok := match(fingerprint, post.fingerprint); ok && (best == nil || similarity > bestSimilarity) {
+			best, bestSimilarity = post, similarity
+		}
+	}
+	return best, bestSimilarity
+}
+
+func (w *postWindow) finished(post *pendingPost) bool {
+	select {
+	case <-post.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// postConcurrency returns how many posts of the city run at once: the city's
+// post_concurrency, else service.post_concurrency. Warming-up cities post one at a time,
+// so the warm-up limit is never overshot by posts already in flight.
+func (s *Service) postConcurrency(cityCfg config.CityConfig, maxPosts int) int {
+	if maxPosts > 0 {
+		return 1
+	}
+	if cityCfg.PostConcurrency > 0 {
+		return cityCfg.PostConcurrency
+	}
+	return max(s.config.Service.PostConcurrency, 1)
+}
+
+// finishPost records the result of a post. It returns false when Drupal was in
+// maintenance mode or rejected the credentials, in which case the article is released
+// and held for a later run rather than counted.
+func (s *Service) finishPost(ctx context.Context, cityCfg config.CityConfig, run *runScope, report *CityResult, post *pendingPost, total int) bool {
+	article := post.article
+	if post.err != nil {
+		s.releaseArticle(ctx, cityCfg, article)
+		s.releaseQuota(ctx, cityCfg, post.quotaDay)
+		if errors.Is(post.err, drupal.ErrMaintenance) || errors.Is(post.err, drupal.ErrUnauthorized) {
+			return false
+		}
+		report.record(article, ArticleFailed, "post: "+post.err.Error())
+		return true
+	}
+	s.markPosted(ctx, cityCfg, article)
+	s.markProcessed(ctx, run, article)
+
+	report.record(article, ArticlePosted, "")
+	report.PostTime += post.duration
+	report.addHighlights(article)
+	s.logger.Info("Posted article",
+		logger.String("title", article.Title),
+		logger.String("city", cityCfg.Name),
+		logger.String("article_id", article.ID),
+		logger.String("url", article.URL),
+		logger.Duration("post_duration", post.duration),
+		logger.Duration("article_processing_duration", time.Since(post.started)),
+		logger.Int("article_index", post.index+1),
+		logger.Int("total_articles", total),
+	)
+	return true
+}
@@ -0,0 +1,97 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gopost/integration/internal/config"
+	"github.com/gopost/integration/internal/logger"
+)
+
+// PreviewArticle is a crime article the next run would consider for a city.
+type PreviewArticle struct {
+	City          string    `json:"city"`
+	ID            string    `json:"id"`
+	Title         string    `json:"title"`
+	URL           string    `json:"url"`
+	PublishedAt   time.Time `json:"published_at"`
+	Highlights    []string  `json:"highlights,omitempty"` // Fragments with the matched keywords, when highlighting is enabled
+	Severity      int       `json:"severity,omitempty"`   // Severity score, when severity scoring is enabled
+	AlreadyPosted bool      `json:"already_posted"`
+}
+
+// Preview lists the articles the next run would consider for city (all configured and
+// discovered cities when empty) without posting or reserving anything: the search results
+// that pass the source filter, the classifier and the severity threshold, flagged when already posted.
+func (s *Service) Preview(ctx context.Context, city string) ([]PreviewArticle, error) {
+	cities, err := s.citiesNamed(ctx, city)
+	if err != nil {
+		return nil, fmt.Errorf("preview: %w", err)
+	}
+
+	var preview []PreviewArticle
+	for _, cityCfg := range cities {
+		articles, err := s.FindCrimeArticles(ctx, cityCfg)
+		if err != nil {
+			return preview, fmt.Errorf("preview %s: %w", cityCfg.Name, err)
+		}
+		sortArticles(articles, s.config.Service.PostOrder)
+
+		sourceFilter := s.sourceFilter(cityCfg)
+		classifier := s.classifierFor(cityCfg)
+		for i := range articles {
+			article := &articles[i]
+			if !s.normalizeURL(cityCfg, article) || !sourceFilter.Allows(*article) || !classifier.Matches(*article) ||
+				article.Severity < s.config.Service.Severity.MinScore {
+				continue
+			}
+
+			dedupCtx, cancel := context.WithTimeout(ctx, redisTimeout)
+			alreadyPosted := s.hasPosted(dedupCtx, article.ID)
+			cancel()
+
+			preview = append(preview, PreviewArticle{
+				City:          cityCfg.Name,
+				ID:            article.ID,
+				Title:         article.Title,
+				URL:           article.URL,
+				PublishedAt:   article.PublishedAt,
+				Highlights:    article.Highlights,
+				Severity:      article.Severity,
+				AlreadyPosted: alreadyPosted,
+			})
+		}
+		s.logger.Debug("City previewed",
+			logger.String("city", cityCfg.Name),
+			logger.Int("article_count", len(articles)),
+		)
+	}
+	return preview, nil
+}
+
+// citiesNamed returns the configured or discovered city named city, or every known city
+// when city is empty.
+func (s *Service) citiesNamed(ctx context.Context, city string) ([]config.CityConfig, error) {
+	known := s.refreshCities(ctx)
+	if city == "" {
+		return known, nil
+	}
+	for _, cityCfg := range known {
+		if cityCfg.Name == city {
+			return []config.CityConfig{cityCfg}, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown city %q", city)
+}
+
+// Preview previews city in the tenants configuring it, or every tenant when city is empty.
+func (g *Group) Preview(ctx context.Context, city string) ([]PreviewArticle, error) {
+	var combined []PreviewArticle
+	err := g.eachTenantWithCity(city, func(service *Service) error {
+		preview, err := service.Preview(ctx, city)
+		combined = append(combined, preview...)
+		return err
+	})
+	return combined, err
+}
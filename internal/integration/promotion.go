@@ -0,0 +1,28 @@
+package integration
+
+import (
+	"github.com/gopost/integration/internal/config"
+	"github.com/gopost/integration/pkg/drupal"
+	"github.com/gopost/integration/pkg/pipeline"
+)
+
+// applyPromotion sets the promote and sticky flags of the node posted for article from
+// service.promotion and the city's overrides.
+func (s *Service) applyPromotion(req *drupal.ArticleRequest, cityCfg config.CityConfig, article *pipeline.Article) {
+	promotion := s.config.Service.Promotion
+	req.Promote = promotionFlag(promotion.Promote, cityCfg.Promote, promotion.PromoteSeverity, article.Severity)
+	req.Sticky = promotionFlag(promotion.Sticky, cityCfg.Sticky, promotion.StickySeverity, article.Severity)
+}
+
+// promotionFlag returns true for articles reaching the severity threshold, and otherwise
+// the city's flag, falling back to the global one. nil leaves the flag to Drupal.
+func promotionFlag(global, city *bool, threshold, severity int) *bool {
+	if threshold > 0 && severity >= threshold {
+		promoted := true
+		return &promoted
+	}
+	if city != nil {
+		return city
+	}
+	return global
+}
@@ -0,0 +1,136 @@
+package integration
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/gopost/integration/internal/config"
+	"github.com/gopost/integration/pkg/pipeline"
+)
+
+// queryParams are the search parameters available to a city's query template. Since and
+// Until are RFC 3339 timestamps in service.timezone, empty when the window is open.
+type queryParams struct {
+	City         string
+	Index        string
+	Keywords     []string // service.crime_keywords
+	KeywordQuery string   // Keywords joined by spaces, as used by the built-in multi_match
+	Since        string
+	Until        string
+	Size         int // Page size; replays page through results sorted by published date, newest first
+
+	TitleField string
+	BodyField  string
+	DateField  string
+}
+
+// queryTemplateFuncs are available to query templates in addition to the text/template builtins.
+var queryTemplateFuncs = template.FuncMap{
+	// json encodes a value as JSON, e.g. {{json .Keywords}} or {{json .KeywordQuery}}
+	"json": func(v any) (string, error) {
+		encoded, err := json.Marshal(v)
+		return string(encoded), err
+	},
+}
+
+// loadQueryTemplates parses the query_template file of every city that sets one.
+func loadQueryTemplates(cities []config.CityConfig) (map[string]*template.Template, error) {
+	templates := make(map[string]*template.Template)
+	for _, cityCfg := range cities {
+		if cityCfg.QueryTemplate == "" {
+			continue
+		}
+		tmpl, err := template.New(filepath.Base(cityCfg.QueryTemplate)).
+			Funcs(queryTemplateFuncs).
+			Option("missingkey=error").
+			ParseFiles(cityCfg.QueryTemplate)
+		if err != nil {
+			return nil, fmt.Errorf("city %s query template: %w", cityCfg.Name, err)
+		}
+		templates[cityCfg.Name] = tmpl
+	}
+	return templates, nil
+}
+
+// searchQuery returns the Elasticsearch query for a city: its rendered query template, the
+// stored search template request when search_template is enabled, or the built-in keyword
+// search. With the percolator enabled it is always the built-in search.
+func (s *Service) searchQuery(cityCfg config.CityConfig, window searchWindow) (map[string]any, error) {
+	if s.config.Percolator.Enabled {
+		return s.builtinQuery(cityCfg, window), nil
+	}
+	if tmpl, ok := s.templates[cityCfg.Name]; ok {
+		return s.renderQuery(tmpl, cityCfg, window)
+	}
+	if s.stored != nil {
+		return s.storedTemplateRequest(cityCfg, window), nil
+	}
+	return s.builtinQuery(cityCfg, window), nil
+}
+
+// usesStoredTemplate reports whether searchQuery returns a stored search template request
+// for the city, to be run with SearchTemplate rather than Search.
+func (s *Service) usesStoredTemplate(cityCfg config.CityConfig) bool {
+	_, templated := s.templates[cityCfg.Name]
+	return s.stored != nil && !s.config.Percolator.Enabled && !templated
+}
+
+// storedTemplateRequest returns the search template request for a city: the template ID
+// and its params. since and until are left out while the window is open, so the template
+// can test for them with mustache sections ({{#since}}...{{/since}}).
+func (s *Service) storedTemplateRequest(cityCfg config.CityConfig, window searchWindow) map[string]any {
+	keywords := s.crimeKeywords(cityCfg)
+	params := map[string]any{
+		"city":          cityCfg.Name,
+		"index":         cityIndex(cityCfg),
+		"keywords":      keywords,
+		"keyword_query": strings.Join(keywords, " "),
+		"size":          s.searchSize(),
+		"title_field":   pipeline.ESFieldTitle,
+		"body_field":    pipeline.ESFieldBody,
+		"date_field":    pipeline.ESFieldPublishedDate,
+	}
+	if !window.since.IsZero() {
+		params["since"] = window.since.In(s.dates.Location()).Format(time.RFC3339)
+	}
+	if !window.until.IsZero() {
+		params["until"] = window.until.In(s.dates.Location()).Format(time.RFC3339)
+	}
+	return map[string]any{"id": s.config.SearchTemplate.ID, "params": params}
+}
+
+// renderQuery executes a city's query template for window.
+func (s *Service) renderQuery(tmpl *template.Template, cityCfg config.CityConfig, window searchWindow) (map[string]any, error) {
+
+	params := queryParams{
+		City:         cityCfg.Name,
+		Index:        cityIndex(cityCfg),
+		Keywords:     s.crimeKeywords(cityCfg),
+		KeywordQuery: strings.Join(s.crimeKeywords(cityCfg), " "),
+		Size:         s.searchSize(),
+		TitleField:   pipeline.ESFieldTitle,
+		BodyField:    pipeline.ESFieldBody,
+		DateField:    pipeline.ESFieldPublishedDate,
+	}
+	if !window.since.IsZero() {
+		params.Since = window.since.In(s.dates.Location()).Format(time.RFC3339)
+	}
+	if !window.until.IsZero() {
+		params.Until = window.until.In(s.dates.Location()).Format(time.RFC3339)
+	}
+
+	var rendered bytes.Buffer
+	if err := tmpl.Execute(&rendered, params); err != nil {
+		return nil, fmt.Errorf("render query template: %w", err)
+	}
+	var query map[string]any
+	if err := json.Unmarshal(rendered.Bytes(), &query); err != nil {
+		return nil, fmt.Errorf("query template %s did not render a JSON object: %w", cityCfg.QueryTemplate, err)
+	}
+	return query, nil
+}
@@ -0,0 +1,172 @@
+package integration
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/gopost/integration/internal/config"
+	"github.com/gopost/integration/internal/logger"
+	"github.com/gopost/integration/internal/quota"
+	"github.com/gopost/integration/pkg/pipeline"
+)
+
+// quotasEnabled reports whether any group has a daily quota.
+func quotasEnabled(quotaCfg config.GroupQuotaConfig) bool {
+	return quotaCfg.DailyLimit > 0 || len(quotaCfg.Groups) > 0
+}
+
+// quotaLimit returns the daily quota of the group, or 0 when it is unlimited.
+func (s *Service) quotaLimit(groupID string) int {
+	if limit, ok := s.config.Service.GroupQuota.Groups[groupID]; ok {
+		return limit
+	}
+	return s.config.Service.GroupQuota.DailyLimit
+}
+
+// quotaDay returns the current quota day, which starts at service.group_quota.rollover.
+func (s *Service) quotaDay() string {
+	var rollover time.Duration
+	if start, err := time.Parse("15:04", s.config.Service.GroupQuota.Rollover); err == nil {
+		rollover = time.Duration(start.Hour())*time.Hour + time.Duration(start.Minute())*time.Minute
+	}
+	return quota.Day(time.Now(), s.dates.Location(), rollover)
+}
+
+// takeQuota counts the article against its group's daily quota and returns the quota day
+// it was counted on, or "" when the group is unlimited. When the quota is full it returns
+// false, after deferring or dropping the article per service.group_quota.overflow. If the
+// quota cannot be checked, the article is let through.
+func (s *Service) takeQuota(ctx context.Context, cityCfg config.CityConfig, article *pipeline.Article) (string, bool) {
+	limit := s.quotaLimit(cityCfg.GroupID)
+	if s.quotas == nil || limit <= 0 {
+		return "", true
+	}
+
+	day := s.quotaDay()
+	quotaCtx, cancel := context.WithTimeout(ctx, redisTimeout)
+	defer cancel()
+	fits, err := s.quotas.Take(quotaCtx, cityCfg.GroupID, day, limit)
+	if err != nil {
+		s.logger.Warn("Failed to check group quota, posting anyway",
+			logger.String("article_id", article.ID),
+			logger.String("city", cityCfg.Name),
+			logger.String("group_id", cityCfg.GroupID),
+			logger.Error(err),
+		)
+		return "", true
+	}
+	if fits {
+		return day, true
+	}
+
+	if s.config.Service.GroupQuota.Overflow == config.QuotaOverflowDrop {
+		s.logger.Info("Article dropped - group quota reached",
+			logger.String("article_id", article.ID),
+			logger.String("city", cityCfg.Name),
+			logger.String("group_id", cityCfg.GroupID),
+			logger.Int("daily_limit", limit),
+			logger.String("quota_day", day),
+		)
+		return "", false
+	}
+	if err := s.quotas.Defer(quotaCtx, cityCfg.Name, *article); err != nil {
+		s.logger.Error("Failed to defer article over group quota",
+			logger.String("article_id", article.ID),
+			logger.String("city", cityCfg.Name),
+			logger.String("group_id", cityCfg.GroupID),
+			logger.Error(err),
+		)
+		return "", false
+	}
+	s.logger.Info("Article deferred - group quota reached",
+		logger.String("article_id", article.ID),
+		logger.String("city", cityCfg.Name),
+		logger.String("group_id", cityCfg.GroupID),
+		logger.Int("daily_limit", limit),
+		logger.String("quota_day", day),
+	)
+	return "", false
+}
+
+// releaseQuota gives back a post counted on day that did not happen.
+func (s *Service) releaseQuota(ctx context.Context, cityCfg config.CityConfig, day string) {
+	if day == "" {
+		return
+	}
+
+	releaseCtx, cancel := context.WithTimeout(ctx, redisTimeout)
+	defer cancel()
+	if err := s.quotas.Release(releaseCtx, cityCfg.GroupID, day); err != nil {
+		s.logger.Warn("Failed to release group quota",
+			logger.String("city", cityCfg.Name),
+			logger.String("group_id", cityCfg.GroupID),
+			logger.Error(err),
+		)
+	}
+}
+
+// withDeferred puts the articles deferred by a full quota ahead of the city's new
+// candidates, so they are posted before newer articles once the quota allows. It also
+// returns the IDs of the deferred articles, which stay deferred until settleDeferred.
+func (s *Service) withDeferred(ctx context.Context, cityCfg config.CityConfig, articles []pipeline.Article) ([]pipeline.Article, []string) {
+	if s.quotas == nil || s.config.Service.GroupQuota.Overflow != config.QuotaOverflowDefer {
+		return articles, nil
+	}
+
+	deferredCtx, cancel := context.WithTimeout(ctx, redisTimeout)
+	defer cancel()
+	deferred, err := s.quotas.Deferred(deferredCtx, cityCfg.Name)
+	if err != nil {
+		s.logger.Warn("Failed to load articles deferred by group quota",
+			logger.String("city", cityCfg.Name),
+			logger.Error(err),
+		)
+	}
+	if len(deferred) == 0 {
+		return articles, nil
+	}
+
+	s.logger.Debug("Retrying articles deferred by group quota",
+		logger.String("city", cityCfg.Name),
+		logger.Int("deferred", len(deferred)),
+	)
+	ids := make([]string, 0, len(deferred))
+	for _, article := range deferred {
+		ids = append(ids, article.ID)
+	}
+	for _, article := range articles {
+		if !slices.Contains(ids, article.ID) {
+			deferred = append(deferred, article)
+		}
+	}
+	return deferred, ids
+}
+
+// settleDeferred forgets the deferred articles the run posted, queued, or skipped. Those
+// over the quota again were deferred again; those that failed, were held, or were not
+// reached (warm-up, service.max_articles_per_city_per_run, shutdown) stay for the next run.
+func (s *Service) settleDeferred(ctx context.Context, cityCfg config.CityConfig, deferredIDs []string, report CityResult) {
+	if len(deferredIDs) == 0 {
+		return
+	}
+
+	var settled []string
+	for _, result := range report.Articles {
+		switch result.Outcome {
+		case ArticlePosted, ArticleQueued, ArticleSkipped:
+			if slices.Contains(deferredIDs, result.ArticleID) {
+				settled = append(settled, result.ArticleID)
+			}
+		}
+	}
+
+	undeferCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), redisTimeout)
+	defer cancel()
+	if err := s.quotas.Undefer(undeferCtx, cityCfg.Name, settled...); err != nil {
+		s.logger.Warn("Failed to forget articles deferred by group quota",
+			logger.String("city", cityCfg.Name),
+			logger.Error(err),
+		)
+	}
+}
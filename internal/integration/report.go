@@ -0,0 +1,257 @@
+package integration
+
+import (
+	"cmp"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/gopost/integration/internal/logger"
+	"github.com/gopost/integration/pkg/pipeline"
+)
+
+// topDuplicateSourceCount bounds how many sources RunReport.TopDuplicateSources lists.
+const topDuplicateSourceCount = 5
+
+// RunReport summarizes one sync run. Dedup counters also include posting workers'
+// activity since the previous report when outbox mode is enabled.
+type RunReport struct {
+	StartedAt time.Time     `json:"started_at"`
+	RunToken  string        `json:"run_token,omitempty"` // Token the run was triggered with, if any
+	Duration  time.Duration `json:"duration"`
+	Cities    []CityReport  `json:"cities"`
+	Dedup     DedupReport   `json:"dedup"`
+}
+
+// CityReport counts what happened to one city's candidate articles.
+type CityReport struct {
+	City    string `json:"city"`
+	Found   int    `json:"found"`
+	Posted  int    `json:"posted"`
+	Queued  int    `json:"queued"`
+	Skipped int    `json:"skipped"`
+	Errors  int    `json:"errors"`
+	Failed  bool   `json:"failed"` // Search failed, so the counts are incomplete
+
+	BrokenLinks int           `json:"broken_links"`     // Articles whose link failed the health check, skipped or flagged
+	Paused      bool          `json:"paused,omitempty"` // The city was paused, so nothing was searched
+	Deferred    int           `json:"deferred"`         // Candidates left for a later run by the warm-up limit
+	OverQuota   int           `json:"over_quota"`       // Candidates deferred or dropped because their group's daily quota was full
+	Held        int           `json:"held"`             // Candidates left for a later run because Drupal was in maintenance mode
+	Truncated   int           `json:"truncated"`        // Candidates not processed because of service.max_articles_per_city_per_run
+	PostTime    time.Duration `json:"post_time"`        // Total time spent in Drupal posts during the run
+	Newest      time.Time     `json:"newest,omitzero"`  // Published date of the newest article the search found
+
+	// How far the newest article matching the city's search is ahead of the newest posted,
+	// when service.sync_lag measured it
+	SyncLag *time.Duration `json:"sync_lag,omitempty"`
+
+	Highlights []ArticleHighlights `json:"highlights,omitempty"` // Why each posted or queued article matched, when highlighting is enabled
+
+	Canary    *CanaryCounts   `json:"canary,omitempty"`    // Set for canary cities while a canary trial runs
+	Anomalies []VolumeAnomaly `json:"anomalies,omitempty"` // Counts far outside the city's rolling average, when volume anomaly detection is enabled
+
+	Outcome     CityOutcome      `json:"outcome,omitempty"`      // How processing the city went
+	Diagnosis   *SearchDiagnosis `json:"diagnosis,omitempty"`    // Why the city's search found nothing
+	ClosedIndex string           `json:"closed_index,omitempty"` // Error type while the city's index is closed or frozen, so nothing was searched
+}
+
+// ArticleHighlights are the keyword fragments of an article selected during a run.
+type ArticleHighlights struct {
+	ArticleID string   `json:"article_id"`
+	Title     string   `json:"title"`
+	Fragments []string `json:"fragments"`
+}
+
+// DedupReport measures how much duplicate work deduplication absorbed.
+type DedupReport struct {
+	AlreadyPosted       int           `json:"already_posted"`        // Candidates skipped because the tracker had them
+	TitleMatches        int           `json:"title_matches"`         // Candidates with a new ID whose normalized title was already posted
+	NearMatches         int           `json:"near_matches"`          // Candidates whose body closely matched a recently posted article
+	ReserveConflicts    int           `json:"reserve_conflicts"`     // Near-misses: another worker reserved the article between check and post
+	AlreadyQueued       int           `json:"already_queued"`        // Candidates skipped because the outbox already held them
+	TopDuplicateSources []SourceCount `json:"top_duplicate_sources"` // Sources producing the most duplicates
+}
+
+// SourceCount is the number of duplicates attributed to an article source.
+type SourceCount struct {
+	Source string `json:"source"`
+	Count  int    `json:"count"`
+}
+
+// add accumulates the counts of another report for the same city.
+func (c *CityReport) add(other CityReport) {
+	c.Found += other.Found
+	c.Posted += other.Posted
+	c.Queued += other.Queued
+	c.Skipped += other.Skipped
+	c.Errors += other.Errors
+	c.BrokenLinks += other.BrokenLinks
+	c.Deferred += other.Deferred
+	c.OverQuota += other.OverQuota
+	c.Held += other.Held
+	c.Truncated += other.Truncated
+	c.PostTime += other.PostTime
+	c.Highlights = append(c.Highlights, other.Highlights...)
+	if other.Canary != nil {
+		if c.Canary == nil {
+			c.Canary = &CanaryCounts{}
+		}
+		c.Canary.Baseline += other.Canary.Baseline
+		c.Canary.Candidate += other.Canary.Candidate
+	}
+	c.Failed = c.Failed || other.Failed
+	c.Paused = c.Paused || other.Paused
+	if other.ClosedIndex != "" {
+		c.ClosedIndex = other.ClosedIndex
+	}
+}
+
+// addHighlights records the fragments of an article that was posted or queued.
+func (c *CityReport) addHighlights(article *pipeline.Article) {
+	if len(article.Highlights) == 0 {
+		return
+	}
+	c.Highlights = append(c.Highlights, ArticleHighlights{
+		ArticleID: article.ID,
+		Title:     article.Title,
+		Fragments: article.Highlights,
+	})
+}
+
+// held reports whether any city left articles for a later run because Drupal was in
+// maintenance mode.
+func (r RunReport) held() bool {
+	return slices.ContainsFunc(r.Cities, func(city CityReport) bool { return city.Held > 0 })
+}
+
+// failed reports whether any city's search or any post failed.
+func (r RunReport) failed() bool {
+	return slices.ContainsFunc(r.Cities, func(city CityReport) bool { return city.Failed || city.Errors > 0 })
+}
+
+// dedupStats accumulates dedup counters between run reports. It is shared by the
+// discovery loop and posting workers.
+type dedupStats struct {
+	mu               sync.Mutex
+	alreadyPosted    int
+	titleMatches     int
+	nearMatches      int
+	reserveConflicts int
+	alreadyQueued    int
+	bySource         map[string]int
+}
+
+// duplicateKind identifies which dedup layer caught a duplicate.
+type duplicateKind int
+
+const (
+	duplicateAlreadyPosted duplicateKind = iota
+	duplicateTitleMatch
+	duplicateNearMatch
+	duplicateReserveConflict
+	duplicateAlreadyQueued
+)
+
+func (d *dedupStats) record(kind duplicateKind, article *pipeline.Article) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	switch kind {
+	case duplicateAlreadyPosted:
+		d.alreadyPosted++
+	case duplicateTitleMatch:
+		d.titleMatches++
+	case duplicateNearMatch:
+		d.nearMatches++
+	case duplicateReserveConflict:
+		d.reserveConflicts++
+	case duplicateAlreadyQueued:
+		d.alreadyQueued++
+	}
+
+	source := article.Source
+	if source == "" {
+		source = "unknown"
+	}
+	if d.bySource == nil {
+		d.bySource = make(map[string]int)
+	}
+	d.bySource[source]++
+}
+
+// drain returns the counters accumulated since the last call and resets them.
+func (d *dedupStats) drain() DedupReport {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	report := DedupReport{
+		AlreadyPosted:    d.alreadyPosted,
+		TitleMatches:     d.titleMatches,
+		NearMatches:      d.nearMatches,
+		ReserveConflicts: d.reserveConflicts,
+		AlreadyQueued:    d.alreadyQueued,
+	}
+	for source, count := range d.bySource {
+		report.TopDuplicateSources = append(report.TopDuplicateSources, SourceCount{Source: source, Count: count})
+	}
+	slices.SortFunc(report.TopDuplicateSources, func(a, b SourceCount) int {
+		return cmp.Or(cmp.Compare(b.Count, a.Count), cmp.Compare(a.Source, b.Source))
+	})
+	if len(report.TopDuplicateSources) > topDuplicateSourceCount {
+		report.TopDuplicateSources = report.TopDuplicateSources[:topDuplicateSourceCount]
+	}
+
+	d.alreadyPosted, d.titleMatches, d.nearMatches, d.reserveConflicts, d.alreadyQueued = 0, 0, 0, 0, 0
+	d.bySource = nil
+	return report
+}
+
+// LastReport returns the report of the most recent completed run, or the zero
+// value if no run has completed yet.
+func (s *Service) LastReport() RunReport {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.lastReport
+}
+
+// logRunReport logs the run totals and dedup effectiveness.
+func (s *Service) logRunReport(report RunReport) {
+	var found, posted, queued, skipped, deferred, overQuota, held, truncated, errors, brokenLinks int
+	for _, city := range report.Cities {
+		found += city.Found
+		posted += city.Posted
+		queued += city.Queued
+		skipped += city.Skipped
+		deferred += city.Deferred
+		overQuota += city.OverQuota
+		held += city.Held
+		truncated += city.Truncated
+		errors += city.Errors
+		brokenLinks += city.BrokenLinks
+	}
+
+	duplicates := report.Dedup.AlreadyPosted + report.Dedup.TitleMatches + report.Dedup.NearMatches +
+		report.Dedup.ReserveConflicts + report.Dedup.AlreadyQueued
+	s.logger.Info("Run report",
+		logger.Int("city_count", len(report.Cities)),
+		logger.Int("found", found),
+		logger.Int("posted", posted),
+		logger.Int("queued", queued),
+		logger.Int("skipped", skipped),
+		logger.Int("deferred", deferred),
+		logger.Int("over_quota", overQuota),
+		logger.Int("held", held),
+		logger.Int("truncated", truncated),
+		logger.Int("errors", errors),
+		logger.Int("broken_links", brokenLinks),
+		logger.Int("duplicates", duplicates),
+		logger.Int("dedup_already_posted", report.Dedup.AlreadyPosted),
+		logger.Int("dedup_title_matches", report.Dedup.TitleMatches),
+		logger.Int("dedup_near_matches", report.Dedup.NearMatches),
+		logger.Int("dedup_reserve_conflicts", report.Dedup.ReserveConflicts),
+		logger.Int("dedup_already_queued", report.Dedup.AlreadyQueued),
+		logger.Any("top_duplicate_sources", report.Dedup.TopDuplicateSources),
+		logger.Duration("total_duration", report.Duration),
+	)
+}
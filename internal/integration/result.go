@@ -0,0 +1,118 @@
+package integration
+
+import (
+	"errors"
+	"slices"
+
+	"github.com/gopost/integration/pkg/pipeline"
+)
+
+// ErrBackendUnavailable marks errors caused by a backend that could not be reached, such
+// as Redis failing to answer at startup.
+var ErrBackendUnavailable = errors.New("backend unavailable")
+
+// ArticleOutcome is what processing a city did with one of its candidate articles.
+type ArticleOutcome string
+
+const (
+	ArticlePosted    ArticleOutcome = "posted"
+	ArticleQueued    ArticleOutcome = "queued"     // Handed to the outbox posting workers
+	ArticleSkipped   ArticleOutcome = "skipped"    // Filtered out, or already posted
+	ArticleOverQuota ArticleOutcome = "over_quota" // Its group's daily quota was full
+	ArticleFailed    ArticleOutcome = "failed"     // Reserving or posting it failed
+)
+
+// ArticleResult is the outcome of one candidate article.
+type ArticleResult struct {
+	ArticleID string         `json:"article_id"`
+	Title     string         `json:"title"`
+	Outcome   ArticleOutcome `json:"outcome"`
+	Reason    string         `json:"reason,omitempty"` // Why it was skipped or failed
+}
+
+// CityOutcome classifies how processing a city went.
+type CityOutcome string
+
+const (
+	CitySucceeded CityOutcome = "succeeded"
+	CitySkipped   CityOutcome = "skipped" // Paused, or its index closed or frozen; nothing was searched
+	CityPartial   CityOutcome = "partial" // Some posts failed, or the search failed after some were posted
+	CityFailed    CityOutcome = "failed"  // The search failed, or every post attempted failed
+)
+
+// CityResult is the outcome of processing one city: the counts for the run report and
+// what happened to each candidate article. Articles left for a later run (deferred or
+// held) are only counted.
+type CityResult struct {
+	CityReport
+	Articles []ArticleResult
+}
+
+// record adds the outcome of an article, counting it in the report.
+func (r *CityResult) record(article *pipeline.Article, outcome ArticleOutcome, reason string) {
+	switch outcome {
+	case ArticlePosted:
+		r.Posted++
+	case ArticleQueued:
+		r.Queued++
+	case ArticleSkipped:
+		r.Skipped++
+	case ArticleOverQuota:
+		r.OverQuota++
+	case ArticleFailed:
+		r.Errors++
+	}
+	r.Articles = append(r.Articles, ArticleResult{
+		ArticleID: article.ID,
+		Title:     article.Title,
+		Outcome:   outcome,
+		Reason:    reason,
+	})
+}
+
+// classify returns the outcome of a city that was searched.
+func (c CityReport) classify() CityOutcome {
+	handled := c.Posted + c.Queued
+	switch {
+	case c.Failed && handled == 0, c.Errors > 0 && handled == 0:
+		return CityFailed
+	case c.Failed, c.Errors > 0:
+		return CityPartial
+	default:
+		return CitySucceeded
+	}
+}
+
+// RunOutcome classifies how a run went across its cities.
+type RunOutcome string
+
+const (
+	RunSucceeded RunOutcome = "succeeded" // No city failed or partially failed
+	RunPartial   RunOutcome = "partial"   // Some cities failed or partially failed
+	RunFailed    RunOutcome = "failed"    // Every city searched failed
+)
+
+// Outcome aggregates the outcomes of the run's cities. Skipped cities count as neither
+// succeeded nor failed.
+func (r RunReport) Outcome() RunOutcome {
+	searched := slices.DeleteFunc(slices.Clone(r.Cities), func(city CityReport) bool {
+		return city.Outcome == CitySkipped
+	})
+	failed := 0
+	for _, city := range searched {
+		switch city.Outcome {
+		case CityFailed:
+			failed++
+		case CityPartial:
+			return RunPartial
+		}
+	}
+	switch {
+	case failed == 0:
+		return RunSucceeded
+	case failed == len(searched):
+		return RunFailed
+	default:
+		return RunPartial
+	}
+}
@@ -0,0 +1,68 @@
+package integration
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+
+	"github.com/gopost/integration/internal/buildinfo"
+	"github.com/gopost/integration/internal/config"
+	"github.com/gopost/integration/internal/logger"
+	"github.com/gopost/integration/pkg/drupal"
+	"github.com/gopost/integration/pkg/pipeline"
+)
+
+// revisionLogData is what drupal.revision_log templates can refer to.
+type revisionLogData struct {
+	Version string // The gopost version
+	Source  string // The article's source
+	ID      string // The article's Elasticsearch ID
+	City    string
+	Title   string
+	URL     string
+}
+
+// parseRevisionLog compiles the drupal.revision_log template, DefaultRevisionLog when it is
+// empty, and checks it only refers to revisionLogData fields. It returns nil for "none".
+func parseRevisionLog(text string) (*template.Template, error) {
+	switch text {
+	case config.RevisionLogNone:
+		return nil, nil
+	case "":
+		text = config.DefaultRevisionLog
+	}
+	tmpl, err := template.New("revision_log").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("drupal.revision_log: %w", err)
+	}
+	if err := tmpl.Execute(io.Discard, revisionLogData{}); err != nil {
+		return nil, fmt.Errorf("drupal.revision_log: %w", err)
+	}
+	return tmpl, nil
+}
+
+// applyRevisionLog sets the revision log message of the node posted for article. A
+// template that fails on this article leaves the message empty rather than failing the post.
+func (s *Service) applyRevisionLog(req *drupal.ArticleRequest, cityCfg config.CityConfig, article *pipeline.Article) {
+	if s.revisionLog == nil {
+		return
+	}
+	var message strings.Builder
+	err := s.revisionLog.Execute(&message, revisionLogData{
+		Version: buildinfo.Get().Version,
+		Source:  article.Source,
+		ID:      article.ID,
+		City:    cityCfg.Name,
+		Title:   article.Title,
+		URL:     article.URL,
+	})
+	if err != nil {
+		s.logger.Warn("Failed to render revision log message",
+			logger.String("article_id", article.ID),
+			logger.Error(err),
+		)
+		return
+	}
+	req.RevisionLog = message.String()
+}
@@ -0,0 +1,251 @@
+package integration
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/gopost/integration/internal/consistency"
+	"github.com/gopost/integration/internal/es"
+	"github.com/gopost/integration/internal/logger"
+	"github.com/gopost/integration/pkg/drupal"
+	"github.com/gopost/integration/pkg/pipeline"
+)
+
+// rollbackPageSize is how many audit documents one rollback search returns.
+const rollbackPageSize = 500
+
+// NodeRemover deletes or unpublishes posted nodes. *drupal.Client implements it.
+type NodeRemover interface {
+	DeleteNode(ctx context.Context, contentType, id string) error
+	UnpublishNode(ctx context.Context, contentType, id string) error
+}
+
+// RollbackOptions selects the posts Rollback removes and how.
+type RollbackOptions struct {
+	Since     time.Time // Posted at or after
+	Until     time.Time // Posted before; zero means now
+	City      string    // Only this city's posts; empty means every city
+	Unpublish bool      // Unpublish the nodes instead of deleting them
+	Suppress  bool      // Suppress the articles instead of clearing their dedup markers
+	DryRun    bool      // Only list the posts
+}
+
+// RolledBackPost is a post Rollback found in the audit index.
+type RolledBackPost struct {
+	ArticleID string
+	City      string
+	Title     string
+	NodeID    string
+	PostedAt  time.Time
+	Err       error // Why the node or the article's state could not be rolled back
+}
+
+// rollbackRecord marks an audit document as rolled back, so later rollbacks skip it.
+type rollbackRecord struct {
+	Action string    `json:"action"` // "deleted" or "unpublished"
+	At     time.Time `json:"at"`
+}
+
+// Rollback removes the nodes posted in a time range, found in the audit index, and
+// updates each article's state: by default its dedup markers are cleared, so a replay
+// with fixed filters can post it again; with Suppress it is never posted again. Posts
+// whose node or state could not be rolled back are returned with Err set; running
+// Rollback again retries them.
+func (s *Service) Rollback(ctx context.Context, opts RollbackOptions) ([]RolledBackPost, error) {
+	if s.audit == nil {
+		return nil, errors.New("rollback requires audit.enabled")
+	}
+	if s.remover == nil {
+		return nil, errors.New("rollback requires a Drupal client or WithNodeRemover")
+	}
+	if opts.Suppress && s.suppressed == nil {
+		return nil, errNoSuppressionList
+	}
+	if opts.Until.IsZero() {
+		opts.Until = time.Now()
+	}
+
+	records, err := s.auditedPosts(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	s.logger.Info("Rolling back posts",
+		logger.Time("since", opts.Since),
+		logger.Time("until", opts.Until),
+		logger.String("city", opts.City),
+		logger.Int("posts", len(records)),
+		logger.Bool("dry_run", opts.DryRun),
+	)
+
+	posts := make([]RolledBackPost, 0, len(records))
+	for _, record := range records {
+		post := RolledBackPost{
+			ArticleID: record.ArticleID,
+			City:      record.City,
+			Title:     record.Title,
+			NodeID:    record.DrupalUUID,
+			PostedAt:  record.PostedAt,
+		}
+		if !opts.DryRun {
+			post.Err = s.rollbackPost(ctx, record, opts)
+		}
+		posts = append(posts, post)
+	}
+	return posts, ctx.Err()
+}
+
+// auditedPosts returns the audit records of the posts in the range that were not rolled
+// back yet, oldest first.
+func (s *Service) auditedPosts(ctx context.Context, opts RollbackOptions) ([]auditRecord, error) {
+	var records []auditRecord
+	seen := make(map[string]bool)
+	since := opts.Since
+	for {
+		filters := []es.Query{
+			es.Range{Field: "posted_at", GTE: since.Format(time.RFC3339Nano), LT: opts.Until.Format(time.RFC3339Nano)},
+		}
+		if opts.City != "" {
+			filters = append(filters, es.Match{Field: "city", Query: opts.City})
+		}
+		query := es.Search{
+			Query: es.Bool{Filter: filters, MustNot: []es.Query{es.Exists{Field: "rolled_back"}}},
+			Size:  rollbackPageSize,
+			Sort:  []es.Sort{{Field: "posted_at", Order: "asc"}},
+		}.Source()
+
+		searchCtx, cancel := context.WithTimeout(ctx, esQueryTimeout)
+		result, err := s.source.Search(searchCtx, s.config.Audit.Index, query)
+		cancel()
+		if err != nil {
+			return nil, fmt.Errorf("search audit index: %w", err)
+		}
+
+		fresh := 0
+		for _, hit := range result.Hits {
+			var record auditRecord
+			if err := json.Unmarshal(hit.Source, &record); err != nil {
+				return nil, fmt.Errorf("decode audit document %s: %w", hit.ID, err)
+			}
+			// The query narrows the search; this check guarantees only the range is removed
+			inRange := !record.PostedAt.Before(opts.Since) && record.PostedAt.Before(opts.Until)
+			if seen[hit.ID] || !inRange || (opts.City != "" && record.City != opts.City) {
+				continue
+			}
+			seen[hit.ID] = true
+			fresh++
+			records = append(records, record)
+			since = record.PostedAt
+		}
+		// Pages overlap at the boundary time; a page of nothing new ends the search
+		if len(result.Hits) < rollbackPageSize || fresh == 0 {
+			return records, nil
+		}
+	}
+}
+
+// rollbackPost removes the node of one audited post and updates the article's state.
+func (s *Service) rollbackPost(ctx context.Context, record auditRecord, opts RollbackOptions) error {
+	action := "deleted"
+	if record.DrupalUUID != "" {
+		contentType := record.Payload.Data.Type
+		removeCtx, cancel := context.WithTimeout(ctx, drupalPostTimeout)
+		var err error
+		if opts.Unpublish {
+			action = "unpublished"
+			err = s.remover.UnpublishNode(removeCtx, contentType, record.DrupalUUID)
+		} else {
+			err = s.remover.DeleteNode(removeCtx, contentType, record.DrupalUUID)
+		}
+		cancel()
+		// A node already gone was removed by an earlier, interrupted rollback (or an editor)
+		if err != nil && !errors.Is(err, drupal.ErrNotFound) {
+			return fmt.Errorf("remove node %s: %w", record.DrupalUUID, err)
+		}
+	}
+
+	if err := s.rollbackState(ctx, record, opts); err != nil {
+		return err
+	}
+
+	if updater, ok := s.audit.(pipeline.DocumentUpdater); ok {
+		update := map[string]rollbackRecord{"rolled_back": {Action: action, At: time.Now()}}
+		auditCtx, cancel := context.WithTimeout(ctx, esQueryTimeout)
+		defer cancel()
+		if err := updater.UpdateDocument(auditCtx, s.config.Audit.Index, record.ArticleID, update); err != nil {
+			s.logger.Warn("Failed to mark audit document as rolled back",
+				logger.String("article_id", record.ArticleID),
+				logger.String("audit_index", s.config.Audit.Index),
+				logger.Error(err),
+			)
+		}
+	}
+	s.logger.Info("Rolled back post",
+		logger.String("article_id", record.ArticleID),
+		logger.String("city", record.City),
+		logger.String("drupal_id", record.DrupalUUID),
+		logger.String("action", action),
+	)
+	return nil
+}
+
+// rollbackState suppresses the article, or clears its dedup markers and fingerprint. Its
+// consistency record is dropped either way, so the removal is not reported as an editor's.
+func (s *Service) rollbackState(ctx context.Context, record auditRecord, opts RollbackOptions) error {
+	redisCtx, cancel := context.WithTimeout(ctx, redisTimeout)
+	defer cancel()
+
+	titleKey := s.titleDedupKey(&pipeline.Article{Title: record.Title})
+	if s.posts != nil {
+		forgotten, err := s.posts.Forget(redisCtx, record.ArticleID)
+		if err != nil {
+			return err
+		}
+		// The recorded key is that of the source title, before any title template
+		if i := slices.IndexFunc(forgotten, func(post consistency.Post) bool { return post.TitleKey != "" }); i >= 0 {
+			titleKey = forgotten[i].TitleKey
+		}
+	}
+
+	if opts.Suppress {
+		return s.suppressed.Add(redisCtx, record.ArticleID)
+	}
+	clearer, ok := s.dedup.(dedupClearer)
+	if !ok {
+		return errors.New("dedup tracker cannot clear articles")
+	}
+	for _, key := range []string{record.ArticleID, titleKey} {
+		if key == "" {
+			continue
+		}
+		if err := clearer.Clear(redisCtx, key); err != nil {
+			return fmt.Errorf("clear dedup marker %s: %w", key, err)
+		}
+	}
+	if s.nearDups != nil {
+		if err := s.nearDups.Remove(redisCtx, record.ArticleID); err != nil {
+			return fmt.Errorf("remove fingerprint: %w", err)
+		}
+	}
+	return nil
+}
+
+// Rollback rolls back every tenant's posts in the range; City names a city of each tenant.
+// Posts are returned with their city prefixed by the tenant name.
+func (g *Group) Rollback(ctx context.Context, opts RollbackOptions) ([]RolledBackPost, error) {
+	var all []RolledBackPost
+	for _, name := range g.names {
+		posts, err := g.services[name].Rollback(ctx, opts)
+		for _, post := range posts {
+			post.City = name + "/" + post.City
+			all = append(all, post)
+		}
+		if err != nil {
+			return all, fmt.Errorf("tenant %s: %w", name, err)
+		}
+	}
+	return all, nil
+}
@@ -0,0 +1,65 @@
+package integration
+
+import (
+	"context"
+
+	"github.com/gopost/integration/internal/logger"
+	"github.com/gopost/integration/pkg/pipeline"
+)
+
+// runScope is the run token of a triggered run and the articles that earlier attempts
+// with the same token processed. A nil *runScope (scheduled runs, replays) tracks nothing.
+type runScope struct {
+	token     string
+	processed map[string]bool
+}
+
+// startRun loads what earlier attempts of the run with token processed. It returns nil
+// without a token or a run token store. If the set cannot be loaded, the attempt handles
+// every article; dedup still prevents double posts.
+func (s *Service) startRun(ctx context.Context, token string) *runScope {
+	if token == "" || s.runTokens == nil {
+		return nil
+	}
+
+	loadCtx, cancel := context.WithTimeout(ctx, redisTimeout)
+	defer cancel()
+	processed, err := s.runTokens.Processed(loadCtx, token)
+	if err != nil {
+		s.logger.Warn("Failed to load run token, processing all articles",
+			logger.String("run_token", token),
+			logger.Error(err),
+		)
+		processed = make(map[string]bool)
+	}
+	if len(processed) > 0 {
+		s.logger.Info("Retrying run, skipping articles already processed",
+			logger.String("run_token", token),
+			logger.Int("processed", len(processed)),
+		)
+	}
+	return &runScope{token: token, processed: processed}
+}
+
+// processedEarlier reports whether an earlier attempt of the run processed the article.
+func (r *runScope) processedEarlier(articleID string) bool {
+	return r != nil && r.processed[articleID]
+}
+
+// markProcessed records that the run processed the article, so a retry with the same token
+// skips it. Failures are logged only; a retry then checks the article again.
+func (s *Service) markProcessed(ctx context.Context, run *runScope, article *pipeline.Article) {
+	if run == nil {
+		return
+	}
+
+	markCtx, cancel := context.WithTimeout(ctx, redisTimeout)
+	defer cancel()
+	if err := s.runTokens.Add(markCtx, run.token, article.ID); err != nil {
+		s.logger.Warn("Failed to record processed article for run token",
+			logger.String("article_id", article.ID),
+			logger.String("run_token", run.token),
+			logger.Error(err),
+		)
+	}
+}
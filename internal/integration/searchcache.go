@@ -0,0 +1,70 @@
+package integration
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/gopost/integration/pkg/pipeline"
+)
+
+// searchCache keeps recent search results, so cities sharing an index and search reuse
+// one result within a run. A nil cache caches nothing.
+type searchCache struct {
+	ttl     time.Duration
+	mu      sync.Mutex
+	entries map[string]cachedSearch
+}
+
+// cachedSearch is a search result and when it stops being reused.
+type cachedSearch struct {
+	result  *pipeline.SearchResult
+	expires time.Time
+}
+
+// newSearchCache returns a cache reusing results for ttl.
+func newSearchCache(ttl time.Duration) *searchCache {
+	return &searchCache{ttl: ttl, entries: make(map[string]cachedSearch)}
+}
+
+// searchKey identifies a search by index and query; the query holds the search window.
+// It returns "" for queries that cannot be encoded, which are not cached.
+func searchKey(index string, query any) string {
+	data, err := json.Marshal(query)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(append([]byte(index+"\x00"), data...))
+	return hex.EncodeToString(sum[:])
+}
+
+// get returns the unexpired result of the search with key.
+func (c *searchCache) get(key string, now time.Time) (*pipeline.SearchResult, bool) {
+	if c == nil || key == "" {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if !ok || !now.Before(entry.expires) {
+		return nil, false
+	}
+	return entry.result, true
+}
+
+// put stores the result of the search with key, dropping expired results.
+func (c *searchCache) put(key string, result *pipeline.SearchResult, now time.Time) {
+	if c == nil || key == "" {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for k, entry := range c.entries {
+		if !now.Before(entry.expires) {
+			delete(c.entries, k)
+		}
+	}
+	c.entries[key] = cachedSearch{result: result, expires: now.Add(c.ttl)}
+}
@@ -0,0 +1,49 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/gopost/integration/internal/config"
+	"github.com/gopost/integration/pkg/pipeline"
+)
+
+// PushSearchTemplate stores the mustache template in file as the search template
+// search_template.id, replacing the stored one, so searches pick it up on their next run.
+// It builds its own client from cfg; in multi-tenant mode the template is stored in every
+// tenant's cluster.
+func PushSearchTemplate(ctx context.Context, cfg *config.Config, file string) error {
+	if file == "" {
+		return errors.New("no template file: set search_template.file")
+	}
+	source, err := os.ReadFile(file)
+	if err != nil {
+		return fmt.Errorf("read search template: %w", err)
+	}
+
+	if len(cfg.Tenants) == 0 {
+		return pushSearchTemplate(ctx, cfg, string(source))
+	}
+	for _, tenant := range cfg.Tenants {
+		if err := pushSearchTemplate(ctx, cfg.ForTenant(tenant), string(source)); err != nil {
+			return fmt.Errorf("%s: %w", tenant.Name, err)
+		}
+	}
+	return nil
+}
+
+func pushSearchTemplate(ctx context.Context, cfg *config.Config, source string) error {
+	esSource, err := newElasticsearchSourceFromConfig(cfg)
+	if err != nil {
+		return err
+	}
+	stored, ok := esSource.(pipeline.TemplateSearcher)
+	if !ok {
+		return errors.New("source cannot store search templates")
+	}
+	putCtx, cancel := context.WithTimeout(ctx, esQueryTimeout)
+	defer cancel()
+	return stored.PutSearchTemplate(putCtx, cfg.SearchTemplate.ID, source)
+}
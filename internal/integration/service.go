@@ -1,276 +1,743 @@
 package integration
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
 	"strings"
 	"sync"
+	"text/template"
 	"time"
 
 	"github.com/elastic/go-elasticsearch/v8"
+	"github.com/gopost/integration/internal/canary"
+	"github.com/gopost/integration/internal/chaos"
+	"github.com/gopost/integration/internal/checkpoint"
 	"github.com/gopost/integration/internal/config"
+	"github.com/gopost/integration/internal/connstats"
+	"github.com/gopost/integration/internal/consistency"
 	"github.com/gopost/integration/internal/dedup"
-	"github.com/gopost/integration/internal/drupal"
+	"github.com/gopost/integration/internal/es"
+	"github.com/gopost/integration/internal/history"
+	"github.com/gopost/integration/internal/hooks"
+	"github.com/gopost/integration/internal/linkcheck"
 	"github.com/gopost/integration/internal/logger"
+	"github.com/gopost/integration/internal/netproxy"
+	"github.com/gopost/integration/internal/outbox"
+	"github.com/gopost/integration/internal/pause"
+	"github.com/gopost/integration/internal/ping"
+	"github.com/gopost/integration/internal/quota"
+	"github.com/gopost/integration/internal/redisconn"
+	"github.com/gopost/integration/internal/requestid"
+	"github.com/gopost/integration/internal/runtoken"
+	"github.com/gopost/integration/internal/timeline"
+	"github.com/gopost/integration/internal/translate"
+	"github.com/gopost/integration/internal/volume"
+	"github.com/gopost/integration/internal/warmup"
+	"github.com/gopost/integration/pkg/drupal"
+	"github.com/gopost/integration/pkg/pipeline"
 	"github.com/redis/go-redis/v9"
 	"golang.org/x/time/rate"
 )
 
-// Elasticsearch field name constants
-const (
-	ESFieldPublishedDate = "published_date"
-	ESFieldBody          = "body"
-	ESFieldCanonicalURL  = "canonical_url"
-	ESFieldTitle         = "title"
-	ESFieldSource        = "source"
-)
-
 // Timeout constants for external operations
 const (
-	esQueryTimeout    = 30 * time.Second
-	drupalPostTimeout = 30 * time.Second
-	redisTimeout      = 5 * time.Second
+	esQueryTimeout     = 30 * time.Second
+	drupalPostTimeout  = 30 * time.Second
+	redisTimeout       = 5 * time.Second
+	drupalProbeTimeout = 15 * time.Second
 )
 
 type Service struct {
-	esClient    *elasticsearch.Client
-	drupal      *drupal.Client
-	dedup       *dedup.Tracker
-	limiter     *rate.Limiter
+	source      pipeline.Source
+	classifier  pipeline.Classifier
+	poster      pipeline.Poster
+	dedup       pipeline.Tracker
+	limiter     pipeline.Limiter
+	dates       *pipeline.DateParser
+	titles      *pipeline.TitleNormalizer
+	urls        *pipeline.URLNormalizer   // nil unless URL normalization is enabled
+	links       *linkcheck.Checker        // nil unless the link health check is enabled
+	pauses      *pause.Switch             // nil when the dedup tracker was supplied without a pause switch
+	history     *history.Store            // nil unless run history is enabled
+	timeline    *timeline.Store           // nil unless article timelines are enabled
+	posts       *consistency.Store        // nil unless consistency.enabled
+	nodes       NodeChecker               // nil unless consistency.enabled
+	remover     NodeRemover               // nil when the poster was supplied without a node remover
+	suppressed  *dedup.SuppressionList    // nil unless suppression.enabled
+	checkpoints *checkpoint.Store         // nil when the dedup tracker was supplied without a checkpoint store
+	runTokens   *runtoken.Store           // nil when the dedup tracker was supplied without a run token store
+	queue       outbox.Queue              // nil unless outbox mode is enabled
+	indices     pipeline.IndexLister      // nil unless city discovery is enabled
+	queries     pipeline.QueryStore       // nil unless percolator.register is set
+	audit       pipeline.DocumentIndexer  // nil unless audit.enabled is set
+	hooks       *hooks.Runner             // nil unless hooks are configured
+	pinger      *ping.Pinger              // nil unless monitoring.ping_url is set
+	connStats   *connstats.Recorder       // nil unless drupal.conn_stats is set
+	translator  translate.Translator      // nil unless a city sets translate_to
+	groups      *resourceDirectory        // nil unless a city sets group_name
+	terms       *resourceDirectory        // nil unless a category mapping sets term
+	warmups     *warmup.Store             // nil unless warm-up is enabled
+	canaries    *canary.Store             // nil unless canary.enabled
+	volumes     *volume.Store             // nil unless volume anomaly detection is enabled
+	quotas      *quota.Store              // nil unless group quotas are enabled
+	nearDups    *dedup.NearDuplicateIndex // nil unless near-duplicate suppression is enabled
+	unmarked    *dedup.Journal            // Posted articles whose dedup marker is still to be written
+	severity    *pipeline.SeverityScorer  // nil unless severity weights are configured
+	searches    *searchCache              // nil unless service.search_cache.enabled
+	multi       pipeline.MultiSearcher    // nil unless service.multi_search is set
+	stored      pipeline.TemplateSearcher // nil unless search_template.enabled
+	templates   map[string]*template.Template
+	fields      map[string]pipeline.FieldTransformer
+	revisionLog *template.Template // nil when drupal.revision_log is "none"
+	pathAlias   *template.Template // nil unless service.path_alias is set
+	categories  []category
 	config      *config.Config
 	logger      logger.Logger
+	stats       dedupStats
+	maintenance backoffBreaker // Open while Drupal is in maintenance mode
+	credentials backoffBreaker // Open while Drupal rejects the credentials
+	moderation  moderationState
+	trial       canaryState
+	closed      closedIndices
+	prefetched  prefetchedSearches
+	discovered  []config.CityConfig // Cities found by city discovery, refreshed by refreshCities
+	cityRefresh time.Time           // When discovered was last refreshed
 	lastCheckTS time.Time
+	lastRunEnd  time.Time // When the last run completed; service creation before the first
+	lastReport  RunReport
+	totals      runTotals   // Counts of the runs since startup, served by Stats
+	redisFailed bool        // Saving the last check time failed in the last run
+	catchingUp  bool        // The next run's window was expanded to cover downtime
+	trigger     chan string // Run tokens of runs requested by TriggerSync; holds at most one
 	mu          sync.RWMutex
 }
 
-func NewService(cfg *config.Config, log logger.Logger) (*Service, error) {
-	// Initialize Elasticsearch client
+// NewService creates the integration service. Dependencies not supplied via options
+// are built from cfg: an Elasticsearch source, a keyword classifier, a Drupal client, a Redis dedup tracker,
+// and a token bucket limiter.
+func NewService(cfg *config.Config, log logger.Logger, opts ...Option) (*Service, error) {
+	s := &Service{
+		config:  cfg,
+		logger:  log,
+		trigger: make(chan string, 1),
+		fields:  builtinFieldTransformers(),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	if s.source == nil {
+		source, err := newElasticsearchSourceFromConfig(cfg)
+		if err != nil {
+			return nil, err
+		}
+		s.source = source
+	}
+
+	if s.indices == nil && cfg.CityDiscovery.Enabled {
+		lister, ok := s.source.(pipeline.IndexLister)
+		if !ok {
+			return nil, errors.New("city discovery requires a source that can list indices")
+		}
+		s.indices = lister
+	}
+
+	if s.queries == nil && cfg.Percolator.Enabled && cfg.Percolator.Register {
+		store, ok := s.source.(pipeline.QueryStore)
+		if !ok {
+			return nil, errors.New("percolator.register requires a source that can store queries")
+		}
+		s.queries = store
+	}
+
+	if cfg.SearchTemplate.Enabled {
+		stored, ok := s.source.(pipeline.TemplateSearcher)
+		if !ok {
+			return nil, errors.New("search_template requires a source that can run search templates")
+		}
+		s.stored = stored
+	}
+
+	if cfg.Service.MultiSearch {
+		multi, ok := s.source.(pipeline.MultiSearcher)
+		if !ok {
+			return nil, errors.New("service.multi_search requires a source that can run multi-searches")
+		}
+		s.multi = multi
+	}
+
+	if s.audit == nil && cfg.Audit.Enabled {
+		indexer, ok := s.source.(pipeline.DocumentIndexer)
+		if !ok {
+			return nil, errors.New("audit requires a source that can index documents")
+		}
+		s.audit = indexer
+	}
+
+	matchMode := keywordMatchMode(cfg.Service.KeywordMatch)
+	if s.classifier == nil {
+		classifier, err := pipeline.NewKeywordClassifier(cfg.Service.CrimeKeywords, matchMode)
+		if err != nil {
+			return nil, fmt.Errorf("service.crime_keywords: %w", err)
+		}
+		if len(cfg.Service.Classify.Fields) > 0 {
+			classifier.SetFields(cfg.Service.Classify.Fields, cfg.Service.Classify.MinScore)
+		}
+		s.classifier = classifier
+	}
+
+	if cfg.Canary.Enabled && len(cfg.Canary.CrimeKeywords) > 0 {
+		classifier, err := pipeline.NewKeywordClassifier(cfg.Canary.CrimeKeywords, matchMode)
+		if err != nil {
+			return nil, fmt.Errorf("canary.crime_keywords: %w", err)
+		}
+		if len(cfg.Service.Classify.Fields) > 0 {
+			classifier.SetFields(cfg.Service.Classify.Fields, cfg.Service.Classify.MinScore)
+		}
+		s.trial.classifier = classifier
+	}
+	// Until the first run loads it, the trial is taken to be running
+	s.trial.set(canary.NewTrial(canaryFingerprint(cfg.Canary), time.Now()))
+
+	if len(cfg.Service.Severity.Weights) > 0 {
+		s.severity = pipeline.NewSeverityScorer(cfg.Service.Severity.Weights)
+	}
+	if cfg.Service.SearchCache.Enabled {
+		s.searches = newSearchCache(cfg.Service.SearchCache.TTL)
+	}
+
+	templates, err := loadQueryTemplates(cfg.Cities)
+	if err != nil {
+		return nil, err
+	}
+	s.templates = templates
+	if s.revisionLog, err = parseRevisionLog(cfg.Drupal.RevisionLog); err != nil {
+		return nil, err
+	}
+	if s.pathAlias, err = parsePathAlias(cfg.Service.PathAlias); err != nil {
+		return nil, err
+	}
+	if err := s.checkFieldTransformers(); err != nil {
+		return nil, err
+	}
+
+	var drupalClient *drupal.Client
+	if s.poster == nil {
+		var err error
+		clientOpts := []drupal.ClientOption{drupal.WithUserAgent(cfg.UserAgent), drupal.WithProxy(cfg.Drupal.Proxy)}
+		if cfg.Drupal.ConnStats {
+			s.connStats = connstats.NewRecorder()
+			clientOpts = append(clientOpts, drupal.WithConnStats(s.connStats.Observe))
+		}
+		drupalClient, err = drupal.NewClient(cfg.Drupal.URL, cfg.Drupal.Username, cfg.Drupal.Token, cfg.Drupal.AuthMethod, cfg.Drupal.SkipTLSVerify, log,
+			clientOpts...,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("drupal client: %w", err)
+		}
+
+		// Detect Drupal features; failures are not fatal since posting may still work
+		probeDrupalSite(drupalClient, cfg, log)
+		s.poster = drupalClient
+	}
+
+	if s.remover == nil && drupalClient != nil {
+		s.remover = drupalClient
+	}
+	if s.nodes == nil && cfg.Consistency.Enabled {
+		if drupalClient == nil {
+			return nil, errors.New("consistency checks require a Drupal client or WithNodeChecker")
+		}
+		s.nodes = drupalClient
+	}
+	if s.groups == nil && usesGroupNames(cfg) {
+		if drupalClient == nil {
+			return nil, errors.New("cities with group_name require a Drupal client or WithGroupLister")
+		}
+		s.groups = newResourceDirectory(drupalClient, cfg.Service.GroupType, "label")
+	}
+	if s.terms == nil && usesTermNames(cfg) {
+		if drupalClient == nil {
+			return nil, errors.New("category mappings with term require a Drupal client or WithTermLister")
+		}
+		s.terms = newResourceDirectory(drupalClient, cfg.Categories.TermType, "name")
+	}
+	s.categories, err = newCategories(cfg.Categories.Mappings, matchMode)
+	if err != nil {
+		return nil, err
+	}
+	// Resolve group and term names at startup so misconfigured names are reported right away
+	s.refreshGroups(context.Background())
+	s.refreshTerms(context.Background())
+
+	needQueue := s.queue == nil && cfg.Outbox.Enabled
+	needNearDups := s.nearDups == nil && cfg.Service.NearDuplicates.Enabled
+	needHistory := s.history == nil && cfg.History.Enabled
+	needTimeline := s.timeline == nil && cfg.Timeline.Enabled
+	needPosts := s.posts == nil && cfg.Consistency.Enabled
+	needSuppressions := s.suppressed == nil && cfg.Suppression.Enabled
+	needWarmup := s.warmups == nil && cfg.Service.Warmup.Enabled
+	needCanary := s.canaries == nil && cfg.Canary.Enabled
+	needVolumes := s.volumes == nil && cfg.Service.VolumeAnomaly.Enabled
+	needQuotas := s.quotas == nil && quotasEnabled(cfg.Service.GroupQuota)
+	needTranslator := s.translator == nil && usesTranslation(cfg)
+	var translationCache *translate.Cache
+	if s.dedup == nil || needQueue || needNearDups || needHistory || needTimeline || needPosts || needSuppressions || needWarmup || needCanary || needVolumes || needQuotas || (needTranslator && cfg.Translation.CacheTTL > 0) {
+		redisClient, err := newRedisClientFromConfig(cfg)
+		if err != nil {
+			return nil, err
+		}
+		prefix := keyPrefix(cfg)
+		if s.dedup == nil {
+			tracker := dedup.NewTracker(redisClient, prefix, cfg.Service.DedupTTL, cfg.Service.DedupLease, log)
+			if bloom := cfg.Service.DedupBloom; bloom.Enabled {
+				tracker.UseBloomFilter(dedup.NewBloomFilter(redisClient, prefix, bloom.Capacity, bloom.FalsePositiveRate, bloom.Refresh, log))
+			}
+			s.dedup = tracker
+			if s.pauses == nil {
+				s.pauses = pause.NewSwitch(redisClient, prefix, log)
+			}
+			if s.checkpoints == nil {
+				s.checkpoints = checkpoint.NewStore(redisClient, prefix)
+			}
+			if s.runTokens == nil {
+				s.runTokens = runtoken.NewStore(redisClient, prefix, cfg.Service.RunTokenTTL)
+			}
+		}
+		if needNearDups {
+			s.nearDups = dedup.NewNearDuplicateIndex(redisClient, prefix, cfg.Service.NearDuplicates.Threshold, cfg.Service.NearDuplicates.Window, log)
+		}
+		if needHistory {
+			s.history = history.NewStore(redisClient, prefix, cfg.History.Retention, log)
+		}
+		if needTimeline {
+			s.timeline = timeline.NewStore(redisClient, prefix, cfg.Timeline.TTL)
+		}
+		if needPosts {
+			s.posts = consistency.NewStore(redisClient, prefix, cfg.Consistency.Window)
+		}
+		if needSuppressions {
+			s.suppressed = dedup.NewSuppressionList(redisClient, prefix)
+		}
+		if needWarmup {
+			s.warmups = warmup.NewStore(redisClient, prefix)
+		}
+		if needCanary {
+			s.canaries = canary.NewStore(redisClient, prefix)
+		}
+		if needVolumes {
+			s.volumes = volume.NewStore(redisClient, prefix)
+		}
+		if needQuotas {
+			s.quotas = quota.NewStore(redisClient, prefix)
+		}
+		if needTranslator && cfg.Translation.CacheTTL > 0 {
+			translationCache = translate.NewCache(redisClient, prefix, cfg.Translation.CacheTTL)
+		}
+		if needQueue {
+			queue, err := newQueueFromConfig(cfg, redisClient, log)
+			if err != nil {
+				return nil, err
+			}
+			s.queue = queue
+		}
+	}
+	if needTranslator {
+		translation := cfg.Translation
+		client, err := translate.NewClient(translation.Provider, translation.URL, translation.APIKey, translation.Timeout, cfg.UserAgent)
+		if err != nil {
+			return nil, fmt.Errorf("translation: %w", err)
+		}
+		s.translator = client
+		if translationCache != nil {
+			s.translator = translate.Cached(client, translationCache)
+		}
+	}
+
+	unmarked, err := dedup.OpenJournal(cfg.Service.MarkJournal)
+	if err != nil {
+		return nil, fmt.Errorf("service.mark_journal: %w", err)
+	}
+	s.unmarked = unmarked
+
+	if s.limiter == nil {
+		s.limiter = rate.NewLimiter(rate.Limit(cfg.Service.RateLimitRPS), cfg.Service.RateLimitRPS)
+	}
+
+	if cfg.Chaos.Enabled {
+		s.injectChaos()
+	}
+
+	// An empty timezone (configs not built by config.Load) loads as UTC
+	location, err := time.LoadLocation(cfg.Service.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("service timezone: %w", err)
+	}
+	s.dates = pipeline.NewDateParser(cfg.Service.DateLayouts, location)
+	s.titles = pipeline.NewTitleNormalizer(cfg.Service.Titles.StripPrefixes)
+	if cfg.Service.URLs.Normalize {
+		s.urls = pipeline.NewURLNormalizer(cfg.Service.URLs.StripParams, cfg.Service.URLs.ForceHTTPS)
+	}
+	if len(cfg.Hooks) > 0 {
+		s.hooks = hooks.NewRunner(cfg.Hooks, cfg.UserAgent)
+	}
+	if cfg.Monitoring.PingURL != "" {
+		pinger, err := ping.NewPinger(cfg.Monitoring.PingURL, cfg.Monitoring.PingTimeout, cfg.UserAgent)
+		if err != nil {
+			return nil, fmt.Errorf("monitoring: %w", err)
+		}
+		s.pinger = pinger
+	}
+	if s.links == nil && cfg.Service.LinkCheck.Enabled {
+		linkCfg := cfg.Service.LinkCheck
+		s.links = linkcheck.NewChecker(linkCfg.Timeout, linkCfg.PerDomainRPS, linkCfg.CacheTTL, linkCfg.PaywallPatterns, cfg.UserAgent, log)
+	}
+
+	// Set initial last check time
+	lookbackDuration := time.Duration(cfg.Service.LookbackHours) * time.Hour
+	s.lastCheckTS = time.Now().Add(-lookbackDuration)
+	s.lastRunEnd = time.Now()
+
+	return s, nil
+}
+
+// newElasticsearchSourceFromConfig builds the default Elasticsearch source.
+func newElasticsearchSourceFromConfig(cfg *config.Config) (pipeline.Source, error) {
 	esCfg := elasticsearch.Config{
-		Addresses: []string{cfg.Elasticsearch.URL},
+		CloudID:      cfg.Elasticsearch.CloudID,
+		APIKey:       cfg.Elasticsearch.APIKey,
+		ServiceToken: cfg.Elasticsearch.ServiceToken,
+	}
+	if cfg.Elasticsearch.URL != "" {
+		esCfg.Addresses = []string{cfg.Elasticsearch.URL}
 	}
 	if cfg.Elasticsearch.Username != "" {
 		esCfg.Username = cfg.Elasticsearch.Username
 		esCfg.Password = cfg.Elasticsearch.Password
 	}
+	if cfg.UserAgent != "" {
+		esCfg.Header = http.Header{"User-Agent": []string{cfg.UserAgent}}
+	}
+	if cfg.Elasticsearch.Proxy != "" {
+		transport, err := netproxy.Transport(cfg.Elasticsearch.Proxy)
+		if err != nil {
+			return nil, fmt.Errorf("elasticsearch proxy: %w", err)
+		}
+		esCfg.Transport = transport
+	}
 
 	esClient, err := elasticsearch.NewClient(esCfg)
 	if err != nil {
 		return nil, fmt.Errorf("elasticsearch client: %w", err)
 	}
+	return pipeline.NewElasticsearchSource(esClient), nil
+}
+
+// newQueueFromConfig builds the outbox queue for the configured backend.
+func newQueueFromConfig(cfg *config.Config, redisClient *redis.Client, log logger.Logger) (outbox.Queue, error) {
+	if cfg.Outbox.Backend != config.OutboxBackendStream {
+		return outbox.NewListQueue(redisClient, keyPrefix(cfg), cfg.Outbox.ClaimIdle, log), nil
+	}
 
-	// Initialize Drupal client
-	drupalClient, err := drupal.NewClient(cfg.Drupal.URL, cfg.Drupal.Username, cfg.Drupal.Token, cfg.Drupal.AuthMethod, cfg.Drupal.SkipTLSVerify, log)
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	queue, err := outbox.NewStreamQueue(ctx, redisClient, keyPrefix(cfg), cfg.Outbox.ConsumerName, cfg.Outbox.ClaimIdle, log)
 	if err != nil {
-		return nil, fmt.Errorf("drupal client: %w", err)
+		return nil, fmt.Errorf("outbox stream: %w", err)
+	}
+	return queue, nil
+}
+
+// keyPrefix returns redis.key_prefix, or the default for configs not built by config.Load.
+func keyPrefix(cfg *config.Config) string {
+	if cfg.Redis.KeyPrefix == "" {
+		return config.DefaultKeyPrefix
 	}
+	return cfg.Redis.KeyPrefix
+}
 
-	// Initialize Redis for deduplication
-	redisClient := redis.NewClient(&redis.Options{
-		Addr:     cfg.Redis.URL,
-		Password: cfg.Redis.Password,
-		DB:       cfg.Redis.DB,
-	})
+// newRedisClientFromConfig connects to Redis and verifies the connection.
+func newRedisClientFromConfig(cfg *config.Config) (*redis.Client, error) {
+	opts, err := redisconn.Options(cfg.Redis)
+	if err != nil {
+		return nil, err
+	}
+	redisClient := redis.NewClient(opts)
 
 	// Test Redis connection
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
 	defer cancel()
 	if err := redisClient.Ping(ctx).Err(); err != nil {
-		return nil, fmt.Errorf("redis connection: %w", err)
+		return nil, fmt.Errorf("redis connection: %w: %w", ErrBackendUnavailable, err)
 	}
 
-	dedupTracker := dedup.NewTracker(redisClient, cfg.Service.DedupTTL, log)
+	return redisClient, nil
+}
 
-	// Initialize rate limiter
-	limiter := rate.NewLimiter(rate.Limit(cfg.Service.RateLimitRPS), cfg.Service.RateLimitRPS)
+// probeDrupalSite detects Drupal site features and warns about configuration that
+// the site does not appear to support.
+func probeDrupalSite(client *drupal.Client, cfg *config.Config, log logger.Logger) {
+	ctx, cancel := context.WithTimeout(context.Background(), drupalProbeTimeout)
+	defer cancel()
 
-	// Set initial last check time
-	lookbackDuration := time.Duration(cfg.Service.LookbackHours) * time.Hour
-	lastCheckTS := time.Now().Add(-lookbackDuration)
-
-	return &Service{
-		esClient:    esClient,
-		drupal:      drupalClient,
-		dedup:       dedupTracker,
-		limiter:     limiter,
-		config:      cfg,
-		logger:      log,
-		lastCheckTS: lastCheckTS,
-	}, nil
+	info, err := client.Probe(ctx)
+	if err != nil {
+		log.Warn("Drupal feature detection failed, using default endpoints",
+			logger.String("drupal_url", cfg.Drupal.URL),
+			logger.Error(err),
+		)
+		return
+	}
+
+	const minSupportedDrupalVersion = 9
+	if version, convErr := strconv.Atoi(info.DrupalVersion); convErr == nil && version < minSupportedDrupalVersion {
+		log.Warn("Drupal version is older than supported",
+			logger.String("drupal_version", info.DrupalVersion),
+			logger.Int("min_supported_version", minSupportedDrupalVersion),
+		)
+	}
+	if len(info.ResourceTypes) == 0 {
+		return
+	}
+	if !info.HasResourceType(cfg.Service.ContentType) {
+		log.Warn("Configured content type is not exposed by Drupal JSON:API",
+			logger.String("content_type", cfg.Service.ContentType),
+			logger.Strings("resource_types", info.ResourceTypeNames()),
+		)
+	}
+	if cfg.Service.GroupType != "" && !info.HasResourceType(cfg.Service.GroupType) {
+		log.Warn("Configured group type is not exposed by Drupal JSON:API",
+			logger.String("group_type", cfg.Service.GroupType),
+		)
+	}
 }
 
-type Article struct {
-	ID            string    `json:"id"`
-	Title         string    `json:"title"`          // Maps to ESFieldTitle
-	Content       string    `json:"body"`           // Maps to ESFieldBody
-	URL           string    `json:"canonical_url"`  // Maps to ESFieldCanonicalURL
-	PublishedAt   time.Time `json:"published_date"` // Maps to ESFieldPublishedDate
-	Source        string    `json:"source"`         // Maps to ESFieldSource
-	Intro         string    `json:"intro,omitempty"`
-	Description   string    `json:"description,omitempty"`
-	OGTitle       string    `json:"og_title,omitempty"`
-	OGDescription string    `json:"og_description,omitempty"`
-	OGImage       string    `json:"og_image,omitempty"`
-	OGURL         string    `json:"og_url,omitempty"`
-	WordCount     int       `json:"word_count,omitempty"`
-	Category      string    `json:"category,omitempty"`
-	Section       string    `json:"section,omitempty"`
-	Keywords      []string  `json:"keywords,omitempty"`
+// searchWindow bounds an article search by published date. Zero bounds are open.
+type searchWindow struct {
+	since time.Time
+	until time.Time
 }
 
-func (s *Service) FindCrimeArticles(ctx context.Context, cityCfg config.CityConfig) ([]Article, error) {
-	startTime := time.Now()
+// FindCrimeArticles returns crime articles for a city published since the last check
+// (or all matching articles when lookback_hours is not positive).
+func (s *Service) FindCrimeArticles(ctx context.Context, cityCfg config.CityConfig) ([]pipeline.Article, error) {
+	var window searchWindow
+	if s.config.Service.LookbackHours > 0 {
+		window.since = s.getLastCheckTS()
+	}
+	articles, err := s.findArticles(ctx, cityCfg, window)
+	if err != nil {
+		return nil, err
+	}
+	return s.percolate(ctx, cityCfg, articles)
+}
 
-	// Build Elasticsearch query
-	mustClauses := []map[string]any{
-		{
-			"multi_match": map[string]any{
-				"query":    strings.Join(s.config.Service.CrimeKeywords, " "),
-				"fields":   []string{ESFieldTitle + "^2", ESFieldBody},
-				"type":     "best_fields",
-				"operator": "or",
-			},
-		},
+// keywordClause matches articles mentioning any of keywords.
+func (s *Service) keywordClause(keywords []string) es.Query {
+	var fields, stemmed []string
+	for _, field := range s.searchedFields() {
+		fields = append(fields, field.name+field.boost)
+		if field.stemmed {
+			stemmed = append(stemmed, field.name+"."+s.config.Service.StemSubfield+field.boost)
+		}
 	}
+	if s.config.Service.KeywordMatch == config.KeywordMatchStem {
+		// Inflections only match on fields analyzed with a stemmer
+		fields = append(fields, stemmed...)
+	}
+	return es.MultiMatch{
+		Query:    strings.Join(keywords, " "),
+		Fields:   fields,
+		Type:     "best_fields",
+		Operator: "or",
+	}
+}
 
-	// Add date filter only if lookback_hours is positive
-	if s.config.Service.LookbackHours > 0 {
-		lastCheckTS := s.getLastCheckTS()
-		lastCheckStr := lastCheckTS.Format(time.RFC3339)
+// searchedField is an article field the keyword clause searches.
+type searchedField struct {
+	name    string
+	boost   string
+	stemmed bool // Whether it has a stemmed subfield
+}
+
+// searchedFields returns the fields the classifier reads, so Elasticsearch does not return
+// articles matching only in fields the classifier ignores.
+func (s *Service) searchedFields() []searchedField {
+	classified := s.config.Service.Classify.Fields
+	reads := func(field string) bool { return len(classified) == 0 || classified[field] > 0 }
+
+	var fields []searchedField
+	if reads(pipeline.ClassifyTitle) {
+		fields = append(fields, searchedField{name: pipeline.ESFieldTitle, boost: "^2", stemmed: true})
+	}
+	if reads(pipeline.ClassifyBody) {
+		fields = append(fields, searchedField{name: pipeline.ESFieldBody, stemmed: true})
+	}
+	if classified[pipeline.ClassifyTags] > 0 {
+		fields = append(fields, searchedField{name: pipeline.ESFieldKeywords})
+	}
+	return fields
+}
+
+// keywordMatchMode returns the classifier match mode for a service.keyword_match value.
+func keywordMatchMode(match string) pipeline.MatchMode {
+	switch match {
+	case config.KeywordMatchWord:
+		return pipeline.MatchWords
+	case config.KeywordMatchStem:
+		return pipeline.MatchStems
+	default:
+		return pipeline.MatchSubstrings
+	}
+}
+
+// builtinQuery builds the keyword search used for cities without a query template. With
+// the percolator enabled it only selects the window; the stored query does the matching.
+func (s *Service) builtinQuery(cityCfg config.CityConfig, window searchWindow) map[string]any {
+	mustClauses := []es.Query{}
+
+	// Add date filter only if the window is bounded
+	if !window.since.IsZero() || !window.until.IsZero() {
+		dateRange := es.Range{
+			Field:  pipeline.ESFieldPublishedDate,
+			Format: "strict_date_optional_time||epoch_millis",
+		}
+		if !window.since.IsZero() {
+			dateRange.GTE = window.since.In(s.dates.Location()).Format(time.RFC3339)
+		}
+		if !window.until.IsZero() {
+			dateRange.LTE = window.until.In(s.dates.Location()).Format(time.RFC3339)
+		}
 		s.logger.Debug("Searching for articles with date filter",
 			logger.String("city", cityCfg.Name),
-			logger.String("since", lastCheckStr),
+			logger.String("since", dateRange.GTE),
+			logger.String("until", dateRange.LTE),
 			logger.Int("lookback_hours", s.config.Service.LookbackHours),
 		)
-
-		mustClauses = append([]map[string]any{
-			{
-				"range": map[string]any{
-					ESFieldPublishedDate: map[string]any{
-						"gte": lastCheckStr,
-					},
-				},
-			},
-		}, mustClauses...)
+		mustClauses = append(mustClauses, dateRange)
 	} else {
 		s.logger.Debug("Searching for articles without date filter",
 			logger.String("city", cityCfg.Name),
 			logger.Int("lookback_hours", s.config.Service.LookbackHours),
 		)
 	}
-
-	query := map[string]any{
-		"query": map[string]any{
-			"bool": map[string]any{
-				"must": mustClauses,
-			},
-		},
-		"size": 100,
-		"sort": []map[string]any{
-			{
-				ESFieldPublishedDate: map[string]any{
-					"order": "desc",
-				},
-			},
-		},
-	}
-
-	var buf bytes.Buffer
-	if err := json.NewEncoder(&buf).Encode(query); err != nil {
-		return nil, fmt.Errorf("encode query: %w", err)
+	if !s.config.Percolator.Enabled {
+		mustClauses = append(mustClauses, s.keywordClause(s.crimeKeywords(cityCfg)))
 	}
 
-	// Execute search
-	index := cityCfg.Index
-	if index == "" {
-		index = fmt.Sprintf("%s_articles", cityCfg.Name)
+	query := es.Search{
+		Query: es.Bool{Must: mustClauses},
+		Size:  s.searchSize(),
+		Sort:  []es.Sort{{Field: pipeline.ESFieldPublishedDate, Order: "desc"}},
+	}.Source()
+	if s.config.Service.Highlight.Enabled {
+		query["highlight"] = s.highlightRequest(cityCfg)
 	}
+	return query
+}
 
-	// Log the query for debugging
-	queryJSON, _ := json.MarshalIndent(query, "", "  ")
-	s.logger.Debug("Elasticsearch query",
-		logger.String("query", string(queryJSON)),
-		logger.String("index_name", index),
-		logger.String("city", cityCfg.Name),
-	)
+// findArticles returns up to searchSize crime articles published within window, newest first.
+func (s *Service) findArticles(ctx context.Context, cityCfg config.CityConfig, window searchWindow) ([]pipeline.Article, error) {
+	startTime := time.Now()
 
-	// Create context with timeout for Elasticsearch query
-	queryCtx, queryCancel := context.WithTimeout(ctx, esQueryTimeout)
-	defer queryCancel()
+	query, err := s.searchQuery(cityCfg, window)
+	if err != nil {
+		return nil, err
+	}
 
-	queryStartTime := time.Now()
-	res, err := s.esClient.Search(
-		s.esClient.Search.WithContext(queryCtx),
-		s.esClient.Search.WithIndex(index),
-		s.esClient.Search.WithBody(&buf),
-		s.esClient.Search.WithTrackTotalHits(true),
-	)
-	queryDuration := time.Since(queryStartTime)
+	// Execute search
+	index := cityIndex(cityCfg)
 
-	if err != nil {
-		s.logger.Error("Elasticsearch search failed",
+	// Log the query for debugging; encoding it is skipped otherwise, since big queries
+	// (many keywords or cities) would be encoded on every search for nothing
+	if s.config.Debug {
+		queryJSON, _ := json.MarshalIndent(query, "", "  ")
+		s.logger.Debug("Elasticsearch query",
+			logger.String("query", string(queryJSON)),
 			logger.String("index_name", index),
 			logger.String("city", cityCfg.Name),
-			logger.Duration("query_duration", queryDuration),
-			logger.Error(err),
 		)
-		return nil, fmt.Errorf("search error: %w", err)
 	}
-	defer res.Body.Close()
+
+	// Cities sharing an index and search reuse the result of the first
+	var cacheKey string
+	if s.searches != nil || s.multi != nil {
+		cacheKey = searchKey(index, query)
+	}
+	queryStartTime := time.Now()
+	result, cached := s.searches.get(cacheKey, queryStartTime)
+	prefetched, multiSearched := s.prefetched.get(cacheKey)
+	switch {
+	case cached:
+	case multiSearched:
+		if prefetched.Err != nil {
+			s.logger.Error("Elasticsearch search failed",
+				logger.String("index_name", index),
+				logger.String("city", cityCfg.Name),
+				logger.Bool("multi_search", true),
+				logger.Error(prefetched.Err),
+			)
+			return nil, prefetched.Err
+		}
+		result = prefetched.Result
+		s.searches.put(cacheKey, result, queryStartTime)
+	default:
+		// Create context with timeout for Elasticsearch query
+		queryCtx, queryCancel := context.WithTimeout(ctx, esQueryTimeout)
+		defer queryCancel()
+
+		if s.usesStoredTemplate(cityCfg) {
+			params, _ := query["params"].(map[string]any)
+			result, err = s.stored.SearchTemplate(queryCtx, index, s.config.SearchTemplate.ID, params)
+		} else {
+			result, err = s.source.Search(queryCtx, index, query)
+		}
+		if err != nil {
+			s.logger.Error("Elasticsearch search failed",
+				logger.String("index_name", index),
+				logger.String("city", cityCfg.Name),
+				logger.Duration("query_duration", time.Since(queryStartTime)),
+				logger.Error(err),
+			)
+			return nil, err
+		}
+		s.searches.put(cacheKey, result, time.Now())
+	}
+	queryDuration := time.Since(queryStartTime)
 
 	s.logger.Debug("Elasticsearch query completed",
 		logger.String("index_name", index),
 		logger.String("city", cityCfg.Name),
 		logger.Duration("query_duration", queryDuration),
-		logger.String("status", res.Status()),
+		logger.Int("hit_count", len(result.Hits)),
+		logger.Bool("cached", cached),
+		logger.Bool("multi_search", multiSearched),
 	)
 
-	if res.IsError() {
-		var e map[string]any
-		if decodeErr := json.NewDecoder(res.Body).Decode(&e); decodeErr != nil {
-			s.logger.Error("Failed to decode Elasticsearch error response",
+	articles := make([]pipeline.Article, 0, len(result.Hits))
+	for _, hit := range result.Hits {
+		article, decodeErr := pipeline.DecodeArticle(hit.Source, s.dates)
+		if decodeErr != nil {
+			s.logger.Warn("Failed to decode article source",
+				logger.String("article_id", hit.ID),
 				logger.String("index_name", index),
 				logger.String("city", cityCfg.Name),
-				logger.String("status", res.Status()),
 				logger.Error(decodeErr),
 			)
-			return nil, fmt.Errorf("elasticsearch error response: %s", res.Status())
+			continue
 		}
-		s.logger.Error("Elasticsearch error",
-			logger.String("index_name", index),
-			logger.String("city", cityCfg.Name),
-			logger.String("status", res.Status()),
-			logger.Duration("query_duration", queryDuration),
-			logger.Any("error_details", e),
-		)
-		return nil, fmt.Errorf("elasticsearch error: %v", e)
-	}
-
-	var result struct {
-		Hits struct {
-			Total struct {
-				Value int `json:"value"`
-			} `json:"total"`
-			Hits []struct {
-				ID     string  `json:"_id"`
-				Source Article `json:"_source"`
-			} `json:"hits"`
-		} `json:"hits"`
-	}
-
-	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("decode response: %w", err)
-	}
-
-	articles := make([]Article, 0, len(result.Hits.Hits))
-	for i := range result.Hits.Hits {
-		hit := &result.Hits.Hits[i]
-		// Use Elasticsearch _id if article doesn't have an ID
-		if hit.Source.ID == "" {
-			hit.Source.ID = hit.ID
+		s.identify(cityCfg, hit, &article)
+		s.capBody(cityCfg, &article)
+		article.Highlights = highlightFragments(hit.Highlight)
+		if s.severity != nil {
+			article.Severity = s.severity.Score(article)
 		}
-		articles = append(articles, hit.Source)
+		articles = append(articles, article)
 	}
 
 	totalDuration := time.Since(startTime)
@@ -278,123 +745,201 @@ func (s *Service) FindCrimeArticles(ctx context.Context, cityCfg config.CityConf
 		logger.String("city", cityCfg.Name),
 		logger.String("index_name", index),
 		logger.Int("count", len(articles)),
-		logger.Int("total", result.Hits.Total.Value),
+		logger.Int("total", result.Total),
 		logger.Duration("duration", totalDuration),
 		logger.Duration("query_duration", queryDuration),
+		logger.Bool("cached", cached),
 	)
 
-	// If no articles found, log a sample query without keyword filter for debugging
-	if result.Hits.Total.Value == 0 && len(s.config.Service.CrimeKeywords) > 0 {
-		s.logger.Debug("No articles found, testing query without keyword filter",
+	return articles, nil
+}
+
+// ProcessCity finds, filters, and posts (or enqueues) one city's articles outside a run
+// and returns what happened to each of them.
+func (s *Service) ProcessCity(ctx context.Context, cityCfg config.CityConfig) (CityResult, error) {
+	return s.processCity(logger.WithContext(ctx, s.logger), cityCfg, nil)
+}
+
+// processCity finds, filters, and posts (or enqueues) one city's articles and
+// returns the per-city counts for the run report, with each article's outcome. ctx carries the run's logger, to which
+// the city is added for the stages below.
+func (s *Service) processCity(ctx context.Context, cityCfg config.CityConfig, run *runScope) (CityResult, error) {
+	ctx = logger.WithContext(ctx, logger.FromContext(ctx).With(logger.String("city", cityCfg.Name)))
+	if s.paused(ctx, cityCfg.Name) {
+		s.logger.Info("City skipped - paused",
+			logger.String("city", cityCfg.Name),
+		)
+		return CityResult{CityReport: CityReport{City: cityCfg.Name, Paused: true, Outcome: CitySkipped}}, nil
+	}
+
+	index := cityIndex(cityCfg)
+	if errType := s.indexClosed(index); errType != "" {
+		s.logger.Debug("City skipped - index closed or frozen",
 			logger.String("city", cityCfg.Name),
 			logger.String("index_name", index),
 		)
-		testQuery := map[string]any{
-			"query": map[string]any{
-				"match_all": map[string]any{},
-			},
-			"size": 1,
-		}
-		var testBuf bytes.Buffer
-		if err := json.NewEncoder(&testBuf).Encode(testQuery); err == nil {
-			testRes, err := s.esClient.Search(
-				s.esClient.Search.WithContext(ctx),
-				s.esClient.Search.WithIndex(index),
-				s.esClient.Search.WithBody(&testBuf),
-				s.esClient.Search.WithTrackTotalHits(true),
-			)
-			if err == nil {
-				defer testRes.Body.Close()
-				if !testRes.IsError() {
-					var testResult struct {
-						Hits struct {
-							Total struct {
-								Value int `json:"value"`
-							} `json:"total"`
-							Hits []struct {
-								Source map[string]any `json:"_source"`
-							} `json:"hits"`
-						} `json:"hits"`
-					}
-					if err := json.NewDecoder(testRes.Body).Decode(&testResult); err == nil {
-						s.logger.Debug("Index contains articles without filters",
-							logger.String("index_name", index),
-							logger.String("city", cityCfg.Name),
-							logger.Int("total_articles", testResult.Hits.Total.Value),
-						)
-						if len(testResult.Hits.Hits) > 0 {
-							s.logger.Debug("Sample article fields",
-								logger.String("index_name", index),
-								logger.String("city", cityCfg.Name),
-								logger.Any("sample_fields", testResult.Hits.Hits[0].Source),
-							)
-						}
-					} else {
-						s.logger.Debug("Failed to decode test query result",
-							logger.String("index_name", index),
-							logger.String("city", cityCfg.Name),
-							logger.Error(err),
-						)
-					}
-				}
-			}
-		}
+		return CityResult{CityReport: CityReport{City: cityCfg.Name, ClosedIndex: errType, Outcome: CitySkipped}}, nil
 	}
 
-	return articles, nil
-}
+	// Markers that failed earlier are written before the dedup checks see this city's articles
+	s.retryMarks(ctx)
+	articles, err := s.FindCrimeArticles(ctx, cityCfg)
+	if errType := s.closeIndex(index, err); errType != "" {
+		return CityResult{CityReport: CityReport{City: cityCfg.Name, ClosedIndex: errType, Outcome: CitySkipped}}, nil
+	}
+	if err != nil {
+		s.logger.Error("Failed to find articles",
+			logger.String("city", cityCfg.Name),
+			logger.Error(err),
+		)
+		return CityResult{CityReport: CityReport{City: cityCfg.Name, Failed: true, Outcome: CityFailed}}, fmt.Errorf("find articles: %w", err)
+	}
+	s.openIndex(index)
 
-func (s *Service) isCrimeRelated(article Article) bool {
-	content := strings.ToLower(article.Title + " " + article.Content)
-	for _, keyword := range s.config.Service.CrimeKeywords {
-		if strings.Contains(content, strings.ToLower(keyword)) {
-			return true
-		}
+	maxPosts := s.warmupLimit(ctx, cityCfg)
+	order := s.config.Service.PostOrder
+	if maxPosts > 0 {
+		order = s.config.Service.Warmup.Order
 	}
-	return false
+	var diagnosis *SearchDiagnosis
+	if len(articles) == 0 {
+		diagnosis = s.diagnoseEmptySearch(ctx, cityCfg)
+	}
+	sortArticles(articles, order)
+	newest := newestPublished(articles)
+	articles, deferredIDs := s.withDeferred(ctx, cityCfg, articles)
+	articles, truncated := s.capArticles(cityCfg, articles, 0)
+	report, err := s.processArticles(ctx, cityCfg, articles, maxPosts, run)
+	s.settleDeferred(ctx, cityCfg, deferredIDs, report)
+	report.Found += truncated
+	report.Truncated = truncated
+	report.Newest = newest
+	report.Diagnosis = diagnosis
+	report.SyncLag = s.measureSyncLag(ctx, cityCfg)
+	report.Outcome = report.classify()
+	if maxPosts > 0 && err == nil && !report.Failed {
+		s.recordWarmupRun(ctx, cityCfg)
+	}
+	return report, err
 }
 
-func (s *Service) ProcessCity(ctx context.Context, cityCfg config.CityConfig) error {
+// processArticles filters and posts (or enqueues) articles found for a city. When maxPosts
+// is positive, articles after the first maxPosts posted or queued are left for a later run.
+// Articles an earlier attempt of run processed are skipped.
+func (s *Service) processArticles(ctx context.Context, cityCfg config.CityConfig, articles []pipeline.Article, maxPosts int, run *runScope) (CityResult, error) {
 	startTime := time.Now()
-
-	articles, err := s.FindCrimeArticles(ctx, cityCfg)
+	report := CityResult{CityReport: CityReport{City: cityCfg.Name, Found: len(articles)}}
+	cityCfg, err := s.resolveGroup(cityCfg)
 	if err != nil {
-		s.logger.Error("Failed to find articles",
+		s.logger.Error("City skipped - group not resolved",
 			logger.String("city", cityCfg.Name),
 			logger.Error(err),
 		)
-		return fmt.Errorf("find articles: %w", err)
+		report.Failed = true
+		return report, err
 	}
-
-	posted := 0
-	skipped := 0
-	errors := 0
+	sourceFilter := s.sourceFilter(cityCfg)
+	classifier := s.classifierFor(cityCfg)
+	shadow := s.newCanaryShadow(cityCfg)
+	s.recordFound(ctx, cityCfg, articles)
 
 	s.logger.Debug("Processing articles",
 		logger.String("city", cityCfg.Name),
 		logger.Int("article_count", len(articles)),
 	)
 
+	// Posts run up to the city's post concurrency at once and are recorded in start order.
+	// Posts Drupal turns back (maintenance mode, rejected credentials) and the articles
+	// after holdFrom are held for a later run.
+	window := &postWindow{size: s.postConcurrency(cityCfg, maxPosts)}
+	holdFrom, heldPosts := -1, 0
+	finish := func(posts []*pendingPost) {
+		for _, post := range posts {
+			if !s.finishPost(ctx, cityCfg, run, &report, post, len(articles)) {
+				heldPosts++
+			}
+		}
+	}
+
 	for i := range articles {
+		if s.queue == nil && (s.inMaintenance() || s.credentialsRejected() || heldPosts > 0) {
+			holdFrom = i
+			break
+		}
+		if maxPosts > 0 && report.Posted+report.Queued >= maxPosts {
+			report.Deferred = len(articles) - i
+			s.logger.Info("City warming up - remaining articles deferred",
+				logger.String("city", cityCfg.Name),
+				logger.Int("max_posts", maxPosts),
+				logger.Int("deferred", report.Deferred),
+			)
+			break
+		}
+
 		article := &articles[i]
 		articleStartTime := time.Now()
 
+		if run.processedEarlier(article.ID) {
+			s.logger.Debug("Article skipped - processed by an earlier attempt of this run",
+				logger.String("article_id", article.ID),
+				logger.String("city", cityCfg.Name),
+				logger.String("run_token", run.token),
+			)
+			report.record(article, ArticleSkipped, "processed by an earlier attempt of the run")
+			continue
+		}
+
+		if !s.normalizeURL(cityCfg, article) {
+			report.record(article, ArticleSkipped, "invalid URL")
+			continue
+		}
+		shadow.count(*article)
+
+		if !sourceFilter.Allows(*article) {
+			s.logger.Debug("Article skipped - source not allowed",
+				logger.String("article_id", article.ID),
+				logger.String("city", cityCfg.Name),
+				logger.String("source", article.Source),
+				logger.String("url", article.URL),
+			)
+			report.record(article, ArticleSkipped, "source not allowed")
+			continue
+		}
+
 		// Additional crime filtering
-		if !s.isCrimeRelated(*article) {
+		if !classifier.Matches(*article) {
 			s.logger.Debug("Article skipped - not crime related",
 				logger.String("article_id", article.ID),
 				logger.String("city", cityCfg.Name),
 				logger.String("title", article.Title),
 				logger.Int("article_index", i+1),
 			)
-			skipped++
+			report.record(article, ArticleSkipped, "not crime related")
+			continue
+		}
+
+		if article.Severity < s.config.Service.Severity.MinScore {
+			s.logger.Debug("Article skipped - below severity threshold",
+				logger.String("article_id", article.ID),
+				logger.String("city", cityCfg.Name),
+				logger.Int("severity", article.Severity),
+				logger.Int("min_score", s.config.Service.Severity.MinScore),
+			)
+			report.record(article, ArticleSkipped, "below severity threshold")
+			continue
+		}
+
+		// Editors deleted it, or it was suppressed by hand
+		if s.suppressedArticle(ctx, cityCfg, article) {
+			s.markProcessed(ctx, run, article)
+			report.record(article, ArticleSkipped, "suppressed")
 			continue
 		}
 
 		// Check if already posted (with timeout)
 		dedupCtx, dedupCancel := context.WithTimeout(ctx, redisTimeout)
 		dedupStartTime := time.Now()
-		alreadyPosted := s.dedup.HasPosted(dedupCtx, article.ID)
+		alreadyPosted := s.hasPosted(dedupCtx, article.ID)
 		dedupDuration := time.Since(dedupStartTime)
 		dedupCancel()
 
@@ -411,150 +956,153 @@ func (s *Service) ProcessCity(ctx context.Context, cityCfg config.CityConfig) er
 				logger.String("city", cityCfg.Name),
 				logger.String("title", article.Title),
 			)
-			skipped++
+			s.stats.record(duplicateAlreadyPosted, article)
+			s.markProcessed(ctx, run, article)
+			report.record(article, ArticleSkipped, "already posted")
 			continue
 		}
+		s.recordStage(ctx, cityCfg, article, timeline.StageClassified, "")
 
-		// Rate limit
-		rateLimitStartTime := time.Now()
-		if err := s.limiter.Wait(ctx); err != nil {
-			s.logger.Error("Rate limit wait failed",
-				logger.String("article_id", article.ID),
-				logger.String("city", cityCfg.Name),
-				logger.Error(err),
-			)
-			return fmt.Errorf("rate limit wait: %w", err)
+		// Same story republished under a new ID (e.g. "UPDATE: ..." prefix)
+		if s.config.Service.Titles.Dedup && s.titlePosted(ctx, cityCfg, article, window) {
+			s.stats.record(duplicateTitleMatch, article)
+			s.recordStage(ctx, cityCfg, article, timeline.StageSkipped, "title already posted")
+			s.markProcessed(ctx, run, article)
+			report.record(article, ArticleSkipped, "title already posted")
+			continue
 		}
-		rateLimitDuration := time.Since(rateLimitStartTime)
 
-		s.logger.Debug("Rate limit wait completed",
-			logger.String("article_id", article.ID),
-			logger.String("city", cityCfg.Name),
-			logger.Duration("rate_limit_wait_duration", rateLimitDuration),
-		)
+		// Near-identical copy of a recently posted article from another outlet
+		if s.nearDuplicateOf(ctx, cityCfg, article, window) {
+			s.stats.record(duplicateNearMatch, article)
+			s.recordStage(ctx, cityCfg, article, timeline.StageSkipped, "near-duplicate of a posted article")
+			s.markProcessed(ctx, run, article)
+			report.record(article, ArticleSkipped, "near-duplicate of a posted article")
+			continue
+		}
 
-		// Post to Drupal (with timeout)
-		postCtx, postCancel := context.WithTimeout(ctx, drupalPostTimeout)
-		postStartTime := time.Now()
-		// Derive OG fields from canonical fields if not present (DRY principle)
-		// After crawler refactor: OG fields are only stored in ES if they differ from canonical values.
-		// If present in ES, use them; otherwise derive from canonical fields.
-		ogTitle := article.OGTitle
-		if ogTitle == "" {
-			ogTitle = article.Title
-		}
-		ogDescription := article.OGDescription
-		if ogDescription == "" {
-			// Prefer description, fallback to intro
-			if article.Description != "" {
-				ogDescription = article.Description
-			} else {
-				ogDescription = article.Intro
+		// Source link is gone or now behind a paywall
+		if !s.checkLink(ctx, cityCfg, article) {
+			report.BrokenLinks++
+			if s.config.Service.LinkCheck.Action != config.LinkCheckActionFlag {
+				s.recordStage(ctx, cityCfg, article, timeline.StageSkipped, "broken link")
+				s.markProcessed(ctx, run, article)
+				report.record(article, ArticleSkipped, "broken link")
+				continue
 			}
 		}
-		ogURL := article.OGURL
-		if ogURL == "" {
-			// Prefer canonical_url, fallback to source
-			if article.URL != "" {
-				ogURL = article.URL
-			} else {
-				ogURL = article.Source
-			}
+
+		// Group moderators cap syndicated posts per day
+		quotaDay, fits := s.takeQuota(ctx, cityCfg, article)
+		if !fits {
+			s.recordStage(ctx, cityCfg, article, timeline.StageSkipped, "group quota reached")
+			report.record(article, ArticleOverQuota, "group quota reached")
+			continue
 		}
 
-		postErr := s.drupal.PostArticle(postCtx, drupal.ArticleRequest{
-			Title:         article.Title,
-			Body:          article.Content,
-			URL:           article.URL,
-			GroupID:       cityCfg.GroupID,
-			GroupType:     s.config.Service.GroupType,
-			ContentType:   s.config.Service.ContentType,
-			ExternalID:    article.ID,
-			Intro:         article.Intro,
-			Description:   article.Description,
-			OGTitle:       ogTitle,
-			OGDescription: ogDescription,
-			OGImage:       article.OGImage, // og_image is unique, not duplicated
-			OGURL:         ogURL,
-			WordCount:     article.WordCount,
-			Category:      article.Category,
-			Section:       article.Section,
-			Keywords:      article.Keywords,
-			CanonicalURL:  article.URL, // canonical_url is the same as URL in our case
-			PublishedDate: article.PublishedAt,
-		})
-		postCancel()
-		if postErr != nil {
-			postDuration := time.Since(postStartTime)
-			articleDuration := time.Since(articleStartTime)
-			s.logger.Error("Error posting article",
-				logger.String("article_id", article.ID),
-				logger.String("city", cityCfg.Name),
-				logger.String("title", article.Title),
-				logger.String("url", article.URL),
-				logger.Duration("post_duration", postDuration),
-				logger.Duration("article_processing_duration", articleDuration),
-				logger.Error(postErr),
-			)
-			errors++
+		// Outbox mode: hand the candidate to the posting workers
+		if s.queue != nil {
+			if s.enqueueArticle(ctx, cityCfg, article, quotaDay) {
+				s.recordStage(ctx, cityCfg, article, timeline.StageQueued, "")
+				s.markProcessed(ctx, run, article)
+				report.record(article, ArticleQueued, "")
+				report.addHighlights(article)
+			} else {
+				s.releaseQuota(ctx, cityCfg, quotaDay)
+				report.record(article, ArticleSkipped, "not enqueued")
+			}
 			continue
 		}
-		postDuration := time.Since(postStartTime)
 
-		// Mark as posted (with timeout)
-		markCtx, markCancel := context.WithTimeout(ctx, redisTimeout)
-		markStartTime := time.Now()
-		markErr := s.dedup.MarkPosted(markCtx, article.ID)
-		markCancel()
-		if markErr != nil {
-			markDuration := time.Since(markStartTime)
-			s.logger.Warn("Failed to mark article as posted",
-				logger.String("article_id", article.ID),
-				logger.String("city", cityCfg.Name),
-				logger.Duration("mark_duration", markDuration),
-				logger.Error(markErr),
-			)
-		} else {
-			markDuration := time.Since(markStartTime)
-			s.logger.Debug("Article marked as posted",
-				logger.String("article_id", article.ID),
-				logger.String("city", cityCfg.Name),
-				logger.Duration("mark_duration", markDuration),
-			)
+		// Rate limit, shared by the city's concurrent posts
+		if err := s.waitForRateLimit(ctx, cityCfg, article); err != nil {
+			s.releaseQuota(ctx, cityCfg, quotaDay)
+			finish(window.drain())
+			report.Canary = shadow.report()
+			return report, fmt.Errorf("rate limit wait: %w", err)
 		}
 
-		posted++
-		articleDuration := time.Since(articleStartTime)
-		s.logger.Info("Posted article",
-			logger.String("title", article.Title),
+		// Reserve, post to Drupal, then promote the reservation to posted
+		reserved, reserveErr := s.reserveArticle(ctx, cityCfg, article)
+		if reserveErr != nil {
+			s.releaseQuota(ctx, cityCfg, quotaDay)
+			report.record(article, ArticleFailed, "reserve: "+reserveErr.Error())
+			continue
+		}
+		if !reserved {
+			s.releaseQuota(ctx, cityCfg, quotaDay)
+			report.record(article, ArticleSkipped, "reserved by another worker")
+			continue
+		}
+		post := &pendingPost{article: article, index: i, quotaDay: quotaDay, started: articleStartTime, titleKey: s.titleDedupKey(article)}
+		post.fingerprint, post.fingerprinted = s.bodyFingerprint(article)
+		finish(window.start(post, func(post *pendingPost) {
+			post.duration, post.err = s.postArticle(ctx, cityCfg, post.article)
+		}))
+		if heldPosts > 0 {
+			holdFrom = i + 1
+			break
+		}
+	}
+	finish(window.drain())
+	if holdFrom >= 0 || heldPosts > 0 {
+		if holdFrom < 0 {
+			holdFrom = len(articles)
+		}
+		report.Held = len(articles) - holdFrom + heldPosts
+		s.logger.Debug("City posting held - Drupal in maintenance mode or rejecting the credentials",
 			logger.String("city", cityCfg.Name),
-			logger.String("article_id", article.ID),
-			logger.String("url", article.URL),
-			logger.Duration("post_duration", postDuration),
-			logger.Duration("article_processing_duration", articleDuration),
-			logger.Int("article_index", i+1),
-			logger.Int("total_articles", len(articles)),
+			logger.Int("held", report.Held),
 		)
 	}
 
 	totalDuration := time.Since(startTime)
 	s.logger.Info("City processing completed",
 		logger.String("city", cityCfg.Name),
-		logger.Int("posted", posted),
-		logger.Int("skipped", skipped),
-		logger.Int("errors", errors),
+		logger.Int("posted", report.Posted),
+		logger.Int("queued", report.Queued),
+		logger.Int("skipped", report.Skipped),
+		logger.Int("deferred", report.Deferred),
+		logger.Int("over_quota", report.OverQuota),
+		logger.Int("held", report.Held),
+		logger.Int("errors", report.Errors),
 		logger.Int("total_articles", len(articles)),
 		logger.Duration("total_duration", totalDuration),
 	)
-	return nil
+	report.Canary = shadow.report()
+	return report, nil
 }
 
 func (s *Service) Run(ctx context.Context) error {
+	// In outbox mode runs only discover articles; workers post them continuously
+	if s.queue != nil {
+		var workers sync.WaitGroup
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			s.runPostingWorkers(ctx)
+		}()
+		defer workers.Wait()
+	}
+	if s.posts != nil && s.nodes != nil {
+		var checker sync.WaitGroup
+		checker.Add(1)
+		go func() {
+			defer checker.Done()
+			s.runConsistencyChecks(ctx)
+		}()
+		defer checker.Wait()
+	}
+
 	ticker := time.NewTicker(s.config.Service.CheckInterval)
 	defer ticker.Stop()
 
+	s.catchUp(ctx)
+	s.registerQueries(ctx)
+	s.adoptWarmupCities(ctx)
+
 	// Run immediately on start
-	if err := s.runOnce(ctx); err != nil {
+	if err := s.runOnce(ctx, ""); err != nil {
 		s.logger.Error("Initial run error",
 			logger.Error(err),
 		)
@@ -565,30 +1113,93 @@ func (s *Service) Run(ctx context.Context) error {
 		case <-ctx.Done():
 			return ctx.Err()
 		case <-ticker.C:
-			if err := s.runOnce(ctx); err != nil {
+			if err := s.runOnce(ctx, ""); err != nil {
 				s.logger.Error("Run error",
 					logger.Error(err),
 				)
 			}
+		case runToken := <-s.trigger:
+			if err := s.runOnce(ctx, runToken); err != nil {
+				s.logger.Error("Triggered run error",
+					logger.Error(err),
+				)
+			}
+			ticker.Reset(s.config.Service.CheckInterval)
 		}
 	}
 }
 
-func (s *Service) runOnce(ctx context.Context) error {
+// RunOnce performs Run's startup steps and a single run, then returns the run report, for
+// one-shot deployments such as cron jobs. In outbox mode the run only enqueues articles;
+// posting workers do not run.
+func (s *Service) RunOnce(ctx context.Context) (RunReport, error) {
+	s.catchUp(ctx)
+	s.registerQueries(ctx)
+	s.adoptWarmupCities(ctx)
+
+	if err := s.runOnce(ctx, ""); err != nil {
+		return RunReport{}, err
+	}
+	return s.Status().LastReport, nil
+}
+
+// runOnce processes every city. Runs triggered with a run token skip the articles that
+// earlier attempts with the same token processed.
+func (s *Service) runOnce(ctx context.Context, runToken string) error {
+	// The last check time is left alone, so the next run after resuming covers the pause
+	// Skipped runs still ping success, so a deliberate pause does not trip the monitor
+	if s.paused(ctx, "") {
+		s.logger.Info("Run skipped - posting paused")
+		s.mu.Lock()
+		s.lastRunEnd = time.Now()
+		s.mu.Unlock()
+		s.ping(ctx, "success")
+		return nil
+	}
+	if s.queue == nil && s.inMaintenance() {
+		s.logger.Info("Run skipped - Drupal in maintenance mode")
+		s.mu.Lock()
+		s.lastRunEnd = time.Now()
+		s.mu.Unlock()
+		s.ping(ctx, "success")
+		return nil
+	}
+	if s.queue == nil && s.credentialsRejected() {
+		s.logger.Warn("Run skipped - Drupal rejecting the credentials")
+		s.mu.Lock()
+		s.lastRunEnd = time.Now()
+		s.mu.Unlock()
+		s.ping(ctx, "fail")
+		return nil
+	}
+	s.ping(ctx, "start")
+
+	cities := s.refreshCities(ctx)
+	s.loadCanary(ctx)
+	run := s.startRun(ctx, runToken)
 	startTime := time.Now()
-	s.logger.Info("Starting article sync",
-		logger.Int("city_count", len(s.config.Cities)),
+	// Stages deep in the run log through logger.FromContext with the run ID attached
+	runLogger := s.logger.With(logger.String("run_id", requestid.New()))
+	ctx = logger.WithContext(ctx, runLogger)
+	runLogger.Info("Starting article sync",
+		logger.Int("city_count", len(cities)),
+		logger.String("run_token", runToken),
 	)
+	report := RunReport{StartedAt: startTime, RunToken: runToken}
+	s.prefetchSearches(ctx, cities)
+	defer s.prefetched.set(nil)
 
-	for i, cityCfg := range s.config.Cities {
+	for i, cityCfg := range cities {
 		cityStartTime := time.Now()
 		s.logger.Debug("Processing city",
 			logger.String("city", cityCfg.Name),
 			logger.Int("city_index", i+1),
-			logger.Int("total_cities", len(s.config.Cities)),
+			logger.Int("total_cities", len(cities)),
 		)
 
-		if err := s.ProcessCity(ctx, cityCfg); err != nil {
+		cityResult, err := s.processCity(ctx, cityCfg, run)
+		report.Cities = append(report.Cities, cityResult.CityReport)
+		if err != nil {
 			cityDuration := time.Since(cityStartTime)
 			s.logger.Error("Error processing city",
 				logger.String("city", cityCfg.Name),
@@ -607,26 +1218,172 @@ func (s *Service) runOnce(ctx context.Context) error {
 		}
 	}
 
-	// Update last check timestamp
+	s.retryMarks(ctx)
+
+	totalDuration := time.Since(startTime)
+	report.Duration = totalDuration
+	report.Dedup = s.stats.drain()
+	s.checkVolumes(ctx, &report)
+
+	// Update last check timestamp and report. Articles held for Drupal maintenance are
+	// searched again, so the last check time stays put until they are posted.
 	s.mu.Lock()
-	s.lastCheckTS = time.Now()
+	s.lastRunEnd = time.Now()
+	if !report.held() {
+		s.lastCheckTS = s.lastRunEnd
+	}
+	s.lastReport = report
+	s.totals.record(report)
 	s.mu.Unlock()
 
-	totalDuration := time.Since(startTime)
-	s.logger.Info("Article sync completed",
-		logger.Int("city_count", len(s.config.Cities)),
+	runLogger.Info("Article sync completed",
+		logger.Int("city_count", len(cities)),
+		logger.String("outcome", string(report.Outcome())),
 		logger.Duration("total_duration", totalDuration),
 	)
+	s.logRunReport(report)
+	s.saveHistory(ctx, report)
+	s.saveCheckpoint(ctx)
+	s.recordCanary(ctx, report)
+	s.runCompleteHooks(ctx, report)
+	if report.failed() || s.credentialsRejected() {
+		s.ping(ctx, "fail")
+	} else {
+		s.ping(ctx, "success")
+	}
 	return nil
 }
 
+// injectChaos wraps the backends with chaos.Injector fault injection. Each backend gets
+// its own seed derived from chaos.seed so their failure sequences are independent.
+func (s *Service) injectChaos() {
+	chaosCfg := s.config.Chaos
+	seed := func(offset uint64) uint64 {
+		if chaosCfg.Seed == 0 {
+			return 0
+		}
+		return chaosCfg.Seed + offset
+	}
+
+	s.logger.Warn("Chaos mode enabled: injecting backend failures and latency",
+		logger.Float64("elasticsearch_failure_rate", chaosCfg.Elasticsearch.FailureRate),
+		logger.Float64("drupal_failure_rate", chaosCfg.Drupal.FailureRate),
+		logger.Float64("redis_failure_rate", chaosCfg.Redis.FailureRate),
+	)
+	s.source = &chaos.Source{Source: s.source, Injector: chaos.NewInjector("elasticsearch", chaosCfg.Elasticsearch, seed(1), s.logger)}
+	s.poster = &chaos.Poster{Poster: s.poster, Injector: chaos.NewInjector("drupal", chaosCfg.Drupal, seed(2), s.logger)}
+	redisInjector := chaos.NewInjector("redis", chaosCfg.Redis, seed(3), s.logger)
+	s.dedup = &chaos.Tracker{Tracker: s.dedup, Injector: redisInjector}
+	if s.queue != nil {
+		s.queue = &chaos.Queue{Queue: s.queue, Injector: redisInjector}
+	}
+}
+
+// sourceFilter combines the global and per-city source lists. A city allow list
+// replaces the global one; block lists are merged. Cities using the canary candidate
+// configuration take its lists as the global ones.
+func (s *Service) sourceFilter(cityCfg config.CityConfig) *pipeline.SourceFilter {
+	allowed, blocked := s.config.Service.AllowedSources, s.config.Service.BlockedSources
+	if s.candidateApplies(cityCfg) {
+		if len(s.config.Canary.AllowedSources) > 0 {
+			allowed = s.config.Canary.AllowedSources
+		}
+		if len(s.config.Canary.BlockedSources) > 0 {
+			blocked = s.config.Canary.BlockedSources
+		}
+	}
+	return s.citySourceFilter(cityCfg, allowed, blocked)
+}
+
+// citySourceFilter combines the global lists given with the city's.
+func (s *Service) citySourceFilter(cityCfg config.CityConfig, allowed, blocked []string) *pipeline.SourceFilter {
+	if len(cityCfg.AllowedSources) > 0 {
+		allowed = cityCfg.AllowedSources
+	}
+	return pipeline.NewSourceFilter(allowed, slices.Concat(blocked, cityCfg.BlockedSources))
+}
+
+// cityIndex returns the Elasticsearch index searched for a city.
+func cityIndex(cityCfg config.CityConfig) string {
+	if cityCfg.Index != "" {
+		return cityCfg.Index
+	}
+	return fmt.Sprintf("%s_articles", cityCfg.Name)
+}
+
+// normalizeURL rewrites the article's canonical URL in place when URL normalization is
+// enabled. It returns false if the URL is invalid and service.urls.reject_invalid is set.
+func (s *Service) normalizeURL(cityCfg config.CityConfig, article *pipeline.Article) bool {
+	if s.urls == nil {
+		return true
+	}
+
+	normalized, err := s.urls.Normalize(article.URL, article.Source)
+	if err != nil {
+		if s.config.Service.URLs.RejectInvalid {
+			s.logger.Debug("Article skipped - invalid URL",
+				logger.String("article_id", article.ID),
+				logger.String("city", cityCfg.Name),
+				logger.String("url", article.URL),
+				logger.Error(err),
+			)
+			return false
+		}
+		s.logger.Debug("Posting article with unnormalized URL",
+			logger.String("article_id", article.ID),
+			logger.String("city", cityCfg.Name),
+			logger.String("url", article.URL),
+			logger.Error(err),
+		)
+		return true
+	}
+	article.URL = normalized
+	return true
+}
+
+// checkLink reports whether the article's URL is healthy. It returns true when the link
+// check is disabled, the article has no URL, or the check itself fails.
+func (s *Service) checkLink(ctx context.Context, cityCfg config.CityConfig, article *pipeline.Article) bool {
+	if s.links == nil || article.URL == "" {
+		return true
+	}
+
+	result, err := s.links.Check(ctx, article.URL)
+	if err != nil {
+		s.logger.Debug("Link check failed, assuming healthy",
+			logger.String("article_id", article.ID),
+			logger.String("city", cityCfg.Name),
+			logger.String("url", article.URL),
+			logger.Error(err),
+		)
+		return true
+	}
+	if !result.Broken {
+		return true
+	}
+
+	s.logger.Warn("Article link is broken",
+		logger.String("article_id", article.ID),
+		logger.String("city", cityCfg.Name),
+		logger.String("url", article.URL),
+		logger.String("final_url", result.FinalURL),
+		logger.Int("status_code", result.StatusCode),
+		logger.String("reason", result.Reason),
+		logger.String("action", s.config.Service.LinkCheck.Action),
+	)
+	return false
+}
+
 func (s *Service) getLastCheckTS() time.Time {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 	return s.lastCheckTS
 }
 
-// FlushCache flushes the Redis deduplication cache
+// FlushCache flushes the Redis deduplication cache and the mark journal
 func (s *Service) FlushCache(ctx context.Context) error {
-	return s.dedup.FlushAll(ctx)
+	if err := s.dedup.FlushAll(ctx); err != nil {
+		return err
+	}
+	return s.unmarked.Clear()
 }
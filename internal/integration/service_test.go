@@ -0,0 +1,3993 @@
+package integration_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gopost/integration/internal/buildinfo"
+	"github.com/gopost/integration/internal/canary"
+	"github.com/gopost/integration/internal/checkpoint"
+	"github.com/gopost/integration/internal/config"
+	"github.com/gopost/integration/internal/consistency"
+	"github.com/gopost/integration/internal/dedup"
+	"github.com/gopost/integration/internal/dedup/deduptest"
+	"github.com/gopost/integration/internal/es/estest"
+	"github.com/gopost/integration/internal/history"
+	"github.com/gopost/integration/internal/integration"
+	"github.com/gopost/integration/internal/linkcheck"
+	"github.com/gopost/integration/internal/logger"
+	"github.com/gopost/integration/internal/outbox"
+	"github.com/gopost/integration/internal/pause"
+	"github.com/gopost/integration/internal/quota"
+	"github.com/gopost/integration/internal/runtoken"
+	"github.com/gopost/integration/internal/timeline"
+	"github.com/gopost/integration/internal/translate"
+	"github.com/gopost/integration/internal/volume"
+	"github.com/gopost/integration/internal/warmup"
+	"github.com/gopost/integration/pkg/drupal"
+	"github.com/gopost/integration/pkg/drupal/drupaltest"
+	"github.com/gopost/integration/pkg/pipeline"
+	"golang.org/x/time/rate"
+)
+
+// fakeSearcher returns canned articles for every search.
+type fakeSearcher struct {
+	articles []map[string]any
+	queries  []any
+}
+
+func (f *fakeSearcher) Search(_ context.Context, _ string, query any) (*pipeline.SearchResult, error) {
+	f.queries = append(f.queries, query)
+	result := &pipeline.SearchResult{Total: len(f.articles)}
+	for _, article := range f.articles {
+		source, err := json.Marshal(article)
+		if err != nil {
+			return nil, err
+		}
+		result.Hits = append(result.Hits, pipeline.SearchHit{ID: article["id"].(string), Source: source})
+	}
+	return result, nil
+}
+
+// newTestConfig returns a minimal valid config for services built with fake dependencies.
+func newTestConfig() *config.Config {
+	return &config.Config{
+		Service: config.ServiceConfig{
+			CheckInterval: time.Minute,
+			RateLimitRPS:  100,
+			CrimeKeywords: []string{"police", "robbery"},
+			ContentType:   "node--article",
+			GroupType:     "group--crime_news",
+			DedupTTL:      time.Hour,
+		},
+	}
+}
+
+func TestProcessCity_WithFakeDependencies(t *testing.T) {
+	searcher := &fakeSearcher{articles: []map[string]any{
+		{"id": "ok", "title": "Police investigate robbery"},
+		{"id": "fails", "title": "Police make arrest"},
+		{"id": "other", "title": "Weather update"},
+	}}
+	poster := &drupaltest.Poster{
+		ErrFunc: func(req drupal.ArticleRequest) error {
+			if req.ExternalID == "fails" {
+				return errors.New("drupal unavailable")
+			}
+			return nil
+		},
+	}
+	tracker, _ := deduptest.NewTracker(t)
+
+	service, err := integration.NewService(newTestConfig(), logger.NewNopLogger(),
+		integration.WithSource(searcher),
+		integration.WithPoster(poster),
+		integration.WithTracker(tracker),
+		integration.WithLimiter(rate.NewLimiter(rate.Inf, 1)),
+	)
+	if err != nil {
+		t.Fatalf("NewService() error = %v", err)
+	}
+
+	result, err := service.ProcessCity(context.Background(), config.CityConfig{Name: "sudbury_com"})
+	if err != nil {
+		t.Fatalf("ProcessCity() error = %v", err)
+	}
+
+	if poster.Attempts() != 2 {
+		t.Errorf("Attempts() = %d, want 2 (non-crime article must be skipped)", poster.Attempts())
+	}
+	if result.Found != 3 || result.Posted != 1 || result.Errors != 1 || result.Skipped != 1 {
+		t.Errorf("result counts = %+v, want 3 found, 1 posted, 1 error, 1 skipped", result.CityReport)
+	}
+	if result.Outcome != integration.CityPartial {
+		t.Errorf("Outcome = %q, want %q", result.Outcome, integration.CityPartial)
+	}
+	outcomes := make(map[string]integration.ArticleOutcome)
+	for _, article := range result.Articles {
+		outcomes[article.ArticleID] = article.Outcome
+	}
+	wantOutcomes := map[string]integration.ArticleOutcome{
+		"ok":    integration.ArticlePosted,
+		"fails": integration.ArticleFailed,
+		"other": integration.ArticleSkipped,
+	}
+	if !reflect.DeepEqual(outcomes, wantOutcomes) {
+		t.Errorf("article outcomes = %v, want %v", outcomes, wantOutcomes)
+	}
+	if len(searcher.queries) != 1 {
+		t.Errorf("searched %d times, want 1", len(searcher.queries))
+	}
+	ctx := context.Background()
+	if !tracker.HasPosted(ctx, "ok") {
+		t.Error("successful article was not marked as posted")
+	}
+	if tracker.HasPosted(ctx, "fails") {
+		t.Error("failed article must not be marked as posted")
+	}
+}
+
+func TestRunReport_Outcome(t *testing.T) {
+	tests := []struct {
+		name     string
+		outcomes []integration.CityOutcome
+		want     integration.RunOutcome
+	}{
+		{"all succeeded", []integration.CityOutcome{integration.CitySucceeded, integration.CitySkipped}, integration.RunSucceeded},
+		{"one partial", []integration.CityOutcome{integration.CitySucceeded, integration.CityPartial}, integration.RunPartial},
+		{"some failed", []integration.CityOutcome{integration.CityFailed, integration.CitySucceeded}, integration.RunPartial},
+		{"every searched city failed", []integration.CityOutcome{integration.CityFailed, integration.CitySkipped, integration.CityFailed}, integration.RunFailed},
+		{"nothing searched", []integration.CityOutcome{integration.CitySkipped}, integration.RunSucceeded},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var report integration.RunReport
+			for _, outcome := range tt.outcomes {
+				report.Cities = append(report.Cities, integration.CityReport{Outcome: outcome})
+			}
+			if got := report.Outcome(); got != tt.want {
+				t.Errorf("Outcome() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProcessCity_EndToEnd(t *testing.T) {
+	esServer := estest.NewServer(t)
+	esServer.AddHits("sudbury_com_articles",
+		estest.Hit{ID: "es-1", Source: map[string]any{
+			"title":          "Police arrest suspect after robbery",
+			"body":           "<p>Officers charged a man on Tuesday.</p>",
+			"canonical_url":  "https://example.com/robbery",
+			"published_date": "2025-01-15T10:30:00Z",
+			"source":         "example.com",
+		}},
+		estest.Hit{ID: "es-2", Source: map[string]any{
+			"title": "Farmers market opens for the season",
+			"body":  "<p>Fresh produce downtown.</p>",
+		}},
+	)
+	drupalServer := drupaltest.NewServer(t, "gopost", "secret")
+	redisServer := miniredis.RunT(t)
+
+	cfg := &config.Config{
+		Elasticsearch: config.ElasticsearchConfig{URL: esServer.URL},
+		Drupal:        config.DrupalConfig{URL: drupalServer.URL, Username: "gopost", Token: "secret"},
+		Redis:         config.RedisConfig{URL: redisServer.Addr()},
+		Service: config.ServiceConfig{
+			CheckInterval: time.Minute,
+			RateLimitRPS:  100,
+			CrimeKeywords: []string{"police", "robbery"},
+			ContentType:   "node--article",
+			GroupType:     "group--crime_news",
+			DedupTTL:      time.Hour,
+		},
+	}
+	city := config.CityConfig{Name: "sudbury_com", GroupID: "e3d024a6-5f6f-4be8-8f3d-75639075959c"}
+
+	service, err := integration.NewService(cfg, logger.NewNopLogger())
+	if err != nil {
+		t.Fatalf("NewService() error = %v", err)
+	}
+
+	if _, err := service.ProcessCity(context.Background(), city); err != nil {
+		t.Fatalf("ProcessCity() error = %v", err)
+	}
+
+	nodes := drupalServer.Nodes()
+	if len(nodes) != 1 {
+		t.Fatalf("posted %d nodes, want 1", len(nodes))
+	}
+	if got := nodes[0].Article.Data.Attributes.FieldExternalID; got != "es-1" {
+		t.Errorf("field_external_id = %q, want %q", got, "es-1")
+	}
+	if !redisServer.Exists("gopost:posted:article:es-1") {
+		t.Error("expected es-1 to be marked as posted")
+	}
+
+	// A second pass must not repost the same article
+	if _, err := service.ProcessCity(context.Background(), city); err != nil {
+		t.Fatalf("second ProcessCity() error = %v", err)
+	}
+	if len(drupalServer.Nodes()) != 1 {
+		t.Errorf("posted %d nodes after second pass, want 1", len(drupalServer.Nodes()))
+	}
+}
+
+func TestRun_OutboxModePostsThroughWorkers(t *testing.T) {
+	searcher := &fakeSearcher{articles: []map[string]any{
+		{"id": "a", "title": "Police investigate robbery"},
+		{"id": "b", "title": "Police make arrest"},
+	}}
+	poster := &drupaltest.Poster{}
+	tracker, mr := deduptest.NewTracker(t)
+	queue := outbox.NewListQueue(deduptest.NewClient(t, mr), deduptest.KeyPrefix, time.Minute, logger.NewNopLogger())
+
+	cfg := newTestConfig()
+	cfg.Service.CheckInterval = time.Hour
+	cfg.Cities = []config.CityConfig{{Name: "sudbury_com"}}
+	cfg.Outbox = config.OutboxConfig{Enabled: true, Workers: 2, MaxAttempts: 3}
+
+	service, err := integration.NewService(cfg, logger.NewNopLogger(),
+		integration.WithSource(searcher),
+		integration.WithPoster(poster),
+		integration.WithTracker(tracker),
+		integration.WithLimiter(rate.NewLimiter(rate.Inf, 1)),
+		integration.WithQueue(queue),
+	)
+	if err != nil {
+		t.Fatalf("NewService() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- service.Run(ctx) }()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(poster.Posted()) < 2 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	if got := len(poster.Posted()); got != 2 {
+		t.Fatalf("posted %d articles, want 2", got)
+	}
+	for _, id := range []string{"a", "b"} {
+		if !tracker.HasPosted(context.Background(), id) {
+			t.Errorf("article %q was not marked as posted", id)
+		}
+	}
+}
+
+func TestRun_ReportsDedupEffectiveness(t *testing.T) {
+	searcher := &fakeSearcher{articles: []map[string]any{
+		{"id": "new", "title": "Police investigate robbery", "source": "sudbury.com"},
+		{"id": "seen-1", "title": "Police make arrest", "source": "sudbury.com"},
+		{"id": "seen-2", "title": "Robbery suspect charged", "source": "ctvnews.ca"},
+		{"id": "other", "title": "Weather update", "source": "sudbury.com"},
+	}}
+	tracker, _ := deduptest.NewTracker(t)
+	for _, id := range []string{"seen-1", "seen-2"} {
+		if err := tracker.MarkPosted(context.Background(), id); err != nil {
+			t.Fatalf("MarkPosted(%q) error = %v", id, err)
+		}
+	}
+
+	cfg := newTestConfig()
+	cfg.Service.CheckInterval = time.Hour
+	cfg.Cities = []config.CityConfig{{Name: "sudbury_com"}}
+
+	service, err := integration.NewService(cfg, logger.NewNopLogger(),
+		integration.WithSource(searcher),
+		integration.WithPoster(&drupaltest.Poster{}),
+		integration.WithTracker(tracker),
+		integration.WithLimiter(rate.NewLimiter(rate.Inf, 1)),
+	)
+	if err != nil {
+		t.Fatalf("NewService() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- service.Run(ctx) }()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for service.LastReport().StartedAt.IsZero() && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	report := service.LastReport()
+	if len(report.Cities) != 1 {
+		t.Fatalf("report has %d cities, want 1", len(report.Cities))
+	}
+	got := report.Cities[0]
+	if got.PostTime <= 0 {
+		t.Errorf("PostTime = %v, want the time spent posting", got.PostTime)
+	}
+	got.PostTime = 0
+	want := integration.CityReport{City: "sudbury_com", Found: 4, Posted: 1, Skipped: 3, Outcome: integration.CitySucceeded}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("city report = %+v, want %+v", got, want)
+	}
+	if report.Dedup.AlreadyPosted != 2 {
+		t.Errorf("AlreadyPosted = %d, want 2", report.Dedup.AlreadyPosted)
+	}
+	wantSources := []integration.SourceCount{{Source: "ctvnews.ca", Count: 1}, {Source: "sudbury.com", Count: 1}}
+	if len(report.Dedup.TopDuplicateSources) != 2 ||
+		report.Dedup.TopDuplicateSources[0] != wantSources[0] ||
+		report.Dedup.TopDuplicateSources[1] != wantSources[1] {
+		t.Errorf("TopDuplicateSources = %+v, want %+v", report.Dedup.TopDuplicateSources, wantSources)
+	}
+}
+
+func TestProcessCity_TitleDedupSkipsRepublishedStory(t *testing.T) {
+	searcher := &fakeSearcher{articles: []map[string]any{
+		{"id": "wire-1", "title": "BREAKING: Police charge man in robbery"},
+		{"id": "wire-2", "title": "UPDATE: Police charge man in robbery."},
+	}}
+	poster := &drupaltest.Poster{}
+	tracker, _ := deduptest.NewTracker(t)
+
+	cfg := newTestConfig()
+	cfg.Service.Titles = config.TitlesConfig{Dedup: true, CleanPosted: true}
+
+	service, err := integration.NewService(cfg, logger.NewNopLogger(),
+		integration.WithSource(searcher),
+		integration.WithPoster(poster),
+		integration.WithTracker(tracker),
+		integration.WithLimiter(rate.NewLimiter(rate.Inf, 1)),
+	)
+	if err != nil {
+		t.Fatalf("NewService() error = %v", err)
+	}
+
+	if _, err := service.ProcessCity(context.Background(), config.CityConfig{Name: "sudbury_com"}); err != nil {
+		t.Fatalf("ProcessCity() error = %v", err)
+	}
+
+	posted := poster.Posted()
+	if len(posted) != 1 {
+		t.Fatalf("posted %d articles, want 1", len(posted))
+	}
+	if posted[0].ExternalID != "wire-1" {
+		t.Errorf("posted %q, want wire-1", posted[0].ExternalID)
+	}
+	if posted[0].Title != "Police charge man in robbery" {
+		t.Errorf("posted title = %q, want prefix stripped", posted[0].Title)
+	}
+}
+
+func TestProcessCity_SuppressesNearDuplicateBodies(t *testing.T) {
+	body := strings.Repeat("Greater Sudbury Police arrested a man after a robbery at a convenience store downtown. ", 4) +
+		"Officers found the clerk uninjured and the suspect faces several charges."
+	searcher := &fakeSearcher{articles: []map[string]any{
+		{"id": "outlet-a", "title": "Man arrested after robbery", "body": body, "source": "sudbury.com"},
+		{"id": "outlet-b", "title": "Police arrest robbery suspect", "body": "SUDBURY - " + body, "source": "ctvnews.ca"},
+	}}
+	poster := &drupaltest.Poster{}
+	tracker, mr := deduptest.NewTracker(t)
+	index := dedup.NewNearDuplicateIndex(deduptest.NewClient(t, mr), deduptest.KeyPrefix, 0.9, time.Hour, logger.NewNopLogger())
+
+	service, err := integration.NewService(newTestConfig(), logger.NewNopLogger(),
+		integration.WithSource(searcher),
+		integration.WithPoster(poster),
+		integration.WithTracker(tracker),
+		integration.WithLimiter(rate.NewLimiter(rate.Inf, 1)),
+		integration.WithNearDuplicateIndex(index),
+	)
+	if err != nil {
+		t.Fatalf("NewService() error = %v", err)
+	}
+
+	if _, err := service.ProcessCity(context.Background(), config.CityConfig{Name: "sudbury_com"}); err != nil {
+		t.Fatalf("ProcessCity() error = %v", err)
+	}
+
+	posted := poster.Posted()
+	if len(posted) != 1 || posted[0].ExternalID != "outlet-a" {
+		t.Fatalf("posted %+v, want only outlet-a", posted)
+	}
+
+	// A later run's copy is found through the fingerprint buckets of the posted article
+	searcher.articles = []map[string]any{
+		{"id": "outlet-c", "title": "Robbery suspect charged", "body": body + " Court date set.", "source": "cp24.com"},
+	}
+	if _, err := service.ProcessCity(context.Background(), config.CityConfig{Name: "sudbury_com"}); err != nil {
+		t.Fatalf("second ProcessCity() error = %v", err)
+	}
+	if posted := poster.Posted(); len(posted) != 1 {
+		t.Errorf("posted %+v after the second run, want only outlet-a", posted)
+	}
+	buckets := 0
+	for _, key := range mr.Keys() {
+		if strings.HasPrefix(key, deduptest.KeyPrefix+"fingerprints:b") {
+			buckets++
+		}
+	}
+	if buckets == 0 {
+		t.Error("no fingerprint buckets stored")
+	}
+}
+
+func TestProcessCity_SourceLists(t *testing.T) {
+	searcher := &fakeSearcher{articles: []map[string]any{
+		{"id": "local", "title": "Police investigate robbery", "canonical_url": "https://www.sudbury.com/a"},
+		{"id": "paywalled", "title": "Police make arrest", "source": "paywall.example"},
+		{"id": "wire", "title": "Robbery suspect charged", "canonical_url": "https://ctvnews.ca/b"},
+	}}
+	poster := &drupaltest.Poster{}
+	tracker, _ := deduptest.NewTracker(t)
+
+	cfg := newTestConfig()
+	cfg.Service.BlockedSources = []string{"paywall.example"}
+
+	service, err := integration.NewService(cfg, logger.NewNopLogger(),
+		integration.WithSource(searcher),
+		integration.WithPoster(poster),
+		integration.WithTracker(tracker),
+		integration.WithLimiter(rate.NewLimiter(rate.Inf, 1)),
+	)
+	if err != nil {
+		t.Fatalf("NewService() error = %v", err)
+	}
+
+	city := config.CityConfig{Name: "sudbury_com", AllowedSources: []string{"sudbury.com", "paywall.example"}}
+	if _, err := service.ProcessCity(context.Background(), city); err != nil {
+		t.Fatalf("ProcessCity() error = %v", err)
+	}
+
+	posted := poster.Posted()
+	if len(posted) != 1 || posted[0].ExternalID != "local" {
+		t.Fatalf("posted %+v, want only local", posted)
+	}
+}
+
+func TestProcessCity_AttributesOutlets(t *testing.T) {
+	searcher := &fakeSearcher{articles: []map[string]any{
+		{"id": "local", "title": "Police investigate robbery", "body": "<p>Story.</p>", "canonical_url": "https://www.sudbury.com/a"},
+		{"id": "wire", "title": "Robbery suspect charged", "source": "CP", "canonical_url": "https://ctvnews.ca/b"},
+		{"id": "unknown", "title": "Police make arrest", "canonical_url": "https://other.example/c"},
+	}}
+	poster := &drupaltest.Poster{}
+	tracker, _ := deduptest.NewTracker(t)
+
+	cfg := newTestConfig()
+	cfg.Sources.TermType = "taxonomy_term--sources"
+	cfg.Sources.AttributionBody = true
+	cfg.Sources.Outlets = map[string]config.OutletConfig{
+		"sudbury.com": {Name: "Sudbury.com", Homepage: "https://www.sudbury.com", Terms: []string{"term-1"}},
+		"cp":          {Name: "The Canadian Press"},
+	}
+
+	service, err := integration.NewService(cfg, logger.NewNopLogger(),
+		integration.WithSource(searcher),
+		integration.WithPoster(poster),
+		integration.WithTracker(tracker),
+		integration.WithLimiter(rate.NewLimiter(rate.Inf, 1)),
+	)
+	if err != nil {
+		t.Fatalf("NewService() error = %v", err)
+	}
+
+	if _, err := service.ProcessCity(context.Background(), config.CityConfig{Name: "sudbury_com"}); err != nil {
+		t.Fatalf("ProcessCity() error = %v", err)
+	}
+
+	byID := make(map[string]drupal.ArticleRequest)
+	for _, req := range poster.Posted() {
+		byID[req.ExternalID] = req
+	}
+
+	local := byID["local"]
+	if local.SourceName != "Sudbury.com" || local.SourceURL != "https://www.sudbury.com" {
+		t.Errorf("local attribution = %q %q, want Sudbury.com homepage", local.SourceName, local.SourceURL)
+	}
+	if len(local.SourceTerms) != 1 || local.SourceTerms[0] != "term-1" || local.SourceTermType != "taxonomy_term--sources" {
+		t.Errorf("local terms = %v (%s), want [term-1]", local.SourceTerms, local.SourceTermType)
+	}
+	if want := `<p>Story.</p><p class="source-attribution">Source: <a href="https://www.sudbury.com">Sudbury.com</a></p>`; local.Body != want {
+		t.Errorf("local body = %q, want %q", local.Body, want)
+	}
+
+	if got := byID["wire"].SourceName; got != "The Canadian Press" {
+		t.Errorf("wire SourceName = %q, want source field match", got)
+	}
+	if unknown := byID["unknown"]; unknown.SourceName != "" || strings.Contains(unknown.Body, "source-attribution") {
+		t.Errorf("unmapped outlet was attributed: %+v", unknown)
+	}
+}
+
+func TestProcessCity_PopulatesFieldMap(t *testing.T) {
+	searcher := &fakeSearcher{articles: []map[string]any{
+		{
+			"id": "mapped", "title": "Police investigate robbery", "intro": "Police were called.", "body": "<p>Story.</p>",
+			"canonical_url": "https://www.sudbury.com/a", "published_date": "2025-12-09T09:30:00-05:00", "word_count": 120,
+		},
+		{"id": "sparse", "title": "Robbery suspect charged", "canonical_url": "https://www.sudbury.com/b"},
+	}}
+	poster := &drupaltest.Poster{}
+	tracker, _ := deduptest.NewTracker(t)
+
+	cfg := newTestConfig()
+	cfg.Service.FieldMap = []config.FieldMapConfig{
+		{Field: "field_teaser", Source: "body", Type: config.FieldTypeText, Format: "basic_html", Summary: "intro"},
+		{Field: "field_original", Source: "canonical_url", Type: config.FieldTypeLink, Title: "title"},
+		{Field: "field_event_date", Source: "published_date", Type: config.FieldTypeDate},
+		{Field: "field_words", Source: "word_count", Type: config.FieldTypeInteger},
+	}
+
+	service, err := integration.NewService(cfg, logger.NewNopLogger(),
+		integration.WithSource(searcher),
+		integration.WithPoster(poster),
+		integration.WithTracker(tracker),
+		integration.WithLimiter(rate.NewLimiter(rate.Inf, 1)),
+	)
+	if err != nil {
+		t.Fatalf("NewService() error = %v", err)
+	}
+	if _, err := service.ProcessCity(context.Background(), config.CityConfig{Name: "sudbury_com"}); err != nil {
+		t.Fatalf("ProcessCity() error = %v", err)
+	}
+
+	byID := make(map[string]drupal.ArticleRequest)
+	for _, req := range poster.Posted() {
+		byID[req.ExternalID] = req
+	}
+
+	fields := byID["mapped"].Fields
+	if got, want := fields["field_teaser"], (drupal.TextValue{Value: "<p>Story.</p>", Format: "basic_html", Summary: "Police were called."}); got != want {
+		t.Errorf("field_teaser = %+v, want %+v", got, want)
+	}
+	if got, want := fields["field_original"], (drupal.LinkValue{URI: "https://www.sudbury.com/a", Title: "Police investigate robbery"}); got != want {
+		t.Errorf("field_original = %+v, want %+v", got, want)
+	}
+	if date, ok := fields["field_event_date"].(drupal.DateValue); !ok || time.Time(date).Format(time.DateOnly) != "2025-12-09" {
+		t.Errorf("field_event_date = %#v, want 2025-12-09", fields["field_event_date"])
+	}
+	if got := fields["field_words"]; got != 120 {
+		t.Errorf("field_words = %#v, want 120", got)
+	}
+
+	// Only the link has a value for the sparse article; empty sources are left to Drupal's defaults
+	if sparse := byID["sparse"].Fields; len(sparse) != 1 || sparse["field_original"] == nil {
+		t.Errorf("sparse Fields = %+v, want only field_original", sparse)
+	}
+}
+
+func TestProcessCity_PopulatesMetatags(t *testing.T) {
+	longBody := "<p>Greater Sudbury Police are investigating a robbery at a convenience store on Elm Street " +
+		"early Tuesday morning. Officers were called at about 2 a.m. after a man threatened the clerk.</p>"
+	searcher := &fakeSearcher{articles: []map[string]any{
+		{"id": "intro", "title": "Police investigate robbery", "intro": "Police were called.", "canonical_url": "https://www.sudbury.com/a"},
+		{"id": "body", "title": "Robbery suspect charged", "body": longBody},
+	}}
+	poster := &drupaltest.Poster{}
+	tracker, _ := deduptest.NewTracker(t)
+
+	cfg := newTestConfig()
+	cfg.Service.FieldMap = []config.FieldMapConfig{{Field: "field_metatag", Type: config.FieldTypeMetatag}}
+
+	service, err := integration.NewService(cfg, logger.NewNopLogger(),
+		integration.WithSource(searcher),
+		integration.WithPoster(poster),
+		integration.WithTracker(tracker),
+		integration.WithLimiter(rate.NewLimiter(rate.Inf, 1)),
+	)
+	if err != nil {
+		t.Fatalf("NewService() error = %v", err)
+	}
+	if _, err := service.ProcessCity(context.Background(), config.CityConfig{Name: "sudbury_com"}); err != nil {
+		t.Fatalf("ProcessCity() error = %v", err)
+	}
+
+	byID := make(map[string]drupal.ArticleRequest)
+	for _, req := range poster.Posted() {
+		byID[req.ExternalID] = req
+	}
+	want := drupal.MetatagValue{
+		"canonical_url":  "https://www.sudbury.com/a",
+		"description":    "Police were called.",
+		"og_title":       "Police investigate robbery",
+		"og_description": "Police were called.",
+	}
+	if got := byID["intro"].Fields["field_metatag"]; !reflect.DeepEqual(got, want) {
+		t.Errorf("field_metatag = %#v, want %#v", got, want)
+	}
+	summary := "Greater Sudbury Police are investigating a robbery at a convenience store on Elm Street early " +
+		"Tuesday morning. Officers were called at about 2 a.m. after a man…"
+	want = drupal.MetatagValue{"description": summary, "og_title": "Robbery suspect charged", "og_description": summary}
+	if got := byID["body"].Fields["field_metatag"]; !reflect.DeepEqual(got, want) {
+		t.Errorf("field_metatag = %#v, want %#v", got, want)
+	}
+
+	encoded, err := json.Marshal(drupal.MetatagValue{"og_title": "Robbery"})
+	if err != nil || string(encoded) != `{"value":{"og_title":"Robbery"}}` {
+		t.Errorf("MetatagValue JSON = %s, %v", encoded, err)
+	}
+}
+
+func TestProcessCity_CapsBody(t *testing.T) {
+	searcher := &fakeSearcher{articles: []map[string]any{
+		{"id": "tag", "title": "Police investigate robbery", "body": "<p>Police were called.</p><p class=\"more\">Officers"},
+		{"id": "rune", "title": "Police investigate robbery", "body": "Police were called to the café robbery"},
+		{"id": "short", "title": "Police make arrest", "body": "<p>Short</p>"},
+	}}
+	poster := &drupaltest.Poster{}
+	tracker, _ := deduptest.NewTracker(t)
+
+	cfg := newTestConfig()
+	cfg.Service.MaxBodyBytes = 30
+
+	service, err := integration.NewService(cfg, logger.NewNopLogger(),
+		integration.WithSource(searcher),
+		integration.WithPoster(poster),
+		integration.WithTracker(tracker),
+		integration.WithLimiter(rate.NewLimiter(rate.Inf, 1)),
+	)
+	if err != nil {
+		t.Fatalf("NewService() error = %v", err)
+	}
+	if _, err := service.ProcessCity(context.Background(), config.CityConfig{Name: "sudbury_com"}); err != nil {
+		t.Fatalf("ProcessCity() error = %v", err)
+	}
+
+	want := map[string]string{
+		"tag":   "<p>Police were called.</p>",    // Not inside the <p class> tag at byte 30
+		"rune":  "Police were called to the caf", // Not inside é, at bytes 29 and 30
+		"short": "<p>Short</p>",
+	}
+	posted := poster.Posted()
+	if len(posted) != len(want) {
+		t.Fatalf("posted %d articles, want %d", len(posted), len(want))
+	}
+	for _, req := range posted {
+		if req.Body != want[req.ExternalID] {
+			t.Errorf("body of %s = %q, want %q", req.ExternalID, req.Body, want[req.ExternalID])
+		}
+	}
+}
+
+func TestProcessCity_FieldTransformers(t *testing.T) {
+	searcher := &fakeSearcher{articles: []map[string]any{
+		{"id": "a1", "title": "Robbery suspect charged", "body": "<p>Police charged a man.</p><p>He appears in court Monday.</p>"},
+	}}
+	poster := &drupaltest.Poster{}
+	tracker, _ := deduptest.NewTracker(t)
+
+	cfg := newTestConfig()
+	cfg.Service.FieldMap = []config.FieldMapConfig{
+		{Field: "field_summary", Source: "fn:first_paragraph", Type: config.FieldTypeString},
+		{Field: "field_title_length", Source: "fn:title_length", Type: config.FieldTypeString},
+		{Field: "field_broken", Source: "fn:broken", Type: config.FieldTypeString},
+	}
+	opts := []integration.Option{
+		integration.WithSource(searcher),
+		integration.WithPoster(poster),
+		integration.WithTracker(tracker),
+		integration.WithLimiter(rate.NewLimiter(rate.Inf, 1)),
+		integration.WithFieldTransformer("title_length", func(article pipeline.Article) (any, error) {
+			return len(article.Title), nil
+		}),
+	}
+
+	if _, err := integration.NewService(cfg, logger.NewNopLogger(), opts...); err == nil ||
+		!strings.Contains(err.Error(), `no field transformer registered as "broken"`) {
+		t.Fatalf("NewService() error = %v, want unregistered transformer error", err)
+	}
+
+	opts = append(opts, integration.WithFieldTransformer("broken", func(pipeline.Article) (any, error) {
+		return nil, errors.New("boom")
+	}))
+	service, err := integration.NewService(cfg, logger.NewNopLogger(), opts...)
+	if err != nil {
+		t.Fatalf("NewService() error = %v", err)
+	}
+	if _, err := service.ProcessCity(context.Background(), config.CityConfig{Name: "sudbury_com"}); err != nil {
+		t.Fatalf("ProcessCity() error = %v", err)
+	}
+
+	posted := poster.Posted()
+	if len(posted) != 1 {
+		t.Fatalf("posted %d articles, want 1", len(posted))
+	}
+	want := map[string]any{"field_summary": "Police charged a man.", "field_title_length": 23}
+	if !reflect.DeepEqual(posted[0].Fields, want) {
+		t.Errorf("Fields = %#v, want %#v", posted[0].Fields, want)
+	}
+}
+
+func TestProcessCity_NormalizesURLs(t *testing.T) {
+	searcher := &fakeSearcher{articles: []map[string]any{
+		{"id": "tracked", "title": "Police investigate robbery", "canonical_url": "http://sudbury.com/a?utm_source=fb#top"},
+		{"id": "relative", "title": "Police make arrest", "source": "sudbury.com", "canonical_url": "/local-news/b"},
+		{"id": "invalid", "title": "Robbery suspect charged", "source": "Sudbury News", "canonical_url": "/local-news/c"},
+	}}
+	poster := &drupaltest.Poster{}
+	tracker, _ := deduptest.NewTracker(t)
+
+	cfg := newTestConfig()
+	cfg.Service.URLs = config.URLsConfig{Normalize: true, ForceHTTPS: true, RejectInvalid: true}
+
+	service, err := integration.NewService(cfg, logger.NewNopLogger(),
+		integration.WithSource(searcher),
+		integration.WithPoster(poster),
+		integration.WithTracker(tracker),
+		integration.WithLimiter(rate.NewLimiter(rate.Inf, 1)),
+	)
+	if err != nil {
+		t.Fatalf("NewService() error = %v", err)
+	}
+
+	if _, err := service.ProcessCity(context.Background(), config.CityConfig{Name: "sudbury_com"}); err != nil {
+		t.Fatalf("ProcessCity() error = %v", err)
+	}
+
+	urls := make(map[string]string)
+	for _, req := range poster.Posted() {
+		urls[req.ExternalID] = req.URL
+	}
+	want := map[string]string{
+		"tracked":  "https://sudbury.com/a",
+		"relative": "https://sudbury.com/local-news/b",
+	}
+	if len(urls) != len(want) {
+		t.Fatalf("posted URLs = %v, want %v", urls, want)
+	}
+	for id, wantURL := range want {
+		if urls[id] != wantURL {
+			t.Errorf("URL of %s = %q, want %q", id, urls[id], wantURL)
+		}
+	}
+}
+
+func TestProcessCity_IdentifiesArticlesPerCityAndOutlet(t *testing.T) {
+	searcher := &fakeSearcher{articles: []map[string]any{
+		{"id": "crawl-1", "title": "Police investigate robbery", "canonical_url": "https://sudbury.com/a"},
+		{"id": "crawl-2", "title": "Police investigate robbery downtown", "canonical_url": "https://sudbury.com/a?utm_source=fb"},
+		{"id": "rss-1", "title": "Robbery suspect charged", "source": "CP", "guid": "cp-42", "canonical_url": "https://ctvnews.ca/b"},
+		{"id": "rss-2", "title": "Robbery suspect charged in court", "source": "CP", "guid": "cp-42", "canonical_url": "https://cbc.ca/c"},
+	}}
+	poster := &drupaltest.Poster{}
+	tracker, _ := deduptest.NewTracker(t)
+
+	cfg := newTestConfig()
+	cfg.Service.URLs = config.URLsConfig{Normalize: true}
+	cfg.Sources.Outlets = map[string]config.OutletConfig{"cp": {Name: "The Canadian Press", Identity: "field:guid"}}
+
+	service, err := integration.NewService(cfg, logger.NewNopLogger(),
+		integration.WithSource(searcher),
+		integration.WithPoster(poster),
+		integration.WithTracker(tracker),
+		integration.WithLimiter(rate.NewLimiter(rate.Inf, 1)),
+	)
+	if err != nil {
+		t.Fatalf("NewService() error = %v", err)
+	}
+
+	city := config.CityConfig{Name: "sudbury_com", Identity: "url"}
+	for range 2 {
+		if _, err := service.ProcessCity(context.Background(), city); err != nil {
+			t.Fatalf("ProcessCity() error = %v", err)
+		}
+	}
+
+	posted := poster.Posted()
+	if len(posted) != 2 {
+		t.Fatalf("posted %d articles, want one per URL and one per GUID: %+v", len(posted), posted)
+	}
+	byTitle := make(map[string]string)
+	for _, req := range posted {
+		byTitle[req.Title] = req.ExternalID
+	}
+	if id := byTitle["Police investigate robbery"]; !strings.HasPrefix(id, "url:") {
+		t.Errorf("external ID of the crawled article = %q, want its url: key", id)
+	}
+	if id := byTitle["Robbery suspect charged"]; id != "cp-42" {
+		t.Errorf("external ID of the CP article = %q, want its GUID", id)
+	}
+	if !tracker.HasPosted(context.Background(), "cp-42") {
+		t.Error("HasPosted(cp-42) = false, want the GUID marked as posted")
+	}
+}
+
+func TestProcessCity_LinkCheck(t *testing.T) {
+	site := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/gone" {
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(site.Close)
+
+	for _, action := range []string{config.LinkCheckActionSkip, config.LinkCheckActionFlag} {
+		t.Run(action, func(t *testing.T) {
+			searcher := &fakeSearcher{articles: []map[string]any{
+				{"id": "live", "title": "Police investigate robbery", "canonical_url": site.URL + "/live"},
+				{"id": "gone", "title": "Police make arrest", "canonical_url": site.URL + "/gone"},
+			}}
+			poster := &drupaltest.Poster{}
+			tracker, _ := deduptest.NewTracker(t)
+
+			cfg := newTestConfig()
+			cfg.Service.LinkCheck = config.LinkCheckConfig{Enabled: true, Action: action}
+
+			service, err := integration.NewService(cfg, logger.NewNopLogger(),
+				integration.WithSource(searcher),
+				integration.WithPoster(poster),
+				integration.WithTracker(tracker),
+				integration.WithLimiter(rate.NewLimiter(rate.Inf, 1)),
+				integration.WithLinkChecker(linkcheck.NewChecker(time.Second, 0, time.Minute, nil, "", logger.NewNopLogger())),
+			)
+			if err != nil {
+				t.Fatalf("NewService() error = %v", err)
+			}
+
+			if _, err := service.ProcessCity(context.Background(), config.CityConfig{Name: "sudbury_com"}); err != nil {
+				t.Fatalf("ProcessCity() error = %v", err)
+			}
+
+			wantPosted := 1
+			if action == config.LinkCheckActionFlag {
+				wantPosted = 2
+			}
+			if got := len(poster.Posted()); got != wantPosted {
+				t.Errorf("posted %d articles, want %d", got, wantPosted)
+			}
+		})
+	}
+}
+
+// windowSearcher serves articles newest first, honoring the published_date upper bound
+// and the page size, like Elasticsearch.
+type windowSearcher struct {
+	articles []map[string]any // Newest first
+}
+
+func (w *windowSearcher) Search(_ context.Context, _ string, query any) (*pipeline.SearchResult, error) {
+	encoded, err := json.Marshal(query)
+	if err != nil {
+		return nil, err
+	}
+	var parsed struct {
+		Size  int `json:"size"`
+		Query struct {
+			Bool struct {
+				Must []struct {
+					Range map[string]struct {
+						Lte string `json:"lte"`
+					} `json:"range"`
+				} `json:"must"`
+			} `json:"bool"`
+		} `json:"query"`
+	}
+	if err := json.Unmarshal(encoded, &parsed); err != nil {
+		return nil, err
+	}
+	var until time.Time
+	for _, clause := range parsed.Query.Bool.Must {
+		if lte := clause.Range["published_date"].Lte; lte != "" {
+			until, _ = time.Parse(time.RFC3339, lte)
+		}
+	}
+
+	result := &pipeline.SearchResult{}
+	for _, article := range w.articles {
+		published, _ := time.Parse(time.RFC3339, article["published_date"].(string))
+		if !until.IsZero() && published.After(until) {
+			continue
+		}
+		if len(result.Hits) == parsed.Size {
+			break
+		}
+		source, _ := json.Marshal(article)
+		result.Hits = append(result.Hits, pipeline.SearchHit{ID: article["id"].(string), Source: source})
+	}
+	result.Total = len(result.Hits)
+	return result, nil
+}
+
+func TestReplay_RevisitsWindowWithCurrentFilters(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+	searcher := &windowSearcher{}
+	for i := range 150 {
+		title := "Police investigate robbery"
+		if i%2 == 1 {
+			title = "Vandals strike downtown"
+		}
+		searcher.articles = append(searcher.articles, map[string]any{
+			"id":             fmt.Sprintf("a-%03d", i),
+			"title":          title,
+			"published_date": now.Add(-time.Duration(i) * time.Minute).Format(time.RFC3339),
+		})
+	}
+	tracker, _ := deduptest.NewTracker(t)
+	city := config.CityConfig{Name: "sudbury_com"}
+
+	newService := func(keywords ...string) (*integration.Service, *drupaltest.Poster) {
+		cfg := newTestConfig()
+		cfg.Service.CrimeKeywords = keywords
+		cfg.Cities = []config.CityConfig{city, {Name: "toronto_com"}}
+		poster := &drupaltest.Poster{}
+		service, err := integration.NewService(cfg, logger.NewNopLogger(),
+			integration.WithSource(searcher),
+			integration.WithPoster(poster),
+			integration.WithTracker(tracker),
+			integration.WithLimiter(rate.NewLimiter(rate.Inf, 1)),
+		)
+		if err != nil {
+			t.Fatalf("NewService() error = %v", err)
+		}
+		return service, poster
+	}
+
+	ctx := context.Background()
+	narrow, narrowPoster := newService("robbery")
+	if _, err := narrow.Replay(ctx, now.Add(-3*time.Hour), city.Name); err != nil {
+		t.Fatalf("Replay() error = %v", err)
+	}
+	if got := len(narrowPoster.Posted()); got != 75 {
+		t.Fatalf("first replay posted %d, want 75 robbery articles across both pages", got)
+	}
+
+	broad, broadPoster := newService("robbery", "vandals")
+	report, err := broad.Replay(ctx, now.Add(-3*time.Hour), city.Name)
+	if err != nil {
+		t.Fatalf("Replay() error = %v", err)
+	}
+	if got := len(broadPoster.Posted()); got != 75 {
+		t.Errorf("second replay posted %d, want only the 75 newly matching articles", got)
+	}
+	if len(report.Cities) != 1 || report.Cities[0].Found != 150 || report.Dedup.AlreadyPosted != 75 {
+		t.Errorf("report = %+v, want one city with 150 found and 75 already posted", report)
+	}
+
+	if _, err := broad.Replay(ctx, now, "hamilton"); err == nil {
+		t.Error("Replay() of unknown city error = nil")
+	}
+}
+
+func TestProcessCity_ChaosFailsPostsWithoutLosingArticles(t *testing.T) {
+	searcher := &fakeSearcher{articles: []map[string]any{
+		{"id": "a", "title": "Police investigate robbery"},
+		{"id": "b", "title": "Police make arrest"},
+	}}
+	poster := &drupaltest.Poster{}
+	tracker, _ := deduptest.NewTracker(t)
+
+	cfg := newTestConfig()
+	cfg.Chaos = config.ChaosConfig{Enabled: true, Seed: 1, Drupal: config.ChaosBackendConfig{FailureRate: 1}}
+
+	service, err := integration.NewService(cfg, logger.NewNopLogger(),
+		integration.WithSource(searcher),
+		integration.WithPoster(poster),
+		integration.WithTracker(tracker),
+		integration.WithLimiter(rate.NewLimiter(rate.Inf, 1)),
+	)
+	if err != nil {
+		t.Fatalf("NewService() error = %v", err)
+	}
+
+	if _, err := service.ProcessCity(context.Background(), config.CityConfig{Name: "sudbury_com"}); err != nil {
+		t.Fatalf("ProcessCity() error = %v", err)
+	}
+
+	if poster.Attempts() != 0 {
+		t.Errorf("Attempts() = %d, want 0 (every post fails before reaching Drupal)", poster.Attempts())
+	}
+	ctx := context.Background()
+	for _, id := range []string{"a", "b"} {
+		if tracker.HasPosted(ctx, id) {
+			t.Errorf("article %s is marked posted or still reserved after an injected failure", id)
+		}
+	}
+}
+
+func TestGroup_IsolatesTenants(t *testing.T) {
+	ctx := context.Background()
+	newTenant := func(city string, articles ...map[string]any) (*integration.Service, *drupaltest.Poster) {
+		cfg := newTestConfig()
+		cfg.Cities = []config.CityConfig{{Name: city}}
+		poster := &drupaltest.Poster{}
+		tracker, _ := deduptest.NewTracker(t)
+		service, err := integration.NewService(cfg, logger.NewNopLogger(),
+			integration.WithSource(&fakeSearcher{articles: articles}),
+			integration.WithPoster(poster),
+			integration.WithTracker(tracker),
+			integration.WithLimiter(rate.NewLimiter(rate.Inf, 1)),
+		)
+		if err != nil {
+			t.Fatalf("NewService() error = %v", err)
+		}
+		return service, poster
+	}
+
+	// Both tenants see an article with the same ID; separate dedup stores post it twice
+	shared := map[string]any{"id": "same", "title": "Police investigate robbery"}
+	north, northPoster := newTenant("north_city", shared)
+	south, southPoster := newTenant("south_city", shared, map[string]any{"id": "other", "title": "Police make arrest"})
+
+	group := integration.NewGroup()
+	group.Add("north", north)
+	group.Add("south", south)
+
+	report, err := group.Replay(ctx, time.Now().Add(-time.Hour), "")
+	if err != nil {
+		t.Fatalf("Replay() error = %v", err)
+	}
+	if len(northPoster.Posted()) != 1 || len(southPoster.Posted()) != 2 {
+		t.Errorf("posted north=%d south=%d, want 1 and 2", len(northPoster.Posted()), len(southPoster.Posted()))
+	}
+	if len(report.Cities) != 2 {
+		t.Errorf("report cities = %+v, want one per tenant", report.Cities)
+	}
+
+	if _, err := group.Replay(ctx, time.Now(), "south_city"); err != nil {
+		t.Errorf("Replay(south_city) error = %v", err)
+	}
+	if _, err := group.Replay(ctx, time.Now(), "nowhere"); err == nil {
+		t.Error("Replay(unknown city) error = nil")
+	}
+
+	status := group.Status()
+	if status.CityCount != 2 || len(status.Tenants) != 2 || status.Tenants["south"].CityCount != 1 {
+		t.Errorf("Status() = %+v, want per-tenant status", status)
+	}
+}
+
+func TestRunOnce_HonorsPauseFlags(t *testing.T) {
+	searcher := &fakeSearcher{articles: []map[string]any{
+		{"id": "a", "title": "Police investigate robbery"},
+	}}
+	poster := &drupaltest.Poster{}
+	tracker, mr := deduptest.NewTracker(t)
+	pauses := pause.NewSwitch(deduptest.NewClient(t, mr), deduptest.KeyPrefix, logger.NewNopLogger())
+
+	cfg := newTestConfig()
+	cfg.Cities = []config.CityConfig{{Name: "sudbury_com"}, {Name: "toronto_com"}}
+
+	service, err := integration.NewService(cfg, logger.NewNopLogger(),
+		integration.WithSource(searcher),
+		integration.WithPoster(poster),
+		integration.WithTracker(tracker),
+		integration.WithLimiter(rate.NewLimiter(rate.Inf, 1)),
+		integration.WithPauseSwitch(pauses),
+	)
+	if err != nil {
+		t.Fatalf("NewService() error = %v", err)
+	}
+	ctx := context.Background()
+
+	if err := service.Pause(ctx, "", "maintenance"); err != nil {
+		t.Fatalf("Pause() error = %v", err)
+	}
+	if _, err := service.ProcessCity(ctx, cfg.Cities[0]); err != nil {
+		t.Fatalf("ProcessCity() error = %v", err)
+	}
+	if len(searcher.queries) != 0 || poster.Attempts() != 0 {
+		t.Fatalf("searched %d times and posted %d while globally paused", len(searcher.queries), poster.Attempts())
+	}
+
+	if err := service.Resume(ctx, ""); err != nil {
+		t.Fatalf("Resume() error = %v", err)
+	}
+	if err := service.Pause(ctx, "sudbury_com", ""); err != nil {
+		t.Fatalf("Pause(city) error = %v", err)
+	}
+	if _, err := service.ProcessCity(ctx, cfg.Cities[0]); err != nil {
+		t.Fatalf("ProcessCity() error = %v", err)
+	}
+	if _, err := service.ProcessCity(ctx, cfg.Cities[1]); err != nil {
+		t.Fatalf("ProcessCity() error = %v", err)
+	}
+	if len(searcher.queries) != 1 || poster.Attempts() != 1 {
+		t.Errorf("searched %d times and posted %d, want only the unpaused city processed", len(searcher.queries), poster.Attempts())
+	}
+
+	if err := service.Pause(ctx, "hamilton", ""); err == nil {
+		t.Error("Pause(unknown city) error = nil")
+	}
+}
+
+// lockedSearcher serializes searches so a test can read the query count while Run is active.
+type lockedSearcher struct {
+	mu sync.Mutex
+	fakeSearcher
+}
+
+func (l *lockedSearcher) Search(ctx context.Context, index string, query any) (*pipeline.SearchResult, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.fakeSearcher.Search(ctx, index, query)
+}
+
+func (l *lockedSearcher) searches() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.queries)
+}
+
+func TestRun_TriggerSyncAndDedupLookup(t *testing.T) {
+	searcher := &lockedSearcher{fakeSearcher: fakeSearcher{articles: []map[string]any{
+		{"id": "a", "title": "Police investigate robbery"},
+	}}}
+	tracker, _ := deduptest.NewTracker(t)
+
+	cfg := newTestConfig()
+	cfg.Service.CheckInterval = time.Hour
+	cfg.Cities = []config.CityConfig{{Name: "sudbury_com"}}
+
+	service, err := integration.NewService(cfg, logger.NewNopLogger(),
+		integration.WithSource(searcher),
+		integration.WithPoster(&drupaltest.Poster{}),
+		integration.WithTracker(tracker),
+		integration.WithLimiter(rate.NewLimiter(rate.Inf, 1)),
+	)
+	if err != nil {
+		t.Fatalf("NewService() error = %v", err)
+	}
+
+	if !service.TriggerSync("") {
+		t.Fatal("TriggerSync() = false, want true")
+	}
+	if service.TriggerSync("") {
+		t.Error("second TriggerSync() = true, want false while a run is pending")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- service.Run(ctx) }()
+
+	// The initial run plus the triggered one, well before the hourly tick
+	deadline := time.Now().Add(5 * time.Second)
+	for searcher.searches() < 2 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+	<-done
+	if got := searcher.searches(); got != 2 {
+		t.Fatalf("searched %d times, want 2", got)
+	}
+
+	for id, want := range map[string]string{"a": dedup.StatePosted, "unknown": dedup.StateNone} {
+		entry, err := service.DedupLookup(context.Background(), id)
+		if err != nil {
+			t.Fatalf("DedupLookup(%q) error = %v", id, err)
+		}
+		if entry.State != want {
+			t.Errorf("DedupLookup(%q) state = %q, want %q", id, entry.State, want)
+		}
+	}
+}
+
+func TestRun_RetriedRunTokenSkipsProcessedArticles(t *testing.T) {
+	searcher := &lockedSearcher{fakeSearcher: fakeSearcher{articles: []map[string]any{
+		{"id": "a", "title": "Police investigate robbery"},
+		{"id": "b", "title": "Police make arrest"},
+	}}}
+	var drupalDown atomic.Bool
+	drupalDown.Store(true)
+	poster := &drupaltest.Poster{ErrFunc: func(req drupal.ArticleRequest) error {
+		if req.ExternalID == "b" && drupalDown.Load() {
+			return errors.New("drupal unavailable")
+		}
+		return nil
+	}}
+	tracker, mr := deduptest.NewTracker(t)
+	runTokens := runtoken.NewStore(deduptest.NewClient(t, mr), deduptest.KeyPrefix, time.Hour)
+
+	cfg := newTestConfig()
+	cfg.Service.CheckInterval = time.Hour
+	cfg.Cities = []config.CityConfig{{Name: "sudbury_com"}}
+
+	service, err := integration.NewService(cfg, logger.NewNopLogger(),
+		integration.WithSource(searcher),
+		integration.WithPoster(poster),
+		integration.WithTracker(tracker),
+		integration.WithLimiter(rate.NewLimiter(rate.Inf, 1)),
+		integration.WithRunTokenStore(runTokens),
+	)
+	if err != nil {
+		t.Fatalf("NewService() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- service.Run(ctx) }()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	// trigger runs with token and waits for its report
+	trigger := func(token string) integration.RunReport {
+		t.Helper()
+		since := time.Now()
+		if !service.TriggerSync(token) {
+			t.Fatalf("TriggerSync(%q) = false, want true", token)
+		}
+		deadline := time.Now().Add(5 * time.Second)
+		for time.Now().Before(deadline) {
+			if report := service.LastReport(); report.RunToken == token && report.StartedAt.After(since) {
+				return report
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		t.Fatalf("run %q did not complete", token)
+		return integration.RunReport{}
+	}
+
+	// The scheduled run at startup posted a; b keeps failing
+	first := trigger("nightly-42")
+	if city := first.Cities[0]; city.Posted != 0 || city.Errors != 1 || first.Dedup.AlreadyPosted != 1 {
+		t.Fatalf("first attempt = %+v (already posted %d), want b failed and a found posted", city, first.Dedup.AlreadyPosted)
+	}
+
+	// The retry skips a without consulting dedup and only handles b
+	drupalDown.Store(false)
+	retry := trigger("nightly-42")
+	if city := retry.Cities[0]; city.Posted != 1 || city.Skipped != 1 || retry.Dedup.AlreadyPosted != 0 {
+		t.Errorf("retry = %+v (already posted %d), want b posted and a skipped before dedup", city, retry.Dedup.AlreadyPosted)
+	}
+
+	processed, err := runTokens.Processed(context.Background(), "nightly-42")
+	if err != nil {
+		t.Fatalf("Processed() error = %v", err)
+	}
+	if len(processed) != 2 {
+		t.Errorf("processed = %v, want a and b", processed)
+	}
+}
+
+func TestRun_SavesRunHistory(t *testing.T) {
+	searcher := &fakeSearcher{articles: []map[string]any{
+		{"id": "a", "title": "Police investigate robbery"},
+	}}
+	tracker, mr := deduptest.NewTracker(t)
+	store := history.NewStore(deduptest.NewClient(t, mr), deduptest.KeyPrefix, time.Hour, logger.NewNopLogger())
+
+	cfg := newTestConfig()
+	cfg.Service.CheckInterval = time.Hour
+	cfg.Cities = []config.CityConfig{{Name: "sudbury_com"}}
+
+	service, err := integration.NewService(cfg, logger.NewNopLogger(),
+		integration.WithSource(searcher),
+		integration.WithPoster(&drupaltest.Poster{}),
+		integration.WithTracker(tracker),
+		integration.WithLimiter(rate.NewLimiter(rate.Inf, 1)),
+		integration.WithHistory(store),
+	)
+	if err != nil {
+		t.Fatalf("NewService() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- service.Run(ctx) }()
+
+	var runs []history.Run
+	deadline := time.Now().Add(5 * time.Second)
+	for len(runs) == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+		if runs, err = store.Since(context.Background(), time.Now().Add(-time.Hour)); err != nil {
+			t.Fatalf("Since() error = %v", err)
+		}
+	}
+	cancel()
+	<-done
+
+	if len(runs) != 1 || len(runs[0].Cities) != 1 {
+		t.Fatalf("history = %+v, want one run with one city", runs)
+	}
+	if city := runs[0].Cities[0]; city.City != "sudbury_com" || city.Found != 1 || city.Posted != 1 {
+		t.Errorf("city run = %+v, want sudbury_com with one article found and posted", city)
+	}
+}
+
+func TestProcessCity_RecordsArticleTimeline(t *testing.T) {
+	searcher := &fakeSearcher{articles: []map[string]any{
+		{"id": "a", "title": "Police investigate robbery", "published_date": "2025-01-15T10:30:00Z"},
+		{"id": "b", "title": "Council approves budget"},
+	}}
+	tracker, mr := deduptest.NewTracker(t)
+	store := timeline.NewStore(deduptest.NewClient(t, mr), deduptest.KeyPrefix, time.Hour)
+
+	service, err := integration.NewService(newTestConfig(), logger.NewNopLogger(),
+		integration.WithSource(searcher),
+		integration.WithPoster(&drupaltest.Poster{}),
+		integration.WithTracker(tracker),
+		integration.WithLimiter(rate.NewLimiter(rate.Inf, 1)),
+		integration.WithTimeline(store),
+	)
+	if err != nil {
+		t.Fatalf("NewService() error = %v", err)
+	}
+	if _, err := service.ProcessCity(context.Background(), config.CityConfig{Name: "sudbury_com"}); err != nil {
+		t.Fatalf("ProcessCity() error = %v", err)
+	}
+
+	stagesOf := func(id string) string {
+		events, err := store.Load(context.Background(), id)
+		if err != nil {
+			t.Fatalf("Load(%s) error = %v", id, err)
+		}
+		var stages []string
+		for _, event := range events {
+			if event.City != "sudbury_com" {
+				t.Errorf("%s event city = %q, want sudbury_com", event.Stage, event.City)
+			}
+			stages = append(stages, event.Stage)
+		}
+		return strings.Join(stages, ",")
+	}
+	if got := stagesOf("a"); got != "published,found,classified,posted,marked" {
+		t.Errorf("posted article stages = %s, want published,found,classified,posted,marked", got)
+	}
+	if got := stagesOf("b"); got != "found" {
+		t.Errorf("filtered article stages = %s, want found", got)
+	}
+}
+
+func TestCheckConsistency_HandlesDeletedNodes(t *testing.T) {
+	for _, policy := range []string{config.ConsistencyPolicyRecord, config.ConsistencyPolicyClear} {
+		t.Run(policy, func(t *testing.T) {
+			searcher := &fakeSearcher{articles: []map[string]any{
+				{"id": "kept", "title": "Police investigate robbery"},
+				{"id": "deleted", "title": "Police make arrest"},
+			}}
+			drupalServer := drupaltest.NewServer(t, "gopost", "secret")
+			client := drupalServer.Client(t)
+			tracker, mr := deduptest.NewTracker(t)
+			store := consistency.NewStore(deduptest.NewClient(t, mr), deduptest.KeyPrefix, time.Hour)
+			suppressions := dedup.NewSuppressionList(deduptest.NewClient(t, mr), deduptest.KeyPrefix)
+			cfg := newTestConfig()
+			cfg.Consistency = config.ConsistencyConfig{Enabled: true, SampleSize: 10, Window: time.Hour, Policy: policy}
+
+			service, err := integration.NewService(cfg, logger.NewNopLogger(),
+				integration.WithSource(searcher),
+				integration.WithPoster(client),
+				integration.WithTracker(tracker),
+				integration.WithLimiter(rate.NewLimiter(rate.Inf, 1)),
+				integration.WithConsistencyStore(store),
+				integration.WithNodeChecker(client),
+				integration.WithSuppressionList(suppressions),
+			)
+			if err != nil {
+				t.Fatalf("NewService() error = %v", err)
+			}
+			ctx := context.Background()
+			if _, err := service.ProcessCity(ctx, config.CityConfig{Name: "sudbury_com"}); err != nil {
+				t.Fatalf("ProcessCity() error = %v", err)
+			}
+
+			// An editor deletes the second node
+			nodes := drupalServer.Nodes()
+			if len(nodes) != 2 {
+				t.Fatalf("posted %d nodes, want 2", len(nodes))
+			}
+			if err := client.DeleteNode(ctx, nodes[1].Bundle, nodes[1].ID); err != nil {
+				t.Fatalf("DeleteNode() error = %v", err)
+			}
+
+			report, err := service.CheckConsistency(ctx)
+			if err != nil {
+				t.Fatalf("CheckConsistency() error = %v", err)
+			}
+			if report.Checked != 2 || report.Missing != 1 {
+				t.Errorf("report = %+v, want 2 checked and 1 missing", report)
+			}
+			if !tracker.HasPosted(ctx, "kept") {
+				t.Error("article with an existing node no longer marked as posted")
+			}
+			deletions, err := store.Deletions(ctx)
+			if err != nil {
+				t.Fatalf("Deletions() error = %v", err)
+			}
+			if policy == config.ConsistencyPolicyClear {
+				if tracker.HasPosted(ctx, "deleted") || len(deletions) != 0 {
+					t.Errorf("posted = true, deletions = %+v; want the marker cleared and nothing recorded", deletions)
+				}
+				return
+			}
+			if !tracker.HasPosted(ctx, "deleted") {
+				t.Error("deleted article no longer marked as posted with the record policy")
+			}
+			if len(deletions) != 1 || deletions[0].ArticleID != "deleted" || deletions[0].City != "sudbury_com" {
+				t.Errorf("deletions = %+v, want the deleted article", deletions)
+			}
+			if suppressed, err := suppressions.Contains(ctx, "deleted"); err != nil || !suppressed {
+				t.Errorf("deleted article suppressed = %v, %v; want true", suppressed, err)
+			}
+		})
+	}
+}
+
+func TestProcessCity_SkipsSuppressedArticles(t *testing.T) {
+	searcher := &fakeSearcher{articles: []map[string]any{
+		{"id": "deleted-by-editor", "title": "Police investigate robbery"},
+		{"id": "new", "title": "Police make arrest"},
+	}}
+	poster := &drupaltest.Poster{}
+	tracker, mr := deduptest.NewTracker(t)
+
+	service, err := integration.NewService(newTestConfig(), logger.NewNopLogger(),
+		integration.WithSource(searcher),
+		integration.WithPoster(poster),
+		integration.WithTracker(tracker),
+		integration.WithLimiter(rate.NewLimiter(rate.Inf, 1)),
+		integration.WithSuppressionList(dedup.NewSuppressionList(deduptest.NewClient(t, mr), deduptest.KeyPrefix)),
+	)
+	if err != nil {
+		t.Fatalf("NewService() error = %v", err)
+	}
+	ctx := context.Background()
+	if err := service.Suppress(ctx, "deleted-by-editor"); err != nil {
+		t.Fatalf("Suppress() error = %v", err)
+	}
+	if _, err := service.ProcessCity(ctx, config.CityConfig{Name: "sudbury_com"}); err != nil {
+		t.Fatalf("ProcessCity() error = %v", err)
+	}
+	posted := poster.Posted()
+	if len(posted) != 1 || posted[0].ExternalID != "new" {
+		t.Fatalf("posted %+v, want only the unsuppressed article", posted)
+	}
+
+	// Lifting the suppression lets a later run post it
+	if err := service.Unsuppress(ctx, "deleted-by-editor"); err != nil {
+		t.Fatalf("Unsuppress() error = %v", err)
+	}
+	if _, err := service.ProcessCity(ctx, config.CityConfig{Name: "sudbury_com"}); err != nil {
+		t.Fatalf("ProcessCity() error = %v", err)
+	}
+	if got := len(poster.Posted()); got != 2 {
+		t.Errorf("posted %d articles after unsuppressing, want 2", got)
+	}
+}
+
+func TestStatus_HealthScore(t *testing.T) {
+	searcher := &fakeSearcher{articles: []map[string]any{
+		{"id": "ok", "title": "Police investigate robbery"},
+		{"id": "fails", "title": "Police make arrest"},
+	}}
+	poster := &drupaltest.Poster{
+		ErrFunc: func(req drupal.ArticleRequest) error {
+			if req.ExternalID == "fails" {
+				return errors.New("drupal unavailable")
+			}
+			return nil
+		},
+	}
+	tracker, _ := deduptest.NewTracker(t)
+	cfg := newTestConfig()
+	cfg.Service.CheckInterval = time.Hour
+	cfg.Cities = []config.CityConfig{{Name: "sudbury_com"}}
+
+	service, err := integration.NewService(cfg, logger.NewNopLogger(),
+		integration.WithSource(searcher),
+		integration.WithPoster(poster),
+		integration.WithTracker(tracker),
+		integration.WithLimiter(rate.NewLimiter(rate.Inf, 1)),
+	)
+	if err != nil {
+		t.Fatalf("NewService() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- service.Run(ctx) }()
+
+	var status integration.Status
+	deadline := time.Now().Add(5 * time.Second)
+	for len(status.LastReport.Cities) == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+		status = service.Status()
+	}
+	cancel()
+	<-done
+
+	health := status.Health
+	if health.ErrorRatio != 0.5 || health.Score != 0.5 {
+		t.Errorf("health = %+v, want error ratio and score 0.5 with one of two posts failed", health)
+	}
+	if want := (integration.BackendHealth{Elasticsearch: true, Drupal: true, Redis: true}); health.Backends != want {
+		t.Errorf("backends = %+v, want all available", health.Backends)
+	}
+}
+
+func TestRun_MeasuresSyncLag(t *testing.T) {
+	searcher := &fakeSearcher{articles: []map[string]any{
+		{"id": "old", "title": "Police investigate robbery", "published_date": "2025-01-15T08:00:00Z"},
+		{"id": "new", "title": "Police make arrest", "published_date": "2025-01-15T11:00:00Z"},
+	}}
+	poster := &drupaltest.Poster{
+		ErrFunc: func(req drupal.ArticleRequest) error {
+			if req.ExternalID == "new" {
+				return errors.New("drupal unavailable")
+			}
+			return nil
+		},
+	}
+	tracker, mr := deduptest.NewTracker(t)
+	cfg := newTestConfig()
+	cfg.Service.CheckInterval = time.Hour
+	cfg.Service.SyncLag = config.SyncLagConfig{Enabled: true, WarnAfter: time.Hour}
+	cfg.Cities = []config.CityConfig{{Name: "sudbury_com"}}
+
+	service, err := integration.NewService(cfg, logger.NewNopLogger(),
+		integration.WithSource(searcher),
+		integration.WithPoster(poster),
+		integration.WithTracker(tracker),
+		integration.WithCheckpointStore(checkpoint.NewStore(deduptest.NewClient(t, mr), deduptest.KeyPrefix)),
+		integration.WithLimiter(rate.NewLimiter(rate.Inf, 1)),
+	)
+	if err != nil {
+		t.Fatalf("NewService() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- service.Run(ctx) }()
+
+	var status integration.Status
+	deadline := time.Now().Add(5 * time.Second)
+	for len(status.LastReport.Cities) == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+		status = service.Status()
+	}
+	cancel()
+	<-done
+
+	if len(status.LastReport.Cities) != 1 {
+		t.Fatalf("run report = %+v, want one city", status.LastReport)
+	}
+	if lag := status.LastReport.Cities[0].SyncLag; lag == nil || *lag != 3*time.Hour {
+		t.Errorf("SyncLag = %v, want 3h between the newest matching and the newest posted article", lag)
+	}
+	if got := mr.HGet(deduptest.KeyPrefix+checkpoint.PostedKey, "sudbury_com"); got != "1736928000000" {
+		t.Errorf("newest posted = %q, want the published time of the posted article", got)
+	}
+}
+
+func TestSmoke(t *testing.T) {
+	esServer := estest.NewServer(t)
+	esServer.CreateIndex("sudbury_com_articles")
+	drupalServer := drupaltest.NewServer(t, "gopost", "secret")
+	redisServer := miniredis.RunT(t)
+
+	cfg := newTestConfig()
+	cfg.Elasticsearch = config.ElasticsearchConfig{URL: esServer.URL}
+	cfg.Drupal = config.DrupalConfig{URL: drupalServer.URL, Username: "gopost", Token: "secret"}
+	cfg.Redis = config.RedisConfig{URL: redisServer.Addr()}
+	cfg.Cities = []config.CityConfig{{Name: "sudbury_com"}, {Name: "toronto_com", Index: "missing"}}
+	cfg.Smoke.GroupID = "test-group-uuid"
+
+	report := integration.Smoke(context.Background(), cfg, logger.NewNopLogger())
+
+	failed := map[string]bool{}
+	for _, check := range report.Checks {
+		failed[check.Name] = check.Err != nil
+	}
+	want := map[string]bool{
+		"elasticsearch search sudbury_com_articles": false,
+		"elasticsearch search missing":              true,
+		"drupal create and delete node":             false,
+		"redis round trip":                          false,
+	}
+	if len(failed) != len(want) {
+		t.Fatalf("checks = %+v, want %d checks", report.Checks, len(want))
+	}
+	for name, wantFailed := range want {
+		if got, ok := failed[name]; !ok || got != wantFailed {
+			t.Errorf("check %q failed = %v (ran: %v), want %v", name, got, ok, wantFailed)
+		}
+	}
+	if report.Passed() {
+		t.Error("Passed() = true with a missing index")
+	}
+	if nodes := drupalServer.Nodes(); len(nodes) != 0 {
+		t.Errorf("%d smoke nodes left in Drupal, want the test node deleted", len(nodes))
+	}
+	if keys := redisServer.Keys(); len(keys) != 0 {
+		t.Errorf("Redis keys left behind: %v", keys)
+	}
+}
+
+func TestRun_ExpandsLookbackAfterDowntime(t *testing.T) {
+	tests := []struct {
+		name         string
+		downtime     time.Duration
+		catchupHours int
+		wantWindow   time.Duration
+	}{
+		{name: "short restart keeps lookback", downtime: time.Hour, catchupHours: 168, wantWindow: 24 * time.Hour},
+		{name: "doubles until the gap is covered", downtime: 60 * time.Hour, catchupHours: 168, wantWindow: 96 * time.Hour},
+		{name: "capped by catchup_hours", downtime: 30 * 24 * time.Hour, catchupHours: 72, wantWindow: 72 * time.Hour},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			searcher := &lockedSearcher{}
+			tracker, mr := deduptest.NewTracker(t)
+			checkpoints := checkpoint.NewStore(deduptest.NewClient(t, mr), deduptest.KeyPrefix)
+			lastCheck := time.Now().Add(-tt.downtime)
+			if err := checkpoints.Save(context.Background(), lastCheck); err != nil {
+				t.Fatal(err)
+			}
+
+			cfg := newTestConfig()
+			cfg.Service.CheckInterval = time.Hour
+			cfg.Service.LookbackHours = 24
+			cfg.Service.CatchupHours = tt.catchupHours
+			cfg.Cities = []config.CityConfig{{Name: "sudbury_com"}}
+
+			service, err := integration.NewService(cfg, logger.NewNopLogger(),
+				integration.WithSource(searcher),
+				integration.WithPoster(&drupaltest.Poster{}),
+				integration.WithTracker(tracker),
+				integration.WithLimiter(rate.NewLimiter(rate.Inf, 1)),
+				integration.WithCheckpointStore(checkpoints),
+			)
+			if err != nil {
+				t.Fatalf("NewService() error = %v", err)
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			done := make(chan error, 1)
+			go func() { done <- service.Run(ctx) }()
+			deadline := time.Now().Add(5 * time.Second)
+			for service.LastReport().StartedAt.IsZero() && time.Now().Before(deadline) {
+				time.Sleep(10 * time.Millisecond)
+			}
+			cancel()
+			<-done
+
+			searcher.mu.Lock()
+			query, err := json.Marshal(searcher.queries[0])
+			searcher.mu.Unlock()
+			if err != nil {
+				t.Fatal(err)
+			}
+			var parsed struct {
+				Query struct {
+					Bool struct {
+						Must []struct {
+							Range map[string]struct {
+								GTE time.Time `json:"gte"`
+							} `json:"range"`
+						} `json:"must"`
+					} `json:"bool"`
+				} `json:"query"`
+			}
+			if err := json.Unmarshal(query, &parsed); err != nil {
+				t.Fatalf("decode query: %v\n%s", err, query)
+			}
+			var since time.Time
+			for _, clause := range parsed.Query.Bool.Must {
+				for _, r := range clause.Range {
+					since = r.GTE
+				}
+			}
+			if window := time.Since(since); window < tt.wantWindow-time.Minute || window > tt.wantWindow+time.Minute {
+				t.Errorf("first search window = %v, want %v\n%s", window.Round(time.Minute), tt.wantWindow, query)
+			}
+
+			saved, err := checkpoints.Load(context.Background())
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if time.Since(saved) > time.Minute {
+				t.Errorf("saved last check = %v, want the end of the run", saved)
+			}
+		})
+	}
+}
+
+func TestReplay_DiscoversCitiesFromIndices(t *testing.T) {
+	esServer := estest.NewServer(t)
+	hit := func(id string) estest.Hit {
+		return estest.Hit{ID: id, Source: map[string]any{"title": "Police investigate robbery " + id}}
+	}
+	esServer.AddHits("sudbury_com_articles", hit("configured"))
+	esServer.AddHits("timmins_articles", hit("discovered"))
+	esServer.AddHits("north_bay_articles", hit("unmapped"))
+	esServer.AddHits("crawler_logs", hit("unrelated"))
+	poster := &drupaltest.Poster{}
+	tracker, _ := deduptest.NewTracker(t)
+
+	cfg := newTestConfig()
+	cfg.Cities = []config.CityConfig{{Name: "sudbury_com", GroupID: "group-sudbury"}}
+	cfg.CityDiscovery = config.CityDiscoveryConfig{
+		Enabled:  true,
+		Pattern:  "*_articles",
+		Groups:   map[string]string{"timmins": "group-timmins", "sudbury_com": "group-ignored"},
+		Interval: time.Hour,
+	}
+
+	service, err := integration.NewService(cfg, logger.NewNopLogger(),
+		integration.WithSource(pipeline.NewElasticsearchSource(esServer.Client(t))),
+		integration.WithPoster(poster),
+		integration.WithTracker(tracker),
+		integration.WithLimiter(rate.NewLimiter(rate.Inf, 1)),
+	)
+	if err != nil {
+		t.Fatalf("NewService() error = %v", err)
+	}
+
+	report, err := service.Replay(context.Background(), time.Now().Add(-time.Hour), "")
+	if err != nil {
+		t.Fatalf("Replay() error = %v", err)
+	}
+
+	var cities []string
+	for _, city := range report.Cities {
+		cities = append(cities, city.City)
+	}
+	if fmt.Sprint(cities) != "[sudbury_com timmins]" {
+		t.Errorf("cities = %v, want configured sudbury_com and discovered timmins", cities)
+	}
+	groups := make(map[string]string)
+	for _, req := range poster.Posted() {
+		groups[req.ExternalID] = req.GroupID
+	}
+	want := map[string]string{"configured": "group-sudbury", "discovered": "group-timmins"}
+	if fmt.Sprint(groups) != fmt.Sprint(want) {
+		t.Errorf("posted groups = %v, want %v", groups, want)
+	}
+	if got := service.Status().CityCount; got != 2 {
+		t.Errorf("Status().CityCount = %d, want 2", got)
+	}
+}
+
+func TestProcessCity_ResolvesGroupNames(t *testing.T) {
+	drupalServer := drupaltest.NewServer(t, "gopost", "secret")
+	sudburyID := drupalServer.AddGroup("crime_news", "Sudbury")
+	for i := range 50 {
+		drupalServer.AddGroup("crime_news", fmt.Sprintf("Filler %d", i))
+	}
+	timminsID := drupalServer.AddGroup("crime_news", "Timmins") // Second page
+	drupalServer.AddGroup("crime_news", "Twin")
+	drupalServer.AddGroup("crime_news", "Twin")
+
+	searcher := &fakeSearcher{}
+	poster := &drupaltest.Poster{}
+	tracker, _ := deduptest.NewTracker(t)
+	cfg := newTestConfig()
+	cfg.Service.GroupRefresh = time.Hour
+	cfg.Cities = []config.CityConfig{
+		{Name: "sudbury_com", GroupName: "sudbury"},
+		{Name: "timmins", GroupName: "Timmins"},
+		{Name: "twin", GroupName: "Twin"},
+		{Name: "nowhere", GroupName: "Nowhere"},
+	}
+
+	service, err := integration.NewService(cfg, logger.NewNopLogger(),
+		integration.WithSource(searcher),
+		integration.WithPoster(poster),
+		integration.WithTracker(tracker),
+		integration.WithLimiter(rate.NewLimiter(rate.Inf, 1)),
+		integration.WithGroupLister(drupalServer.Client(t)),
+	)
+	if err != nil {
+		t.Fatalf("NewService() error = %v", err)
+	}
+
+	tests := []struct {
+		city        config.CityConfig
+		wantGroupID string // Empty when the city must fail without posting
+	}{
+		{city: cfg.Cities[0], wantGroupID: sudburyID},
+		{city: cfg.Cities[1], wantGroupID: timminsID},
+		{city: cfg.Cities[2]},
+		{city: cfg.Cities[3]},
+	}
+	for _, tt := range tests {
+		t.Run(tt.city.Name, func(t *testing.T) {
+			searcher.articles = []map[string]any{{"id": tt.city.Name, "title": "Police investigate robbery"}}
+			before := len(poster.Posted())
+
+			_, err := service.ProcessCity(context.Background(), tt.city)
+			posted := poster.Posted()[before:]
+			if tt.wantGroupID == "" {
+				if err == nil || len(posted) != 0 {
+					t.Errorf("ProcessCity() error = %v, posted %d; want error and nothing posted", err, len(posted))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ProcessCity() error = %v", err)
+			}
+			if len(posted) != 1 || posted[0].GroupID != tt.wantGroupID {
+				t.Errorf("posted = %+v, want one article in group %s", posted, tt.wantGroupID)
+			}
+		})
+	}
+}
+
+func TestRunOnce_SharedIndexSearchedOnce(t *testing.T) {
+	searcher := &fakeSearcher{articles: []map[string]any{
+		{"id": "a", "title": "Police investigate robbery"},
+	}}
+	tracker, _ := deduptest.NewTracker(t)
+	cfg := newTestConfig()
+	cfg.Service.SearchCache = config.SearchCacheConfig{Enabled: true, TTL: time.Minute}
+	cfg.Cities = []config.CityConfig{
+		{Name: "sudbury_east", Index: "sudbury_articles", GroupID: "east"},
+		{Name: "sudbury_west", Index: "sudbury_articles", GroupID: "west"},
+		{Name: "timmins"},
+	}
+
+	service, err := integration.NewService(cfg, logger.NewNopLogger(),
+		integration.WithSource(searcher),
+		integration.WithPoster(&drupaltest.Poster{}),
+		integration.WithTracker(tracker),
+		integration.WithLimiter(rate.NewLimiter(rate.Inf, 1)),
+	)
+	if err != nil {
+		t.Fatalf("NewService() error = %v", err)
+	}
+	report, err := service.RunOnce(context.Background())
+	if err != nil {
+		t.Fatalf("RunOnce() error = %v", err)
+	}
+
+	if len(searcher.queries) != 2 {
+		t.Errorf("searches = %d, want 2 (one per index)", len(searcher.queries))
+	}
+	for _, city := range report.Cities {
+		if city.Found != 1 {
+			t.Errorf("%s found %d articles, want 1", city.City, city.Found)
+		}
+	}
+}
+
+func TestService_Stats(t *testing.T) {
+	searcher := &fakeSearcher{articles: []map[string]any{
+		{"id": "a", "title": "Police investigate robbery"},
+	}}
+	tracker, _ := deduptest.NewTracker(t)
+	cfg := newTestConfig()
+	cfg.Cities = []config.CityConfig{{Name: "sudbury_com"}}
+
+	service, err := integration.NewService(cfg, logger.NewNopLogger(),
+		integration.WithSource(searcher),
+		integration.WithPoster(&drupaltest.Poster{}),
+		integration.WithTracker(tracker),
+		integration.WithLimiter(rate.NewLimiter(rate.Inf, 1)),
+	)
+	if err != nil {
+		t.Fatalf("NewService() error = %v", err)
+	}
+	if stats := service.Stats(); stats.RunsCompleted != 0 || len(stats.Cities) != 0 {
+		t.Errorf("Stats() before any run = %+v, want zero", stats)
+	}
+
+	var last integration.RunReport
+	for range 2 {
+		if last, err = service.RunOnce(context.Background()); err != nil {
+			t.Fatalf("RunOnce() error = %v", err)
+		}
+	}
+
+	stats := service.Stats()
+	if stats.RunsCompleted != 2 || stats.Found != 2 || stats.Posted != 1 || stats.Skipped != 1 || stats.Errors != 0 {
+		t.Errorf("Stats() = %+v, want 2 runs, 2 found, 1 posted, 1 skipped", stats)
+	}
+	if !stats.LastRunAt.Equal(last.StartedAt) || stats.LastRunDuration != last.Duration {
+		t.Errorf("last run = %v (%v), want %v (%v)", stats.LastRunAt, stats.LastRunDuration, last.StartedAt, last.Duration)
+	}
+	want := []integration.CityStats{{City: "sudbury_com", Runs: 2, Found: 2, Posted: 1, Skipped: 1}}
+	if !reflect.DeepEqual(stats.Cities, want) {
+		t.Errorf("Stats().Cities = %+v, want %+v", stats.Cities, want)
+	}
+	if status := service.Status(); !reflect.DeepEqual(status.Stats, stats) {
+		t.Errorf("Status().Stats = %+v, want %+v", status.Stats, stats)
+	}
+}
+
+func TestRunOnce_MultiSearch(t *testing.T) {
+	tests := []struct {
+		name      string
+		disabled  bool // The server rejects _msearch
+		wantMulti bool
+	}{
+		{name: "one request for all cities", wantMulti: true},
+		{name: "falls back to individual searches", disabled: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			esServer := estest.NewServer(t)
+			for _, index := range []string{"sudbury_com_articles", "timmins_articles"} {
+				esServer.AddHits(index, estest.Hit{ID: index, Source: map[string]any{"title": "Police investigate robbery"}})
+			}
+			if tt.disabled {
+				esServer.DisableMultiSearch()
+			}
+			tracker, _ := deduptest.NewTracker(t)
+			cfg := newTestConfig()
+			cfg.Elasticsearch = config.ElasticsearchConfig{URL: esServer.URL}
+			cfg.Service.MultiSearch = true
+			cfg.Cities = []config.CityConfig{
+				{Name: "sudbury_com"},
+				{Name: "timmins", Index: "timmins_articles"},
+				{Name: "toronto_com", Index: "missing"},
+			}
+
+			service, err := integration.NewService(cfg, logger.NewNopLogger(),
+				integration.WithPoster(&drupaltest.Poster{}),
+				integration.WithTracker(tracker),
+				integration.WithLimiter(rate.NewLimiter(rate.Inf, 1)),
+			)
+			if err != nil {
+				t.Fatalf("NewService() error = %v", err)
+			}
+			report, err := service.RunOnce(context.Background())
+			if err != nil {
+				t.Fatalf("RunOnce() error = %v", err)
+			}
+
+			requests := esServer.Requests()
+			if len(requests) != 3 {
+				t.Fatalf("searches = %d, want 3 (one per city)", len(requests))
+			}
+			for _, req := range requests {
+				if req.Multi != tt.wantMulti {
+					t.Errorf("search of %s multi = %v, want %v", req.Index, req.Multi, tt.wantMulti)
+				}
+			}
+			for _, city := range report.Cities {
+				wantFailed := city.City == "toronto_com"
+				if city.Failed != wantFailed {
+					t.Errorf("%s failed = %v, want %v", city.City, city.Failed, wantFailed)
+				}
+				if !wantFailed && city.Found != 1 {
+					t.Errorf("%s found %d articles, want 1", city.City, city.Found)
+				}
+			}
+		})
+	}
+}
+
+func TestRunOnce_SkipsClosedIndex(t *testing.T) {
+	esServer := estest.NewServer(t)
+	for _, index := range []string{"sudbury_com_articles", "timmins_articles"} {
+		esServer.AddHits(index, estest.Hit{ID: index, Source: map[string]any{"title": "Police investigate robbery"}})
+	}
+	esServer.CloseIndex("sudbury_com_articles")
+	tracker, _ := deduptest.NewTracker(t)
+	cfg := newTestConfig()
+	cfg.Elasticsearch = config.ElasticsearchConfig{URL: esServer.URL}
+	cfg.Service.ClosedIndex = config.ClosedIndexConfig{Backoff: time.Hour, MaxBackoff: time.Hour}
+	cfg.Cities = []config.CityConfig{
+		{Name: "sudbury_com"},
+		{Name: "timmins", Index: "timmins_articles"},
+	}
+
+	service, err := integration.NewService(cfg, logger.NewNopLogger(),
+		integration.WithPoster(&drupaltest.Poster{}),
+		integration.WithTracker(tracker),
+		integration.WithLimiter(rate.NewLimiter(rate.Inf, 1)),
+	)
+	if err != nil {
+		t.Fatalf("NewService() error = %v", err)
+	}
+	for run := 1; run <= 2; run++ {
+		report, err := service.RunOnce(context.Background())
+		if err != nil {
+			t.Fatalf("run %d: RunOnce() error = %v", run, err)
+		}
+		for _, city := range report.Cities {
+			wantClosed := ""
+			if city.City == "sudbury_com" {
+				wantClosed = "index_closed_exception"
+			}
+			if city.ClosedIndex != wantClosed || city.Failed {
+				t.Errorf("run %d: %s closed index = %q (failed %v), want %q", run, city.City, city.ClosedIndex, city.Failed, wantClosed)
+			}
+		}
+	}
+
+	var closedSearches int
+	for _, req := range esServer.Requests() {
+		if req.Index == "sudbury_com_articles" {
+			closedSearches++
+		}
+	}
+	if closedSearches != 1 {
+		t.Errorf("searches of the closed index = %d, want 1 (the second run waits for the backoff)", closedSearches)
+	}
+}
+
+func TestRunOnce_StoredSearchTemplate(t *testing.T) {
+	esServer := estest.NewServer(t)
+	esServer.AddHits("sudbury_com_articles", estest.Hit{ID: "es-1", Source: map[string]any{"title": "Police investigate robbery"}})
+	tracker, _ := deduptest.NewTracker(t)
+	cfg := newTestConfig()
+	cfg.Elasticsearch = config.ElasticsearchConfig{URL: esServer.URL}
+	cfg.SearchTemplate = config.SearchTemplateConfig{Enabled: true, ID: "gopost-articles"}
+	cfg.Cities = []config.CityConfig{{Name: "sudbury_com"}}
+
+	template := `{"size": {{size}}, "query": {"multi_match": {"query": "{{keyword_query}}", "fields": ["{{title_field}}"]}}}`
+	file := filepath.Join(t.TempDir(), "articles.mustache")
+	if err := os.WriteFile(file, []byte(template), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := integration.PushSearchTemplate(context.Background(), cfg, file); err != nil {
+		t.Fatalf("PushSearchTemplate() error = %v", err)
+	}
+	if stored, _ := esServer.Script("gopost-articles"); stored != template {
+		t.Errorf("stored template = %q, want the file's contents", stored)
+	}
+
+	service, err := integration.NewService(cfg, logger.NewNopLogger(),
+		integration.WithPoster(&drupaltest.Poster{}),
+		integration.WithTracker(tracker),
+		integration.WithLimiter(rate.NewLimiter(rate.Inf, 1)),
+	)
+	if err != nil {
+		t.Fatalf("NewService() error = %v", err)
+	}
+	report, err := service.RunOnce(context.Background())
+	if err != nil {
+		t.Fatalf("RunOnce() error = %v", err)
+	}
+
+	if len(report.Cities) != 1 || report.Cities[0].Found != 1 {
+		t.Fatalf("report = %+v, want the template search's article found", report.Cities)
+	}
+	requests := esServer.Requests()
+	if len(requests) != 1 {
+		t.Fatalf("searches = %d, want 1", len(requests))
+	}
+	params, _ := requests[0].Body["params"].(map[string]any)
+	if requests[0].Body["id"] != "gopost-articles" || params["keyword_query"] != "police robbery" || params["size"] != float64(100) {
+		t.Errorf("template request = %v, want the template ID with keyword and size params", requests[0].Body)
+	}
+	if _, ok := params["since"]; ok {
+		t.Errorf("params = %v, want no since without lookback_hours", params)
+	}
+}
+
+// diagnosedIndex answers the diagnostic queries of an empty city search.
+type diagnosedIndex struct {
+	documents int
+	fields    map[string]int // Documents having each field
+	indices   []string       // Indices ListIndices returns
+}
+
+func (d *diagnosedIndex) Search(_ context.Context, _ string, query any) (*pipeline.SearchResult, error) {
+	body, err := json.Marshal(query)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case strings.Contains(string(body), `"exists"`):
+		for field, count := range d.fields {
+			if strings.Contains(string(body), `"field":"`+field+`"`) {
+				return &pipeline.SearchResult{Total: count}, nil
+			}
+		}
+		return &pipeline.SearchResult{}, nil
+	case strings.Contains(string(body), `"match_all"`):
+		result := &pipeline.SearchResult{Total: d.documents}
+		if d.documents > 0 {
+			result.Hits = []pipeline.SearchHit{{ID: "newest", Source: json.RawMessage(`{"published_date":"2025-01-02T10:00:00Z"}`)}}
+		}
+		return result, nil
+	default:
+		return &pipeline.SearchResult{}, nil // The city search finds nothing
+	}
+}
+
+func (d *diagnosedIndex) ListIndices(context.Context, string) ([]string, error) {
+	return d.indices, nil
+}
+
+func TestRunOnce_DiagnosesEmptySearch(t *testing.T) {
+	allFields := map[string]int{"title": 5, "body": 5, "published_date": 5}
+	tests := []struct {
+		name        string
+		index       diagnosedIndex
+		wantCause   string
+		wantMissing []string
+	}{
+		{"missing index", diagnosedIndex{}, integration.DiagnosisIndexMissing, nil},
+		{"empty index", diagnosedIndex{indices: []string{"sudbury_com_articles"}}, integration.DiagnosisIndexEmpty, nil},
+		{"body mapping lost", diagnosedIndex{documents: 5, fields: map[string]int{"title": 5, "published_date": 5}}, integration.DiagnosisFieldsMissing, []string{"body"}},
+		{"quiet city", diagnosedIndex{documents: 5, fields: allFields}, integration.DiagnosisNoMatches, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracker, _ := deduptest.NewTracker(t)
+			cfg := newTestConfig()
+			cfg.Cities = []config.CityConfig{{Name: "sudbury_com"}}
+
+			service, err := integration.NewService(cfg, logger.NewNopLogger(),
+				integration.WithSource(&tt.index),
+				integration.WithPoster(&drupaltest.Poster{}),
+				integration.WithTracker(tracker),
+				integration.WithLimiter(rate.NewLimiter(rate.Inf, 1)),
+			)
+			if err != nil {
+				t.Fatalf("NewService() error = %v", err)
+			}
+			report, err := service.RunOnce(context.Background())
+			if err != nil {
+				t.Fatalf("RunOnce() error = %v", err)
+			}
+
+			diagnosis := report.Cities[0].Diagnosis
+			if diagnosis == nil {
+				t.Fatal("Diagnosis = nil, want the cause of the empty search")
+			}
+			if diagnosis.Cause != tt.wantCause || !slices.Equal(diagnosis.MissingFields, tt.wantMissing) {
+				t.Errorf("Diagnosis = %+v, want cause %q, missing fields %v", diagnosis, tt.wantCause, tt.wantMissing)
+			}
+			if diagnosis.Documents != tt.index.documents {
+				t.Errorf("Documents = %d, want %d", diagnosis.Documents, tt.index.documents)
+			}
+		})
+	}
+}
+
+func TestProcessCity_WarmsUpNewCities(t *testing.T) {
+	searcher := &fakeSearcher{}
+	for day := 6; day >= 1; day-- { // Newest first, like the Elasticsearch query
+		searcher.articles = append(searcher.articles, map[string]any{
+			"id":             fmt.Sprintf("day-%d", day),
+			"title":          "Police investigate robbery",
+			"published_date": fmt.Sprintf("2025-01-0%dT10:00:00Z", day),
+		})
+	}
+	poster := &drupaltest.Poster{}
+	tracker, mr := deduptest.NewTracker(t)
+	cfg := newTestConfig()
+	cfg.Service.Warmup = config.WarmupConfig{Runs: 2, MaxPosts: 2, Order: config.PostOrderOldestFirst}
+	cityCfg := config.CityConfig{Name: "timmins"}
+
+	service, err := integration.NewService(cfg, logger.NewNopLogger(),
+		integration.WithSource(searcher),
+		integration.WithPoster(poster),
+		integration.WithTracker(tracker),
+		integration.WithLimiter(rate.NewLimiter(rate.Inf, 1)),
+		integration.WithWarmupStore(warmup.NewStore(deduptest.NewClient(t, mr), deduptest.KeyPrefix)),
+	)
+	if err != nil {
+		t.Fatalf("NewService() error = %v", err)
+	}
+
+	wantRuns := [][]string{
+		{"day-1", "day-2"}, // Warm-up run 1, oldest first
+		{"day-3", "day-4"}, // Warm-up run 2
+		{"day-5", "day-6"}, // Warmed up: everything left
+	}
+	for run, want := range wantRuns {
+		before := len(poster.Posted())
+		if _, err := service.ProcessCity(context.Background(), cityCfg); err != nil {
+			t.Fatalf("run %d: ProcessCity() error = %v", run+1, err)
+		}
+		var got []string
+		for _, req := range poster.Posted()[before:] {
+			got = append(got, req.ExternalID)
+		}
+		slices.Sort(got)
+		if !slices.Equal(got, want) {
+			t.Errorf("run %d posted %v, want %v", run+1, got, want)
+		}
+	}
+}
+
+func TestProcessCity_TagsCategories(t *testing.T) {
+	drupalServer := drupaltest.NewServer(t, "gopost", "secret")
+	violentID := drupalServer.AddTerm("crime_categories", "Violent crime")
+	propertyID := drupalServer.AddTerm("crime_categories", "Property crime")
+	drupalServer.AddTerm("tags", "Police operations") // Other vocabulary
+
+	searcher := &fakeSearcher{articles: []map[string]any{
+		{"id": "violent", "title": "Police investigate homicide", "body": "An assault preceded the homicide"},
+		{"id": "mixed", "title": "Police: theft suspect sentenced in court"},
+		{"id": "untagged", "title": "Robbery reported downtown"},
+	}}
+	tracker, _ := deduptest.NewTracker(t)
+	cfg := newTestConfig()
+	cfg.Categories = config.CategoriesConfig{
+		TermType: "taxonomy_term--crime_categories",
+		Refresh:  time.Hour,
+		Mappings: []config.CategoryConfig{
+			{Term: "violent crime", Keywords: []string{"homicide", "assault"}},
+			{Term: "Property crime", Keywords: []string{"theft", "burglary"}},
+			{TermID: "court-term", Keywords: []string{"court", "sentenced"}},
+			{Term: "Police operations", Keywords: []string{"police"}}, // Does not resolve
+		},
+	}
+
+	service, err := integration.NewService(cfg, logger.NewNopLogger(),
+		integration.WithSource(searcher),
+		integration.WithPoster(drupalServer.Client(t)),
+		integration.WithTracker(tracker),
+		integration.WithLimiter(rate.NewLimiter(rate.Inf, 1)),
+		integration.WithTermLister(drupalServer.Client(t)),
+	)
+	if err != nil {
+		t.Fatalf("NewService() error = %v", err)
+	}
+	if _, err := service.ProcessCity(context.Background(), config.CityConfig{Name: "sudbury_com", GroupID: "group-1"}); err != nil {
+		t.Fatalf("ProcessCity() error = %v", err)
+	}
+
+	want := map[string][]string{
+		"violent":  {violentID},
+		"mixed":    {propertyID, "court-term"},
+		"untagged": nil,
+	}
+	nodes := drupalServer.Nodes()
+	if len(nodes) != len(want) {
+		t.Fatalf("posted %d nodes, want %d", len(nodes), len(want))
+	}
+	for _, node := range nodes {
+		var got []string
+		if terms := node.Article.Data.Relationships.FieldCrimeCategories; terms != nil {
+			for _, term := range terms.Data {
+				if term.Type != "taxonomy_term--crime_categories" {
+					t.Errorf("term type = %q, want taxonomy_term--crime_categories", term.Type)
+				}
+				got = append(got, term.ID)
+			}
+		}
+		id := node.Article.Data.Attributes.FieldExternalID
+		if !slices.Equal(got, want[id]) {
+			t.Errorf("%s categories = %v, want %v", id, got, want[id])
+		}
+	}
+}
+
+func TestProcessCity_PostsInConfiguredOrder(t *testing.T) {
+	searcher := &fakeSearcher{articles: []map[string]any{
+		{"id": "undated", "title": "Police investigate robbery"},
+		{"id": "jan-03", "title": "Police investigate robbery", "published_date": "2025-01-03T10:00:00Z"},
+		{"id": "jan-01", "title": "Police investigate robbery", "published_date": "2025-01-01T10:00:00Z"},
+		{"id": "jan-02", "title": "Police investigate robbery", "published_date": "2025-01-02T10:00:00Z"},
+	}}
+
+	tests := []struct {
+		order string
+		want  []string
+	}{
+		{order: config.PostOrderOldestFirst, want: []string{"jan-01", "jan-02", "jan-03", "undated"}},
+		{order: config.PostOrderNewestFirst, want: []string{"jan-03", "jan-02", "jan-01", "undated"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.order, func(t *testing.T) {
+			poster := &drupaltest.Poster{}
+			tracker, _ := deduptest.NewTracker(t)
+			cfg := newTestConfig()
+			cfg.Service.PostOrder = tt.order
+
+			service, err := integration.NewService(cfg, logger.NewNopLogger(),
+				integration.WithSource(searcher),
+				integration.WithPoster(poster),
+				integration.WithTracker(tracker),
+				integration.WithLimiter(rate.NewLimiter(rate.Inf, 1)),
+			)
+			if err != nil {
+				t.Fatalf("NewService() error = %v", err)
+			}
+			if _, err := service.ProcessCity(context.Background(), config.CityConfig{Name: "sudbury_com"}); err != nil {
+				t.Fatalf("ProcessCity() error = %v", err)
+			}
+
+			var got []string
+			for _, req := range poster.Posted() {
+				got = append(got, req.ExternalID)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("posted %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProcessCity_ScoresSeverity(t *testing.T) {
+	searcher := &fakeSearcher{articles: []map[string]any{
+		{"id": "theft", "title": "Police investigate theft", "published_date": "2025-01-03T10:00:00Z"},
+		{"id": "homicide", "title": "Police investigate homicide", "published_date": "2025-01-01T10:00:00Z"},
+		{"id": "robbery", "title": "Police investigate robbery", "body": "Homicide detectives assist", "published_date": "2025-01-02T10:00:00Z"},
+		{"id": "unscored", "title": "Police make arrest", "published_date": "2025-01-04T10:00:00Z"},
+	}}
+	poster := &drupaltest.Poster{}
+	tracker, _ := deduptest.NewTracker(t)
+	cfg := newTestConfig()
+	cfg.Service.PostOrder = config.PostOrderSeverity
+	cfg.Service.Severity = config.SeverityConfig{
+		Weights:  map[string]int{"homicide": 10, "robbery": 5, "theft": 2},
+		MinScore: 2,
+	}
+	cfg.Service.FieldMap = []config.FieldMapConfig{{Field: "field_severity", Source: "severity", Type: config.FieldTypeInteger}}
+
+	service, err := integration.NewService(cfg, logger.NewNopLogger(),
+		integration.WithSource(searcher),
+		integration.WithPoster(poster),
+		integration.WithTracker(tracker),
+		integration.WithLimiter(rate.NewLimiter(rate.Inf, 1)),
+	)
+	if err != nil {
+		t.Fatalf("NewService() error = %v", err)
+	}
+	if _, err := service.ProcessCity(context.Background(), config.CityConfig{Name: "sudbury_com"}); err != nil {
+		t.Fatalf("ProcessCity() error = %v", err)
+	}
+
+	// Highest score first; the article matching no weighted keyword is below min_score
+	var got []string
+	for _, req := range poster.Posted() {
+		got = append(got, fmt.Sprintf("%s=%v", req.ExternalID, req.Fields["field_severity"]))
+	}
+	want := []string{"robbery=15", "homicide=10", "theft=2"}
+	if !slices.Equal(got, want) {
+		t.Errorf("posted %v, want %v", got, want)
+	}
+}
+
+func TestProcessCity_DefersArticlesOverGroupQuota(t *testing.T) {
+	searcher := &fakeSearcher{articles: []map[string]any{
+		{"id": "jan-01", "title": "Police investigate robbery", "published_date": "2025-01-01T10:00:00Z"},
+		{"id": "jan-02", "title": "Police investigate robbery", "published_date": "2025-01-02T10:00:00Z"},
+		{"id": "jan-03", "title": "Police investigate robbery", "published_date": "2025-01-03T10:00:00Z"},
+	}}
+	poster := &drupaltest.Poster{}
+	tracker, mr := deduptest.NewTracker(t)
+	cfg := newTestConfig()
+	cfg.Service.GroupQuota = config.GroupQuotaConfig{
+		DailyLimit: 5,
+		Groups:     map[string]int{"group-1": 2},
+		Rollover:   "06:00",
+		Overflow:   config.QuotaOverflowDefer,
+	}
+
+	service, err := integration.NewService(cfg, logger.NewNopLogger(),
+		integration.WithSource(searcher),
+		integration.WithPoster(poster),
+		integration.WithTracker(tracker),
+		integration.WithLimiter(rate.NewLimiter(rate.Inf, 1)),
+		integration.WithQuotaStore(quota.NewStore(deduptest.NewClient(t, mr), deduptest.KeyPrefix)),
+	)
+	if err != nil {
+		t.Fatalf("NewService() error = %v", err)
+	}
+	city := config.CityConfig{Name: "sudbury_com", GroupID: "group-1"}
+
+	assertPosted := func(want ...string) {
+		t.Helper()
+		var got []string
+		for _, req := range poster.Posted() {
+			got = append(got, req.ExternalID)
+		}
+		if !slices.Equal(got, want) {
+			t.Errorf("posted %v, want %v", got, want)
+		}
+	}
+
+	// The quota of 2 takes the newest articles; jan-01 is deferred, and stays deferred
+	// while the quota is full
+	for range 2 {
+		if _, err := service.ProcessCity(context.Background(), city); err != nil {
+			t.Fatalf("ProcessCity() error = %v", err)
+		}
+		assertPosted("jan-03", "jan-02")
+		if !mr.Exists(deduptest.KeyPrefix + quota.KeyPrefix + "deferred:sudbury_com") {
+			t.Fatal("jan-01 was not deferred")
+		}
+	}
+
+	// The next quota day, the deferred article is posted first
+	for _, key := range mr.Keys() {
+		if strings.HasPrefix(key, deduptest.KeyPrefix+quota.KeyPrefix+"group-1:") {
+			mr.Del(key)
+		}
+	}
+	searcher.articles = []map[string]any{{"id": "jan-04", "title": "Police investigate robbery", "published_date": "2025-01-04T10:00:00Z"}}
+	if _, err := service.ProcessCity(context.Background(), city); err != nil {
+		t.Fatalf("ProcessCity() error = %v", err)
+	}
+	assertPosted("jan-03", "jan-02", "jan-01", "jan-04")
+}
+
+func TestProcessCity_KeepsDeferredArticlesUntilPosted(t *testing.T) {
+	searcher := &fakeSearcher{articles: []map[string]any{
+		{"id": "jan-01", "title": "Police investigate robbery", "published_date": "2025-01-01T10:00:00Z"},
+		{"id": "jan-02", "title": "Police investigate robbery", "published_date": "2025-01-02T10:00:00Z"},
+	}}
+	poster := &drupaltest.Poster{}
+	tracker, mr := deduptest.NewTracker(t)
+	cfg := newTestConfig()
+	cfg.Service.GroupQuota = config.GroupQuotaConfig{Groups: map[string]int{"group-1": 1}, Overflow: config.QuotaOverflowDefer}
+	cfg.Service.Maintenance = config.MaintenanceConfig{Backoff: 10 * time.Millisecond, MaxBackoff: 10 * time.Millisecond}
+
+	service, err := integration.NewService(cfg, logger.NewNopLogger(),
+		integration.WithSource(searcher),
+		integration.WithPoster(poster),
+		integration.WithTracker(tracker),
+		integration.WithLimiter(rate.NewLimiter(rate.Inf, 1)),
+		integration.WithQuotaStore(quota.NewStore(deduptest.NewClient(t, mr), deduptest.KeyPrefix)),
+	)
+	if err != nil {
+		t.Fatalf("NewService() error = %v", err)
+	}
+	city := config.CityConfig{Name: "sudbury_com", GroupID: "group-1"}
+	deferredKey := deduptest.KeyPrefix + quota.KeyPrefix + "deferred:sudbury_com"
+	newQuotaDay := func() {
+		for _, key := range mr.Keys() {
+			if strings.HasPrefix(key, deduptest.KeyPrefix+quota.KeyPrefix+"group-1:") {
+				mr.Del(key)
+			}
+		}
+	}
+
+	// jan-01 does not fit the quota of 1
+	if _, err := service.ProcessCity(context.Background(), city); err != nil {
+		t.Fatalf("ProcessCity() error = %v", err)
+	}
+	if !mr.Exists(deferredKey) {
+		t.Fatal("jan-01 was not deferred")
+	}
+
+	// The next day Drupal is in maintenance; the held article stays deferred
+	newQuotaDay()
+	searcher.articles = nil
+	poster.Err = drupal.ErrMaintenance
+	if _, err := service.ProcessCity(context.Background(), city); err != nil {
+		t.Fatalf("ProcessCity() error = %v", err)
+	}
+	if !mr.Exists(deferredKey) {
+		t.Fatal("jan-01 was forgotten while held for maintenance")
+	}
+
+	// Once posted, it is no longer deferred
+	newQuotaDay()
+	poster.Err = nil
+	time.Sleep(20 * time.Millisecond)
+	if _, err := service.ProcessCity(context.Background(), city); err != nil {
+		t.Fatalf("ProcessCity() error = %v", err)
+	}
+	if posted := poster.Posted(); len(posted) != 2 || posted[1].ExternalID != "jan-01" {
+		t.Fatalf("posted %+v, want jan-02 then jan-01", posted)
+	}
+	if mr.Exists(deferredKey) {
+		t.Error("jan-01 is still deferred after it was posted")
+	}
+}
+
+func TestRun_OutboxReleasesGroupQuotaOfDeadLetteredArticles(t *testing.T) {
+	searcher := &fakeSearcher{articles: []map[string]any{
+		{"id": "ok", "title": "Police investigate robbery"},
+		{"id": "fails", "title": "Police make arrest"},
+	}}
+	poster := &drupaltest.Poster{
+		ErrFunc: func(req drupal.ArticleRequest) error {
+			if req.ExternalID == "fails" {
+				return errors.New("drupal unavailable")
+			}
+			return nil
+		},
+	}
+	tracker, mr := deduptest.NewTracker(t)
+	queue := outbox.NewListQueue(deduptest.NewClient(t, mr), deduptest.KeyPrefix, time.Minute, logger.NewNopLogger())
+
+	cfg := newTestConfig()
+	cfg.Service.CheckInterval = time.Hour
+	cfg.Cities = []config.CityConfig{{Name: "sudbury_com", GroupID: "group-1"}}
+	cfg.Service.GroupQuota = config.GroupQuotaConfig{DailyLimit: 5, Overflow: config.QuotaOverflowDrop}
+	cfg.Outbox = config.OutboxConfig{Enabled: true, Workers: 1, MaxAttempts: 1}
+
+	service, err := integration.NewService(cfg, logger.NewNopLogger(),
+		integration.WithSource(searcher),
+		integration.WithPoster(poster),
+		integration.WithTracker(tracker),
+		integration.WithLimiter(rate.NewLimiter(rate.Inf, 1)),
+		integration.WithQueue(queue),
+		integration.WithQuotaStore(quota.NewStore(deduptest.NewClient(t, mr), deduptest.KeyPrefix)),
+	)
+	if err != nil {
+		t.Fatalf("NewService() error = %v", err)
+	}
+
+	counted := func() int {
+		total := 0
+		for _, key := range mr.Keys() {
+			if strings.HasPrefix(key, deduptest.KeyPrefix+quota.KeyPrefix+"group-1:") {
+				value, _ := mr.Get(key)
+				n, _ := strconv.Atoi(value)
+				total += n
+			}
+		}
+		return total
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- service.Run(ctx) }()
+
+	// Both articles are counted at enqueue; the dead-lettered one is given back
+	deadline := time.Now().Add(5 * time.Second)
+	for (len(poster.Posted()) < 1 || counted() != 1) && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	if got := len(poster.Posted()); got != 1 {
+		t.Fatalf("posted %d articles, want 1", got)
+	}
+	if got := counted(); got != 1 {
+		t.Errorf("group quota counts %d posts, want only the posted article", got)
+	}
+}
+
+func TestRun_OutboxRequeuesArticlesDrupalTurnsBack(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+	}{
+		{name: "maintenance", err: drupal.ErrMaintenance},
+		{name: "rejected credentials", err: &drupal.APIError{StatusCode: http.StatusForbidden}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			searcher := &fakeSearcher{articles: []map[string]any{
+				{"id": "a", "title": "Police investigate robbery"},
+			}}
+			var calls atomic.Int32
+			poster := &drupaltest.Poster{
+				ErrFunc: func(drupal.ArticleRequest) error {
+					if calls.Add(1) == 1 {
+						return tt.err
+					}
+					return nil
+				},
+			}
+			tracker, mr := deduptest.NewTracker(t)
+			queue := outbox.NewListQueue(deduptest.NewClient(t, mr), deduptest.KeyPrefix, time.Minute, logger.NewNopLogger())
+
+			cfg := newTestConfig()
+			cfg.Service.CheckInterval = time.Hour
+			cfg.Service.Maintenance = config.MaintenanceConfig{Backoff: 20 * time.Millisecond, MaxBackoff: 20 * time.Millisecond}
+			cfg.Service.AuthFailure = config.AuthFailureConfig{Backoff: 20 * time.Millisecond, MaxBackoff: 20 * time.Millisecond}
+			cfg.Cities = []config.CityConfig{{Name: "sudbury_com"}}
+			// A single failed attempt would dead-letter the article
+			cfg.Outbox = config.OutboxConfig{Enabled: true, Workers: 1, MaxAttempts: 1}
+
+			service, err := integration.NewService(cfg, logger.NewNopLogger(),
+				integration.WithSource(searcher),
+				integration.WithPoster(poster),
+				integration.WithTracker(tracker),
+				integration.WithLimiter(rate.NewLimiter(rate.Inf, 1)),
+				integration.WithQueue(queue),
+			)
+			if err != nil {
+				t.Fatalf("NewService() error = %v", err)
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			done := make(chan error, 1)
+			go func() { done <- service.Run(ctx) }()
+
+			deadline := time.Now().Add(5 * time.Second)
+			for len(poster.Posted()) < 1 && time.Now().Before(deadline) {
+				time.Sleep(10 * time.Millisecond)
+			}
+			cancel()
+			<-done
+
+			if got := len(poster.Posted()); got != 1 {
+				t.Fatalf("posted %d articles, want 1 once Drupal accepted posts again", got)
+			}
+			if dead, _ := mr.List(deduptest.KeyPrefix + "outbox:dead"); len(dead) != 0 {
+				t.Errorf("dead-letter list = %v, want empty", dead)
+			}
+		})
+	}
+}
+
+func TestRun_OutboxHoldsQueuedArticlesOfPausedCity(t *testing.T) {
+	poster := &drupaltest.Poster{}
+	tracker, mr := deduptest.NewTracker(t)
+	queue := outbox.NewListQueue(deduptest.NewClient(t, mr), deduptest.KeyPrefix, time.Minute, logger.NewNopLogger())
+	pauses := pause.NewSwitch(deduptest.NewClient(t, mr), deduptest.KeyPrefix, logger.NewNopLogger())
+
+	cfg := newTestConfig()
+	cfg.Service.CheckInterval = time.Hour
+	cfg.Cities = []config.CityConfig{{Name: "sudbury_com"}, {Name: "toronto_com"}}
+	cfg.Outbox = config.OutboxConfig{Enabled: true, Workers: 1, MaxAttempts: 1}
+
+	service, err := integration.NewService(cfg, logger.NewNopLogger(),
+		integration.WithSource(&fakeSearcher{}),
+		integration.WithPoster(poster),
+		integration.WithTracker(tracker),
+		integration.WithLimiter(rate.NewLimiter(rate.Inf, 1)),
+		integration.WithQueue(queue),
+		integration.WithPauseSwitch(pauses),
+	)
+	if err != nil {
+		t.Fatalf("NewService() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	for _, city := range []string{"sudbury_com", "toronto_com"} {
+		item := outbox.Item{City: city, Article: pipeline.Article{ID: city + "-1", Title: "Police investigate robbery"}}
+		if _, err := queue.Enqueue(ctx, item); err != nil {
+			t.Fatalf("Enqueue(%s) error = %v", city, err)
+		}
+	}
+	if err := service.Pause(ctx, "sudbury_com", ""); err != nil {
+		t.Fatalf("Pause() error = %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- service.Run(ctx) }()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for len(poster.Posted()) < 1 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	// Give the worker time to reach the paused city's article too
+	time.Sleep(100 * time.Millisecond)
+	cancel()
+	<-done
+
+	posted := poster.Posted()
+	if len(posted) != 1 || posted[0].ExternalID != "toronto_com-1" {
+		t.Fatalf("posted %+v, want only the unpaused city's article", posted)
+	}
+	if dead, _ := mr.List(deduptest.KeyPrefix + "outbox:dead"); len(dead) != 0 {
+		t.Errorf("dead-letter list = %v, want empty", dead)
+	}
+	if added, _ := queue.Enqueue(context.Background(), outbox.Item{City: "sudbury_com", Article: pipeline.Article{ID: "sudbury_com-1"}}); added {
+		t.Error("paused city's article is no longer queued")
+	}
+}
+
+func TestProcessCity_HoldsPostingDuringDrupalMaintenance(t *testing.T) {
+	searcher := &fakeSearcher{articles: []map[string]any{
+		{"id": "a1", "title": "Police investigate robbery"},
+		{"id": "a2", "title": "Police charge man with assault"},
+		{"id": "a3", "title": "Police recover stolen car"},
+	}}
+	poster := &drupaltest.Poster{Err: drupal.ErrMaintenance}
+	tracker, _ := deduptest.NewTracker(t)
+	cfg := newTestConfig()
+	cfg.Service.Maintenance = config.MaintenanceConfig{Backoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+
+	service, err := integration.NewService(cfg, logger.NewNopLogger(),
+		integration.WithSource(searcher),
+		integration.WithPoster(poster),
+		integration.WithTracker(tracker),
+		integration.WithLimiter(rate.NewLimiter(rate.Inf, 1)),
+	)
+	if err != nil {
+		t.Fatalf("NewService() error = %v", err)
+	}
+	city := config.CityConfig{Name: "sudbury_com"}
+
+	// The first post finds the site in maintenance; the rest of the city waits
+	if _, err := service.ProcessCity(context.Background(), city); err != nil {
+		t.Fatalf("ProcessCity() error = %v", err)
+	}
+	if got := poster.Attempts(); got != 1 {
+		t.Errorf("post attempts = %d, want 1", got)
+	}
+	if service.Status().Maintenance == nil {
+		t.Fatal("Status().Maintenance = nil, want the maintenance mode")
+	}
+
+	// After the backoff, the next post checks the site again
+	poster.Err = nil
+	time.Sleep(150 * time.Millisecond)
+	if _, err := service.ProcessCity(context.Background(), city); err != nil {
+		t.Fatalf("ProcessCity() error = %v", err)
+	}
+	if got := len(poster.Posted()); got != 3 {
+		t.Errorf("posted %d articles, want 3", got)
+	}
+	if got := service.Status().Maintenance; got != nil {
+		t.Errorf("Status().Maintenance = %+v, want nil once the site is back", got)
+	}
+}
+
+func TestProcessCity_StopsPostingWhenCredentialsAreRejected(t *testing.T) {
+	searcher := &fakeSearcher{articles: []map[string]any{
+		{"id": "a1", "title": "Police investigate robbery"},
+		{"id": "a2", "title": "Police charge man with assault"},
+	}}
+	poster := &drupaltest.Poster{Err: &drupal.APIError{StatusCode: http.StatusForbidden}}
+	tracker, _ := deduptest.NewTracker(t)
+	cfg := newTestConfig()
+	cfg.Service.AuthFailure = config.AuthFailureConfig{Backoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+
+	service, err := integration.NewService(cfg, logger.NewNopLogger(),
+		integration.WithSource(searcher),
+		integration.WithPoster(poster),
+		integration.WithTracker(tracker),
+		integration.WithLimiter(rate.NewLimiter(rate.Inf, 1)),
+	)
+	if err != nil {
+		t.Fatalf("NewService() error = %v", err)
+	}
+	city := config.CityConfig{Name: "sudbury_com"}
+
+	// The first rejected post opens the circuit; the rest of the city waits
+	if _, err := service.ProcessCity(context.Background(), city); err != nil {
+		t.Fatalf("ProcessCity() error = %v", err)
+	}
+	if got := poster.Attempts(); got != 1 {
+		t.Errorf("post attempts = %d, want 1", got)
+	}
+	status := service.Status()
+	if status.Credentials == nil || status.Health.Backends.Drupal {
+		t.Fatalf("Status() = %+v, want the credential rejection and Drupal unavailable", status)
+	}
+
+	// After the backoff, the next post tries the credentials again
+	poster.Err = nil
+	time.Sleep(150 * time.Millisecond)
+	if _, err := service.ProcessCity(context.Background(), city); err != nil {
+		t.Fatalf("ProcessCity() error = %v", err)
+	}
+	if got := len(poster.Posted()); got != 2 {
+		t.Errorf("posted %d articles, want 2", got)
+	}
+	if got := service.Status().Credentials; got != nil {
+		t.Errorf("Status().Credentials = %+v, want nil once the credentials work", got)
+	}
+}
+
+// prefixTranslator "translates" texts by prefixing them with the target language.
+type prefixTranslator struct {
+	requests []translate.Request
+	err      error
+}
+
+func (p *prefixTranslator) Translate(_ context.Context, req translate.Request) ([]string, error) {
+	p.requests = append(p.requests, req)
+	if p.err != nil {
+		return nil, p.err
+	}
+	translations := make([]string, len(req.Texts))
+	for i, text := range req.Texts {
+		translations[i] = "[" + req.Target + "] " + text
+	}
+	return translations, nil
+}
+
+func TestProcessCity_TranslatesArticles(t *testing.T) {
+	searcher := &fakeSearcher{articles: []map[string]any{
+		{"id": "a1", "title": "Police investigate robbery", "body": "<p>Officers responded.</p>"},
+	}}
+	poster := &drupaltest.Poster{}
+	translator := &prefixTranslator{err: errors.New("quota exceeded")}
+	tracker, _ := deduptest.NewTracker(t)
+
+	service, err := integration.NewService(newTestConfig(), logger.NewNopLogger(),
+		integration.WithSource(searcher),
+		integration.WithPoster(poster),
+		integration.WithTracker(tracker),
+		integration.WithLimiter(rate.NewLimiter(rate.Inf, 1)),
+		integration.WithTranslator(translator),
+	)
+	if err != nil {
+		t.Fatalf("NewService() error = %v", err)
+	}
+	city := config.CityConfig{Name: "grand_sudbury", TranslateFrom: "en", TranslateTo: "fr", Language: "fr"}
+
+	// A failed translation is not posted untranslated, and the article is retried
+	if _, err := service.ProcessCity(context.Background(), city); err != nil {
+		t.Fatalf("ProcessCity() error = %v", err)
+	}
+	if got := poster.Attempts(); got != 0 {
+		t.Fatalf("post attempts = %d after a failed translation, want 0", got)
+	}
+
+	translator.err = nil
+	translator.requests = nil
+	if _, err := service.ProcessCity(context.Background(), city); err != nil {
+		t.Fatalf("ProcessCity() error = %v", err)
+	}
+	posted := poster.Posted()
+	if len(posted) != 1 {
+		t.Fatalf("posted %d articles, want 1", len(posted))
+	}
+	if posted[0].Title != "[fr] Police investigate robbery" || posted[0].Body != "[fr] <p>Officers responded.</p>" || posted[0].Language != "fr" {
+		t.Errorf("posted title %q, body %q, language %q; want the French translation", posted[0].Title, posted[0].Body, posted[0].Language)
+	}
+	if len(translator.requests) != 2 || translator.requests[0].HTML || !translator.requests[1].HTML || translator.requests[0].Source != "en" {
+		t.Errorf("translation requests = %+v, want plain text from en, then the HTML body", translator.requests)
+	}
+}
+
+func TestProcessCity_AuditsPostedArticles(t *testing.T) {
+	esServer := estest.NewServer(t)
+	esServer.AddHits("sudbury_com_articles",
+		estest.Hit{ID: "es-1", Source: map[string]any{
+			"title":          "Police arrest suspect after robbery",
+			"body":           "<p>Officers charged a man on Tuesday.</p>",
+			"canonical_url":  "https://example.com/robbery",
+			"published_date": "2025-01-15T10:30:00Z",
+		}},
+	)
+	drupalServer := drupaltest.NewServer(t, "gopost", "secret")
+	tracker, _ := deduptest.NewTracker(t)
+	cfg := newTestConfig()
+	cfg.Elasticsearch = config.ElasticsearchConfig{URL: esServer.URL}
+	cfg.Drupal = config.DrupalConfig{URL: drupalServer.URL, Username: "gopost", Token: "secret"}
+	cfg.Audit = config.AuditConfig{Enabled: true, Index: "gopost_audit"}
+
+	service, err := integration.NewService(cfg, logger.NewNopLogger(),
+		integration.WithTracker(tracker),
+		integration.WithLimiter(rate.NewLimiter(rate.Inf, 1)),
+	)
+	if err != nil {
+		t.Fatalf("NewService() error = %v", err)
+	}
+	if _, err := service.ProcessCity(context.Background(), config.CityConfig{Name: "sudbury_com"}); err != nil {
+		t.Fatalf("ProcessCity() error = %v", err)
+	}
+
+	nodes := drupalServer.Nodes()
+	docs := esServer.Documents("gopost_audit")
+	if len(nodes) != 1 || len(docs) != 1 {
+		t.Fatalf("posted %d nodes and %d audit documents, want 1 each", len(nodes), len(docs))
+	}
+	if docs[0].ID != "es-1" {
+		t.Errorf("audit document ID = %q, want the article ID", docs[0].ID)
+	}
+	audit := docs[0].Source.(map[string]any)
+	if audit["drupal_uuid"] != nodes[0].ID || audit["source_index"] != "sudbury_com_articles" || audit["posted_at"] == nil {
+		t.Errorf("audit document = %v, want node UUID %s, source index, and post time", audit, nodes[0].ID)
+	}
+	payload, _ := audit["payload"].(map[string]any)
+	data, _ := payload["data"].(map[string]any)
+	attributes, _ := data["attributes"].(map[string]any)
+	if attributes["field_external_id"] != "es-1" || attributes["title"] != "Police arrest suspect after robbery" {
+		t.Errorf("audit payload attributes = %v, want the JSON:API document sent to Drupal", attributes)
+	}
+}
+
+func TestRecordModeration_SuppressesRejectedArticles(t *testing.T) {
+	esServer := estest.NewServer(t)
+	esServer.AddHits("sudbury_com_articles",
+		estest.Hit{ID: "es-1", Source: map[string]any{"title": "Police arrest suspect after robbery"}},
+	)
+	drupalServer := drupaltest.NewServer(t, "gopost", "secret")
+	tracker, mr := deduptest.NewTracker(t)
+	cfg := newTestConfig()
+	cfg.Elasticsearch = config.ElasticsearchConfig{URL: esServer.URL}
+	cfg.Drupal = config.DrupalConfig{URL: drupalServer.URL, Username: "gopost", Token: "secret"}
+	cfg.Audit = config.AuditConfig{Enabled: true, Index: "gopost_audit"}
+	suppressed := dedup.NewSuppressionList(deduptest.NewClient(t, mr), deduptest.KeyPrefix)
+
+	service, err := integration.NewService(cfg, logger.NewNopLogger(),
+		integration.WithTracker(tracker),
+		integration.WithLimiter(rate.NewLimiter(rate.Inf, 1)),
+		integration.WithSuppressionList(suppressed),
+	)
+	if err != nil {
+		t.Fatalf("NewService() error = %v", err)
+	}
+	ctx := context.Background()
+	if _, err := service.ProcessCity(ctx, config.CityConfig{Name: "sudbury_com"}); err != nil {
+		t.Fatalf("ProcessCity() error = %v", err)
+	}
+
+	for _, event := range []integration.ModerationEvent{
+		{ArticleID: "es-1", Decision: integration.ModerationEdited, City: "sudbury_com"},
+		{ArticleID: "es-1", Decision: integration.ModerationRejected, State: "archived", Editor: "jdoe", City: "sudbury_com"},
+	} {
+		if err := service.RecordModeration(ctx, event); err != nil {
+			t.Fatalf("RecordModeration(%s) error = %v", event.Decision, err)
+		}
+	}
+	if err := service.RecordModeration(ctx, integration.ModerationEvent{ArticleID: "es-1", Decision: "liked"}); !errors.Is(err, integration.ErrInvalidModeration) {
+		t.Errorf("RecordModeration(unknown decision) error = %v, want ErrInvalidModeration", err)
+	}
+
+	if ok, err := suppressed.Contains(ctx, "es-1"); err != nil || !ok {
+		t.Errorf("suppression list contains es-1 = %v, %v; want rejected article suppressed", ok, err)
+	}
+	docs := esServer.Documents("gopost_audit")
+	if len(docs) != 1 {
+		t.Fatalf("%d audit documents, want 1", len(docs))
+	}
+	audit := docs[0].Source.(map[string]any)
+	moderation, _ := audit["moderation"].(map[string]any)
+	if moderation["decision"] != "rejected" || moderation["state"] != "archived" || moderation["editor"] != "jdoe" || audit["drupal_uuid"] == nil {
+		t.Errorf("audit document = %v, want the last decision added to the posted article", audit)
+	}
+	want := []integration.ModerationCount{
+		{City: "sudbury_com", Decision: "edited", Count: 1},
+		{City: "sudbury_com", Decision: "rejected", Count: 1},
+	}
+	if got := service.Status().Moderation; !reflect.DeepEqual(got, want) {
+		t.Errorf("Status().Moderation = %+v, want %+v", got, want)
+	}
+}
+
+func TestRollback_DeletesAuditedPosts(t *testing.T) {
+	esServer := estest.NewServer(t)
+	esServer.AddHits("sudbury_com_articles",
+		estest.Hit{ID: "es-1", Source: map[string]any{"title": "Police arrest suspect after robbery"}},
+		estest.Hit{ID: "es-2", Source: map[string]any{"title": "Police investigate break-in"}},
+	)
+	drupalServer := drupaltest.NewServer(t, "gopost", "secret")
+	tracker, _ := deduptest.NewTracker(t)
+	cfg := newTestConfig()
+	cfg.Elasticsearch = config.ElasticsearchConfig{URL: esServer.URL}
+	cfg.Drupal = config.DrupalConfig{URL: drupalServer.URL, Username: "gopost", Token: "secret"}
+	cfg.Audit = config.AuditConfig{Enabled: true, Index: "gopost_audit"}
+
+	service, err := integration.NewService(cfg, logger.NewNopLogger(),
+		integration.WithTracker(tracker),
+		integration.WithLimiter(rate.NewLimiter(rate.Inf, 1)),
+	)
+	if err != nil {
+		t.Fatalf("NewService() error = %v", err)
+	}
+	ctx := context.Background()
+	if _, err := service.ProcessCity(ctx, config.CityConfig{Name: "sudbury_com"}); err != nil {
+		t.Fatalf("ProcessCity() error = %v", err)
+	}
+	if got := len(drupalServer.Nodes()); got != 2 {
+		t.Fatalf("posted %d nodes, want 2", got)
+	}
+
+	opts := integration.RollbackOptions{Since: time.Now().Add(-time.Hour), City: "sudbury_com", DryRun: true}
+	posts, err := service.Rollback(ctx, opts)
+	if err != nil || len(posts) != 2 || len(drupalServer.Nodes()) != 2 {
+		t.Fatalf("dry run Rollback() = %d posts, %v with %d nodes left; want 2 posts listed and none deleted", len(posts), err, len(drupalServer.Nodes()))
+	}
+
+	opts.DryRun = false
+	posts, err = service.Rollback(ctx, opts)
+	if err != nil {
+		t.Fatalf("Rollback() error = %v", err)
+	}
+	for _, post := range posts {
+		if post.Err != nil {
+			t.Errorf("Rollback() post %s error = %v", post.ArticleID, post.Err)
+		}
+	}
+	if nodes := drupalServer.Nodes(); len(nodes) != 0 {
+		t.Errorf("%d nodes left after rollback, want 0", len(nodes))
+	}
+	if tracker.HasPosted(ctx, "es-1") || tracker.HasPosted(ctx, "es-2") {
+		t.Error("rolled back articles still marked as posted, want dedup markers cleared")
+	}
+	for _, doc := range esServer.Documents("gopost_audit") {
+		rolledBack, _ := doc.Source.(map[string]any)["rolled_back"].(map[string]any)
+		if rolledBack["action"] != "deleted" {
+			t.Errorf("audit document %s rolled_back = %v, want action deleted", doc.ID, rolledBack)
+		}
+	}
+
+	// Fixed filters can post them again with a replay
+	if _, err := service.ProcessCity(ctx, config.CityConfig{Name: "sudbury_com"}); err != nil {
+		t.Fatalf("ProcessCity() error = %v", err)
+	}
+	if got := len(drupalServer.Nodes()); got != 2 {
+		t.Errorf("reposted %d nodes after rollback, want 2", got)
+	}
+}
+
+func TestProcessCity_RunsHooks(t *testing.T) {
+	var mu sync.Mutex
+	var events []string
+	hookServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload struct {
+			Event   string `json:"event"`
+			City    string `json:"city"`
+			Article struct {
+				ID string `json:"id"`
+			} `json:"article"`
+			Error string `json:"error"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Errorf("decode hook payload: %v", err)
+		}
+		mu.Lock()
+		events = append(events, fmt.Sprintf("%s %s %s %s", payload.Event, payload.City, payload.Article.ID, payload.Error))
+		mu.Unlock()
+	}))
+	t.Cleanup(hookServer.Close)
+
+	searcher := &fakeSearcher{articles: []map[string]any{
+		{"id": "ok", "title": "Police investigate robbery"},
+		{"id": "fails", "title": "Police make arrest"},
+	}}
+	poster := &drupaltest.Poster{
+		ErrFunc: func(req drupal.ArticleRequest) error {
+			if req.ExternalID == "fails" {
+				return errors.New("drupal unavailable")
+			}
+			return nil
+		},
+	}
+	tracker, _ := deduptest.NewTracker(t)
+	cfg := newTestConfig()
+	cfg.Hooks = []config.HookConfig{
+		{Event: config.HookPrePost, URL: hookServer.URL, Timeout: time.Second},
+		{Event: config.HookPostSuccess, URL: hookServer.URL, Timeout: time.Second},
+		{Event: config.HookPostFailure, URL: hookServer.URL, Timeout: time.Second},
+	}
+
+	service, err := integration.NewService(cfg, logger.NewNopLogger(),
+		integration.WithSource(searcher),
+		integration.WithPoster(poster),
+		integration.WithTracker(tracker),
+		integration.WithLimiter(rate.NewLimiter(rate.Inf, 1)),
+	)
+	if err != nil {
+		t.Fatalf("NewService() error = %v", err)
+	}
+	if _, err := service.ProcessCity(context.Background(), config.CityConfig{Name: "sudbury_com"}); err != nil {
+		t.Fatalf("ProcessCity() error = %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	slices.Sort(events)
+	want := []string{
+		"post_failure sudbury_com fails drupal unavailable",
+		"post_success sudbury_com ok ",
+		"pre_post sudbury_com fails ",
+		"pre_post sudbury_com ok ",
+	}
+	if !slices.Equal(events, want) {
+		t.Errorf("hook events = %q, want %q", events, want)
+	}
+}
+
+func TestProcessCity_UsesQueryTemplate(t *testing.T) {
+	templatePath := filepath.Join(t.TempDir(), "sudbury.json.tmpl")
+	queryTemplate := `{
+  "size": {{.Size}},
+  "query": {"bool": {"must": [
+    {"match": {"{{.BodyField}}": {{json .KeywordQuery}}}},
+    {"term": {"section": "crime"}}
+  ]}},
+  "sort": [{"{{.DateField}}": {"order": "desc"}}]
+}`
+	if err := os.WriteFile(templatePath, []byte(queryTemplate), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	searcher := &fakeSearcher{articles: []map[string]any{{"id": "1", "title": "Police investigate robbery"}}}
+	poster := &drupaltest.Poster{}
+	tracker, _ := deduptest.NewTracker(t)
+	cfg := newTestConfig()
+	cfg.Cities = []config.CityConfig{{Name: "sudbury_com", QueryTemplate: templatePath}}
+
+	service, err := integration.NewService(cfg, logger.NewNopLogger(),
+		integration.WithSource(searcher),
+		integration.WithPoster(poster),
+		integration.WithTracker(tracker),
+		integration.WithLimiter(rate.NewLimiter(rate.Inf, 1)),
+	)
+	if err != nil {
+		t.Fatalf("NewService() error = %v", err)
+	}
+	if _, err := service.ProcessCity(context.Background(), cfg.Cities[0]); err != nil {
+		t.Fatalf("ProcessCity() error = %v", err)
+	}
+
+	encoded, err := json.Marshal(searcher.queries[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`"size":100`, `{"term":{"section":"crime"}}`, `"body":"police robbery"`} {
+		if !strings.Contains(string(encoded), want) {
+			t.Errorf("query %s does not contain %s", encoded, want)
+		}
+	}
+	if len(poster.Posted()) != 1 {
+		t.Errorf("posted %d articles, want 1", len(poster.Posted()))
+	}
+
+	cfg.Cities[0].QueryTemplate = filepath.Join(t.TempDir(), "missing.json.tmpl")
+	if _, err := integration.NewService(cfg, logger.NewNopLogger(),
+		integration.WithSource(searcher),
+		integration.WithPoster(poster),
+		integration.WithTracker(tracker),
+	); err == nil {
+		t.Error("NewService() with a missing query template succeeded, want error")
+	}
+}
+
+func TestProcessCity_ArticleCeiling(t *testing.T) {
+	searcher := &fakeSearcher{articles: []map[string]any{
+		{"id": "1", "title": "Police investigate robbery", "published_date": "2025-01-15T12:00:00Z"},
+		{"id": "2", "title": "Police make arrest", "published_date": "2025-01-15T11:00:00Z"},
+		{"id": "3", "title": "Robbery suspect charged", "published_date": "2025-01-15T10:00:00Z"},
+	}}
+	poster := &drupaltest.Poster{}
+	tracker, _ := deduptest.NewTracker(t)
+	cfg := newTestConfig()
+	cfg.Cities = []config.CityConfig{{Name: "sudbury_com"}}
+	cfg.Service.SearchSize = 25
+	cfg.Service.MaxArticlesPerCityPerRun = 2
+
+	service, err := integration.NewService(cfg, logger.NewNopLogger(),
+		integration.WithSource(searcher),
+		integration.WithPoster(poster),
+		integration.WithTracker(tracker),
+		integration.WithLimiter(rate.NewLimiter(rate.Inf, 1)),
+	)
+	if err != nil {
+		t.Fatalf("NewService() error = %v", err)
+	}
+	result, err := service.ProcessCity(context.Background(), cfg.Cities[0])
+	if err != nil {
+		t.Fatalf("ProcessCity() error = %v", err)
+	}
+
+	if query, _ := searcher.queries[0].(map[string]any); query["size"] != 25 {
+		t.Errorf("query = %v, want size 25", searcher.queries[0])
+	}
+	if result.Found != 3 || result.Posted != 2 || result.Truncated != 1 {
+		t.Errorf("result = found %d, posted %d, truncated %d; want 3, 2, 1", result.Found, result.Posted, result.Truncated)
+	}
+	if posted := poster.Posted(); len(posted) != 2 || posted[0].ExternalID != "1" || posted[1].ExternalID != "2" {
+		t.Errorf("posted = %+v, want the two newest articles", posted)
+	}
+}
+
+func TestProcessCity_PathAlias(t *testing.T) {
+	searcher := &fakeSearcher{articles: []map[string]any{
+		{"id": "es-1", "title": "Café robbery: police seek suspect", "published_date": "2025-01-15T12:00:00Z"},
+	}}
+	poster := &drupaltest.Poster{}
+	tracker, _ := deduptest.NewTracker(t)
+	cfg := newTestConfig()
+	cfg.Cities = []config.CityConfig{{Name: "sudbury_com"}}
+	cfg.Service.PathAlias = `/crime/{{slug .City}}/{{.Published.Format "2006/01"}}/{{slug .Title}}/{{.Section}}`
+
+	service, err := integration.NewService(cfg, logger.NewNopLogger(),
+		integration.WithSource(searcher),
+		integration.WithPoster(poster),
+		integration.WithTracker(tracker),
+		integration.WithLimiter(rate.NewLimiter(rate.Inf, 1)),
+	)
+	if err != nil {
+		t.Fatalf("NewService() error = %v", err)
+	}
+	if _, err := service.ProcessCity(context.Background(), cfg.Cities[0]); err != nil {
+		t.Fatalf("ProcessCity() error = %v", err)
+	}
+	want := "/crime/sudbury-com/2025/01/cafe-robbery-police-seek-suspect"
+	if posted := poster.Posted(); len(posted) != 1 || posted[0].PathAlias != want {
+		t.Errorf("posted = %+v, want path alias %q", posted, want)
+	}
+
+	cfg.Service.PathAlias = "/crime/{{.Headline}}"
+	if _, err := integration.NewService(cfg, logger.NewNopLogger(),
+		integration.WithSource(searcher),
+		integration.WithPoster(poster),
+		integration.WithTracker(tracker),
+	); err == nil {
+		t.Error("NewService() with an unknown path alias field succeeded, want error")
+	}
+}
+
+func TestProcessCity_Promotion(t *testing.T) {
+	searcher := &fakeSearcher{articles: []map[string]any{
+		{"id": "homicide", "title": "Police investigate homicide", "published_date": "2025-01-15T12:00:00Z"},
+		{"id": "theft", "title": "Police investigate theft", "published_date": "2025-01-15T11:00:00Z"},
+	}}
+	poster := &drupaltest.Poster{}
+	tracker, _ := deduptest.NewTracker(t)
+	promote, sticky := true, false
+	cfg := newTestConfig()
+	cfg.Cities = []config.CityConfig{{Name: "sudbury_com", Promote: &promote}}
+	cfg.Service.Severity.Weights = map[string]int{"homicide": 10, "theft": 2}
+	cfg.Service.Promotion = config.PromotionConfig{Sticky: &sticky, StickySeverity: 10}
+
+	service, err := integration.NewService(cfg, logger.NewNopLogger(),
+		integration.WithSource(searcher),
+		integration.WithPoster(poster),
+		integration.WithTracker(tracker),
+		integration.WithLimiter(rate.NewLimiter(rate.Inf, 1)),
+	)
+	if err != nil {
+		t.Fatalf("NewService() error = %v", err)
+	}
+	if _, err := service.ProcessCity(context.Background(), cfg.Cities[0]); err != nil {
+		t.Fatalf("ProcessCity() error = %v", err)
+	}
+
+	flags := make(map[string][2]bool)
+	for _, req := range poster.Posted() {
+		if req.Promote == nil || req.Sticky == nil {
+			t.Fatalf("posted %s with promote %v and sticky %v, want both set", req.ExternalID, req.Promote, req.Sticky)
+		}
+		flags[req.ExternalID] = [2]bool{*req.Promote, *req.Sticky}
+	}
+	want := map[string][2]bool{"homicide": {true, true}, "theft": {true, false}}
+	if !reflect.DeepEqual(flags, want) {
+		t.Errorf("promote and sticky flags = %v, want %v", flags, want)
+	}
+}
+
+func TestProcessCity_RevisionLog(t *testing.T) {
+	tests := []struct {
+		name        string
+		revisionLog string
+		want        string
+		wantErr     bool
+	}{
+		{name: "default", want: "Imported by gopost " + buildinfo.Get().Version + " from sudbury.com (es-1)"},
+		{name: "custom", revisionLog: "gopost: {{.City}} {{.URL}}", want: "gopost: sudbury_com https://sudbury.com/robbery"},
+		{name: "none", revisionLog: config.RevisionLogNone},
+		{name: "unknown field", revisionLog: "{{.Outlet}}", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			searcher := &fakeSearcher{articles: []map[string]any{
+				{"id": "es-1", "title": "Police investigate robbery", "source": "sudbury.com", "canonical_url": "https://sudbury.com/robbery"},
+			}}
+			poster := &drupaltest.Poster{}
+			tracker, _ := deduptest.NewTracker(t)
+			cfg := newTestConfig()
+			cfg.Cities = []config.CityConfig{{Name: "sudbury_com"}}
+			cfg.Drupal.RevisionLog = tt.revisionLog
+
+			service, err := integration.NewService(cfg, logger.NewNopLogger(),
+				integration.WithSource(searcher),
+				integration.WithPoster(poster),
+				integration.WithTracker(tracker),
+				integration.WithLimiter(rate.NewLimiter(rate.Inf, 1)),
+			)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("NewService() succeeded, want a revision_log error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewService() error = %v", err)
+			}
+			if _, err := service.ProcessCity(context.Background(), cfg.Cities[0]); err != nil {
+				t.Fatalf("ProcessCity() error = %v", err)
+			}
+			if posted := poster.Posted(); len(posted) != 1 || posted[0].RevisionLog != tt.want {
+				t.Errorf("posted = %+v, want revision log %q", posted, tt.want)
+			}
+		})
+	}
+}
+
+func TestProcessCity_ClassifiesTitleOnly(t *testing.T) {
+	searcher := &fakeSearcher{articles: []map[string]any{
+		{"id": "1", "title": "Robbery downtown"},
+		{"id": "2", "title": "Council meeting", "body": "A councillor mentioned last week's robbery"},
+	}}
+	poster := &drupaltest.Poster{}
+	tracker, _ := deduptest.NewTracker(t)
+	cfg := newTestConfig()
+	cfg.Cities = []config.CityConfig{{Name: "sudbury_com"}}
+	cfg.Service.Classify = config.ClassifyConfig{Fields: map[string]int{"title": 1}, MinScore: 1}
+
+	service, err := integration.NewService(cfg, logger.NewNopLogger(),
+		integration.WithSource(searcher),
+		integration.WithPoster(poster),
+		integration.WithTracker(tracker),
+		integration.WithLimiter(rate.NewLimiter(rate.Inf, 1)),
+	)
+	if err != nil {
+		t.Fatalf("NewService() error = %v", err)
+	}
+	if _, err := service.ProcessCity(context.Background(), cfg.Cities[0]); err != nil {
+		t.Fatalf("ProcessCity() error = %v", err)
+	}
+
+	encoded, err := json.Marshal(searcher.queries[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `"fields":["title^2"]`; !strings.Contains(string(encoded), want) {
+		t.Errorf("query %s does not contain %s", encoded, want)
+	}
+	if posted := poster.Posted(); len(posted) != 1 || posted[0].Title != "Robbery downtown" {
+		t.Errorf("posted = %+v, want only the article with the keyword in its title", posted)
+	}
+}
+
+func TestProcessCity_StemMatching(t *testing.T) {
+	searcher := &fakeSearcher{articles: []map[string]any{
+		{"id": "1", "title": "Two robberies downtown"},
+		{"id": "2", "title": "Robber Street reopens"},
+	}}
+	poster := &drupaltest.Poster{}
+	tracker, _ := deduptest.NewTracker(t)
+	cfg := newTestConfig()
+	cfg.Cities = []config.CityConfig{{Name: "sudbury_com"}}
+	cfg.Service.CrimeKeywords = []string{"robbery"}
+	cfg.Service.KeywordMatch = config.KeywordMatchStem
+	cfg.Service.StemSubfield = "english"
+
+	service, err := integration.NewService(cfg, logger.NewNopLogger(),
+		integration.WithSource(searcher),
+		integration.WithPoster(poster),
+		integration.WithTracker(tracker),
+		integration.WithLimiter(rate.NewLimiter(rate.Inf, 1)),
+	)
+	if err != nil {
+		t.Fatalf("NewService() error = %v", err)
+	}
+	if _, err := service.ProcessCity(context.Background(), cfg.Cities[0]); err != nil {
+		t.Fatalf("ProcessCity() error = %v", err)
+	}
+
+	encoded, err := json.Marshal(searcher.queries[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `"fields":["title^2","body","title.english^2","body.english"]`; !strings.Contains(string(encoded), want) {
+		t.Errorf("query %s does not contain %s", encoded, want)
+	}
+	if posted := poster.Posted(); len(posted) != 1 || posted[0].Title != "Two robberies downtown" {
+		t.Errorf("posted = %+v, want only the article mentioning robberies", posted)
+	}
+}
+
+func TestRun_MatchesArticlesWithPercolator(t *testing.T) {
+	esServer := estest.NewServer(t)
+	esServer.SetMapping("sudbury_com_articles", map[string]any{
+		"title": map[string]any{"type": "text"},
+		"body":  map[string]any{"type": "text"},
+	})
+	esServer.AddHits("sudbury_com_articles",
+		estest.Hit{ID: "match", Source: map[string]any{"title": "Police investigate robbery"}},
+		estest.Hit{ID: "no-match", Source: map[string]any{"title": "Police charity run"}},
+	)
+	// The percolate result staged for the query the service registers
+	esServer.AddHits("gopost_queries", estest.Hit{
+		ID:     "sudbury_com",
+		Fields: map[string]any{pipeline.PercolatorSlotField: []int{0}},
+	})
+	poster := &drupaltest.Poster{}
+	tracker, _ := deduptest.NewTracker(t)
+
+	cfg := newTestConfig()
+	cfg.Service.CheckInterval = time.Hour
+	cfg.Cities = []config.CityConfig{{Name: "sudbury_com"}}
+	cfg.Percolator = config.PercolatorConfig{Enabled: true, Index: "gopost_queries", Register: true}
+
+	service, err := integration.NewService(cfg, logger.NewNopLogger(),
+		integration.WithSource(pipeline.NewElasticsearchSource(esServer.Client(t))),
+		integration.WithPoster(poster),
+		integration.WithTracker(tracker),
+		integration.WithLimiter(rate.NewLimiter(rate.Inf, 1)),
+	)
+	if err != nil {
+		t.Fatalf("NewService() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- service.Run(ctx) }()
+	deadline := time.Now().Add(5 * time.Second)
+	for service.LastReport().StartedAt.IsZero() && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	cancel()
+	<-done
+
+	mapping := esServer.Mapping("gopost_queries")
+	if mapping["title"] == nil || !reflect.DeepEqual(mapping["query"], map[string]any{"type": "percolator"}) {
+		t.Errorf("percolator index mapping = %v, want title copied and query as percolator", mapping)
+	}
+	stored, err := json.Marshal(esServer.Documents("gopost_queries")[0].Source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(stored), `"multi_match"`) {
+		t.Errorf("registered query = %s, want the keyword search", stored)
+	}
+
+	posted := poster.Posted()
+	if len(posted) != 1 || posted[0].ExternalID != "match" {
+		t.Errorf("posted = %+v, want only the percolated match", posted)
+	}
+	for _, req := range esServer.Requests() {
+		if req.Index == "sudbury_com_articles" && strings.Contains(fmt.Sprint(req.Body), "multi_match") {
+			t.Errorf("city search %v still filters by keyword", req.Body)
+		}
+	}
+}
+
+func TestReplay_CarriesHighlights(t *testing.T) {
+	esServer := estest.NewServer(t)
+	esServer.AddHits("sudbury_com_articles", estest.Hit{
+		ID:     "es-1",
+		Source: map[string]any{"title": "Police investigate robbery", "published_date": "2025-01-15T10:30:00Z"},
+		Highlight: map[string][]string{
+			"body":  {"a <em>robbery</em> downtown"},
+			"title": {"<em>Police</em> investigate <em>robbery</em>"},
+		},
+	})
+	poster := &drupaltest.Poster{}
+	tracker, _ := deduptest.NewTracker(t)
+
+	cfg := newTestConfig()
+	cfg.Cities = []config.CityConfig{{Name: "sudbury_com"}}
+	cfg.Service.Highlight = config.HighlightConfig{Enabled: true, FragmentSize: 100, Fragments: 2}
+	cfg.Service.FieldMap = []config.FieldMapConfig{{Field: "field_editorial_note", Source: "highlights", Type: config.FieldTypeString}}
+
+	service, err := integration.NewService(cfg, logger.NewNopLogger(),
+		integration.WithSource(pipeline.NewElasticsearchSource(esServer.Client(t))),
+		integration.WithPoster(poster),
+		integration.WithTracker(tracker),
+		integration.WithLimiter(rate.NewLimiter(rate.Inf, 1)),
+	)
+	if err != nil {
+		t.Fatalf("NewService() error = %v", err)
+	}
+
+	wantFragments := []string{"<em>Police</em> investigate <em>robbery</em>", "a <em>robbery</em> downtown"}
+	preview, err := service.Preview(context.Background(), "sudbury_com")
+	if err != nil {
+		t.Fatalf("Preview() error = %v", err)
+	}
+	if len(preview) != 1 || !slices.Equal(preview[0].Highlights, wantFragments) || preview[0].AlreadyPosted {
+		t.Errorf("Preview() = %+v, want one new article with fragments %q", preview, wantFragments)
+	}
+	if highlight, ok := esServer.Requests()[0].Body["highlight"].(map[string]any); !ok || highlight["highlight_query"] == nil {
+		t.Errorf("search body highlight = %v, want a keyword highlight query", esServer.Requests()[0].Body["highlight"])
+	}
+
+	report, err := service.Replay(context.Background(), time.Time{}, "")
+	if err != nil {
+		t.Fatalf("Replay() error = %v", err)
+	}
+	wantHighlights := []integration.ArticleHighlights{{ArticleID: "es-1", Title: "Police investigate robbery", Fragments: wantFragments}}
+	if !reflect.DeepEqual(report.Cities[0].Highlights, wantHighlights) {
+		t.Errorf("report highlights = %+v, want %+v", report.Cities[0].Highlights, wantHighlights)
+	}
+	posted := poster.Posted()
+	if len(posted) != 1 || posted[0].Fields["field_editorial_note"] != strings.Join(wantFragments, " … ") {
+		t.Errorf("posted fields = %+v, want the fragments as an editorial note", posted)
+	}
+}
+
+func TestRunOnce_PingsMonitor(t *testing.T) {
+	var mu sync.Mutex
+	var pings []string
+	monitor := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		pings = append(pings, r.URL.Path)
+	}))
+	defer monitor.Close()
+
+	searcher := &fakeSearcher{articles: []map[string]any{
+		{"id": "a", "title": "Police investigate robbery"},
+	}}
+	poster := &drupaltest.Poster{Err: errors.New("drupal unavailable")}
+	tracker, _ := deduptest.NewTracker(t)
+
+	cfg := newTestConfig()
+	cfg.Cities = []config.CityConfig{{Name: "sudbury_com"}}
+	cfg.Monitoring.PingURL = monitor.URL + "/check"
+	cfg.Monitoring.PingTimeout = time.Second
+
+	service, err := integration.NewService(cfg, logger.NewNopLogger(),
+		integration.WithSource(searcher),
+		integration.WithPoster(poster),
+		integration.WithTracker(tracker),
+		integration.WithLimiter(rate.NewLimiter(rate.Inf, 1)),
+	)
+	if err != nil {
+		t.Fatalf("NewService() error = %v", err)
+	}
+
+	if _, err := service.RunOnce(context.Background()); err != nil {
+		t.Fatalf("RunOnce() error = %v", err)
+	}
+	poster.Err = nil
+	if _, err := service.RunOnce(context.Background()); err != nil {
+		t.Fatalf("RunOnce() error = %v", err)
+	}
+
+	want := []string{"/check/start", "/check/fail", "/check/start", "/check"}
+	if !slices.Equal(pings, want) {
+		t.Errorf("pings = %q, want %q", pings, want)
+	}
+}
+
+func TestRunOnce_ReportsVolumeAnomalies(t *testing.T) {
+	searcher := &fakeSearcher{}
+	poster := &drupaltest.Poster{}
+	tracker, mr := deduptest.NewTracker(t)
+
+	cfg := newTestConfig()
+	cfg.Cities = []config.CityConfig{{Name: "sudbury_com"}}
+	cfg.Service.VolumeAnomaly = config.VolumeAnomalyConfig{Window: 10, MinRuns: 3, MinAverage: 3, SpikeFactor: 10}
+
+	service, err := integration.NewService(cfg, logger.NewNopLogger(),
+		integration.WithSource(searcher),
+		integration.WithPoster(poster),
+		integration.WithTracker(tracker),
+		integration.WithLimiter(rate.NewLimiter(rate.Inf, 1)),
+		integration.WithVolumeStore(volume.NewStore(deduptest.NewClient(t, mr), deduptest.KeyPrefix)),
+	)
+	if err != nil {
+		t.Fatalf("NewService() error = %v", err)
+	}
+
+	run := func(articles int) integration.CityReport {
+		t.Helper()
+		searcher.articles = nil
+		for i := range articles {
+			searcher.articles = append(searcher.articles, map[string]any{
+				"id":    fmt.Sprintf("%s-%d", t.Name(), len(poster.Posted())+i),
+				"title": "Police investigate robbery",
+			})
+		}
+		report, err := service.RunOnce(context.Background())
+		if err != nil {
+			t.Fatalf("RunOnce() error = %v", err)
+		}
+		return report.Cities[0]
+	}
+
+	// Until min_runs runs are averaged, even nothing is usual
+	for _, articles := range []int{5, 5, 0} {
+		if city := run(articles); len(city.Anomalies) != 0 {
+			t.Fatalf("anomalies before min_runs = %+v, want none", city.Anomalies)
+		}
+	}
+	for _, articles := range []int{5, 5} {
+		run(articles)
+	}
+
+	// The index returning nothing for a city averaging 4 articles per run
+	city := run(0)
+	want := []integration.VolumeAnomaly{
+		{Metric: "found", Kind: volume.KindDrop, Count: 0, Average: 4},
+		{Metric: "posted", Kind: volume.KindDrop, Count: 0, Average: 4},
+	}
+	if !reflect.DeepEqual(city.Anomalies, want) {
+		t.Errorf("anomalies = %+v, want %+v", city.Anomalies, want)
+	}
+
+	if city := run(40); len(city.Anomalies) != 2 || city.Anomalies[0].Kind != volume.KindSpike {
+		t.Errorf("anomalies of a tenfold run = %+v, want found and posted spikes", city.Anomalies)
+	}
+}
+
+func TestRunOnce_CanaryTrialRevertsDeviatingConfig(t *testing.T) {
+	searcher := &fakeSearcher{articles: []map[string]any{
+		{"id": "robbery", "title": "Police investigate robbery"},
+		{"id": "storm", "title": "Storm warning issued"},
+	}}
+	poster := &drupaltest.Poster{}
+	tracker, mr := deduptest.NewTracker(t)
+
+	cfg := newTestConfig()
+	cfg.Cities = []config.CityConfig{{Name: "sudbury_com"}, {Name: "timmins"}}
+	cfg.Canary = config.CanaryConfig{
+		Enabled:       true,
+		Cities:        []string{"sudbury_com"},
+		Runs:          2,
+		MaxDeviation:  0.5,
+		CrimeKeywords: []string{"police", "storm"},
+	}
+
+	service, err := integration.NewService(cfg, logger.NewNopLogger(),
+		integration.WithSource(searcher),
+		integration.WithPoster(poster),
+		integration.WithTracker(tracker),
+		integration.WithLimiter(rate.NewLimiter(rate.Inf, 1)),
+		integration.WithCanaryStore(canary.NewStore(deduptest.NewClient(t, mr), deduptest.KeyPrefix)),
+	)
+	if err != nil {
+		t.Fatalf("NewService() error = %v", err)
+	}
+
+	report, err := service.RunOnce(context.Background())
+	if err != nil {
+		t.Fatalf("RunOnce() error = %v", err)
+	}
+	// The canary city searches for both keyword lists and posts with the candidate's
+	if query, _ := json.Marshal(searcher.queries[0]); !strings.Contains(string(query), "police robbery storm") {
+		t.Errorf("canary city query = %s, want both keyword lists", query)
+	}
+	var posted []string
+	for _, req := range poster.Posted() {
+		posted = append(posted, req.ExternalID)
+	}
+	if want := []string{"robbery", "storm"}; !slices.Equal(posted, want) {
+		t.Errorf("posted %v, want %v", posted, want)
+	}
+	if got := report.Cities[0].Canary; got == nil || *got != (integration.CanaryCounts{Baseline: 1, Candidate: 2}) {
+		t.Errorf("canary city counts = %+v, want baseline 1, candidate 2", got)
+	}
+	if report.Cities[1].Canary != nil {
+		t.Errorf("other city counts = %+v, want none", report.Cities[1].Canary)
+	}
+
+	// Twice the baseline volume fails the trial once its runs are done
+	if _, err := service.RunOnce(context.Background()); err != nil {
+		t.Fatalf("RunOnce() error = %v", err)
+	}
+	trial := service.Status().Canary
+	if trial == nil || trial.Verdict != canary.VerdictFailed || trial.Runs != 2 || trial.Deviation != 1 {
+		t.Fatalf("Status().Canary = %+v, want failed after 2 runs with deviation 1", trial)
+	}
+
+	// The canary city is back on the current configuration
+	searcher.articles = []map[string]any{{"id": "storm-2", "title": "Storm warning extended"}}
+	before := len(poster.Posted())
+	if _, err := service.ProcessCity(context.Background(), cfg.Cities[0]); err != nil {
+		t.Fatalf("ProcessCity() error = %v", err)
+	}
+	if posted := poster.Posted()[before:]; len(posted) != 0 {
+		t.Errorf("posted %d articles after the trial failed, want none", len(posted))
+	}
+}
+
+func TestBenchmark(t *testing.T) {
+	result, err := integration.Benchmark(context.Background(), newTestConfig(), integration.BenchmarkOptions{
+		Articles:   100,
+		Matching:   0.5,
+		Duplicates: 0.1,
+	})
+	if err != nil {
+		t.Fatalf("Benchmark() error = %v", err)
+	}
+	// 10 duplicates of posted articles, and half of the other 90 mention a keyword
+	if result.Articles != 100 || result.Posted != 45 || result.Skipped != 55 {
+		t.Errorf("Benchmark() = %d articles, %d posted, %d skipped, want 100, 45, 55",
+			result.Articles, result.Posted, result.Skipped)
+	}
+	if result.Duration <= 0 || result.ArticlesPerSecond() <= 0 || result.Allocs == 0 || result.Bytes == 0 {
+		t.Errorf("Benchmark() measurements = %+v, want non-zero", result)
+	}
+}
+
+// gatedPoster holds posts until three are in flight, recording the most that ran at once
+// and the order they finished in. The post of slowID finishes last.
+type gatedPoster struct {
+	slowID string
+
+	mu       sync.Mutex
+	inFlight int
+	peak     int
+	finished []string
+	open     chan struct{}
+	opened   sync.Once
+}
+
+func (p *gatedPoster) PostArticle(_ context.Context, req drupal.ArticleRequest) error {
+	p.mu.Lock()
+	p.inFlight++
+	p.peak = max(p.peak, p.inFlight)
+	if p.inFlight == 3 {
+		p.opened.Do(func() { close(p.open) })
+	}
+	p.mu.Unlock()
+
+	<-p.open
+	if req.ExternalID == p.slowID {
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.inFlight--
+	p.finished = append(p.finished, req.ExternalID)
+	return nil
+}
+
+func TestProcessCity_PostsConcurrently(t *testing.T) {
+	searcher := &fakeSearcher{articles: []map[string]any{
+		{"id": "a1", "title": "Police investigate robbery"},
+		{"id": "a2", "title": "Police charge man with assault"},
+		{"id": "a3", "title": "Police recover stolen car"},
+		{"id": "a4", "title": "Police seek robbery suspect"},
+	}}
+	poster := &gatedPoster{slowID: "a1", open: make(chan struct{})}
+	tracker, _ := deduptest.NewTracker(t)
+	cfg := newTestConfig()
+	cfg.Service.PostConcurrency = 3
+
+	service, err := integration.NewService(cfg, logger.NewNopLogger(),
+		integration.WithSource(searcher),
+		integration.WithPoster(poster),
+		integration.WithTracker(tracker),
+		integration.WithLimiter(rate.NewLimiter(rate.Inf, 1)),
+	)
+	if err != nil {
+		t.Fatalf("NewService() error = %v", err)
+	}
+	result, err := service.ProcessCity(context.Background(), config.CityConfig{Name: "sudbury_com"})
+	if err != nil {
+		t.Fatalf("ProcessCity() error = %v", err)
+	}
+
+	if poster.peak != 3 {
+		t.Errorf("posts in flight at once = %d, want 3", poster.peak)
+	}
+	if poster.finished[0] == "a1" {
+		t.Errorf("posts finished in order %v, want a1 after the others", poster.finished)
+	}
+	// Recorded in post order regardless of which post finished first
+	var recorded []string
+	for _, article := range result.Articles {
+		if article.Outcome != integration.ArticlePosted {
+			t.Errorf("article %s outcome = %s, want posted", article.ArticleID, article.Outcome)
+		}
+		recorded = append(recorded, article.ArticleID)
+	}
+	if want := []string{"a1", "a2", "a3", "a4"}; !slices.Equal(recorded, want) {
+		t.Errorf("recorded articles = %v, want %v", recorded, want)
+	}
+	for _, id := range recorded {
+		if !tracker.HasPosted(context.Background(), id) {
+			t.Errorf("HasPosted(%s) = false after the run", id)
+		}
+	}
+}
+
+func TestProcessCity_ConcurrentPostsDedupStoriesInFlight(t *testing.T) {
+	body := strings.Repeat("Greater Sudbury Police arrested a man after a robbery at a convenience store downtown. ", 4) +
+		"Officers found the clerk uninjured and the suspect faces several charges."
+	searcher := &fakeSearcher{articles: []map[string]any{
+		{"id": "title-a", "title": "Police investigate robbery"},
+		{"id": "title-b", "title": "Police investigate robbery"},
+		{"id": "body-a", "title": "Man arrested after robbery", "body": body},
+		{"id": "body-b", "title": "Police arrest robbery suspect", "body": "SUDBURY - " + body},
+	}}
+	// Slow posts keep each story in flight while its copy is checked
+	poster := &drupaltest.Poster{ErrFunc: func(drupal.ArticleRequest) error {
+		time.Sleep(50 * time.Millisecond)
+		return nil
+	}}
+	tracker, mr := deduptest.NewTracker(t)
+	index := dedup.NewNearDuplicateIndex(deduptest.NewClient(t, mr), deduptest.KeyPrefix, 0.9, time.Hour, logger.NewNopLogger())
+	cfg := newTestConfig()
+	cfg.Service.PostConcurrency = 2
+	cfg.Service.Titles.Dedup = true
+
+	service, err := integration.NewService(cfg, logger.NewNopLogger(),
+		integration.WithSource(searcher),
+		integration.WithPoster(poster),
+		integration.WithTracker(tracker),
+		integration.WithLimiter(rate.NewLimiter(rate.Inf, 1)),
+		integration.WithNearDuplicateIndex(index),
+	)
+	if err != nil {
+		t.Fatalf("NewService() error = %v", err)
+	}
+	if _, err := service.ProcessCity(context.Background(), config.CityConfig{Name: "sudbury_com"}); err != nil {
+		t.Fatalf("ProcessCity() error = %v", err)
+	}
+
+	var posted []string
+	for _, req := range poster.Posted() {
+		posted = append(posted, req.ExternalID)
+	}
+	slices.Sort(posted)
+	if want := []string{"body-a", "title-a"}; !slices.Equal(posted, want) {
+		t.Errorf("posted %v, want one article per story: %v", posted, want)
+	}
+}
+
+func TestProcessCity_ConcurrentPostsHeldInMaintenance(t *testing.T) {
+	searcher := &fakeSearcher{articles: []map[string]any{
+		{"id": "a1", "title": "Police investigate robbery"},
+		{"id": "a2", "title": "Police charge man with assault"},
+		{"id": "a3", "title": "Police recover stolen car"},
+		{"id": "a4", "title": "Police seek robbery suspect"},
+		{"id": "a5", "title": "Police close highway"},
+	}}
+	poster := &drupaltest.Poster{Err: drupal.ErrMaintenance}
+	tracker, _ := deduptest.NewTracker(t)
+	cfg := newTestConfig()
+	cfg.Service.PostConcurrency = 3
+	cfg.Service.Maintenance = config.MaintenanceConfig{Backoff: time.Minute, MaxBackoff: time.Hour}
+
+	service, err := integration.NewService(cfg, logger.NewNopLogger(),
+		integration.WithSource(searcher),
+		integration.WithPoster(poster),
+		integration.WithTracker(tracker),
+		integration.WithLimiter(rate.NewLimiter(rate.Inf, 1)),
+	)
+	if err != nil {
+		t.Fatalf("NewService() error = %v", err)
+	}
+	result, err := service.ProcessCity(context.Background(), config.CityConfig{Name: "sudbury_com"})
+	if err != nil {
+		t.Fatalf("ProcessCity() error = %v", err)
+	}
+
+	// However many posts were in flight when the first failed, every article is held
+	if result.Held != 5 || result.Posted != 0 || result.Errors != 0 {
+		t.Errorf("held %d, posted %d, errors %d, want 5, 0, 0", result.Held, result.Posted, result.Errors)
+	}
+	for _, id := range []string{"a1", "a2", "a3", "a4", "a5"} {
+		if tracker.HasPosted(context.Background(), id) {
+			t.Errorf("HasPosted(%s) = true, want the reservation released", id)
+		}
+	}
+}
+
+func TestProcessCity_JournalsFailedMarks(t *testing.T) {
+	searcher := &fakeSearcher{articles: []map[string]any{
+		{"id": "a1", "title": "Police investigate robbery"},
+	}}
+	tracker, mr := deduptest.NewTracker(t)
+	// Redis goes away right after the post, before the article is marked
+	poster := &drupaltest.Poster{ErrFunc: func(drupal.ArticleRequest) error {
+		mr.SetError("LOADING Redis is loading the dataset in memory")
+		return nil
+	}}
+	cfg := newTestConfig()
+	cfg.Service.MarkJournal = filepath.Join(t.TempDir(), "marks.log")
+	newService := func() *integration.Service {
+		service, err := integration.NewService(cfg, logger.NewNopLogger(),
+			integration.WithSource(searcher),
+			integration.WithPoster(poster),
+			integration.WithTracker(tracker),
+			integration.WithLimiter(rate.NewLimiter(rate.Inf, 1)),
+		)
+		if err != nil {
+			t.Fatalf("NewService() error = %v", err)
+		}
+		return service
+	}
+	city := config.CityConfig{Name: "sudbury_com"}
+
+	service := newService()
+	if _, err := service.ProcessCity(context.Background(), city); err != nil {
+		t.Fatalf("ProcessCity() error = %v", err)
+	}
+	journal, err := os.ReadFile(cfg.Service.MarkJournal)
+	if err != nil {
+		t.Fatalf("read mark journal: %v", err)
+	}
+	if string(journal) != "a1\n" {
+		t.Errorf("mark journal = %q, want %q", journal, "a1\n")
+	}
+
+	// Still unmarked in Redis, but the journal keeps the article from being posted again
+	result, err := service.ProcessCity(context.Background(), city)
+	if err != nil {
+		t.Fatalf("second ProcessCity() error = %v", err)
+	}
+	if result.Posted != 0 || poster.Attempts() != 1 {
+		t.Errorf("second run posted %d (attempts %d), want 0 (attempts 1)", result.Posted, poster.Attempts())
+	}
+
+	// A restarted service loads the journal and writes the marker once Redis is back
+	mr.SetError("")
+	result, err = newService().ProcessCity(context.Background(), city)
+	if err != nil {
+		t.Fatalf("ProcessCity() after restart error = %v", err)
+	}
+	if result.Posted != 0 || poster.Attempts() != 1 {
+		t.Errorf("run after restart posted %d (attempts %d), want 0 (attempts 1)", result.Posted, poster.Attempts())
+	}
+	entry, err := tracker.Lookup(context.Background(), "a1")
+	if err != nil {
+		t.Fatalf("Lookup() error = %v", err)
+	}
+	if entry.State != dedup.StatePosted {
+		t.Errorf("a1 dedup state = %s, want %s", entry.State, dedup.StatePosted)
+	}
+	if _, err := os.Stat(cfg.Service.MarkJournal); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("mark journal still present after the retry: %v", err)
+	}
+}
+
+func TestMigrateKeys(t *testing.T) {
+	mr := miniredis.RunT(t)
+	mustSet := func(key, value string) {
+		t.Helper()
+		if err := mr.Set(key, value); err != nil {
+			t.Fatalf("Set(%s) error = %v", key, err)
+		}
+	}
+	// Markers written before keys were prefixed, one already re-posted under the prefix
+	mustSet("posted:article:a1", "posted")
+	mr.SetTTL("posted:article:a1", time.Hour)
+	mustSet("posted:article:a2", "posted")
+	mustSet("gopost:posted:article:a2", "pending")
+	mustSet("gopost:last_check", "2025-01-15T10:00:00Z")
+	mustSet("unrelated", "kept")
+
+	cfg := newTestConfig()
+	cfg.Redis = config.RedisConfig{URL: mr.Addr(), KeyPrefix: "gopost:"}
+
+	dryRun, err := integration.MigrateKeys(context.Background(), cfg, "", true)
+	if err != nil {
+		t.Fatalf("MigrateKeys(dry run) error = %v", err)
+	}
+	if !mr.Exists("posted:article:a1") || len(dryRun.Renamed) != 1 {
+		t.Errorf("dry run renamed %v, want a1 listed and left in place", dryRun.Renamed)
+	}
+
+	migration, err := integration.MigrateKeys(context.Background(), cfg, "", false)
+	if err != nil {
+		t.Fatalf("MigrateKeys() error = %v", err)
+	}
+	if want := []string{"posted:article:a1"}; !slices.Equal(migration.Renamed, want) {
+		t.Errorf("Renamed = %v, want %v", migration.Renamed, want)
+	}
+	if want := []string{"posted:article:a2"}; !slices.Equal(migration.Skipped, want) {
+		t.Errorf("Skipped = %v, want %v", migration.Skipped, want)
+	}
+	if got, _ := mr.Get("gopost:posted:article:a1"); got != "posted" || mr.TTL("gopost:posted:article:a1") != time.Hour {
+		t.Errorf("migrated a1 = %q with TTL %v, want posted with 1h", got, mr.TTL("gopost:posted:article:a1"))
+	}
+	if got, _ := mr.Get("gopost:posted:article:a2"); got != "pending" {
+		t.Errorf("a2 under the prefix = %q, want the newer pending marker kept", got)
+	}
+
+	// Moving the environment to a prefix of its own takes every key under the old one
+	cfg.Redis.KeyPrefix = "gopost:staging:"
+	migration, err = integration.MigrateKeys(context.Background(), cfg, "gopost:", false)
+	if err != nil {
+		t.Fatalf("MigrateKeys(from gopost:) error = %v", err)
+	}
+	if len(migration.Renamed) != 3 {
+		t.Errorf("Renamed = %v, want the two markers and last_check", migration.Renamed)
+	}
+	for _, key := range []string{"gopost:staging:posted:article:a1", "gopost:staging:posted:article:a2", "gopost:staging:last_check", "unrelated"} {
+		if !mr.Exists(key) {
+			t.Errorf("key %s missing after the migration", key)
+		}
+	}
+}
+
+func TestDedupStatistics(t *testing.T) {
+	mr := miniredis.RunT(t)
+	for _, key := range []string{"gopost:posted:article:a1", "gopost:posted:article:a2", "gopost:paused"} {
+		if err := mr.Set(key, "posted"); err != nil {
+			t.Fatalf("Set(%s) error = %v", key, err)
+		}
+	}
+	mr.SetTTL("gopost:posted:article:a1", time.Hour)
+
+	cfg := newTestConfig()
+	cfg.Redis = config.RedisConfig{URL: mr.Addr(), KeyPrefix: "gopost:"}
+	all, err := integration.DedupStatistics(context.Background(), cfg, 1, []string{"a1", "missing"})
+	if err != nil {
+		t.Fatalf("DedupStatistics() error = %v", err)
+	}
+	if len(all) != 1 {
+		t.Fatalf("DedupStatistics() = %d pipelines, want 1", len(all))
+	}
+	stats := all[0]
+	if stats.Count != 2 || stats.AverageBytes <= 0 || stats.EstimatedBytes() != 2*stats.AverageBytes {
+		t.Errorf("stats = %+v, want 2 markers with a size", stats)
+	}
+	if len(stats.Oldest) != 1 || stats.Oldest[0].ID != "a1" {
+		t.Errorf("Oldest = %+v, want a1", stats.Oldest)
+	}
+	if stats.TTLs["a1"] != time.Hour || !slices.Equal(stats.Untracked, []string{"missing"}) {
+		t.Errorf("TTLs = %v, Untracked = %v; want a1 at 1h and missing untracked", stats.TTLs, stats.Untracked)
+	}
+}
@@ -0,0 +1,152 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gopost/integration/internal/config"
+	"github.com/gopost/integration/internal/es"
+	"github.com/gopost/integration/internal/logger"
+	"github.com/gopost/integration/internal/requestid"
+	"github.com/gopost/integration/pkg/drupal"
+)
+
+// smokeKeyTTL bounds how long a smoke-test Redis key survives if its deletion fails.
+const smokeKeyTTL = time.Minute
+
+// SmokeCheck is the outcome of one smoke-test step.
+type SmokeCheck struct {
+	Name     string
+	Duration time.Duration
+	Err      error // nil when the step passed
+}
+
+// SmokeReport lists the smoke-test steps in the order they ran.
+type SmokeReport struct {
+	Checks []SmokeCheck
+}
+
+// Passed reports whether every step passed.
+func (r SmokeReport) Passed() bool {
+	for _, check := range r.Checks {
+		if check.Err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *SmokeReport) run(name string, step func() error) {
+	start := time.Now()
+	err := step()
+	r.Checks = append(r.Checks, SmokeCheck{Name: name, Duration: time.Since(start), Err: err})
+}
+
+// Smoke checks the live backends end to end: a search on each city's index, creating and
+// deleting a throwaway node in smoke.group_id, and a Redis write, read, and delete. It
+// builds its own clients from cfg and leaves dedup state untouched. In multi-tenant mode
+// every tenant is checked and step names are prefixed with the tenant name.
+func Smoke(ctx context.Context, cfg *config.Config, log logger.Logger) SmokeReport {
+	var report SmokeReport
+	if len(cfg.Tenants) == 0 {
+		smokePipeline(ctx, cfg, "", log, &report)
+		return report
+	}
+	for _, tenant := range cfg.Tenants {
+		smokePipeline(ctx, cfg.ForTenant(tenant), tenant.Name+": ", log.With(logger.String("tenant", tenant.Name)), &report)
+	}
+	return report
+}
+
+func smokePipeline(ctx context.Context, cfg *config.Config, prefix string, log logger.Logger, report *SmokeReport) {
+	source, err := newElasticsearchSourceFromConfig(cfg)
+	for _, cityCfg := range cfg.Cities {
+		index := cityIndex(cityCfg)
+		report.run(prefix+"elasticsearch search "+index, func() error {
+			if err != nil {
+				return err
+			}
+			searchCtx, cancel := context.WithTimeout(ctx, esQueryTimeout)
+			defer cancel()
+			_, searchErr := source.Search(searchCtx, index, es.Search{Query: es.MatchAll{}, Size: 1}.Source())
+			return searchErr
+		})
+	}
+
+	report.run(prefix+"drupal create and delete node", func() error {
+		return smokeDrupal(ctx, cfg, log)
+	})
+
+	report.run(prefix+"redis round trip", func() error {
+		return smokeRedis(ctx, cfg)
+	})
+}
+
+// smokeDrupal posts a throwaway node to the smoke test group and deletes it again.
+func smokeDrupal(ctx context.Context, cfg *config.Config, log logger.Logger) error {
+	if cfg.Smoke.GroupID == "" {
+		return errors.New("smoke.group_id is not set")
+	}
+	client, err := drupal.NewClient(cfg.Drupal.URL, cfg.Drupal.Username, cfg.Drupal.Token, cfg.Drupal.AuthMethod, cfg.Drupal.SkipTLSVerify, log,
+		drupal.WithUserAgent(cfg.UserAgent),
+		drupal.WithProxy(cfg.Drupal.Proxy),
+	)
+	if err != nil {
+		return fmt.Errorf("drupal client: %w", err)
+	}
+
+	postCtx, cancel := context.WithTimeout(ctx, drupalPostTimeout)
+	defer cancel()
+	id, err := client.CreateArticle(postCtx, drupal.ArticleRequest{
+		Title:       "gopost smoke test " + time.Now().UTC().Format(time.RFC3339),
+		Body:        "Temporary node created by gopost smoke. It is deleted immediately.",
+		GroupID:     cfg.Smoke.GroupID,
+		GroupType:   cfg.Service.GroupType,
+		ContentType: cfg.Service.ContentType,
+		ExternalID:  "gopost-smoke-" + requestid.New(),
+	})
+	if err != nil {
+		return fmt.Errorf("create node: %w", err)
+	}
+	if id == "" {
+		return errors.New("create node: response has no node ID")
+	}
+
+	// Delete even if the caller was interrupted, so the test node does not linger
+	deleteCtx, cancelDelete := context.WithTimeout(context.WithoutCancel(ctx), drupalPostTimeout)
+	defer cancelDelete()
+	if err := client.DeleteNode(deleteCtx, cfg.Service.ContentType, id); err != nil {
+		return fmt.Errorf("delete node %s (remove it by hand): %w", id, err)
+	}
+	return nil
+}
+
+// smokeRedis writes, reads back, and deletes a temporary key.
+func smokeRedis(ctx context.Context, cfg *config.Config) error {
+	redisClient, err := newRedisClientFromConfig(cfg)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = redisClient.Close() }()
+
+	redisCtx, cancel := context.WithTimeout(ctx, redisTimeout)
+	defer cancel()
+	key := keyPrefix(cfg) + "smoke:" + requestid.New()
+	value := time.Now().UTC().Format(time.RFC3339Nano)
+	if err := redisClient.Set(redisCtx, key, value, smokeKeyTTL).Err(); err != nil {
+		return fmt.Errorf("set %s: %w", key, err)
+	}
+	got, err := redisClient.Get(redisCtx, key).Result()
+	if err != nil {
+		return fmt.Errorf("get %s: %w", key, err)
+	}
+	if got != value {
+		return fmt.Errorf("get %s: read %q, wrote %q", key, got, value)
+	}
+	if err := redisClient.Del(redisCtx, key).Err(); err != nil {
+		return fmt.Errorf("delete %s: %w", key, err)
+	}
+	return nil
+}
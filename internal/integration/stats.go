@@ -0,0 +1,126 @@
+package integration
+
+import (
+	"cmp"
+	"maps"
+	"slices"
+	"time"
+)
+
+// Stats totals the service's runs since startup, so the admin API, the health endpoint,
+// and tests can read what the runs did without parsing logs.
+type Stats struct {
+	RunsCompleted   int           `json:"runs_completed"`
+	Found           int           `json:"found"`
+	Posted          int           `json:"posted"`
+	Queued          int           `json:"queued"`
+	Skipped         int           `json:"skipped"`
+	Errors          int           `json:"errors"`
+	LastRunAt       time.Time     `json:"last_run_at,omitzero"` // When the last run started
+	LastRunDuration time.Duration `json:"last_run_duration"`
+	Cities          []CityStats   `json:"cities,omitempty"` // Sorted by city
+
+	Tenants map[string]Stats `json:"tenants,omitempty"` // Per-tenant stats in multi-tenant mode
+}
+
+// CityStats totals one city's runs since startup.
+type CityStats struct {
+	City       string `json:"city"`
+	Runs       int    `json:"runs"` // Runs that included the city
+	FailedRuns int    `json:"failed_runs"`
+	Found      int    `json:"found"`
+	Posted     int    `json:"posted"`
+	Queued     int    `json:"queued"`
+	Skipped    int    `json:"skipped"`
+	Errors     int    `json:"errors"`
+}
+
+// runTotals accumulates run reports into Stats. It is guarded by Service.mu.
+type runTotals struct {
+	stats  Stats
+	cities map[string]*CityStats
+}
+
+// record adds a completed run's counts.
+func (t *runTotals) record(report RunReport) {
+	t.stats.RunsCompleted++
+	t.stats.LastRunAt = report.StartedAt
+	t.stats.LastRunDuration = report.Duration
+	if t.cities == nil {
+		t.cities = make(map[string]*CityStats)
+	}
+	for _, city := range report.Cities {
+		t.stats.Found += city.Found
+		t.stats.Posted += city.Posted
+		t.stats.Queued += city.Queued
+		t.stats.Skipped += city.Skipped
+		t.stats.Errors += city.Errors
+
+		cityStats, ok := t.cities[city.City]
+		if !ok {
+			cityStats = &CityStats{City: city.City}
+			t.cities[city.City] = cityStats
+		}
+		cityStats.Runs++
+		if city.Failed {
+			cityStats.FailedRuns++
+		}
+		cityStats.Found += city.Found
+		cityStats.Posted += city.Posted
+		cityStats.Queued += city.Queued
+		cityStats.Skipped += city.Skipped
+		cityStats.Errors += city.Errors
+	}
+}
+
+// snapshot returns a copy of the totals.
+func (t *runTotals) snapshot() Stats {
+	stats := t.stats
+	stats.Cities = make([]CityStats, 0, len(t.cities))
+	for _, city := range t.cities {
+		stats.Cities = append(stats.Cities, *city)
+	}
+	slices.SortFunc(stats.Cities, func(a, b CityStats) int { return cmp.Compare(a.City, b.City) })
+	return stats
+}
+
+// Stats returns the service's run totals since startup. It is safe to call while Run is
+// active.
+func (s *Service) Stats() Stats {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.totals.snapshot()
+}
+
+// Stats returns the run totals of every tenant, summed, with each tenant's own stats,
+// including its cities, under Tenants.
+func (g *Group) Stats() Stats {
+	tenants := make(map[string]Stats, len(g.names))
+	for _, name := range g.names {
+		tenants[name] = g.services[name].Stats()
+	}
+	return sumTenants(tenants)
+}
+
+// sumTenants returns the totals of tenants' stats, with the stats themselves under Tenants.
+func sumTenants(tenants map[string]Stats) Stats {
+	stats := Stats{Tenants: tenants}
+	for _, name := range slices.Sorted(maps.Keys(tenants)) {
+		stats.add(tenants[name])
+	}
+	return stats
+}
+
+// add accumulates another tenant's totals. Its cities stay with the tenant.
+func (s *Stats) add(other Stats) {
+	s.RunsCompleted += other.RunsCompleted
+	s.Found += other.Found
+	s.Posted += other.Posted
+	s.Queued += other.Queued
+	s.Skipped += other.Skipped
+	s.Errors += other.Errors
+	if other.LastRunAt.After(s.LastRunAt) {
+		s.LastRunAt = other.LastRunAt
+		s.LastRunDuration = other.LastRunDuration
+	}
+}
@@ -0,0 +1,66 @@
+package integration
+
+import (
+	"time"
+
+	"github.com/gopost/integration/internal/canary"
+	"github.com/gopost/integration/internal/connstats"
+)
+
+// Status is a point-in-time view of the service for operational endpoints.
+type Status struct {
+	LastCheck     time.Time `json:"last_check"`
+	LastReport    RunReport `json:"last_report"`
+	CityCount     int       `json:"city_count"`
+	OutboxEnabled bool      `json:"outbox_enabled"`
+
+	Maintenance *MaintenanceStatus `json:"maintenance,omitempty"` // Set while Drupal is in maintenance mode
+	Credentials *CredentialStatus  `json:"credentials,omitempty"` // Set while Drupal rejects the credentials
+	Health      Health             `json:"health"`                // In multi-tenant mode, that of the least healthy tenant
+	Stats       Stats              `json:"stats"`                 // Run totals since startup; in multi-tenant mode, summed over tenants
+
+	ConnStats *connstats.Snapshot `json:"-"` // Drupal connection stats when drupal.conn_stats is set, served on /metrics
+
+	Moderation []ModerationCount `json:"moderation,omitempty"` // Editorial decisions received by the moderation webhook since startup
+	Canary     *canary.Trial     `json:"canary,omitempty"`     // The canary trial, when canary.enabled is set
+
+	Tenants map[string]Status `json:"tenants,omitempty"` // Per-tenant status in multi-tenant mode
+}
+
+// Status returns the current service status. It is safe to call while Run is active.
+func (s *Service) Status() Status {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return Status{
+		LastCheck:     s.lastCheckTS,
+		LastReport:    s.lastReport,
+		CityCount:     len(s.config.Cities) + len(s.discovered),
+		OutboxEnabled: s.queue != nil,
+		Maintenance:   s.maintenanceStatus(),
+		Credentials:   s.credentialStatus(),
+		Health:        s.health(),
+		Stats:         s.totals.snapshot(),
+		ConnStats:     s.connStatsSnapshot(),
+		Moderation:    s.moderation.snapshot(),
+		Canary:        s.canaryStatus(),
+	}
+}
+
+// Healthy reports whether runs are still completing: the last run (or service creation,
+// before the first run) finished no more than check_interval plus grace ago. A stuck run
+// makes it false, which the systemd watchdog uses to restart the service.
+func (s *Service) Healthy(grace time.Duration) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return time.Since(s.lastRunEnd) <= s.config.Service.CheckInterval+grace
+}
+
+// connStatsSnapshot returns the Drupal connection stats, or nil unless drupal.conn_stats
+// is set.
+func (s *Service) connStatsSnapshot() *connstats.Snapshot {
+	if s.connStats == nil {
+		return nil
+	}
+	return s.connStats.Snapshot()
+}
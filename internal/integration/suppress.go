@@ -0,0 +1,93 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/gopost/integration/internal/config"
+	"github.com/gopost/integration/internal/logger"
+	"github.com/gopost/integration/pkg/pipeline"
+)
+
+// errNoSuppressionList is returned by Suppress and Unsuppress when suppression is disabled.
+var errNoSuppressionList = errors.New("suppression list unavailable (suppression.enabled is not set)")
+
+// suppressedArticle reports whether the article is on the suppression list. When Redis
+// cannot be read the article continues; reserving it for posting fails the same way.
+func (s *Service) suppressedArticle(ctx context.Context, cityCfg config.CityConfig, article *pipeline.Article) bool {
+	if s.suppressed == nil {
+		return false
+	}
+
+	checkCtx, cancel := context.WithTimeout(ctx, redisTimeout)
+	defer cancel()
+	suppressed, err := s.suppressed.Contains(checkCtx, article.ID)
+	if err != nil {
+		s.logger.Warn("Failed to check suppression list, continuing",
+			logger.String("article_id", article.ID),
+			logger.String("city", cityCfg.Name),
+			logger.Error(err),
+		)
+		return false
+	}
+	if suppressed {
+		s.logger.Debug("Article skipped - suppressed",
+			logger.String("article_id", article.ID),
+			logger.String("city", cityCfg.Name),
+			logger.String("title", article.Title),
+		)
+	}
+	return suppressed
+}
+
+// Suppress puts an article on the suppression list, so it is never posted again, on every
+// replica sharing the Redis database.
+func (s *Service) Suppress(ctx context.Context, articleID string) error {
+	if s.suppressed == nil {
+		return errNoSuppressionList
+	}
+	if err := s.suppressed.Add(ctx, articleID); err != nil {
+		return err
+	}
+	s.logger.Info("Article suppressed",
+		logger.String("article_id", articleID),
+	)
+	return nil
+}
+
+// Unsuppress takes an article off the suppression list. It stays marked as posted until
+// its dedup marker expires.
+func (s *Service) Unsuppress(ctx context.Context, articleID string) error {
+	if s.suppressed == nil {
+		return errNoSuppressionList
+	}
+	if err := s.suppressed.Remove(ctx, articleID); err != nil {
+		return err
+	}
+	s.logger.Info("Article suppression lifted",
+		logger.String("article_id", articleID),
+	)
+	return nil
+}
+
+// Suppress suppresses the article in every tenant, since a webhook does not say which
+// tenant's Drupal site deleted it.
+func (g *Group) Suppress(ctx context.Context, articleID string) error {
+	for _, name := range g.names {
+		if err := g.services[name].Suppress(ctx, articleID); err != nil {
+			return fmt.Errorf("tenant %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// Unsuppress lifts the article's suppression in every tenant.
+func (g *Group) Unsuppress(ctx context.Context, articleID string) error {
+	for _, name := range g.names {
+		if err := g.services[name].Unsuppress(ctx, articleID); err != nil {
+			return fmt.Errorf("tenant %s: %w", name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gopost/integration/internal/config"
+	"github.com/gopost/integration/internal/logger"
+	"github.com/gopost/integration/pkg/pipeline"
+)
+
+// newestMatching returns the published date of the newest article in the city's index
+// matching its search criteria, whatever the search window, or the zero time if none does.
+func (s *Service) newestMatching(ctx context.Context, cityCfg config.CityConfig) (time.Time, error) {
+	criteria, err := s.criteriaQuery(cityCfg)
+	if err != nil {
+		return time.Time{}, err
+	}
+	query := map[string]any{
+		"size":  1,
+		"query": criteria,
+		"sort": []map[string]any{
+			{pipeline.ESFieldPublishedDate: map[string]any{"order": "desc", "unmapped_type": "date"}},
+		},
+	}
+
+	searchCtx, cancel := context.WithTimeout(ctx, esQueryTimeout)
+	defer cancel()
+	result, err := s.source.Search(searchCtx, cityIndex(cityCfg), query)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("search newest article: %w", err)
+	}
+	var articles []pipeline.Article
+	for _, hit := range result.Hits {
+		if article, decodeErr := pipeline.DecodeArticle(hit.Source, s.dates); decodeErr == nil {
+			articles = append(articles, article)
+		}
+	}
+	return newestPublished(articles), nil
+}
+
+// measureSyncLag returns how far the newest article matching the city's search is ahead of
+// the newest article posted for it, when service.sync_lag is enabled. It returns nil when
+// the lag cannot be measured: the search or the lookup failed, or nothing matched or was
+// posted yet.
+func (s *Service) measureSyncLag(ctx context.Context, cityCfg config.CityConfig) *time.Duration {
+	if !s.config.Service.SyncLag.Enabled || s.checkpoints == nil {
+		return nil
+	}
+
+	newest, err := s.newestMatching(ctx, cityCfg)
+	if err != nil {
+		s.logger.Warn("Failed to measure sync lag",
+			logger.String("city", cityCfg.Name),
+			logger.Error(err),
+		)
+		return nil
+	}
+	loadCtx, cancel := context.WithTimeout(ctx, redisTimeout)
+	defer cancel()
+	posted, err := s.checkpoints.NewestPosted(loadCtx, cityCfg.Name)
+	if err != nil {
+		s.logger.Warn("Failed to measure sync lag",
+			logger.String("city", cityCfg.Name),
+			logger.Error(err),
+		)
+		return nil
+	}
+	if newest.IsZero() || posted.IsZero() {
+		return nil
+	}
+
+	lag := max(newest.Sub(posted), 0)
+	fields := []logger.Field{
+		logger.String("city", cityCfg.Name),
+		logger.Duration("sync_lag", lag),
+		logger.Time("newest_matching", newest),
+		logger.Time("newest_posted", posted),
+	}
+	if lag > s.config.Service.SyncLag.WarnAfter {
+		s.logger.Warn("City sync lagging behind Elasticsearch", fields...)
+	} else {
+		s.logger.Debug("City sync lag measured", fields...)
+	}
+	return &lag
+}
+
+// recordNewestPosted advances the city's newest posted time for sync lag measurement.
+// Failures are logged only; the lag then reads high until a newer post is recorded.
+func (s *Service) recordNewestPosted(ctx context.Context, cityCfg config.CityConfig, article *pipeline.Article) {
+	if !s.config.Service.SyncLag.Enabled || s.checkpoints == nil || article.PublishedAt.IsZero() {
+		return
+	}
+	if err := s.checkpoints.RecordPosted(ctx, cityCfg.Name, article.PublishedAt); err != nil {
+		logger.FromContext(ctx).Warn("Failed to record newest posted article",
+			logger.String("article_id", article.ID),
+			logger.Error(err),
+		)
+	}
+}
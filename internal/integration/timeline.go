@@ -0,0 +1,102 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gopost/integration/internal/config"
+	"github.com/gopost/integration/internal/logger"
+	"github.com/gopost/integration/internal/timeline"
+	"github.com/gopost/integration/pkg/pipeline"
+)
+
+// timelineEntry returns the event of article reaching stage now in the city.
+func timelineEntry(cityCfg config.CityConfig, article *pipeline.Article, stage, detail string) timeline.Entry {
+	return timeline.Entry{
+		ArticleID: article.ID,
+		Event:     timeline.Event{Stage: stage, At: time.Now(), City: cityCfg.Name, Detail: detail},
+	}
+}
+
+// recordTimeline saves entries when article timelines are enabled. Failures are logged
+// only; a missing timeline never holds up posting.
+func (s *Service) recordTimeline(ctx context.Context, entries ...timeline.Entry) {
+	if s.timeline == nil || len(entries) == 0 {
+		return
+	}
+
+	// Use a fresh context so events of posts cut short by shutdown are still recorded
+	recordCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), redisTimeout)
+	defer cancel()
+	if err := s.timeline.Record(recordCtx, entries...); err != nil {
+		s.logger.Warn("Failed to record article timeline",
+			logger.String("article_id", entries[0].ArticleID),
+			logger.Int("events", len(entries)),
+			logger.Error(err),
+		)
+	}
+}
+
+// recordStage records that article reached stage in the city.
+func (s *Service) recordStage(ctx context.Context, cityCfg config.CityConfig, article *pipeline.Article, stage, detail string) {
+	if s.timeline == nil {
+		return
+	}
+	s.recordTimeline(ctx, timelineEntry(cityCfg, article, stage, detail))
+}
+
+// recordFound records when the city's search found each article, and when each was
+// published, in one round trip.
+func (s *Service) recordFound(ctx context.Context, cityCfg config.CityConfig, articles []pipeline.Article) {
+	if s.timeline == nil {
+		return
+	}
+	entries := make([]timeline.Entry, 0, 2*len(articles))
+	for i := range articles {
+		article := &articles[i]
+		if !article.PublishedAt.IsZero() {
+			entries = append(entries, timeline.Entry{
+				ArticleID: article.ID,
+				Event:     timeline.Event{Stage: timeline.StagePublished, At: article.PublishedAt, City: cityCfg.Name},
+			})
+		}
+		entries = append(entries, timelineEntry(cityCfg, article, timeline.StageFound, ""))
+	}
+	s.recordTimeline(ctx, entries...)
+}
+
+// ReadTimeline returns the recorded stages of an article, oldest first, without building
+// the rest of the pipeline. In multi-tenant mode it reads every tenant's Redis database
+// and prefixes city names with the tenant name.
+func ReadTimeline(ctx context.Context, cfg *config.Config, articleID string) ([]timeline.Event, error) {
+	if len(cfg.Tenants) == 0 {
+		return readTimeline(ctx, cfg, articleID)
+	}
+
+	var events []timeline.Event
+	for _, tenant := range cfg.Tenants {
+		tenantEvents, err := readTimeline(ctx, cfg.ForTenant(tenant), articleID)
+		if err != nil {
+			return nil, fmt.Errorf("tenant %s: %w", tenant.Name, err)
+		}
+		for _, event := range tenantEvents {
+			if event.City != "" {
+				event.City = tenant.Name + "/" + event.City
+			}
+			events = append(events, event)
+		}
+	}
+	timeline.Sort(events)
+	return events, nil
+}
+
+func readTimeline(ctx context.Context, cfg *config.Config, articleID string) ([]timeline.Event, error) {
+	redisClient, err := newRedisClientFromConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = redisClient.Close() }()
+
+	return timeline.NewStore(redisClient, keyPrefix(cfg), cfg.Timeline.TTL).Load(ctx, articleID)
+}
@@ -0,0 +1,81 @@
+package integration
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gopost/integration/internal/config"
+	"github.com/gopost/integration/internal/logger"
+	"github.com/gopost/integration/internal/translate"
+	"github.com/gopost/integration/pkg/pipeline"
+)
+
+// usesTranslation reports whether any configured city sets translate_to.
+func usesTranslation(cfg *config.Config) bool {
+	for _, cityCfg := range cfg.Cities {
+		if cityCfg.TranslateTo != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// translateArticle returns a copy of article with its title, body, intro, description,
+// and Open Graph text machine-translated into the city's translate_to language, or
+// article itself for cities without one. The body is translated as HTML.
+func (s *Service) translateArticle(ctx context.Context, cityCfg config.CityConfig, article *pipeline.Article) (*pipeline.Article, error) {
+	if cityCfg.TranslateTo == "" || s.translator == nil {
+		return article, nil
+	}
+	translated := *article
+	startTime := time.Now()
+
+	// Empty fields are left out rather than sent for translation
+	var texts []*string
+	for _, field := range []*string{&translated.Title, &translated.Intro, &translated.Description, &translated.OGTitle, &translated.OGDescription} {
+		if *field != "" {
+			texts = append(texts, field)
+		}
+	}
+	if err := s.translateFields(ctx, cityCfg, texts, false); err != nil {
+		return nil, err
+	}
+	if translated.Content != "" {
+		if err := s.translateFields(ctx, cityCfg, []*string{&translated.Content}, true); err != nil {
+			return nil, err
+		}
+	}
+
+	s.logger.Debug("Article translated",
+		logger.String("article_id", article.ID),
+		logger.String("city", cityCfg.Name),
+		logger.String("source_language", cityCfg.TranslateFrom),
+		logger.String("target_language", cityCfg.TranslateTo),
+		logger.String("translated_title", translated.Title),
+		logger.Duration("translate_duration", time.Since(startTime)),
+	)
+	return &translated, nil
+}
+
+// translateFields replaces each field with its translation.
+func (s *Service) translateFields(ctx context.Context, cityCfg config.CityConfig, fields []*string, html bool) error {
+	if len(fields) == 0 {
+		return nil
+	}
+	req := translate.Request{Source: cityCfg.TranslateFrom, Target: cityCfg.TranslateTo, HTML: html}
+	for _, field := range fields {
+		req.Texts = append(req.Texts, *field)
+	}
+	translations, err := s.translator.Translate(ctx, req)
+	if err != nil {
+		return fmt.Errorf("translate to %s: %w", cityCfg.TranslateTo, err)
+	}
+	if len(translations) != len(fields) {
+		return fmt.Errorf("translate to %s: got %d translations for %d texts", cityCfg.TranslateTo, len(translations), len(fields))
+	}
+	for i, field := range fields {
+		*field = translations[i]
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package integration
+
+import (
+	"context"
+
+	"github.com/gopost/integration/internal/logger"
+	"github.com/gopost/integration/internal/volume"
+)
+
+// VolumeAnomaly is an article count of a city's run far outside the city's rolling average.
+type VolumeAnomaly struct {
+	Metric  string  `json:"metric"` // "found", or "posted" for articles posted or queued
+	Kind    string  `json:"kind"`   // volume.KindDrop or volume.KindSpike
+	Count   int     `json:"count"`
+	Average float64 `json:"average"`
+}
+
+// checkVolumes compares each city's counts in the run with its rolling average, adds the
+// anomalies to the city reports and logs them, then averages the counts in. Cities whose
+// search failed or that were paused are left out, as are runs whose window catchUp
+// expanded to cover downtime. Failures to read or save the averages are logged only.
+func (s *Service) checkVolumes(ctx context.Context, report *RunReport) {
+	s.mu.Lock()
+	catchingUp := s.catchingUp
+	s.catchingUp = false
+	s.mu.Unlock()
+	if s.volumes == nil || catchingUp {
+		return
+	}
+
+	var cities []string
+	for _, city := range report.Cities {
+		if !city.Failed && !city.Paused && city.ClosedIndex == "" {
+			cities = append(cities, city.City)
+		}
+	}
+	loadCtx, cancel := context.WithTimeout(ctx, redisTimeout)
+	baselines, err := s.volumes.Load(loadCtx, cities)
+	cancel()
+	if err != nil {
+		s.logger.Warn("Failed to load volume baselines",
+			logger.Error(err),
+		)
+		return
+	}
+
+	cfg := s.config.Service.VolumeAnomaly
+	for i := range report.Cities {
+		city := &report.Cities[i]
+		if city.Failed || city.Paused || city.ClosedIndex != "" {
+			continue
+		}
+		check := func(metric string, count int, average float64) {
+			kind := volume.Check(count, average, cfg.MinAverage, cfg.SpikeFactor)
+			if kind == "" {
+				return
+			}
+			city.Anomalies = append(city.Anomalies, VolumeAnomaly{Metric: metric, Kind: kind, Count: count, Average: average})
+			s.logger.Warn("City volume anomaly - check the index and its mapping",
+				logger.String("city", city.City),
+				logger.String("metric", metric),
+				logger.String("kind", kind),
+				logger.Int("count", count),
+				logger.Float64("average", average),
+			)
+		}
+
+		baseline := baselines[city.City]
+		posted := city.Posted + city.Queued
+		if baseline.Runs >= cfg.MinRuns {
+			check("found", city.Found, baseline.Found)
+			// Posts held for Drupal maintenance are expected to drop
+			if city.Held == 0 {
+				check("posted", posted, baseline.Posted)
+			}
+		}
+		baselines[city.City] = baseline.Add(city.Found, posted, cfg.Window)
+	}
+
+	saveCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), redisTimeout)
+	defer cancel()
+	if err := s.volumes.Save(saveCtx, baselines); err != nil {
+		s.logger.Warn("Failed to save volume baselines",
+			logger.Error(err),
+		)
+	}
+}
@@ -0,0 +1,84 @@
+package integration
+
+import (
+	"context"
+
+	"github.com/gopost/integration/internal/config"
+	"github.com/gopost/integration/internal/logger"
+)
+
+// warmupLimit returns the maximum number of articles the city may post this run, or 0 when
+// it is not warming up. If the run count cannot be read, the city is not limited.
+func (s *Service) warmupLimit(ctx context.Context, cityCfg config.CityConfig) int {
+	if s.warmups == nil {
+		return 0
+	}
+
+	warmupCtx, cancel := context.WithTimeout(ctx, redisTimeout)
+	defer cancel()
+	runs, err := s.warmups.Runs(warmupCtx, cityCfg.Name)
+	if err != nil {
+		s.logger.Warn("Failed to load warm-up runs, posting without limit",
+			logger.String("city", cityCfg.Name),
+			logger.Error(err),
+		)
+		return 0
+	}
+	if runs >= s.config.Service.Warmup.Runs {
+		return 0
+	}
+
+	s.logger.Debug("City warming up",
+		logger.String("city", cityCfg.Name),
+		logger.Int("warmup_run", runs+1),
+		logger.Int("warmup_runs", s.config.Service.Warmup.Runs),
+		logger.Int("max_posts", s.config.Service.Warmup.MaxPosts),
+	)
+	return s.config.Service.Warmup.MaxPosts
+}
+
+// recordWarmupRun counts a completed warm-up run for the city. Failures are logged only;
+// the city then stays in warm-up one run longer.
+func (s *Service) recordWarmupRun(ctx context.Context, cityCfg config.CityConfig) {
+	recordCtx, cancel := context.WithTimeout(ctx, redisTimeout)
+	defer cancel()
+	if err := s.warmups.Record(recordCtx, cityCfg.Name); err != nil {
+		s.logger.Warn("Failed to record warm-up run",
+			logger.String("city", cityCfg.Name),
+			logger.Error(err),
+		)
+	}
+}
+
+// adoptWarmupCities marks the current cities as established when warm-up is turned on for
+// a service that has run before, so only cities enabled afterwards warm up. On a fresh
+// install (no saved last check time) every city warms up.
+func (s *Service) adoptWarmupCities(ctx context.Context) {
+	if s.warmups == nil || s.checkpoints == nil {
+		return
+	}
+
+	adoptCtx, cancel := context.WithTimeout(ctx, redisTimeout)
+	defer cancel()
+	lastCheck, err := s.checkpoints.Load(adoptCtx)
+	if err != nil || lastCheck.IsZero() {
+		return
+	}
+
+	var names []string
+	for _, cityCfg := range s.refreshCities(ctx) {
+		names = append(names, cityCfg.Name)
+	}
+	adopted, err := s.warmups.Adopt(adoptCtx, names, s.config.Service.Warmup.Runs)
+	if err != nil {
+		s.logger.Warn("Failed to adopt existing cities for warm-up",
+			logger.Error(err),
+		)
+		return
+	}
+	if adopted {
+		s.logger.Info("Existing cities marked as warmed up",
+			logger.Int("city_count", len(names)),
+		)
+	}
+}
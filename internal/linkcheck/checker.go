@@ -0,0 +1,192 @@
+// Package linkcheck verifies that article links still resolve before they are posted.
+package linkcheck
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gopost/integration/internal/logger"
+	"golang.org/x/time/rate"
+)
+
+// DefaultPaywallPatterns are matched against the final URL of a redirected link when no
+// patterns are configured.
+var DefaultPaywallPatterns = []string{"paywall", "subscribe", "/login", "/signin", "/register"}
+
+// Result describes the health of a link.
+type Result struct {
+	StatusCode int    // Status of the final response
+	FinalURL   string // URL after redirects
+	Broken     bool   // The link is gone or redirects to a paywall interstitial
+	Reason     string // Why the link is broken, e.g. "status 404"
+}
+
+// Checker HEADs links with a timeout, limits requests per domain, and caches results.
+type Checker struct {
+	client          *http.Client
+	userAgent       string
+	perDomain       rate.Limit
+	cacheTTL        time.Duration
+	paywallPatterns []string
+	logger          logger.Logger
+
+	mu       sync.Mutex
+	limiters map[string]*rate.Limiter
+	cache    map[string]cachedResult
+}
+
+type cachedResult struct {
+	result  Result
+	expires time.Time
+}
+
+// NewChecker returns a checker. perDomainRPS limits requests to each host, cacheTTL is how
+// long results are reused, and paywallPatterns (DefaultPaywallPatterns if empty) are
+// case-insensitive substrings identifying paywall redirect targets. An empty userAgent
+// keeps Go's default.
+func NewChecker(timeout time.Duration, perDomainRPS float64, cacheTTL time.Duration, paywallPatterns []string, userAgent string, log logger.Logger) *Checker {
+	if len(paywallPatterns) == 0 {
+		paywallPatterns = DefaultPaywallPatterns
+	}
+	lower := make([]string, 0, len(paywallPatterns))
+	for _, pattern := range paywallPatterns {
+		if pattern = strings.ToLower(strings.TrimSpace(pattern)); pattern != "" {
+			lower = append(lower, pattern)
+		}
+	}
+
+	perDomain := rate.Inf
+	if perDomainRPS > 0 {
+		perDomain = rate.Limit(perDomainRPS)
+	}
+	return &Checker{
+		client:          &http.Client{Timeout: timeout},
+		userAgent:       userAgent,
+		perDomain:       perDomain,
+		cacheTTL:        cacheTTL,
+		paywallPatterns: lower,
+		logger:          log,
+		limiters:        make(map[string]*rate.Limiter),
+		cache:           make(map[string]cachedResult),
+	}
+}
+
+// Check reports whether link is broken. Errors mean the health is unknown (timeouts,
+// connection failures) and are not cached.
+func (c *Checker) Check(ctx context.Context, link string) (Result, error) {
+	if result, ok := c.cached(link); ok {
+		return result, nil
+	}
+
+	parsed, err := url.Parse(link)
+	if err != nil || parsed.Hostname() == "" {
+		return Result{}, fmt.Errorf("check link %q: not an absolute URL", link)
+	}
+	if err := c.limiter(parsed.Hostname()).Wait(ctx); err != nil {
+		return Result{}, fmt.Errorf("check link rate limit: %w", err)
+	}
+
+	resp, err := c.do(ctx, http.MethodHead, link)
+	if err == nil && (resp.StatusCode == http.StatusMethodNotAllowed || resp.StatusCode == http.StatusNotImplemented) {
+		// Some servers refuse HEAD; the body is not read, so GET costs little more
+		resp.Body.Close()
+		resp, err = c.do(ctx, http.MethodGet, link)
+	}
+	if err != nil {
+		return Result{}, fmt.Errorf("check link %q: %w", link, err)
+	}
+	resp.Body.Close()
+
+	result := Result{
+		StatusCode: resp.StatusCode,
+		FinalURL:   resp.Request.URL.String(),
+	}
+	switch {
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+		result.Broken = true
+		result.Reason = fmt.Sprintf("status %d", resp.StatusCode)
+	case result.FinalURL != link && c.isPaywall(result.FinalURL):
+		result.Broken = true
+		result.Reason = "redirected to paywall"
+	}
+
+	c.logger.Debug("Link checked",
+		logger.String("url", link),
+		logger.String("final_url", result.FinalURL),
+		logger.Int("status_code", result.StatusCode),
+		logger.Bool("broken", result.Broken),
+	)
+	c.store(link, result)
+	return result, nil
+}
+
+func (c *Checker) do(ctx context.Context, method, link string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, link, http.NoBody)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	if c.userAgent != "" {
+		req.Header.Set("User-Agent", c.userAgent)
+	}
+	return c.client.Do(req)
+}
+
+func (c *Checker) isPaywall(finalURL string) bool {
+	finalURL = strings.ToLower(finalURL)
+	for _, pattern := range c.paywallPatterns {
+		if strings.Contains(finalURL, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Checker) limiter(host string) *rate.Limiter {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	limiter, ok := c.limiters[host]
+	if !ok {
+		limiter = rate.NewLimiter(c.perDomain, 1)
+		c.limiters[host] = limiter
+	}
+	return limiter
+}
+
+func (c *Checker) cached(link string) (Result, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.cache[link]
+	if !ok {
+		return Result{}, false
+	}
+	if time.Now().After(entry.expires) {
+		delete(c.cache, link)
+		return Result{}, false
+	}
+	return entry.result, true
+}
+
+func (c *Checker) store(link string, result Result) {
+	if c.cacheTTL <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	// Drop expired entries so the cache does not grow without bound in a long-running daemon
+	for key, entry := range c.cache {
+		if now.After(entry.expires) {
+			delete(c.cache, key)
+		}
+	}
+	c.cache[link] = cachedResult{result: result, expires: now.Add(c.cacheTTL)}
+}
@@ -0,0 +1,81 @@
+package linkcheck_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gopost/integration/internal/linkcheck"
+	"github.com/gopost/integration/internal/logger"
+)
+
+func TestChecker_Check(t *testing.T) {
+	var requests atomic.Int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+	})
+	mux.HandleFunc("/gone", func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		http.NotFound(w, r)
+	})
+	mux.HandleFunc("/locked", func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		http.Redirect(w, r, "/subscribe?return=/locked", http.StatusFound)
+	})
+	mux.HandleFunc("/subscribe", func(w http.ResponseWriter, r *http.Request) {})
+	mux.HandleFunc("/no-head", func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.Method == http.MethodHead {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	checker := linkcheck.NewChecker(time.Second, 0, time.Hour, nil, "gopost/test", logger.NewNopLogger())
+
+	tests := []struct {
+		path       string
+		wantBroken bool
+		wantStatus int
+	}{
+		{"/ok", false, http.StatusOK},
+		{"/gone", true, http.StatusNotFound},
+		{"/locked", true, http.StatusOK},
+		{"/no-head", false, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			result, err := checker.Check(context.Background(), server.URL+tt.path)
+			if err != nil {
+				t.Fatalf("Check() error = %v", err)
+			}
+			if result.Broken != tt.wantBroken || result.StatusCode != tt.wantStatus {
+				t.Errorf("Check() = %+v, want broken=%v status=%d", result, tt.wantBroken, tt.wantStatus)
+			}
+		})
+	}
+
+	before := requests.Load()
+	if _, err := checker.Check(context.Background(), server.URL+"/gone"); err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+	if requests.Load() != before {
+		t.Error("cached link was requested again")
+	}
+}
+
+func TestChecker_UnreachableIsAnError(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	url := server.URL
+	server.Close()
+
+	checker := linkcheck.NewChecker(time.Second, 0, time.Hour, nil, "", logger.NewNopLogger())
+	if _, err := checker.Check(context.Background(), url+"/a"); err == nil {
+		t.Error("Check() error = nil, want connection error")
+	}
+}
@@ -0,0 +1,24 @@
+package logger
+
+import "context"
+
+// contextKey is the context key under which WithContext stores a Logger.
+type contextKey struct{}
+
+// WithContext returns a copy of ctx carrying l.
+// Attach a logger that already holds the correlation fields of the current scope
+// (run, city, article) so code deeper in the call tree logs with them via FromContext.
+// Example: ctx = logger.WithContext(ctx, log.With(String("run_id", runID)))
+func WithContext(ctx context.Context, l Logger) context.Context {
+	return context.WithValue(ctx, contextKey{}, l)
+}
+
+// FromContext returns the logger attached to ctx by WithContext.
+// If no logger is attached, it returns a no-op logger, so callers never need a nil check;
+// attach a logger at each entry point whose callees log through FromContext.
+func FromContext(ctx context.Context) Logger {
+	if l, ok := ctx.Value(contextKey{}).(Logger); ok && l != nil {
+		return l
+	}
+	return NewNopLogger()
+}
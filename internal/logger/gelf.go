@@ -0,0 +1,268 @@
+package logger
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"os"
+	"regexp"
+	"slices"
+	"sync"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// GELF compression methods for UDP. TCP messages are never compressed.
+const (
+	GELFCompressionGzip = "gzip"
+	GELFCompressionZlib = "zlib"
+	GELFCompressionNone = "none"
+)
+
+// GELF chunking limits: a chunk is at most gelfChunkSize bytes including its
+// gelfChunkHeader-byte header, and a message at most gelfMaxChunks chunks.
+const (
+	gelfChunkSize   = 8192
+	gelfChunkHeader = 12
+	gelfMaxChunks   = 128
+)
+
+// gelfFieldName matches the characters GELF allows in additional field names.
+var gelfFieldName = regexp.MustCompile(`[^\w.\-]`)
+
+// GELF ships log entries to a Graylog or Logstash GELF input, alongside the regular
+// output.
+type GELF struct {
+	// Network is "udp" (the default) or "tcp".
+	Network string
+
+	// Address is the GELF input's host:port, e.g. "graylog:12201".
+	Address string
+
+	// Compression is the UDP compression: GELFCompressionGzip (the default),
+	// GELFCompressionZlib, or GELFCompressionNone.
+	Compression string
+
+	// Host is the message's host field. Empty uses the hostname.
+	Host string
+}
+
+// WithGELF also sends every entry to the GELF input g, as GELF 1.1 messages with each log
+// field as an additional field (e.g. article_id as _article_id).
+func WithGELF(g GELF) Option {
+	return func(o *options) {
+		o.gelf = &g
+	}
+}
+
+// gelfCore writes each entry as a GELF message.
+type gelfCore struct {
+	zapcore.LevelEnabler
+	w      *gelfWriter
+	fields []Field // Fields attached with With
+}
+
+func (c *gelfCore) With(fields []Field) zapcore.Core {
+	return &gelfCore{
+		LevelEnabler: c.LevelEnabler,
+		w:            c.w,
+		fields:       append(slices.Clip(c.fields), fields...),
+	}
+}
+
+func (c *gelfCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return ce.AddCore(entry, c)
+	}
+	return ce
+}
+
+func (c *gelfCore) Write(entry zapcore.Entry, fields []Field) error {
+	enc := zapcore.NewMapObjectEncoder()
+	for _, field := range c.fields {
+		field.AddTo(enc)
+	}
+	for _, field := range fields {
+		field.AddTo(enc)
+	}
+
+	message := map[string]any{
+		"version":       "1.1",
+		"host":          c.w.host,
+		"short_message": entry.Message,
+		"timestamp":     float64(entry.Time.UnixMicro()) / 1e6,
+		"level":         severity(entry.Level),
+	}
+	if entry.Stack != "" {
+		message["full_message"] = entry.Message + "\n" + entry.Stack
+	}
+	if entry.Caller.Defined {
+		message["_caller"] = entry.Caller.TrimmedPath()
+	}
+	for key, value := range enc.Fields {
+		message[gelfAdditionalField(key)] = gelfValue(value)
+	}
+
+	payload, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("encode GELF message: %w", err)
+	}
+	return c.w.write(payload)
+}
+
+func (c *gelfCore) Sync() error {
+	return nil
+}
+
+// gelfAdditionalField names a log field as a GELF additional field. _id is reserved, so
+// an id field is sent as _gopost_id.
+func gelfAdditionalField(key string) string {
+	name := gelfFieldName.ReplaceAllString(key, "_")
+	if name == "id" || name == "caller" {
+		name = "gopost_" + name
+	}
+	return "_" + name
+}
+
+// gelfValue converts a log field value to a GELF value: numbers as is, anything else as
+// a string.
+func gelfValue(value any) any {
+	switch v := value.(type) {
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32:
+		return v
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return formatFieldValue(v)
+		}
+		return v
+	default:
+		return formatFieldValue(v)
+	}
+}
+
+// gelfWriter sends GELF messages over UDP, chunked and compressed, or over TCP, delimited
+// by null bytes. A failed TCP write reconnects once.
+type gelfWriter struct {
+	network     string
+	address     string
+	compression string
+	host        string
+
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+// newGELFWriter connects to the GELF input configured in g.
+func newGELFWriter(g GELF) (*gelfWriter, error) {
+	w := &gelfWriter{network: g.Network, address: g.Address, compression: g.Compression, host: g.Host}
+	if w.network == "" {
+		w.network = "udp"
+	}
+	if w.network != "udp" && w.network != "tcp" {
+		return nil, fmt.Errorf("unknown GELF network %q", g.Network)
+	}
+	if w.compression == "" {
+		w.compression = GELFCompressionGzip
+	}
+	if !slices.Contains([]string{GELFCompressionGzip, GELFCompressionZlib, GELFCompressionNone}, w.compression) {
+		return nil, fmt.Errorf("unknown GELF compression %q", g.Compression)
+	}
+	if w.host == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return nil, fmt.Errorf("get hostname for GELF: %w", err)
+		}
+		w.host = hostname
+	}
+
+	conn, err := net.Dial(w.network, w.address)
+	if err != nil {
+		return nil, fmt.Errorf("connect to GELF input: %w", err)
+	}
+	w.conn = conn
+	return w, nil
+}
+
+// write sends one encoded message.
+func (w *gelfWriter) write(payload []byte) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.network == "udp" {
+		return w.writeUDP(payload)
+	}
+	framed := append(payload, 0)
+	if w.conn != nil {
+		if _, err := w.conn.Write(framed); err == nil {
+			return nil
+		}
+		_ = w.conn.Close()
+		w.conn = nil
+	}
+	conn, err := net.Dial(w.network, w.address)
+	if err != nil {
+		return fmt.Errorf("connect to GELF input: %w", err)
+	}
+	w.conn = conn
+	_, err = w.conn.Write(framed)
+	return err
+}
+
+// writeUDP compresses the message and sends it in one datagram, or in chunks when it is
+// too large. Callers hold w.mu.
+func (w *gelfWriter) writeUDP(payload []byte) error {
+	data, err := w.compress(payload)
+	if err != nil {
+		return err
+	}
+	if len(data) <= gelfChunkSize {
+		_, err = w.conn.Write(data)
+		return err
+	}
+
+	const chunkData = gelfChunkSize - gelfChunkHeader
+	count := (len(data) + chunkData - 1) / chunkData
+	if count > gelfMaxChunks {
+		return fmt.Errorf("GELF message of %d bytes exceeds %d chunks", len(data), gelfMaxChunks)
+	}
+	var id [8]byte
+	_, _ = rand.Read(id[:]) // crypto/rand.Read never returns an error
+	for i := range count {
+		chunk := make([]byte, 0, gelfChunkSize)
+		chunk = append(chunk, 0x1e, 0x0f)
+		chunk = append(chunk, id[:]...)
+		chunk = append(chunk, byte(i), byte(count))
+		chunk = append(chunk, data[i*chunkData:min((i+1)*chunkData, len(data))]...)
+		if _, err := w.conn.Write(chunk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// compress applies the configured UDP compression.
+func (w *gelfWriter) compress(payload []byte) ([]byte, error) {
+	var b bytes.Buffer
+	var zw io.WriteCloser
+	switch w.compression {
+	case GELFCompressionNone:
+		return payload, nil
+	case GELFCompressionZlib:
+		zw = zlib.NewWriter(&b)
+	default:
+		zw = gzip.NewWriter(&b)
+	}
+	if _, err := zw.Write(payload); err != nil {
+		return nil, fmt.Errorf("compress GELF message: %w", err)
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("compress GELF message: %w", err)
+	}
+	return b.Bytes(), nil
+}
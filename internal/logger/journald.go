@@ -0,0 +1,159 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// defaultJournalSocket is where journald accepts native protocol datagrams.
+const defaultJournalSocket = "/run/systemd/journal/socket"
+
+// journalReserved are the journal fields journalCore sets itself; log fields with the same
+// name are prefixed with GOPOST_.
+var journalReserved = []string{"MESSAGE", "PRIORITY", "SYSLOG_IDENTIFIER", "CODE_FILE", "CODE_LINE", "CODE_FUNC", "STACKTRACE"}
+
+// journalCore writes each entry to journald with its fields as journal fields.
+type journalCore struct {
+	zapcore.LevelEnabler
+	w      *journalWriter
+	fields []Field // Fields attached with With
+}
+
+func (c *journalCore) With(fields []Field) zapcore.Core {
+	return &journalCore{
+		LevelEnabler: c.LevelEnabler,
+		w:            c.w,
+		fields:       append(slices.Clip(c.fields), fields...),
+	}
+}
+
+func (c *journalCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return ce.AddCore(entry, c)
+	}
+	return ce
+}
+
+func (c *journalCore) Write(entry zapcore.Entry, fields []Field) error {
+	enc := zapcore.NewMapObjectEncoder()
+	for _, field := range c.fields {
+		field.AddTo(enc)
+	}
+	for _, field := range fields {
+		field.AddTo(enc)
+	}
+	return c.w.write(entry, enc.Fields)
+}
+
+func (c *journalCore) Sync() error {
+	return nil
+}
+
+// journalWriter sends entries to journald over its native protocol.
+type journalWriter struct {
+	conn net.Conn
+	tag  string
+}
+
+// newJournalWriter connects to the journald socket configured in o.
+func newJournalWriter(o Output) (*journalWriter, error) {
+	socket := o.Address
+	if socket == "" {
+		socket = defaultJournalSocket
+	}
+	conn, err := net.Dial("unixgram", socket)
+	if err != nil {
+		return nil, fmt.Errorf("connect to journald: %w", err)
+	}
+	return &journalWriter{conn: conn, tag: o.Tag}, nil
+}
+
+// write sends one entry as a single datagram. Entries too large for a datagram fail
+// rather than being passed to journald as a file descriptor.
+func (w *journalWriter) write(entry zapcore.Entry, fields map[string]any) error {
+	var b bytes.Buffer
+	writeJournalField(&b, "MESSAGE", entry.Message)
+	writeJournalField(&b, "PRIORITY", strconv.Itoa(severity(entry.Level)))
+	writeJournalField(&b, "SYSLOG_IDENTIFIER", w.tag)
+	if entry.Caller.Defined {
+		writeJournalField(&b, "CODE_FILE", entry.Caller.File)
+		writeJournalField(&b, "CODE_LINE", strconv.Itoa(entry.Caller.Line))
+		writeJournalField(&b, "CODE_FUNC", entry.Caller.Function)
+	}
+	if entry.Stack != "" {
+		writeJournalField(&b, "STACKTRACE", entry.Stack)
+	}
+
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	for _, key := range keys {
+		writeJournalField(&b, journalFieldName(key), formatFieldValue(fields[key]))
+	}
+
+	_, err := w.conn.Write(b.Bytes())
+	return err
+}
+
+// writeJournalField appends one field in the native protocol: KEY=value, or for values
+// containing newlines, KEY, a newline, the value's little-endian 64-bit length, and the
+// value.
+func writeJournalField(b *bytes.Buffer, name, value string) {
+	if !strings.Contains(value, "\n") {
+		b.WriteString(name + "=" + value + "\n")
+		return
+	}
+	b.WriteString(name + "\n")
+	_ = binary.Write(b, binary.LittleEndian, uint64(len(value)))
+	b.WriteString(value + "\n")
+}
+
+// journalFieldName converts a log field name to a valid journal field name: upper case
+// letters, digits, and underscores, starting with a letter.
+func journalFieldName(key string) string {
+	name := []byte(strings.ToUpper(key))
+	for i, c := range name {
+		if (c < 'A' || c > 'Z') && (c < '0' || c > '9') {
+			name[i] = '_'
+		}
+	}
+	result := string(name)
+	if result == "" || result[0] < 'A' || result[0] > 'Z' || slices.Contains(journalReserved, result) {
+		result = "GOPOST_" + strings.TrimLeft(result, "_")
+	}
+	const maxName = 64
+	if len(result) > maxName {
+		result = result[:maxName]
+	}
+	return result
+}
+
+// formatFieldValue formats a log field value for sinks that take string values: strings
+// as is, times as RFC 3339, durations and other Stringers with String, anything else as
+// JSON.
+func formatFieldValue(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case fmt.Stringer:
+		return v.String()
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Sprint(value)
+	}
+	return string(encoded)
+}
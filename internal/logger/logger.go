@@ -1,6 +1,8 @@
 package logger
 
 import (
+	"time"
+
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
@@ -20,10 +22,23 @@ type Logger interface {
 	// Error logs a message at error level.
 	Error(msg string, fields ...Field)
 
+	// DPanic logs a message at DPanic level. In development (debug) mode the logger
+	// then panics, so "can't happen" paths surface during development without taking
+	// down production.
+	DPanic(msg string, fields ...Field)
+
+	// Fatal logs a message at fatal level, flushes buffered entries and exits with
+	// status 1. Deferred functions do not run.
+	Fatal(msg string, fields ...Field)
+
 	// With returns a new logger with the given fields attached.
 	// Fields are added to all subsequent log entries from this logger.
 	With(fields ...Field) Logger
 
+	// WithError returns a new logger with err attached as the "error" field.
+	// It is shorthand for With(Error(err)).
+	WithError(err error) Logger
+
 	// Sync flushes any buffered log entries.
 	// Applications should call Sync before exiting to ensure all logs are written.
 	Sync() error
@@ -31,7 +46,8 @@ type Logger interface {
 
 // zapLogger is a zap-based implementation of the Logger interface.
 type zapLogger struct {
-	logger *zap.Logger
+	logger     *zap.Logger
+	suppressed *suppressor // Set when created WithSampling
 }
 
 // Debug logs a message at debug level.
@@ -54,15 +70,35 @@ func (l *zapLogger) Error(msg string, fields ...Field) {
 	l.logger.Error(msg, fields...)
 }
 
+// DPanic logs a message at DPanic level, panicking in development mode.
+func (l *zapLogger) DPanic(msg string, fields ...Field) {
+	l.logger.DPanic(msg, fields...)
+}
+
+// Fatal logs a message at fatal level, flushes and exits with status 1.
+func (l *zapLogger) Fatal(msg string, fields ...Field) {
+	l.logger.Fatal(msg, fields...)
+}
+
 // With returns a new logger with the given fields attached.
 func (l *zapLogger) With(fields ...Field) Logger {
 	return &zapLogger{
-		logger: l.logger.With(fields...),
+		logger:     l.logger.With(fields...),
+		suppressed: l.suppressed,
 	}
 }
 
-// Sync flushes any buffered log entries.
+// WithError returns a new logger with err attached as the "error" field.
+func (l *zapLogger) WithError(err error) Logger {
+	return l.With(Error(err))
+}
+
+// Sync flushes any buffered log entries, first logging the summary of entries
+// suppressed by sampling.
 func (l *zapLogger) Sync() error {
+	if l.suppressed != nil {
+		l.suppressed.flush()
+	}
 	return l.logger.Sync()
 }
 
@@ -74,20 +110,29 @@ func (l *zapLogger) Sync() error {
 // - Clean timestamp formatting
 // - Pretty-printed structured fields
 //
-// If debug is false, it uses zap.NewProduction() which provides:
+// If debug is false, it uses zap's production configuration which provides:
 // - JSON-formatted output
 // - Optimized for performance
 // - Stack traces only for errors and above
 // - Suitable for production environments
 //
+// WithSampling replaces production mode's default sampling and also applies in debug mode.
+// WithOutput writes to stdout, a file, syslog, or journald instead of stderr, and WithGELF
+// also ships entries to Graylog or Logstash.
+//
 // Returns an error if the logger cannot be created.
-func NewLogger(debug bool) (Logger, error) {
-	var z *zap.Logger
-	var err error
+func NewLogger(debug bool, opts ...Option) (Logger, error) {
+	var o options
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	var config zap.Config
+	var buildOpts []zap.Option
 
 	if debug {
 		// Create a custom development config with prettier formatting
-		config := zap.NewDevelopmentConfig()
+		config = zap.NewDevelopmentConfig()
 
 		// Enable color output for log levels
 		config.EncoderConfig.EncodeLevel = zapcore.CapitalColorLevelEncoder
@@ -108,28 +153,66 @@ func NewLogger(debug bool) (Logger, error) {
 		// Disable sampling in development for all logs to be visible
 		config.Sampling = nil
 
-		z, err = config.Build(
+		buildOpts = append(buildOpts,
 			// Add caller skip to show the actual calling function
 			zap.AddCallerSkip(0),
 			// Add stack traces only for errors and warnings (not for debug/info)
 			zap.AddStacktrace(zapcore.WarnLevel),
 		)
 	} else {
-		z, err = zap.NewProduction()
+		config = zap.NewProductionConfig()
+	}
+
+	if o.output != nil {
+		outputOpts, err := applyOutput(&config, *o.output)
+		if err != nil {
+			return nil, err
+		}
+		buildOpts = append(buildOpts, outputOpts...)
+	}
+	if o.gelf != nil {
+		w, err := newGELFWriter(*o.gelf)
+		if err != nil {
+			return nil, err
+		}
+		gelf := &gelfCore{LevelEnabler: config.Level, w: w}
+		buildOpts = append(buildOpts, zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+			return zapcore.NewTee(core, gelf)
+		}))
+	}
+
+	var suppressed *suppressor
+	switch {
+	case o.sampling != nil:
+		// Replace zap's sampler with one that counts what it drops
+		config.Sampling = nil
+		suppressed = newSuppressor(*o.sampling)
+		buildOpts = append(buildOpts, zap.WrapCore(suppressed.wrap))
+	case config.Sampling != nil:
+		// Build samples its own core before WrapCore options replace or tee it, so apply
+		// zap's default sampling to the final core instead
+		sampling := config.Sampling
+		config.Sampling = nil
+		buildOpts = append(buildOpts, zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+			return zapcore.NewSamplerWithOptions(core, time.Second, sampling.Initial, sampling.Thereafter)
+		}))
 	}
 
+	z, err := config.Build(buildOpts...)
 	if err != nil {
 		return nil, err
 	}
 
 	return &zapLogger{
-		logger: z,
+		logger:     z,
+		suppressed: suppressed,
 	}, nil
 }
 
 // NewNopLogger returns a no-op logger that discards all log entries.
 // Useful for testing or when logging should be disabled.
 func NewNopLogger() Logger {
+	// Fatal still exits: zap.NewNop discards entries but keeps the fatal hook
 	return &zapLogger{
 		logger: zap.NewNop(),
 	}
@@ -1,9 +1,23 @@
 package logger
 
 import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
 	"errors"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
 )
 
 func TestNewLogger(t *testing.T) {
@@ -286,3 +300,267 @@ func TestLoggerProductionVsDevelopment(t *testing.T) {
 	devLog.Debug("dev debug")
 	prodLog.Debug("prod debug")
 }
+
+func TestLoggerDPanic(t *testing.T) {
+	devLog, err := NewLogger(true)
+	if err != nil {
+		t.Fatalf("NewLogger(debug=true) error = %v", err)
+	}
+	defer devLog.Sync()
+
+	prodLog, err := NewLogger(false)
+	if err != nil {
+		t.Fatalf("NewLogger(debug=false) error = %v", err)
+	}
+	defer prodLog.Sync()
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("DPanic() did not panic in development mode")
+			}
+		}()
+		devLog.DPanic("dev dpanic")
+	}()
+
+	// Production mode logs without panicking
+	prodLog.DPanic("prod dpanic")
+}
+
+func TestLoggerWithError(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	log := &zapLogger{logger: zap.New(core)}
+
+	testErr := errors.New("test error")
+	log.WithError(testErr).Info("message with error")
+
+	entries := logs.All()
+	if len(entries) != 1 {
+		t.Fatalf("logged %d entries, want 1", len(entries))
+	}
+	got, ok := entries[0].ContextMap()["error"]
+	if !ok || got != testErr.Error() {
+		t.Errorf("error field = %v, want %q", got, testErr.Error())
+	}
+}
+
+func TestLoggerFatal(t *testing.T) {
+	// Fatal exits the process, so run it in a child test process
+	if os.Getenv("LOGGER_TEST_FATAL") == "1" {
+		NewNopLogger().WithError(errors.New("boom")).Fatal("fatal message")
+		return
+	}
+
+	cmd := exec.Command(os.Args[0], "-test.run=^TestLoggerFatal$")
+	cmd.Env = append(os.Environ(), "LOGGER_TEST_FATAL=1")
+	err := cmd.Run()
+
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) || exitErr.ExitCode() != 1 {
+		t.Fatalf("Fatal() exit = %v, want exit status 1", err)
+	}
+}
+
+func TestLoggerContext(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	log := &zapLogger{logger: zap.New(core)}
+
+	// Without an attached logger, FromContext discards entries rather than returning nil
+	FromContext(context.Background()).Info("dropped")
+
+	ctx := WithContext(context.Background(), log.With(String("run_id", "r1")))
+	ctx = WithContext(ctx, FromContext(ctx).With(String("city", "sudbury")))
+	FromContext(ctx).Info("message with context")
+
+	entries := logs.All()
+	if len(entries) != 1 {
+		t.Fatalf("logged %d entries, want 1", len(entries))
+	}
+	fields := entries[0].ContextMap()
+	if fields["run_id"] != "r1" || fields["city"] != "sudbury" {
+		t.Errorf("fields = %v, want run_id and city from the context", fields)
+	}
+}
+
+func TestLoggerSampling(t *testing.T) {
+	core, logs := observer.New(zapcore.DebugLevel)
+	suppressed := newSuppressor(Sampling{Initial: 2, Tick: time.Minute, SummaryInterval: time.Hour})
+	log := &zapLogger{logger: zap.New(core, zap.WrapCore(suppressed.wrap)), suppressed: suppressed}
+
+	for range 5 {
+		log.Error("Redis error checking article")
+	}
+	log.Info("different message")
+	if got := logs.FilterMessage("Redis error checking article").Len(); got != 2 {
+		t.Errorf("logged %d repeated entries, want 2", got)
+	}
+	if got := logs.FilterMessage("different message").Len(); got != 1 {
+		t.Errorf("logged %d entries of another message, want 1", got)
+	}
+
+	// The summary waits for the interval, or Sync
+	summaries := logs.FilterMessage("Suppressed similar log messages")
+	if summaries.Len() != 0 {
+		t.Fatalf("logged %d summaries before Sync, want 0", summaries.Len())
+	}
+	_ = log.Sync()
+	summaries = logs.FilterMessage("Suppressed similar log messages")
+	if summaries.Len() != 1 {
+		t.Fatalf("logged %d summaries after Sync, want 1", summaries.Len())
+	}
+	summary := summaries.All()[0]
+	fields := summary.ContextMap()
+	if summary.Level != zapcore.ErrorLevel || fields["message"] != "Redis error checking article" || fields["suppressed"] != int64(3) {
+		t.Errorf("summary = %s %v, want error level with 3 suppressed", summary.Level, fields)
+	}
+}
+
+func TestNewLogger_SyslogOutput(t *testing.T) {
+	listener, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer listener.Close()
+
+	log, err := NewLogger(false, WithOutput(Output{
+		Type:     OutputSyslog,
+		Network:  "udp",
+		Address:  listener.LocalAddr().String(),
+		Facility: "local0",
+	}))
+	if err != nil {
+		t.Fatalf("NewLogger() error = %v", err)
+	}
+	log.With(String("city", "sudbury")).Warn("Redis unavailable")
+
+	buf := make([]byte, 4096)
+	_ = listener.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, _, err := listener.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("read syslog message: %v", err)
+	}
+	message := string(buf[:n])
+	// local0 (16) * 8 + warning (4)
+	if !strings.HasPrefix(message, "<132>1 ") || !strings.Contains(message, " gopost ") {
+		t.Errorf("message = %q, want an RFC 5424 header with PRI 132 and APP-NAME gopost", message)
+	}
+	if !strings.Contains(message, `"msg":"Redis unavailable"`) || !strings.Contains(message, `"city":"sudbury"`) {
+		t.Errorf("message = %q, want the entry as JSON", message)
+	}
+}
+
+func TestNewLogger_JournaldOutput(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "journal.sock")
+	listener, err := net.ListenPacket("unixgram", socket)
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer listener.Close()
+
+	log, err := NewLogger(false, WithOutput(Output{Type: OutputJournald, Address: socket}))
+	if err != nil {
+		t.Fatalf("NewLogger() error = %v", err)
+	}
+	log.Error("Error posting article",
+		String("article_id", "a1"),
+		String("message", "multi\nline"),
+		Int("attempt", 2),
+	)
+
+	buf := make([]byte, 65536)
+	_ = listener.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, _, err := listener.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("read journal entry: %v", err)
+	}
+	entry := string(buf[:n])
+	for _, want := range []string{
+		"MESSAGE=Error posting article\n",
+		"PRIORITY=3\n",
+		"SYSLOG_IDENTIFIER=gopost\n",
+		"ARTICLE_ID=a1\n",
+		"ATTEMPT=2\n",
+		"GOPOST_MESSAGE\n\x0a\x00\x00\x00\x00\x00\x00\x00multi\nline\n",
+	} {
+		if !strings.Contains(entry, want) {
+			t.Errorf("entry = %q, want it to contain %q", entry, want)
+		}
+	}
+}
+
+func TestNewLogger_GELF(t *testing.T) {
+	listener, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer listener.Close()
+
+	log, err := NewLogger(false, WithGELF(GELF{Address: listener.LocalAddr().String(), Host: "worker-1"}))
+	if err != nil {
+		t.Fatalf("NewLogger() error = %v", err)
+	}
+	log.Error("Error posting article", String("article_id", "a1"), String("id", "n1"), Int("attempt", 2))
+
+	buf := make([]byte, 65536)
+	_ = listener.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, _, err := listener.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("read GELF message: %v", err)
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(buf[:n]))
+	if err != nil {
+		t.Fatalf("GELF message is not gzip-compressed: %v", err)
+	}
+	var message map[string]any
+	if err := json.NewDecoder(zr).Decode(&message); err != nil {
+		t.Fatalf("decode GELF message: %v", err)
+	}
+
+	want := map[string]any{
+		"version":       "1.1",
+		"host":          "worker-1",
+		"short_message": "Error posting article",
+		"level":         float64(3),
+		"_article_id":   "a1",
+		"_gopost_id":    "n1",
+		"_attempt":      float64(2),
+	}
+	for key, value := range want {
+		if message[key] != value {
+			t.Errorf("%s = %v, want %v", key, message[key], value)
+		}
+	}
+}
+
+func TestNewLogger_GELFOverTCP(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer listener.Close()
+	received := make(chan []byte, 1)
+	go func() {
+		conn, acceptErr := listener.Accept()
+		if acceptErr != nil {
+			return
+		}
+		defer conn.Close()
+		message, _ := bufio.NewReader(conn).ReadBytes(0)
+		received <- message
+	}()
+
+	log, err := NewLogger(false, WithGELF(GELF{Network: "tcp", Address: listener.Addr().String()}))
+	if err != nil {
+		t.Fatalf("NewLogger() error = %v", err)
+	}
+	log.Info("Article posted")
+
+	select {
+	case message := <-received:
+		if !bytes.HasSuffix(message, []byte{0}) || !bytes.Contains(message, []byte(`"short_message":"Article posted"`)) {
+			t.Errorf("message = %q, want an uncompressed null-terminated GELF message", message)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no GELF message received")
+	}
+}
@@ -0,0 +1,108 @@
+package logger
+
+import (
+	"fmt"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Output types, selecting where log entries are written.
+const (
+	OutputStderr   = "stderr"   // Standard error (the default)
+	OutputStdout   = "stdout"   // Standard output
+	OutputFile     = "file"     // A file, appended to
+	OutputSyslog   = "syslog"   // A syslog daemon, as RFC 5424 messages
+	OutputJournald = "journald" // The systemd journal, with fields as journal fields
+)
+
+// Output selects where log entries are written. Syslog messages carry the entry as JSON;
+// journald entries carry the message as MESSAGE and each field as an upper-case journal
+// field (e.g. article_id as ARTICLE_ID).
+type Output struct {
+	// Type is one of the Output* constants. Empty means OutputStderr.
+	Type string
+
+	// Path is the file written by OutputFile.
+	Path string
+
+	// Network is the syslog transport: "udp", "tcp", or "unix". Empty, with Address
+	// empty, uses the local syslog socket.
+	Network string
+
+	// Address is the syslog server's host:port or socket path, or the journald socket
+	// path (default: /run/systemd/journal/socket).
+	Address string
+
+	// Facility is the syslog facility name, e.g. "daemon" (the default) or "local0".
+	Facility string
+
+	// Tag is the syslog APP-NAME and journald SYSLOG_IDENTIFIER (default: gopost).
+	Tag string
+}
+
+// WithOutput writes log entries to o instead of standard error.
+func WithOutput(o Output) Option {
+	return func(opts *options) {
+		opts.output = &o
+	}
+}
+
+// applyOutput points config at o's destination, returning the options that replace the
+// core for syslog and journald.
+func applyOutput(config *zap.Config, o Output) ([]zap.Option, error) {
+	if o.Tag == "" {
+		o.Tag = "gopost"
+	}
+
+	var core zapcore.Core
+	switch o.Type {
+	case "", OutputStderr:
+		config.OutputPaths = []string{"stderr"}
+		return nil, nil
+	case OutputStdout:
+		config.OutputPaths = []string{"stdout"}
+		return nil, nil
+	case OutputFile:
+		if o.Path == "" {
+			return nil, fmt.Errorf("%s output requires a path", OutputFile)
+		}
+		config.OutputPaths = []string{o.Path}
+		return nil, nil
+	case OutputSyslog:
+		w, err := newSyslogWriter(o)
+		if err != nil {
+			return nil, err
+		}
+		// Syslog messages have their own timestamp and severity, and no color
+		encoderConfig := zap.NewProductionEncoderConfig()
+		encoderConfig.TimeKey = zapcore.OmitKey
+		core = &syslogCore{LevelEnabler: config.Level, enc: zapcore.NewJSONEncoder(encoderConfig), w: w}
+	case OutputJournald:
+		w, err := newJournalWriter(o)
+		if err != nil {
+			return nil, err
+		}
+		core = &journalCore{LevelEnabler: config.Level, w: w}
+	default:
+		return nil, fmt.Errorf("unknown log output type %q", o.Type)
+	}
+
+	return []zap.Option{zap.WrapCore(func(zapcore.Core) zapcore.Core { return core })}, nil
+}
+
+// severity maps a zap level to a syslog severity, which journald uses as PRIORITY.
+func severity(level zapcore.Level) int {
+	switch {
+	case level <= zapcore.DebugLevel:
+		return 7 // debug
+	case level == zapcore.InfoLevel:
+		return 6 // informational
+	case level == zapcore.WarnLevel:
+		return 4 // warning
+	case level == zapcore.ErrorLevel:
+		return 3 // error
+	default:
+		return 2 // critical: DPanic, Panic, Fatal
+	}
+}
@@ -0,0 +1,144 @@
+package logger
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Sampling configures suppression of repeated log entries, so an outage that fails every
+// article logs a handful of identical lines per tick instead of thousands per minute.
+type Sampling struct {
+	// Initial is the number of entries with the same level and message logged in full
+	// each tick.
+	Initial int
+
+	// Thereafter logs every Nth further entry within the tick; 0 suppresses the rest.
+	Thereafter int
+
+	// Tick is the sampling window. Zero defaults to one second.
+	Tick time.Duration
+
+	// SummaryInterval is how often the number of suppressed entries is logged per
+	// message. Zero defaults to one minute.
+	SummaryInterval time.Duration
+}
+
+// Option configures a logger created by NewLogger.
+type Option func(*options)
+
+type options struct {
+	sampling *Sampling
+	output   *Output
+	gelf     *GELF
+}
+
+// WithSampling replaces zap's default production sampling with s, in debug and production
+// mode alike, and logs a "Suppressed similar log messages" summary per suppressed message.
+func WithSampling(s Sampling) Option {
+	return func(o *options) {
+		o.sampling = &s
+	}
+}
+
+// suppressedKey identifies entries the sampler treats as similar.
+type suppressedKey struct {
+	level   zapcore.Level
+	message string
+}
+
+// suppressor counts the entries dropped by the sampler and logs the counts, unsampled,
+// at most every interval. Summaries are written when a later entry passes through the
+// sampler after the interval, and on Sync.
+type suppressor struct {
+	sampling Sampling
+	out      *zap.Logger
+
+	mu     sync.Mutex
+	counts map[suppressedKey]int
+	last   time.Time
+}
+
+// newSuppressor returns a suppressor for s with zero durations defaulted.
+func newSuppressor(s Sampling) *suppressor {
+	if s.Tick <= 0 {
+		s.Tick = time.Second
+	}
+	if s.SummaryInterval <= 0 {
+		s.SummaryInterval = time.Minute
+	}
+	return &suppressor{
+		sampling: s,
+		out:      zap.NewNop(),
+		counts:   make(map[suppressedKey]int),
+		last:     time.Now(),
+	}
+}
+
+// wrap samples core, keeping the unsampled core for summaries. It is passed to
+// zap.WrapCore.
+func (s *suppressor) wrap(core zapcore.Core) zapcore.Core {
+	s.out = zap.New(core)
+	return zapcore.NewSamplerWithOptions(core, s.sampling.Tick, s.sampling.Initial, s.sampling.Thereafter,
+		zapcore.SamplerHook(s.hook))
+}
+
+// hook counts dropped entries and writes the summary once the interval has passed.
+func (s *suppressor) hook(entry zapcore.Entry, decision zapcore.SamplingDecision) {
+	s.mu.Lock()
+	if decision&zapcore.LogDropped != 0 {
+		s.counts[suppressedKey{level: entry.Level, message: entry.Message}]++
+	}
+	var pending map[suppressedKey]int
+	if len(s.counts) > 0 && entry.Time.Sub(s.last) >= s.sampling.SummaryInterval {
+		pending = s.take(entry.Time)
+	}
+	s.mu.Unlock()
+
+	s.summarize(pending)
+}
+
+// flush writes the summary for every entry suppressed since the last one.
+func (s *suppressor) flush() {
+	s.mu.Lock()
+	pending := s.take(time.Now())
+	s.mu.Unlock()
+
+	s.summarize(pending)
+}
+
+// take returns the counts and resets them. Callers hold s.mu.
+func (s *suppressor) take(now time.Time) map[suppressedKey]int {
+	pending := s.counts
+	s.counts = make(map[suppressedKey]int)
+	s.last = now
+	return pending
+}
+
+// summarize logs one summary per suppressed message, at the message's level (at most
+// error, so a suppressed fatal entry cannot exit).
+func (s *suppressor) summarize(pending map[suppressedKey]int) {
+	keys := make([]suppressedKey, 0, len(pending))
+	for key := range pending {
+		keys = append(keys, key)
+	}
+	slices.SortFunc(keys, func(a, b suppressedKey) int {
+		return cmp.Or(strings.Compare(a.message, b.message), cmp.Compare(a.level, b.level))
+	})
+
+	for _, key := range keys {
+		level := min(key.level, zapcore.ErrorLevel)
+		if ce := s.out.Check(level, "Suppressed similar log messages"); ce != nil {
+			ce.Write(
+				String("message", key.message),
+				Int("suppressed", pending[key]),
+				Duration("summary_interval", s.sampling.SummaryInterval),
+			)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package logger
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// syslogFacilities maps facility names to their RFC 5424 codes.
+var syslogFacilities = map[string]int{
+	"kern": 0, "user": 1, "mail": 2, "daemon": 3, "auth": 4, "syslog": 5, "lpr": 6, "news": 7,
+	"uucp": 8, "cron": 9, "authpriv": 10, "ftp": 11,
+	"local0": 16, "local1": 17, "local2": 18, "local3": 19,
+	"local4": 20, "local5": 21, "local6": 22, "local7": 23,
+}
+
+// localSyslogSockets are tried in order when no syslog network or address is set.
+var localSyslogSockets = []string{"/dev/log", "/var/run/syslog", "/var/run/log"}
+
+// syslogTimeFormat is RFC 3339 with at most microseconds, as RFC 5424 requires.
+const syslogTimeFormat = "2006-01-02T15:04:05.000000Z07:00"
+
+// syslogCore writes each entry, encoded as JSON, as the MSG of an RFC 5424 message.
+type syslogCore struct {
+	zapcore.LevelEnabler
+	enc zapcore.Encoder
+	w   *syslogWriter
+}
+
+func (c *syslogCore) With(fields []Field) zapcore.Core {
+	enc := c.enc.Clone()
+	for _, field := range fields {
+		field.AddTo(enc)
+	}
+	return &syslogCore{LevelEnabler: c.LevelEnabler, enc: enc, w: c.w}
+}
+
+func (c *syslogCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return ce.AddCore(entry, c)
+	}
+	return ce
+}
+
+func (c *syslogCore) Write(entry zapcore.Entry, fields []Field) error {
+	buf, err := c.enc.EncodeEntry(entry, fields)
+	if err != nil {
+		return err
+	}
+	defer buf.Free()
+	return c.w.write(entry, bytes.TrimSuffix(buf.Bytes(), []byte("\n")))
+}
+
+func (c *syslogCore) Sync() error {
+	return nil
+}
+
+// syslogWriter sends RFC 5424 messages over one connection, redialing once when a write
+// fails (e.g. after the syslog daemon restarts).
+type syslogWriter struct {
+	network  string
+	address  string
+	facility int
+	tag      string
+	hostname string
+
+	mu     sync.Mutex
+	conn   net.Conn
+	stream bool // Octet-counting framing (RFC 6587) for stream transports
+}
+
+// newSyslogWriter connects to the syslog server configured in o.
+func newSyslogWriter(o Output) (*syslogWriter, error) {
+	facility := "daemon"
+	if o.Facility != "" {
+		facility = o.Facility
+	}
+	code, ok := syslogFacilities[facility]
+	if !ok {
+		return nil, fmt.Errorf("unknown syslog facility %q", facility)
+	}
+	switch o.Network {
+	case "", "udp", "tcp", "unix":
+	default:
+		return nil, fmt.Errorf("unknown syslog network %q", o.Network)
+	}
+	if o.Network != "" && o.Address == "" {
+		return nil, fmt.Errorf("syslog network %s requires an address", o.Network)
+	}
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "-"
+	}
+
+	w := &syslogWriter{network: o.Network, address: o.Address, facility: code, tag: o.Tag, hostname: hostname}
+	if err := w.dial(); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// dial connects to the syslog server. Unix sockets are tried as datagram sockets first,
+// then as stream sockets. Callers hold w.mu, except newSyslogWriter.
+func (w *syslogWriter) dial() error {
+	type candidate struct{ network, address string }
+	var candidates []candidate
+	switch w.network {
+	case "":
+		for _, path := range localSyslogSockets {
+			candidates = append(candidates, candidate{"unixgram", path}, candidate{"unix", path})
+		}
+	case "unix":
+		candidates = []candidate{{"unixgram", w.address}, {"unix", w.address}}
+	default:
+		candidates = []candidate{{w.network, w.address}}
+	}
+
+	var errs []error
+	for _, c := range candidates {
+		conn, err := net.Dial(c.network, c.address)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		w.conn = conn
+		w.stream = c.network == "tcp" || c.network == "unix"
+		return nil
+	}
+	return fmt.Errorf("connect to syslog: %w", errors.Join(errs...))
+}
+
+// write sends msg as one syslog message at the entry's severity.
+func (w *syslogWriter) write(entry zapcore.Entry, msg []byte) error {
+	// <PRI>VERSION TIMESTAMP HOSTNAME APP-NAME PROCID MSGID STRUCTURED-DATA MSG
+	var b bytes.Buffer
+	b.WriteString("<" + strconv.Itoa(w.facility*8+severity(entry.Level)) + ">1 ")
+	b.WriteString(entry.Time.Format(syslogTimeFormat))
+	b.WriteString(" " + w.hostname + " " + w.tag + " " + strconv.Itoa(os.Getpid()) + " - - ")
+	b.Write(msg)
+	message := b.Bytes()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.conn != nil {
+		if err := w.send(message); err == nil {
+			return nil
+		}
+		_ = w.conn.Close()
+		w.conn = nil
+	}
+	if err := w.dial(); err != nil {
+		return err
+	}
+	return w.send(message)
+}
+
+// send writes one message, framed for stream transports. Callers hold w.mu.
+func (w *syslogWriter) send(message []byte) error {
+	if w.stream {
+		message = append([]byte(strconv.Itoa(len(message))+" "), message...)
+	}
+	_, err := w.conn.Write(message)
+	return err
+}
@@ -0,0 +1,366 @@
+// Package metrics turns the service status into metrics, served in the Prometheus text
+// format by the admin /metrics endpoint and pushed to a Pushgateway or StatsD after
+// one-shot runs.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gopost/integration/internal/canary"
+	"github.com/gopost/integration/internal/connstats"
+	"github.com/gopost/integration/internal/integration"
+)
+
+// Family is a metric and its samples.
+type Family struct {
+	Name    string
+	Help    string
+	Type    string // "gauge" (the default), "counter", or "histogram"
+	Samples []Sample
+}
+
+// Sample is one series of a metric.
+type Sample struct {
+	Suffix string  // Appended to the family name, e.g. "_bucket" for histograms
+	Labels []Label // Labels with empty values are omitted
+	Value  float64
+}
+
+// Label is a series label.
+type Label struct {
+	Name  string
+	Value string
+}
+
+// Collect returns the gauges for status, labelled by tenant in multi-tenant mode.
+// Families without samples are omitted.
+func Collect(status integration.Status) []Family {
+	statuses := map[string]integration.Status{"": status}
+	if len(status.Tenants) > 0 {
+		statuses = status.Tenants
+	}
+	tenants := slices.Sorted(maps.Keys(statuses))
+
+	perTenant := func(name, help string, value func(integration.Status) float64) Family {
+		family := Family{Name: name, Help: help}
+		for _, tenant := range tenants {
+			family.Samples = append(family.Samples, Sample{
+				Labels: []Label{{"tenant", tenant}},
+				Value:  value(statuses[tenant]),
+			})
+		}
+		return family
+	}
+	families := []Family{
+		perTenant("gopost_health_score", "Overall health from 0 (down) to 1 (healthy).",
+			func(s integration.Status) float64 { return s.Health.Score }),
+		perTenant("gopost_health_error_ratio", "Failed posts over attempted posts in the last run.",
+			func(s integration.Status) float64 { return s.Health.ErrorRatio }),
+		perTenant("gopost_health_lag_seconds", "How far the newest article found is ahead of the last check time.",
+			func(s integration.Status) float64 { return s.Health.Lag.Seconds() }),
+	}
+
+	backendUp := Family{Name: "gopost_backend_up", Help: "Whether the last run found the backend available."}
+	for _, tenant := range tenants {
+		backends := statuses[tenant].Health.Backends
+		for _, backend := range []struct {
+			name string
+			up   bool
+		}{{"elasticsearch", backends.Elasticsearch}, {"drupal", backends.Drupal}, {"redis", backends.Redis}} {
+			backendUp.Samples = append(backendUp.Samples, Sample{
+				Labels: []Label{{"tenant", tenant}, {"backend", backend.name}},
+				Value:  boolValue(backend.up),
+			})
+		}
+	}
+	families = append(families, backendUp)
+
+	syncLag := Family{Name: "gopost_sync_lag_seconds", Help: "How far the newest article matching the city's search is ahead of the newest posted."}
+	runArticles := Family{Name: "gopost_last_run_articles", Help: "Articles found, posted, queued, skipped, or failed in the last run."}
+	runStarted := Family{Name: "gopost_last_run_start_timestamp_seconds", Help: "When the last run started, as a Unix timestamp."}
+	runDuration := Family{Name: "gopost_last_run_duration_seconds", Help: "How long the last run took."}
+	volumeAnomaly := Family{Name: "gopost_volume_anomaly", Help: "1 for each article count of the city's last run far outside its rolling average."}
+	searchDiagnosis := Family{Name: "gopost_empty_search_diagnosis", Help: "1 for the cause of the city's search finding nothing in the last run."}
+	closedIndex := Family{Name: "gopost_closed_index", Help: "1 when the city was skipped in the last run because its index is closed or frozen."}
+	for _, tenant := range tenants {
+		report := statuses[tenant].LastReport
+		var found, posted, queued, skipped, errors int
+		for _, city := range report.Cities {
+			found += city.Found
+			posted += city.Posted
+			queued += city.Queued
+			skipped += city.Skipped
+			errors += city.Errors
+			if city.SyncLag != nil {
+				syncLag.Samples = append(syncLag.Samples, Sample{
+					Labels: []Label{{"tenant", tenant}, {"city", city.City}},
+					Value:  city.SyncLag.Seconds(),
+				})
+			}
+			if city.Diagnosis != nil {
+				searchDiagnosis.Samples = append(searchDiagnosis.Samples, Sample{
+					Labels: []Label{{"tenant", tenant}, {"city", city.City}, {"cause", city.Diagnosis.Cause}},
+					Value:  1,
+				})
+			}
+			if city.ClosedIndex != "" {
+				closedIndex.Samples = append(closedIndex.Samples, Sample{
+					Labels: []Label{{"tenant", tenant}, {"city", city.City}, {"type", city.ClosedIndex}},
+					Value:  1,
+				})
+			}
+			for _, anomaly := range city.Anomalies {
+				volumeAnomaly.Samples = append(volumeAnomaly.Samples, Sample{
+					Labels: []Label{{"tenant", tenant}, {"city", city.City}, {"metric", anomaly.Metric}, {"kind", anomaly.Kind}},
+					Value:  1,
+				})
+			}
+		}
+		if report.StartedAt.IsZero() {
+			continue
+		}
+		for _, result := range []struct {
+			name  string
+			count int
+		}{{"found", found}, {"posted", posted}, {"queued", queued}, {"skipped", skipped}, {"errors", errors}} {
+			runArticles.Samples = append(runArticles.Samples, Sample{
+				Labels: []Label{{"tenant", tenant}, {"result", result.name}},
+				Value:  float64(result.count),
+			})
+		}
+		runStarted.Samples = append(runStarted.Samples, Sample{
+			Labels: []Label{{"tenant", tenant}},
+			Value:  float64(report.StartedAt.Unix()),
+		})
+		runDuration.Samples = append(runDuration.Samples, Sample{
+			Labels: []Label{{"tenant", tenant}},
+			Value:  report.Duration.Seconds(),
+		})
+	}
+	for _, family := range []Family{syncLag, volumeAnomaly, searchDiagnosis, closedIndex, runArticles, runStarted, runDuration} {
+		if len(family.Samples) > 0 {
+			families = append(families, family)
+		}
+	}
+	families = append(families, statsFamilies(tenants, statuses)...)
+	families = append(families, moderationFamilies(tenants, statuses)...)
+	families = append(families, canaryFamilies(tenants, statuses)...)
+	return append(families, connStatsFamilies(tenants, statuses)...)
+}
+
+// statsFamilies returns the runs completed since startup and each city's article counts
+// over them.
+func statsFamilies(tenants []string, statuses map[string]integration.Status) []Family {
+	runs := Family{Name: "gopost_runs_total", Help: "Runs completed since startup.", Type: "counter"}
+	articles := Family{Name: "gopost_articles_total", Help: "Articles found, posted, queued, skipped, or failed since startup.", Type: "counter"}
+	for _, tenant := range tenants {
+		stats := statuses[tenant].Stats
+		if stats.RunsCompleted == 0 {
+			continue
+		}
+		runs.Samples = append(runs.Samples, Sample{
+			Labels: []Label{{"tenant", tenant}},
+			Value:  float64(stats.RunsCompleted),
+		})
+		for _, city := range stats.Cities {
+			for _, result := range []struct {
+				name  string
+				count int
+			}{{"found", city.Found}, {"posted", city.Posted}, {"queued", city.Queued}, {"skipped", city.Skipped}, {"errors", city.Errors}} {
+				articles.Samples = append(articles.Samples, Sample{
+					Labels: []Label{{"tenant", tenant}, {"city", city.City}, {"result", result.name}},
+					Value:  float64(result.count),
+				})
+			}
+		}
+	}
+
+	var families []Family
+	for _, family := range []Family{runs, articles} {
+		if len(family.Samples) > 0 {
+			families = append(families, family)
+		}
+	}
+	return families
+}
+
+// moderationFamilies returns the editorial decisions received by the moderation webhook,
+// and the share of decided posts each city's editors published.
+func moderationFamilies(tenants []string, statuses map[string]integration.Status) []Family {
+	decisions := Family{Name: "gopost_moderation_decisions_total", Help: "Editorial decisions on posted nodes reported by Drupal.", Type: "counter"}
+	acceptance := Family{Name: "gopost_moderation_acceptance_ratio", Help: "Published posts over published, rejected, and deleted posts since startup."}
+	for _, tenant := range tenants {
+		type tally struct{ published, decided int }
+		byCity := make(map[string]*tally)
+		for _, count := range statuses[tenant].Moderation {
+			decisions.Samples = append(decisions.Samples, Sample{
+				Labels: []Label{{"tenant", tenant}, {"city", count.City}, {"decision", count.Decision}},
+				Value:  float64(count.Count),
+			})
+			if count.Decision == integration.ModerationEdited {
+				continue
+			}
+			if byCity[count.City] == nil {
+				byCity[count.City] = &tally{}
+			}
+			byCity[count.City].decided += count.Count
+			if count.Decision == integration.ModerationPublished {
+				byCity[count.City].published += count.Count
+			}
+		}
+		for _, city := range slices.Sorted(maps.Keys(byCity)) {
+			acceptance.Samples = append(acceptance.Samples, Sample{
+				Labels: []Label{{"tenant", tenant}, {"city", city}},
+				Value:  float64(byCity[city].published) / float64(byCity[city].decided),
+			})
+		}
+	}
+
+	var families []Family
+	for _, family := range []Family{decisions, acceptance} {
+		if len(family.Samples) > 0 {
+			families = append(families, family)
+		}
+	}
+	return families
+}
+
+// canaryFamilies returns the progress and verdict of the tenants' canary trials.
+func canaryFamilies(tenants []string, statuses map[string]integration.Status) []Family {
+	deviation := Family{Name: "gopost_canary_deviation_ratio", Help: "Relative change in canary city volume of the candidate configuration over the current one."}
+	runs := Family{Name: "gopost_canary_runs", Help: "Runs counted by the canary trial."}
+	failed := Family{Name: "gopost_canary_failed", Help: "1 if the canary trial failed and the candidate configuration was reverted."}
+	for _, tenant := range tenants {
+		trial := statuses[tenant].Canary
+		if trial == nil {
+			continue
+		}
+		labels := []Label{{"tenant", tenant}}
+		deviation.Samples = append(deviation.Samples, Sample{Labels: labels, Value: trial.Deviation})
+		runs.Samples = append(runs.Samples, Sample{Labels: labels, Value: float64(trial.Runs)})
+		failed.Samples = append(failed.Samples, Sample{Labels: labels, Value: boolValue(trial.Verdict == canary.VerdictFailed)})
+	}
+	if len(deviation.Samples) == 0 {
+		return nil
+	}
+	return []Family{deviation, runs, failed}
+}
+
+// connStatsFamilies returns the Drupal connection stats of the tenants recording them.
+func connStatsFamilies(tenants []string, statuses map[string]integration.Status) []Family {
+	requests := Family{Name: "gopost_drupal_requests_total", Help: "Drupal requests by connection reuse and protocol.", Type: "counter"}
+	histograms := []struct {
+		family Family
+		value  func(*connstats.Snapshot) connstats.Histogram
+	}{
+		{Family{Name: "gopost_drupal_dns_seconds", Help: "DNS lookup time of new Drupal connections."},
+			func(s *connstats.Snapshot) connstats.Histogram { return s.DNS }},
+		{Family{Name: "gopost_drupal_connect_seconds", Help: "TCP connect time of new Drupal connections."},
+			func(s *connstats.Snapshot) connstats.Histogram { return s.Connect }},
+		{Family{Name: "gopost_drupal_tls_handshake_seconds", Help: "TLS handshake time of new Drupal connections."},
+			func(s *connstats.Snapshot) connstats.Histogram { return s.TLS }},
+		{Family{Name: "gopost_drupal_ttfb_seconds", Help: "Time from the start of a Drupal request to the first response byte."},
+			func(s *connstats.Snapshot) connstats.Histogram { return s.TTFB }},
+	}
+
+	recorded := false
+	for _, tenant := range tenants {
+		stats := statuses[tenant].ConnStats
+		if stats == nil {
+			continue
+		}
+		recorded = true
+		for _, count := range stats.Requests {
+			connection := "new"
+			if count.Reused {
+				connection = "reused"
+			}
+			requests.Samples = append(requests.Samples, Sample{
+				Labels: []Label{{"tenant", tenant}, {"connection", connection}, {"protocol", count.Protocol}},
+				Value:  float64(count.Count),
+			})
+		}
+		for i := range histograms {
+			h := &histograms[i]
+			h.family.Samples = append(h.family.Samples, histogramSamples(h.value(stats), Label{"tenant", tenant})...)
+		}
+	}
+	if !recorded {
+		return nil
+	}
+
+	families := []Family{requests}
+	for _, h := range histograms {
+		h.family.Type = "histogram"
+		families = append(families, h.family)
+	}
+	return families
+}
+
+// histogramSamples returns the _bucket, _sum, and _count series of h.
+func histogramSamples(h connstats.Histogram, labels ...Label) []Sample {
+	var samples []Sample
+	for i, bound := range connstats.Buckets {
+		var count uint64
+		if i < len(h.Counts) {
+			count = h.Counts[i]
+		}
+		samples = append(samples, Sample{
+			Suffix: "_bucket",
+			Labels: append(slices.Clone(labels), Label{"le", strconv.FormatFloat(bound, 'g', -1, 64)}),
+			Value:  float64(count),
+		})
+	}
+	return append(samples,
+		Sample{Suffix: "_bucket", Labels: append(slices.Clone(labels), Label{"le", "+Inf"}), Value: float64(h.Count)},
+		Sample{Suffix: "_sum", Labels: labels, Value: h.Sum},
+		Sample{Suffix: "_count", Labels: labels, Value: float64(h.Count)},
+	)
+}
+
+// WriteText writes families in the Prometheus text exposition format.
+func WriteText(w io.Writer, families []Family) error {
+	var b strings.Builder
+	for _, family := range families {
+		metricType := family.Type
+		if metricType == "" {
+			metricType = "gauge"
+		}
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n", family.Name, family.Help, family.Name, metricType)
+		for _, sample := range family.Samples {
+			fmt.Fprintf(&b, "%s%s%s %g\n", family.Name, sample.Suffix, formatLabels(sample.Labels), sample.Value)
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// labelEscaper escapes Prometheus label values.
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// formatLabels formats the labels of a series, omitting labels with empty values.
+func formatLabels(labels []Label) string {
+	var formatted []string
+	for _, label := range labels {
+		if label.Value != "" {
+			formatted = append(formatted, label.Name+`="`+labelEscaper.Replace(label.Value)+`"`)
+		}
+	}
+	if len(formatted) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(formatted, ",") + "}"
+}
+
+// boolValue encodes b as a gauge value.
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
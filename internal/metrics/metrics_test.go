@@ -0,0 +1,140 @@
+package metrics_test
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gopost/integration/internal/config"
+	"github.com/gopost/integration/internal/connstats"
+	"github.com/gopost/integration/internal/integration"
+	"github.com/gopost/integration/internal/metrics"
+	"github.com/gopost/integration/pkg/drupal"
+)
+
+func TestPusher_Push(t *testing.T) {
+	var gotMethod, gotPath, gotBody string
+	gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		gotMethod, gotPath, gotBody = r.Method, r.URL.Path, string(body)
+	}))
+	t.Cleanup(gateway.Close)
+
+	statsd, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { _ = statsd.Close() })
+
+	status := integration.Status{
+		Health: integration.Health{Score: 0.75, Backends: integration.BackendHealth{Elasticsearch: true, Drupal: true, Redis: true}},
+		LastReport: integration.RunReport{
+			StartedAt: time.Unix(1700000000, 0),
+			Duration:  90 * time.Second,
+			Cities: []integration.CityReport{
+				{City: "sudbury_com", Found: 4, Posted: 3, Errors: 1},
+				{City: "timmins_com", Found: 2, Posted: 2},
+			},
+		},
+	}
+	pusher := metrics.NewPusher(config.MetricsPushConfig{
+		Pushgateway: gateway.URL,
+		StatsD:      statsd.LocalAddr().String(),
+		Job:         "gopost",
+		Instance:    "cron-1",
+		Timeout:     5 * time.Second,
+	}, "gopost/test")
+
+	if err := pusher.Push(context.Background(), metrics.Collect(status)); err != nil {
+		t.Fatalf("Push() error = %v", err)
+	}
+
+	if gotMethod != http.MethodPut || gotPath != "/metrics/job/gopost/instance/cron-1" {
+		t.Errorf("Pushgateway request = %s %s, want PUT /metrics/job/gopost/instance/cron-1", gotMethod, gotPath)
+	}
+	for _, want := range []string{
+		"gopost_health_score 0.75\n",
+		`gopost_last_run_articles{result="posted"} 5` + "\n",
+		`gopost_last_run_articles{result="errors"} 1` + "\n",
+		"gopost_last_run_start_timestamp_seconds 1.7e+09\n",
+		"gopost_last_run_duration_seconds 90\n",
+	} {
+		if !strings.Contains(gotBody, want) {
+			t.Errorf("Pushgateway body missing %q:\n%s", want, gotBody)
+		}
+	}
+
+	buf := make([]byte, 65536)
+	_ = statsd.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, _, err := statsd.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("read StatsD packet: %v", err)
+	}
+	packet := string(buf[:n])
+	for _, want := range []string{
+		"gopost_health_score:0.75|g|#job:gopost,instance:cron-1\n",
+		"gopost_backend_up:1|g|#job:gopost,instance:cron-1,backend:redis\n",
+		"gopost_last_run_articles:5|g|#job:gopost,instance:cron-1,result:posted\n",
+	} {
+		if !strings.Contains(packet, want) {
+			t.Errorf("StatsD packet missing %q:\n%s", want, packet)
+		}
+	}
+}
+
+func TestWriteText_ConnStatsHistograms(t *testing.T) {
+	recorder := connstats.NewRecorder()
+	recorder.Observe(drupal.ConnStats{Protocol: "HTTP/2.0", Connect: 3 * time.Millisecond, TLS: 20 * time.Millisecond, TTFB: 40 * time.Millisecond})
+	recorder.Observe(drupal.ConnStats{Protocol: "HTTP/2.0", Reused: true, TTFB: 8 * time.Millisecond})
+
+	var b strings.Builder
+	if err := metrics.WriteText(&b, metrics.Collect(integration.Status{ConnStats: recorder.Snapshot()})); err != nil {
+		t.Fatalf("WriteText() error = %v", err)
+	}
+	text := b.String()
+	for _, want := range []string{
+		"# TYPE gopost_drupal_requests_total counter\n",
+		`gopost_drupal_requests_total{connection="new",protocol="HTTP/2.0"} 1` + "\n",
+		`gopost_drupal_requests_total{connection="reused",protocol="HTTP/2.0"} 1` + "\n",
+		"# TYPE gopost_drupal_ttfb_seconds histogram\n",
+		`gopost_drupal_ttfb_seconds_bucket{le="0.01"} 1` + "\n",
+		`gopost_drupal_ttfb_seconds_bucket{le="0.05"} 2` + "\n",
+		`gopost_drupal_ttfb_seconds_bucket{le="+Inf"} 2` + "\n",
+		"gopost_drupal_ttfb_seconds_count 2\n",
+		"gopost_drupal_tls_handshake_seconds_count 1\n",
+		"gopost_drupal_dns_seconds_count 0\n",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("metrics missing %q:\n%s", want, text)
+		}
+	}
+}
+
+func TestCollect_ModerationAcceptance(t *testing.T) {
+	status := integration.Status{Moderation: []integration.ModerationCount{
+		{City: "sudbury_com", Decision: integration.ModerationEdited, Count: 4},
+		{City: "sudbury_com", Decision: integration.ModerationPublished, Count: 3},
+		{City: "sudbury_com", Decision: integration.ModerationRejected, Count: 1},
+	}}
+
+	var b strings.Builder
+	if err := metrics.WriteText(&b, metrics.Collect(status)); err != nil {
+		t.Fatalf("WriteText() error = %v", err)
+	}
+	text := b.String()
+	for _, want := range []string{
+		"# TYPE gopost_moderation_decisions_total counter\n",
+		`gopost_moderation_decisions_total{city="sudbury_com",decision="edited"} 4` + "\n",
+		`gopost_moderation_decisions_total{city="sudbury_com",decision="published"} 3` + "\n",
+		`gopost_moderation_acceptance_ratio{city="sudbury_com"} 0.75` + "\n",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("metrics missing %q:\n%s", want, text)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gopost/integration/internal/config"
+)
+
+// statsdPacketSize keeps StatsD datagrams within a typical Ethernet MTU.
+const statsdPacketSize = 1432
+
+// Pusher pushes gauges to a Prometheus Pushgateway and/or StatsD, for one-shot runs that
+// nothing scrapes.
+type Pusher struct {
+	config    config.MetricsPushConfig
+	client    *http.Client
+	userAgent string
+}
+
+// NewPusher returns a pusher for cfg. An empty userAgent keeps Go's default.
+func NewPusher(cfg config.MetricsPushConfig, userAgent string) *Pusher {
+	return &Pusher{
+		config:    cfg,
+		client:    &http.Client{Timeout: cfg.Timeout},
+		userAgent: userAgent,
+	}
+}
+
+// Push sends families to every configured destination. The Pushgateway group for the
+// configured job and instance is replaced, so series from an earlier run that are absent
+// now are dropped. The returned error joins the failures.
+func (p *Pusher) Push(ctx context.Context, families []Family) error {
+	var errs []error
+	if p.config.Pushgateway != "" {
+		if err := p.pushGateway(ctx, families); err != nil {
+			errs = append(errs, fmt.Errorf("push to Pushgateway: %w", err))
+		}
+	}
+	if p.config.StatsD != "" {
+		if err := p.pushStatsD(ctx, families); err != nil {
+			errs = append(errs, fmt.Errorf("push to StatsD: %w", err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// pushGateway PUTs the families to the group /metrics/job/<job>/instance/<instance>.
+func (p *Pusher) pushGateway(ctx context.Context, families []Family) error {
+	var body bytes.Buffer
+	if err := WriteText(&body, families); err != nil {
+		return err
+	}
+	target := strings.TrimSuffix(p.config.Pushgateway, "/") +
+		"/metrics/job/" + url.PathEscape(p.config.Job) +
+		"/instance/" + url.PathEscape(p.config.Instance)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, target, &body)
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	if p.userAgent != "" {
+		req.Header.Set("User-Agent", p.userAgent)
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("status %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+	return nil
+}
+
+// pushStatsD sends each sample as a StatsD gauge over UDP, with job, instance, and the
+// sample's labels as DogStatsD tags: gopost_health_score:0.9|g|#job:gopost,instance:host.
+func (p *Pusher) pushStatsD(ctx context.Context, families []Family) error {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "udp", p.config.StatsD)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = conn.Close() }()
+
+	var packet bytes.Buffer
+	flush := func() error {
+		if packet.Len() == 0 {
+			return nil
+		}
+		_, err := conn.Write(packet.Bytes())
+		packet.Reset()
+		return err
+	}
+	for _, family := range families {
+		for _, sample := range family.Samples {
+			tags := []string{"job:" + p.config.Job, "instance:" + p.config.Instance}
+			for _, label := range sample.Labels {
+				if label.Value != "" {
+					tags = append(tags, label.Name+":"+label.Value)
+				}
+			}
+			line := family.Name + sample.Suffix + ":" + strconv.FormatFloat(sample.Value, 'g', -1, 64) + "|g|#" + strings.Join(tags, ",")
+			if packet.Len() > 0 && packet.Len()+1+len(line) > statsdPacketSize {
+				if err := flush(); err != nil {
+					return err
+				}
+			}
+			if packet.Len() > 0 {
+				packet.WriteByte('\n')
+			}
+			packet.WriteString(line)
+		}
+	}
+	return flush()
+}
@@ -0,0 +1,281 @@
+// Package outbox implements the durable work queue that decouples article discovery
+// from posting. Discovery enqueues candidate articles; posting workers dequeue them,
+// post to Drupal, and acknowledge. Unacknowledged items survive process crashes.
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gopost/integration/internal/logger"
+	"github.com/gopost/integration/pkg/pipeline"
+	"github.com/redis/go-redis/v9"
+)
+
+// Item is a candidate article waiting to be posted.
+type Item struct {
+	City       string           `json:"city"`                  // City name, used to look up group routing
+	Article    pipeline.Article `json:"article"`               // Article to post
+	Attempts   int              `json:"attempts"`              // Failed posting attempts so far
+	EnqueuedAt time.Time        `json:"enqueued_at"`           // Time the item was first enqueued
+	QuotaGroup string           `json:"quota_group,omitempty"` // Group whose daily quota the article was counted against
+	QuotaDay   string           `json:"quota_day,omitempty"`   // Quota day counted on, released if the article is never posted
+}
+
+// Delivery is an item handed to a worker. It must be acknowledged with Ack or
+// returned with Nack.
+type Delivery struct {
+	Item    Item
+	receipt string // Backend-specific handle identifying the in-flight item
+}
+
+// Queue is a durable work queue of articles to post.
+type Queue interface {
+	// Enqueue adds an item unless the same article is already queued.
+	// It returns false if the article was already queued.
+	Enqueue(ctx context.Context, item Item) (bool, error)
+
+	// Dequeue waits up to wait for the next item. It returns nil, nil if none arrived.
+	Dequeue(ctx context.Context, wait time.Duration) (*Delivery, error)
+
+	// Ack removes a successfully processed item from the queue.
+	Ack(ctx context.Context, delivery *Delivery) error
+
+	// Nack returns a failed item to the queue for another attempt after delay, or moves it
+	// to the dead-letter list once maxAttempts is reached. It reports whether the item was
+	// dead-lettered.
+	Nack(ctx context.Context, delivery *Delivery, maxAttempts int, delay time.Duration) (bool, error)
+
+	// Requeue returns an item that could not be posted through no fault of its own, e.g.
+	// while Drupal is in maintenance, to the queue after delay without counting an attempt.
+	Requeue(ctx context.Context, delivery *Delivery, delay time.Duration) error
+
+	// Extend tells the queue the item is still being worked on, so it is not taken back
+	// as abandoned while a slow post or rate limit wait is in progress.
+	Extend(ctx context.Context, delivery *Delivery) error
+
+	// Recover returns in-flight items abandoned by a crashed worker to the queue.
+	Recover(ctx context.Context) (int, error)
+}
+
+// Redis key names used by ListQueue, after the key prefix. queuedSetKey is shared by all
+// queue backends.
+const (
+	pendingKey    = "outbox:pending"
+	processingKey = "outbox:processing"
+	deadKey       = "outbox:dead"
+	leasesKey     = "outbox:leases"
+	delayedKey    = "outbox:delayed"
+	queuedSetKey  = "outbox:queued"
+)
+
+// promoteDelayedScript moves items whose retry delay has passed (score <= ARGV[1], Unix ms)
+// from the delayed set KEYS[1] to the back of the pending list KEYS[2], at most ARGV[2] at a time.
+var promoteDelayedScript = redis.NewScript(`
+local due = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, tonumber(ARGV[2]))
+for _, payload in ipairs(due) do
+	redis.call('ZREM', KEYS[1], payload)
+	redis.call('LPUSH', KEYS[2], payload)
+end
+return #due
+`)
+
+// recoverScript returns processing items (KEYS[1]) whose lease in KEYS[3] is at least
+// ARGV[2] ms older than ARGV[1] (Unix ms) to the front of the pending list KEYS[2]. Items
+// without a lease, e.g. from a worker that crashed right after dequeueing, are leased now
+// and recovered once that lease expires.
+var recoverScript = redis.NewScript(`
+local now, visibility = tonumber(ARGV[1]), tonumber(ARGV[2])
+local recovered = 0
+for _, payload in ipairs(redis.call('LRANGE', KEYS[1], 0, -1)) do
+	local leased = redis.call('HGET', KEYS[3], payload)
+	if not leased then
+		redis.call('HSET', KEYS[3], payload, now)
+	elseif now - tonumber(leased) >= visibility then
+		redis.call('LREM', KEYS[1], 1, payload)
+		redis.call('RPUSH', KEYS[2], payload)
+		redis.call('HDEL', KEYS[3], payload)
+		recovered = recovered + 1
+	end
+end
+return recovered
+`)
+
+// extendScript refreshes the lease ARGV[1] in KEYS[1] to ARGV[2], unless it was recovered.
+var extendScript = redis.NewScript(`
+if redis.call('HEXISTS', KEYS[1], ARGV[1]) == 1 then
+	redis.call('HSET', KEYS[1], ARGV[1], ARGV[2])
+	return 1
+end
+return 0
+`)
+
+// promoteBatch bounds how many due retries one Dequeue moves back to the queue.
+const promoteBatch = 100
+
+// ListQueue is a reliable queue built on Redis lists: items move atomically from the
+// pending list to a processing list while a worker holds them (BLMOVE), so a crash
+// leaves them in the processing list for Recover. Each in-flight item has a lease that
+// its worker refreshes with Extend; Recover only takes back items whose lease is older
+// than the visibility timeout, so it is safe to run while other workers or replicas are
+// posting. Failed items wait out their retry delay in a sorted set before returning to
+// the pending list. A set of queued article IDs prevents the same article from being
+// enqueued twice by successive discovery runs.
+type ListQueue struct {
+	client     *redis.Client
+	prefix     string
+	visibility time.Duration
+	logger     logger.Logger
+}
+
+// NewListQueue returns a queue stored in Redis lists under prefix (redis.key_prefix).
+// In-flight items not extended for visibility (outbox.claim_idle) are recovered.
+func NewListQueue(client *redis.Client, prefix string, visibility time.Duration, log logger.Logger) *ListQueue {
+	return &ListQueue{
+		client:     client,
+		prefix:     prefix,
+		visibility: visibility,
+		logger:     log,
+	}
+}
+
+func (q *ListQueue) Enqueue(ctx context.Context, item Item) (bool, error) {
+	added, err := q.client.SAdd(ctx, q.prefix+queuedSetKey, item.Article.ID).Result()
+	if err != nil {
+		return false, fmt.Errorf("mark queued: %w", err)
+	}
+	if added == 0 {
+		return false, nil
+	}
+
+	if item.EnqueuedAt.IsZero() {
+		item.EnqueuedAt = time.Now()
+	}
+	payload, err := json.Marshal(item)
+	if err != nil {
+		return false, fmt.Errorf("encode item: %w", err)
+	}
+	if err := q.client.LPush(ctx, q.prefix+pendingKey, payload).Err(); err != nil {
+		// Undo the queued marker so the next discovery run can try again
+		_ = q.client.SRem(ctx, q.prefix+queuedSetKey, item.Article.ID).Err()
+		return false, fmt.Errorf("push item: %w", err)
+	}
+
+	q.logger.Debug("Article enqueued for posting",
+		logger.String("article_id", item.Article.ID),
+		logger.String("city", item.City),
+	)
+	return true, nil
+}
+
+func (q *ListQueue) Dequeue(ctx context.Context, wait time.Duration) (*Delivery, error) {
+	keys := []string{q.prefix + delayedKey, q.prefix + pendingKey}
+	if err := promoteDelayedScript.Run(ctx, q.client, keys, time.Now().UnixMilli(), promoteBatch).Err(); err != nil {
+		return nil, fmt.Errorf("promote retries: %w", err)
+	}
+
+	payload, err := q.client.BLMove(ctx, q.prefix+pendingKey, q.prefix+processingKey, "RIGHT", "LEFT", wait).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("dequeue: %w", err)
+	}
+
+	var item Item
+	if err := json.Unmarshal([]byte(payload), &item); err != nil {
+		// Drop undecodable payloads so they don't block the queue
+		_ = q.client.LRem(ctx, q.prefix+processingKey, 1, payload).Err()
+		return nil, fmt.Errorf("decode item: %w", err)
+	}
+	// A failure here leaves the item unleased; Recover leases it and takes it back later
+	if err := q.client.HSet(ctx, q.prefix+leasesKey, payload, time.Now().UnixMilli()).Err(); err != nil {
+		q.logger.Warn("Failed to lease outbox item",
+			logger.String("article_id", item.Article.ID),
+			logger.Error(err),
+		)
+	}
+	return &Delivery{Item: item, receipt: payload}, nil
+}
+
+func (q *ListQueue) Ack(ctx context.Context, delivery *Delivery) error {
+	pipe := q.client.TxPipeline()
+	pipe.LRem(ctx, q.prefix+processingKey, 1, delivery.receipt)
+	pipe.HDel(ctx, q.prefix+leasesKey, delivery.receipt)
+	pipe.SRem(ctx, q.prefix+queuedSetKey, delivery.Item.Article.ID)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("ack: %w", err)
+	}
+	return nil
+}
+
+func (q *ListQueue) Nack(ctx context.Context, delivery *Delivery, maxAttempts int, delay time.Duration) (bool, error) {
+	item := delivery.Item
+	item.Attempts++
+	payload, err := json.Marshal(item)
+	if err != nil {
+		return false, fmt.Errorf("encode item: %w", err)
+	}
+
+	dead := item.Attempts >= maxAttempts
+	pipe := q.client.TxPipeline()
+	pipe.LRem(ctx, q.prefix+processingKey, 1, delivery.receipt)
+	pipe.HDel(ctx, q.prefix+leasesKey, delivery.receipt)
+	if dead {
+		pipe.LPush(ctx, q.prefix+deadKey, payload)
+		pipe.SRem(ctx, q.prefix+queuedSetKey, item.Article.ID)
+	} else {
+		q.retry(ctx, pipe, payload, delay)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return false, fmt.Errorf("nack: %w", err)
+	}
+	return dead, nil
+}
+
+func (q *ListQueue) Requeue(ctx context.Context, delivery *Delivery, delay time.Duration) error {
+	pipe := q.client.TxPipeline()
+	pipe.LRem(ctx, q.prefix+processingKey, 1, delivery.receipt)
+	pipe.HDel(ctx, q.prefix+leasesKey, delivery.receipt)
+	q.retry(ctx, pipe, delivery.receipt, delay)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("requeue: %w", err)
+	}
+	return nil
+}
+
+// retry queues payload to be dequeued again after delay.
+func (q *ListQueue) retry(ctx context.Context, pipe redis.Pipeliner, payload any, delay time.Duration) {
+	if delay > 0 {
+		// Dequeue moves it to the back of the pending list once the delay has passed
+		pipe.ZAdd(ctx, q.prefix+delayedKey, redis.Z{Score: float64(time.Now().Add(delay).UnixMilli()), Member: payload})
+		return
+	}
+	// Requeue at the back so other items get a turn before the retry
+	pipe.LPush(ctx, q.prefix+pendingKey, payload)
+}
+
+func (q *ListQueue) Extend(ctx context.Context, delivery *Delivery) error {
+	if err := extendScript.Run(ctx, q.client, []string{q.prefix + leasesKey}, delivery.receipt, time.Now().UnixMilli()).Err(); err != nil {
+		return fmt.Errorf("extend lease: %w", err)
+	}
+	return nil
+}
+
+func (q *ListQueue) Recover(ctx context.Context) (int, error) {
+	keys := []string{q.prefix + processingKey, q.prefix + pendingKey, q.prefix + leasesKey}
+	recovered, err := recoverScript.Run(ctx, q.client, keys, time.Now().UnixMilli(), q.visibility.Milliseconds()).Int()
+	if err != nil {
+		return 0, fmt.Errorf("recover: %w", err)
+	}
+
+	if recovered > 0 {
+		q.logger.Info("Recovered in-flight outbox items",
+			logger.Int("item_count", recovered),
+		)
+	}
+	return recovered, nil
+}
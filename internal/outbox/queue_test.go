@@ -0,0 +1,194 @@
+package outbox_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gopost/integration/internal/dedup/deduptest"
+	"github.com/gopost/integration/internal/logger"
+	"github.com/gopost/integration/internal/outbox"
+	"github.com/gopost/integration/pkg/pipeline"
+)
+
+func newTestQueue(t *testing.T) (*outbox.ListQueue, *miniredis.Miniredis) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	return outbox.NewListQueue(deduptest.NewClient(t, mr), deduptest.KeyPrefix, time.Minute, logger.NewNopLogger()), mr
+}
+
+func item(id string) outbox.Item {
+	return outbox.Item{City: "sudbury_com", Article: pipeline.Article{ID: id, Title: "Title " + id}}
+}
+
+func TestListQueue_EnqueueIsIdempotent(t *testing.T) {
+	ctx := context.Background()
+	queue, _ := newTestQueue(t)
+
+	added, err := queue.Enqueue(ctx, item("a"))
+	if err != nil || !added {
+		t.Fatalf("first Enqueue() = %v, %v; want true, nil", added, err)
+	}
+	added, err = queue.Enqueue(ctx, item("a"))
+	if err != nil || added {
+		t.Fatalf("second Enqueue() = %v, %v; want false, nil", added, err)
+	}
+}
+
+func TestListQueue_FIFOAndAck(t *testing.T) {
+	ctx := context.Background()
+	queue, _ := newTestQueue(t)
+
+	for _, id := range []string{"a", "b"} {
+		if _, err := queue.Enqueue(ctx, item(id)); err != nil {
+			t.Fatalf("Enqueue(%q) error = %v", id, err)
+		}
+	}
+
+	delivery, err := queue.Dequeue(ctx, time.Second)
+	if err != nil || delivery == nil {
+		t.Fatalf("Dequeue() = %v, %v", delivery, err)
+	}
+	if delivery.Item.Article.ID != "a" {
+		t.Errorf("dequeued %q, want %q (FIFO)", delivery.Item.Article.ID, "a")
+	}
+	if delivery.Item.EnqueuedAt.IsZero() {
+		t.Error("EnqueuedAt was not set")
+	}
+	if err := queue.Ack(ctx, delivery); err != nil {
+		t.Fatalf("Ack() error = %v", err)
+	}
+
+	// Acked articles can be queued again
+	if added, _ := queue.Enqueue(ctx, item("a")); !added {
+		t.Error("Enqueue() after Ack = false, want true")
+	}
+}
+
+func TestListQueue_NackDeadLetters(t *testing.T) {
+	ctx := context.Background()
+	queue, mr := newTestQueue(t)
+
+	if _, err := queue.Enqueue(ctx, item("a")); err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+
+	const maxAttempts = 2
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		delivery, err := queue.Dequeue(ctx, time.Second)
+		if err != nil || delivery == nil {
+			t.Fatalf("attempt %d: Dequeue() = %v, %v", attempt, delivery, err)
+		}
+		dead, err := queue.Nack(ctx, delivery, maxAttempts, 0)
+		if err != nil {
+			t.Fatalf("attempt %d: Nack() error = %v", attempt, err)
+		}
+		if dead != (attempt == maxAttempts) {
+			t.Errorf("attempt %d: dead = %v", attempt, dead)
+		}
+	}
+
+	if delivery, _ := queue.Dequeue(ctx, 10*time.Millisecond); delivery != nil {
+		t.Errorf("Dequeue() after dead-letter = %+v, want nil", delivery)
+	}
+	dead, err := mr.List("gopost:outbox:dead")
+	if err != nil || len(dead) != 1 {
+		t.Errorf("dead-letter list = %v, %v; want one item", dead, err)
+	}
+}
+
+func TestListQueue_NackDelaysRetry(t *testing.T) {
+	ctx := context.Background()
+	queue, mr := newTestQueue(t)
+
+	if _, err := queue.Enqueue(ctx, item("a")); err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+	delivery, err := queue.Dequeue(ctx, time.Second)
+	if err != nil || delivery == nil {
+		t.Fatalf("Dequeue() = %v, %v", delivery, err)
+	}
+	if dead, err := queue.Nack(ctx, delivery, 5, 50*time.Millisecond); err != nil || dead {
+		t.Fatalf("Nack() = %v, %v; want false, nil", dead, err)
+	}
+
+	if delivery, _ := queue.Dequeue(ctx, 10*time.Millisecond); delivery != nil {
+		t.Errorf("Dequeue() during the retry delay = %+v, want nil", delivery)
+	}
+	if delayed, _ := mr.ZMembers("gopost:outbox:delayed"); len(delayed) != 1 {
+		t.Errorf("delayed set = %v, want the nacked item", delayed)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	delivery, err = queue.Dequeue(ctx, time.Second)
+	if err != nil || delivery == nil || delivery.Item.Article.ID != "a" || delivery.Item.Attempts != 1 {
+		t.Fatalf("Dequeue() after the retry delay = %+v, %v; want article a on attempt 1", delivery, err)
+	}
+}
+
+func TestListQueue_RequeueKeepsAttempts(t *testing.T) {
+	ctx := context.Background()
+	queue, _ := newTestQueue(t)
+
+	if _, err := queue.Enqueue(ctx, item("a")); err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+	for range 3 {
+		delivery, err := queue.Dequeue(ctx, time.Second)
+		if err != nil || delivery == nil {
+			t.Fatalf("Dequeue() = %v, %v", delivery, err)
+		}
+		if delivery.Item.Attempts != 0 {
+			t.Fatalf("requeued delivery attempts = %d, want 0", delivery.Item.Attempts)
+		}
+		if err := queue.Requeue(ctx, delivery, 0); err != nil {
+			t.Fatalf("Requeue() error = %v", err)
+		}
+	}
+	// Still queued, so discovery doesn't enqueue it twice
+	if added, _ := queue.Enqueue(ctx, item("a")); added {
+		t.Error("Enqueue() of a requeued article = true, want false")
+	}
+}
+
+func TestListQueue_RecoverInFlight(t *testing.T) {
+	ctx := context.Background()
+	mr := miniredis.RunT(t)
+	const visibility = 50 * time.Millisecond
+	queue := outbox.NewListQueue(deduptest.NewClient(t, mr), deduptest.KeyPrefix, visibility, logger.NewNopLogger())
+
+	for _, id := range []string{"crashed", "live"} {
+		if _, err := queue.Enqueue(ctx, item(id)); err != nil {
+			t.Fatalf("Enqueue(%q) error = %v", id, err)
+		}
+	}
+	// Simulate a worker that crashed after dequeueing, and one still posting
+	if delivery, err := queue.Dequeue(ctx, time.Second); err != nil || delivery == nil {
+		t.Fatalf("Dequeue() = %v, %v", delivery, err)
+	}
+	live, err := queue.Dequeue(ctx, time.Second)
+	if err != nil || live == nil {
+		t.Fatalf("Dequeue() = %v, %v", live, err)
+	}
+
+	// Neither lease has expired yet
+	if recovered, err := queue.Recover(ctx); err != nil || recovered != 0 {
+		t.Fatalf("Recover() before the visibility timeout = %d, %v; want 0, nil", recovered, err)
+	}
+
+	time.Sleep(visibility / 2)
+	if err := queue.Extend(ctx, live); err != nil {
+		t.Fatalf("Extend() error = %v", err)
+	}
+	time.Sleep(visibility/2 + 10*time.Millisecond)
+
+	recovered, err := queue.Recover(ctx)
+	if err != nil || recovered != 1 {
+		t.Fatalf("Recover() = %d, %v; want 1, nil", recovered, err)
+	}
+	delivery, err := queue.Dequeue(ctx, time.Second)
+	if err != nil || delivery == nil || delivery.Item.Article.ID != "crashed" {
+		t.Fatalf("Dequeue() after Recover = %+v, %v; want article crashed", delivery, err)
+	}
+}
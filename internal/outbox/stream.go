@@ -0,0 +1,241 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gopost/integration/internal/logger"
+	"github.com/redis/go-redis/v9"
+)
+
+// Redis key names used by StreamQueue, after the key prefix
+const (
+	streamKey     = "outbox:stream"
+	deadStreamKey = "outbox:stream:dead"
+	delayedStream = "outbox:stream:delayed"
+	streamGroup   = "gopost-posters"
+	payloadField  = "item"
+)
+
+// promoteDelayedEntriesScript adds items whose retry delay has passed (score <= ARGV[1],
+// Unix ms) from the delayed set KEYS[1] to the stream KEYS[2], at most ARGV[2] at a time.
+var promoteDelayedEntriesScript = redis.NewScript(`
+local due = redis.call('ZRANGEBYSCORE', KEYS[1], '-inf', ARGV[1], 'LIMIT', 0, tonumber(ARGV[2]))
+for _, payload in ipairs(due) do
+	redis.call('ZREM', KEYS[1], payload)
+	redis.call('XADD', KEYS[2], '*', ARGV[3], payload)
+end
+return #due
+`)
+
+// StreamQueue is a work queue built on a Redis Stream consumer group, so several gopost
+// replicas can share the posting workload. Each entry stays in the group's pending entries
+// list until acknowledged; entries held by a crashed consumer longer than claimIdle are
+// claimed by the next consumer that asks for work. Live consumers reset the idle time of
+// the entries they hold with Extend. Failed items wait out their retry delay in a sorted
+// set before being added back to the stream.
+type StreamQueue struct {
+	client    *redis.Client
+	prefix    string
+	consumer  string
+	claimIdle time.Duration
+	logger    logger.Logger
+}
+
+// NewStreamQueue returns a stream-backed queue and creates the consumer group if needed.
+// consumer must be unique per replica (e.g. hostname-pid). Keys are kept under prefix
+// (redis.key_prefix).
+func NewStreamQueue(ctx context.Context, client *redis.Client, prefix, consumer string, claimIdle time.Duration, log logger.Logger) (*StreamQueue, error) {
+	err := client.XGroupCreateMkStream(ctx, prefix+streamKey, streamGroup, "0").Err()
+	if err != nil && !strings.Contains(err.Error(), "BUSYGROUP") {
+		return nil, fmt.Errorf("create consumer group: %w", err)
+	}
+
+	return &StreamQueue{
+		client:    client,
+		prefix:    prefix,
+		consumer:  consumer,
+		claimIdle: claimIdle,
+		logger:    log.With(logger.String("consumer", consumer)),
+	}, nil
+}
+
+func (q *StreamQueue) Enqueue(ctx context.Context, item Item) (bool, error) {
+	added, err := q.client.SAdd(ctx, q.prefix+queuedSetKey, item.Article.ID).Result()
+	if err != nil {
+		return false, fmt.Errorf("mark queued: %w", err)
+	}
+	if added == 0 {
+		return false, nil
+	}
+
+	if item.EnqueuedAt.IsZero() {
+		item.EnqueuedAt = time.Now()
+	}
+	if err := q.add(ctx, q.prefix+streamKey, item); err != nil {
+		// Undo the queued marker so the next discovery run can try again
+		_ = q.client.SRem(ctx, q.prefix+queuedSetKey, item.Article.ID).Err()
+		return false, err
+	}
+
+	q.logger.Debug("Article enqueued for posting",
+		logger.String("article_id", item.Article.ID),
+		logger.String("city", item.City),
+	)
+	return true, nil
+}
+
+func (q *StreamQueue) Dequeue(ctx context.Context, wait time.Duration) (*Delivery, error) {
+	keys := []string{q.prefix + delayedStream, q.prefix + streamKey}
+	if err := promoteDelayedEntriesScript.Run(ctx, q.client, keys, time.Now().UnixMilli(), promoteBatch, payloadField).Err(); err != nil {
+		return nil, fmt.Errorf("promote retries: %w", err)
+	}
+
+	// Prefer entries abandoned by crashed consumers over new work
+	claimed, _, err := q.client.XAutoClaim(ctx, &redis.XAutoClaimArgs{
+		Stream:   q.prefix + streamKey,
+		Group:    streamGroup,
+		MinIdle:  q.claimIdle,
+		Start:    "0-0",
+		Count:    1,
+		Consumer: q.consumer,
+	}).Result()
+	if err != nil {
+		return nil, fmt.Errorf("claim pending entries: %w", err)
+	}
+	if len(claimed) > 0 {
+		q.logger.Info("Claimed outbox entry from idle consumer",
+			logger.String("entry_id", claimed[0].ID),
+		)
+		return q.delivery(ctx, claimed[0])
+	}
+
+	streams, err := q.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+		Group:    streamGroup,
+		Consumer: q.consumer,
+		Streams:  []string{q.prefix + streamKey, ">"},
+		Count:    1,
+		Block:    wait,
+	}).Result()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read group: %w", err)
+	}
+	if len(streams) == 0 || len(streams[0].Messages) == 0 {
+		return nil, nil
+	}
+	return q.delivery(ctx, streams[0].Messages[0])
+}
+
+func (q *StreamQueue) Ack(ctx context.Context, delivery *Delivery) error {
+	pipe := q.client.TxPipeline()
+	pipe.XAck(ctx, q.prefix+streamKey, streamGroup, delivery.receipt)
+	pipe.XDel(ctx, q.prefix+streamKey, delivery.receipt)
+	pipe.SRem(ctx, q.prefix+queuedSetKey, delivery.Item.Article.ID)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("ack: %w", err)
+	}
+	return nil
+}
+
+func (q *StreamQueue) Nack(ctx context.Context, delivery *Delivery, maxAttempts int, delay time.Duration) (bool, error) {
+	item := delivery.Item
+	item.Attempts++
+	payload, err := json.Marshal(item)
+	if err != nil {
+		return false, fmt.Errorf("encode item: %w", err)
+	}
+
+	dead := item.Attempts >= maxAttempts
+	pipe := q.client.TxPipeline()
+	if dead {
+		pipe.XAdd(ctx, &redis.XAddArgs{Stream: q.prefix + deadStreamKey, Values: map[string]any{payloadField: payload}})
+		pipe.SRem(ctx, q.prefix+queuedSetKey, item.Article.ID)
+	} else {
+		q.retry(ctx, pipe, payload, delay)
+	}
+	pipe.XAck(ctx, q.prefix+streamKey, streamGroup, delivery.receipt)
+	pipe.XDel(ctx, q.prefix+streamKey, delivery.receipt)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return false, fmt.Errorf("nack: %w", err)
+	}
+	return dead, nil
+}
+
+func (q *StreamQueue) Requeue(ctx context.Context, delivery *Delivery, delay time.Duration) error {
+	payload, err := json.Marshal(delivery.Item)
+	if err != nil {
+		return fmt.Errorf("encode item: %w", err)
+	}
+
+	pipe := q.client.TxPipeline()
+	q.retry(ctx, pipe, payload, delay)
+	pipe.XAck(ctx, q.prefix+streamKey, streamGroup, delivery.receipt)
+	pipe.XDel(ctx, q.prefix+streamKey, delivery.receipt)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("requeue: %w", err)
+	}
+	return nil
+}
+
+// retry queues payload to be delivered again after delay.
+func (q *StreamQueue) retry(ctx context.Context, pipe redis.Pipeliner, payload []byte, delay time.Duration) {
+	if delay > 0 {
+		// Dequeue adds it back to the end of the stream once the delay has passed
+		pipe.ZAdd(ctx, q.prefix+delayedStream, redis.Z{Score: float64(time.Now().Add(delay).UnixMilli()), Member: payload})
+		return
+	}
+	// Re-add at the end of the stream so other entries get a turn before the retry
+	pipe.XAdd(ctx, &redis.XAddArgs{Stream: q.prefix + streamKey, Values: map[string]any{payloadField: payload}})
+}
+
+// Extend resets the entry's idle time by claiming it again for this consumer, so it is
+// not claimed by another consumer while this one is still working on it.
+func (q *StreamQueue) Extend(ctx context.Context, delivery *Delivery) error {
+	err := q.client.XClaimJustID(ctx, &redis.XClaimArgs{
+		Stream:   q.prefix + streamKey,
+		Group:    streamGroup,
+		Consumer: q.consumer,
+		Messages: []string{delivery.receipt},
+	}).Err()
+	if err != nil {
+		return fmt.Errorf("extend entry %s: %w", delivery.receipt, err)
+	}
+	return nil
+}
+
+// Recover is a no-op: abandoned entries are claimed by Dequeue once they have been
+// idle for claimIdle, which is safe with several replicas running.
+func (q *StreamQueue) Recover(context.Context) (int, error) {
+	return 0, nil
+}
+
+func (q *StreamQueue) add(ctx context.Context, stream string, item Item) error {
+	payload, err := json.Marshal(item)
+	if err != nil {
+		return fmt.Errorf("encode item: %w", err)
+	}
+	if err := q.client.XAdd(ctx, &redis.XAddArgs{Stream: stream, Values: map[string]any{payloadField: payload}}).Err(); err != nil {
+		return fmt.Errorf("add entry: %w", err)
+	}
+	return nil
+}
+
+func (q *StreamQueue) delivery(ctx context.Context, message redis.XMessage) (*Delivery, error) {
+	payload, _ := message.Values[payloadField].(string)
+
+	var item Item
+	if err := json.Unmarshal([]byte(payload), &item); err != nil {
+		// Drop undecodable entries so they aren't claimed forever
+		_ = q.client.XAck(ctx, q.prefix+streamKey, streamGroup, message.ID).Err()
+		_ = q.client.XDel(ctx, q.prefix+streamKey, message.ID).Err()
+		return nil, fmt.Errorf("decode entry %s: %w", message.ID, err)
+	}
+	return &Delivery{Item: item, receipt: message.ID}, nil
+}
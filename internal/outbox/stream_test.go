@@ -0,0 +1,163 @@
+package outbox_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gopost/integration/internal/dedup/deduptest"
+	"github.com/gopost/integration/internal/logger"
+	"github.com/gopost/integration/internal/outbox"
+)
+
+func newTestStreamQueue(t *testing.T, mr *miniredis.Miniredis, consumer string) *outbox.StreamQueue {
+	t.Helper()
+	queue, err := outbox.NewStreamQueue(context.Background(), deduptest.NewClient(t, mr), deduptest.KeyPrefix, consumer, time.Minute, logger.NewNopLogger())
+	if err != nil {
+		t.Fatalf("NewStreamQueue(%q) error = %v", consumer, err)
+	}
+	return queue
+}
+
+func TestStreamQueue_SharedBetweenConsumers(t *testing.T) {
+	ctx := context.Background()
+	mr := miniredis.RunT(t)
+	first := newTestStreamQueue(t, mr, "replica-1")
+	second := newTestStreamQueue(t, mr, "replica-2")
+
+	for _, id := range []string{"a", "b"} {
+		if _, err := first.Enqueue(ctx, item(id)); err != nil {
+			t.Fatalf("Enqueue(%q) error = %v", id, err)
+		}
+	}
+	if added, _ := second.Enqueue(ctx, item("a")); added {
+		t.Error("Enqueue() of an already queued article = true, want false")
+	}
+
+	d1, err := first.Dequeue(ctx, 10*time.Millisecond)
+	if err != nil || d1 == nil {
+		t.Fatalf("first Dequeue() = %v, %v", d1, err)
+	}
+	d2, err := second.Dequeue(ctx, 10*time.Millisecond)
+	if err != nil || d2 == nil {
+		t.Fatalf("second Dequeue() = %v, %v", d2, err)
+	}
+	if d1.Item.Article.ID == d2.Item.Article.ID {
+		t.Errorf("both consumers received article %q", d1.Item.Article.ID)
+	}
+
+	for _, pair := range []struct {
+		queue    *outbox.StreamQueue
+		delivery *outbox.Delivery
+	}{{first, d1}, {second, d2}} {
+		if err := pair.queue.Ack(ctx, pair.delivery); err != nil {
+			t.Fatalf("Ack() error = %v", err)
+		}
+	}
+	if d, _ := first.Dequeue(ctx, 10*time.Millisecond); d != nil {
+		t.Errorf("Dequeue() after acking everything = %+v, want nil", d)
+	}
+}
+
+func TestStreamQueue_ClaimsFromCrashedConsumer(t *testing.T) {
+	ctx := context.Background()
+	mr := miniredis.RunT(t)
+	mr.SetTime(time.Now())
+	crashed := newTestStreamQueue(t, mr, "crashed")
+	survivor := newTestStreamQueue(t, mr, "survivor")
+
+	if _, err := crashed.Enqueue(ctx, item("a")); err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+	if d, err := crashed.Dequeue(ctx, 10*time.Millisecond); err != nil || d == nil {
+		t.Fatalf("Dequeue() = %v, %v", d, err)
+	}
+
+	// Not idle long enough yet
+	if d, _ := survivor.Dequeue(ctx, 10*time.Millisecond); d != nil {
+		t.Fatalf("Dequeue() claimed a fresh entry: %+v", d)
+	}
+
+	mr.SetTime(time.Now().Add(2 * time.Minute))
+	d, err := survivor.Dequeue(ctx, 10*time.Millisecond)
+	if err != nil || d == nil || d.Item.Article.ID != "a" {
+		t.Fatalf("Dequeue() after idle = %+v, %v; want claimed article a", d, err)
+	}
+}
+
+func TestStreamQueue_NackRetriesThenDeadLetters(t *testing.T) {
+	ctx := context.Background()
+	mr := miniredis.RunT(t)
+	queue := newTestStreamQueue(t, mr, "replica-1")
+
+	if _, err := queue.Enqueue(ctx, item("a")); err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+
+	d, _ := queue.Dequeue(ctx, 10*time.Millisecond)
+	if dead, err := queue.Nack(ctx, d, 2, 0); err != nil || dead {
+		t.Fatalf("first Nack() = %v, %v; want false, nil", dead, err)
+	}
+	d, _ = queue.Dequeue(ctx, 10*time.Millisecond)
+	if d == nil || d.Item.Attempts != 1 {
+		t.Fatalf("retried delivery = %+v, want attempts=1", d)
+	}
+	if dead, err := queue.Nack(ctx, d, 2, 0); err != nil || !dead {
+		t.Fatalf("second Nack() = %v, %v; want true, nil", dead, err)
+	}
+	if d, _ := queue.Dequeue(ctx, 10*time.Millisecond); d != nil {
+		t.Errorf("Dequeue() after dead-letter = %+v, want nil", d)
+	}
+}
+
+func TestStreamQueue_ExtendKeepsEntryFromLiveConsumer(t *testing.T) {
+	ctx := context.Background()
+	mr := miniredis.RunT(t)
+	mr.SetTime(time.Now())
+	slow := newTestStreamQueue(t, mr, "slow")
+	other := newTestStreamQueue(t, mr, "other")
+
+	if _, err := slow.Enqueue(ctx, item("a")); err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+	d, err := slow.Dequeue(ctx, 10*time.Millisecond)
+	if err != nil || d == nil {
+		t.Fatalf("Dequeue() = %v, %v", d, err)
+	}
+
+	// Still posting after claim_idle, but extending the entry as it goes
+	mr.SetTime(time.Now().Add(2 * time.Minute))
+	if err := slow.Extend(ctx, d); err != nil {
+		t.Fatalf("Extend() error = %v", err)
+	}
+	if claimed, _ := other.Dequeue(ctx, 10*time.Millisecond); claimed != nil {
+		t.Fatalf("Dequeue() claimed an extended entry: %+v", claimed)
+	}
+	if err := slow.Ack(ctx, d); err != nil {
+		t.Fatalf("Ack() error = %v", err)
+	}
+}
+
+func TestStreamQueue_NackDelaysRetry(t *testing.T) {
+	ctx := context.Background()
+	mr := miniredis.RunT(t)
+	queue := newTestStreamQueue(t, mr, "replica-1")
+
+	if _, err := queue.Enqueue(ctx, item("a")); err != nil {
+		t.Fatalf("Enqueue() error = %v", err)
+	}
+	d, _ := queue.Dequeue(ctx, 10*time.Millisecond)
+	if dead, err := queue.Nack(ctx, d, 5, 50*time.Millisecond); err != nil || dead {
+		t.Fatalf("Nack() = %v, %v; want false, nil", dead, err)
+	}
+	if d, _ := queue.Dequeue(ctx, 10*time.Millisecond); d != nil {
+		t.Errorf("Dequeue() during the retry delay = %+v, want nil", d)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	d, err := queue.Dequeue(ctx, 10*time.Millisecond)
+	if err != nil || d == nil || d.Item.Attempts != 1 {
+		t.Fatalf("Dequeue() after the retry delay = %+v, %v; want attempts=1", d, err)
+	}
+}
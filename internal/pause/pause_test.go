@@ -0,0 +1,53 @@
+package pause_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gopost/integration/internal/dedup/deduptest"
+	"github.com/gopost/integration/internal/logger"
+	"github.com/gopost/integration/internal/pause"
+)
+
+func TestSwitch(t *testing.T) {
+	mr := miniredis.RunT(t)
+	pauses := pause.NewSwitch(deduptest.NewClient(t, mr), deduptest.KeyPrefix, logger.NewNopLogger())
+	ctx := context.Background()
+
+	assertPaused := func(city string, want bool) {
+		t.Helper()
+		got, err := pauses.Paused(ctx, city)
+		if err != nil {
+			t.Fatalf("Paused(%q) error = %v", city, err)
+		}
+		if got != want {
+			t.Errorf("Paused(%q) = %v, want %v", city, got, want)
+		}
+	}
+
+	assertPaused("", false)
+	assertPaused("sudbury_com", false)
+
+	if err := pauses.Pause(ctx, "sudbury_com", ""); err != nil {
+		t.Fatalf("Pause() error = %v", err)
+	}
+	assertPaused("sudbury_com", true)
+	assertPaused("toronto_com", false)
+	assertPaused("", false)
+
+	// An operator pausing everything by hand
+	if err := mr.Set(deduptest.KeyPrefix+pause.GlobalKey, "maintenance"); err != nil {
+		t.Fatal(err)
+	}
+	assertPaused("", true)
+	assertPaused("toronto_com", true)
+
+	if err := pauses.Resume(ctx, ""); err != nil {
+		t.Fatalf("Resume() error = %v", err)
+	}
+	if err := pauses.Resume(ctx, "sudbury_com"); err != nil {
+		t.Fatalf("Resume() error = %v", err)
+	}
+	assertPaused("sudbury_com", false)
+}
@@ -0,0 +1,75 @@
+// Package ping reports run starts, successes, and failures to dead man's switch monitors
+// such as healthchecks.io, which alert when the expected pings stop arriving.
+package ping
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Pinger sends healthchecks.io style pings: GET <url>/start when a run starts, GET <url>
+// when it succeeds, and GET <url>/fail when it fails.
+type Pinger struct {
+	url       *url.URL
+	client    *http.Client
+	userAgent string
+}
+
+// NewPinger returns a pinger for the check at pingURL. An empty userAgent keeps Go's
+// default.
+func NewPinger(pingURL string, timeout time.Duration, userAgent string) (*Pinger, error) {
+	u, err := url.Parse(pingURL)
+	if err != nil {
+		return nil, fmt.Errorf("parse ping URL: %w", err)
+	}
+	return &Pinger{
+		url:       u,
+		client:    &http.Client{Timeout: timeout},
+		userAgent: userAgent,
+	}, nil
+}
+
+// Start reports that a run started, so the monitor can also alert on runs that never
+// finish and measure run durations.
+func (p *Pinger) Start(ctx context.Context) error {
+	return p.ping(ctx, "start")
+}
+
+// Success reports that a run completed successfully.
+func (p *Pinger) Success(ctx context.Context) error {
+	return p.ping(ctx, "")
+}
+
+// Fail reports that a run failed, alerting without waiting for the grace period.
+func (p *Pinger) Fail(ctx context.Context) error {
+	return p.ping(ctx, "fail")
+}
+
+// ping sends a GET to the ping URL with suffix appended to its path, keeping its query.
+func (p *Pinger) ping(ctx context.Context, suffix string) error {
+	target := p.url
+	if suffix != "" {
+		target = p.url.JoinPath(suffix)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target.String(), nil)
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	if p.userAgent != "" {
+		req.Header.Set("User-Agent", p.userAgent)
+	}
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 512))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("status %d", resp.StatusCode)
+	}
+	return nil
+}
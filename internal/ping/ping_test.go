@@ -0,0 +1,57 @@
+package ping_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gopost/integration/internal/ping"
+)
+
+func TestPinger(t *testing.T) {
+	var mu sync.Mutex
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests = append(requests, r.Method+" "+r.URL.RequestURI()+" "+r.UserAgent())
+		mu.Unlock()
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	pinger, err := ping.NewPinger(server.URL+"/check?create=1", time.Second, "gopost/test")
+	if err != nil {
+		t.Fatalf("NewPinger() error = %v", err)
+	}
+	ctx := context.Background()
+	for name, send := range map[string]func(context.Context) error{
+		"Start": pinger.Start, "Success": pinger.Success, "Fail": pinger.Fail,
+	} {
+		if err := send(ctx); err != nil {
+			t.Errorf("%s() error = %v", name, err)
+		}
+	}
+	slices.Sort(requests)
+	want := []string{
+		"GET /check/fail?create=1 gopost/test",
+		"GET /check/start?create=1 gopost/test",
+		"GET /check?create=1 gopost/test",
+	}
+	if !slices.Equal(requests, want) {
+		t.Errorf("requests = %q, want %q", requests, want)
+	}
+
+	missing, err := ping.NewPinger(server.URL+"/missing", time.Second, "")
+	if err != nil {
+		t.Fatalf("NewPinger() error = %v", err)
+	}
+	if err := missing.Success(ctx); err == nil {
+		t.Error("Success() error = nil for a 404 response, want error")
+	}
+}
@@ -0,0 +1,134 @@
+// Package quota counts the articles posted to each Drupal group per day in Redis, so group
+// quotas hold across restarts and replicas, and keeps each city's articles deferred by a
+// full quota.
+package quota
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/gopost/integration/pkg/pipeline"
+	"github.com/redis/go-redis/v9"
+)
+
+// KeyPrefix, after the key prefix, starts the per-group, per-day post counters
+// ("gopost:quota:{group}:{day}" with the default key prefix) and the per-city hashes of
+// deferred articles ("gopost:quota:deferred:{city}").
+const KeyPrefix = "quota:"
+
+// counterTTL keeps a day's counter past the day itself, whatever the rollover time.
+const counterTTL = 48 * time.Hour
+
+// takeScript increments the counter unless it has reached the limit in ARGV[1].
+var takeScript = redis.NewScript(`
+local used = tonumber(redis.call("GET", KEYS[1]) or "0")
+if used >= tonumber(ARGV[1]) then
+	return 0
+end
+redis.call("INCR", KEYS[1])
+redis.call("EXPIRE", KEYS[1], ARGV[2])
+return 1
+`)
+
+// Store reads and updates the counters and deferred articles.
+type Store struct {
+	client    *redis.Client
+	keyPrefix string // KeyPrefix after the key prefix
+}
+
+// NewStore returns a store backed by client, keeping its data under prefix (redis.key_prefix).
+func NewStore(client *redis.Client, prefix string) *Store {
+	return &Store{client: client, keyPrefix: prefix + KeyPrefix}
+}
+
+// Day returns the quota day containing t: its date in loc once the rollover offset (time
+// since local midnight) is subtracted. With a 06:00 rollover, 05:30 counts toward the day before.
+func Day(t time.Time, loc *time.Location, rollover time.Duration) string {
+	return t.In(loc).Add(-rollover).Format(time.DateOnly)
+}
+
+func (s *Store) counterKey(group, day string) string {
+	return s.keyPrefix + group + ":" + day
+}
+
+func (s *Store) deferredKey(city string) string {
+	return s.keyPrefix + "deferred:" + city
+}
+
+// Take counts one post to group on day, unless limit posts were already counted.
+// It reports whether the post fits the quota.
+func (s *Store) Take(ctx context.Context, group, day string, limit int) (bool, error) {
+	taken, err := takeScript.Run(ctx, s.client, []string{s.counterKey(group, day)}, limit, int(counterTTL.Seconds())).Int()
+	if err != nil {
+		return false, fmt.Errorf("take quota of %s: %w", group, err)
+	}
+	return taken == 1, nil
+}
+
+// Release gives back a post taken on day that did not happen.
+func (s *Store) Release(ctx context.Context, group, day string) error {
+	if err := s.client.Decr(ctx, s.counterKey(group, day)).Err(); err != nil {
+		return fmt.Errorf("release quota of %s: %w", group, err)
+	}
+	return nil
+}
+
+// Defer keeps an article of city that did not fit its group's quota for a later run.
+func (s *Store) Defer(ctx context.Context, city string, article pipeline.Article) error {
+	encoded, err := json.Marshal(article)
+	if err != nil {
+		return fmt.Errorf("encode article %s: %w", article.ID, err)
+	}
+	if err := s.client.HSet(ctx, s.deferredKey(city), article.ID, encoded).Err(); err != nil {
+		return fmt.Errorf("defer article %s: %w", article.ID, err)
+	}
+	return nil
+}
+
+// Deferred returns the articles deferred for city, oldest first. They stay deferred until
+// removed with Undefer, so articles a run does not get to are kept for the next one.
+// Articles that cannot be decoded are dropped and reported in the error.
+func (s *Store) Deferred(ctx context.Context, city string) ([]pipeline.Article, error) {
+	key := s.deferredKey(city)
+	values, err := s.client.HGetAll(ctx, key).Result()
+	if err != nil {
+		return nil, fmt.Errorf("load deferred articles of %s: %w", city, err)
+	}
+
+	articles := make([]pipeline.Article, 0, len(values))
+	var decodeErr error
+	var undecodable []string
+	for id, encoded := range values {
+		var article pipeline.Article
+		if err := json.Unmarshal([]byte(encoded), &article); err != nil {
+			decodeErr = errors.Join(decodeErr, fmt.Errorf("decode deferred article %s: %w", id, err))
+			undecodable = append(undecodable, id)
+			continue
+		}
+		articles = append(articles, article)
+	}
+	slices.SortFunc(articles, func(a, b pipeline.Article) int {
+		return cmp.Or(a.PublishedAt.Compare(b.PublishedAt), cmp.Compare(a.ID, b.ID))
+	})
+	if len(undecodable) > 0 {
+		decodeErr = errors.Join(decodeErr, s.Undefer(ctx, city, undecodable...))
+	}
+	return articles, decodeErr
+}
+
+// Undefer forgets deferred articles of city once they are posted, dropped, or no longer
+// need a later run.
+func (s *Store) Undefer(ctx context.Context, city string, ids ...string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	if err := s.client.HDel(ctx, s.deferredKey(city), ids...).Err(); err != nil {
+		return fmt.Errorf("undefer articles of %s: %w", city, err)
+	}
+	return nil
+}
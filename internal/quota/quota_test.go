@@ -0,0 +1,88 @@
+package quota_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gopost/integration/internal/dedup/deduptest"
+	"github.com/gopost/integration/internal/quota"
+	"github.com/gopost/integration/pkg/pipeline"
+)
+
+func TestDay(t *testing.T) {
+	toronto, err := time.LoadLocation("America/Toronto")
+	if err != nil {
+		t.Skipf("time zone data unavailable: %v", err)
+	}
+	rollover := 6 * time.Hour
+
+	tests := []struct {
+		at   time.Time
+		want string
+	}{
+		{at: time.Date(2025, 3, 10, 9, 59, 0, 0, time.UTC), want: "2025-03-09"}, // 05:59 EDT
+		{at: time.Date(2025, 3, 10, 10, 0, 0, 0, time.UTC), want: "2025-03-10"}, // 06:00 EDT starts the day
+		{at: time.Date(2025, 3, 11, 3, 0, 0, 0, time.UTC), want: "2025-03-10"},  // 23:00 local
+	}
+	for _, tt := range tests {
+		if got := quota.Day(tt.at, toronto, rollover); got != tt.want {
+			t.Errorf("Day(%v) = %s, want %s", tt.at, got, tt.want)
+		}
+	}
+}
+
+func TestStore(t *testing.T) {
+	mr := miniredis.RunT(t)
+	store := quota.NewStore(deduptest.NewClient(t, mr), deduptest.KeyPrefix)
+	ctx := context.Background()
+
+	take := func(day string) bool {
+		t.Helper()
+		ok, err := store.Take(ctx, "group-1", day, 2)
+		if err != nil {
+			t.Fatalf("Take() error = %v", err)
+		}
+		return ok
+	}
+
+	if !take("2025-03-10") || !take("2025-03-10") {
+		t.Fatal("first two posts of the day should fit a quota of 2")
+	}
+	if take("2025-03-10") {
+		t.Error("third post of the day fits a quota of 2")
+	}
+	if err := store.Release(ctx, "group-1", "2025-03-10"); err != nil {
+		t.Fatalf("Release() error = %v", err)
+	}
+	if !take("2025-03-10") {
+		t.Error("post after a release should fit")
+	}
+	if !take("2025-03-11") {
+		t.Error("first post of the next day should fit")
+	}
+
+	older := pipeline.Article{ID: "b", Title: "Older", PublishedAt: time.Date(2025, 3, 9, 8, 0, 0, 0, time.UTC)}
+	newer := pipeline.Article{ID: "a", Title: "Newer", PublishedAt: time.Date(2025, 3, 10, 8, 0, 0, 0, time.UTC)}
+	for _, article := range []pipeline.Article{newer, older, newer} {
+		if err := store.Defer(ctx, "sudbury_com", article); err != nil {
+			t.Fatalf("Defer() error = %v", err)
+		}
+	}
+	deferred, err := store.Deferred(ctx, "sudbury_com")
+	if err != nil {
+		t.Fatalf("Deferred() error = %v", err)
+	}
+	if len(deferred) != 2 || deferred[0].ID != "b" || deferred[1].ID != "a" || !deferred[0].PublishedAt.Equal(older.PublishedAt) {
+		t.Errorf("Deferred() = %+v, want b then a", deferred)
+	}
+
+	// Reading them doesn't forget them; Undefer does
+	if err := store.Undefer(ctx, "sudbury_com", "b"); err != nil {
+		t.Fatalf("Undefer() error = %v", err)
+	}
+	if deferred, _ := store.Deferred(ctx, "sudbury_com"); len(deferred) != 1 || deferred[0].ID != "a" {
+		t.Errorf("Deferred() after Undefer = %+v, want only a", deferred)
+	}
+}
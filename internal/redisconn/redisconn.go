@@ -0,0 +1,105 @@
+// Package redisconn builds go-redis client options from the redis section of the
+// configuration: address and ACL credentials, TLS, the connection pool, and the proxy.
+package redisconn
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"os"
+
+	"github.com/gopost/integration/internal/config"
+	"github.com/gopost/integration/internal/netproxy"
+	"github.com/redis/go-redis/v9"
+)
+
+// Options returns the client options for cfg. It reads the TLS certificate files, so
+// errors are reported before the first connection.
+func Options(cfg config.RedisConfig) (*redis.Options, error) {
+	opts := &redis.Options{
+		Addr:            cfg.URL,
+		Username:        cfg.Username,
+		Password:        cfg.Password,
+		DB:              cfg.DB,
+		PoolSize:        cfg.Pool.Size,
+		MinIdleConns:    cfg.Pool.MinIdle,
+		MaxIdleConns:    cfg.Pool.MaxIdle,
+		ConnMaxIdleTime: cfg.Pool.IdleTimeout,
+		ConnMaxLifetime: cfg.Pool.MaxLifetime,
+		PoolTimeout:     cfg.Pool.Timeout,
+	}
+	if cfg.TLS.Enabled {
+		tlsConfig, err := TLSConfig(cfg.TLS, cfg.URL)
+		if err != nil {
+			return nil, fmt.Errorf("redis tls: %w", err)
+		}
+		opts.TLSConfig = tlsConfig
+	}
+
+	dial, err := netproxy.Dialer(cfg.Proxy)
+	if err != nil {
+		return nil, fmt.Errorf("redis proxy: %w", err)
+	}
+	switch {
+	case dial != nil && opts.TLSConfig != nil:
+		// go-redis only adds TLS to its own dialer, so a proxied connection is wrapped here
+		opts.Dialer = tlsDialer(dial, opts.TLSConfig)
+	case dial != nil:
+		opts.Dialer = dial
+	}
+	return opts, nil
+}
+
+// TLSConfig returns the client TLS configuration for reaching the Redis server at addr.
+func TLSConfig(cfg config.RedisTLSConfig, addr string) (*tls.Config, error) {
+	serverName := cfg.ServerName
+	if serverName == "" {
+		host, _, err := net.SplitHostPort(addr)
+		if err != nil {
+			host = addr
+		}
+		serverName = host
+	}
+	tlsConfig := &tls.Config{
+		ServerName:         serverName,
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: cfg.SkipVerify, //nolint:gosec // Opt-in for development servers
+	}
+	if cfg.CAFile != "" {
+		caPEM, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("read CA: %w", err)
+		}
+		rootCAs := x509.NewCertPool()
+		if !rootCAs.AppendCertsFromPEM(caPEM) {
+			return nil, fmt.Errorf("CA %s: no certificates found", cfg.CAFile)
+		}
+		tlsConfig.RootCAs = rootCAs
+	}
+	if cfg.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return tlsConfig, nil
+}
+
+// tlsDialer runs the TLS handshake over connections opened by dial.
+func tlsDialer(dial netproxy.DialContextFunc, tlsConfig *tls.Config) netproxy.DialContextFunc {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := dial(ctx, network, addr)
+		if err != nil {
+			return nil, err
+		}
+		tlsConn := tls.Client(conn, tlsConfig)
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			_ = conn.Close()
+			return nil, fmt.Errorf("tls handshake with %s: %w", addr, err)
+		}
+		return tlsConn, nil
+	}
+}
@@ -0,0 +1,208 @@
+package redisconn_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gopost/integration/internal/config"
+	"github.com/gopost/integration/internal/redisconn"
+	"github.com/redis/go-redis/v9"
+)
+
+// testCerts writes a CA and a server certificate for 127.0.0.1 signed by it, and returns
+// the server's TLS configuration and the CA file.
+func testCerts(t *testing.T) (*tls.Config, string) {
+	t.Helper()
+	dir := t.TempDir()
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "gopost test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caCert, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caFile := filepath.Join(dir, "ca.pem")
+	if err := os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "redis"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, caCert, &key.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	serverTLS := &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{der}, PrivateKey: key}},
+		MinVersion:   tls.VersionTLS12,
+	}
+	return serverTLS, caFile
+}
+
+// connectProxy is an HTTP proxy that only tunnels CONNECT requests.
+func connectProxy(t *testing.T) string {
+	t.Helper()
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodConnect {
+			http.Error(w, "CONNECT only", http.StatusMethodNotAllowed)
+			return
+		}
+		backend, err := net.Dial("tcp", r.Host)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		defer backend.Close()
+		conn, buf, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		_, _ = conn.Write([]byte("HTTP/1.1 200 Connection established\r\n\r\n"))
+		go func() { _, _ = io.Copy(backend, buf) }()
+		_, _ = io.Copy(conn, backend)
+	}))
+	t.Cleanup(proxy.Close)
+	return "http://" + proxy.Listener.Addr().String()
+}
+
+func TestOptions_TLSAndACLUser(t *testing.T) {
+	serverTLS, caFile := testCerts(t)
+	mr, err := miniredis.RunTLS(serverTLS)
+	if err != nil {
+		t.Fatalf("RunTLS() error = %v", err)
+	}
+	defer mr.Close()
+	mr.RequireUserAuth("gopost", "secret")
+
+	for _, proxy := range []string{"", connectProxy(t)} {
+		name := "direct"
+		if proxy != "" {
+			name = "proxied"
+		}
+		t.Run(name, func(t *testing.T) {
+			opts, err := redisconn.Options(config.RedisConfig{
+				URL:      mr.Addr(),
+				Username: "gopost",
+				Password: "secret",
+				Proxy:    proxy,
+				TLS:      config.RedisTLSConfig{Enabled: true, CAFile: caFile},
+				Pool:     config.RedisPoolConfig{Size: 3, MinIdle: 1, IdleTimeout: time.Minute},
+			})
+			if err != nil {
+				t.Fatalf("Options() error = %v", err)
+			}
+			if opts.PoolSize != 3 || opts.MinIdleConns != 1 || opts.ConnMaxIdleTime != time.Minute {
+				t.Errorf("pool options = %d/%d/%v, want 3/1/1m", opts.PoolSize, opts.MinIdleConns, opts.ConnMaxIdleTime)
+			}
+			client := redis.NewClient(opts)
+			defer client.Close()
+			if err := client.Ping(context.Background()).Err(); err != nil {
+				t.Errorf("Ping() error = %v", err)
+			}
+		})
+	}
+
+	// Without the CA the self-signed server is rejected, and without TLS the server hangs up
+	for name, tlsCfg := range map[string]config.RedisTLSConfig{
+		"unknown CA": {Enabled: true},
+		"plain text": {},
+	} {
+		opts, err := redisconn.Options(config.RedisConfig{URL: mr.Addr(), Username: "gopost", Password: "secret", TLS: tlsCfg})
+		if err != nil {
+			t.Fatalf("Options(%s) error = %v", name, err)
+		}
+		opts.MaxRetries = -1
+		client := redis.NewClient(opts)
+		if err := client.Ping(context.Background()).Err(); err == nil {
+			t.Errorf("Ping() with %s error = nil, want error", name)
+		}
+		_ = client.Close()
+	}
+}
+
+func TestOptions_TLSFileErrors(t *testing.T) {
+	empty := filepath.Join(t.TempDir(), "empty.pem")
+	if err := os.WriteFile(empty, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		tls     config.RedisTLSConfig
+		wantErr string
+	}{
+		{name: "missing CA", tls: config.RedisTLSConfig{Enabled: true, CAFile: "/nonexistent/ca.pem"}, wantErr: "read CA"},
+		{name: "empty CA", tls: config.RedisTLSConfig{Enabled: true, CAFile: empty}, wantErr: "no certificates found"},
+		{name: "bad client certificate", tls: config.RedisTLSConfig{Enabled: true, CertFile: empty, KeyFile: empty}, wantErr: "load client certificate"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := redisconn.Options(config.RedisConfig{URL: "localhost:6379", TLS: tt.tls})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Options() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTLSConfig_ServerName(t *testing.T) {
+	for _, tt := range []struct {
+		tls  config.RedisTLSConfig
+		addr string
+		want string
+	}{
+		{addr: "redis.example.com:6380", want: "redis.example.com"},
+		{addr: "redis.example.com", want: "redis.example.com"},
+		{tls: config.RedisTLSConfig{ServerName: "cache.internal"}, addr: "10.0.0.5:6380", want: "cache.internal"},
+	} {
+		got, err := redisconn.TLSConfig(tt.tls, tt.addr)
+		if err != nil {
+			t.Fatalf("TLSConfig(%q) error = %v", tt.addr, err)
+		}
+		if got.ServerName != tt.want {
+			t.Errorf("TLSConfig(%q).ServerName = %q, want %q", tt.addr, got.ServerName, tt.want)
+		}
+	}
+}
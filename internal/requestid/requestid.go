@@ -0,0 +1,21 @@
+// Package requestid generates the X-Request-ID values gopost attaches to outgoing
+// HTTP requests, so a request can be matched between gopost's logs and the
+// receiving system's logs.
+package requestid
+
+import (
+	"crypto/rand"
+	"fmt"
+)
+
+// Header is the HTTP header carrying the request ID.
+const Header = "X-Request-ID"
+
+// New returns a random RFC 4122 version 4 UUID.
+func New() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:]) // crypto/rand.Read never returns an error
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
@@ -0,0 +1,56 @@
+// Package runtoken remembers the articles handled by a run triggered with a run token, so
+// an orchestrator retrying the run after a partial failure only has the remainder handled.
+package runtoken
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// KeyPrefix, after the key prefix, starts the set of processed article IDs kept per run token.
+const KeyPrefix = "run:"
+
+// Store reads and updates the processed sets. Each set expires ttl after its last update;
+// a zero ttl keeps them.
+type Store struct {
+	client    *redis.Client
+	keyPrefix string // KeyPrefix after the key prefix
+	ttl       time.Duration
+}
+
+// NewStore returns a store backed by client, keeping its sets under prefix (redis.key_prefix).
+func NewStore(client *redis.Client, prefix string, ttl time.Duration) *Store {
+	return &Store{client: client, keyPrefix: prefix + KeyPrefix, ttl: ttl}
+}
+
+// Processed returns the IDs of the articles processed by earlier runs with token.
+func (s *Store) Processed(ctx context.Context, token string) (map[string]bool, error) {
+	ids, err := s.client.SMembers(ctx, s.keyPrefix+token).Result()
+	if err != nil {
+		return nil, fmt.Errorf("load run %s: %w", token, err)
+	}
+	processed := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		processed[id] = true
+	}
+	return processed, nil
+}
+
+// Add records that the run with token processed the article.
+func (s *Store) Add(ctx context.Context, token, articleID string) error {
+	key := s.keyPrefix + token
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.SAdd(ctx, key, articleID)
+		if s.ttl > 0 {
+			pipe.Expire(ctx, key, s.ttl)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("record article %s in run %s: %w", articleID, token, err)
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package runtoken_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gopost/integration/internal/dedup/deduptest"
+	"github.com/gopost/integration/internal/runtoken"
+)
+
+func TestStore(t *testing.T) {
+	mr := miniredis.RunT(t)
+	store := runtoken.NewStore(deduptest.NewClient(t, mr), deduptest.KeyPrefix, 30*time.Minute)
+	ctx := context.Background()
+
+	for _, id := range []string{"a", "b", "a"} {
+		if err := store.Add(ctx, "nightly-42", id); err != nil {
+			t.Fatalf("Add(%q) error = %v", id, err)
+		}
+	}
+	if err := store.Add(ctx, "nightly-43", "c"); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+
+	processed, err := store.Processed(ctx, "nightly-42")
+	if err != nil {
+		t.Fatalf("Processed() error = %v", err)
+	}
+	if len(processed) != 2 || !processed["a"] || !processed["b"] {
+		t.Errorf("Processed() = %v, want a and b", processed)
+	}
+
+	// A retry after the TTL starts over
+	mr.FastForward(31 * time.Minute)
+	processed, err = store.Processed(ctx, "nightly-42")
+	if err != nil {
+		t.Fatalf("Processed() after TTL error = %v", err)
+	}
+	if len(processed) != 0 {
+		t.Errorf("Processed() after TTL = %v, want empty", processed)
+	}
+}
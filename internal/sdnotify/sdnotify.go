@@ -0,0 +1,85 @@
+// Package sdnotify implements the systemd notify protocol (sd_notify) so a Type=notify
+// unit can tell when gopost is ready and restart it when its watchdog stops being fed.
+package sdnotify
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/gopost/integration/internal/logger"
+)
+
+// Notification states understood by systemd.
+const (
+	Ready    = "READY=1"
+	Stopping = "STOPPING=1"
+	Watchdog = "WATCHDOG=1"
+)
+
+// Notify sends state to the socket in NOTIFY_SOCKET. It returns false, nil when not
+// running under systemd (the variable is unset).
+func Notify(state string) (bool, error) {
+	socketPath := os.Getenv("NOTIFY_SOCKET")
+	if socketPath == "" {
+		return false, nil
+	}
+	// A leading "@" denotes a Linux abstract socket
+	if socketPath[0] == '@' {
+		socketPath = "\x00" + socketPath[1:]
+	}
+
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socketPath, Net: "unixgram"})
+	if err != nil {
+		return false, fmt.Errorf("dial notify socket: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.Write([]byte(state)); err != nil {
+		return false, fmt.Errorf("write notify socket: %w", err)
+	}
+	return true, nil
+}
+
+// WatchdogInterval returns the watchdog timeout systemd expects to be fed within
+// (WatchdogSec=), or false if the watchdog is not enabled for this process.
+func WatchdogInterval() (time.Duration, bool) {
+	usec, err := strconv.ParseInt(os.Getenv("WATCHDOG_USEC"), 10, 64)
+	if err != nil || usec <= 0 {
+		return 0, false
+	}
+	if pid := os.Getenv("WATCHDOG_PID"); pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		return 0, false
+	}
+	return time.Duration(usec) * time.Microsecond, true
+}
+
+// RunWatchdog feeds the watchdog at half of interval for as long as healthy reports true,
+// until ctx is done. Once healthy turns false the pings stop, so systemd restarts the
+// service when interval elapses.
+func RunWatchdog(ctx context.Context, interval time.Duration, healthy func() bool, log logger.Logger) {
+	ticker := time.NewTicker(interval / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if !healthy() {
+				log.Warn("Skipping systemd watchdog ping: no run completed recently",
+					logger.Duration("watchdog_interval", interval),
+				)
+				continue
+			}
+			if _, err := Notify(Watchdog); err != nil {
+				log.Warn("Failed to ping systemd watchdog",
+					logger.Error(err),
+				)
+			}
+		}
+	}
+}
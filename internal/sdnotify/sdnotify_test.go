@@ -0,0 +1,75 @@
+package sdnotify_test
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gopost/integration/internal/sdnotify"
+)
+
+func TestNotify(t *testing.T) {
+	t.Setenv("NOTIFY_SOCKET", "")
+	if sent, err := sdnotify.Notify(sdnotify.Ready); sent || err != nil {
+		t.Fatalf("Notify() without NOTIFY_SOCKET = %v, %v; want false, nil", sent, err)
+	}
+
+	// Unix socket paths are length-limited, so avoid the long t.TempDir path
+	dir, err := os.MkdirTemp("", "sd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	socketPath := filepath.Join(dir, "notify")
+
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socketPath, Net: "unixgram"})
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	t.Setenv("NOTIFY_SOCKET", socketPath)
+
+	sent, err := sdnotify.Notify(sdnotify.Ready)
+	if !sent || err != nil {
+		t.Fatalf("Notify() = %v, %v; want true, nil", sent, err)
+	}
+
+	buf := make([]byte, 64)
+	_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+	n, err := conn.Read(buf)
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if got := string(buf[:n]); got != sdnotify.Ready {
+		t.Errorf("received %q, want %q", got, sdnotify.Ready)
+	}
+}
+
+func TestWatchdogInterval(t *testing.T) {
+	tests := []struct {
+		name   string
+		usec   string
+		pid    string
+		want   time.Duration
+		wantOK bool
+	}{
+		{name: "unset"},
+		{name: "enabled", usec: "30000000", want: 30 * time.Second, wantOK: true},
+		{name: "this process", usec: "1000000", pid: strconv.Itoa(os.Getpid()), want: time.Second, wantOK: true},
+		{name: "other process", usec: "1000000", pid: "1"},
+		{name: "invalid", usec: "soon"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("WATCHDOG_USEC", tt.usec)
+			t.Setenv("WATCHDOG_PID", tt.pid)
+			got, ok := sdnotify.WatchdogInterval()
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("WatchdogInterval() = %v, %v; want %v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
@@ -9,12 +9,14 @@ import (
 
 	"github.com/gopost/integration/internal/config"
 	"github.com/gopost/integration/internal/logger"
+	"github.com/gopost/integration/internal/requestid"
 )
 
 type Client struct {
-	url     string
-	timeout time.Duration
-	logger  logger.Logger
+	url       string
+	timeout   time.Duration
+	userAgent string
+	logger    logger.Logger
 }
 
 type CitiesResponse struct {
@@ -28,11 +30,13 @@ type City struct {
 	GroupID string `json:"group_id,omitempty"`
 }
 
-func NewClient(cfg *config.SourcesConfig, log logger.Logger) *Client {
+// NewClient creates a sources service client. An empty userAgent keeps Go's default.
+func NewClient(cfg *config.SourcesConfig, userAgent string, log logger.Logger) *Client {
 	return &Client{
-		url:     cfg.URL,
-		timeout: cfg.Timeout,
-		logger:  log,
+		url:       cfg.URL,
+		timeout:   cfg.Timeout,
+		userAgent: userAgent,
+		logger:    log,
 	}
 }
 
@@ -45,6 +49,11 @@ func (c *Client) GetCities(ctx context.Context) ([]config.CityConfig, error) {
 	if err != nil {
 		return nil, fmt.Errorf("create request: %w", err)
 	}
+	if c.userAgent != "" {
+		req.Header.Set("User-Agent", c.userAgent)
+	}
+	requestID := requestid.New()
+	req.Header.Set(requestid.Header, requestID)
 
 	client := &http.Client{
 		Timeout: c.timeout,
@@ -57,6 +66,7 @@ func (c *Client) GetCities(ctx context.Context) ([]config.CityConfig, error) {
 	if err != nil {
 		c.logger.Warn("Failed to fetch cities from sources service",
 			logger.String("url", url),
+			logger.String("request_id", requestID),
 			logger.Duration("duration", duration),
 			logger.Error(err),
 		)
@@ -67,6 +77,7 @@ func (c *Client) GetCities(ctx context.Context) ([]config.CityConfig, error) {
 	if resp.StatusCode != http.StatusOK {
 		c.logger.Warn("Sources service returned non-OK status",
 			logger.String("url", url),
+			logger.String("request_id", requestID),
 			logger.Int("status_code", resp.StatusCode),
 			logger.Duration("duration", duration),
 		)
@@ -0,0 +1,145 @@
+// Package timeline records when each article reached each processing stage in Redis, so
+// "gopost trace" can show why a story appeared late.
+package timeline
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// KeyPrefix, after the key prefix, starts the hash of each article's stages:
+// gopost:timeline:<article_id> with the default key prefix.
+const KeyPrefix = "timeline:"
+
+// Processing stages, in pipeline order.
+const (
+	StagePublished  = "published"  // The article's published date
+	StageFound      = "found"      // A city search first returned it
+	StageClassified = "classified" // It first passed the keyword and severity filters and was not yet posted
+	StageSkipped    = "skipped"    // It was last skipped after classification; Detail has the reason
+	StageQueued     = "queued"     // It was handed to the outbox
+	StageFailed     = "failed"     // Its last post failed; Detail has the error
+	StagePosted     = "posted"     // Drupal created the node; Detail has its UUID
+	StageMarked     = "marked"     // It was recorded as posted in the dedup store
+)
+
+// stageOrder orders events recorded at the same time.
+var stageOrder = []string{StagePublished, StageFound, StageClassified, StageSkipped, StageQueued, StageFailed, StagePosted, StageMarked}
+
+// firstOnly are the stages whose first time is kept; the others keep the latest.
+var firstOnly = []string{StagePublished, StageFound, StageClassified, StageQueued}
+
+// Event is one stage an article reached.
+type Event struct {
+	Stage  string    `json:"-"`
+	At     time.Time `json:"at"`
+	City   string    `json:"city,omitempty"`
+	Detail string    `json:"detail,omitempty"`
+}
+
+// Entry is an event of one article.
+type Entry struct {
+	ArticleID string
+	Event
+}
+
+// Store records and reads article timelines. Each timeline expires ttl after its last
+// recorded event.
+type Store struct {
+	client    *redis.Client
+	keyPrefix string // KeyPrefix after the key prefix
+	ttl       time.Duration
+}
+
+// NewStore returns a store backed by client, keeping timelines under prefix (redis.key_prefix).
+func NewStore(client *redis.Client, prefix string, ttl time.Duration) *Store {
+	return &Store{client: client, keyPrefix: prefix + KeyPrefix, ttl: ttl}
+}
+
+// Record saves entries in one round trip.
+func (s *Store) Record(ctx context.Context, entries ...Entry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	pipe := s.client.Pipeline()
+	for _, entry := range entries {
+		data, err := json.Marshal(entry.Event)
+		if err != nil {
+			return fmt.Errorf("encode %s event: %w", entry.Stage, err)
+		}
+		key := s.keyPrefix + entry.ArticleID
+		if slices.Contains(firstOnly, entry.Stage) {
+			pipe.HSetNX(ctx, key, entry.Stage, data)
+		} else {
+			pipe.HSet(ctx, key, entry.Stage, data)
+		}
+		pipe.Expire(ctx, key, s.ttl)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("record timeline: %w", err)
+	}
+	return nil
+}
+
+// Load returns the events recorded for articleID in time order, or none when the article
+// has no timeline (never found, or expired).
+func (s *Store) Load(ctx context.Context, articleID string) ([]Event, error) {
+	fields, err := s.client.HGetAll(ctx, s.keyPrefix+articleID).Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return nil, fmt.Errorf("load timeline of %s: %w", articleID, err)
+	}
+	events := make([]Event, 0, len(fields))
+	for stage, data := range fields {
+		event := Event{Stage: stage}
+		if err := json.Unmarshal([]byte(data), &event); err != nil {
+			return nil, fmt.Errorf("decode %s event of %s: %w", stage, articleID, err)
+		}
+		events = append(events, event)
+	}
+	Sort(events)
+	return events, nil
+}
+
+// Sort orders events by time, and events at the same time by stage.
+func Sort(events []Event) {
+	slices.SortStableFunc(events, func(a, b Event) int {
+		if c := a.At.Compare(b.At); c != 0 {
+			return c
+		}
+		return slices.Index(stageOrder, a.Stage) - slices.Index(stageOrder, b.Stage)
+	})
+}
+
+// Write prints events as a table with the time of each stage and the delay since the
+// article was published (or first found, without a published date).
+func Write(w io.Writer, events []Event) error {
+	var start time.Time
+	for _, event := range events {
+		if event.Stage == StagePublished || (event.Stage == StageFound && start.IsZero()) {
+			start = event.At
+		}
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.Join([]string{"STAGE", "TIME", "DELAY", "CITY", "DETAIL"}, "\t"))
+	for _, event := range events {
+		delay := "-"
+		if !start.IsZero() {
+			delay = event.At.Sub(start).Round(time.Second).String()
+			if !strings.HasPrefix(delay, "-") {
+				delay = "+" + delay
+			}
+		}
+		fmt.Fprintln(tw, strings.Join([]string{event.Stage, event.At.Format(time.RFC3339), delay, event.City, event.Detail}, "\t"))
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,84 @@
+package timeline_test
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gopost/integration/internal/dedup/deduptest"
+	"github.com/gopost/integration/internal/timeline"
+)
+
+func TestStore_KeepsFirstAndLatestStages(t *testing.T) {
+	mr := miniredis.RunT(t)
+	store := timeline.NewStore(deduptest.NewClient(t, mr), deduptest.KeyPrefix, time.Hour)
+	ctx := context.Background()
+	start := time.Date(2025, 1, 15, 10, 0, 0, 0, time.UTC)
+
+	entry := func(stage string, minutes int, detail string) timeline.Entry {
+		return timeline.Entry{ArticleID: "a1", Event: timeline.Event{
+			Stage: stage, At: start.Add(time.Duration(minutes) * time.Minute), City: "sudbury_com", Detail: detail,
+		}}
+	}
+	if err := store.Record(ctx, entry(timeline.StagePublished, 0, ""), entry(timeline.StageFound, 20, "")); err != nil {
+		t.Fatalf("Record() error = %v", err)
+	}
+	// A later run finds the article again and retries the failed post
+	for _, e := range []timeline.Entry{
+		entry(timeline.StageFailed, 21, "status 503"),
+		entry(timeline.StageFound, 35, ""),
+		entry(timeline.StageFailed, 36, "status 502"),
+		entry(timeline.StagePosted, 50, "uuid-1"),
+	} {
+		if err := store.Record(ctx, e); err != nil {
+			t.Fatalf("Record() error = %v", err)
+		}
+	}
+
+	events, err := store.Load(ctx, "a1")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	var stages []string
+	for _, event := range events {
+		stages = append(stages, event.Stage)
+	}
+	if got := strings.Join(stages, ","); got != "published,found,failed,posted" {
+		t.Fatalf("stages = %s, want published,found,failed,posted", got)
+	}
+	if !events[1].At.Equal(start.Add(20*time.Minute)) || events[2].Detail != "status 502" {
+		t.Errorf("found at %v, failed %q; want the first find and the latest failure", events[1].At, events[2].Detail)
+	}
+	if ttl := mr.TTL(deduptest.KeyPrefix + timeline.KeyPrefix + "a1"); ttl != time.Hour {
+		t.Errorf("TTL = %v, want 1h", ttl)
+	}
+
+	if events, err := store.Load(ctx, "unknown"); err != nil || len(events) != 0 {
+		t.Errorf("Load(unknown) = %v, %v; want no events", events, err)
+	}
+}
+
+func TestWrite_ShowsDelaySincePublished(t *testing.T) {
+	start := time.Date(2025, 1, 15, 10, 0, 0, 0, time.UTC)
+	events := []timeline.Event{
+		{Stage: timeline.StagePosted, At: start.Add(90 * time.Minute), City: "sudbury_com", Detail: "uuid-1"},
+		{Stage: timeline.StageFound, At: start.Add(time.Hour), City: "sudbury_com"},
+		{Stage: timeline.StagePublished, At: start},
+	}
+	timeline.Sort(events)
+
+	var out bytes.Buffer
+	if err := timeline.Write(&out, events); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 4 || !strings.HasPrefix(lines[1], "published") {
+		t.Fatalf("Write() =\n%s\nwant a header and three stages from published", out.String())
+	}
+	if !strings.Contains(lines[2], "+1h0m0s") || !strings.Contains(lines[3], "+1h30m0s") || !strings.Contains(lines[3], "uuid-1") {
+		t.Errorf("Write() =\n%s\nwant delays since published", out.String())
+	}
+}
@@ -0,0 +1,116 @@
+package translate
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// KeyPrefix, after the key prefix, starts the Redis key of every cached translation:
+// gopost:translation:<source>:<target>:<sha256 of the text> with the default key prefix.
+const KeyPrefix = "translation:"
+
+// Cache stores translations in Redis, so retried and reprocessed articles are not
+// translated, and billed, twice.
+type Cache struct {
+	client    *redis.Client
+	keyPrefix string // KeyPrefix after the key prefix
+	ttl       time.Duration
+}
+
+// NewCache returns a cache backed by client keeping translations for ttl, under prefix
+// (redis.key_prefix).
+func NewCache(client *redis.Client, prefix string, ttl time.Duration) *Cache {
+	return &Cache{client: client, keyPrefix: prefix + KeyPrefix, ttl: ttl}
+}
+
+// Cached returns a translator that serves translations from cache and asks t only for
+// the texts it has not translated before. A failing cache is bypassed, so Redis
+// outages cost translations rather than posts.
+func Cached(t Translator, cache *Cache) Translator {
+	return &cachedTranslator{next: t, cache: cache}
+}
+
+type cachedTranslator struct {
+	next  Translator
+	cache *Cache
+}
+
+func (c *cachedTranslator) Translate(ctx context.Context, req Request) ([]string, error) {
+	translations, _ := c.cache.get(ctx, req)
+	if translations == nil {
+		translations = make([]string, len(req.Texts))
+	}
+
+	missing := Request{Source: req.Source, Target: req.Target, HTML: req.HTML}
+	var positions []int
+	for i, text := range req.Texts {
+		if translations[i] == "" && text != "" {
+			missing.Texts = append(missing.Texts, text)
+			positions = append(positions, i)
+		}
+	}
+	if len(missing.Texts) == 0 {
+		return translations, nil
+	}
+
+	translated, err := c.next.Translate(ctx, missing)
+	if err != nil {
+		return nil, err
+	}
+	for i, position := range positions {
+		translations[position] = translated[i]
+	}
+	_ = c.cache.set(ctx, missing, translated)
+	return translations, nil
+}
+
+// key returns the cache key of text translated as req asks.
+func (c *Cache) key(req Request, text string) string {
+	format := "text"
+	if req.HTML {
+		format = "html"
+	}
+	sum := sha256.Sum256([]byte(format + "\x00" + text))
+	source := req.Source
+	if source == "" {
+		source = "auto"
+	}
+	return c.keyPrefix + source + ":" + req.Target + ":" + hex.EncodeToString(sum[:])
+}
+
+// get returns the cached translation of each text in req, "" for those not cached.
+func (c *Cache) get(ctx context.Context, req Request) ([]string, error) {
+	keys := make([]string, len(req.Texts))
+	for i, text := range req.Texts {
+		keys[i] = c.key(req, text)
+	}
+	values, err := c.client.MGet(ctx, keys...).Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return nil, fmt.Errorf("load cached translations: %w", err)
+	}
+	translations := make([]string, len(req.Texts))
+	for i, value := range values {
+		if s, ok := value.(string); ok {
+			translations[i] = s
+		}
+	}
+	return translations, nil
+}
+
+// set caches translations[i] as the translation of req.Texts[i].
+func (c *Cache) set(ctx context.Context, req Request, translations []string) error {
+	pipe := c.client.Pipeline()
+	for i, text := range req.Texts {
+		pipe.Set(ctx, c.key(req, text), translations[i], c.ttl)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("cache translations: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,230 @@
+// Package translate machine-translates article text through the HTTP APIs of DeepL,
+// Google Cloud Translation, or LibreTranslate, with translations cached in Redis.
+package translate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Translation providers
+const (
+	ProviderDeepL          = "deepl"
+	ProviderGoogle         = "google"
+	ProviderLibreTranslate = "libretranslate"
+)
+
+// Default API base URLs. DeepL API Free keys (ending in ":fx") use DefaultDeepLFreeURL.
+const (
+	DefaultDeepLURL          = "https://api.deepl.com"
+	DefaultDeepLFreeURL      = "https://api-free.deepl.com"
+	DefaultGoogleURL         = "https://translation.googleapis.com"
+	DefaultLibreTranslateURL = "https://libretranslate.com"
+)
+
+// Request is a batch of texts to translate.
+type Request struct {
+	Texts  []string
+	Source string // Langcode of the texts, e.g. "en"; empty lets the provider detect it
+	Target string // Langcode to translate into, e.g. "fr"
+	HTML   bool   // The texts are HTML, whose markup is kept
+}
+
+// Translator translates texts, returning one translation per text in order.
+type Translator interface {
+	Translate(ctx context.Context, req Request) ([]string, error)
+}
+
+// Client calls a translation provider's HTTP API.
+type Client struct {
+	provider  string
+	baseURL   string
+	apiKey    string
+	client    *http.Client
+	userAgent string
+}
+
+// NewClient returns a client for provider. An empty baseURL uses the provider's public
+// API, and an empty userAgent keeps Go's default.
+func NewClient(provider, baseURL, apiKey string, timeout time.Duration, userAgent string) (*Client, error) {
+	if baseURL == "" {
+		switch provider {
+		case ProviderDeepL:
+			baseURL = DefaultDeepLURL
+			if strings.HasSuffix(apiKey, ":fx") {
+				baseURL = DefaultDeepLFreeURL
+			}
+		case ProviderGoogle:
+			baseURL = DefaultGoogleURL
+		case ProviderLibreTranslate:
+			baseURL = DefaultLibreTranslateURL
+		}
+	}
+	switch provider {
+	case ProviderDeepL, ProviderGoogle:
+		if apiKey == "" {
+			return nil, fmt.Errorf("%s requires an API key", provider)
+		}
+	case ProviderLibreTranslate:
+	default:
+		return nil, fmt.Errorf("unknown translation provider %q", provider)
+	}
+	return &Client{
+		provider:  provider,
+		baseURL:   strings.TrimSuffix(baseURL, "/"),
+		apiKey:    apiKey,
+		client:    &http.Client{Timeout: timeout},
+		userAgent: userAgent,
+	}, nil
+}
+
+// Translate sends the texts to the provider in one request.
+func (c *Client) Translate(ctx context.Context, req Request) ([]string, error) {
+	if len(req.Texts) == 0 {
+		return nil, nil
+	}
+	var translations []string
+	var err error
+	switch c.provider {
+	case ProviderDeepL:
+		translations, err = c.translateDeepL(ctx, req)
+	case ProviderGoogle:
+		translations, err = c.translateGoogle(ctx, req)
+	default:
+		translations, err = c.translateLibre(ctx, req)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", c.provider, err)
+	}
+	if len(translations) != len(req.Texts) {
+		return nil, fmt.Errorf("%s: got %d translations for %d texts", c.provider, len(translations), len(req.Texts))
+	}
+	return translations, nil
+}
+
+// translateDeepL calls POST /v2/translate. DeepL language codes are upper case, e.g. "FR"
+// or "PT-BR".
+func (c *Client) translateDeepL(ctx context.Context, req Request) ([]string, error) {
+	body := map[string]any{
+		"text":        req.Texts,
+		"target_lang": strings.ToUpper(req.Target),
+	}
+	if req.Source != "" {
+		// Source languages have no regional variants
+		source, _, _ := strings.Cut(req.Source, "-")
+		body["source_lang"] = strings.ToUpper(source)
+	}
+	if req.HTML {
+		body["tag_handling"] = "html"
+	}
+	var resp struct {
+		Translations []struct {
+			Text string `json:"text"`
+		} `json:"translations"`
+	}
+	header := http.Header{"Authorization": []string{"DeepL-Auth-Key " + c.apiKey}}
+	if err := c.post(ctx, c.baseURL+"/v2/translate", header, body, &resp); err != nil {
+		return nil, err
+	}
+	translations := make([]string, 0, len(resp.Translations))
+	for _, t := range resp.Translations {
+		translations = append(translations, t.Text)
+	}
+	return translations, nil
+}
+
+// translateGoogle calls the Cloud Translation Basic (v2) API with an API key.
+func (c *Client) translateGoogle(ctx context.Context, req Request) ([]string, error) {
+	body := map[string]any{
+		"q":      req.Texts,
+		"target": req.Target,
+		"format": "text",
+	}
+	if req.Source != "" {
+		body["source"] = req.Source
+	}
+	if req.HTML {
+		body["format"] = "html"
+	}
+	var resp struct {
+		Data struct {
+			Translations []struct {
+				TranslatedText string `json:"translatedText"`
+			} `json:"translations"`
+		} `json:"data"`
+	}
+	header := http.Header{"X-Goog-Api-Key": []string{c.apiKey}}
+	if err := c.post(ctx, c.baseURL+"/language/translate/v2", header, body, &resp); err != nil {
+		return nil, err
+	}
+	translations := make([]string, 0, len(resp.Data.Translations))
+	for _, t := range resp.Data.Translations {
+		translations = append(translations, t.TranslatedText)
+	}
+	return translations, nil
+}
+
+// translateLibre calls POST /translate, which returns an array of translations for an
+// array of texts.
+func (c *Client) translateLibre(ctx context.Context, req Request) ([]string, error) {
+	body := map[string]any{
+		"q":      req.Texts,
+		"source": "auto",
+		"target": req.Target,
+		"format": "text",
+	}
+	if req.Source != "" {
+		body["source"] = req.Source
+	}
+	if req.HTML {
+		body["format"] = "html"
+	}
+	if c.apiKey != "" {
+		body["api_key"] = c.apiKey
+	}
+	var resp struct {
+		TranslatedText []string `json:"translatedText"`
+	}
+	if err := c.post(ctx, c.baseURL+"/translate", nil, body, &resp); err != nil {
+		return nil, err
+	}
+	return resp.TranslatedText, nil
+}
+
+// post sends body as JSON and decodes the response into v.
+func (c *Client) post(ctx context.Context, endpoint string, header http.Header, body, v any) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("encode request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.userAgent != "" {
+		req.Header.Set("User-Agent", c.userAgent)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("status %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	return nil
+}
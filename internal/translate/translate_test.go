@@ -0,0 +1,155 @@
+package translate_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gopost/integration/internal/translate"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestClient_Providers(t *testing.T) {
+	tests := []struct {
+		provider   string
+		path       string
+		wantHeader [2]string
+		wantBody   map[string]any
+		response   string
+	}{
+		{
+			provider:   translate.ProviderDeepL,
+			path:       "/v2/translate",
+			wantHeader: [2]string{"Authorization", "DeepL-Auth-Key key"},
+			wantBody:   map[string]any{"text": []any{"<p>Police</p>"}, "source_lang": "EN", "target_lang": "FR", "tag_handling": "html"},
+			response:   `{"translations":[{"detected_source_language":"EN","text":"<p>Police FR</p>"}]}`,
+		},
+		{
+			provider:   translate.ProviderGoogle,
+			path:       "/language/translate/v2",
+			wantHeader: [2]string{"X-Goog-Api-Key", "key"},
+			wantBody:   map[string]any{"q": []any{"<p>Police</p>"}, "source": "en", "target": "fr", "format": "html"},
+			response:   `{"data":{"translations":[{"translatedText":"<p>Police FR</p>"}]}}`,
+		},
+		{
+			provider: translate.ProviderLibreTranslate,
+			path:     "/translate",
+			wantBody: map[string]any{"q": []any{"<p>Police</p>"}, "source": "en", "target": "fr", "format": "html", "api_key": "key"},
+			response: `{"translatedText":["<p>Police FR</p>"]}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.provider, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodPost || r.URL.Path != tt.path {
+					http.NotFound(w, r)
+					return
+				}
+				if tt.wantHeader[0] != "" && r.Header.Get(tt.wantHeader[0]) != tt.wantHeader[1] {
+					t.Errorf("%s = %q, want %q", tt.wantHeader[0], r.Header.Get(tt.wantHeader[0]), tt.wantHeader[1])
+				}
+				var body map[string]any
+				if err := json.NewDecoder(r.Body).Decode(&body); err != nil || !reflect.DeepEqual(body, tt.wantBody) {
+					t.Errorf("request body = %v (%v), want %v", body, err, tt.wantBody)
+				}
+				_, _ = w.Write([]byte(tt.response))
+			}))
+			defer server.Close()
+
+			client, err := translate.NewClient(tt.provider, server.URL, "key", time.Second, "")
+			if err != nil {
+				t.Fatalf("NewClient() error = %v", err)
+			}
+			got, err := client.Translate(context.Background(), translate.Request{
+				Texts: []string{"<p>Police</p>"}, Source: "en", Target: "fr", HTML: true,
+			})
+			if err != nil {
+				t.Fatalf("Translate() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, []string{"<p>Police FR</p>"}) {
+				t.Errorf("Translate() = %q, want the provider's translation", got)
+			}
+		})
+	}
+}
+
+func TestClient_ReportsProviderErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"message":"Quota Exceeded"}`, 456)
+	}))
+	defer server.Close()
+
+	client, err := translate.NewClient(translate.ProviderDeepL, server.URL, "key", time.Second, "")
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	if _, err := client.Translate(context.Background(), translate.Request{Texts: []string{"Police"}, Target: "fr"}); err == nil {
+		t.Error("Translate() error = nil for status 456, want error")
+	}
+	if _, err := translate.NewClient(translate.ProviderGoogle, "", "", time.Second, ""); err == nil {
+		t.Error("NewClient(google) error = nil without an API key, want error")
+	}
+}
+
+// countingTranslator prefixes texts with the target language and counts how many it was
+// asked to translate.
+type countingTranslator struct {
+	texts atomic.Int32
+}
+
+func (c *countingTranslator) Translate(_ context.Context, req translate.Request) ([]string, error) {
+	c.texts.Add(int32(len(req.Texts)))
+	translations := make([]string, len(req.Texts))
+	for i, text := range req.Texts {
+		translations[i] = req.Target + ":" + text
+	}
+	return translations, nil
+}
+
+func TestCached(t *testing.T) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer client.Close()
+	next := &countingTranslator{}
+	translator := translate.Cached(next, translate.NewCache(client, "gopost:", time.Hour))
+	ctx := context.Background()
+
+	first, err := translator.Translate(ctx, translate.Request{Texts: []string{"Police", "Fire"}, Source: "en", Target: "fr"})
+	if err != nil {
+		t.Fatalf("Translate() error = %v", err)
+	}
+	second, err := translator.Translate(ctx, translate.Request{Texts: []string{"Fire", "Court"}, Source: "en", Target: "fr"})
+	if err != nil {
+		t.Fatalf("Translate() error = %v", err)
+	}
+	if !reflect.DeepEqual(first, []string{"fr:Police", "fr:Fire"}) || !reflect.DeepEqual(second, []string{"fr:Fire", "fr:Court"}) {
+		t.Errorf("translations = %q, %q", first, second)
+	}
+	if got := next.texts.Load(); got != 3 {
+		t.Errorf("provider translated %d texts, want 3 (Fire cached)", got)
+	}
+
+	// HTML and other target languages are cached separately
+	if _, err := translator.Translate(ctx, translate.Request{Texts: []string{"Fire"}, Source: "en", Target: "fr", HTML: true}); err != nil {
+		t.Fatalf("Translate() error = %v", err)
+	}
+	if _, err := translator.Translate(ctx, translate.Request{Texts: []string{"Fire"}, Source: "en", Target: "de"}); err != nil {
+		t.Fatalf("Translate() error = %v", err)
+	}
+	if got := next.texts.Load(); got != 5 {
+		t.Errorf("provider translated %d texts, want 5", got)
+	}
+
+	// An unreachable cache falls back to the provider
+	mr.Close()
+	got, err := translator.Translate(ctx, translate.Request{Texts: []string{"Police"}, Source: "en", Target: "fr"})
+	if err != nil || !reflect.DeepEqual(got, []string{"fr:Police"}) {
+		t.Errorf("Translate() without Redis = %q, %v; want the provider's translation", got, err)
+	}
+}
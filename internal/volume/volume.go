@@ -0,0 +1,106 @@
+// Package volume keeps a rolling average of the articles each city finds and posts per run
+// in Redis, so runs far outside a city's usual volume can be reported across restarts and
+// replicas.
+package volume
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Key, after the key prefix, is a hash of city name to its JSON-encoded Baseline.
+const Key = "volume"
+
+// Kinds of anomaly.
+const (
+	KindDrop  = "drop"  // Nothing, where the average is at least the minimum
+	KindSpike = "spike" // At least the spike factor times the average
+)
+
+// Baseline is a city's rolling average of articles found and posted (or queued) per run.
+type Baseline struct {
+	Runs   int     `json:"runs"` // Runs averaged, up to the window
+	Found  float64 `json:"found"`
+	Posted float64 `json:"posted"`
+}
+
+// Add returns the baseline with a run's counts averaged in. Until window runs are counted
+// it is the plain mean; after that each run weighs 1/window.
+func (b Baseline) Add(found, posted, window int) Baseline {
+	b.Runs = min(b.Runs+1, window)
+	b.Found += (float64(found) - b.Found) / float64(b.Runs)
+	b.Posted += (float64(posted) - b.Posted) / float64(b.Runs)
+	return b
+}
+
+// Check returns the kind of anomaly count is against average, or "" when it is usual.
+func Check(count int, average, minAverage, spikeFactor float64) string {
+	switch {
+	case count == 0 && average >= minAverage:
+		return KindDrop
+	case float64(count) >= spikeFactor*math.Max(average, 1):
+		return KindSpike
+	default:
+		return ""
+	}
+}
+
+// Store reads and saves the baselines.
+type Store struct {
+	client *redis.Client
+	key    string // Key after the key prefix
+}
+
+// NewStore returns a store backed by client, keeping its data under prefix (redis.key_prefix).
+func NewStore(client *redis.Client, prefix string) *Store {
+	return &Store{client: client, key: prefix + Key}
+}
+
+// Load returns the baselines of cities. Cities never seen are missing from the map.
+func (s *Store) Load(ctx context.Context, cities []string) (map[string]Baseline, error) {
+	baselines := make(map[string]Baseline, len(cities))
+	if len(cities) == 0 {
+		return baselines, nil
+	}
+
+	values, err := s.client.HMGet(ctx, s.key, cities...).Result()
+	if err != nil {
+		return nil, fmt.Errorf("load volume baselines: %w", err)
+	}
+	for i, value := range values {
+		data, ok := value.(string)
+		if !ok {
+			continue
+		}
+		var baseline Baseline
+		if err := json.Unmarshal([]byte(data), &baseline); err != nil {
+			return nil, fmt.Errorf("decode volume baseline of %s: %w", cities[i], err)
+		}
+		baselines[cities[i]] = baseline
+	}
+	return baselines, nil
+}
+
+// Save stores the baselines, leaving those of other cities alone.
+func (s *Store) Save(ctx context.Context, baselines map[string]Baseline) error {
+	if len(baselines) == 0 {
+		return nil
+	}
+
+	values := make(map[string]any, len(baselines))
+	for city, baseline := range baselines {
+		data, err := json.Marshal(baseline)
+		if err != nil {
+			return fmt.Errorf("encode volume baseline of %s: %w", city, err)
+		}
+		values[city] = data
+	}
+	if err := s.client.HSet(ctx, s.key, values).Err(); err != nil {
+		return fmt.Errorf("save volume baselines: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,67 @@
+package volume_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gopost/integration/internal/dedup/deduptest"
+	"github.com/gopost/integration/internal/volume"
+)
+
+func TestBaseline_Add(t *testing.T) {
+	var baseline volume.Baseline
+	for _, found := range []int{10, 20, 30} {
+		baseline = baseline.Add(found, found/2, 4)
+	}
+	if baseline.Runs != 3 || baseline.Found != 20 || baseline.Posted != 10 {
+		t.Fatalf("baseline after 3 runs = %+v, want the mean of 3 runs", baseline)
+	}
+
+	// Past the window each run weighs 1/window
+	baseline = baseline.Add(20, 10, 4).Add(60, 10, 4)
+	if baseline.Runs != 4 || baseline.Found != 30 {
+		t.Errorf("baseline past the window = %+v, want 4 runs averaging 30 found", baseline)
+	}
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name    string
+		count   int
+		average float64
+		want    string
+	}{
+		{"usual", 8, 10, ""},
+		{"nothing for a busy city", 0, 10, volume.KindDrop},
+		{"nothing for a quiet city", 0, 1.5, ""},
+		{"tenfold", 100, 10, volume.KindSpike},
+		{"below tenfold", 99, 10, ""},
+		{"spike from almost nothing", 10, 0.2, volume.KindSpike},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := volume.Check(tt.count, tt.average, 3, 10); got != tt.want {
+				t.Errorf("Check(%d, %v) = %q, want %q", tt.count, tt.average, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStore(t *testing.T) {
+	mr := miniredis.RunT(t)
+	store := volume.NewStore(deduptest.NewClient(t, mr), deduptest.KeyPrefix)
+	ctx := context.Background()
+
+	saved := map[string]volume.Baseline{"sudbury_com": {Runs: 5, Found: 12.5, Posted: 4}}
+	if err := store.Save(ctx, saved); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	baselines, err := store.Load(ctx, []string{"sudbury_com", "timmins"})
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(baselines) != 1 || baselines["sudbury_com"] != saved["sudbury_com"] {
+		t.Errorf("Load() = %+v, want only the saved baseline", baselines)
+	}
+}
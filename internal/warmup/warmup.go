@@ -0,0 +1,67 @@
+// Package warmup counts the completed runs of each city in Redis, so posting can be limited
+// for cities that were only recently enabled, across restarts and replicas.
+package warmup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Key, after the key prefix, is a hash of city name to completed run count.
+const Key = "warmup"
+
+// Store reads and updates the run counts.
+type Store struct {
+	client *redis.Client
+	key    string // Key after the key prefix
+}
+
+// NewStore returns a store backed by client, keeping its data under prefix (redis.key_prefix).
+func NewStore(client *redis.Client, prefix string) *Store {
+	return &Store{client: client, key: prefix + Key}
+}
+
+// Runs returns the number of runs completed for city, or 0 for a city never seen.
+func (s *Store) Runs(ctx context.Context, city string) (int, error) {
+	runs, err := s.client.HGet(ctx, s.key, city).Int()
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("load warm-up runs of %s: %w", city, err)
+	}
+	return runs, nil
+}
+
+// Record counts one completed run for city.
+func (s *Store) Record(ctx context.Context, city string) error {
+	if err := s.client.HIncrBy(ctx, s.key, city, 1).Err(); err != nil {
+		return fmt.Errorf("record warm-up run of %s: %w", city, err)
+	}
+	return nil
+}
+
+// Adopt marks cities as having completed runs, so they skip warm-up. It only acts when
+// no run was ever recorded, which lets a deployment that predates warm-up treat its
+// existing cities as established. It reports whether the cities were adopted.
+func (s *Store) Adopt(ctx context.Context, cities []string, runs int) (bool, error) {
+	exists, err := s.client.Exists(ctx, s.key).Result()
+	if err != nil {
+		return false, fmt.Errorf("check warm-up runs: %w", err)
+	}
+	if exists > 0 || len(cities) == 0 {
+		return false, nil
+	}
+
+	values := make(map[string]any, len(cities))
+	for _, city := range cities {
+		values[city] = runs
+	}
+	if err := s.client.HSet(ctx, s.key, values).Err(); err != nil {
+		return false, fmt.Errorf("adopt cities: %w", err)
+	}
+	return true, nil
+}
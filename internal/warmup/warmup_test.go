@@ -0,0 +1,50 @@
+package warmup_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/gopost/integration/internal/dedup/deduptest"
+	"github.com/gopost/integration/internal/warmup"
+)
+
+func TestStore(t *testing.T) {
+	mr := miniredis.RunT(t)
+	store := warmup.NewStore(deduptest.NewClient(t, mr), deduptest.KeyPrefix)
+	ctx := context.Background()
+
+	assertRuns := func(city string, want int) {
+		t.Helper()
+		got, err := store.Runs(ctx, city)
+		if err != nil {
+			t.Fatalf("Runs(%q) error = %v", city, err)
+		}
+		if got != want {
+			t.Errorf("Runs(%q) = %d, want %d", city, got, want)
+		}
+	}
+
+	// A deployment upgrading to warm-up keeps its existing cities established
+	adopted, err := store.Adopt(ctx, []string{"sudbury_com", "toronto_com"}, 3)
+	if err != nil || !adopted {
+		t.Fatalf("Adopt() = %v, %v; want true, nil", adopted, err)
+	}
+	assertRuns("sudbury_com", 3)
+	assertRuns("timmins", 0)
+
+	// Once runs are recorded, later cities are new
+	adopted, err = store.Adopt(ctx, []string{"timmins"}, 3)
+	if err != nil || adopted {
+		t.Fatalf("second Adopt() = %v, %v; want false, nil", adopted, err)
+	}
+	assertRuns("timmins", 0)
+
+	for range 2 {
+		if err := store.Record(ctx, "timmins"); err != nil {
+			t.Fatalf("Record() error = %v", err)
+		}
+	}
+	assertRuns("timmins", 2)
+	assertRuns("sudbury_com", 3)
+}
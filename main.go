@@ -2,26 +2,59 @@ package main
 
 import (
 	"context"
+	"crypto/tls"
 	"errors"
 	"flag"
+	"fmt"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
+	"github.com/gopost/integration/internal/admin"
+	"github.com/gopost/integration/internal/buildinfo"
 	"github.com/gopost/integration/internal/config"
+	"github.com/gopost/integration/internal/dedup"
+	"github.com/gopost/integration/internal/doctor"
+	"github.com/gopost/integration/internal/grpcadmin"
+	"github.com/gopost/integration/internal/history"
 	"github.com/gopost/integration/internal/integration"
 	"github.com/gopost/integration/internal/logger"
+	"github.com/gopost/integration/internal/metrics"
+	"github.com/gopost/integration/internal/sdnotify"
 	"github.com/gopost/integration/internal/sources"
-)
-
-var (
-	// version can be set at build time via -ldflags
-	version = "dev"
+	"github.com/gopost/integration/internal/timeline"
+	"github.com/gopost/integration/pkg/pipeline"
 )
 
 func initializeLogger(cfg *config.Config) (logger.Logger, error) {
-	appLogger, err := logger.NewLogger(cfg.Debug)
+	opts := []logger.Option{logger.WithOutput(logger.Output{
+		Type:     cfg.Logging.Output.Type,
+		Path:     cfg.Logging.Output.Path,
+		Network:  cfg.Logging.Output.Network,
+		Address:  cfg.Logging.Output.Address,
+		Facility: cfg.Logging.Output.Facility,
+		Tag:      cfg.Logging.Output.Tag,
+	})}
+	if gelf := cfg.Logging.GELF; gelf.Enabled {
+		opts = append(opts, logger.WithGELF(logger.GELF{
+			Network:     gelf.Network,
+			Address:     gelf.Address,
+			Compression: gelf.Compression,
+			Host:        gelf.Host,
+		}))
+	}
+	if sampling := cfg.Logging.Sampling; sampling.Enabled {
+		opts = append(opts, logger.WithSampling(logger.Sampling{
+			Initial:         sampling.Initial,
+			Thereafter:      sampling.Thereafter,
+			Tick:            sampling.Tick,
+			SummaryInterval: sampling.SummaryInterval,
+		}))
+	}
+	appLogger, err := logger.NewLogger(cfg.Debug, opts...)
 	if err != nil {
 		return nil, err
 	}
@@ -29,36 +62,825 @@ func initializeLogger(cfg *config.Config) (logger.Logger, error) {
 	// Add service context fields to all log entries
 	appLogger = appLogger.With(
 		logger.String("service", "gopost"),
-		logger.String("version", version),
+		logger.String("version", buildinfo.Get().Version),
 	)
 
 	return appLogger, nil
 }
 
-func handleFlushCache(service *integration.Service, appLogger logger.Logger) {
+// applyUserAgentDefault identifies gopost and its version to backends unless
+// user_agent is configured.
+func applyUserAgentDefault(cfg *config.Config) {
+	if cfg.UserAgent == "" {
+		cfg.UserAgent = "gopost/" + buildinfo.Get().Version
+	}
+}
+
+// pipelines is the set of sync pipelines run by the process: a single *integration.Service,
+// or an *integration.Group with one service per tenant.
+type pipelines interface {
+	Run(ctx context.Context) error
+	Status() integration.Status
+	Healthy(grace time.Duration) bool
+	FlushCache(ctx context.Context) error
+	RunOnce(ctx context.Context) (integration.RunReport, error)
+	Replay(ctx context.Context, since time.Time, city string) (integration.RunReport, error)
+	Preview(ctx context.Context, city string) ([]integration.PreviewArticle, error)
+	Pause(ctx context.Context, city, reason string) error
+	Resume(ctx context.Context, city string) error
+	TriggerSync(runToken string) bool
+	DedupLookup(ctx context.Context, articleID string) (dedup.Entry, error)
+	Suppress(ctx context.Context, articleID string) error
+	Unsuppress(ctx context.Context, articleID string) error
+	RecordModeration(ctx context.Context, event integration.ModerationEvent) error
+	Rollback(ctx context.Context, opts integration.RollbackOptions) ([]integration.RolledBackPost, error)
+}
+
+func handleFlushCache(service pipelines, appLogger logger.Logger) {
 	const flushCacheTimeout = 30 * time.Second
 	ctx, cancel := context.WithTimeout(context.Background(), flushCacheTimeout)
 	defer cancel()
 
 	if err := service.FlushCache(ctx); err != nil {
-		appLogger.Error("Failed to flush cache",
+		appLogger.WithError(err).Fatal("Failed to flush cache")
+	}
+
+	appLogger.Info("Cache flushed successfully")
+	_ = appLogger.Sync()
+}
+
+// Exit codes of "gopost once", so wrappers and CronJob alerts can tell failures apart
+// without parsing logs. A failed metrics push exits 1.
+const (
+	exitConfigError        = 2 // The configuration failed to load or validate
+	exitBackendUnavailable = 3 // Elasticsearch, Drupal, or Redis was unreachable or refused the run
+	exitPartialFailure     = 4 // Some cities or posts failed
+	exitTotalFailure       = 5 // Every city searched failed
+)
+
+// runOnce implements "gopost once": a single sync run for cron deployments. The run's
+// metrics are pushed to the configured Pushgateway or StatsD before exiting, and the exit
+// code classifies the run (see exitConfigError and the codes after it).
+// --profile and --memprofile write pprof CPU and heap profiles of the run, for diagnosing
+// slow backfills without attaching to a daemon.
+func runOnce(args []string) {
+	flags := flag.NewFlagSet("once", flag.ExitOnError)
+	configPath := flags.String("config", "config.yml", "Path to configuration file (empty: configure from GOPOST_* environment variables only)")
+	enableChaos := flags.Bool("chaos", false, "Inject the backend failures configured under chaos (staging only)")
+	cpuProfile := flags.String("profile", "", "Write a CPU profile of the run to this file")
+	memProfile := flags.String("memprofile", "", "Write a heap profile to this file when the run ends")
+	_ = flags.Parse(args)
+
+	cfg, appLogger, service := loadService(*configPath, *enableChaos)
+	defer func() { _ = appLogger.Sync() }()
+
+	ctx, cancel := signalContext(appLogger)
+	defer cancel()
+
+	profiles, err := startProfiles(*cpuProfile, *memProfile)
+	if err != nil {
+		appLogger.WithError(err).Fatal("Failed to start profiling")
+	}
+	report, runErr := service.RunOnce(ctx)
+	if err := profiles.stop(); err != nil {
+		appLogger.WithError(err).Error("Failed to write profiles")
+	} else if *cpuProfile != "" || *memProfile != "" {
+		appLogger.Info("Wrote profiles",
+			logger.String("cpu_profile", *cpuProfile),
+			logger.String("mem_profile", *memProfile),
+		)
+	}
+	if push := cfg.Metrics.Push; push.Pushgateway != "" || push.StatsD != "" {
+		// Push even when the run failed, so the failure shows up in the metrics
+		pushCtx, pushCancel := context.WithTimeout(context.WithoutCancel(ctx), push.Timeout)
+		err := metrics.NewPusher(push, cfg.UserAgent).Push(pushCtx, metrics.Collect(service.Status()))
+		pushCancel()
+		if err != nil {
+			appLogger.WithError(err).Fatal("Failed to push metrics")
+		}
+		appLogger.Debug("Pushed metrics",
+			logger.String("pushgateway", push.Pushgateway),
+			logger.String("statsd", push.StatsD),
+		)
+	}
+	if code := onceExitCode(service.Status(), report, runErr); code != 0 {
+		appLogger.Error("Run failed",
+			logger.Int("exit_code", code),
+			logger.String("outcome", string(report.Outcome())),
+			logger.Error(runErr),
+		)
+		_ = appLogger.Sync()
+		os.Exit(code)
+	}
+}
+
+// onceExitCode classifies a one-shot run. Unavailable backends take precedence, since
+// they usually fail every city and call for a different response than a partial failure.
+func onceExitCode(status integration.Status, report integration.RunReport, runErr error) int {
+	if backendUnavailable(status) || errors.Is(runErr, integration.ErrBackendUnavailable) {
+		return exitBackendUnavailable
+	}
+	if runErr != nil {
+		return exitTotalFailure
+	}
+	switch report.Outcome() {
+	case integration.RunFailed:
+		return exitTotalFailure
+	case integration.RunPartial:
+		return exitPartialFailure
+	default:
+		return 0
+	}
+}
+
+// backendUnavailable reports whether the last run, of any tenant, found a backend down:
+// every search failed, Drupal was in maintenance mode, rejected the credentials, or failed
+// every post, or saving the last check time in Redis failed.
+func backendUnavailable(status integration.Status) bool {
+	for _, tenantStatus := range status.Tenants {
+		if backendUnavailable(tenantStatus) {
+			return true
+		}
+	}
+	backends := status.Health.Backends
+	return len(status.Tenants) == 0 && (!backends.Elasticsearch || !backends.Drupal || !backends.Redis)
+}
+
+// runReplay implements "gopost replay --since <time> [--city <name>]": it re-evaluates
+// articles published since the given time with the current filters and exits.
+func runReplay(args []string) {
+	flags := flag.NewFlagSet("replay", flag.ExitOnError)
+	configPath := flags.String("config", "config.yml", "Path to configuration file (empty: configure from GOPOST_* environment variables only)")
+	sinceFlag := flags.String("since", "", `Replay articles published since this time: RFC 3339, a date ("2025-01-15"), or a duration ago ("72h")`)
+	city := flags.String("city", "", "Only replay this city (default: all cities)")
+	_ = flags.Parse(args)
+
+	if *sinceFlag == "" {
+		fmt.Fprintln(os.Stderr, "replay: --since is required")
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	cfg, appLogger, service := loadService(*configPath, false)
+	defer func() { _ = appLogger.Sync() }()
+
+	location, err := time.LoadLocation(cfg.Service.Timezone)
+	if err != nil {
+		location = time.UTC
+	}
+	since, err := parseSince(*sinceFlag, location, time.Now())
+	if err != nil {
+		appLogger.Error("Invalid --since value",
+			logger.String("since", *sinceFlag),
 			logger.Error(err),
 		)
+		_ = appLogger.Sync()
+		os.Exit(2)
+	}
+
+	ctx, cancel := signalContext(appLogger)
+	defer cancel()
+
+	if _, err := service.Replay(ctx, since, *city); err != nil {
+		appLogger.WithError(err).Fatal("Replay failed")
+	}
+	appLogger.Info("Replay completed")
+}
+
+// runRollback implements "gopost rollback --since <time> [--city <name>]": it deletes (or
+// unpublishes) the nodes posted in the range, found in the audit index, and clears or
+// suppresses their articles. It exits 1 if any post could not be rolled back.
+func runRollback(args []string) {
+	flags := flag.NewFlagSet("rollback", flag.ExitOnError)
+	configPath := flags.String("config", "config.yml", "Path to configuration file (empty: configure from GOPOST_* environment variables only)")
+	sinceFlag := flags.String("since", "", `Roll back posts made since this time: RFC 3339, a date ("2025-01-15"), or a duration ago ("2h", "2 hours ago")`)
+	untilFlag := flags.String("until", "", "Only roll back posts made before this time, in the same formats (default: now)")
+	city := flags.String("city", "", "Only roll back this city's posts (default: all cities)")
+	unpublish := flags.Bool("unpublish", false, "Unpublish the nodes instead of deleting them")
+	suppress := flags.Bool("suppress", false, "Never post the articles again, instead of clearing their dedup markers so a replay can repost them")
+	dryRun := flags.Bool("dry-run", false, "List the posts without changing anything")
+	_ = flags.Parse(args)
+
+	if *sinceFlag == "" {
+		fmt.Fprintln(os.Stderr, "rollback: --since is required")
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	cfg, appLogger, service := loadService(*configPath, false)
+	defer func() { _ = appLogger.Sync() }()
+
+	location, err := time.LoadLocation(cfg.Service.Timezone)
+	if err != nil {
+		location = time.UTC
+	}
+	parseTime := func(name, value string) time.Time {
+		t, err := parseSince(value, location, time.Now())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "rollback: invalid --%s value %q: %v\n", name, value, err)
+			_ = appLogger.Sync()
+			os.Exit(2)
+		}
+		return t
+	}
+	opts := integration.RollbackOptions{City: *city, Unpublish: *unpublish, Suppress: *suppress, DryRun: *dryRun}
+	opts.Since = parseTime("since", *sinceFlag)
+	if *untilFlag != "" {
+		opts.Until = parseTime("until", *untilFlag)
+	}
+
+	ctx, cancel := signalContext(appLogger)
+	defer cancel()
+
+	posts, err := service.Rollback(ctx, opts)
+	failed := 0
+	for _, post := range posts {
+		status := "done"
+		switch {
+		case opts.DryRun:
+			status = "found"
+		case post.Err != nil:
+			status = "FAILED"
+			failed++
+		}
+		fmt.Printf("%-6s  %s  %s  %s  %s\n", status, post.City, post.PostedAt.Format(time.RFC3339), post.ArticleID, post.Title)
+		if post.Err != nil {
+			fmt.Printf("        %v\n", post.Err)
+		}
+	}
+	if err != nil {
+		appLogger.WithError(err).Fatal("Rollback failed")
+	}
+	fmt.Printf("%d posts, %d failed\n", len(posts), failed)
+	if failed > 0 {
 		_ = appLogger.Sync()
 		os.Exit(1)
 	}
+}
 
-	appLogger.Info("Cache flushed successfully")
-	_ = appLogger.Sync()
+// runPreview implements "gopost preview [--city <name>]": it lists the crime articles the
+// next run would consider, with the keyword fragments that selected them, and exits
+// without posting.
+func runPreview(args []string) {
+	flags := flag.NewFlagSet("preview", flag.ExitOnError)
+	configPath := flags.String("config", "config.yml", "Path to configuration file (empty: configure from GOPOST_* environment variables only)")
+	city := flags.String("city", "", "Only preview this city (default: all cities)")
+	_ = flags.Parse(args)
+
+	_, appLogger, service := loadService(*configPath, false)
+	defer func() { _ = appLogger.Sync() }()
+
+	ctx, cancel := signalContext(appLogger)
+	defer cancel()
+
+	preview, err := service.Preview(ctx, *city)
+	for _, article := range preview {
+		status := "new"
+		if article.AlreadyPosted {
+			status = "posted"
+		}
+		fmt.Printf("%-6s  %s  %s  %s  %s\n", status, article.City, article.PublishedAt.Format(time.RFC3339), article.ID, article.Title)
+		for _, fragment := range article.Highlights {
+			fmt.Printf("        %s\n", fragment)
+		}
+	}
+	if err != nil {
+		appLogger.WithError(err).Fatal("Preview failed")
+	}
+}
+
+// runReport implements "gopost report [--last 7d] [--csv]": it prints per-city posting
+// volumes, error rates, and average post latencies from the run history.
+func runReport(args []string) {
+	flags := flag.NewFlagSet("report", flag.ExitOnError)
+	configPath := flags.String("config", "config.yml", "Path to configuration file (empty: configure from GOPOST_* environment variables only)")
+	lastFlag := flags.String("last", "7d", `Report on runs started within this period: days ("7d") or a duration ("12h")`)
+	csvOutput := flags.Bool("csv", false, "Print CSV instead of a table")
+	_ = flags.Parse(args)
+
+	last, err := parseLast(*lastFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "report: invalid --last value %q: %v\n", *lastFlag, err)
+		os.Exit(2)
+	}
+
+	cfg, appLogger := loadConfig(*configPath)
+	defer func() { _ = appLogger.Sync() }()
+	if !cfg.History.Enabled {
+		appLogger.Warn("history.enabled is not set; only runs recorded while it was enabled are reported")
+	}
+
+	ctx, cancel := signalContext(appLogger)
+	defer cancel()
+
+	runs, err := integration.ReadHistory(ctx, cfg, time.Now().Add(-last), appLogger)
+	if err != nil {
+		appLogger.WithError(err).Fatal("Failed to read run history")
+	}
+
+	trends := history.Summarize(runs)
+	write := history.WriteTable
+	if *csvOutput {
+		write = history.WriteCSV
+	}
+	if err := write(os.Stdout, trends); err != nil {
+		appLogger.WithError(err).Fatal("Failed to write report")
+	}
+}
+
+// runTrace implements "gopost trace <article_id>": it prints when the article was
+// published, found, classified, queued, posted, and marked, to show where a late story
+// spent its time.
+func runTrace(args []string) {
+	flags := flag.NewFlagSet("trace", flag.ExitOnError)
+	configPath := flags.String("config", "config.yml", "Path to configuration file (empty: configure from GOPOST_* environment variables only)")
+	_ = flags.Parse(args)
+
+	if flags.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: gopost trace [--config path] <article_id>")
+		os.Exit(2)
+	}
+	articleID := flags.Arg(0)
+
+	cfg, appLogger := loadConfig(*configPath)
+	defer func() { _ = appLogger.Sync() }()
+	if !cfg.Timeline.Enabled {
+		appLogger.Warn("timeline.enabled is not set; only stages recorded while it was enabled are shown")
+	}
+
+	ctx, cancel := signalContext(appLogger)
+	defer cancel()
+
+	events, err := integration.ReadTimeline(ctx, cfg, articleID)
+	if err != nil {
+		appLogger.WithError(err).Fatal("Failed to read article timeline")
+	}
+	if len(events) == 0 {
+		fmt.Fprintf(os.Stderr, "trace: no timeline recorded for article %s (never found, or expired after timeline.ttl)\n", articleID)
+		_ = appLogger.Sync()
+		os.Exit(1)
+	}
+	if err := timeline.Write(os.Stdout, events); err != nil {
+		appLogger.WithError(err).Fatal("Failed to write timeline")
+	}
+}
+
+// runSmoke implements "gopost smoke": a single pass/fail end-to-end check of the live
+// backends for deployment pipelines. It exits 1 if any step fails.
+func runSmoke(args []string) {
+	flags := flag.NewFlagSet("smoke", flag.ExitOnError)
+	configPath := flags.String("config", "config.yml", "Path to configuration file (empty: configure from GOPOST_* environment variables only)")
+	_ = flags.Parse(args)
+
+	cfg, appLogger := loadConfig(*configPath)
+	defer func() { _ = appLogger.Sync() }()
+
+	ctx, cancel := signalContext(appLogger)
+	defer cancel()
+
+	report := integration.Smoke(ctx, cfg, appLogger)
+	for _, check := range report.Checks {
+		duration := check.Duration.Round(time.Millisecond)
+		if check.Err != nil {
+			fmt.Printf("FAIL  %s (%s): %v\n", check.Name, duration, check.Err)
+			continue
+		}
+		fmt.Printf("PASS  %s (%s)\n", check.Name, duration)
+	}
+
+	if !report.Passed() {
+		fmt.Println("smoke test failed")
+		_ = appLogger.Sync()
+		os.Exit(1)
+	}
+	fmt.Println("smoke test passed")
+}
+
+// runBenchmark implements "gopost benchmark [--articles N]": it runs synthetic articles
+// through the pipeline's in-process stages with the configured settings and fake backends,
+// and prints the throughput and allocations.
+func runBenchmark(args []string) {
+	flags := flag.NewFlagSet("benchmark", flag.ExitOnError)
+	configPath := flags.String("config", "config.yml", "Path to configuration file (empty: configure from GOPOST_* environment variables only)")
+	articles := flags.Int("articles", 10000, "Synthetic articles to process")
+	matching := flags.Float64("matching", 0.5, "Share of articles mentioning a crime keyword, 0 to 1")
+	duplicates := flags.Float64("duplicates", 0.1, "Share of articles repeating an earlier article's ID, 0 to 1")
+	_ = flags.Parse(args)
+
+	if *articles <= 0 || *matching < 0 || *matching > 1 || *duplicates < 0 || *duplicates > 1 {
+		fmt.Fprintln(os.Stderr, "usage: gopost benchmark [--articles N] [--matching 0-1] [--duplicates 0-1]")
+		os.Exit(2)
+	}
+
+	cfg, appLogger := loadConfig(*configPath)
+	defer func() { _ = appLogger.Sync() }()
+
+	ctx, cancel := signalContext(appLogger)
+	defer cancel()
+
+	result, err := integration.Benchmark(ctx, cfg, integration.BenchmarkOptions{
+		Articles:   *articles,
+		Matching:   *matching,
+		Duplicates: *duplicates,
+	})
+	if err != nil {
+		appLogger.WithError(err).Fatal("Benchmark failed")
+	}
+	fmt.Printf("articles:      %d (%d posted, %d skipped)\n", result.Articles, result.Posted, result.Skipped)
+	fmt.Printf("duration:      %s\n", result.Duration.Round(time.Millisecond))
+	fmt.Printf("throughput:    %.0f articles/sec\n", result.ArticlesPerSecond())
+	fmt.Printf("allocations:   %d (%d per article)\n", result.Allocs, result.Allocs/uint64(result.Articles))
+	fmt.Printf("bytes:         %d (%d per article)\n", result.Bytes, result.Bytes/uint64(result.Articles))
+}
+
+// runMigrateKeys implements "gopost migrate-keys [--from <prefix>] [--dry-run]": it moves
+// existing Redis keys under redis.key_prefix, by default the dedup markers written before
+// keys were prefixed.
+func runMigrateKeys(args []string) {
+	flags := flag.NewFlagSet("migrate-keys", flag.ExitOnError)
+	configPath := flags.String("config", "config.yml", "Path to configuration file (empty: configure from GOPOST_* environment variables only)")
+	from := flags.String("from", "", `Move every key under this earlier prefix, e.g. "gopost:" (default: only the unprefixed posted:article:* markers)`)
+	dryRun := flags.Bool("dry-run", false, "List the keys without renaming them")
+	_ = flags.Parse(args)
+
+	cfg, appLogger := loadConfig(*configPath)
+	defer func() { _ = appLogger.Sync() }()
+
+	ctx, cancel := signalContext(appLogger)
+	defer cancel()
+
+	migration, err := integration.MigrateKeys(ctx, cfg, *from, *dryRun)
+	status := "moved"
+	if *dryRun {
+		status = "found"
+	}
+	for _, key := range migration.Renamed {
+		fmt.Printf("%-7s  %s\n", status, key)
+	}
+	for _, key := range migration.Skipped {
+		fmt.Printf("%-7s  %s (new name already taken)\n", "skipped", key)
+	}
+	if err != nil {
+		appLogger.WithError(err).Fatal("Key migration failed")
+	}
+	fmt.Printf("%d keys %s, %d skipped, under prefix %q\n", len(migration.Renamed), status, len(migration.Skipped), cfg.Redis.KeyPrefix)
+}
+
+// runDedupStats implements "gopost dedup-stats [--oldest <n>] [article_id...]": it reports
+// how many dedup markers Redis holds, an estimate of their memory, the oldest posted
+// markers, and the remaining TTL of the given articles.
+func runDedupStats(args []string) {
+	flags := flag.NewFlagSet("dedup-stats", flag.ExitOnError)
+	configPath := flags.String("config", "config.yml", "Path to configuration file (empty: configure from GOPOST_* environment variables only)")
+	oldest := flags.Int("oldest", 10, "List this many posted markers closest to expiry")
+	_ = flags.Parse(args)
+
+	cfg, appLogger := loadConfig(*configPath)
+	defer func() { _ = appLogger.Sync() }()
+
+	ctx, cancel := signalContext(appLogger)
+	defer cancel()
+
+	all, err := integration.DedupStatistics(ctx, cfg, *oldest, flags.Args())
+	for i, stats := range all {
+		if i > 0 {
+			fmt.Println()
+		}
+		if stats.Tenant != "" {
+			fmt.Printf("tenant:        %s\n", stats.Tenant)
+		}
+		fmt.Printf("markers:       %d\n", stats.Count)
+		fmt.Printf("average size:  %d bytes\n", stats.AverageBytes)
+		fmt.Printf("estimated:     %d bytes\n", stats.EstimatedBytes())
+		if len(stats.Oldest) > 0 {
+			fmt.Println("oldest:")
+		}
+		for _, marker := range stats.Oldest {
+			fmt.Printf("  %-21s  %s\n", formatMarkerTTL(marker.TTL), marker.ID)
+		}
+		for _, articleID := range flags.Args() {
+			ttl, ok := stats.TTLs[articleID]
+			status := "not tracked"
+			if ok {
+				status = formatMarkerTTL(ttl)
+			}
+			fmt.Printf("%s: %s\n", articleID, status)
+		}
+	}
+	if err != nil {
+		appLogger.WithError(err).Fatal("Failed to read dedup statistics")
+	}
+}
+
+// formatMarkerTTL describes a marker's remaining TTL, where 0 means it never expires.
+func formatMarkerTTL(ttl time.Duration) string {
+	if ttl == 0 {
+		return "never expires"
+	}
+	return "expires in " + ttl.Round(time.Second).String()
+}
+
+// runSearchTemplate implements "gopost search-template push [--file <path>]": it stores the
+// mustache template as the search template search_template.id in Elasticsearch.
+func runSearchTemplate(args []string) {
+	if len(args) == 0 || args[0] != "push" {
+		fmt.Fprintln(os.Stderr, "usage: gopost search-template push [--config <path>] [--file <path>]")
+		os.Exit(2)
+	}
+	flags := flag.NewFlagSet("search-template push", flag.ExitOnError)
+	configPath := flags.String("config", "config.yml", "Path to configuration file (empty: configure from GOPOST_* environment variables only)")
+	file := flags.String("file", "", "Mustache template to store (default: search_template.file)")
+	_ = flags.Parse(args[1:])
+
+	cfg, appLogger := loadConfig(*configPath)
+	defer func() { _ = appLogger.Sync() }()
+
+	ctx, cancel := signalContext(appLogger)
+	defer cancel()
+
+	if *file == "" {
+		*file = cfg.SearchTemplate.File
+	}
+	if err := integration.PushSearchTemplate(ctx, cfg, *file); err != nil {
+		appLogger.WithError(err).Fatal("Failed to push search template")
+	}
+	appLogger.Info("Pushed search template",
+		logger.String("template_id", cfg.SearchTemplate.ID),
+		logger.String("file", *file),
+	)
+}
+
+// runDoctor implements "gopost doctor": it diagnoses the environment gopost runs in
+// (limits, DNS, TLS, clock skew, Redis latency) and prints a hint for each problem.
+// It exits 1 if any check fails; warnings alone do not.
+func runDoctor(args []string) {
+	flags := flag.NewFlagSet("doctor", flag.ExitOnError)
+	configPath := flags.String("config", "config.yml", "Path to configuration file (empty: configure from GOPOST_* environment variables only)")
+	_ = flags.Parse(args)
+
+	cfg, appLogger := loadConfig(*configPath)
+	defer func() { _ = appLogger.Sync() }()
+
+	ctx, cancel := signalContext(appLogger)
+	defer cancel()
+
+	report := doctor.Run(ctx, cfg)
+	for _, check := range report.Checks {
+		fmt.Printf("%-4s  %s: %s\n", check.Status, check.Name, check.Detail)
+		if check.Hint != "" {
+			fmt.Printf("      hint: %s\n", check.Hint)
+		}
+	}
+
+	if report.Failed() {
+		fmt.Println("doctor found problems")
+		_ = appLogger.Sync()
+		os.Exit(1)
+	}
+	fmt.Println("doctor found no blocking problems")
+}
+
+// parseLast parses a reporting period: whole days ("7d") or a Go duration ("12h").
+func parseLast(value string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n <= 0 {
+			return 0, errors.New("days must be a positive integer")
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	period, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, err
+	}
+	if period <= 0 {
+		return 0, errors.New("period must be positive")
+	}
+	return period, nil
+}
+
+// parseSince accepts a duration before now ("72h", "2 hours ago") or any date the article
+// date parser understands; dates without a zone are in loc.
+func parseSince(value string, loc *time.Location, now time.Time) (time.Time, error) {
+	if ago, err := time.ParseDuration(strings.TrimSuffix(value, " ago")); err == nil {
+		return now.Add(-ago), nil
+	}
+	if ago, ok := parseAgo(value); ok {
+		return now.Add(-ago), nil
+	}
+	since, err := pipeline.NewDateParser(nil, loc).ParseString(value)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if since.IsZero() {
+		return time.Time{}, fmt.Errorf("parse date %q: empty", value)
+	}
+	return since, nil
+}
+
+// agoUnits are the units parseAgo understands, singular.
+var agoUnits = map[string]time.Duration{
+	"minute": time.Minute,
+	"hour":   time.Hour,
+	"day":    24 * time.Hour,
+	"week":   7 * 24 * time.Hour,
+}
+
+// parseAgo parses a relative time such as "2 hours ago" or "1 day ago".
+func parseAgo(value string) (time.Duration, bool) {
+	fields := strings.Fields(value)
+	if len(fields) != 3 || fields[2] != "ago" {
+		return 0, false
+	}
+	n, err := strconv.Atoi(fields[0])
+	unit, ok := agoUnits[strings.TrimSuffix(fields[1], "s")]
+	if err != nil || n < 0 || !ok {
+		return 0, false
+	}
+	return time.Duration(n) * unit, true
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "version" {
+		fmt.Println(buildinfo.Get())
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "once" {
+		runOnce(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "replay" {
+		runReplay(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "rollback" {
+		runRollback(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "preview" {
+		runPreview(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "report" {
+		runReport(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "trace" {
+		runTrace(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "smoke" {
+		runSmoke(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "search-template" {
+		runSearchTemplate(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "doctor" {
+		runDoctor(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "benchmark" {
+		runBenchmark(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "migrate-keys" {
+		runMigrateKeys(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "dedup-stats" {
+		runDedupStats(os.Args[2:])
+		return
+	}
+
 	var configPath string
 	var flushCache bool
-	flag.StringVar(&configPath, "config", "config.yml", "Path to configuration file")
+	var showVersion bool
+	var enableChaos bool
+	flag.StringVar(&configPath, "config", "config.yml", "Path to configuration file (empty: configure from GOPOST_* environment variables only)")
 	flag.BoolVar(&flushCache, "flush-cache", false, "Flush Redis deduplication cache and exit")
+	flag.BoolVar(&showVersion, "version", false, "Print version information and exit")
+	flag.BoolVar(&enableChaos, "chaos", false, "Inject the backend failures configured under chaos (staging only)")
 	flag.Parse()
 
+	if showVersion {
+		fmt.Println(buildinfo.Get())
+		return
+	}
+
+	cfg, appLogger, service := loadService(configPath, enableChaos)
+	defer func() {
+		if syncErr := appLogger.Sync(); syncErr != nil {
+			// Can't log this error since logger might be closed
+			_ = syncErr
+		}
+	}()
+
+	// Handle flush-cache flag
+	if flushCache {
+		handleFlushCache(service, appLogger)
+		return
+	}
+
+	ctx, cancel := signalContext(appLogger)
+	defer cancel()
+
+	build := buildinfo.Get()
+	appLogger.Info("Starting integration service",
+		logger.String("config_path", configPath),
+		logger.Bool("debug", cfg.Debug),
+		logger.String("commit", build.Commit),
+		logger.String("build_date", build.Date),
+		logger.String("go_version", build.GoVersion),
+	)
+
+	if cfg.Admin.Addr != "" {
+		adminOpts := []admin.Option{
+			admin.WithPauseControl(service),
+			admin.WithSyncTrigger(service),
+			admin.WithDedupLookup(service),
+		}
+		if cfg.Suppression.Enabled {
+			adminOpts = append(adminOpts, admin.WithSuppressionControl(service, cfg.Suppression.WebhookToken))
+		}
+		if cfg.Moderation.Enabled {
+			adminOpts = append(adminOpts, admin.WithModerationReceiver(service, cfg.Moderation.WebhookToken))
+		}
+		adminServer := admin.NewServer(cfg.Admin.Addr, service, appLogger, adminOpts...)
+		go func() {
+			if adminErr := adminServer.Run(ctx); adminErr != nil {
+				appLogger.Error("Admin server stopped",
+					logger.Error(adminErr),
+				)
+			}
+		}()
+	}
+	if cfg.GRPC.Addr != "" {
+		startGRPCServer(ctx, cfg.GRPC, service, appLogger)
+	}
+
+	handleOperatorSignals(ctx, service, appLogger)
+	notifySystemd(ctx, service, appLogger)
+	defer func() { _, _ = sdnotify.Notify(sdnotify.Stopping) }()
+
+	if runErr := service.Run(ctx); runErr != nil && !errors.Is(runErr, context.Canceled) {
+		appLogger.WithError(runErr).Fatal("Service error")
+	}
+
+	appLogger.Info("Service stopped")
+}
+
+// startGRPCServer serves the admin gRPC API in the background, with TLS when a certificate
+// is configured. It exits if the TLS files cannot be loaded.
+func startGRPCServer(ctx context.Context, grpcCfg config.GRPCConfig, service pipelines, appLogger logger.Logger) {
+	var tlsConfig *tls.Config
+	if grpcCfg.CertFile != "" {
+		var err error
+		tlsConfig, err = grpcadmin.LoadTLSConfig(grpcCfg.CertFile, grpcCfg.KeyFile, grpcCfg.ClientCAFile)
+		if err != nil {
+			appLogger.WithError(err).Fatal("Failed to load gRPC TLS configuration")
+		}
+	}
+
+	grpcServer := grpcadmin.NewServer(grpcCfg.Addr, service, tlsConfig, appLogger)
+	go func() {
+		if grpcErr := grpcServer.Run(ctx); grpcErr != nil {
+			appLogger.Error("gRPC server stopped",
+				logger.Error(grpcErr),
+			)
+		}
+	}()
+}
+
+// notifySystemd reports readiness to systemd and, when WatchdogSec= is set, feeds the
+// watchdog while runs keep completing. It does nothing outside systemd.
+func notifySystemd(ctx context.Context, service pipelines, appLogger logger.Logger) {
+	if sent, err := sdnotify.Notify(sdnotify.Ready); err != nil {
+		appLogger.Warn("Failed to notify systemd",
+			logger.Error(err),
+		)
+	} else if sent {
+		appLogger.Info("Notified systemd of readiness")
+	}
+
+	interval, ok := sdnotify.WatchdogInterval()
+	if !ok {
+		return
+	}
+	appLogger.Info("Feeding systemd watchdog",
+		logger.Duration("watchdog_interval", interval),
+	)
+	// A run may take up to check_interval to start plus the watchdog interval to finish
+	// before it counts as stuck
+	go sdnotify.RunWatchdog(ctx, interval, func() bool {
+		return service.Healthy(interval)
+	}, appLogger)
+}
+
+// loadConfig loads the configuration (with cities from the sources service when enabled)
+// and creates the logger. It exits on failure.
+func loadConfig(configPath string) (*config.Config, logger.Logger) {
 	// Load configuration first (needed to determine debug mode)
 	// Load base config to determine debug mode
 	baseCfg, err := config.Load(configPath)
@@ -70,7 +892,7 @@ func main() {
 			logger.Error(err),
 		)
 		_ = tempLogger.Sync()
-		os.Exit(1)
+		os.Exit(exitConfigError)
 	}
 
 	// Create logger based on debug mode from config
@@ -78,17 +900,15 @@ func main() {
 	if err != nil {
 		// Fallback to temporary logger if logger creation fails
 		tempLogger, _ := logger.NewLogger(true)
-		tempLogger.Error("Failed to create logger",
-			logger.Error(err),
-		)
-		_ = tempLogger.Sync()
-		os.Exit(1)
+		tempLogger.WithError(err).Fatal("Failed to create logger")
 	}
 
+	applyUserAgentDefault(baseCfg)
+
 	// If sources service is enabled, try to fetch cities from it
 	var cfg *config.Config
 	if baseCfg.Sources.Enabled {
-		sourcesClient := sources.NewClient(&baseCfg.Sources, appLogger)
+		sourcesClient := sources.NewClient(&baseCfg.Sources, baseCfg.UserAgent, appLogger)
 		cfg, err = config.LoadWithSources(configPath, sourcesClient)
 		if err != nil {
 			appLogger.Warn("Failed to load config with sources, falling back to config file",
@@ -99,35 +919,87 @@ func main() {
 			appLogger.Info("Loaded cities from sources service",
 				logger.Int("city_count", len(cfg.Cities)),
 			)
+			applyUserAgentDefault(cfg)
 		}
 	} else {
 		cfg = baseCfg
 	}
-	defer func() {
-		if syncErr := appLogger.Sync(); syncErr != nil {
-			// Can't log this error since logger might be closed
-			_ = syncErr
+
+	for _, warning := range cfg.Warnings() {
+		appLogger.Warn("Configuration warning",
+			logger.String("warning", warning),
+		)
+	}
+	return cfg, appLogger
+}
+
+// loadService loads the configuration, creates the logger, and builds the integration
+// service, or one service per tenant when tenants are configured. enableChaos turns on
+// chaos mode regardless of chaos.enabled. It exits on failure.
+func loadService(configPath string, enableChaos bool) (*config.Config, logger.Logger, pipelines) {
+	cfg, appLogger := loadConfig(configPath)
+
+	if enableChaos {
+		cfg.Chaos.Enabled = true
+		if err := cfg.Validate(); err != nil {
+			appLogger.Error("Invalid chaos configuration",
+				logger.Error(err),
+			)
+			_ = appLogger.Sync()
+			os.Exit(exitConfigError)
 		}
-	}()
+	}
+
+	if len(cfg.Tenants) > 0 {
+		return cfg, appLogger, newTenantGroup(cfg, appLogger)
+	}
 
 	// Create integration service with logger
 	service, err := integration.NewService(cfg, appLogger)
 	if err != nil {
-		appLogger.Error("Failed to create integration service",
-			logger.Error(err),
-		)
-		_ = appLogger.Sync()
-		os.Exit(1)
+		exitServiceError(appLogger, err)
 	}
 
-	// Handle flush-cache flag
-	if flushCache {
-		handleFlushCache(service, appLogger)
-		return
+	return cfg, appLogger, service
+}
+
+// exitServiceError logs why the integration service could not be created and exits: with
+// exitBackendUnavailable when a backend was unreachable, else exitConfigError, since the
+// service rejects configurations it cannot run.
+func exitServiceError(appLogger logger.Logger, err error) {
+	code := exitConfigError
+	if errors.Is(err, integration.ErrBackendUnavailable) {
+		code = exitBackendUnavailable
 	}
+	appLogger.Error("Failed to create integration service",
+		logger.Int("exit_code", code),
+		logger.Error(err),
+	)
+	_ = appLogger.Sync()
+	os.Exit(code)
+}
 
+// newTenantGroup builds an isolated service per tenant, each logging with a tenant field.
+// It exits on failure.
+func newTenantGroup(cfg *config.Config, appLogger logger.Logger) *integration.Group {
+	group := integration.NewGroup()
+	for _, tenant := range cfg.Tenants {
+		tenantLogger := appLogger.With(logger.String("tenant", tenant.Name))
+		service, err := integration.NewService(cfg.ForTenant(tenant), tenantLogger)
+		if err != nil {
+			exitServiceError(tenantLogger, err)
+		}
+		group.Add(tenant.Name, service)
+	}
+	appLogger.Info("Multi-tenant mode",
+		logger.Int("tenant_count", len(cfg.Tenants)),
+	)
+	return group
+}
+
+// signalContext returns a context canceled on SIGINT or SIGTERM.
+func signalContext(appLogger logger.Logger) (context.Context, context.CancelFunc) {
 	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
 
 	// Handle graceful shutdown
 	sigChan := make(chan os.Signal, 1)
@@ -141,18 +1013,5 @@ func main() {
 		cancel()
 	}()
 
-	appLogger.Info("Starting integration service",
-		logger.String("config_path", configPath),
-		logger.Bool("debug", cfg.Debug),
-	)
-
-	if runErr := service.Run(ctx); runErr != nil && !errors.Is(runErr, context.Canceled) {
-		appLogger.Error("Service error",
-			logger.Error(runErr),
-		)
-		_ = appLogger.Sync()
-		os.Exit(1)
-	}
-
-	appLogger.Info("Service stopped")
+	return ctx, cancel
 }
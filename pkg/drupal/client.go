@@ -0,0 +1,884 @@
+// Package drupal is a Drupal JSON:API client for posting articles and reading nodes.
+package drupal
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gopost/integration/internal/logger"
+	"github.com/gopost/integration/internal/netproxy"
+	"github.com/gopost/integration/internal/requestid"
+)
+
+// DefaultUserAgent is sent when no user agent is configured with WithUserAgent.
+const DefaultUserAgent = "gopost"
+
+type Client struct {
+	baseURL   string
+	userAgent string
+	client    *http.Client // authenticates every request; see authTransport
+	logger    logger.Logger
+	connStats func(ConnStats) // nil unless WithConnStats is set
+	proxy     string          // Proxy setting from WithProxy
+
+	// resourceEndpoints maps resource types to collection URLs detected by Probe
+	resourceEndpoints map[string]string
+	mu                sync.RWMutex
+}
+
+type ArticleRequest struct {
+	Title          string
+	Body           string
+	URL            string
+	GroupID        string
+	GroupType      string
+	ContentType    string
+	ExternalID     string
+	Intro          string
+	Description    string
+	OGTitle        string
+	OGDescription  string
+	OGImage        string
+	OGURL          string
+	WordCount      int
+	Category       string
+	Section        string
+	Keywords       []string
+	CanonicalURL   string
+	PublishedDate  time.Time
+	SourceName     string   // Outlet display name (field_source_name)
+	SourceURL      string   // Outlet homepage (field_source_url)
+	SourceTerms    []string // Outlet taxonomy term UUIDs (field_source_terms)
+	SourceTermType string   // JSON:API type of SourceTerms, e.g. "taxonomy_term--sources"
+	CrimeTerms     []string // Crime category taxonomy term UUIDs (field_crime_categories)
+	CrimeTermType  string   // JSON:API type of CrimeTerms, e.g. "taxonomy_term--crime_categories"
+	Language       string   // Node langcode, also sent as Accept-Language; empty leaves it to Drupal
+	LanguagePrefix string   // Path prefix of the language's routes, e.g. "fr" to post to /fr/jsonapi/...
+	RevisionLog    string   // Revision log message of the node (revision_log)
+	Promote        *bool    // Whether the node is promoted to the front page; nil keeps the content type's default
+	Sticky         *bool    // Whether the node is sticky at the top of lists; nil keeps the content type's default
+	PathAlias      string   // URL alias of the node, e.g. "/crime/sudbury/robbery-downtown", set with Pathauto bypassed
+
+	// Fields sets extra attributes by Drupal field name. Values are JSON-encoded as-is;
+	// use TextValue, LinkValue, DateTimeValue, and DateValue for structured fields.
+	Fields map[string]any
+}
+
+// TermReference is a JSON:API resource identifier for a taxonomy term.
+type TermReference struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+}
+
+type GroupReference struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+	Meta struct {
+		DrupalInternalTargetID int `json:"drupal_internal__target_id,omitempty"`
+	} `json:"meta,omitempty"`
+}
+
+// ArticleAttributes are the attributes of a posted article node.
+type ArticleAttributes struct {
+	Title              string         `json:"title"`
+	Langcode           string         `json:"langcode,omitempty"`
+	Body               map[string]any `json:"body,omitempty"`
+	FieldURL           map[string]any `json:"field_url,omitempty"`
+	FieldExternalID    string         `json:"field_external_id,omitempty"`
+	FieldIntro         string         `json:"field_intro,omitempty"`
+	FieldDescription   string         `json:"field_description,omitempty"`
+	FieldOGTitle       string         `json:"field_og_title,omitempty"`
+	FieldOGDescription string         `json:"field_og_description,omitempty"`
+	FieldOGImage       string         `json:"field_og_image,omitempty"`
+	FieldOGURL         string         `json:"field_og_url,omitempty"`
+	FieldWordCount     string         `json:"field_word_count,omitempty"`
+	FieldCategory      string         `json:"field_category,omitempty"`
+	FieldSection       string         `json:"field_section,omitempty"`
+	FieldKeywords      string         `json:"field_keywords,omitempty"`
+	FieldCanonicalURL  string         `json:"field_canonical_url,omitempty"`
+	FieldPublishedDate string         `json:"field_published_date,omitempty"`
+	FieldSourceName    string         `json:"field_source_name,omitempty"`
+	FieldSourceURL     map[string]any `json:"field_source_url,omitempty"`
+	RevisionLog        string         `json:"revision_log,omitempty"`
+	Promote            *bool          `json:"promote,omitempty"`
+	Sticky             *bool          `json:"sticky,omitempty"`
+	Path               *PathValue     `json:"path,omitempty"`
+
+	// Fields holds extra attributes from ArticleRequest.Fields; they are encoded
+	// alongside, and take precedence over, the fields above
+	Fields map[string]any `json:"-"`
+}
+
+type DrupalArticle struct {
+	Data struct {
+		Type          string            `json:"type"`
+		Attributes    ArticleAttributes `json:"attributes"`
+		Relationships struct {
+			FieldGroup *struct {
+				Data []GroupReference `json:"data"`
+			} `json:"field_group,omitempty"`
+			FieldSourceTerms *struct {
+				Data []TermReference `json:"data"`
+			} `json:"field_source_terms,omitempty"`
+			FieldCrimeCategories *struct {
+				Data []TermReference `json:"data"`
+			} `json:"field_crime_categories,omitempty"`
+		} `json:"relationships,omitempty"`
+	} `json:"data"`
+}
+
+type DrupalResponse struct {
+	Data struct {
+		ID   string `json:"id"`
+		Type string `json:"type"`
+	} `json:"data"`
+	Errors []DrupalError `json:"errors,omitempty"`
+}
+
+type DrupalError struct {
+	Status string      `json:"status"`
+	Title  string      `json:"title"`
+	Detail string      `json:"detail"`
+	Source ErrorSource `json:"source,omitzero"`
+}
+
+// ErrorSource locates the cause of an error in the request document.
+type ErrorSource struct {
+	Pointer   string `json:"pointer,omitempty"`   // JSON pointer, e.g. "/data/attributes/body/format"
+	Parameter string `json:"parameter,omitempty"` // Query parameter, for errors not in the document
+}
+
+// Field returns the attribute or relationship an error points at, e.g. "body" for
+// /data/attributes/body/format, or "" when the pointer names no field.
+func (e DrupalError) Field() (name string, relationship bool) {
+	rest, ok := strings.CutPrefix(e.Source.Pointer, "/data/")
+	if !ok {
+		return "", false
+	}
+	member, rest, _ := strings.Cut(rest, "/")
+	if member != "attributes" && member != "relationships" {
+		return "", false
+	}
+	name, _, _ = strings.Cut(rest, "/")
+	return name, member == "relationships"
+}
+
+// String formats the error as "title: detail (at pointer)".
+func (e DrupalError) String() string {
+	text := e.Title
+	if e.Detail != "" {
+		text += ": " + e.Detail
+	}
+	if e.Source.Pointer != "" {
+		text += " (at " + e.Source.Pointer + ")"
+	}
+	return text
+}
+
+// APIError is a Drupal error response with JSON:API error objects, such as the 422
+// returned when a node fails validation. Every error object is kept; use errors.As to
+// inspect them.
+type APIError struct {
+	StatusCode int
+	Errors     []DrupalError
+}
+
+func (e *APIError) Error() string {
+	if len(e.Errors) == 0 {
+		return fmt.Sprintf("drupal API error (%d): %s", e.StatusCode, http.StatusText(e.StatusCode))
+	}
+	details := make([]string, len(e.Errors))
+	for i, drupalErr := range e.Errors {
+		details[i] = drupalErr.String()
+	}
+	return fmt.Sprintf("drupal API error (%d): %s", e.StatusCode, strings.Join(details, "; "))
+}
+
+// Is makes errors.Is(err, ErrUnauthorized) report whether Drupal rejected the credentials:
+// any 401, and 403s other than a rejected CSRF token.
+func (e *APIError) Is(target error) bool {
+	if target != ErrUnauthorized {
+		return false
+	}
+	switch e.StatusCode {
+	case http.StatusUnauthorized:
+		return true
+	case http.StatusForbidden:
+		return !slices.ContainsFunc(e.Errors, func(drupalErr DrupalError) bool {
+			return isCSRFRejection([]byte(drupalErr.Detail))
+		})
+	}
+	return false
+}
+
+// ErrMaintenance is returned when the Drupal site is in maintenance mode. The request can
+// be retried unchanged once the site is back.
+var ErrMaintenance = errors.New("drupal site is in maintenance mode")
+
+// ErrNotFound is returned when Drupal answers 404, such as for a node that was deleted.
+var ErrNotFound = errors.New("drupal resource not found")
+
+// ErrUnauthorized matches errors for requests Drupal rejected because of the credentials
+// or their permissions (401, or a 403 not caused by the CSRF token). Unlike maintenance
+// mode, retrying will not help until the credentials are fixed.
+var ErrUnauthorized = errors.New("drupal rejected the credentials")
+
+// isCSRFRejection reports whether a 403 response body is Drupal's CSRF check failing
+// ("X-CSRF-Token request header is missing" or "... is invalid") rather than the
+// credentials.
+func isCSRFRejection(body []byte) bool {
+	return bytes.Contains(body, []byte(csrfHeader+" request header"))
+}
+
+// isMaintenance reports whether an error response comes from Drupal's maintenance mode,
+// which answers every request with 503 Service Unavailable and the site's maintenance
+// message ("... is currently under maintenance"), rather than from a failed request.
+func isMaintenance(resp *http.Response, body []byte) bool {
+	return resp.StatusCode == http.StatusServiceUnavailable &&
+		strings.Contains(strings.ToLower(string(body)), "maintenance")
+}
+
+// ClientOption configures optional Client behavior.
+type ClientOption func(*Client)
+
+// WithUserAgent sets the User-Agent header sent with every request (default: DefaultUserAgent).
+func WithUserAgent(userAgent string) ClientOption {
+	return func(c *Client) {
+		if userAgent != "" {
+			c.userAgent = userAgent
+		}
+	}
+}
+
+// WithConnStats calls observe with the connection reuse and timings of every request the
+// client sends, including CSRF token fetches, to verify that keep-alive and HTTP/2 work
+// through proxies. observe must be safe for concurrent use.
+func WithConnStats(observe func(ConnStats)) ClientOption {
+	return func(c *Client) {
+		c.connStats = observe
+	}
+}
+
+// WithProxy sends requests through the proxy setting, a SOCKS5 or HTTP proxy URL or
+// netproxy.Direct, instead of the HTTP_PROXY and HTTPS_PROXY environment variables.
+func WithProxy(setting string) ClientOption {
+	return func(c *Client) {
+		c.proxy = setting
+	}
+}
+
+// NewClient creates a Drupal JSON:API client.
+// A nil log discards client logs, for programs embedding this package without the gopost logger.
+func NewClient(baseURL, username, token, authMethod string, skipTLSVerify bool, log logger.Logger, opts ...ClientOption) (*Client, error) {
+	if log == nil {
+		log = logger.NewNopLogger()
+	}
+	if baseURL == "" {
+		return nil, errors.New("drupal URL is required")
+	}
+	if token == "" {
+		return nil, errors.New("drupal token is required")
+	}
+
+	c := &Client{
+		baseURL:   baseURL,
+		userAgent: DefaultUserAgent,
+		logger:    log,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	// The default transport is cloned, rather than replaced, so HTTP/2 and its connection
+	// pool settings still apply with a proxy or without TLS verification.
+	base := http.DefaultTransport
+	if skipTLSVerify || c.proxy != "" {
+		transport, err := netproxy.Transport(c.proxy)
+		if err != nil {
+			return nil, fmt.Errorf("drupal proxy: %w", err)
+		}
+		base = transport
+	}
+
+	// Skip TLS verification in development mode
+	if skipTLSVerify {
+		base.(*http.Transport).TLSClientConfig = &tls.Config{
+			InsecureSkipVerify: true,
+		}
+		log.Warn("TLS certificate verification is disabled",
+			logger.String("base_url", baseURL),
+			logger.String("component", "drupal_client"),
+		)
+	}
+
+	if c.connStats != nil {
+		base = &traceTransport{base: base, observe: c.connStats}
+	}
+	c.client = &http.Client{
+		Timeout:   30 * time.Second,
+		Transport: newAuthTransport(base, baseURL, username, token, authMethod, log),
+	}
+	return c, nil
+}
+
+// newRequest creates a request carrying the client's User-Agent and a fresh X-Request-ID.
+// The ID is returned so callers can log it for correlation with Drupal's access logs.
+func (c *Client) newRequest(ctx context.Context, method, url string, body io.Reader) (*http.Request, string, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return nil, "", err
+	}
+
+	requestID := requestid.New()
+	httpReq.Header.Set("User-Agent", c.userAgent)
+	httpReq.Header.Set(requestid.Header, requestID)
+	return httpReq, requestID, nil
+}
+
+// ArticleDocument returns the JSON:API document CreateArticle sends for req.
+func ArticleDocument(req ArticleRequest) DrupalArticle {
+	var drupalArticle DrupalArticle
+	mapArticleFields(req, &drupalArticle)
+
+	// field_group is optional - only include if GroupID is provided
+	// Drupal JSON:API expects relationship format with type and id (UUID)
+	if req.GroupID != "" && req.GroupType != "" {
+		groupItem := GroupReference{
+			Type: req.GroupType,
+			ID:   req.GroupID, // Use UUID, not numeric ID
+		}
+
+		// Include meta.drupal_internal__target_id if we know it
+		// For UUID e3d024a6-5f6f-4be8-8f3d-75639075959c, the numeric ID is 1
+		// TODO: Fetch group by UUID to get the numeric ID dynamically
+		if req.GroupID == "e3d024a6-5f6f-4be8-8f3d-75639075959c" {
+			groupItem.Meta.DrupalInternalTargetID = 1
+		}
+
+		drupalArticle.Data.Relationships.FieldGroup = &struct {
+			Data []GroupReference `json:"data"`
+		}{
+			Data: []GroupReference{groupItem},
+		}
+	}
+	return drupalArticle
+}
+
+// mapArticleFields maps ArticleRequest fields to DrupalArticle attributes
+func mapArticleFields(req ArticleRequest, drupalArticle *DrupalArticle) {
+	drupalArticle.Data.Type = req.ContentType
+	drupalArticle.Data.Attributes.Title = req.Title
+	drupalArticle.Data.Attributes.Langcode = req.Language
+	drupalArticle.Data.Attributes.RevisionLog = req.RevisionLog
+	drupalArticle.Data.Attributes.Promote = req.Promote
+	drupalArticle.Data.Attributes.Sticky = req.Sticky
+	if req.PathAlias != "" {
+		drupalArticle.Data.Attributes.Path = &PathValue{Alias: req.PathAlias}
+	}
+
+	if req.Body != "" {
+		// Drupal body field requires value and format structure
+		drupalArticle.Data.Attributes.Body = map[string]any{
+			"value":  req.Body,
+			"format": "full_html", // Use full_html format to preserve HTML content
+		}
+	}
+
+	// field_url is required - send as URI field in attributes
+	if req.URL != "" {
+		drupalArticle.Data.Attributes.FieldURL = map[string]any{
+			"uri": req.URL,
+		}
+	}
+
+	// Map additional fields from Elasticsearch
+	if req.ExternalID != "" {
+		drupalArticle.Data.Attributes.FieldExternalID = req.ExternalID
+	}
+	if req.Intro != "" {
+		drupalArticle.Data.Attributes.FieldIntro = req.Intro
+	}
+	if req.Description != "" {
+		drupalArticle.Data.Attributes.FieldDescription = req.Description
+	}
+	if req.OGTitle != "" {
+		drupalArticle.Data.Attributes.FieldOGTitle = req.OGTitle
+	}
+	if req.OGDescription != "" {
+		drupalArticle.Data.Attributes.FieldOGDescription = req.OGDescription
+	}
+	if req.OGImage != "" {
+		drupalArticle.Data.Attributes.FieldOGImage = req.OGImage
+	}
+	if req.OGURL != "" {
+		drupalArticle.Data.Attributes.FieldOGURL = req.OGURL
+	}
+	if req.WordCount > 0 {
+		// Drupal may expect word_count as string, convert to string
+		drupalArticle.Data.Attributes.FieldWordCount = strconv.Itoa(req.WordCount)
+	}
+	if req.Category != "" {
+		drupalArticle.Data.Attributes.FieldCategory = req.Category
+	}
+	if req.Section != "" {
+		drupalArticle.Data.Attributes.FieldSection = req.Section
+	}
+	if len(req.Keywords) > 0 {
+		// Join keywords array into a single string (pipe-separated as shown in ES)
+		drupalArticle.Data.Attributes.FieldKeywords = strings.Join(req.Keywords, "|")
+	}
+	if req.CanonicalURL != "" {
+		drupalArticle.Data.Attributes.FieldCanonicalURL = req.CanonicalURL
+	}
+	// field_published_date - convert time.Time to ISO8601 format
+	if !req.PublishedDate.IsZero() {
+		// Drupal expects ISO8601 format (e.g., "2025-12-09T00:00:00Z")
+		drupalArticle.Data.Attributes.FieldPublishedDate = req.PublishedDate.Format(time.RFC3339)
+	}
+	// Outlet attribution is only sent when a mapping is configured, so sites without
+	// these fields are unaffected
+	if req.SourceName != "" {
+		drupalArticle.Data.Attributes.FieldSourceName = req.SourceName
+	}
+	if req.SourceURL != "" {
+		drupalArticle.Data.Attributes.FieldSourceURL = map[string]any{
+			"uri":   req.SourceURL,
+			"title": req.SourceName,
+		}
+	}
+	if len(req.Fields) > 0 {
+		drupalArticle.Data.Attributes.Fields = req.Fields
+	}
+	if len(req.SourceTerms) > 0 && req.SourceTermType != "" {
+		terms := make([]TermReference, 0, len(req.SourceTerms))
+		for _, termID := range req.SourceTerms {
+			terms = append(terms, TermReference{Type: req.SourceTermType, ID: termID})
+		}
+		drupalArticle.Data.Relationships.FieldSourceTerms = &struct {
+			Data []TermReference `json:"data"`
+		}{
+			Data: terms,
+		}
+	}
+	if len(req.CrimeTerms) > 0 && req.CrimeTermType != "" {
+		terms := make([]TermReference, 0, len(req.CrimeTerms))
+		for _, termID := range req.CrimeTerms {
+			terms = append(terms, TermReference{Type: req.CrimeTermType, ID: termID})
+		}
+		drupalArticle.Data.Relationships.FieldCrimeCategories = &struct {
+			Data []TermReference `json:"data"`
+		}{
+			Data: terms,
+		}
+	}
+}
+
+// collectionEndpoint returns the JSON:API collection URL for a resource type such as
+// "node--article" or "group--crime_news"; a bare bundle is taken to be a node type.
+// Endpoints detected by Probe take precedence over the conventional /jsonapi/{entity}/{bundle} path.
+func (c *Client) collectionEndpoint(resourceType string) string {
+	c.mu.RLock()
+	href, ok := c.resourceEndpoints[resourceType]
+	c.mu.RUnlock()
+	if ok {
+		return href
+	}
+
+	entity, bundle, found := strings.Cut(resourceType, "--")
+	if !found {
+		entity, bundle = "node", resourceType
+	}
+	return fmt.Sprintf("%s/jsonapi/%s/%s", c.baseURL, entity, bundle)
+}
+
+// languageEndpoint inserts the language path prefix after the site URL, turning
+// https://example.com/jsonapi/node/article into https://example.com/fr/jsonapi/node/article.
+// Endpoints outside the site URL are returned unchanged.
+func (c *Client) languageEndpoint(endpoint, prefix string) string {
+	prefix = strings.Trim(prefix, "/")
+	rest, ok := strings.CutPrefix(endpoint, c.baseURL+"/")
+	if prefix == "" || !ok {
+		return endpoint
+	}
+	return c.baseURL + "/" + url.PathEscape(prefix) + "/" + rest
+}
+
+// PostArticle creates an article node.
+func (c *Client) PostArticle(ctx context.Context, req ArticleRequest) error {
+	_, err := c.CreateArticle(ctx, req)
+	return err
+}
+
+// CreateArticle creates an article node and returns its UUID.
+func (c *Client) CreateArticle(ctx context.Context, req ArticleRequest) (string, error) {
+	startTime := time.Now()
+
+	// Add method-level context
+	methodLogger := c.logger.With(
+		logger.String("method", "CreateArticle"),
+	)
+
+	drupalArticle := ArticleDocument(req)
+
+	payload, err := json.Marshal(drupalArticle)
+	if err != nil {
+		methodLogger.Error("Failed to marshal article payload",
+			logger.String("title", req.Title),
+			logger.String("content_type", req.ContentType),
+			logger.Error(err),
+		)
+		return "", fmt.Errorf("marshal payload: %w", err)
+	}
+
+	// Debug: Log the payload to verify group relationship
+	methodLogger.Debug("Article payload prepared",
+		logger.String("group_type", req.GroupType),
+		logger.String("group_id", req.GroupID),
+		logger.String("payload", string(payload)),
+	)
+
+	endpoint := c.languageEndpoint(c.collectionEndpoint(req.ContentType), req.LanguagePrefix)
+
+	methodLogger.Debug("Posting article to Drupal",
+		logger.String("endpoint", endpoint),
+		logger.String("langcode", req.Language),
+		logger.String("title", req.Title),
+		logger.String("content_type", req.ContentType),
+		logger.String("group_type", req.GroupType),
+		logger.String("group_id", req.GroupID),
+		logger.String("url", req.URL),
+		logger.Int("payload_size", len(payload)),
+	)
+
+	var resp *http.Response
+	var requestDuration time.Duration
+	requestLogger := methodLogger
+	for attempt := 1; ; attempt++ {
+		httpReq, requestID, httpErr := c.newRequest(ctx, http.MethodPost, endpoint, bytes.NewReader(payload))
+		if httpErr != nil {
+			methodLogger.Error("Failed to create HTTP request",
+				logger.String("endpoint", endpoint),
+				logger.String("title", req.Title),
+				logger.Error(httpErr),
+			)
+			return "", fmt.Errorf("create request: %w", httpErr)
+		}
+		requestLogger = methodLogger.With(logger.String("request_id", requestID))
+
+		httpReq.Header.Set("Content-Type", "application/vnd.api+json")
+		httpReq.Header.Set("Accept", "application/vnd.api+json")
+		if req.Language != "" {
+			httpReq.Header.Set("Accept-Language", req.Language)
+		}
+
+		requestStartTime := time.Now()
+		resp, err = c.client.Do(httpReq)
+		requestDuration = time.Since(requestStartTime)
+
+		if err != nil {
+			requestLogger.Error("HTTP request failed",
+				logger.String("endpoint", endpoint),
+				logger.String("title", req.Title),
+				logger.Duration("request_duration", requestDuration),
+				logger.Error(err),
+			)
+			return "", fmt.Errorf("http request: %w", err)
+		}
+
+		// A rejected CSRF token is retried once; the transport fetches a fresh token for
+		// every request
+		if attempt == 1 && resp.StatusCode == http.StatusForbidden {
+			bodyBytes, _ := io.ReadAll(resp.Body)
+			_ = resp.Body.Close()
+			if isCSRFRejection(bodyBytes) {
+				requestLogger.Warn("Drupal rejected the CSRF token, retrying with a fresh token",
+					logger.String("endpoint", endpoint),
+					logger.String("article_title", req.Title),
+				)
+				continue
+			}
+			resp.Body = io.NopCloser(bytes.NewReader(bodyBytes))
+		}
+		break
+	}
+	methodLogger = requestLogger
+	defer resp.Body.Close()
+
+	const badRequestStatusCode = 400
+	if resp.StatusCode >= badRequestStatusCode {
+		// Read the full response body for debugging
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		bodyStr := string(bodyBytes)
+
+		if isMaintenance(resp, bodyBytes) {
+			methodLogger.Debug("Drupal site in maintenance mode",
+				logger.String("endpoint", endpoint),
+				logger.String("article_title", req.Title),
+				logger.Duration("request_duration", requestDuration),
+			)
+			return "", ErrMaintenance
+		}
+
+		var drupalResp DrupalResponse
+		decodeErr := json.NewDecoder(bytes.NewReader(bodyBytes)).Decode(&drupalResp)
+
+		if decodeErr == nil && len(drupalResp.Errors) > 0 {
+			// Log all validation errors, not just the first one
+			errorDetails := make([]string, len(drupalResp.Errors))
+			var pointers []string
+			for i, err := range drupalResp.Errors {
+				errorDetails[i] = err.String()
+				if err.Source.Pointer != "" {
+					pointers = append(pointers, err.Source.Pointer)
+				}
+			}
+			allErrors := strings.Join(errorDetails, "; ")
+
+			errorDetail := drupalResp.Errors[0]
+			methodLogger.Error("Drupal API validation error",
+				logger.String("endpoint", endpoint),
+				logger.String("article_title", req.Title),
+				logger.String("group_type", req.GroupType),
+				logger.String("group_id", req.GroupID),
+				logger.Int("status_code", resp.StatusCode),
+				logger.String("status", resp.Status),
+				logger.Int("error_count", len(drupalResp.Errors)),
+				logger.String("all_errors", allErrors),
+				logger.String("error_status", errorDetail.Status),
+				logger.String("error_title", errorDetail.Title),
+				logger.String("error_detail", errorDetail.Detail),
+				logger.Strings("error_pointers", pointers),
+				logger.String("response_body", bodyStr),
+				logger.Duration("request_duration", requestDuration),
+			)
+			return "", &APIError{StatusCode: resp.StatusCode, Errors: drupalResp.Errors}
+		}
+
+		methodLogger.Error("Drupal API error",
+			logger.String("endpoint", endpoint),
+			logger.String("article_title", req.Title),
+			logger.Int("status_code", resp.StatusCode),
+			logger.String("status", resp.Status),
+			logger.Duration("request_duration", requestDuration),
+			logger.Error(decodeErr),
+		)
+		return "", &APIError{StatusCode: resp.StatusCode}
+	}
+
+	var drupalResp DrupalResponse
+	if decodeErr := json.NewDecoder(resp.Body).Decode(&drupalResp); decodeErr != nil {
+		totalDuration := time.Since(startTime)
+		methodLogger.Error("Failed to decode Drupal response",
+			logger.String("endpoint", endpoint),
+			logger.String("article_title", req.Title),
+			logger.Int("status_code", resp.StatusCode),
+			logger.Duration("request_duration", requestDuration),
+			logger.Duration("total_duration", totalDuration),
+			logger.Error(decodeErr),
+		)
+		return "", fmt.Errorf("decode response: %w", decodeErr)
+	}
+
+	totalDuration := time.Since(startTime)
+	methodLogger.Info("Successfully posted article to Drupal",
+		logger.String("endpoint", endpoint),
+		logger.String("article_title", req.Title),
+		logger.String("content_type", req.ContentType),
+		logger.String("drupal_id", drupalResp.Data.ID),
+		logger.String("drupal_type", drupalResp.Data.Type),
+		logger.Int("status_code", resp.StatusCode),
+		logger.Duration("request_duration", requestDuration),
+		logger.Duration("total_duration", totalDuration),
+	)
+
+	return drupalResp.Data.ID, nil
+}
+
+// DeleteNode deletes the node with the given UUID from a content type's collection. A
+// missing node returns ErrNotFound.
+func (c *Client) DeleteNode(ctx context.Context, contentType, id string) error {
+	endpoint := fmt.Sprintf("%s/%s", c.collectionEndpoint(contentType), id)
+
+	httpReq, requestID, err := c.newRequest(ctx, http.MethodDelete, endpoint, http.NoBody)
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	httpReq.Header.Set("Accept", "application/vnd.api+json")
+
+	resp, err := c.client.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("http request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("delete node %s: request %s: %w", id, requestID, ErrNotFound)
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("delete node %s: request %s: HTTP %d: %s", id, requestID, resp.StatusCode, string(bodyBytes))
+	}
+
+	c.logger.Info("Deleted node from Drupal",
+		logger.String("endpoint", endpoint),
+		logger.String("drupal_id", id),
+		logger.String("request_id", requestID),
+	)
+	return nil
+}
+
+// UnpublishNode sets the status of the node with the given UUID to unpublished, keeping
+// the node and its revisions. A missing node returns ErrNotFound.
+func (c *Client) UnpublishNode(ctx context.Context, contentType, id string) error {
+	resourceType := contentType
+	if !strings.Contains(resourceType, "--") {
+		resourceType = "node--" + resourceType
+	}
+	payload, err := json.Marshal(map[string]any{
+		"data": map[string]any{
+			"type":       resourceType,
+			"id":         id,
+			"attributes": map[string]any{"status": false},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("marshal payload: %w", err)
+	}
+	endpoint := fmt.Sprintf("%s/%s", c.collectionEndpoint(contentType), id)
+
+	httpReq, requestID, err := c.newRequest(ctx, http.MethodPatch, endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/vnd.api+json")
+	httpReq.Header.Set("Accept", "application/vnd.api+json")
+
+	resp, err := c.client.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("http request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("unpublish node %s: request %s: %w", id, requestID, ErrNotFound)
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("unpublish node %s: request %s: HTTP %d: %s", id, requestID, resp.StatusCode, string(bodyBytes))
+	}
+
+	c.logger.Info("Unpublished node in Drupal",
+		logger.String("endpoint", endpoint),
+		logger.String("drupal_id", id),
+		logger.String("request_id", requestID),
+	)
+	return nil
+}
+
+// doJSONAPIRequest performs a GET request to a Drupal JSON:API endpoint and decodes the response into v
+func (c *Client) doJSONAPIRequest(ctx context.Context, endpoint string, v any) error {
+	httpReq, requestID, err := c.newRequest(ctx, http.MethodGet, endpoint, http.NoBody)
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+
+	httpReq.Header.Set("Accept", "application/vnd.api+json")
+
+	resp, respErr := c.client.Do(httpReq)
+	if respErr != nil {
+		return fmt.Errorf("http request: %w", respErr)
+	}
+	defer resp.Body.Close()
+
+	bodyBytes, readErr := io.ReadAll(resp.Body)
+	if readErr != nil {
+		return fmt.Errorf("read response: %w", readErr)
+	}
+
+	const badRequestStatusCode = 400
+	if isMaintenance(resp, bodyBytes) {
+		return fmt.Errorf("request %s: %w", requestID, ErrMaintenance)
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("request %s: %w: %s", requestID, ErrNotFound, string(bodyBytes))
+	}
+	if resp.StatusCode >= badRequestStatusCode {
+		return fmt.Errorf("request %s: HTTP %d: %s", requestID, resp.StatusCode, string(bodyBytes))
+	}
+
+	if decodeErr := json.Unmarshal(bodyBytes, v); decodeErr != nil {
+		return fmt.Errorf("decode response: %w", decodeErr)
+	}
+
+	return nil
+}
+
+// GetNode fetches an article node by ID from Drupal JSON:API
+// nodeID can be either a UUID or numeric ID
+func (c *Client) GetNode(ctx context.Context, nodeID string, opts ...QueryOption) (*Node, error) {
+	endpoint := withQuery(fmt.Sprintf("%s/jsonapi/node/article/%s", c.baseURL, nodeID), url.Values{}, opts)
+	var doc nodeDocument
+	if err := c.doJSONAPIRequest(ctx, endpoint, &doc); err != nil {
+		return nil, err
+	}
+	doc.Data.Included = doc.Included
+	return &doc.Data, nil
+}
+
+// NodeExists reports whether the node with the given UUID is still in a content type's
+// collection. A deleted node answers 404; other failures are returned as errors.
+func (c *Client) NodeExists(ctx context.Context, contentType, id string) (bool, error) {
+	endpoint := fmt.Sprintf("%s/%s", c.collectionEndpoint(contentType), id)
+	var doc nodeDocument
+	err := c.doJSONAPIRequest(ctx, endpoint, &doc)
+	if errors.Is(err, ErrNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("get node %s: %w", id, err)
+	}
+	return true, nil
+}
+
+// ListNodes lists the first page of article nodes from Drupal JSON:API
+func (c *Client) ListNodes(ctx context.Context, limit int, opts ...QueryOption) (*NodeList, error) {
+	q := url.Values{"page[limit]": {strconv.Itoa(limit)}}
+	endpoint := withQuery(fmt.Sprintf("%s/jsonapi/node/article", c.baseURL), q, opts)
+	return c.listNodes(ctx, endpoint)
+}
+
+// ListResources lists the first page of any JSON:API resource type, such as
+// "group--crime_news". Follow further pages with NextNodes.
+func (c *Client) ListResources(ctx context.Context, resourceType string, opts ...QueryOption) (*NodeList, error) {
+	return c.listNodes(ctx, withQuery(c.collectionEndpoint(resourceType), url.Values{}, opts))
+}
+
+// NextNodes fetches the page after list by following its next link.
+// It returns ErrLastPage when list has no next link.
+func (c *Client) NextNodes(ctx context.Context, list *NodeList) (*NodeList, error) {
+	next := list.Next()
+	if next == "" {
+		return nil, ErrLastPage
+	}
+	return c.listNodes(ctx, next)
+}
+
+func (c *Client) listNodes(ctx context.Context, endpoint string) (*NodeList, error) {
+	var list NodeList
+	if err := c.doJSONAPIRequest(ctx, endpoint, &list); err != nil {
+		return nil, err
+	}
+	return &list, nil
+}
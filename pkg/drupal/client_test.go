@@ -0,0 +1,396 @@
+package drupal_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gopost/integration/internal/logger"
+	"github.com/gopost/integration/pkg/drupal"
+	"github.com/gopost/integration/pkg/drupal/drupaltest"
+)
+
+func TestPostArticle_IdentifiesRequests(t *testing.T) {
+	server := drupaltest.NewServer(t, "gopost", "secret")
+
+	tests := []struct {
+		name          string
+		opts          []drupal.ClientOption
+		wantUserAgent string
+	}{
+		{name: "default", wantUserAgent: drupal.DefaultUserAgent},
+		{name: "configured", opts: []drupal.ClientOption{drupal.WithUserAgent("gopost/1.2.3")}, wantUserAgent: "gopost/1.2.3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server.Reset()
+			client, err := drupal.NewClient(server.URL, "gopost", "secret", "", false, logger.NewNopLogger(), tt.opts...)
+			if err != nil {
+				t.Fatalf("NewClient() error = %v", err)
+			}
+
+			for range 2 {
+				if err := client.PostArticle(context.Background(), drupal.ArticleRequest{Title: "Police arrest suspect", ContentType: "node--article"}); err != nil {
+					t.Fatalf("PostArticle() error = %v", err)
+				}
+			}
+
+			nodes := server.Nodes()
+			if len(nodes) != 2 {
+				t.Fatalf("len(Nodes()) = %d, want 2", len(nodes))
+			}
+			for _, node := range nodes {
+				if got := node.Header.Get("User-Agent"); got != tt.wantUserAgent {
+					t.Errorf("User-Agent = %q, want %q", got, tt.wantUserAgent)
+				}
+			}
+			first, second := nodes[0].Header.Get("X-Request-ID"), nodes[1].Header.Get("X-Request-ID")
+			if first == "" || first == second {
+				t.Errorf("X-Request-ID values = %q, %q; want distinct non-empty IDs", first, second)
+			}
+		})
+	}
+}
+
+func TestNodeExists_ReportsDeletedNodes(t *testing.T) {
+	server := drupaltest.NewServer(t, "gopost", "secret")
+	client := server.Client(t)
+	ctx := context.Background()
+
+	id, err := client.CreateArticle(ctx, drupal.ArticleRequest{Title: "Police arrest suspect", ContentType: "node--article"})
+	if err != nil {
+		t.Fatalf("CreateArticle() error = %v", err)
+	}
+	if exists, err := client.NodeExists(ctx, "node--article", id); err != nil || !exists {
+		t.Fatalf("NodeExists() = %v, %v before deletion, want true", exists, err)
+	}
+	if err := client.DeleteNode(ctx, "node--article", id); err != nil {
+		t.Fatalf("DeleteNode() error = %v", err)
+	}
+	if exists, err := client.NodeExists(ctx, "node--article", id); err != nil || exists {
+		t.Errorf("NodeExists() = %v, %v after deletion, want false", exists, err)
+	}
+
+	// Errors other than 404 are not mistaken for deletions
+	bad, err := drupal.NewClient(server.URL, "gopost", "wrong", "", false, logger.NewNopLogger())
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	if _, err := bad.NodeExists(ctx, "node--article", id); err == nil {
+		t.Error("NodeExists() error = nil with rejected credentials, want error")
+	}
+}
+
+func TestUnpublishNode_KeepsNode(t *testing.T) {
+	server := drupaltest.NewServer(t, "gopost", "secret")
+	client := server.Client(t)
+	ctx := context.Background()
+
+	id, err := client.CreateArticle(ctx, drupal.ArticleRequest{Title: "Police arrest suspect", ContentType: "node--article"})
+	if err != nil {
+		t.Fatalf("CreateArticle() error = %v", err)
+	}
+	if err := client.UnpublishNode(ctx, "node--article", id); err != nil {
+		t.Fatalf("UnpublishNode() error = %v", err)
+	}
+	nodes := server.Nodes()
+	if len(nodes) != 1 || !nodes[0].Unpublished {
+		t.Errorf("nodes = %+v, want the node kept and unpublished", nodes)
+	}
+	if err := client.UnpublishNode(ctx, "node--article", "00000000-0000-4000-8000-999999999999"); !errors.Is(err, drupal.ErrNotFound) {
+		t.Errorf("UnpublishNode() of a missing node error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestClient_AuthenticatesEveryRequest(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		requests []*http.Request
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests = append(requests, r)
+		mu.Unlock()
+		switch {
+		case r.URL.Path == "/session/token":
+			_, _ = w.Write([]byte("csrf-token\n"))
+		case r.Method == http.MethodDelete:
+			w.WriteHeader(http.StatusNoContent)
+		case r.URL.Path == "/jsonapi/node/article":
+			w.Header().Set("Content-Type", "application/vnd.api+json")
+			_, _ = w.Write([]byte(`{"data":[]}`))
+		default:
+			w.Header().Set("Content-Type", "application/vnd.api+json")
+			_, _ = w.Write([]byte(`{"data":{"id":"1"}}`))
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := drupal.NewClient(server.URL, "gopost", "secret", "api_key", false, logger.NewNopLogger())
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	ctx := context.Background()
+	if _, err := client.GetNode(ctx, "1"); err != nil {
+		t.Fatalf("GetNode() error = %v", err)
+	}
+	if _, err := client.ListNodes(ctx, 5); err != nil {
+		t.Fatalf("ListNodes() error = %v", err)
+	}
+	if err := client.DeleteNode(ctx, "node--article", "1"); err != nil {
+		t.Fatalf("DeleteNode() error = %v", err)
+	}
+
+	const wantAPIKey = "Z29wb3N0OnNlY3JldA==" // base64("gopost:secret")
+	mu.Lock()
+	defer mu.Unlock()
+	if len(requests) != 4 {
+		t.Fatalf("server saw %d requests, want 4 (GET, GET, token, DELETE)", len(requests))
+	}
+	for _, r := range requests {
+		//nolint:canonicalheader // Drupal REST API requires exact header name
+		if got := r.Header.Get("API-KEY"); got != wantAPIKey {
+			t.Errorf("%s %s API-KEY = %q, want %q", r.Method, r.URL.Path, got, wantAPIKey)
+		}
+		if got := r.Header.Get("Authorization"); got != "Basic "+wantAPIKey {
+			t.Errorf("%s %s Authorization = %q, want Basic credentials", r.Method, r.URL.Path, got)
+		}
+		//nolint:canonicalheader // Drupal REST API requires exact header name
+		if got := r.Header.Get("AUTH-METHOD"); got != "api_key" {
+			t.Errorf("%s %s AUTH-METHOD = %q, want api_key", r.Method, r.URL.Path, got)
+		}
+
+		wantCSRF := ""
+		if r.Method == http.MethodDelete {
+			wantCSRF = "csrf-token"
+		}
+		if got := r.Header.Get("X-CSRF-Token"); got != wantCSRF {
+			t.Errorf("%s %s X-CSRF-Token = %q, want %q", r.Method, r.URL.Path, got, wantCSRF)
+		}
+	}
+}
+
+func TestCreateArticle_EncodesStructuredFields(t *testing.T) {
+	server := drupaltest.NewServer(t, "gopost", "secret")
+	client := server.Client(t)
+
+	published := time.Date(2025, 12, 9, 9, 30, 0, 0, time.FixedZone("EST", -5*60*60))
+	_, err := client.CreateArticle(context.Background(), drupal.ArticleRequest{
+		Title:       "Police arrest suspect",
+		ContentType: "node--article",
+		Intro:       "Built-in intro",
+		Fields: map[string]any{
+			"field_teaser":     drupal.TextValue{Value: "<p>Teaser</p>", Format: "basic_html"},
+			"field_original":   drupal.LinkValue{URI: "https://example.com/a", Title: "Original"},
+			"field_updated":    drupal.DateTimeValue(published),
+			"field_event_date": drupal.DateValue(published),
+			"field_intro":      "Mapped intro",
+		},
+	})
+	if err != nil {
+		t.Fatalf("CreateArticle() error = %v", err)
+	}
+
+	nodes := server.Nodes()
+	if len(nodes) != 1 {
+		t.Fatalf("len(Nodes()) = %d, want 1", len(nodes))
+	}
+	attributes := nodes[0].Article.Data.Attributes
+	if attributes.Title != "Police arrest suspect" {
+		t.Errorf("Title = %q, built-in attributes must still be sent", attributes.Title)
+	}
+	if attributes.FieldIntro != "Mapped intro" {
+		t.Errorf("field_intro = %q, want mapped value to take precedence", attributes.FieldIntro)
+	}
+
+	want := map[string]any{
+		"field_teaser":     map[string]any{"value": "<p>Teaser</p>", "format": "basic_html"},
+		"field_original":   map[string]any{"uri": "https://example.com/a", "title": "Original"},
+		"field_updated":    "2025-12-09T14:30:00+00:00",
+		"field_event_date": "2025-12-09",
+	}
+	if !reflect.DeepEqual(attributes.Fields, want) {
+		t.Errorf("Fields = %#v, want %#v", attributes.Fields, want)
+	}
+}
+
+func TestCreateArticle_PostsInLanguage(t *testing.T) {
+	server := drupaltest.NewServer(t, "gopost", "secret")
+	client := server.Client(t)
+
+	for _, req := range []drupal.ArticleRequest{
+		{Title: "Arrestation à Sudbury", ContentType: "node--article", Language: "fr", LanguagePrefix: "fr"},
+		{Title: "Arrest in Sudbury", ContentType: "node--article", Language: "en"},
+	} {
+		if _, err := client.CreateArticle(context.Background(), req); err != nil {
+			t.Fatalf("CreateArticle(%q) error = %v", req.Title, err)
+		}
+	}
+
+	nodes := server.Nodes()
+	if len(nodes) != 2 {
+		t.Fatalf("len(Nodes()) = %d, want 2", len(nodes))
+	}
+	for i, want := range []struct{ prefix, langcode string }{{"fr", "fr"}, {"", "en"}} {
+		node := nodes[i]
+		if node.Prefix != want.prefix {
+			t.Errorf("node %d posted with prefix %q, want %q", i, node.Prefix, want.prefix)
+		}
+		if node.Article.Data.Attributes.Langcode != want.langcode {
+			t.Errorf("node %d langcode = %q, want %q", i, node.Article.Data.Attributes.Langcode, want.langcode)
+		}
+		if got := node.Header.Get("Accept-Language"); got != want.langcode {
+			t.Errorf("node %d Accept-Language = %q, want %q", i, got, want.langcode)
+		}
+	}
+}
+
+func TestCreateArticle_DetectsMaintenanceMode(t *testing.T) {
+	server := drupaltest.NewServer(t, "gopost", "secret")
+	client := server.Client(t)
+	req := drupal.ArticleRequest{Title: "Police arrest suspect", ContentType: "node--article"}
+
+	server.FailNext(http.StatusServiceUnavailable, drupal.DrupalError{
+		Title:  "Service Unavailable",
+		Detail: "Gopost News is currently under maintenance. We should be back shortly.",
+	})
+	if _, err := client.CreateArticle(context.Background(), req); !errors.Is(err, drupal.ErrMaintenance) {
+		t.Errorf("CreateArticle() error = %v, want ErrMaintenance", err)
+	}
+
+	// An overloaded site also answers 503, but is not in maintenance
+	server.FailNext(http.StatusServiceUnavailable, drupal.DrupalError{Title: "Service Unavailable", Detail: "Backend fetch failed"})
+	if _, err := client.CreateArticle(context.Background(), req); err == nil || errors.Is(err, drupal.ErrMaintenance) {
+		t.Errorf("CreateArticle() error = %v, want a non-maintenance error", err)
+	}
+}
+
+func TestCreateArticle_ReturnsEveryValidationError(t *testing.T) {
+	server := drupaltest.NewServer(t, "gopost", "secret")
+	client := server.Client(t)
+
+	server.FailNext(http.StatusUnprocessableEntity,
+		drupal.DrupalError{
+			Title:  "Unprocessable Content",
+			Detail: "body.0.format: The value you selected is not a valid choice.",
+			Source: drupal.ErrorSource{Pointer: "/data/attributes/body/format"},
+		},
+		drupal.DrupalError{
+			Title:  "Unprocessable Content",
+			Detail: "field_group.0.target_id: This entity cannot be referenced.",
+			Source: drupal.ErrorSource{Pointer: "/data/relationships/field_group/data/0"},
+		},
+		drupal.DrupalError{Title: "Unprocessable Content", Detail: "Node validation failed."},
+	)
+	_, err := client.CreateArticle(context.Background(), drupal.ArticleRequest{Title: "Police arrest suspect", ContentType: "node--article"})
+
+	var apiErr *drupal.APIError
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("CreateArticle() error = %v, want *APIError", err)
+	}
+	if apiErr.StatusCode != http.StatusUnprocessableEntity || len(apiErr.Errors) != 3 {
+		t.Fatalf("APIError = %+v, want status 422 with 3 errors", apiErr)
+	}
+
+	type field struct {
+		name         string
+		relationship bool
+	}
+	var fields []field
+	for _, drupalErr := range apiErr.Errors {
+		name, relationship := drupalErr.Field()
+		fields = append(fields, field{name, relationship})
+	}
+	want := []field{{"body", false}, {"field_group", true}, {"", false}}
+	if !reflect.DeepEqual(fields, want) {
+		t.Errorf("Field() = %v, want %v", fields, want)
+	}
+
+	wantMessage := "drupal API error (422): Unprocessable Content: body.0.format: The value you selected is not a valid choice. (at /data/attributes/body/format); " +
+		"Unprocessable Content: field_group.0.target_id: This entity cannot be referenced. (at /data/relationships/field_group/data/0); " +
+		"Unprocessable Content: Node validation failed."
+	if err.Error() != wantMessage {
+		t.Errorf("Error() = %q, want %q", err.Error(), wantMessage)
+	}
+}
+
+func TestCreateArticle_RetriesRejectedCSRFToken(t *testing.T) {
+	server := drupaltest.NewServer(t, "gopost", "secret")
+	client := server.Client(t)
+	req := drupal.ArticleRequest{Title: "Police arrest suspect", ContentType: "node--article"}
+	csrfRejected := drupal.DrupalError{Title: "Forbidden", Detail: "X-CSRF-Token request header is invalid"}
+
+	server.FailNext(http.StatusForbidden, csrfRejected)
+	if _, err := client.CreateArticle(context.Background(), req); err != nil {
+		t.Fatalf("CreateArticle() error = %v, want the retry with a fresh token to succeed", err)
+	}
+	if len(server.Nodes()) != 1 {
+		t.Errorf("len(Nodes()) = %d, want 1", len(server.Nodes()))
+	}
+
+	// Only one retry, and a CSRF failure is not a credential failure
+	server.FailNext(http.StatusForbidden, csrfRejected)
+	server.FailNext(http.StatusForbidden, csrfRejected)
+	_, err := client.CreateArticle(context.Background(), req)
+	var apiErr *drupal.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusForbidden || errors.Is(err, drupal.ErrUnauthorized) {
+		t.Errorf("CreateArticle() error = %v, want a 403 APIError that is not ErrUnauthorized", err)
+	}
+}
+
+func TestCreateArticle_ReportsRejectedCredentials(t *testing.T) {
+	server := drupaltest.NewServer(t, "gopost", "secret")
+	client, err := drupal.NewClient(server.URL, "gopost", "wrong", "", false, logger.NewNopLogger())
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	_, err = client.CreateArticle(context.Background(), drupal.ArticleRequest{Title: "Police arrest suspect", ContentType: "node--article"})
+	if !errors.Is(err, drupal.ErrUnauthorized) {
+		t.Errorf("CreateArticle() error = %v, want ErrUnauthorized", err)
+	}
+}
+
+func TestClient_ReportsConnStats(t *testing.T) {
+	server := drupaltest.NewServer(t, "gopost", "secret")
+	var mu sync.Mutex
+	var stats []drupal.ConnStats
+	client, err := drupal.NewClient(server.URL, "gopost", "secret", "", false, logger.NewNopLogger(),
+		drupal.WithConnStats(func(s drupal.ConnStats) {
+			mu.Lock()
+			defer mu.Unlock()
+			stats = append(stats, s)
+		}),
+	)
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	req := drupal.ArticleRequest{Title: "Police arrest suspect", ContentType: "node--article"}
+	for range 2 {
+		if _, err := client.CreateArticle(context.Background(), req); err != nil {
+			t.Fatalf("CreateArticle() error = %v", err)
+		}
+	}
+
+	// A CSRF token fetch and a POST per article, all over the first connection
+	if len(stats) != 4 {
+		t.Fatalf("observed %d requests, want 4", len(stats))
+	}
+	for i, s := range stats {
+		if s.Protocol != "HTTP/1.1" || s.TTFB <= 0 {
+			t.Errorf("stats[%d] = %+v, want HTTP/1.1 with a TTFB", i, s)
+		}
+		if wantReused := i > 0; s.Reused != wantReused {
+			t.Errorf("stats[%d].Reused = %v, want %v", i, s.Reused, wantReused)
+		}
+	}
+	if stats[0].Connect <= 0 {
+		t.Errorf("stats[0].Connect = %v, want the connect time of the new connection", stats[0].Connect)
+	}
+}
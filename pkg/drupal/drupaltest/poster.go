@@ -0,0 +1,53 @@
+package drupaltest
+
+import (
+	"context"
+	"sync"
+
+	"github.com/gopost/integration/pkg/drupal"
+)
+
+// Poster is a fake article poster that records requests instead of calling Drupal.
+// The zero value accepts every post.
+type Poster struct {
+	// Err, if set, is returned from every PostArticle call.
+	Err error
+	// ErrFunc, if set, decides the result per request and takes precedence over Err.
+	ErrFunc func(req drupal.ArticleRequest) error
+
+	mu       sync.Mutex
+	posted   []drupal.ArticleRequest
+	attempts int
+}
+
+// PostArticle records the request and returns the configured error, if any.
+// Only successful posts are recorded.
+func (p *Poster) PostArticle(_ context.Context, req drupal.ArticleRequest) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.attempts++
+	err := p.Err
+	if p.ErrFunc != nil {
+		err = p.ErrFunc(req)
+	}
+	if err != nil {
+		return err
+	}
+	p.posted = append(p.posted, req)
+	return nil
+}
+
+// Posted returns a copy of the successfully posted requests.
+func (p *Poster) Posted() []drupal.ArticleRequest {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]drupal.ArticleRequest(nil), p.posted...)
+}
+
+// Attempts returns the number of PostArticle calls, including failed ones.
+func (p *Poster) Attempts() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.attempts
+}
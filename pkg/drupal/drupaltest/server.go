@@ -0,0 +1,391 @@
+// Package drupaltest provides test doubles for the Drupal JSON:API client:
+// an httptest-based fake JSON:API server and a fake Poster.
+// It lets pipeline code and forks write integration tests without a live Drupal site.
+package drupaltest
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gopost/integration/internal/logger"
+	"github.com/gopost/integration/pkg/drupal"
+)
+
+// CSRFToken is the token served by the fake /session/token endpoint.
+const CSRFToken = "drupaltest-csrf-token"
+
+// Node is a node recorded by the fake server.
+type Node struct {
+	ID      string               // UUID assigned by the fake server
+	Bundle  string               // Bundle from the request path (e.g. "article")
+	Prefix  string               // Language path prefix from the request path (e.g. "fr"), if any
+	Article drupal.DrupalArticle // Decoded JSON:API payload
+	Header  http.Header          // Request headers, for asserting on auth and CSRF headers
+
+	Unpublished bool // Set by a PATCH setting status to false
+}
+
+// resource is a labeled group or taxonomy term entity served by the fake server.
+type resource struct {
+	entity string // "group" or "taxonomy_term"
+	bundle string
+	id     string
+	label  string
+}
+
+// failure is a queued error response returned instead of handling the next POST.
+type failure struct {
+	statusCode int
+	errors     []drupal.DrupalError
+}
+
+// Server is a fake Drupal JSON:API server backed by httptest.
+// It validates authentication headers the same way the REST API Authentication module does,
+// records posted nodes, and can be told to fail upcoming posts.
+type Server struct {
+	*httptest.Server
+
+	username string
+	token    string
+
+	mu       sync.Mutex
+	nodes    []Node
+	labeled  []resource
+	failures []failure
+}
+
+// NewServer starts a fake Drupal server that accepts the given credentials.
+// The server is closed automatically when the test finishes.
+func NewServer(tb testing.TB, username, token string) *Server {
+	tb.Helper()
+
+	s := &Server{
+		username: username,
+		token:    token,
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /session/token", s.handleSessionToken)
+	mux.HandleFunc("GET /jsonapi", s.handleRoot)
+	mux.HandleFunc("POST /jsonapi/node/{bundle}", s.handleCreate)
+	mux.HandleFunc("POST /{prefix}/jsonapi/node/{bundle}", s.handleCreate)
+	mux.HandleFunc("GET /jsonapi/node/{bundle}", s.handleList)
+	mux.HandleFunc("GET /jsonapi/node/{bundle}/{id}", s.handleGet)
+	mux.HandleFunc("PATCH /jsonapi/node/{bundle}/{id}", s.handleUpdate)
+	mux.HandleFunc("DELETE /jsonapi/node/{bundle}/{id}", s.handleDelete)
+	mux.HandleFunc("GET /jsonapi/group/{bundle}", s.handleListLabeled("group", "label"))
+	mux.HandleFunc("GET /jsonapi/taxonomy_term/{bundle}", s.handleListLabeled("taxonomy_term", "name"))
+
+	s.Server = httptest.NewServer(mux)
+	tb.Cleanup(s.Close)
+	return s
+}
+
+// Client returns a drupal.Client configured with the server's URL and credentials.
+func (s *Server) Client(tb testing.TB) *drupal.Client {
+	tb.Helper()
+
+	client, err := drupal.NewClient(s.URL, s.username, s.token, "", false, logger.NewNopLogger())
+	if err != nil {
+		tb.Fatalf("drupaltest: create client: %v", err)
+	}
+	return client
+}
+
+// Nodes returns a copy of all nodes posted so far.
+func (s *Server) Nodes() []Node {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Node(nil), s.nodes...)
+}
+
+// AddGroup adds a group of the bundle (e.g. "crime_news") with the label and returns its UUID.
+// Groups are listed at /jsonapi/group/{bundle}, paginated with page[offset] and page[limit].
+func (s *Server) AddGroup(bundle, label string) string {
+	return s.addLabeled("group", bundle, label)
+}
+
+// AddTerm adds a taxonomy term to the vocabulary (e.g. "crime_categories") and returns its UUID.
+// Terms are listed at /jsonapi/taxonomy_term/{vocabulary} like groups.
+func (s *Server) AddTerm(vocabulary, name string) string {
+	return s.addLabeled("taxonomy_term", vocabulary, name)
+}
+
+func (s *Server) addLabeled(entity, bundle, label string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	id := fmt.Sprintf("00000000-0000-4000-9000-%012d", len(s.labeled)+1)
+	s.labeled = append(s.labeled, resource{entity: entity, bundle: bundle, id: id, label: label})
+	return id
+}
+
+// FailNext makes the next POST return the given status code and JSON:API errors.
+// Calls are queued, so FailNext can be called several times to fail several posts.
+func (s *Server) FailNext(statusCode int, errs ...drupal.DrupalError) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failures = append(s.failures, failure{statusCode: statusCode, errors: errs})
+}
+
+// Reset discards recorded nodes and queued failures.
+func (s *Server) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nodes = nil
+	s.failures = nil
+}
+
+// authorized checks the API-KEY and Authorization headers against the configured credentials.
+func (s *Server) authorized(r *http.Request) bool {
+	credentials := s.token
+	if s.username != "" {
+		credentials = s.username + ":" + s.token
+	}
+	expected := base64.StdEncoding.EncodeToString([]byte(credentials))
+
+	//nolint:canonicalheader // Drupal REST API requires exact header name
+	return r.Header.Get("API-KEY") == expected && r.Header.Get("Authorization") == "Basic "+expected
+}
+
+func (s *Server) handleSessionToken(w http.ResponseWriter, r *http.Request) {
+	if !s.authorized(r) {
+		writeErrors(w, http.StatusForbidden, drupal.DrupalError{Title: "Forbidden", Detail: "invalid credentials"})
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain")
+	_, _ = w.Write([]byte(CSRFToken))
+}
+
+func (s *Server) handleRoot(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("X-Generator", "Drupal 11 (https://www.drupal.org)")
+	writeJSON(w, http.StatusOK, map[string]any{
+		"jsonapi": map[string]any{"version": "1.0"},
+		"links": map[string]any{
+			"self":          map[string]string{"href": s.URL + "/jsonapi"},
+			"node--article": map[string]string{"href": s.URL + "/jsonapi/node/article"},
+		},
+	})
+}
+
+func (s *Server) handleCreate(w http.ResponseWriter, r *http.Request) {
+	if !s.authorized(r) {
+		writeErrors(w, http.StatusForbidden, drupal.DrupalError{Title: "Forbidden", Detail: "invalid credentials"})
+		return
+	}
+	//nolint:canonicalheader // Drupal REST API requires exact header name
+	if r.Header.Get("X-CSRF-Token") != CSRFToken {
+		writeErrors(w, http.StatusForbidden, drupal.DrupalError{Title: "Forbidden", Detail: "X-CSRF-Token request header is invalid"})
+		return
+	}
+
+	s.mu.Lock()
+	if len(s.failures) > 0 {
+		next := s.failures[0]
+		s.failures = s.failures[1:]
+		s.mu.Unlock()
+		writeErrors(w, next.statusCode, next.errors...)
+		return
+	}
+	s.mu.Unlock()
+
+	var article drupal.DrupalArticle
+	if err := json.NewDecoder(r.Body).Decode(&article); err != nil {
+		writeErrors(w, http.StatusBadRequest, drupal.DrupalError{Title: "Bad Request", Detail: err.Error()})
+		return
+	}
+	bundle := r.PathValue("bundle")
+	if article.Data.Type != "node--"+bundle {
+		writeErrors(w, http.StatusConflict, drupal.DrupalError{
+			Title:  "Conflict",
+			Detail: fmt.Sprintf("resource type %q does not match endpoint node--%s", article.Data.Type, bundle),
+		})
+		return
+	}
+
+	s.mu.Lock()
+	node := Node{
+		ID:      fmt.Sprintf("00000000-0000-4000-8000-%012d", len(s.nodes)+1),
+		Bundle:  bundle,
+		Prefix:  r.PathValue("prefix"),
+		Article: article,
+		Header:  r.Header.Clone(),
+	}
+	s.nodes = append(s.nodes, node)
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusCreated, nodeDocument(node))
+}
+
+func (s *Server) handleList(w http.ResponseWriter, r *http.Request) {
+	if !s.authorized(r) {
+		writeErrors(w, http.StatusForbidden, drupal.DrupalError{Title: "Forbidden", Detail: "invalid credentials"})
+		return
+	}
+	bundle := r.PathValue("bundle")
+	data := []any{}
+	for _, node := range s.Nodes() {
+		if node.Bundle == bundle {
+			data = append(data, nodeDocument(node)["data"])
+		}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"data": data})
+}
+
+func (s *Server) handleGet(w http.ResponseWriter, r *http.Request) {
+	if !s.authorized(r) {
+		writeErrors(w, http.StatusForbidden, drupal.DrupalError{Title: "Forbidden", Detail: "invalid credentials"})
+		return
+	}
+	id := r.PathValue("id")
+	for _, node := range s.Nodes() {
+		if node.ID == id && node.Bundle == r.PathValue("bundle") {
+			writeJSON(w, http.StatusOK, nodeDocument(node))
+			return
+		}
+	}
+	writeErrors(w, http.StatusNotFound, drupal.DrupalError{Title: "Not Found", Detail: "node " + id + " not found"})
+}
+
+// handleUpdate applies the status attribute of a PATCH; other attributes are ignored.
+func (s *Server) handleUpdate(w http.ResponseWriter, r *http.Request) {
+	if !s.authorized(r) {
+		writeErrors(w, http.StatusForbidden, drupal.DrupalError{Title: "Forbidden", Detail: "invalid credentials"})
+		return
+	}
+	//nolint:canonicalheader // Drupal REST API requires exact header name
+	if r.Header.Get("X-CSRF-Token") != CSRFToken {
+		writeErrors(w, http.StatusForbidden, drupal.DrupalError{Title: "Forbidden", Detail: "X-CSRF-Token request header is invalid"})
+		return
+	}
+	var update struct {
+		Data struct {
+			ID         string `json:"id"`
+			Attributes struct {
+				Status *bool `json:"status"`
+			} `json:"attributes"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		writeErrors(w, http.StatusBadRequest, drupal.DrupalError{Title: "Bad Request", Detail: err.Error()})
+		return
+	}
+	id := r.PathValue("id")
+	if update.Data.ID != id {
+		writeErrors(w, http.StatusBadRequest, drupal.DrupalError{Title: "Bad Request", Detail: "resource id does not match the endpoint"})
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, node := range s.nodes {
+		if node.ID == id && node.Bundle == r.PathValue("bundle") {
+			if status := update.Data.Attributes.Status; status != nil {
+				s.nodes[i].Unpublished = !*status
+			}
+			writeJSON(w, http.StatusOK, nodeDocument(s.nodes[i]))
+			return
+		}
+	}
+	writeErrors(w, http.StatusNotFound, drupal.DrupalError{Title: "Not Found", Detail: "node " + id + " not found"})
+}
+
+func (s *Server) handleDelete(w http.ResponseWriter, r *http.Request) {
+	if !s.authorized(r) {
+		writeErrors(w, http.StatusForbidden, drupal.DrupalError{Title: "Forbidden", Detail: "invalid credentials"})
+		return
+	}
+	id := r.PathValue("id")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, node := range s.nodes {
+		if node.ID == id && node.Bundle == r.PathValue("bundle") {
+			s.nodes = append(s.nodes[:i], s.nodes[i+1:]...)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+	}
+	writeErrors(w, http.StatusNotFound, drupal.DrupalError{Title: "Not Found", Detail: "node " + id + " not found"})
+}
+
+// handleListLabeled lists the resources of an entity type, with their label in labelField.
+func (s *Server) handleListLabeled(entity, labelField string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !s.authorized(r) {
+			writeErrors(w, http.StatusForbidden, drupal.DrupalError{Title: "Forbidden", Detail: "invalid credentials"})
+			return
+		}
+		bundle := r.PathValue("bundle")
+		offset, _ := strconv.Atoi(r.URL.Query().Get("page[offset]"))
+		limit, err := strconv.Atoi(r.URL.Query().Get("page[limit]"))
+		if err != nil || limit <= 0 {
+			limit = 50
+		}
+
+		s.mu.Lock()
+		var matching []resource
+		for _, res := range s.labeled {
+			if res.entity == entity && res.bundle == bundle {
+				matching = append(matching, res)
+			}
+		}
+		s.mu.Unlock()
+
+		data := []any{}
+		for i := offset; i < len(matching) && i < offset+limit; i++ {
+			data = append(data, map[string]any{
+				"type":       entity + "--" + bundle,
+				"id":         matching[i].id,
+				"attributes": map[string]any{labelField: matching[i].label},
+			})
+		}
+		document := map[string]any{"data": data}
+		if offset+limit < len(matching) {
+			next := fmt.Sprintf("%s/jsonapi/%s/%s?page[offset]=%d&page[limit]=%d", s.URL, entity, bundle, offset+limit, limit)
+			document["links"] = map[string]any{"next": map[string]any{"href": next}}
+		}
+		writeJSON(w, http.StatusOK, document)
+	}
+}
+
+// nodeDocument renders a recorded node as a JSON:API resource document.
+func nodeDocument(node Node) map[string]any {
+	attributes := map[string]any{}
+	if raw, err := json.Marshal(node.Article.Data.Attributes); err == nil {
+		_ = json.Unmarshal(raw, &attributes)
+	}
+	return map[string]any{
+		"data": map[string]any{
+			"type":       node.Article.Data.Type,
+			"id":         node.ID,
+			"attributes": attributes,
+		},
+	}
+}
+
+func writeJSON(w http.ResponseWriter, statusCode int, body any) {
+	w.Header().Set("Content-Type", "application/vnd.api+json")
+	w.WriteHeader(statusCode)
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func writeErrors(w http.ResponseWriter, statusCode int, errs ...drupal.DrupalError) {
+	for i := range errs {
+		if errs[i].Status == "" {
+			errs[i].Status = fmt.Sprint(statusCode)
+		}
+	}
+	if len(errs) == 0 {
+		errs = []drupal.DrupalError{{
+			Status: fmt.Sprint(statusCode),
+			Title:  strings.TrimSpace(http.StatusText(statusCode)),
+		}}
+	}
+	writeJSON(w, statusCode, drupal.DrupalResponse{Errors: errs})
+}
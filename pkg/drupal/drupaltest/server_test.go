@@ -0,0 +1,114 @@
+package drupaltest_test
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/gopost/integration/internal/logger"
+	"github.com/gopost/integration/pkg/drupal"
+	"github.com/gopost/integration/pkg/drupal/drupaltest"
+)
+
+func TestServer_RecordsPostedArticle(t *testing.T) {
+	server := drupaltest.NewServer(t, "gopost", "secret")
+	client := server.Client(t)
+
+	err := client.PostArticle(context.Background(), drupal.ArticleRequest{
+		Title:       "Police arrest suspect",
+		Body:        "<p>Body</p>",
+		URL:         "https://example.com/story",
+		GroupID:     "e3d024a6-5f6f-4be8-8f3d-75639075959c",
+		GroupType:   "group--crime_news",
+		ContentType: "node--article",
+		ExternalID:  "es-1",
+	})
+	if err != nil {
+		t.Fatalf("PostArticle() error = %v", err)
+	}
+
+	nodes := server.Nodes()
+	if len(nodes) != 1 {
+		t.Fatalf("len(Nodes()) = %d, want 1", len(nodes))
+	}
+	node := nodes[0]
+	if node.Article.Data.Attributes.Title != "Police arrest suspect" {
+		t.Errorf("title = %q, want %q", node.Article.Data.Attributes.Title, "Police arrest suspect")
+	}
+	if node.Article.Data.Attributes.FieldExternalID != "es-1" {
+		t.Errorf("field_external_id = %q, want %q", node.Article.Data.Attributes.FieldExternalID, "es-1")
+	}
+	if node.Article.Data.Relationships.FieldGroup == nil || len(node.Article.Data.Relationships.FieldGroup.Data) != 1 {
+		t.Fatal("expected a single field_group relationship")
+	}
+	//nolint:canonicalheader // Drupal REST API requires exact header name
+	if got := node.Header.Get("X-CSRF-Token"); got != drupaltest.CSRFToken {
+		t.Errorf("X-CSRF-Token = %q, want %q", got, drupaltest.CSRFToken)
+	}
+}
+
+func TestServer_RejectsInvalidCredentials(t *testing.T) {
+	server := drupaltest.NewServer(t, "gopost", "secret")
+
+	client, err := drupal.NewClient(server.URL, "gopost", "wrong", "", false, logger.NewNopLogger())
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	err = client.PostArticle(context.Background(), drupal.ArticleRequest{
+		Title:       "Police arrest suspect",
+		ContentType: "node--article",
+	})
+	if err == nil {
+		t.Fatal("PostArticle() error = nil, want auth error")
+	}
+	if len(server.Nodes()) != 0 {
+		t.Errorf("len(Nodes()) = %d, want 0", len(server.Nodes()))
+	}
+}
+
+func TestServer_FailNext(t *testing.T) {
+	server := drupaltest.NewServer(t, "", "secret")
+	client := server.Client(t)
+
+	server.FailNext(http.StatusUnprocessableEntity, drupal.DrupalError{
+		Title:  "Unprocessable Content",
+		Detail: "field_url: This value should not be null.",
+	})
+
+	req := drupal.ArticleRequest{Title: "Court hearing", ContentType: "node--article"}
+	err := client.PostArticle(context.Background(), req)
+	if err == nil || !strings.Contains(err.Error(), "field_url") {
+		t.Fatalf("PostArticle() error = %v, want validation error mentioning field_url", err)
+	}
+
+	if err := client.PostArticle(context.Background(), req); err != nil {
+		t.Fatalf("second PostArticle() error = %v, want nil", err)
+	}
+	if len(server.Nodes()) != 1 {
+		t.Errorf("len(Nodes()) = %d, want 1", len(server.Nodes()))
+	}
+}
+
+func TestPoster_RecordsSuccessfulPosts(t *testing.T) {
+	poster := &drupaltest.Poster{
+		ErrFunc: func(req drupal.ArticleRequest) error {
+			if req.ExternalID == "bad" {
+				return context.DeadlineExceeded
+			}
+			return nil
+		},
+	}
+
+	_ = poster.PostArticle(context.Background(), drupal.ArticleRequest{ExternalID: "good"})
+	_ = poster.PostArticle(context.Background(), drupal.ArticleRequest{ExternalID: "bad"})
+
+	if poster.Attempts() != 2 {
+		t.Errorf("Attempts() = %d, want 2", poster.Attempts())
+	}
+	posted := poster.Posted()
+	if len(posted) != 1 || posted[0].ExternalID != "good" {
+		t.Errorf("Posted() = %+v, want only the good request", posted)
+	}
+}
@@ -0,0 +1,110 @@
+package drupal
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// TextValue is a formatted text field value, such as body or a text_with_summary teaser.
+type TextValue struct {
+	Value   string `json:"value"`
+	Format  string `json:"format,omitempty"`  // Text format machine name, e.g. "basic_html"
+	Summary string `json:"summary,omitempty"` // Teaser of text_with_summary fields
+}
+
+// LinkValue is a link field value.
+type LinkValue struct {
+	URI   string `json:"uri"`
+	Title string `json:"title,omitempty"`
+}
+
+// MetatagValue is a Metatag module field value: tag values by tag name, such as
+// "canonical_url" or "og_title". It is encoded as {"value": {tag: value}}.
+type MetatagValue map[string]string
+
+// MarshalJSON wraps the tags in the field's value property.
+func (v MetatagValue) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]map[string]string{"value": v})
+}
+
+// PathValue is a node's path field: its URL alias. Pathauto false keeps the Pathauto module,
+// when installed, from replacing the alias with one generated from its patterns.
+type PathValue struct {
+	Alias    string `json:"alias"`
+	Pathauto bool   `json:"pathauto"`
+}
+
+// DateTimeValue is a datetime field value, encoded in UTC in the RFC 3339 form Drupal expects.
+type DateTimeValue time.Time
+
+// DateValue is a date-only datetime field value, encoded as YYYY-MM-DD.
+type DateValue time.Time
+
+// MarshalJSON encodes the time in UTC with an explicit offset, e.g. "2025-12-09T14:30:00+00:00".
+func (v DateTimeValue) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Time(v).UTC().Format("2006-01-02T15:04:05-07:00"))
+}
+
+// MarshalJSON encodes the calendar date of the time.
+func (v DateValue) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Time(v).Format(time.DateOnly))
+}
+
+// builtinAttributes are the JSON names of the ArticleAttributes struct fields.
+var builtinAttributes = attributeNames(reflect.TypeFor[ArticleAttributes]())
+
+func attributeNames(t reflect.Type) map[string]bool {
+	names := make(map[string]bool, t.NumField())
+	for i := range t.NumField() {
+		name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+		if name != "" && name != "-" {
+			names[name] = true
+		}
+	}
+	return names
+}
+
+// attributes drops the methods of ArticleAttributes so it can be encoded by default rules.
+type attributes ArticleAttributes
+
+// MarshalJSON encodes the built-in attributes merged with Fields.
+func (a ArticleAttributes) MarshalJSON() ([]byte, error) {
+	encoded, err := json.Marshal(attributes(a))
+	if err != nil || len(a.Fields) == 0 {
+		return encoded, err
+	}
+
+	merged := make(map[string]any)
+	if err := json.Unmarshal(encoded, &merged); err != nil {
+		return nil, err
+	}
+	for name, value := range a.Fields {
+		merged[name] = value
+	}
+	return json.Marshal(merged)
+}
+
+// UnmarshalJSON decodes the built-in attributes and collects the others in Fields.
+func (a *ArticleAttributes) UnmarshalJSON(data []byte) error {
+	var decoded attributes
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	var all map[string]any
+	if err := json.Unmarshal(data, &all); err != nil {
+		return err
+	}
+	for name, value := range all {
+		if builtinAttributes[name] {
+			continue
+		}
+		if decoded.Fields == nil {
+			decoded.Fields = make(map[string]any)
+		}
+		decoded.Fields[name] = value
+	}
+	*a = ArticleAttributes(decoded)
+	return nil
+}
@@ -0,0 +1,183 @@
+package drupal
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ErrLastPage is returned by Client.NextNodes when the list has no following page.
+var ErrLastPage = errors.New("no next page")
+
+// Node is a JSON:API resource object returned by GetNode and ListNodes.
+// Attributes are kept raw so fields added on the Drupal side need no client change;
+// use the accessor methods to read them.
+type Node struct {
+	Type          string                     `json:"type"`
+	ID            string                     `json:"id"`
+	Attributes    map[string]json.RawMessage `json:"attributes,omitempty"`
+	Relationships map[string]Relationship    `json:"relationships,omitempty"`
+	Links         Links                      `json:"links,omitempty"`
+
+	// Included holds the resources side-loaded by WithInclude when the node was fetched by GetNode.
+	Included []Node `json:"-"`
+}
+
+// NodeList is a page of nodes returned by ListNodes. Links.Next is the cursor for the
+// following page; pass the list to Client.NextNodes to fetch it while Next is non-empty.
+type NodeList struct {
+	Data     []Node         `json:"data"`
+	Included []Node         `json:"included,omitempty"` // Resources side-loaded by WithInclude
+	Links    Links          `json:"links,omitempty"`
+	Meta     map[string]any `json:"meta,omitempty"`
+}
+
+// Links maps JSON:API link names (e.g. "self", "next", "prev") to their targets.
+type Links map[string]Link
+
+// Link is a JSON:API link. Drupal renders links as objects with an href member,
+// but the plain string form allowed by the specification is also accepted.
+type Link struct {
+	Href string         `json:"href"`
+	Meta map[string]any `json:"meta,omitempty"`
+}
+
+// Relationship holds the resource identifiers of a JSON:API relationship.
+// To-one relationships decode to a single-element Data slice, empty ones to nil.
+type Relationship struct {
+	Data  []ResourceIdentifier `json:"data"`
+	Links Links                `json:"links,omitempty"`
+}
+
+// ResourceIdentifier identifies a related JSON:API resource.
+type ResourceIdentifier struct {
+	Type string         `json:"type"`
+	ID   string         `json:"id"`
+	Meta map[string]any `json:"meta,omitempty"`
+}
+
+// nodeDocument is a JSON:API document whose primary data is a single node.
+type nodeDocument struct {
+	Data     Node   `json:"data"`
+	Included []Node `json:"included"`
+}
+
+// Title returns the node's title attribute.
+func (n *Node) Title() string {
+	return n.StringAttribute("title")
+}
+
+// Attribute decodes the named attribute into v.
+// It returns an error if the attribute is missing or does not decode into v.
+func (n *Node) Attribute(name string, v any) error {
+	raw, ok := n.Attributes[name]
+	if !ok {
+		return fmt.Errorf("attribute %q not present", name)
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return fmt.Errorf("decode attribute %q: %w", name, err)
+	}
+	return nil
+}
+
+// StringAttribute returns the named attribute as a string. Formatted text fields
+// (objects with a value member, like body) return their value. Missing or
+// non-string attributes return an empty string.
+func (n *Node) StringAttribute(name string) string {
+	var s string
+	if n.Attribute(name, &s) == nil {
+		return s
+	}
+	var text struct {
+		Value string `json:"value"`
+	}
+	if n.Attribute(name, &text) == nil {
+		return text.Value
+	}
+	return ""
+}
+
+// Related returns the resource identifiers of the named relationship,
+// or nil if the node has no such relationship.
+func (n *Node) Related(name string) []ResourceIdentifier {
+	return n.Relationships[name].Data
+}
+
+// Resolve returns the included resource ref points to, or nil if it was not side-loaded.
+func (n *Node) Resolve(ref ResourceIdentifier) *Node {
+	return findResource(n.Included, ref)
+}
+
+// Resolve returns the included resource ref points to, or nil if it was not side-loaded.
+func (l *NodeList) Resolve(ref ResourceIdentifier) *Node {
+	return findResource(l.Included, ref)
+}
+
+func findResource(resources []Node, ref ResourceIdentifier) *Node {
+	for i := range resources {
+		if resources[i].Type == ref.Type && resources[i].ID == ref.ID {
+			return &resources[i]
+		}
+	}
+	return nil
+}
+
+// Href returns the target of the named link, or an empty string if it is absent.
+func (l Links) Href(name string) string {
+	return l[name].Href
+}
+
+// Next returns the URL of the following page, or an empty string on the last page.
+func (l *NodeList) Next() string {
+	return l.Links.Href("next")
+}
+
+// Prev returns the URL of the preceding page, or an empty string on the first page.
+func (l *NodeList) Prev() string {
+	return l.Links.Href("prev")
+}
+
+// UnmarshalJSON accepts both the object and the string form of a link.
+func (l *Link) UnmarshalJSON(data []byte) error {
+	var href string
+	if json.Unmarshal(data, &href) == nil {
+		*l = Link{Href: href}
+		return nil
+	}
+	type link Link // drops the method set to avoid recursion
+	var decoded link
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	*l = Link(decoded)
+	return nil
+}
+
+// UnmarshalJSON accepts to-one (object or null) and to-many (array) relationship data.
+func (r *Relationship) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Data  json.RawMessage `json:"data"`
+		Links Links           `json:"links"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	r.Links = raw.Links
+	r.Data = nil
+
+	trimmed := bytes.TrimSpace(raw.Data)
+	switch {
+	case len(trimmed) == 0 || bytes.Equal(trimmed, []byte("null")):
+		return nil
+	case trimmed[0] == '[':
+		return json.Unmarshal(trimmed, &r.Data)
+	default:
+		var single ResourceIdentifier
+		if err := json.Unmarshal(trimmed, &single); err != nil {
+			return err
+		}
+		r.Data = []ResourceIdentifier{single}
+		return nil
+	}
+}
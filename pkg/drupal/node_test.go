@@ -0,0 +1,198 @@
+package drupal_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gopost/integration/internal/logger"
+	"github.com/gopost/integration/pkg/drupal"
+)
+
+func newJSONAPIServer(t *testing.T, documents map[string]string) *drupal.Client {
+	t.Helper()
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestURI := r.URL.Path
+		if query, err := url.QueryUnescape(r.URL.RawQuery); err == nil && query != "" {
+			requestURI += "?" + query
+		}
+		document, ok := documents[requestURI]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "application/vnd.api+json")
+		_, _ = w.Write([]byte(strings.ReplaceAll(document, "{{url}}", server.URL)))
+	}))
+	t.Cleanup(server.Close)
+
+	client, err := drupal.NewClient(server.URL, "gopost", "secret", "", false, logger.NewNopLogger())
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	return client
+}
+
+func TestGetNode_DecodesResource(t *testing.T) {
+	client := newJSONAPIServer(t, map[string]string{
+		"/jsonapi/node/article/abc": `{
+			"data": {
+				"type": "node--article",
+				"id": "abc",
+				"attributes": {
+					"title": "Police arrest suspect",
+					"body": {"value": "<p>Details</p>", "format": "basic_html"},
+					"field_word_count": 42
+				},
+				"relationships": {
+					"field_group": {"data": [{"type": "group--crime_news", "id": "g1"}]},
+					"uid": {"data": {"type": "user--user", "id": "u1"}},
+					"field_image": {"data": null}
+				},
+				"links": {"self": {"href": "{{url}}/jsonapi/node/article/abc"}}
+			}
+		}`,
+	})
+
+	node, err := client.GetNode(context.Background(), "abc")
+	if err != nil {
+		t.Fatalf("GetNode() error = %v", err)
+	}
+	if node.ID != "abc" || node.Type != "node--article" {
+		t.Errorf("node = %s/%s, want node--article/abc", node.Type, node.ID)
+	}
+	if got := node.Title(); got != "Police arrest suspect" {
+		t.Errorf("Title() = %q", got)
+	}
+	if got := node.StringAttribute("body"); got != "<p>Details</p>" {
+		t.Errorf("StringAttribute(body) = %q", got)
+	}
+	var wordCount int
+	if err := node.Attribute("field_word_count", &wordCount); err != nil || wordCount != 42 {
+		t.Errorf("Attribute(field_word_count) = %d, %v; want 42", wordCount, err)
+	}
+	if err := node.Attribute("field_missing", &wordCount); err == nil {
+		t.Error("Attribute(field_missing) error = nil, want error")
+	}
+	if groups := node.Related("field_group"); len(groups) != 1 || groups[0].ID != "g1" {
+		t.Errorf("Related(field_group) = %+v", groups)
+	}
+	if owner := node.Related("uid"); len(owner) != 1 || owner[0].Type != "user--user" {
+		t.Errorf("Related(uid) = %+v", owner)
+	}
+	if image := node.Related("field_image"); image != nil {
+		t.Errorf("Related(field_image) = %+v, want nil", image)
+	}
+	if node.Links.Href("self") == "" {
+		t.Error("Links.Href(self) is empty")
+	}
+}
+
+func TestListNodes_FollowsNextLinks(t *testing.T) {
+	client := newJSONAPIServer(t, map[string]string{
+		"/jsonapi/node/article?page[limit]=1": `{
+			"data": [{"type": "node--article", "id": "1", "attributes": {"title": "First"}}],
+			"links": {"next": {"href": "{{url}}/jsonapi/node/article?page[offset]=1&page[limit]=1"}}
+		}`,
+		"/jsonapi/node/article?page[offset]=1&page[limit]=1": `{
+			"data": [{"type": "node--article", "id": "2", "attributes": {"title": "Second"}}],
+			"links": {"prev": "{{url}}/jsonapi/node/article?page[limit]=1"}
+		}`,
+	})
+	ctx := context.Background()
+
+	list, err := client.ListNodes(ctx, 1)
+	if err != nil {
+		t.Fatalf("ListNodes() error = %v", err)
+	}
+	var titles []string
+	for {
+		for _, node := range list.Data {
+			titles = append(titles, node.Title())
+		}
+		if list.Next() == "" {
+			break
+		}
+		if list, err = client.NextNodes(ctx, list); err != nil {
+			t.Fatalf("NextNodes() error = %v", err)
+		}
+	}
+
+	if fmt.Sprint(titles) != "[First Second]" {
+		t.Errorf("titles = %v, want [First Second]", titles)
+	}
+	if list.Prev() == "" {
+		t.Error("Prev() on last page is empty, want string-form link")
+	}
+	if _, err := client.NextNodes(ctx, list); !errors.Is(err, drupal.ErrLastPage) {
+		t.Errorf("NextNodes() on last page error = %v, want ErrLastPage", err)
+	}
+}
+
+func TestGetNode_AppliesQueryOptions(t *testing.T) {
+	client := newJSONAPIServer(t, map[string]string{
+		"/jsonapi/node/article/abc?fields[node--article]=title,field_group&include=field_group": `{
+			"data": {
+				"type": "node--article",
+				"id": "abc",
+				"attributes": {"title": "Police arrest suspect"},
+				"relationships": {"field_group": {"data": [{"type": "group--crime_news", "id": "g1"}]}}
+			},
+			"included": [{"type": "group--crime_news", "id": "g1", "attributes": {"label": "Sudbury"}}]
+		}`,
+	})
+
+	node, err := client.GetNode(context.Background(), "abc",
+		drupal.WithFields("node--article", "title", "field_group"),
+		drupal.WithInclude("field_group"),
+	)
+	if err != nil {
+		t.Fatalf("GetNode() error = %v", err)
+	}
+	group := node.Resolve(node.Related("field_group")[0])
+	if group == nil {
+		t.Fatal("Resolve(field_group) = nil, want included group")
+	}
+	if got := group.StringAttribute("label"); got != "Sudbury" {
+		t.Errorf("included group label = %q, want Sudbury", got)
+	}
+}
+
+func TestListNodes_AppliesQueryOptions(t *testing.T) {
+	tests := []struct {
+		name  string
+		opts  []drupal.QueryOption
+		query string
+	}{
+		{name: "limit only", query: "page[limit]=5"},
+		{name: "filter", opts: []drupal.QueryOption{drupal.WithFilter("status", "1")}, query: "filter[status]=1&page[limit]=5"},
+		{
+			name: "filter conditions",
+			opts: []drupal.QueryOption{
+				drupal.WithFilterCondition("created", ">", "100"),
+				drupal.WithFilterCondition("created", "<", "200"),
+			},
+			query: "filter[condition_0][condition][operator]=>&filter[condition_0][condition][path]=created&" +
+				"filter[condition_0][condition][value]=100&filter[condition_1][condition][operator]=<&" +
+				"filter[condition_1][condition][path]=created&filter[condition_1][condition][value]=200&page[limit]=5",
+		},
+		{name: "sort", opts: []drupal.QueryOption{drupal.WithSort("-created", "title")}, query: "page[limit]=5&sort=-created,title"},
+		{name: "page overrides limit", opts: []drupal.QueryOption{drupal.WithPage(10, 2)}, query: "page[limit]=2&page[offset]=10"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newJSONAPIServer(t, map[string]string{
+				"/jsonapi/node/article?" + tt.query: `{"data": []}`,
+			})
+			if _, err := client.ListNodes(context.Background(), 5, tt.opts...); err != nil {
+				t.Fatalf("ListNodes() error = %v", err)
+			}
+		})
+	}
+}
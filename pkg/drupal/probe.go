@@ -0,0 +1,150 @@
+package drupal
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/gopost/integration/internal/logger"
+)
+
+// generatorPattern extracts the major version from Drupal's X-Generator header
+// (e.g. "Drupal 11 (https://www.drupal.org)").
+var generatorPattern = regexp.MustCompile(`Drupal (\d+)`)
+
+// SiteInfo describes the Drupal site features detected by Probe.
+type SiteInfo struct {
+	DrupalVersion  string            // Major version from X-Generator header (empty if the header is hidden)
+	JSONAPIVersion string            // JSON:API specification version advertised by the root document
+	ResourceTypes  map[string]string // Resource type (e.g. "node--article") to collection endpoint URL
+	Subrequests    bool              // Subrequests module endpoint is available
+	OAuth          bool              // Simple OAuth token endpoint is available
+}
+
+// HasResourceType reports whether the JSON:API root document advertised the resource type.
+func (s *SiteInfo) HasResourceType(resourceType string) bool {
+	_, ok := s.ResourceTypes[resourceType]
+	return ok
+}
+
+// ResourceTypeNames returns the advertised resource types in sorted order.
+func (s *SiteInfo) ResourceTypeNames() []string {
+	names := make([]string, 0, len(s.ResourceTypes))
+	for name := range s.ResourceTypes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// jsonAPIRoot is the subset of the JSON:API root document used for feature detection.
+type jsonAPIRoot struct {
+	JSONAPI struct {
+		Version string `json:"version"`
+	} `json:"jsonapi"`
+	Links map[string]json.RawMessage `json:"links"`
+}
+
+// Probe inspects the JSON:API root document and well-known module endpoints to detect
+// the Drupal version, available resource types, and enabled extensions.
+// The detected resource endpoints are remembered and used by PostArticle, so sites that
+// relocate the JSON:API base path (e.g. via jsonapi_extras) are handled transparently.
+func (c *Client) Probe(ctx context.Context) (*SiteInfo, error) {
+	startTime := time.Now()
+
+	methodLogger := c.logger.With(
+		logger.String("method", "Probe"),
+	)
+
+	rootURL := fmt.Sprintf("%s/jsonapi", c.baseURL)
+	httpReq, requestID, err := c.newRequest(ctx, http.MethodGet, rootURL, http.NoBody)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	httpReq.Header.Set("Accept", "application/vnd.api+json")
+
+	resp, err := c.client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("fetch JSON:API root: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("JSON:API root request %s failed: %d %s", requestID, resp.StatusCode, string(bodyBytes))
+	}
+
+	var root jsonAPIRoot
+	if decodeErr := json.NewDecoder(resp.Body).Decode(&root); decodeErr != nil {
+		return nil, fmt.Errorf("decode JSON:API root: %w", decodeErr)
+	}
+
+	info := &SiteInfo{
+		JSONAPIVersion: root.JSONAPI.Version,
+		ResourceTypes:  make(map[string]string, len(root.Links)),
+	}
+	if match := generatorPattern.FindStringSubmatch(resp.Header.Get("X-Generator")); match != nil {
+		info.DrupalVersion = match[1]
+	}
+
+	for name, raw := range root.Links {
+		// "self" and "me" are document links, not resource types
+		if name == "self" || name == "me" {
+			continue
+		}
+		var link struct {
+			Href string `json:"href"`
+		}
+		if json.Unmarshal(raw, &link) != nil || link.Href == "" {
+			continue
+		}
+		info.ResourceTypes[name] = link.Href
+	}
+
+	info.Subrequests = c.endpointExists(ctx, fmt.Sprintf("%s/subrequests?_format=json", c.baseURL))
+	info.OAuth = c.endpointExists(ctx, fmt.Sprintf("%s/oauth/token", c.baseURL))
+
+	c.mu.Lock()
+	c.resourceEndpoints = info.ResourceTypes
+	c.mu.Unlock()
+
+	methodLogger.Info("Detected Drupal site features",
+		logger.String("base_url", c.baseURL),
+		logger.String("drupal_version", info.DrupalVersion),
+		logger.String("jsonapi_version", info.JSONAPIVersion),
+		logger.Int("resource_type_count", len(info.ResourceTypes)),
+		logger.Bool("subrequests", info.Subrequests),
+		logger.Bool("oauth", info.OAuth),
+		logger.Duration("duration", time.Since(startTime)),
+	)
+
+	return info, nil
+}
+
+// endpointExists reports whether a module endpoint is routed on the site.
+// Any response other than 404 means the route exists (GET is usually rejected with 405/400).
+func (c *Client) endpointExists(ctx context.Context, endpoint string) bool {
+	httpReq, requestID, err := c.newRequest(ctx, http.MethodGet, endpoint, http.NoBody)
+	if err != nil {
+		return false
+	}
+
+	resp, err := c.client.Do(httpReq)
+	if err != nil {
+		c.logger.Debug("Feature endpoint probe failed",
+			logger.String("endpoint", endpoint),
+			logger.String("request_id", requestID),
+			logger.Error(err),
+		)
+		return false
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	return resp.StatusCode != http.StatusNotFound
+}
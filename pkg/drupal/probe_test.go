@@ -0,0 +1,81 @@
+package drupal_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gopost/integration/internal/logger"
+	"github.com/gopost/integration/pkg/drupal"
+)
+
+func TestProbe_DetectsSiteFeatures(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/jsonapi":
+			w.Header().Set("X-Generator", "Drupal 11 (https://www.drupal.org)")
+			w.Header().Set("Content-Type", "application/vnd.api+json")
+			fmt.Fprintf(w, `{
+				"jsonapi": {"version": "1.0"},
+				"links": {
+					"self": {"href": "%[1]s/jsonapi"},
+					"node--article": {"href": "%[1]s/api/node/article"},
+					"group--crime_news": {"href": "%[1]s/api/group/crime_news"}
+				}
+			}`, server.URL)
+		case "/oauth/token":
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client, err := drupal.NewClient(server.URL, "user", "token", "", false, logger.NewNopLogger())
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	info, err := client.Probe(context.Background())
+	if err != nil {
+		t.Fatalf("Probe() error = %v", err)
+	}
+
+	if info.DrupalVersion != "11" {
+		t.Errorf("DrupalVersion = %q, want %q", info.DrupalVersion, "11")
+	}
+	if info.JSONAPIVersion != "1.0" {
+		t.Errorf("JSONAPIVersion = %q, want %q", info.JSONAPIVersion, "1.0")
+	}
+	if !info.HasResourceType("node--article") || !info.HasResourceType("group--crime_news") {
+		t.Errorf("ResourceTypes = %v, want node--article and group--crime_news", info.ResourceTypeNames())
+	}
+	if info.HasResourceType("self") {
+		t.Error("ResourceTypes should not include document links")
+	}
+	if !info.OAuth {
+		t.Error("OAuth = false, want true")
+	}
+	if info.Subrequests {
+		t.Error("Subrequests = true, want false")
+	}
+}
+
+func TestProbe_RootUnavailable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	client, err := drupal.NewClient(server.URL, "", "token", "", false, logger.NewNopLogger())
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	if _, err := client.Probe(context.Background()); err == nil {
+		t.Fatal("Probe() error = nil, want error for forbidden root document")
+	}
+}
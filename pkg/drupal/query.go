@@ -0,0 +1,82 @@
+package drupal
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// QueryOption adds JSON:API query parameters to a GetNode or ListNodes request.
+type QueryOption func(url.Values)
+
+// WithFields limits the attributes and relationships returned for resourceType
+// (e.g. "node--article") to fields, using a JSON:API sparse fieldset.
+func WithFields(resourceType string, fields ...string) QueryOption {
+	return func(q url.Values) {
+		q.Set(fmt.Sprintf("fields[%s]", resourceType), strings.Join(fields, ","))
+	}
+}
+
+// WithInclude side-loads the resources behind the relationship paths (e.g. "field_group"
+// or "field_group.uid"); they are returned in Node.Included and NodeList.Included.
+func WithInclude(paths ...string) QueryOption {
+	return func(q url.Values) {
+		q.Set("include", strings.Join(paths, ","))
+	}
+}
+
+// WithFilter keeps resources whose path (e.g. "status" or "field_group.id") equals value.
+func WithFilter(path, value string) QueryOption {
+	return func(q url.Values) {
+		q.Set(fmt.Sprintf("filter[%s]", path), value)
+	}
+}
+
+// WithFilterCondition keeps resources where path compares to value with operator
+// (e.g. ">", "CONTAINS", "STARTS_WITH"). Conditions on the same path combine with AND.
+func WithFilterCondition(path, operator, value string) QueryOption {
+	return func(q url.Values) {
+		// Each condition needs a unique label; number them in the order they were added
+		label := "condition_" + strconv.Itoa(countConditions(q))
+		q.Set(fmt.Sprintf("filter[%s][condition][path]", label), path)
+		q.Set(fmt.Sprintf("filter[%s][condition][operator]", label), operator)
+		q.Set(fmt.Sprintf("filter[%s][condition][value]", label), value)
+	}
+}
+
+// WithSort orders results by fields; prefix a field with "-" for descending order.
+func WithSort(fields ...string) QueryOption {
+	return func(q url.Values) {
+		q.Set("sort", strings.Join(fields, ","))
+	}
+}
+
+// WithPage requests limit resources starting at offset, overriding the ListNodes limit.
+func WithPage(offset, limit int) QueryOption {
+	return func(q url.Values) {
+		q.Set("page[offset]", strconv.Itoa(offset))
+		q.Set("page[limit]", strconv.Itoa(limit))
+	}
+}
+
+// withQuery appends the query built from q and opts to endpoint.
+func withQuery(endpoint string, q url.Values, opts []QueryOption) string {
+	for _, opt := range opts {
+		opt(q)
+	}
+	if len(q) == 0 {
+		return endpoint
+	}
+	return endpoint + "?" + q.Encode()
+}
+
+func countConditions(q url.Values) int {
+	n := 0
+	for key := range q {
+		if strings.HasPrefix(key, "filter[") && strings.HasSuffix(key, "][condition][path]") {
+			n++
+		}
+	}
+	return n
+}
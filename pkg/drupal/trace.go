@@ -0,0 +1,95 @@
+package drupal
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// ConnStats describes the connection behind one request and where its time went.
+// Connection setup timings are zero for reused connections.
+type ConnStats struct {
+	Method   string
+	Reused   bool          // The connection came from the idle pool (keep-alive or HTTP/2)
+	IdleTime time.Duration // How long a reused connection was idle
+	Protocol string        // Response protocol, e.g. "HTTP/1.1" or "HTTP/2.0"
+	DNS      time.Duration // DNS lookup
+	Connect  time.Duration // TCP connect
+	TLS      time.Duration // TLS handshake
+	TTFB     time.Duration // From the start of the request, including connection setup, to the first response byte
+}
+
+// traceTransport reports the ConnStats of every request that gets a response.
+type traceTransport struct {
+	base    http.RoundTripper
+	observe func(ConnStats)
+}
+
+// RoundTrip sends req with an httptrace.ClientTrace attached and reports its stats.
+func (t *traceTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// Dial hooks run on the transport's dialing goroutines, concurrently for dual-stack
+	// hosts, so every hook holds mu
+	var mu sync.Mutex
+	stats := ConnStats{Method: req.Method}
+	var dnsStart, connectStart, tlsStart, firstByte time.Time
+	since := func(start time.Time) time.Duration {
+		if start.IsZero() {
+			return 0
+		}
+		return time.Since(start)
+	}
+	locked := func(fn func()) {
+		mu.Lock()
+		defer mu.Unlock()
+		fn()
+	}
+	trace := &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			locked(func() { stats.Reused, stats.IdleTime = info.Reused, info.IdleTime })
+		},
+		DNSStart: func(httptrace.DNSStartInfo) { locked(func() { dnsStart = time.Now() }) },
+		DNSDone:  func(httptrace.DNSDoneInfo) { locked(func() { stats.DNS = since(dnsStart) }) },
+		ConnectStart: func(string, string) {
+			locked(func() {
+				if connectStart.IsZero() {
+					connectStart = time.Now()
+				}
+			})
+		},
+		ConnectDone: func(_, _ string, err error) {
+			locked(func() {
+				if err == nil && stats.Connect == 0 {
+					stats.Connect = since(connectStart)
+				}
+			})
+		},
+		TLSHandshakeStart: func() { locked(func() { tlsStart = time.Now() }) },
+		TLSHandshakeDone: func(_ tls.ConnectionState, err error) {
+			locked(func() {
+				if err == nil {
+					stats.TLS = since(tlsStart)
+				}
+			})
+		},
+		GotFirstResponseByte: func() { locked(func() { firstByte = time.Now() }) },
+	}
+
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req.WithContext(httptrace.WithClientTrace(req.Context(), trace)))
+	if err != nil {
+		return nil, err
+	}
+
+	mu.Lock()
+	if firstByte.IsZero() {
+		firstByte = time.Now()
+	}
+	stats.TTFB = firstByte.Sub(start)
+	stats.Protocol = resp.Proto
+	observed := stats
+	mu.Unlock()
+	t.observe(observed)
+	return resp, nil
+}
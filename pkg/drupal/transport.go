@@ -0,0 +1,115 @@
+package drupal
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/gopost/integration/internal/logger"
+	"github.com/gopost/integration/internal/requestid"
+)
+
+// csrfHeader carries the token from /session/token on requests that change state.
+const csrfHeader = "X-CSRF-Token"
+
+// authTransport authenticates every request sent by Client, so new endpoints only need to
+// build the request. It sets the API-KEY, Authorization, and AUTH-METHOD headers, and on
+// requests that change state (anything but GET, HEAD, and OPTIONS) a CSRF token fetched
+// from /session/token. A failed token fetch is logged and the request proceeds without one.
+type authTransport struct {
+	base       http.RoundTripper
+	baseURL    string
+	apiKey     string // base64(username:token), or base64(token) without a username
+	authMethod string
+	logger     logger.Logger
+}
+
+func newAuthTransport(base http.RoundTripper, baseURL, username, token, authMethod string, log logger.Logger) *authTransport {
+	// REST API Authentication module expects API-KEY header with base64(username:api-key)
+	credentials := token
+	if username != "" {
+		credentials = fmt.Sprintf("%s:%s", username, token)
+	}
+	return &authTransport{
+		base:       base,
+		baseURL:    baseURL,
+		apiKey:     base64.StdEncoding.EncodeToString([]byte(credentials)),
+		authMethod: authMethod,
+		logger:     log,
+	}
+}
+
+// RoundTrip adds the authentication headers to a copy of req and sends it.
+func (t *authTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	authReq := req.Clone(req.Context())
+	t.setAuthHeaders(authReq)
+
+	if changesState(authReq.Method) && authReq.Header.Get(csrfHeader) == "" {
+		csrfToken, err := t.csrfToken(authReq.Context(), authReq.Header.Get("User-Agent"))
+		if err != nil {
+			t.logger.Warn("Failed to fetch CSRF token, proceeding without it",
+				logger.String("endpoint", authReq.URL.String()),
+				logger.String("request_id", authReq.Header.Get(requestid.Header)),
+				logger.Error(err),
+			)
+		} else {
+			authReq.Header.Set(csrfHeader, csrfToken)
+		}
+	}
+
+	return t.base.RoundTrip(authReq)
+}
+
+// setAuthHeaders sets the API-KEY header, the Authorization header in Basic format that
+// miniOrange also requires, and AUTH-METHOD when configured.
+func (t *authTransport) setAuthHeaders(req *http.Request) {
+	req.Header.Set("API-KEY", t.apiKey)
+	req.Header.Set("Authorization", "Basic "+t.apiKey)
+	if t.authMethod != "" {
+		req.Header.Set("AUTH-METHOD", t.authMethod)
+	}
+}
+
+// csrfToken fetches a CSRF token from Drupal's session/token endpoint.
+func (t *authTransport) csrfToken(ctx context.Context, userAgent string) (string, error) {
+	tokenURL := fmt.Sprintf("%s/session/token", t.baseURL)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, tokenURL, http.NoBody)
+	if err != nil {
+		return "", fmt.Errorf("create CSRF token request: %w", err)
+	}
+	requestID := requestid.New()
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set(requestid.Header, requestID)
+	t.setAuthHeaders(req)
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return "", fmt.Errorf("fetch CSRF token: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("CSRF token request %s failed: %d %s", requestID, resp.StatusCode, resp.Status)
+	}
+
+	// CSRF token is returned as plain text
+	bodyBytes, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("read CSRF token: %w", err)
+	}
+	return strings.TrimSpace(string(bodyBytes)), nil
+}
+
+// changesState reports whether Drupal requires a CSRF token for the method.
+func changesState(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return false
+	default:
+		return true
+	}
+}
@@ -0,0 +1,238 @@
+package pipeline
+
+import (
+	"fmt"
+	"regexp"
+	"regexp/syntax"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// KeywordClassifier matches articles whose title or body contains any keyword. Plain
+// keywords match case-insensitively as set by its MatchMode; keywords written as
+// /pattern/ are regular expressions (see ParseKeyword).
+type KeywordClassifier struct {
+	keywords []string
+	stems    [][]string // Stemmed words of each keyword, in MatchStems mode
+	patterns []*regexp.Regexp
+	mode     MatchMode
+	fields   map[string]int // Weight by classified field; nil for the title and body together
+	minScore int
+}
+
+// Article fields a KeywordClassifier can weigh (see SetFields).
+const (
+	ClassifyTitle = "title"
+	ClassifyBody  = "body"
+	ClassifyTags  = "tags" // The tags the source assigned (Article.Keywords)
+)
+
+// ClassifyFields lists the fields a KeywordClassifier can weigh.
+var ClassifyFields = []string{ClassifyTitle, ClassifyBody, ClassifyTags}
+
+// MatchMode is how a KeywordClassifier matches plain keywords.
+type MatchMode int
+
+const (
+	// MatchSubstrings matches a keyword anywhere, even inside a longer word.
+	MatchSubstrings MatchMode = iota
+	// MatchWords matches a keyword only where it is not preceded or followed by a letter
+	// or digit, so "theft" does not match "thefts" and "eft" does not match "left".
+	MatchWords
+	// MatchStems matches a keyword's words in any inflection (see Stem), so "robbery"
+	// matches "robberies" and "assault" matches "assaulted".
+	MatchStems
+)
+
+// NewKeywordClassifier returns a classifier for the given keywords. It fails if a regular
+// expression keyword does not compile.
+func NewKeywordClassifier(keywords []string, mode MatchMode) (*KeywordClassifier, error) {
+	classifier := &KeywordClassifier{mode: mode}
+	for _, keyword := range keywords {
+		pattern, err := ParseKeyword(keyword)
+		if err != nil {
+			return nil, err
+		}
+		if pattern != nil {
+			classifier.patterns = append(classifier.patterns, pattern)
+			continue
+		}
+		classifier.keywords = append(classifier.keywords, strings.ToLower(keyword))
+		if mode == MatchStems {
+			classifier.stems = append(classifier.stems, stems(keyword))
+		}
+	}
+	return classifier, nil
+}
+
+// SetFields makes the classifier look for keywords in each of the given fields separately
+// and match an article when the weights of the fields mentioning a keyword sum to at least
+// minScore. With title: 1, only headlines count; with title: 2, body: 1, and minScore 2, a
+// keyword in the title suffices but one in the body alone does not. Fields not listed, or
+// not in ClassifyFields, are ignored. Without SetFields, the title and body are searched
+// together.
+func (c *KeywordClassifier) SetFields(weights map[string]int, minScore int) {
+	c.fields = make(map[string]int, len(weights))
+	for field, weight := range weights {
+		if slices.Contains(ClassifyFields, field) && weight > 0 {
+			c.fields[field] = weight
+		}
+	}
+	c.minScore = max(minScore, 1)
+}
+
+// ParseKeyword compiles a keyword written as /pattern/ or /pattern/i: a regular expression
+// in RE2 syntax, matched against the title and body as written, case-insensitively with
+// the i flag. It returns nil for plain keywords.
+func ParseKeyword(keyword string) (*regexp.Regexp, error) {
+	end := strings.LastIndex(keyword, "/")
+	if !strings.HasPrefix(keyword, "/") || end <= 0 {
+		return nil, nil
+	}
+	expr, flags := keyword[1:end], keyword[end+1:]
+	switch flags {
+	case "":
+	case "i":
+		expr = "(?i)" + expr
+	default:
+		return nil, fmt.Errorf("keyword %s: unknown flags %q (only i is supported)", keyword, flags)
+	}
+	if expr == "" || expr == "(?i)" {
+		return nil, fmt.Errorf("keyword %s: empty pattern", keyword)
+	}
+	pattern, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("keyword %s: %w", keyword, err)
+	}
+	return pattern, nil
+}
+
+// SearchTerms returns the words to search for articles mentioning the keywords: plain
+// keywords as they are, and the literal text of regular expression keywords, since an
+// Elasticsearch full-text search cannot evaluate them. Keywords that fail to parse are
+// left out.
+func SearchTerms(keywords []string) []string {
+	terms := make([]string, 0, len(keywords))
+	for _, keyword := range keywords {
+		pattern, err := ParseKeyword(keyword)
+		switch {
+		case err != nil:
+			continue
+		case pattern == nil:
+			terms = append(terms, keyword)
+			continue
+		}
+		re, err := syntax.Parse(keyword[1:strings.LastIndex(keyword, "/")], syntax.Perl)
+		if err != nil {
+			continue
+		}
+		terms = appendLiterals(terms, re)
+	}
+	return terms
+}
+
+// appendLiterals appends the literal strings of a parsed regular expression.
+func appendLiterals(terms []string, re *syntax.Regexp) []string {
+	if re.Op == syntax.OpLiteral {
+		return append(terms, string(re.Rune))
+	}
+	for _, sub := range re.Sub {
+		terms = appendLiterals(terms, sub)
+	}
+	return terms
+}
+
+// Matches reports whether the article mentions any of the keywords, in fields weighing at
+// least the minimum score when SetFields was called.
+func (c *KeywordClassifier) Matches(article Article) bool {
+	if c.fields == nil {
+		return c.mentions(article.Title + " " + article.Content)
+	}
+	score := 0
+	for _, field := range ClassifyFields {
+		weight := c.fields[field]
+		if weight == 0 {
+			continue
+		}
+		var text string
+		switch field {
+		case ClassifyTitle:
+			text = article.Title
+		case ClassifyBody:
+			text = article.Content
+		case ClassifyTags:
+			// Tags are separate phrases; a line break keeps a keyword from spanning two
+			text = strings.Join(article.Keywords, "\n")
+		}
+		if c.mentions(text) {
+			score += weight
+			if score >= c.minScore {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// mentions reports whether text contains any of the keywords.
+func (c *KeywordClassifier) mentions(text string) bool {
+	switch c.mode {
+	case MatchStems:
+		words := stems(text)
+		for _, keyword := range c.stems {
+			if containsStems(words, keyword) {
+				return true
+			}
+		}
+	case MatchWords:
+		content := strings.ToLower(text)
+		for _, keyword := range c.keywords {
+			if containsWord(content, keyword) {
+				return true
+			}
+		}
+	default:
+		content := strings.ToLower(text)
+		for _, keyword := range c.keywords {
+			if strings.Contains(content, keyword) {
+				return true
+			}
+		}
+	}
+	for _, pattern := range c.patterns {
+		if pattern.MatchString(text) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsWord reports whether keyword occurs in content with no letter or digit
+// directly before or after it.
+func containsWord(content, keyword string) bool {
+	if keyword == "" {
+		return false
+	}
+	for offset := 0; offset < len(content); {
+		i := strings.Index(content[offset:], keyword)
+		if i < 0 {
+			return false
+		}
+		start := offset + i
+		end := start + len(keyword)
+		before, _ := utf8.DecodeLastRuneInString(content[:start])
+		after, _ := utf8.DecodeRuneInString(content[end:])
+		if !isWordRune(before) && !isWordRune(after) {
+			return true
+		}
+		_, size := utf8.DecodeRuneInString(content[start:])
+		offset = start + size
+	}
+	return false
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
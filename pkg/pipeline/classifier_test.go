@@ -0,0 +1,224 @@
+package pipeline_test
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/gopost/integration/pkg/pipeline"
+)
+
+func TestKeywordClassifier_Matches(t *testing.T) {
+	classifier, err := pipeline.NewKeywordClassifier([]string{"Police", "robbery"}, pipeline.MatchSubstrings)
+	if err != nil {
+		t.Fatalf("NewKeywordClassifier() error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		article  pipeline.Article
+		expected bool
+	}{
+		{"keyword in title", pipeline.Article{Title: "Police respond to call"}, true},
+		{"keyword in body", pipeline.Article{Title: "Downtown", Content: "An armed ROBBERY occurred"}, true},
+		{"no keyword", pipeline.Article{Title: "Farmers market opens", Content: "Fresh produce"}, false},
+		{"empty article", pipeline.Article{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := classifier.Matches(tt.article); got != tt.expected {
+				t.Errorf("Matches() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestKeywordClassifier_WholeWords(t *testing.T) {
+	classifier, err := pipeline.NewKeywordClassifier([]string{"theft", "eft", "break and enter", "B&E"}, pipeline.MatchWords)
+	if err != nil {
+		t.Fatalf("NewKeywordClassifier() error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		article  pipeline.Article
+		expected bool
+	}{
+		{"whole word", pipeline.Article{Title: "Theft reported downtown"}, true},
+		{"punctuation around word", pipeline.Article{Content: "Police said the (theft) was minor."}, true},
+		{"inside a longer word", pipeline.Article{Title: "Councillor left the meeting"}, false},
+		{"plural", pipeline.Article{Title: "Thefts on the rise"}, false},
+		{"later occurrence is a whole word", pipeline.Article{Content: "Thefts fell, but one theft remains"}, true},
+		{"phrase", pipeline.Article{Title: "Break and enter at a pharmacy"}, true},
+		{"keyword with symbols", pipeline.Article{Title: "Suspect charged with B&E"}, true},
+		{"no keyword", pipeline.Article{Title: "Farmers market opens"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := classifier.Matches(tt.article); got != tt.expected {
+				t.Errorf("Matches() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestKeywordClassifier_Fields(t *testing.T) {
+	tests := []struct {
+		name     string
+		weights  map[string]int
+		minScore int
+		article  pipeline.Article
+		expected bool
+	}{
+		{"title only, keyword in title", map[string]int{"title": 1}, 1, pipeline.Article{Title: "Robbery downtown"}, true},
+		{"title only, keyword in body", map[string]int{"title": 1}, 1, pipeline.Article{Title: "Downtown", Content: "A robbery"}, false},
+		{"weighted, title suffices", map[string]int{"title": 2, "body": 1}, 2, pipeline.Article{Title: "Robbery downtown"}, true},
+		{"weighted, body alone falls short", map[string]int{"title": 2, "body": 1}, 2, pipeline.Article{Content: "A robbery"}, false},
+		{"weighted, body and tags", map[string]int{"title": 2, "body": 1, "tags": 1}, 2,
+			pipeline.Article{Content: "A robbery", Keywords: []string{"Crime", "Robbery"}}, true},
+		{"tags are separate phrases", map[string]int{"tags": 1}, 1, pipeline.Article{Keywords: []string{"armed", "robbery"}}, true},
+		{"unknown fields are ignored", map[string]int{"headline": 5, "body": 1}, 1, pipeline.Article{Title: "Robbery downtown"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			classifier, err := pipeline.NewKeywordClassifier([]string{"robbery"}, pipeline.MatchWords)
+			if err != nil {
+				t.Fatalf("NewKeywordClassifier() error = %v", err)
+			}
+			classifier.SetFields(tt.weights, tt.minScore)
+			if got := classifier.Matches(tt.article); got != tt.expected {
+				t.Errorf("Matches() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestKeywordClassifier_Patterns(t *testing.T) {
+	classifier, err := pipeline.NewKeywordClassifier([]string{`/\bB&E\b/i`, `/Break.{0,5}Enter/`}, pipeline.MatchWords)
+	if err != nil {
+		t.Fatalf("NewKeywordClassifier() error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		article  pipeline.Article
+		expected bool
+	}{
+		{"case-insensitive pattern", pipeline.Article{Title: "Man charged after b&e on Elm St"}, true},
+		{"pattern needs its boundaries", pipeline.Article{Title: "B&Eagle Hardware opens"}, false},
+		{"case-sensitive pattern", pipeline.Article{Content: "Break and Enter reported"}, true},
+		{"case-sensitive pattern, other case", pipeline.Article{Content: "break and enter reported"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := classifier.Matches(tt.article); got != tt.expected {
+				t.Errorf("Matches() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestKeywordClassifier_Stems(t *testing.T) {
+	classifier, err := pipeline.NewKeywordClassifier([]string{"robbery", "assault", "charge", "break and enter"}, pipeline.MatchStems)
+	if err != nil {
+		t.Fatalf("NewKeywordClassifier() error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		article  pipeline.Article
+		expected bool
+	}{
+		{"same form", pipeline.Article{Title: "Robbery at a gas station"}, true},
+		{"plural", pipeline.Article{Title: "String of robberies downtown"}, true},
+		{"past tense", pipeline.Article{Content: "A man was assaulted on Elm St."}, true},
+		{"gerund", pipeline.Article{Content: "Police are charging two suspects"}, true},
+		{"inflected phrase", pipeline.Article{Title: "Breaking and entering at a pharmacy"}, true},
+		{"words apart", pipeline.Article{Title: "Break in the case, police enter home"}, false},
+		{"derived word", pipeline.Article{Title: "Robber flees on foot"}, false},
+		{"no keyword", pipeline.Article{Title: "Farmers market opens"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := classifier.Matches(tt.article); got != tt.expected {
+				t.Errorf("Matches() = %v, want %v", got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestStem(t *testing.T) {
+	tests := []struct {
+		word string
+		want string
+	}{
+		{"robbery", "robbery"},
+		{"robberies", "robbery"},
+		{"assault", "assault"},
+		{"Assaulted", "assault"},
+		{"assaulting", "assault"},
+		{"assaults", "assault"},
+		{"charge", "charg"},
+		{"charged", "charg"},
+		{"charges", "charg"},
+		{"robbed", "rob"},
+		{"stabbing", "stab"},
+		{"stabbings", "stab"},
+		{"killed", "kill"},
+		{"witnesses", "witness"},
+		{"bus", "bus"},
+		{"status", "status"},
+		{"ring", "ring"},
+	}
+
+	for _, tt := range tests {
+		if got := pipeline.Stem(tt.word); got != tt.want {
+			t.Errorf("Stem(%q) = %q, want %q", tt.word, got, tt.want)
+		}
+	}
+}
+
+func TestParseKeyword(t *testing.T) {
+	tests := []struct {
+		keyword     string
+		wantPattern bool
+		wantErr     string
+	}{
+		{keyword: "robbery"},
+		{keyword: "/robbery"},
+		{keyword: `/\bB&E\b/i`, wantPattern: true},
+		{keyword: "/rob(bery/", wantErr: "missing closing )"},
+		{keyword: "/robbery/g", wantErr: "unknown flags"},
+		{keyword: "//i", wantErr: "empty pattern"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.keyword, func(t *testing.T) {
+			pattern, err := pipeline.ParseKeyword(tt.keyword)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("ParseKeyword() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseKeyword() error = %v", err)
+			}
+			if (pattern != nil) != tt.wantPattern {
+				t.Errorf("ParseKeyword() = %v, want pattern %v", pattern, tt.wantPattern)
+			}
+		})
+	}
+}
+
+func TestSearchTerms(t *testing.T) {
+	got := pipeline.SearchTerms([]string{"police", `/\bB&E\b/i`, `/break.{0,5}enter/`, "/rob(/"})
+	want := []string{"police", "B&E", "break", "enter"}
+	if !slices.Equal(got, want) {
+		t.Errorf("SearchTerms() = %q, want %q", got, want)
+	}
+}
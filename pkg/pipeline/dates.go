@@ -0,0 +1,129 @@
+package pipeline
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultDateLayouts are tried, after RFC 3339, for published_date strings.
+// Layouts without a zone are interpreted in the parser's location.
+var DefaultDateLayouts = []string{
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05Z0700",
+	"2006-01-02 15:04:05Z07:00",
+	time.RFC1123Z,
+	time.RFC1123,
+	"2006-01-02",
+}
+
+// epochMillisThreshold separates epoch seconds from epoch milliseconds: a seconds value
+// this large would be in the year 5138.
+const epochMillisThreshold = 100_000_000_000
+
+// DateParser parses published_date values in the formats found in crawled indexes:
+// RFC 3339, epoch seconds or milliseconds (as numbers or strings), and configurable
+// layouts such as date-only values. Values without a zone are taken to be in loc.
+type DateParser struct {
+	layouts []string
+	loc     *time.Location
+}
+
+// NewDateParser returns a parser trying layouts (DefaultDateLayouts if empty) in order.
+// A nil loc means UTC.
+func NewDateParser(layouts []string, loc *time.Location) *DateParser {
+	if len(layouts) == 0 {
+		layouts = DefaultDateLayouts
+	}
+	if loc == nil {
+		loc = time.UTC
+	}
+	return &DateParser{
+		layouts: layouts,
+		loc:     loc,
+	}
+}
+
+// Location returns the timezone used for values without a zone.
+func (p *DateParser) Location() *time.Location {
+	return p.loc
+}
+
+// Parse parses a raw JSON date value. null, "" and a missing value yield the zero time.
+func (p *DateParser) Parse(raw json.RawMessage) (time.Time, error) {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) == 0 || bytes.Equal(raw, []byte("null")) {
+		return time.Time{}, nil
+	}
+
+	if raw[0] != '"' {
+		epoch, err := strconv.ParseFloat(string(raw), 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("parse date %s: not a string or number", raw)
+		}
+		return fromEpoch(int64(epoch)), nil
+	}
+
+	var value string
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return time.Time{}, fmt.Errorf("parse date %s: %w", raw, err)
+	}
+	return p.ParseString(value)
+}
+
+// ParseString parses a date string.
+func (p *DateParser) ParseString(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	if epoch, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return fromEpoch(epoch), nil
+	}
+	if t, err := time.Parse(time.RFC3339Nano, value); err == nil {
+		return t, nil
+	}
+	for _, layout := range p.layouts {
+		if t, err := time.ParseInLocation(layout, value, p.loc); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("parse date %q: no matching layout", value)
+}
+
+func fromEpoch(epoch int64) time.Time {
+	if epoch >= epochMillisThreshold || epoch <= -epochMillisThreshold {
+		return time.UnixMilli(epoch).UTC()
+	}
+	return time.Unix(epoch, 0).UTC()
+}
+
+// DecodeArticle decodes an article document, parsing published_date with dates
+// instead of requiring RFC 3339. A nil dates uses a UTC parser with DefaultDateLayouts.
+func DecodeArticle(source json.RawMessage, dates *DateParser) (Article, error) {
+	if dates == nil {
+		dates = NewDateParser(nil, nil)
+	}
+
+	// The outer PublishedAt shadows Article.PublishedAt, so the raw value is captured here
+	var doc struct {
+		Article
+		PublishedAt json.RawMessage `json:"published_date"`
+	}
+	if err := json.Unmarshal(source, &doc); err != nil {
+		return Article{}, err
+	}
+
+	article := doc.Article
+	publishedAt, err := dates.Parse(doc.PublishedAt)
+	if err != nil {
+		return Article{}, err
+	}
+	article.PublishedAt = publishedAt
+	return article, nil
+}
@@ -0,0 +1,73 @@
+package pipeline_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/gopost/integration/pkg/pipeline"
+)
+
+func TestDateParser_Parse(t *testing.T) {
+	toronto, err := time.LoadLocation("America/Toronto")
+	if err != nil {
+		t.Skipf("timezone data unavailable: %v", err)
+	}
+	parser := pipeline.NewDateParser(nil, toronto)
+
+	tests := []struct {
+		name string
+		raw  string
+		want time.Time
+	}{
+		{"RFC 3339", `"2025-01-15T10:30:00Z"`, time.Date(2025, 1, 15, 10, 30, 0, 0, time.UTC)},
+		{"RFC 3339 with offset", `"2025-01-15T10:30:00-05:00"`, time.Date(2025, 1, 15, 15, 30, 0, 0, time.UTC)},
+		{"no zone uses service timezone", `"2025-01-15T10:30:00"`, time.Date(2025, 1, 15, 10, 30, 0, 0, toronto)},
+		{"date only", `"2025-07-01"`, time.Date(2025, 7, 1, 0, 0, 0, 0, toronto)},
+		{"epoch millis", `1736937000000`, time.Date(2025, 1, 15, 10, 30, 0, 0, time.UTC)},
+		{"epoch seconds", `1736937000`, time.Date(2025, 1, 15, 10, 30, 0, 0, time.UTC)},
+		{"epoch millis string", `"1736937000000"`, time.Date(2025, 1, 15, 10, 30, 0, 0, time.UTC)},
+		{"null", `null`, time.Time{}},
+		{"empty", `""`, time.Time{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parser.Parse(json.RawMessage(tt.raw))
+			if err != nil {
+				t.Fatalf("Parse(%s) error = %v", tt.raw, err)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("Parse(%s) = %v, want %v", tt.raw, got, tt.want)
+			}
+		})
+	}
+
+	if _, err := parser.Parse(json.RawMessage(`"last Tuesday"`)); err == nil {
+		t.Error("Parse(last Tuesday) error = nil, want error")
+	}
+}
+
+func TestDateParser_CustomLayouts(t *testing.T) {
+	parser := pipeline.NewDateParser([]string{"02/01/2006 15:04"}, time.UTC)
+
+	got, err := parser.ParseString("15/01/2025 10:30")
+	if err != nil {
+		t.Fatalf("ParseString() error = %v", err)
+	}
+	if want := time.Date(2025, 1, 15, 10, 30, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("ParseString() = %v, want %v", got, want)
+	}
+}
+
+func TestDecodeArticle_TolerantPublishedDate(t *testing.T) {
+	article, err := pipeline.DecodeArticle(json.RawMessage(`{"id":"a","title":"Police","published_date":1736937000000}`), nil)
+	if err != nil {
+		t.Fatalf("DecodeArticle() error = %v", err)
+	}
+	if article.ID != "a" || article.Title != "Police" {
+		t.Errorf("article = %+v, want id and title decoded", article)
+	}
+	if want := time.Date(2025, 1, 15, 10, 30, 0, 0, time.UTC); !article.PublishedAt.Equal(want) {
+		t.Errorf("PublishedAt = %v, want %v", article.PublishedAt, want)
+	}
+}
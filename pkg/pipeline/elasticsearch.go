@@ -0,0 +1,512 @@
+package pipeline
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"strings"
+
+	"github.com/elastic/go-elasticsearch/v8"
+	"github.com/elastic/go-elasticsearch/v8/esapi"
+)
+
+// esSource is the Elasticsearch-backed Source.
+type esSource struct {
+	client *elasticsearch.Client
+}
+
+// NewElasticsearchSource returns a Source backed by an Elasticsearch client.
+func NewElasticsearchSource(client *elasticsearch.Client) Source {
+	return &esSource{client: client}
+}
+
+func (e *esSource) Search(ctx context.Context, index string, query any) (*SearchResult, error) {
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(query); err != nil {
+		return nil, fmt.Errorf("encode query: %w", err)
+	}
+
+	res, err := e.client.Search(
+		e.client.Search.WithContext(ctx),
+		e.client.Search.WithIndex(index),
+		e.client.Search.WithBody(&buf),
+		e.client.Search.WithTrackTotalHits(true),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("search error: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return nil, responseError(res)
+	}
+
+	response, err := decodeSearchResponse(json.NewDecoder(res.Body))
+	if err != nil {
+		return nil, fmt.Errorf("decode response: %w", err)
+	}
+	return response.result, nil
+}
+
+// MultiSearch runs the searches with the multi search API.
+func (e *esSource) MultiSearch(ctx context.Context, searches []SearchRequest) ([]MultiSearchResult, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, search := range searches {
+		if err := enc.Encode(map[string]any{"index": search.Index}); err != nil {
+			return nil, fmt.Errorf("encode search header: %w", err)
+		}
+		if err := enc.Encode(trackTotalHits(search.Query)); err != nil {
+			return nil, fmt.Errorf("encode query for %s: %w", search.Index, err)
+		}
+	}
+
+	res, err := e.client.Msearch(&buf,
+		e.client.Msearch.WithContext(ctx),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("multi search error: %w", err)
+	}
+	defer res.Body.Close()
+	if res.IsError() {
+		return nil, fmt.Errorf("multi search: %w", responseError(res))
+	}
+
+	var responses []*searchResponse
+	dec := json.NewDecoder(res.Body)
+	err = decodeObject(dec, func(key string) error {
+		if key != "responses" {
+			return skipValue(dec)
+		}
+		return decodeArray(dec, func() error {
+			response, err := decodeSearchResponse(dec)
+			responses = append(responses, response)
+			return err
+		})
+	})
+	if err != nil {
+		return nil, fmt.Errorf("decode response: %w", err)
+	}
+	if len(responses) != len(searches) {
+		return nil, fmt.Errorf("multi search returned %d responses for %d searches", len(responses), len(searches))
+	}
+
+	results := make([]MultiSearchResult, len(searches))
+	for i, item := range responses {
+		if item.Error != nil {
+			results[i].Err = newResponseError(item.Status, map[string]any{"error": item.Error, "status": item.Status})
+			continue
+		}
+		results[i].Result = item.result
+	}
+	return results, nil
+}
+
+// SearchTemplate runs the stored search template id with the search template API.
+func (e *esSource) SearchTemplate(ctx context.Context, index, id string, params map[string]any) (*SearchResult, error) {
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(map[string]any{"id": id, "params": params}); err != nil {
+		return nil, fmt.Errorf("encode template params: %w", err)
+	}
+
+	res, err := e.client.SearchTemplate(&buf,
+		e.client.SearchTemplate.WithContext(ctx),
+		e.client.SearchTemplate.WithIndex(index),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("search template error: %w", err)
+	}
+	defer res.Body.Close()
+	if res.IsError() {
+		return nil, responseError(res)
+	}
+
+	response, err := decodeSearchResponse(json.NewDecoder(res.Body))
+	if err != nil {
+		return nil, fmt.Errorf("decode response: %w", err)
+	}
+	return response.result, nil
+}
+
+// PutSearchTemplate stores source as a mustache script with the stored script API.
+func (e *esSource) PutSearchTemplate(ctx context.Context, id, source string) error {
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(map[string]any{
+		"script": map[string]any{"lang": "mustache", "source": source},
+	}); err != nil {
+		return fmt.Errorf("encode template: %w", err)
+	}
+
+	res, err := e.client.PutScript(id, &buf,
+		e.client.PutScript.WithContext(ctx),
+	)
+	if err != nil {
+		return fmt.Errorf("put script error: %w", err)
+	}
+	defer res.Body.Close()
+	if res.IsError() {
+		return fmt.Errorf("put search template %s: %w", id, responseError(res))
+	}
+	return nil
+}
+
+// trackTotalHits asks for the exact total of a multi search query, which Search does with
+// a URL parameter. Queries other than maps are sent unchanged.
+func trackTotalHits(query any) any {
+	body, ok := query.(map[string]any)
+	if !ok {
+		return query
+	}
+	if _, set := body["track_total_hits"]; set {
+		return body
+	}
+	tracked := maps.Clone(body)
+	tracked["track_total_hits"] = true
+	return tracked
+}
+
+// searchResponse is the part of a search response the pipeline reads. Error and Status
+// are set for failed searches of a multi search.
+type searchResponse struct {
+	result *SearchResult
+	Error  map[string]any
+	Status int
+}
+
+// searchHit is a hit as it appears in a search response.
+type searchHit struct {
+	ID     string                     `json:"_id"`
+	Source json.RawMessage            `json:"_source"`
+	Fields map[string]json.RawMessage `json:"fields"`
+
+	Highlight map[string][]string `json:"highlight"`
+}
+
+// decodeSearchResponse reads one search response object from dec a hit at a time, so
+// only the hits themselves are held in memory rather than the whole response as well.
+// Parts of the response the pipeline does not read, such as aggregations, are skipped.
+func decodeSearchResponse(dec *json.Decoder) (*searchResponse, error) {
+	response := &searchResponse{result: &SearchResult{}}
+	err := decodeObject(dec, func(key string) error {
+		switch key {
+		case "hits":
+			return decodeObject(dec, func(key string) error {
+				switch key {
+				case "total":
+					var total struct {
+						Value int `json:"value"`
+					}
+					err := dec.Decode(&total)
+					response.result.Total = total.Value
+					return err
+				case "hits":
+					return decodeArray(dec, func() error {
+						var hit searchHit
+						if err := dec.Decode(&hit); err != nil {
+							return err
+						}
+						response.result.Hits = append(response.result.Hits, SearchHit(hit))
+						return nil
+					})
+				default:
+					return skipValue(dec)
+				}
+			})
+		case "error":
+			return dec.Decode(&response.Error)
+		case "status":
+			return dec.Decode(&response.Status)
+		default:
+			return skipValue(dec)
+		}
+	})
+	return response, err
+}
+
+// decodeObject reads a JSON object from dec, calling field for each key with dec
+// positioned at its value; field must consume the value. A null is read as an empty object.
+func decodeObject(dec *json.Decoder, field func(key string) error) error {
+	tok, err := dec.Token()
+	if err != nil || tok == nil {
+		return err
+	}
+	if tok != json.Delim('{') {
+		return fmt.Errorf("expected an object, got %v", tok)
+	}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		if err := field(tok.(string)); err != nil {
+			return err
+		}
+	}
+	_, err = dec.Token() // '}'
+	return err
+}
+
+// decodeArray reads a JSON array from dec, calling element with dec positioned at each
+// element; element must consume it. A null is read as an empty array.
+func decodeArray(dec *json.Decoder, element func() error) error {
+	tok, err := dec.Token()
+	if err != nil || tok == nil {
+		return err
+	}
+	if tok != json.Delim('[') {
+		return fmt.Errorf("expected an array, got %v", tok)
+	}
+	for dec.More() {
+		if err := element(); err != nil {
+			return err
+		}
+	}
+	_, err = dec.Token() // ']'
+	return err
+}
+
+// skipValue reads past the next JSON value of dec without keeping it.
+func skipValue(dec *json.Decoder) error {
+	depth := 0
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		switch tok {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+		if depth == 0 {
+			return nil
+		}
+	}
+}
+
+// ListIndices lists the open indices matching pattern with the cat indices API.
+func (e *esSource) ListIndices(ctx context.Context, pattern string) ([]string, error) {
+	res, err := e.client.Cat.Indices(
+		e.client.Cat.Indices.WithContext(ctx),
+		e.client.Cat.Indices.WithIndex(pattern),
+		e.client.Cat.Indices.WithFormat("json"),
+		e.client.Cat.Indices.WithH("index"),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("cat indices error: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return nil, fmt.Errorf("elasticsearch error response: %s", res.Status())
+	}
+
+	var rows []struct {
+		Index string `json:"index"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&rows); err != nil {
+		return nil, fmt.Errorf("decode response: %w", err)
+	}
+	indices := make([]string, 0, len(rows))
+	for _, row := range rows {
+		indices = append(indices, row.Index)
+	}
+	return indices, nil
+}
+
+// SaveQuery stores query as a percolator document, creating the percolator index (or
+// extending its mapping) with the field mappings of mappingsFrom first.
+func (e *esSource) SaveQuery(ctx context.Context, index, id string, query any, mappingsFrom string) error {
+	properties, err := e.fieldMappings(ctx, mappingsFrom)
+	if err != nil {
+		return err
+	}
+	properties["query"] = map[string]any{"type": "percolator"}
+	if err := e.ensureMapping(ctx, index, properties); err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(map[string]any{"query": query}); err != nil {
+		return fmt.Errorf("encode query: %w", err)
+	}
+	res, err := e.client.Index(index, &buf,
+		e.client.Index.WithContext(ctx),
+		e.client.Index.WithDocumentID(id),
+		e.client.Index.WithRefresh("true"),
+	)
+	if err != nil {
+		return fmt.Errorf("index query error: %w", err)
+	}
+	defer res.Body.Close()
+	if res.IsError() {
+		return fmt.Errorf("index query: %w", responseError(res))
+	}
+	return nil
+}
+
+// IndexDocument stores doc under id in index.
+func (e *esSource) IndexDocument(ctx context.Context, index, id string, doc any) error {
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(doc); err != nil {
+		return fmt.Errorf("encode document: %w", err)
+	}
+	res, err := e.client.Index(index, &buf,
+		e.client.Index.WithContext(ctx),
+		e.client.Index.WithDocumentID(id),
+	)
+	if err != nil {
+		return fmt.Errorf("index document error: %w", err)
+	}
+	defer res.Body.Close()
+	if res.IsError() {
+		return fmt.Errorf("index document %s: %w", id, responseError(res))
+	}
+	return nil
+}
+
+// UpdateDocument merges fields into the document stored under id in index.
+func (e *esSource) UpdateDocument(ctx context.Context, index, id string, fields any) error {
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(map[string]any{"doc": fields}); err != nil {
+		return fmt.Errorf("encode document update: %w", err)
+	}
+	res, err := e.client.Update(index, id, &buf,
+		e.client.Update.WithContext(ctx),
+	)
+	if err != nil {
+		return fmt.Errorf("update document error: %w", err)
+	}
+	defer res.Body.Close()
+	if res.IsError() {
+		return fmt.Errorf("update document %s: %w", id, responseError(res))
+	}
+	return nil
+}
+
+// fieldMappings returns the merged field mappings of the indices matching index.
+func (e *esSource) fieldMappings(ctx context.Context, index string) (map[string]any, error) {
+	res, err := e.client.Indices.GetMapping(
+		e.client.Indices.GetMapping.WithContext(ctx),
+		e.client.Indices.GetMapping.WithIndex(index),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("get mapping error: %w", err)
+	}
+	defer res.Body.Close()
+	if res.IsError() {
+		return nil, fmt.Errorf("get mapping of %s: %w", index, responseError(res))
+	}
+
+	var response map[string]struct {
+		Mappings struct {
+			Properties map[string]any `json:"properties"`
+		} `json:"mappings"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("decode mapping: %w", err)
+	}
+	properties := make(map[string]any)
+	for _, mapping := range response {
+		for field, property := range mapping.Mappings.Properties {
+			properties[field] = property
+		}
+	}
+	return properties, nil
+}
+
+// ensureMapping creates index with properties, or adds properties to it if it exists.
+func (e *esSource) ensureMapping(ctx context.Context, index string, properties map[string]any) error {
+	mapping := map[string]any{"properties": properties}
+	body, err := json.Marshal(mapping)
+	if err != nil {
+		return fmt.Errorf("encode mapping: %w", err)
+	}
+	createBody, err := json.Marshal(map[string]any{"mappings": mapping})
+	if err != nil {
+		return fmt.Errorf("encode mapping: %w", err)
+	}
+
+	created, err := e.client.Indices.Create(index,
+		e.client.Indices.Create.WithContext(ctx),
+		e.client.Indices.Create.WithBody(bytes.NewReader(createBody)),
+	)
+	if err != nil {
+		return fmt.Errorf("create index error: %w", err)
+	}
+	defer created.Body.Close()
+	if !created.IsError() {
+		return nil
+	}
+	createErr := responseError(created)
+	if !strings.Contains(createErr.Error(), "resource_already_exists_exception") {
+		return fmt.Errorf("create index %s: %w", index, createErr)
+	}
+
+	res, err := e.client.Indices.PutMapping([]string{index}, bytes.NewReader(body),
+		e.client.Indices.PutMapping.WithContext(ctx),
+	)
+	if err != nil {
+		return fmt.Errorf("put mapping error: %w", err)
+	}
+	defer res.Body.Close()
+	if res.IsError() {
+		return fmt.Errorf("put mapping of %s: %w", index, responseError(res))
+	}
+	return nil
+}
+
+// ErrIndexUnavailable matches errors for searches against an index that is closed or
+// frozen. Unlike most search errors, retrying will not help until the index is reopened.
+var ErrIndexUnavailable = errors.New("elasticsearch index is closed or frozen")
+
+// ResponseError is an Elasticsearch error response. Use errors.As to read its error type.
+type ResponseError struct {
+	StatusCode int
+	Type       string         // Error type, e.g. "index_closed_exception"
+	Reason     string         // Error reason, e.g. "closed"
+	Body       map[string]any // Whole decoded response
+}
+
+func (e *ResponseError) Error() string {
+	return fmt.Sprintf("elasticsearch error: %v", e.Body)
+}
+
+// Is makes errors.Is(err, ErrIndexUnavailable) report whether the index is closed, or
+// blocked because it is closed or frozen.
+func (e *ResponseError) Is(target error) bool {
+	if target != ErrIndexUnavailable {
+		return false
+	}
+	switch e.Type {
+	case "index_closed_exception":
+		return true
+	case "cluster_block_exception":
+		reason := strings.ToLower(e.Reason)
+		return strings.Contains(reason, "index closed") || strings.Contains(reason, "frozen")
+	}
+	return false
+}
+
+// newResponseError reads the error type and reason of a decoded error response.
+func newResponseError(statusCode int, body map[string]any) *ResponseError {
+	responseErr := &ResponseError{StatusCode: statusCode, Body: body}
+	if details, ok := body["error"].(map[string]any); ok {
+		responseErr.Type, _ = details["type"].(string)
+		responseErr.Reason, _ = details["reason"].(string)
+	}
+	return responseErr
+}
+
+// responseError describes an Elasticsearch error response.
+func responseError(res *esapi.Response) error {
+	var body map[string]any
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		return fmt.Errorf("elasticsearch error response: %s", res.Status())
+	}
+	return newResponseError(res.StatusCode, body)
+}
@@ -0,0 +1,57 @@
+package pipeline_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/elastic/go-elasticsearch/v8"
+	"github.com/gopost/integration/pkg/pipeline"
+)
+
+func TestElasticsearchSource_StreamsHits(t *testing.T) {
+	const response = `{
+		"took": 3,
+		"_shards": {"total": 1, "successful": 1, "failed": 0},
+		"hits": {
+			"total": {"value": 42, "relation": "eq"},
+			"max_score": null,
+			"hits": [
+				{"_id": "a1", "_score": 1.5, "_source": {"title": "Police investigate robbery", "tags": ["a", {"b": [1, 2]}]},
+					"highlight": {"title": ["<em>Police</em> investigate"]}},
+				{"_id": "a2", "_source": {"title": "Suspect charged"}, "fields": {"_percolator_document_slot": [0]}}
+			]
+		},
+		"aggregations": {"by_source": {"buckets": [{"key": "sudbury.com", "doc_count": 2}]}}
+	}`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Elastic-Product", "Elasticsearch")
+		_, _ = io.WriteString(w, response)
+	}))
+	t.Cleanup(server.Close)
+	client, err := elasticsearch.NewClient(elasticsearch.Config{Addresses: []string{server.URL}})
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+
+	result, err := pipeline.NewElasticsearchSource(client).Search(context.Background(), "sudbury_com_articles", map[string]any{})
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if result.Total != 42 || len(result.Hits) != 2 {
+		t.Fatalf("Search() = total %d, %d hits, want 42, 2", result.Total, len(result.Hits))
+	}
+	if got := string(result.Hits[0].Source); got != `{"title": "Police investigate robbery", "tags": ["a", {"b": [1, 2]}]}` {
+		t.Errorf("Hits[0].Source = %s", got)
+	}
+	if want := map[string][]string{"title": {"<em>Police</em> investigate"}}; !reflect.DeepEqual(result.Hits[0].Highlight, want) {
+		t.Errorf("Hits[0].Highlight = %v, want %v", result.Hits[0].Highlight, want)
+	}
+	if result.Hits[1].ID != "a2" || string(result.Hits[1].Fields[pipeline.PercolatorSlotField]) != "[0]" {
+		t.Errorf("Hits[1] = %+v", result.Hits[1])
+	}
+}
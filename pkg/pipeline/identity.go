@@ -0,0 +1,172 @@
+package pipeline
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Identity strategies accepted by ParseIdentity. IdentityFieldPrefix is followed by the
+// name of a top-level _source field, e.g. "field:guid" for the GUID of an RSS item.
+const (
+	IdentityID          = "id"     // _source.id, else the document _id (default)
+	IdentityDocumentID  = "es_id"  // The document _id, ignoring _source.id
+	IdentityURL         = "url"    // The canonical URL, hashed for the dedup key
+	IdentityContentHash = "hash"   // A hash of the normalized title and body
+	IdentityFieldPrefix = "field:" // The value of a _source field
+)
+
+// Dedup key prefixes of the hashed identities, and the hash length in bytes.
+const (
+	identityKeyURL     = "url:"
+	identityKeyContent = "hash:"
+	identityHashBytes  = 16
+)
+
+// Identity derives an article's identity from its search hit: the dedup key under which
+// it is remembered as posted, and the external ID stored in the Drupal node's
+// field_external_id. Sources identify articles differently (Elasticsearch _id, canonical
+// URL, RSS GUID, content), so the strategy is chosen per city or outlet. Both return ""
+// when the hit has nothing to identify the article by.
+//
+// The built-in identities use the dedup key as the external ID, since moderation webhooks
+// and suppressions name articles by field_external_id and are applied by dedup key; an
+// identity returning a different external ID gives up those features.
+type Identity interface {
+	Key(hit SearchHit, article Article) string
+	ExternalID(hit SearchHit, article Article) string
+}
+
+// ParseIdentity returns the Identity named by spec, one of IdentityID, IdentityDocumentID,
+// IdentityURL, IdentityContentHash, or IdentityFieldPrefix followed by a field name. An
+// empty spec is IdentityID. urls normalizes canonical URLs for IdentityURL; nil keeps
+// them as they are.
+func ParseIdentity(spec string, urls *URLNormalizer) (Identity, error) {
+	switch spec {
+	case "", IdentityID:
+		return SourceIDIdentity{}, nil
+	case IdentityDocumentID:
+		return DocumentIDIdentity{}, nil
+	case IdentityURL:
+		return URLIdentity{Normalizer: urls}, nil
+	case IdentityContentHash:
+		return ContentHashIdentity{}, nil
+	}
+	if field, ok := strings.CutPrefix(spec, IdentityFieldPrefix); ok {
+		if field = strings.TrimSpace(field); field == "" {
+			return nil, fmt.Errorf("identity %q: field name is required", spec)
+		}
+		return FieldIdentity{Field: field}, nil
+	}
+	return nil, fmt.Errorf("identity must be %s, %s, %s, %s, or %s<field>, got %q",
+		IdentityID, IdentityDocumentID, IdentityURL, IdentityContentHash, IdentityFieldPrefix, spec)
+}
+
+// SourceIDIdentity identifies articles by _source.id, falling back to the document _id.
+type SourceIDIdentity struct{}
+
+func (SourceIDIdentity) Key(hit SearchHit, article Article) string {
+	if article.ID != "" {
+		return article.ID
+	}
+	return hit.ID
+}
+
+func (i SourceIDIdentity) ExternalID(hit SearchHit, article Article) string {
+	return i.Key(hit, article)
+}
+
+// DocumentIDIdentity identifies articles by the document _id, for indices whose _source.id
+// is not unique.
+type DocumentIDIdentity struct{}
+
+func (DocumentIDIdentity) Key(hit SearchHit, _ Article) string {
+	return hit.ID
+}
+
+func (DocumentIDIdentity) ExternalID(hit SearchHit, _ Article) string {
+	return hit.ID
+}
+
+// URLIdentity identifies articles by canonical URL, so the same story indexed twice, e.g.
+// by two crawlers, is posted once. The dedup key, also the external ID, is "url:" and a
+// hash of the URL, keeping it short enough for Drupal and Elasticsearch IDs.
+type URLIdentity struct {
+	Normalizer *URLNormalizer // Applied before hashing; nil uses the URL as it is
+}
+
+func (i URLIdentity) canonical(article Article) string {
+	if i.Normalizer == nil {
+		return strings.TrimSpace(article.URL)
+	}
+	normalized, err := i.Normalizer.Normalize(article.URL, article.Source)
+	if err != nil {
+		return strings.TrimSpace(article.URL)
+	}
+	return normalized
+}
+
+func (i URLIdentity) Key(_ SearchHit, article Article) string {
+	canonical := i.canonical(article)
+	if canonical == "" {
+		return ""
+	}
+	return identityKeyURL + identityHash(canonical)
+}
+
+func (i URLIdentity) ExternalID(hit SearchHit, article Article) string {
+	return i.Key(hit, article)
+}
+
+// ContentHashIdentity identifies articles by their normalized title and body, for sources
+// without stable IDs or URLs. The dedup key, also the external ID, is "hash:" and the hash.
+type ContentHashIdentity struct{}
+
+func (ContentHashIdentity) Key(_ SearchHit, article Article) string {
+	title, body := NormalizeText(article.Title), NormalizeText(article.Content)
+	if title == "" && body == "" {
+		return ""
+	}
+	return identityKeyContent + identityHash(title+"\n"+body)
+}
+
+func (i ContentHashIdentity) ExternalID(hit SearchHit, article Article) string {
+	return i.Key(hit, article)
+}
+
+// FieldIdentity identifies articles by a top-level _source field holding a string or a
+// number, such as the GUID of an RSS item.
+type FieldIdentity struct {
+	Field string
+}
+
+func (i FieldIdentity) Key(hit SearchHit, _ Article) string {
+	var source map[string]json.RawMessage
+	if err := json.Unmarshal(hit.Source, &source); err != nil {
+		return ""
+	}
+	raw, ok := source[i.Field]
+	if !ok {
+		return ""
+	}
+	var value string
+	if err := json.Unmarshal(raw, &value); err == nil {
+		return strings.TrimSpace(value)
+	}
+	var number json.Number
+	if err := json.Unmarshal(raw, &number); err == nil {
+		return number.String()
+	}
+	return ""
+}
+
+func (i FieldIdentity) ExternalID(hit SearchHit, article Article) string {
+	return i.Key(hit, article)
+}
+
+func identityHash(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:identityHashBytes])
+}
@@ -0,0 +1,116 @@
+package pipeline_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/gopost/integration/pkg/pipeline"
+)
+
+func TestParseIdentity(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    pipeline.Identity
+		wantErr bool
+	}{
+		{spec: "", want: pipeline.SourceIDIdentity{}},
+		{spec: "id", want: pipeline.SourceIDIdentity{}},
+		{spec: "es_id", want: pipeline.DocumentIDIdentity{}},
+		{spec: "url", want: pipeline.URLIdentity{}},
+		{spec: "hash", want: pipeline.ContentHashIdentity{}},
+		{spec: "field:guid", want: pipeline.FieldIdentity{Field: "guid"}},
+		{spec: "field:", wantErr: true},
+		{spec: "guid", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			got, err := pipeline.ParseIdentity(tt.spec, nil)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ParseIdentity(%q) = %#v, want error", tt.spec, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseIdentity(%q) error = %v", tt.spec, err)
+			}
+			if got != tt.want {
+				t.Errorf("ParseIdentity(%q) = %#v, want %#v", tt.spec, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIdentity_Key(t *testing.T) {
+	hit := func(id, source string) pipeline.SearchHit {
+		return pipeline.SearchHit{ID: id, Source: json.RawMessage(source)}
+	}
+	article := pipeline.Article{ID: "src-1", Title: "Man charged", Content: "Police said.", URL: "https://sudbury.com/a"}
+
+	tests := []struct {
+		name     string
+		identity pipeline.Identity
+		hit      pipeline.SearchHit
+		article  pipeline.Article
+		want     string
+	}{
+		{name: "source id", identity: pipeline.SourceIDIdentity{}, hit: hit("es-1", `{}`), article: article, want: "src-1"},
+		{name: "source id falls back to _id", identity: pipeline.SourceIDIdentity{}, hit: hit("es-1", `{}`), want: "es-1"},
+		{name: "document id", identity: pipeline.DocumentIDIdentity{}, hit: hit("es-1", `{}`), article: article, want: "es-1"},
+		{name: "url without article url", identity: pipeline.URLIdentity{}, hit: hit("es-1", `{}`), want: ""},
+		{name: "hash without text", identity: pipeline.ContentHashIdentity{}, hit: hit("es-1", `{}`), want: ""},
+		{name: "string field", identity: pipeline.FieldIdentity{Field: "guid"}, hit: hit("es-1", `{"guid":" rss-7 "}`), want: "rss-7"},
+		{name: "number field", identity: pipeline.FieldIdentity{Field: "guid"}, hit: hit("es-1", `{"guid":12345678901234567890}`), want: "12345678901234567890"},
+		{name: "missing field", identity: pipeline.FieldIdentity{Field: "guid"}, hit: hit("es-1", `{"id":"src-1"}`), want: ""},
+		{name: "object field", identity: pipeline.FieldIdentity{Field: "guid"}, hit: hit("es-1", `{"guid":{"a":1}}`), want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.identity.Key(tt.hit, tt.article)
+			if got != tt.want {
+				t.Errorf("Key() = %q, want %q", got, tt.want)
+			}
+			if external := tt.identity.ExternalID(tt.hit, tt.article); external != got {
+				t.Errorf("ExternalID() = %q, want the key %q", external, got)
+			}
+		})
+	}
+}
+
+func TestURLIdentity_SameCanonicalURL(t *testing.T) {
+	identity := pipeline.URLIdentity{Normalizer: pipeline.NewURLNormalizer(nil, false)}
+	key := func(url string) string {
+		return identity.Key(pipeline.SearchHit{}, pipeline.Article{URL: url})
+	}
+
+	a := key("https://sudbury.com/local-news/a")
+	if !strings.HasPrefix(a, "url:") || len(a) != len("url:")+32 {
+		t.Fatalf("Key() = %q, want url: and a 32-digit hash", a)
+	}
+	if b := key("https://Sudbury.com/local-news/a?utm_source=fb#comments"); b != a {
+		t.Errorf("Key() of the same canonical URL = %q, want %q", b, a)
+	}
+	if c := key("https://sudbury.com/local-news/b"); c == a {
+		t.Errorf("Key() of another URL = %q, want a different key", c)
+	}
+}
+
+func TestContentHashIdentity(t *testing.T) {
+	var identity pipeline.ContentHashIdentity
+	key := func(title, body string) string {
+		return identity.Key(pipeline.SearchHit{}, pipeline.Article{Title: title, Content: body})
+	}
+
+	a := key("Man charged after crash", "Police said the driver fled.")
+	if !strings.HasPrefix(a, "hash:") {
+		t.Fatalf("Key() = %q, want a hash: key", a)
+	}
+	if b := key("  MAN charged after crash ", "Police said the driver fled."); b != a {
+		t.Errorf("Key() of the same normalized text = %q, want %q", b, a)
+	}
+	// The separator keeps text moving between title and body from colliding
+	if c := key("Man charged after crash Police", "said the driver fled."); c == a {
+		t.Errorf("Key() of different title and body = %q, want a different key", c)
+	}
+}
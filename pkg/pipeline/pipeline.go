@@ -0,0 +1,176 @@
+// Package pipeline defines the core types of the article integration pipeline:
+// the Article model and the Source, Classifier, Tracker, Poster, and Limiter
+// stages that move articles from a search index into a CMS.
+//
+// The gopost service wires Elasticsearch, keyword matching, Redis, and Drupal
+// implementations of these interfaces; programs embedding the pipeline can
+// supply their own.
+package pipeline
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/gopost/integration/pkg/drupal"
+)
+
+// Elasticsearch field name constants
+const (
+	ESFieldPublishedDate = "published_date"
+	ESFieldBody          = "body"
+	ESFieldCanonicalURL  = "canonical_url"
+	ESFieldTitle         = "title"
+	ESFieldSource        = "source"
+	ESFieldKeywords      = "keywords"
+)
+
+// Article is a crawled news article as stored in the search index.
+type Article struct {
+	ID            string    `json:"id"`
+	Title         string    `json:"title"`          // Maps to ESFieldTitle
+	Content       string    `json:"body"`           // Maps to ESFieldBody
+	URL           string    `json:"canonical_url"`  // Maps to ESFieldCanonicalURL
+	PublishedAt   time.Time `json:"published_date"` // Maps to ESFieldPublishedDate
+	Source        string    `json:"source"`         // Maps to ESFieldSource
+	Intro         string    `json:"intro,omitempty"`
+	Description   string    `json:"description,omitempty"`
+	OGTitle       string    `json:"og_title,omitempty"`
+	OGDescription string    `json:"og_description,omitempty"`
+	OGImage       string    `json:"og_image,omitempty"`
+	OGURL         string    `json:"og_url,omitempty"`
+	WordCount     int       `json:"word_count,omitempty"`
+	Category      string    `json:"category,omitempty"`
+	Section       string    `json:"section,omitempty"`
+	Keywords      []string  `json:"keywords,omitempty"`   // Tags assigned by the source; maps to ESFieldKeywords
+	Highlights    []string  `json:"highlights,omitempty"` // Search fragments with the matched keywords, when highlighting is enabled
+	Severity      int       `json:"severity,omitempty"`   // Keyword-weighted severity score, when severity scoring is enabled
+	ExternalID    string    `json:"-"`                    // Drupal field_external_id, set by the article's Identity; empty uses ID
+}
+
+// Source executes search requests against an article index.
+type Source interface {
+	// Search runs query (any JSON-encodable value) against index.
+	Search(ctx context.Context, index string, query any) (*SearchResult, error)
+}
+
+// IndexLister lists the indices of a search backend, for discovering cities.
+type IndexLister interface {
+	// ListIndices returns the names of the indices matching pattern (e.g. "*_articles").
+	ListIndices(ctx context.Context, pattern string) ([]string, error)
+}
+
+// MultiSearcher is implemented by Sources that can run several searches in one request.
+type MultiSearcher interface {
+	// MultiSearch runs the searches in one request and returns their results in order.
+	// A search that fails has its error in its result; the returned error means the
+	// request as a whole failed, e.g. because the backend does not allow it.
+	MultiSearch(ctx context.Context, searches []SearchRequest) ([]MultiSearchResult, error)
+}
+
+// SearchRequest is one search of a multi-search.
+type SearchRequest struct {
+	Index string
+	Query any // Any JSON-encodable value
+}
+
+// MultiSearchResult is the outcome of one search of a multi-search.
+type MultiSearchResult struct {
+	Result *SearchResult // Nil when Err is set
+	Err    error
+}
+
+// TemplateSearcher is implemented by Sources that can search with search templates stored
+// in the cluster, so the query can be changed there without redeploying.
+type TemplateSearcher interface {
+	// SearchTemplate searches index with the stored template id, rendered with params.
+	SearchTemplate(ctx context.Context, index, id string, params map[string]any) (*SearchResult, error)
+
+	// PutSearchTemplate stores source, a mustache template, as the search template id,
+	// replacing any template stored under it.
+	PutSearchTemplate(ctx context.Context, id, source string) error
+}
+
+// QueryStore saves queries in a percolator index, so deployments sharing a cluster match
+// articles against the same stored criteria instead of each building its own.
+type QueryStore interface {
+	// SaveQuery stores query under id in the percolator index. The index is created when
+	// missing, and the field mappings of mappingsFrom are copied to it so the query's
+	// fields can be percolated.
+	SaveQuery(ctx context.Context, index, id string, query any, mappingsFrom string) error
+}
+
+// DocumentIndexer writes documents to a search backend, such as the audit copies of
+// posted articles.
+type DocumentIndexer interface {
+	// IndexDocument stores doc (any JSON-encodable value) under id in index, replacing
+	// a document with the same id.
+	IndexDocument(ctx context.Context, index, id string, doc any) error
+}
+
+// DocumentUpdater is implemented by DocumentIndexers that can change part of a stored
+// document, such as the moderation decision on an audit copy.
+type DocumentUpdater interface {
+	// UpdateDocument merges fields (any JSON-encodable value) into the document stored
+	// under id in index. It fails when there is no such document.
+	UpdateDocument(ctx context.Context, index, id string, fields any) error
+}
+
+// PercolatorSlotField is the hit field listing which percolated documents a stored query matched.
+const PercolatorSlotField = "_percolator_document_slot"
+
+// SearchResult is the subset of a search response used by the pipeline.
+type SearchResult struct {
+	Total int         // Total matching documents (may exceed len(Hits))
+	Hits  []SearchHit // Returned documents
+}
+
+// SearchHit is a single search result document.
+type SearchHit struct {
+	ID     string                     // Document _id
+	Source json.RawMessage            // Document _source
+	Fields map[string]json.RawMessage // Document fields, such as PercolatorSlotField
+
+	Highlight map[string][]string // Highlighted fragments by field, when the query requested highlighting
+}
+
+// Classifier decides whether an article is relevant for posting.
+type Classifier interface {
+	Matches(article Article) bool
+}
+
+// Tracker records which articles have already been posted. Posting is two-phase:
+// Reserve claims an article before the post, then MarkPosted confirms it on success
+// or Release gives it up on failure.
+type Tracker interface {
+	// HasPosted reports whether the article is posted or reserved by an in-flight post.
+	HasPosted(ctx context.Context, articleID string) bool
+
+	// Reserve claims the article for posting. It returns false if the article is
+	// already posted or reserved elsewhere.
+	Reserve(ctx context.Context, articleID string) (bool, error)
+
+	// Release drops a reservation after a failed post.
+	Release(ctx context.Context, articleID string) error
+
+	// MarkPosted records the article as posted.
+	MarkPosted(ctx context.Context, articleID string) error
+
+	FlushAll(ctx context.Context) error
+}
+
+// Poster publishes articles to the destination CMS.
+type Poster interface {
+	PostArticle(ctx context.Context, req drupal.ArticleRequest) error
+}
+
+// NodeCreator is a Poster that also returns the UUID of the node it created.
+// *drupal.Client satisfies this interface.
+type NodeCreator interface {
+	CreateArticle(ctx context.Context, req drupal.ArticleRequest) (string, error)
+}
+
+// Limiter throttles posting. *rate.Limiter satisfies this interface.
+type Limiter interface {
+	Wait(ctx context.Context) error
+}
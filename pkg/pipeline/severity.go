@@ -0,0 +1,31 @@
+package pipeline
+
+import "strings"
+
+// SeverityScorer scores articles by the weights of the keywords their title or body
+// mentions (case-insensitive substring match, like KeywordClassifier). Each keyword
+// counts once, however often it appears.
+type SeverityScorer struct {
+	weights map[string]int
+}
+
+// NewSeverityScorer returns a scorer for the given keyword weights.
+func NewSeverityScorer(weights map[string]int) *SeverityScorer {
+	lowered := make(map[string]int, len(weights))
+	for keyword, weight := range weights {
+		lowered[strings.ToLower(keyword)] += weight
+	}
+	return &SeverityScorer{weights: lowered}
+}
+
+// Score returns the sum of the weights of the keywords the article mentions.
+func (s *SeverityScorer) Score(article Article) int {
+	content := strings.ToLower(article.Title + " " + article.Content)
+	score := 0
+	for keyword, weight := range s.weights {
+		if strings.Contains(content, keyword) {
+			score += weight
+		}
+	}
+	return score
+}
@@ -0,0 +1,30 @@
+package pipeline_test
+
+import (
+	"testing"
+
+	"github.com/gopost/integration/pkg/pipeline"
+)
+
+func TestSeverityScorer_Score(t *testing.T) {
+	scorer := pipeline.NewSeverityScorer(map[string]int{"Homicide": 10, "theft": 2, "police": 1})
+
+	tests := []struct {
+		name    string
+		article pipeline.Article
+		want    int
+	}{
+		{"single keyword", pipeline.Article{Title: "Theft at mall"}, 2},
+		{"title and body", pipeline.Article{Title: "Police investigate", Content: "A HOMICIDE downtown"}, 11},
+		{"repeated keyword counts once", pipeline.Article{Title: "Theft", Content: "another theft"}, 2},
+		{"no keyword", pipeline.Article{Title: "Farmers market opens"}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := scorer.Score(tt.article); got != tt.want {
+				t.Errorf("Score() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package pipeline
+
+import (
+	"hash/fnv"
+	"html"
+	"math/bits"
+	"regexp"
+	"strings"
+)
+
+var htmlTagPattern = regexp.MustCompile(`<[^>]*>`)
+
+// NormalizeText reduces article body HTML to lower-case words for fingerprinting:
+// tags and entities are removed, then punctuation and case as for titles.
+func NormalizeText(text string) string {
+	return foldText(html.UnescapeString(htmlTagPattern.ReplaceAllString(text, " ")))
+}
+
+// PlainText reduces article body HTML to its text: tags and entities are removed and
+// whitespace collapsed, keeping case and punctuation.
+func PlainText(text string) string {
+	return strings.Join(strings.Fields(html.UnescapeString(htmlTagPattern.ReplaceAllString(text, " "))), " ")
+}
+
+// simhashShingleSize is the number of consecutive words hashed together. Three-word
+// shingles keep word order significant without being thrown off by small edits.
+const simhashShingleSize = 3
+
+// SimHash returns a 64-bit locality-sensitive fingerprint of text: texts sharing most
+// of their word shingles get fingerprints differing in few bits. Text should already be
+// normalized (see NormalizeText); an empty text yields 0.
+func SimHash(text string) uint64 {
+	words := strings.Fields(text)
+	if len(words) == 0 {
+		return 0
+	}
+
+	var weights [64]int
+	add := func(shingle string) {
+		h := fnv.New64a()
+		_, _ = h.Write([]byte(shingle))
+		sum := h.Sum64()
+		for bit := range weights {
+			if sum&(1<<bit) != 0 {
+				weights[bit]++
+			} else {
+				weights[bit]--
+			}
+		}
+	}
+
+	if len(words) < simhashShingleSize {
+		add(strings.Join(words, " "))
+	}
+	for i := 0; i+simhashShingleSize <= len(words); i++ {
+		add(strings.Join(words[i:i+simhashShingleSize], " "))
+	}
+
+	var fingerprint uint64
+	for bit, weight := range weights {
+		if weight > 0 {
+			fingerprint |= 1 << bit
+		}
+	}
+	return fingerprint
+}
+
+// Similarity returns the fraction of matching bits between two fingerprints, from 0 to 1.
+func Similarity(a, b uint64) float64 {
+	return 1 - float64(bits.OnesCount64(a^b))/64
+}
@@ -0,0 +1,38 @@
+package pipeline_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gopost/integration/pkg/pipeline"
+)
+
+const wireCopy = `<p>Greater Sudbury Police arrested a 34-year-old man on Tuesday after a robbery at a
+convenience store on Lasalle Boulevard. Officers responded to the call shortly after 9 p.m. and
+found the clerk uninjured. The suspect was located a short distance away and faces charges of
+robbery, possession of a weapon, and failing to comply with a release order. He was held for a
+bail hearing on Wednesday. Anyone with information is asked to contact police or Crime Stoppers.</p>`
+
+func TestSimHash_Similarity(t *testing.T) {
+	original := pipeline.SimHash(pipeline.NormalizeText(wireCopy))
+
+	// Another outlet's copy with a different dateline and sign-off
+	clone := strings.Replace(wireCopy, "<p>", "<p>SUDBURY &mdash; ", 1) + "<p>With files from The Canadian Press.</p>"
+	if got := pipeline.Similarity(original, pipeline.SimHash(pipeline.NormalizeText(clone))); got < 0.9 {
+		t.Errorf("Similarity(original, clone) = %.2f, want >= 0.9", got)
+	}
+
+	unrelated := `<p>The city's farmers market opens for the season on Saturday with more than forty
+vendors selling fresh produce, baked goods, and crafts. Organizers expect record crowds and have
+added extra parking near the arena. Live music runs all afternoon and admission is free.</p>`
+	if got := pipeline.Similarity(original, pipeline.SimHash(pipeline.NormalizeText(unrelated))); got >= 0.9 {
+		t.Errorf("Similarity(original, unrelated) = %.2f, want < 0.9", got)
+	}
+}
+
+func TestNormalizeText_StripsHTML(t *testing.T) {
+	got := pipeline.NormalizeText("<p>Police&nbsp;<b>ARREST</b> suspect.</p>")
+	if got != "police arrest suspect" {
+		t.Errorf("NormalizeText() = %q, want %q", got, "police arrest suspect")
+	}
+}
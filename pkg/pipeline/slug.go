@@ -0,0 +1,51 @@
+package pipeline
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// slugLetters transliterates letters that do not decompose into an ASCII letter and
+// combining marks.
+var slugLetters = map[rune]string{
+	'ß': "ss", 'æ': "ae", 'œ': "oe", 'ø': "o", 'đ': "d", 'ð': "d", 'ł': "l", 'þ': "th", 'ı': "i",
+}
+
+// Slugify turns text into a URL path segment: lowercase ASCII letters and digits separated
+// by single hyphens. Accented Latin letters are transliterated ("Café Déjà-vu" becomes
+// "cafe-deja-vu"); other characters, including letters of other scripts, separate words.
+// With maxLen positive, the slug is cut at the last hyphen that keeps it within maxLen
+// bytes, or at maxLen when its first word is longer.
+func Slugify(text string, maxLen int) string {
+	var b strings.Builder
+	b.Grow(len(text))
+	hyphen := false
+	for _, r := range norm.NFKD.String(strings.ToLower(text)) {
+		if unicode.Is(unicode.Mn, r) {
+			continue
+		}
+		word := string(r)
+		if letters, ok := slugLetters[r]; ok {
+			word = letters
+		} else if r > unicode.MaxASCII || !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			hyphen = true
+			continue
+		}
+		if hyphen && b.Len() > 0 {
+			b.WriteByte('-')
+		}
+		hyphen = false
+		b.WriteString(word)
+	}
+
+	slug := b.String()
+	if maxLen <= 0 || len(slug) <= maxLen {
+		return slug
+	}
+	if cut := strings.LastIndexByte(slug[:maxLen+1], '-'); cut > 0 {
+		return slug[:cut]
+	}
+	return slug[:maxLen]
+}
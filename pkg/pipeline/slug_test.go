@@ -0,0 +1,31 @@
+package pipeline_test
+
+import (
+	"testing"
+
+	"github.com/gopost/integration/pkg/pipeline"
+)
+
+func TestSlugify(t *testing.T) {
+	tests := []struct {
+		text   string
+		maxLen int
+		want   string
+	}{
+		{"Police investigate robbery", 0, "police-investigate-robbery"},
+		{"  Café Déjà-vu: suspect’s car found!  ", 0, "cafe-deja-vu-suspect-s-car-found"},
+		{"Straße in Łódź, Ærø", 0, "strasse-in-lodz-aero"},
+		{"Man, 34, charged after 2 break-ins", 0, "man-34-charged-after-2-break-ins"},
+		{"Москва news", 0, "news"},
+		{"Police investigate robbery", 20, "police-investigate"},
+		{"Police investigate robbery", 18, "police-investigate"},
+		{"Supercalifragilistic", 5, "super"},
+		{"", 0, ""},
+	}
+
+	for _, tt := range tests {
+		if got := pipeline.Slugify(tt.text, tt.maxLen); got != tt.want {
+			t.Errorf("Slugify(%q, %d) = %q, want %q", tt.text, tt.maxLen, got, tt.want)
+		}
+	}
+}
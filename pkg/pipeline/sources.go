@@ -0,0 +1,70 @@
+package pipeline
+
+import (
+	"net/url"
+	"strings"
+)
+
+// SourceFilter excludes articles by outlet. Entries match the article's source field
+// (case-insensitive) or the domain of its canonical URL, including subdomains, so
+// "example.com" matches "www.example.com" and "news.example.com".
+type SourceFilter struct {
+	allowed []string
+	blocked []string
+}
+
+// NewSourceFilter returns a filter. An empty allowed list allows every source that is not blocked;
+// blocked entries always win.
+func NewSourceFilter(allowed, blocked []string) *SourceFilter {
+	return &SourceFilter{
+		allowed: normalizeSourceEntries(allowed),
+		blocked: normalizeSourceEntries(blocked),
+	}
+}
+
+// Allows reports whether the article's outlet passes the filter.
+func (f *SourceFilter) Allows(article Article) bool {
+	source := strings.ToLower(strings.TrimSpace(article.Source))
+	domain := urlDomain(article.URL)
+
+	if matchesAnySource(f.blocked, source, domain) {
+		return false
+	}
+	return len(f.allowed) == 0 || matchesAnySource(f.allowed, source, domain)
+}
+
+func normalizeSourceEntries(entries []string) []string {
+	normalized := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		// Accept full URLs in config as well as bare domains
+		if domain := urlDomain(entry); domain != "" && strings.Contains(entry, "://") {
+			entry = domain
+		}
+		if entry != "" {
+			normalized = append(normalized, strings.TrimPrefix(entry, "www."))
+		}
+	}
+	return normalized
+}
+
+func matchesAnySource(entries []string, source, domain string) bool {
+	for _, entry := range entries {
+		if entry == source || strings.TrimPrefix(source, "www.") == entry {
+			return true
+		}
+		if domain != "" && (domain == entry || strings.HasSuffix(domain, "."+entry)) {
+			return true
+		}
+	}
+	return false
+}
+
+// urlDomain returns the lower-cased host of rawURL without "www.", or "" if it has none.
+func urlDomain(rawURL string) string {
+	parsed, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimPrefix(strings.ToLower(parsed.Hostname()), "www.")
+}
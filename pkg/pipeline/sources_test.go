@@ -0,0 +1,33 @@
+package pipeline_test
+
+import (
+	"testing"
+
+	"github.com/gopost/integration/pkg/pipeline"
+)
+
+func TestSourceFilter_Allows(t *testing.T) {
+	tests := []struct {
+		name    string
+		allowed []string
+		blocked []string
+		article pipeline.Article
+		want    bool
+	}{
+		{"no lists", nil, nil, pipeline.Article{Source: "sudbury.com"}, true},
+		{"blocked source", nil, []string{"Paywall Times"}, pipeline.Article{Source: "paywall times"}, false},
+		{"blocked URL subdomain", nil, []string{"paywall.example"}, pipeline.Article{URL: "https://news.paywall.example/story"}, false},
+		{"blocked by full URL entry", nil, []string{"https://www.paywall.example/"}, pipeline.Article{URL: "https://paywall.example/a"}, false},
+		{"allowed domain", []string{"sudbury.com"}, nil, pipeline.Article{URL: "https://www.sudbury.com/local-news/a"}, true},
+		{"not in allow list", []string{"sudbury.com"}, nil, pipeline.Article{Source: "ctvnews.ca", URL: "https://ctvnews.ca/a"}, false},
+		{"block wins over allow", []string{"sudbury.com"}, []string{"sudbury.com"}, pipeline.Article{Source: "sudbury.com"}, false},
+		{"suffix is not a subdomain", nil, []string{"bc.ca"}, pipeline.Article{URL: "https://abc.ca/a"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := pipeline.NewSourceFilter(tt.allowed, tt.blocked).Allows(tt.article); got != tt.want {
+				t.Errorf("Allows() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
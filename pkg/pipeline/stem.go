@@ -0,0 +1,85 @@
+package pipeline
+
+import "strings"
+
+// Stem reduces an English word to a stem shared by its common inflections, so
+// "robbery" and "robberies" both become "robbery", and "assault", "assaulted", and
+// "assaulting" all become "assault". It strips plural, past tense, and gerund endings
+// only; derived forms ("robber", "investigate") keep their own stems. The word is
+// lowercased first.
+func Stem(word string) string {
+	word = strings.ToLower(word)
+	if len(word) <= 3 {
+		return word
+	}
+	// Plurals and third person
+	switch {
+	case strings.HasSuffix(word, "ies") && len(word) > 4:
+		word = word[:len(word)-3] + "y"
+	case strings.HasSuffix(word, "sses"):
+		word = word[:len(word)-2]
+	case strings.HasSuffix(word, "s") && !strings.HasSuffix(word, "ss") &&
+		!strings.HasSuffix(word, "us") && !strings.HasSuffix(word, "is"):
+		word = word[:len(word)-1]
+	}
+	// Past tense and gerunds, including plural gerunds ("stabbings")
+	switch {
+	case strings.HasSuffix(word, "ied") && len(word) > 4:
+		word = word[:len(word)-3] + "y"
+	case strings.HasSuffix(word, "ing") && len(word) > 5 && hasVowel(word[:len(word)-3]):
+		word = undouble(word[:len(word)-3])
+	case strings.HasSuffix(word, "ed") && len(word) > 4 && hasVowel(word[:len(word)-2]):
+		word = undouble(word[:len(word)-2])
+	}
+	// "charge", "charged", and "charges" share "charg"
+	if len(word) > 3 && strings.HasSuffix(word, "e") {
+		word = word[:len(word)-1]
+	}
+	return word
+}
+
+// undouble drops the last letter of a stem ending in a doubled consonant, as left by
+// "robbed" or "stabbing", except for l, s, and z ("called", "passed", "buzzed").
+func undouble(stem string) string {
+	n := len(stem)
+	if n < 2 || stem[n-1] != stem[n-2] || strings.ContainsRune("aeiouylsz", rune(stem[n-1])) {
+		return stem
+	}
+	return stem[:n-1]
+}
+
+func hasVowel(s string) bool {
+	return strings.ContainsAny(s, "aeiouy")
+}
+
+// stems splits text into words (runs of letters and digits) and stems each.
+func stems(text string) []string {
+	words := strings.FieldsFunc(text, func(r rune) bool { return !isWordRune(r) })
+	for i, word := range words {
+		words[i] = Stem(word)
+	}
+	return words
+}
+
+// containsStems reports whether the stemmed words of a keyword occur consecutively in the
+// stemmed words of an article.
+func containsStems(words, keyword []string) bool {
+	if len(keyword) == 0 {
+		return false
+	}
+	for i := 0; i+len(keyword) <= len(words); i++ {
+		if equalWords(words[i:i+len(keyword)], keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+func equalWords(a, b []string) bool {
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,107 @@
+package pipeline
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// DefaultTitlePrefixes are the wire-service markers stripped by NewTitleNormalizer
+// when no prefixes are configured.
+var DefaultTitlePrefixes = []string{
+	"UPDATE", "UPDATED", "BREAKING", "BREAKING NEWS", "DEVELOPING", "EXCLUSIVE", "WATCH", "VIDEO",
+}
+
+// titlePrefixSeparators may follow a prefix ("UPDATE: ...", "BREAKING - ...", "VIDEO | ...").
+const titlePrefixSeparators = ":-–—|"
+
+// TitleNormalizer reduces republished headlines to a common form so that
+// "BREAKING: Man charged in robbery" and "Man charged in robbery." compare equal.
+type TitleNormalizer struct {
+	prefixes []string // Upper-cased, longest first so "BREAKING NEWS" wins over "BREAKING"
+}
+
+// NewTitleNormalizer returns a normalizer stripping prefixes (DefaultTitlePrefixes if empty).
+func NewTitleNormalizer(prefixes []string) *TitleNormalizer {
+	if len(prefixes) == 0 {
+		prefixes = DefaultTitlePrefixes
+	}
+
+	upper := make([]string, 0, len(prefixes))
+	for _, prefix := range prefixes {
+		if prefix = strings.ToUpper(strings.TrimSpace(prefix)); prefix != "" {
+			upper = append(upper, prefix)
+		}
+	}
+	slices.SortStableFunc(upper, func(a, b string) int {
+		return len(b) - len(a)
+	})
+	return &TitleNormalizer{prefixes: upper}
+}
+
+// StripPrefixes removes leading markers such as "UPDATE:" or "BREAKING -", repeatedly,
+// leaving the rest of the title untouched. Prefixes only match when followed by a separator.
+func (n *TitleNormalizer) StripPrefixes(title string) string {
+	title = strings.TrimSpace(title)
+	for {
+		stripped := n.stripOne(title)
+		if stripped == title {
+			return title
+		}
+		title = stripped
+	}
+}
+
+func (n *TitleNormalizer) stripOne(title string) string {
+	for _, prefix := range n.prefixes {
+		if len(title) < len(prefix) || !strings.EqualFold(title[:len(prefix)], prefix) {
+			continue
+		}
+		rest := strings.TrimLeft(title[len(prefix):], " ")
+		if rest == "" || !strings.ContainsRune(titlePrefixSeparators, []rune(rest)[0]) {
+			continue
+		}
+		return strings.TrimSpace(strings.TrimLeft(rest, titlePrefixSeparators+" "))
+	}
+	return title
+}
+
+// Normalize strips prefixes, case-folds, drops punctuation, and collapses whitespace.
+func (n *TitleNormalizer) Normalize(title string) string {
+	return foldText(n.StripPrefixes(title))
+}
+
+// foldText lower-cases text, drops punctuation and symbols, and collapses whitespace.
+func foldText(text string) string {
+	text = strings.ToLower(text)
+
+	var b strings.Builder
+	b.Grow(len(text))
+	space := false
+	for _, r := range text {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if space && b.Len() > 0 {
+				b.WriteByte(' ')
+			}
+			space = false
+			b.WriteRune(r)
+		case unicode.IsSpace(r) || unicode.IsPunct(r) || unicode.IsSymbol(r):
+			space = true
+		}
+	}
+	return b.String()
+}
+
+// Key returns a stable hash of the normalized title, for use as a dedup key.
+// It returns "" for titles that normalize to nothing.
+func (n *TitleNormalizer) Key(title string) string {
+	normalized := n.Normalize(title)
+	if normalized == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:16])
+}
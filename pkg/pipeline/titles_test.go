@@ -0,0 +1,51 @@
+package pipeline_test
+
+import (
+	"testing"
+
+	"github.com/gopost/integration/pkg/pipeline"
+)
+
+func TestTitleNormalizer(t *testing.T) {
+	normalizer := pipeline.NewTitleNormalizer(nil)
+
+	tests := []struct {
+		title      string
+		stripped   string
+		normalized string
+	}{
+		{"UPDATE: Man charged in robbery", "Man charged in robbery", "man charged in robbery"},
+		{"Breaking News - Man charged in robbery.", "Man charged in robbery.", "man charged in robbery"},
+		{"UPDATED | BREAKING: Man charged in robbery!", "Man charged in robbery!", "man charged in robbery"},
+		{"Update on downtown construction", "Update on downtown construction", "update on downtown construction"},
+		{"  Police: suspect   arrested  ", "Police: suspect   arrested", "police suspect arrested"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.title, func(t *testing.T) {
+			if got := normalizer.StripPrefixes(tt.title); got != tt.stripped {
+				t.Errorf("StripPrefixes() = %q, want %q", got, tt.stripped)
+			}
+			if got := normalizer.Normalize(tt.title); got != tt.normalized {
+				t.Errorf("Normalize() = %q, want %q", got, tt.normalized)
+			}
+		})
+	}
+
+	if a, b := normalizer.Key("UPDATE: Man charged in robbery"), normalizer.Key("Man charged in robbery."); a != b {
+		t.Errorf("Key() differs for republished title: %q vs %q", a, b)
+	}
+	if key := normalizer.Key("!!!"); key != "" {
+		t.Errorf("Key(punctuation only) = %q, want empty", key)
+	}
+}
+
+func TestTitleNormalizer_CustomPrefixes(t *testing.T) {
+	normalizer := pipeline.NewTitleNormalizer([]string{"mise à jour"})
+
+	if got := normalizer.StripPrefixes("MISE À JOUR : Arrestation à Sudbury"); got != "Arrestation à Sudbury" {
+		t.Errorf("StripPrefixes() = %q, want %q", got, "Arrestation à Sudbury")
+	}
+	if got := normalizer.StripPrefixes("UPDATE: Arrest"); got != "UPDATE: Arrest" {
+		t.Errorf("StripPrefixes() stripped an unconfigured prefix: %q", got)
+	}
+}
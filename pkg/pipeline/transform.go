@@ -0,0 +1,31 @@
+package pipeline
+
+import (
+	"regexp"
+	"strings"
+)
+
+// FieldTransformer computes a Drupal field value from an article. Field map entries
+// reference transformers by name with a "fn:" source, e.g. "fn:first_paragraph". A
+// string result is encoded as the entry's field type; any other value is sent as is.
+// A nil or empty result leaves the field out.
+type FieldTransformer func(article Article) (any, error)
+
+var paragraphPattern = regexp.MustCompile(`(?is)<p[\s>].*?</p>`)
+
+// FirstParagraph is a FieldTransformer returning the text of the first non-empty <p>
+// element of the article body, or of its first blank-line-separated paragraph when the
+// body has no <p> elements.
+func FirstParagraph(article Article) (any, error) {
+	for _, paragraph := range paragraphPattern.FindAllString(article.Content, -1) {
+		if text := PlainText(paragraph); text != "" {
+			return text, nil
+		}
+	}
+	for paragraph := range strings.SplitSeq(strings.ReplaceAll(article.Content, "\r\n", "\n"), "\n\n") {
+		if text := PlainText(paragraph); text != "" {
+			return text, nil
+		}
+	}
+	return "", nil
+}
@@ -0,0 +1,27 @@
+package pipeline_test
+
+import (
+	"testing"
+
+	"github.com/gopost/integration/pkg/pipeline"
+)
+
+func TestFirstParagraph(t *testing.T) {
+	tests := []struct {
+		body string
+		want string
+	}{
+		{"<p class=\"lead\"> </p><P>Police charged a <b>man</b> on Elm Street.</P><p>Court Monday.</p>", "Police charged a man on Elm Street."},
+		{"<div><p>\n  Officers &amp; paramedics responded.\n</p></div>", "Officers & paramedics responded."},
+		{"\n\nPolice charged a man.\nHe is 34.\r\n\r\nCourt Monday.", "Police charged a man. He is 34."},
+		{"<pre>raw</pre>", "raw"},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		got, err := pipeline.FirstParagraph(pipeline.Article{Content: tt.body})
+		if err != nil || got != tt.want {
+			t.Errorf("FirstParagraph(%q) = %q, %v, want %q", tt.body, got, err, tt.want)
+		}
+	}
+}
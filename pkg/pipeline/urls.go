@@ -0,0 +1,132 @@
+package pipeline
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// ErrInvalidURL is returned by URLNormalizer.Normalize for URLs that cannot be made
+// absolute http(s) URLs.
+var ErrInvalidURL = errors.New("invalid article URL")
+
+// DefaultTrackingParams are the query parameters removed by NewURLNormalizer when none
+// are configured. A trailing "*" matches any parameter with that prefix.
+var DefaultTrackingParams = []string{
+	"utm_*", "fbclid", "gclid", "dclid", "msclkid", "mc_cid", "mc_eid", "_ga", "ocid",
+}
+
+// URLNormalizer cleans canonical URLs found in crawled articles: tracking parameters and
+// fragments are removed, relative URLs are resolved against the article's source, and
+// http may be upgraded to https.
+type URLNormalizer struct {
+	stripParams []string // Lower-cased; entries ending in "*" are prefixes
+	forceHTTPS  bool
+}
+
+// NewURLNormalizer returns a normalizer removing stripParams (DefaultTrackingParams if
+// empty). With forceHTTPS, http URLs are rewritten to https.
+func NewURLNormalizer(stripParams []string, forceHTTPS bool) *URLNormalizer {
+	if len(stripParams) == 0 {
+		stripParams = DefaultTrackingParams
+	}
+
+	lower := make([]string, 0, len(stripParams))
+	for _, param := range stripParams {
+		if param = strings.ToLower(strings.TrimSpace(param)); param != "" {
+			lower = append(lower, param)
+		}
+	}
+	return &URLNormalizer{
+		stripParams: lower,
+		forceHTTPS:  forceHTTPS,
+	}
+}
+
+// Normalize returns the normalized form of rawURL. Relative and scheme-less URLs are
+// resolved against source, which may be a URL or a bare domain such as "sudbury.com".
+// An empty rawURL yields "" without error; anything that does not end up as an absolute
+// http(s) URL with a host yields an error wrapping ErrInvalidURL.
+func (n *URLNormalizer) Normalize(rawURL, source string) (string, error) {
+	rawURL = strings.TrimSpace(rawURL)
+	if rawURL == "" {
+		return "", nil
+	}
+
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return "", fmt.Errorf("%w %q: %w", ErrInvalidURL, rawURL, err)
+	}
+	if !parsed.IsAbs() {
+		base := sourceBaseURL(source)
+		if base == nil {
+			return "", fmt.Errorf("%w %q: relative URL without a source domain", ErrInvalidURL, rawURL)
+		}
+		parsed = base.ResolveReference(parsed)
+	}
+
+	parsed.Scheme = strings.ToLower(parsed.Scheme)
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return "", fmt.Errorf("%w %q: unsupported scheme %q", ErrInvalidURL, rawURL, parsed.Scheme)
+	}
+	if parsed.Hostname() == "" {
+		return "", fmt.Errorf("%w %q: missing host", ErrInvalidURL, rawURL)
+	}
+	if n.forceHTTPS && parsed.Scheme == "http" {
+		parsed.Scheme = "https"
+		if parsed.Port() == "80" {
+			parsed.Host = parsed.Hostname()
+		}
+	}
+
+	parsed.Host = strings.ToLower(parsed.Host)
+	parsed.Fragment = ""
+	parsed.RawFragment = ""
+	parsed.RawQuery = n.stripQuery(parsed.Query())
+	return parsed.String(), nil
+}
+
+func (n *URLNormalizer) stripQuery(query url.Values) string {
+	for param := range query {
+		if n.isTrackingParam(strings.ToLower(param)) {
+			query.Del(param)
+		}
+	}
+	// Encode sorts parameters, so the same page always normalizes to the same URL
+	return query.Encode()
+}
+
+func (n *URLNormalizer) isTrackingParam(param string) bool {
+	for _, entry := range n.stripParams {
+		if prefix, ok := strings.CutSuffix(entry, "*"); ok {
+			if strings.HasPrefix(param, prefix) {
+				return true
+			}
+		} else if param == entry {
+			return true
+		}
+	}
+	return false
+}
+
+// sourceBaseURL returns the https root of an article source given as a URL or bare
+// domain, or nil if source does not look like either.
+func sourceBaseURL(source string) *url.URL {
+	source = strings.TrimSpace(source)
+	if source == "" || strings.ContainsAny(source, " \t") {
+		return nil
+	}
+	if !strings.Contains(source, "://") {
+		if !strings.Contains(source, ".") {
+			return nil
+		}
+		source = "https://" + source
+	}
+
+	base, err := url.Parse(source)
+	if err != nil || base.Hostname() == "" {
+		return nil
+	}
+	return base
+}
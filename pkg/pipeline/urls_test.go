@@ -0,0 +1,60 @@
+package pipeline_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/gopost/integration/pkg/pipeline"
+)
+
+func TestURLNormalizer_Normalize(t *testing.T) {
+	tests := []struct {
+		name       string
+		forceHTTPS bool
+		rawURL     string
+		source     string
+		want       string
+		wantErr    bool
+	}{
+		{name: "empty", rawURL: "", want: ""},
+		{name: "unchanged", rawURL: "https://www.sudbury.com/local-news/a", want: "https://www.sudbury.com/local-news/a"},
+		{name: "tracking params", rawURL: "https://sudbury.com/a?utm_source=fb&id=7&UTM_Medium=social&fbclid=x", want: "https://sudbury.com/a?id=7"},
+		{name: "fragment and host case", rawURL: "https://Sudbury.COM/a#comments", want: "https://sudbury.com/a"},
+		{name: "relative to domain source", rawURL: "/local-news/a", source: "sudbury.com", want: "https://sudbury.com/local-news/a"},
+		{name: "relative to URL source", rawURL: "b", source: "http://sudbury.com/news/a", want: "http://sudbury.com/news/b"},
+		{name: "scheme-relative", rawURL: "//ctvnews.ca/a", source: "ctvnews.ca", want: "https://ctvnews.ca/a"},
+		{name: "force https", forceHTTPS: true, rawURL: "http://sudbury.com:80/a", want: "https://sudbury.com/a"},
+		{name: "http kept", rawURL: "http://sudbury.com/a", want: "http://sudbury.com/a"},
+		{name: "relative without source", rawURL: "/local-news/a", source: "Sudbury News", wantErr: true},
+		{name: "unsupported scheme", rawURL: "javascript:alert(1)", wantErr: true},
+		{name: "unparseable", rawURL: "https://exa mple.com/%zz", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := pipeline.NewURLNormalizer(nil, tt.forceHTTPS).Normalize(tt.rawURL, tt.source)
+			if tt.wantErr {
+				if !errors.Is(err, pipeline.ErrInvalidURL) {
+					t.Errorf("Normalize() error = %v, want ErrInvalidURL", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Normalize() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Normalize() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestURLNormalizer_CustomParams(t *testing.T) {
+	normalizer := pipeline.NewURLNormalizer([]string{"ref", "src_*"}, false)
+	got, err := normalizer.Normalize("https://sudbury.com/a?ref=home&src_x=1&utm_source=fb", "")
+	if err != nil {
+		t.Fatalf("Normalize() error = %v", err)
+	}
+	if want := "https://sudbury.com/a?utm_source=fb"; got != want {
+		t.Errorf("Normalize() = %q, want %q", got, want)
+	}
+}
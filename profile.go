@@ -0,0 +1,72 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/pprof"
+)
+
+// runProfiles are the pprof profiles of a one-shot run: a CPU profile recorded while the run
+// is in progress and a heap profile taken when it ends. Either path may be empty.
+type runProfiles struct {
+	cpu     *os.File
+	memPath string
+}
+
+// startProfiles starts CPU profiling to cpuPath, if set, and remembers memPath for stop.
+func startProfiles(cpuPath, memPath string) (*runProfiles, error) {
+	profiles := &runProfiles{memPath: memPath}
+	if cpuPath == "" {
+		return profiles, nil
+	}
+	f, err := os.Create(cpuPath)
+	if err != nil {
+		return nil, fmt.Errorf("create CPU profile: %w", err)
+	}
+	if err := pprof.StartCPUProfile(f); err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("start CPU profile: %w", err)
+	}
+	profiles.cpu = f
+	return profiles, nil
+}
+
+// stop finishes the CPU profile and writes the heap profile. It is called before the run's
+// exit code is decided, since os.Exit skips deferred calls.
+func (p *runProfiles) stop() error {
+	var errs []error
+	if p.cpu != nil {
+		pprof.StopCPUProfile()
+		if err := p.cpu.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("write CPU profile: %w", err))
+		}
+		p.cpu = nil
+	}
+	if p.memPath != "" {
+		if err := writeHeapProfile(p.memPath); err != nil {
+			errs = append(errs, err)
+		}
+		p.memPath = ""
+	}
+	return errors.Join(errs...)
+}
+
+// writeHeapProfile writes a heap profile to path after a garbage collection, so it shows
+// live memory as of the end of the run.
+func writeHeapProfile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("create memory profile: %w", err)
+	}
+	runtime.GC()
+	if err := pprof.WriteHeapProfile(f); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("write memory profile: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("write memory profile: %w", err)
+	}
+	return nil
+}
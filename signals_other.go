@@ -0,0 +1,13 @@
+//go:build !unix
+
+package main
+
+import (
+	"context"
+
+	"github.com/gopost/integration/internal/logger"
+)
+
+// handleOperatorSignals does nothing where SIGUSR1 and SIGUSR2 do not exist; use the
+// admin /sync and /status endpoints instead.
+func handleOperatorSignals(context.Context, pipelines, logger.Logger) {}
@@ -0,0 +1,57 @@
+//go:build unix
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/signal"
+	"runtime"
+	"runtime/pprof"
+	"syscall"
+
+	"github.com/gopost/integration/internal/logger"
+)
+
+// handleOperatorSignals serves the classic daemon signals until ctx is done, for operators
+// without access to the admin port: SIGUSR1 starts a sync run ahead of schedule, and
+// SIGUSR2 logs the service status and every goroutine's stack.
+func handleOperatorSignals(ctx context.Context, service pipelines, appLogger logger.Logger) {
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGUSR1, syscall.SIGUSR2)
+
+	go func() {
+		defer signal.Stop(sigChan)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case sig := <-sigChan:
+				if sig == syscall.SIGUSR1 {
+					appLogger.Info("Sync requested by signal",
+						logger.String("signal", sig.String()),
+						logger.Bool("queued", service.TriggerSync("")),
+					)
+					continue
+				}
+				dumpStatus(service, appLogger)
+			}
+		}
+	}()
+}
+
+// dumpStatus logs the service status and the goroutine stacks, grouped by identical stack.
+func dumpStatus(service pipelines, appLogger logger.Logger) {
+	var stacks bytes.Buffer
+	if err := pprof.Lookup("goroutine").WriteTo(&stacks, 1); err != nil {
+		appLogger.Warn("Failed to dump goroutine stacks",
+			logger.Error(err),
+		)
+	}
+	appLogger.Info("Status dump",
+		logger.Any("status", service.Status()),
+		logger.Int("goroutines", runtime.NumGoroutine()),
+		logger.String("goroutine_stacks", stacks.String()),
+	)
+}